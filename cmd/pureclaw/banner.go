@@ -0,0 +1,91 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/edouard/pureclaw/internal/config"
+	"github.com/edouard/pureclaw/internal/llm"
+	"github.com/edouard/pureclaw/internal/memory"
+	"github.com/edouard/pureclaw/internal/platform"
+)
+
+// startupSummary returns the effective configuration as alternating key/value
+// pairs, defaults resolved, for the startup banner and memory entry. Secrets are
+// never listed: the vault contributes key names only, and config strings go
+// through redactor, the one tool output and memory use.
+func startupSummary(cfg *config.Config, vaultKeys []string, redactor *platform.Redactor) []any {
+	redact := redactor.Redact
+	timeouts := cfg.ResolvedTimeouts()
+	return []any{
+		"version", Version,
+		"workspace", redact(cfg.Workspace),
+		"model_text", redact(cfg.ModelText),
+		"model_audio", redact(cfg.ModelAudio),
+		"owners", len(cfg.TelegramAllowedIDs),
+		"telegram_mode", cmp.Or(cfg.TelegramMode, config.TelegramModePoll),
+		"heartbeat_interval", cfg.HeartbeatInterval.String(),
+		"llm_provider", cmp.Or(cfg.LLMProvider, llm.ProviderMistral),
+		"llm_base_url", cmp.Or(redact(cfg.LLMBaseURL), "default"),
+		"response_format", cmp.Or(cfg.ResponseFormat, llm.DefaultResponseFormat(cfg.LLMProvider)),
+		"memory_verbosity", cmp.Or(cfg.MemoryVerbosity, "all"),
+		"llm_timeout", timeouts.LLM.String(),
+		"message_timeout", timeouts.Message.String(),
+		"llm_max_concurrent", cfg.LLMMaxConcurrent,
+		"features", strings.Join(enabledFeatures(cfg), ","),
+		"vault_keys", strings.Join(slices.Sorted(slices.Values(vaultKeys)), ","),
+	}
+}
+
+// enabledFeatures names the optional behaviours switched on in cfg.
+func enabledFeatures(cfg *config.Config) []string {
+	var features []string
+	for _, f := range []struct {
+		name string
+		on   bool
+	}{
+		{"format_code_blocks", cfg.FormatCodeBlocks},
+		{"reply_attachments", cfg.ReplyAttachments},
+		{"status_message", cfg.StatusMessage != ""},
+		{"status_progress", cfg.StatusProgress && cfg.StatusMessage != ""},
+		{"sub_agent_document", cfg.SubAgentDocument},
+		{"memory_dedup", cfg.MemoryDedup},
+		{"memory_per_chat", cfg.MemoryPerChat},
+		{"memory_jsonl", cfg.MemoryJSONL},
+		{"skip_blocked_chats", cfg.SkipBlockedChats},
+		{"notify_default_soul", cfg.NotifyDefaultSoul},
+		{"notify_shutdown", cfg.NotifyShutdown},
+		{"tool_confirmations", len(cfg.ToolConfirmations) > 0},
+		{"chat_prompt_suffix", len(cfg.ChatPromptSuffix) > 0},
+		{"min_free_disk", cfg.MinFreeDiskMB > 0},
+	} {
+		if f.on {
+			features = append(features, f.name)
+		}
+	}
+	return features
+}
+
+// logStartupBanner logs the effective configuration and records it in memory
+// under the "startup" source, so support can confirm what a run actually used.
+// The memory entry is redacted again as a whole, like every other entry.
+func logStartupBanner(ctx context.Context, mem *memory.Memory, redactor *platform.Redactor, summary []any) {
+	slog.Info("effective configuration", append([]any{"component", "cmd", "operation", "run"}, summary...)...)
+
+	var b strings.Builder
+	b.WriteString("PureClaw started with:")
+	for i := 0; i+1 < len(summary); i += 2 {
+		fmt.Fprintf(&b, "\n- %v: %v", summary[i], summary[i+1])
+	}
+	if err := mem.Write(ctx, "startup", redactor.Redact(b.String())); err != nil {
+		slog.Warn("failed to write startup memory entry",
+			"component", "cmd",
+			"operation", "run",
+			"error", err,
+		)
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/edouard/pureclaw/internal/config"
+	"github.com/edouard/pureclaw/internal/memory"
+	"github.com/edouard/pureclaw/internal/platform"
+)
+
+func TestStartupBanner_RedactsSecrets(t *testing.T) {
+	var logs bytes.Buffer
+	orig := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+	t.Cleanup(func() { slog.SetDefault(orig) })
+
+	cfg := &config.Config{
+		Workspace:          "/srv/pureclaw-sk-secret-123",
+		ModelText:          "mistral-large-latest",
+		TelegramAllowedIDs: []int64{1, 2},
+		FormatCodeBlocks:   true,
+		NotifyShutdown:     true,
+	}
+	mem := memory.New(t.TempDir())
+	r := platform.SecretRedactor([]string{"sk-secret-123", "123456:tg-token"})
+	logStartupBanner(context.Background(), mem, r, startupSummary(cfg,
+		[]string{"telegram_bot_token", "mistral_api_key"}, r))
+
+	out := logs.String()
+	if strings.Contains(out, "sk-secret-123") || strings.Contains(out, "tg-token") {
+		t.Errorf("banner leaks a secret:\n%s", out)
+	}
+	for _, want := range []string{
+		"effective configuration",
+		"workspace=/srv/pureclaw-[REDACTED]",
+		"model_text=mistral-large-latest",
+		"owners=2",
+		"features=format_code_blocks,notify_shutdown",
+		"vault_keys=mistral_api_key,telegram_bot_token",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("banner missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestStartupBanner_WritesMemoryEntry(t *testing.T) {
+	cfg := &config.Config{
+		Workspace:          "/srv/ws",
+		ModelText:          "mistral-small",
+		TelegramAllowedIDs: []int64{42},
+		ReplyAttachments:   true,
+	}
+	mem := memory.New(t.TempDir())
+	r := platform.SecretRedactor([]string{"sk-value"})
+	logStartupBanner(context.Background(), mem, r, startupSummary(cfg, []string{"mistral_api_key"}, r))
+
+	entries, err := mem.ReadRange(context.Background(), time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("entries = %+v, err = %v, want one startup entry", entries, err)
+	}
+	e := entries[0]
+	if e.Source != "startup" {
+		t.Errorf("source = %q, want startup", e.Source)
+	}
+	for _, want := range []string{"- model_text: mistral-small", "- workspace: /srv/ws", "- owners: 1", "- features: reply_attachments", "- response_format: json_schema"} {
+		if !strings.Contains(e.Content, want) {
+			t.Errorf("entry missing %q:\n%s", want, e.Content)
+		}
+	}
+	if strings.Contains(e.Content, "sk-value") {
+		t.Errorf("entry leaks a secret:\n%s", e.Content)
+	}
+}
+
+func TestStartupSummary_Provider(t *testing.T) {
+	cfg := &config.Config{LLMProvider: "ollama", LLMBaseURL: "http://pi.local:11434/v1"}
+	summary := startupSummary(cfg, nil, nil)
+	got := map[any]any{}
+	for i := 0; i+1 < len(summary); i += 2 {
+		got[summary[i]] = summary[i+1]
+	}
+	if got["llm_provider"] != "ollama" || got["llm_base_url"] != "http://pi.local:11434/v1" || got["response_format"] != "json_object" {
+		t.Errorf("summary = %v, want the provider, its URL and its default response format", summary)
+	}
+}
+
+func TestStartupBanner_RedactsPatterns(t *testing.T) {
+	r, err := platform.NewRedactor(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const key = "sk-abcdefghijklmnop1234"
+	cfg := &config.Config{LLMProvider: "openai", LLMBaseURL: "https://proxy.local/" + key}
+	mem := memory.New(t.TempDir())
+	logStartupBanner(context.Background(), mem, r, startupSummary(cfg, nil, r))
+
+	entries, err := mem.ReadRange(context.Background(), time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("entries = %+v, err = %v, want one startup entry", entries, err)
+	}
+	if strings.Contains(entries[0].Content, key) || !strings.Contains(entries[0].Content, "https://proxy.local/[REDACTED]") {
+		t.Errorf("entry = %q, want the key masked by pattern", entries[0].Content)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/edouard/pureclaw/internal/config"
+)
+
+// Replaceable for testing.
+var configMigrate = config.Migrate
+
+// runConfig dispatches config subcommands: migrate.
+func runConfig(args []string, stdout, stderr io.Writer) int {
+	switch args[0] {
+	case "migrate":
+		return configMigrateCmd(args[1:], stdout, stderr)
+	default:
+		fmt.Fprintf(stderr, "config: unknown subcommand %q\n", args[0])
+		printConfigUsage(stderr)
+		return 1
+	}
+}
+
+// configMigrateCmd upgrades config.json (or the file given with --config) to the current schema.
+func configMigrateCmd(args []string, stdout, stderr io.Writer) int {
+	path := defaultConfigPath
+	switch {
+	case len(args) == 0:
+	case len(args) == 2 && args[0] == "--config":
+		path = args[1]
+	default:
+		fmt.Fprintln(stderr, "Usage: pureclaw config migrate [--config <path>]")
+		return 1
+	}
+
+	changes, err := configMigrate(path)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	if len(changes) == 0 {
+		fmt.Fprintf(stdout, "%s is already at version %d\n", path, config.CurrentVersion)
+		return 0
+	}
+	fmt.Fprintf(stdout, "Migrated %s (backup: %s.bak):\n", path, path)
+	for _, c := range changes {
+		fmt.Fprintf(stdout, "  - %s\n", c)
+	}
+	return 0
+}
+
+func printConfigUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: pureclaw config <subcommand>")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Subcommands:")
+	fmt.Fprintln(w, "  migrate [--config <path>]   Upgrade config.json to the current schema (keeps a .bak copy)")
+}
@@ -254,6 +254,7 @@ func runInit(stdin io.Reader, stdout, stderr io.Writer) int {
 
 	// Create config
 	cfg := &config.Config{
+		Version:            config.CurrentVersion,
 		Workspace:          defaultWorkspacePath,
 		ModelText:          "mistral-large-latest",
 		ModelAudio:         "voxtral-mini-latest",
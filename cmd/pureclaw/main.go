@@ -35,6 +35,22 @@ func run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int
 			return runSubAgentCmd(agentPath, configPath, vaultPath, stdin, stderr)
 		}
 		return runAgent(stdin, stdout, stderr)
+	case "config":
+		if len(args) < 3 {
+			printConfigUsage(stderr)
+			return 1
+		}
+		return runConfig(args[2:], stdout, stderr)
+	case "memory":
+		if len(args) < 3 {
+			printMemoryUsage(stderr)
+			return 1
+		}
+		return runMemory(args[2:], stdin, stdout, stderr)
+	case "replay":
+		return runReplay(args[2:], stdin, stdout, stderr)
+	case "selftest":
+		return runSelftest(args[2:], stdin, stdout, stderr)
 	case "vault":
 		if len(args) < 3 {
 			printVaultUsage(stderr)
@@ -88,8 +104,12 @@ func printUsage(w io.Writer) {
 	fmt.Fprintln(w, "Usage: pureclaw <command>")
 	fmt.Fprintln(w, "")
 	fmt.Fprintln(w, "Commands:")
+	fmt.Fprintln(w, "  config    Manage config.json (migrate)")
 	fmt.Fprintln(w, "  init      Initialize a new workspace")
+	fmt.Fprintln(w, "  memory    Manage agent memory (reindex, compact, stats, prune)")
+	fmt.Fprintln(w, "  replay    Re-run a transcript of user messages offline")
 	fmt.Fprintln(w, "  run       Start the agent")
+	fmt.Fprintln(w, "  selftest  Check the tool loop end to end against the LLM")
 	fmt.Fprintln(w, "  vault     Manage encrypted vault")
 	fmt.Fprintln(w, "  version   Print version")
 }
@@ -2,11 +2,19 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/edouard/pureclaw/internal/config"
+	"github.com/edouard/pureclaw/internal/llm"
+	"github.com/edouard/pureclaw/internal/memory"
 )
 
 func TestRun_version(t *testing.T) {
@@ -233,3 +241,279 @@ func TestHelperMain(t *testing.T) {
 	}
 	main()
 }
+
+func TestRun_configMigrate(t *testing.T) {
+	orig := configMigrate
+	t.Cleanup(func() { configMigrate = orig })
+
+	var gotPath string
+	configMigrate = func(path string) ([]string, error) {
+		gotPath = path
+		return []string{`renamed "model" to "model_text"`}, nil
+	}
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"pureclaw", "config", "migrate", "--config", "/etc/pureclaw/config.json"}, strings.NewReader(""), &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("exit code = %d, want 0 (stderr: %s)", code, stderr.String())
+	}
+	if gotPath != "/etc/pureclaw/config.json" {
+		t.Errorf("migrated path = %q", gotPath)
+	}
+	if !strings.Contains(stdout.String(), `renamed "model" to "model_text"`) {
+		t.Errorf("stdout = %q, want change list", stdout.String())
+	}
+}
+
+func TestRun_configMigrateAlreadyCurrent(t *testing.T) {
+	orig := configMigrate
+	t.Cleanup(func() { configMigrate = orig })
+	configMigrate = func(path string) ([]string, error) { return nil, nil }
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"pureclaw", "config", "migrate"}, strings.NewReader(""), &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code = %d, want 0", code)
+	}
+	if !strings.Contains(stdout.String(), "already at version") {
+		t.Errorf("stdout = %q", stdout.String())
+	}
+}
+
+func TestRun_configMigrateError(t *testing.T) {
+	orig := configMigrate
+	t.Cleanup(func() { configMigrate = orig })
+	configMigrate = func(path string) ([]string, error) { return nil, errors.New("boom") }
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"pureclaw", "config", "migrate"}, strings.NewReader(""), &stdout, &stderr); code != 1 {
+		t.Fatalf("exit code = %d, want 1", code)
+	}
+	if !strings.Contains(stderr.String(), "boom") {
+		t.Errorf("stderr = %q", stderr.String())
+	}
+}
+
+func TestRun_configUsage(t *testing.T) {
+	for _, args := range [][]string{
+		{"pureclaw", "config"},
+		{"pureclaw", "config", "bogus"},
+		{"pureclaw", "config", "migrate", "extra"},
+	} {
+		var stdout, stderr bytes.Buffer
+		if code := run(args, strings.NewReader(""), &stdout, &stderr); code != 1 {
+			t.Errorf("%v: exit code = %d, want 1", args, code)
+		}
+		if !strings.Contains(stderr.String(), "Usage:") {
+			t.Errorf("%v: stderr = %q, want usage", args, stderr.String())
+		}
+	}
+}
+
+func TestRun_memoryReindex(t *testing.T) {
+	saveRunVars(t)
+	ws := t.TempDir()
+	if err := memory.New(ws).Write(context.Background(), "owner", "remember the milk"); err != nil {
+		t.Fatal(err)
+	}
+	var gotPath string
+	configLoad = func(path string) (*config.Config, error) {
+		gotPath = path
+		return &config.Config{Workspace: ws}, nil
+	}
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"pureclaw", "memory", "reindex", "--config", "/etc/pureclaw/config.json"}, strings.NewReader(""), &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("exit code = %d, want 0 (stderr: %s)", code, stderr.String())
+	}
+	if gotPath != "/etc/pureclaw/config.json" {
+		t.Errorf("config path = %q", gotPath)
+	}
+	if !strings.Contains(stdout.String(), "1 files, 1 entries") {
+		t.Errorf("stdout = %q, want reindex counts", stdout.String())
+	}
+	if _, err := os.Stat(filepath.Join(ws, "memory", memory.IndexFileName)); err != nil {
+		t.Errorf("index not written: %v", err)
+	}
+}
+
+func TestRun_memoryReindexConfigError(t *testing.T) {
+	saveRunVars(t)
+	configLoad = func(path string) (*config.Config, error) { return nil, errors.New("no config") }
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"pureclaw", "memory", "reindex"}, strings.NewReader(""), &stdout, &stderr); code != 1 {
+		t.Fatalf("exit code = %d, want 1", code)
+	}
+	if !strings.Contains(stderr.String(), "no config") {
+		t.Errorf("stderr = %q", stderr.String())
+	}
+}
+
+func TestRun_memoryUsage(t *testing.T) {
+	for _, args := range [][]string{
+		{"pureclaw", "memory"},
+		{"pureclaw", "memory", "bogus"},
+		{"pureclaw", "memory", "reindex", "extra"},
+		{"pureclaw", "memory", "compact"},
+		{"pureclaw", "memory", "compact", "--before", "last week"},
+		{"pureclaw", "memory", "compact", "--before"},
+		{"pureclaw", "memory", "stats", "--since", "a week"},
+		{"pureclaw", "memory", "stats", "--since"},
+		{"pureclaw", "memory", "prune"},
+		{"pureclaw", "memory", "prune", "--before", "soon"},
+	} {
+		var stdout, stderr bytes.Buffer
+		if code := run(args, strings.NewReader(""), &stdout, &stderr); code != 1 {
+			t.Errorf("%v: exit code = %d, want 1", args, code)
+		}
+		if !strings.Contains(stderr.String(), "Usage:") {
+			t.Errorf("%v: stderr = %q, want usage", args, stderr.String())
+		}
+	}
+}
+
+func TestRun_memoryCompact(t *testing.T) {
+	saveRunVars(t)
+	ws := t.TempDir()
+	mem := memory.New(ws)
+	for range 3 {
+		if err := mem.Write(context.Background(), "owner", "remember the milk"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	configLoad = func(path string) (*config.Config, error) {
+		return &config.Config{Workspace: ws}, nil
+	}
+
+	var stdout, stderr bytes.Buffer
+	// The entries were written this hour, which compact never touches.
+	code := run([]string{"pureclaw", "memory", "compact", "--before", "2999-01-01"}, strings.NewReader(""), &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("exit code = %d, want 0 (stderr: %s)", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "Compacted 0 files") {
+		t.Errorf("stdout = %q, want compaction counts", stdout.String())
+	}
+}
+
+func TestRun_memoryStats(t *testing.T) {
+	saveRunVars(t)
+	ws := t.TempDir()
+	mem := memory.New(ws)
+	for _, source := range []string{"owner", "agent", "owner"} {
+		if err := mem.Write(context.Background(), source, "remember the milk"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	configLoad = func(path string) (*config.Config, error) {
+		return &config.Config{Workspace: ws}, nil
+	}
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"pureclaw", "memory", "stats", "--since", "2h"}, strings.NewReader(""), &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("exit code = %d, want 0 (stderr: %s)", code, stderr.String())
+	}
+	out := stdout.String()
+	for _, want := range []string{"Files     1\n", "Entries   3\n", "owner     2\n", "agent     1\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("stdout = %q, want it to contain %q", out, want)
+		}
+	}
+	if strings.Index(out, "owner") > strings.Index(out, "agent") {
+		t.Errorf("stdout = %q, want sources with the most entries first", out)
+	}
+}
+
+func TestRun_memoryPrune(t *testing.T) {
+	saveRunVars(t)
+	ws := t.TempDir()
+	mem := memory.New(ws)
+	if err := mem.Write(context.Background(), "owner", "remember the milk"); err != nil {
+		t.Fatal(err)
+	}
+	configLoad = func(path string) (*config.Config, error) {
+		return &config.Config{Workspace: ws}, nil
+	}
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"pureclaw", "memory", "prune", "--before", "30d"}, strings.NewReader(""), &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("exit code = %d, want 0 (stderr: %s)", code, stderr.String())
+	}
+	if !strings.HasPrefix(stdout.String(), "Pruned 0 entries") {
+		t.Errorf("stdout = %q, want nothing pruned", stdout.String())
+	}
+
+	stdout.Reset()
+	code = run([]string{"pureclaw", "memory", "prune", "--before", "2999-01-01"}, strings.NewReader(""), &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("exit code = %d, want 0 (stderr: %s)", code, stderr.String())
+	}
+	if !strings.HasPrefix(stdout.String(), "Pruned 1 entries") {
+		t.Errorf("stdout = %q, want the entry pruned", stdout.String())
+	}
+}
+
+func TestParsePruneBefore(t *testing.T) {
+	now := time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC)
+	for in, want := range map[string]time.Time{
+		"30d":                  time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+		"72h":                  time.Date(2026, 3, 28, 12, 0, 0, 0, time.UTC),
+		"2026-02-01":           time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+		"2026-02-01T10:00:00Z": time.Date(2026, 2, 1, 10, 0, 0, 0, time.UTC),
+	} {
+		got, err := parsePruneBefore(in, now)
+		if err != nil || !got.Equal(want) {
+			t.Errorf("parsePruneBefore(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "0d", "-5d", "-1h", "last month"} {
+		if _, err := parsePruneBefore(in, now); err == nil {
+			t.Errorf("parsePruneBefore(%q) succeeded, want an error", in)
+		}
+	}
+}
+
+func TestParseMemoryCompactArgs(t *testing.T) {
+	opts, err := parseMemoryCompactArgs([]string{"--before", "2026-03-16", "--summarize", "--config", "c.json", "--vault", "v.enc"})
+	if err != nil {
+		t.Fatalf("parseMemoryCompactArgs: %v", err)
+	}
+	if !opts.before.Equal(time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC)) || !opts.summarize || opts.configPath != "c.json" || opts.vaultPath != "v.enc" {
+		t.Errorf("opts = %+v", opts)
+	}
+
+	opts, err = parseMemoryCompactArgs([]string{"--before", "2026-03-16T12:00:00+02:00"})
+	if err != nil {
+		t.Fatalf("parseMemoryCompactArgs RFC 3339: %v", err)
+	}
+	if !opts.before.Equal(time.Date(2026, 3, 16, 10, 0, 0, 0, time.UTC)) || opts.summarize {
+		t.Errorf("opts = %+v", opts)
+	}
+}
+
+// summaryLLM answers every request with a fixed condensed note and records the prompt.
+type summaryLLM struct {
+	prompt []llm.Message
+}
+
+func (s *summaryLLM) ChatCompletionWithRetry(ctx context.Context, messages []llm.Message, tools []llm.Tool) (*llm.ChatResponse, error) {
+	s.prompt = messages
+	return &llm.ChatResponse{Choices: []llm.Choice{{Message: llm.Message{Content: `{"type":"message","content":"milk, eggs"}`}}}}, nil
+}
+
+func TestLLMSummarizer(t *testing.T) {
+	fake := &summaryLLM{}
+	got, err := llmSummarizer(fake)(context.Background(), "owner", "buy milk\n\nbuy eggs")
+	if err != nil {
+		t.Fatalf("summarize: %v", err)
+	}
+	if got != "milk, eggs" {
+		t.Errorf("summary = %q, want %q", got, "milk, eggs")
+	}
+	if len(fake.prompt) != 2 || !strings.Contains(fake.prompt[1].Content, "buy eggs") {
+		t.Errorf("prompt = %+v, want the merged notes", fake.prompt)
+	}
+}
@@ -0,0 +1,374 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/edouard/pureclaw/internal/agent"
+	"github.com/edouard/pureclaw/internal/llm"
+	"github.com/edouard/pureclaw/internal/memory"
+	"github.com/edouard/pureclaw/internal/tool"
+)
+
+// memorySummaryPrompt instructs the LLM condensing merged entries in memory compact --summarize.
+const memorySummaryPrompt = `You condense an agent's memory notes. Rewrite the notes below as one shorter note that keeps every fact, decision, name, number and date. Reply with JSON: {"type": "message", "content": "<condensed note>"}`
+
+// runMemory dispatches memory subcommands: reindex, compact, stats, prune.
+func runMemory(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	switch args[0] {
+	case "reindex":
+		return memoryReindexCmd(args[1:], stdout, stderr)
+	case "compact":
+		return memoryCompactCmd(args[1:], stdin, stdout, stderr)
+	case "stats":
+		return memoryStatsCmd(args[1:], stdout, stderr)
+	case "prune":
+		return memoryPruneCmd(args[1:], stdout, stderr)
+	default:
+		fmt.Fprintf(stderr, "memory: unknown subcommand %q\n", args[0])
+		printMemoryUsage(stderr)
+		return 1
+	}
+}
+
+// memoryReindexCmd rebuilds the memory index of the workspace named in config.json
+// (or the file given with --config). Interrupting it leaves the previous index in place.
+func memoryReindexCmd(args []string, stdout, stderr io.Writer) int {
+	path := defaultConfigPath
+	switch {
+	case len(args) == 0:
+	case len(args) == 2 && args[0] == "--config":
+		path = args[1]
+	default:
+		fmt.Fprintln(stderr, "Usage: pureclaw memory reindex [--config <path>]")
+		return 1
+	}
+
+	cfg, err := configLoad(path)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	ctx, stop := signalContext()
+	defer stop()
+
+	stats, err := newMemory(cfg.Workspace).Reindex(ctx)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Fprintln(stdout, tool.FormatReindexStats(stats))
+	return 0
+}
+
+// memoryCompactCmd collapses consecutive same-source entries in the hourly files
+// older than --before, keeping a .bak copy of each rewritten file. With --summarize,
+// collapsed entries are condensed by the LLM (which needs the vault).
+func memoryCompactCmd(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	opts, err := parseMemoryCompactArgs(args)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		fmt.Fprintln(stderr, "Usage: pureclaw memory compact --before <YYYY-MM-DD|RFC3339> [--summarize] [--config <path>] [--vault <path>]")
+		return 1
+	}
+
+	cfg, err := configLoad(opts.configPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	var summarize memory.Summarizer
+	if opts.summarize {
+		llmKey, err := loadLLMKey(opts.vaultPath, cfg, stdin, stderr)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+		llmClient := newLLMClient(llmKey, cfg.ModelText, cfg.ResolvedTimeouts().LLM.Duration)
+		setProvider(llmClient, cfg)
+		setResponseFormat(llmClient, cfg.ResponseFormat)
+		setAssistantPrefix(llmClient, cfg.AssistantPrefix)
+		summarize = llmSummarizer(llmClient)
+	}
+
+	ctx, stop := signalContext()
+	defer stop()
+
+	mem := newMemory(cfg.Workspace)
+	stats, err := mem.Compact(ctx, opts.before, summarize)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(stdout, "Compacted %d files: %d entries -> %d", stats.Files, stats.EntriesBefore, stats.EntriesAfter)
+	if stats.Skipped > 0 {
+		fmt.Fprintf(stdout, " (%d files with malformed entries left untouched)", stats.Skipped)
+	}
+	fmt.Fprintln(stdout)
+
+	// Keep an existing index in step with the rewritten files.
+	if stats.Files > 0 {
+		if _, err := mem.LoadIndex(); err == nil {
+			if _, err := mem.Reindex(ctx); err != nil {
+				fmt.Fprintf(stderr, "Warning: memory index not rebuilt: %v\n", err)
+			}
+		}
+	}
+	return 0
+}
+
+// memoryStatsCmd prints how much memory the workspace holds: files, bytes,
+// entries per source and the time span they cover. --since restricts the scan
+// to the hourly files of a recent period.
+func memoryStatsCmd(args []string, stdout, stderr io.Writer) int {
+	const usage = "Usage: pureclaw memory stats [--since <duration>] [--config <path>]"
+	path := defaultConfigPath
+	var since time.Duration
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--config" && i+1 < len(args):
+			i++
+			path = args[i]
+		case args[i] == "--since" && i+1 < len(args):
+			i++
+			d, err := time.ParseDuration(args[i])
+			if err != nil || d <= 0 {
+				fmt.Fprintf(stderr, "Error: invalid --since %q: use a positive duration such as 168h\n", args[i])
+				fmt.Fprintln(stderr, usage)
+				return 1
+			}
+			since = d
+		default:
+			fmt.Fprintln(stderr, usage)
+			return 1
+		}
+	}
+
+	cfg, err := configLoad(path)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	ctx, stop := signalContext()
+	defer stop()
+
+	mem := newMemory(cfg.Workspace)
+	mem.SetPerChat(cfg.MemoryPerChat)
+	stats, err := mem.Stats(ctx, since)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	printMemoryStats(stdout, stats)
+	return 0
+}
+
+// printMemoryStats writes stats as an aligned table, sources by entry count.
+func printMemoryStats(w io.Writer, stats memory.Stats) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	span := func(t time.Time) string {
+		if t.IsZero() {
+			return "-"
+		}
+		return t.Format("2006-01-02 15:04")
+	}
+	fmt.Fprintf(tw, "Files\t%d\n", stats.Files)
+	if stats.Skipped > 0 {
+		fmt.Fprintf(tw, "Unreadable files\t%d\n", stats.Skipped)
+	}
+	fmt.Fprintf(tw, "Bytes\t%d\n", stats.Bytes)
+	fmt.Fprintf(tw, "Entries\t%d\n", stats.Entries)
+	fmt.Fprintf(tw, "Earliest\t%s\n", span(stats.Earliest))
+	fmt.Fprintf(tw, "Latest\t%s\n", span(stats.Latest))
+
+	sources := slices.Collect(maps.Keys(stats.BySource))
+	slices.SortFunc(sources, func(a, b string) int {
+		return cmp.Or(cmp.Compare(stats.BySource[b], stats.BySource[a]), cmp.Compare(a, b))
+	})
+	if len(sources) > 0 {
+		fmt.Fprintln(tw, "\t")
+		fmt.Fprintln(tw, "Source\tEntries")
+		for _, s := range sources {
+			fmt.Fprintf(tw, "%s\t%d\n", s, stats.BySource[s])
+		}
+	}
+	tw.Flush()
+}
+
+// memoryPruneCmd deletes memory entries older than --before, which takes an age
+// (30d, 72h) or a date, and rebuilds an existing index afterwards.
+func memoryPruneCmd(args []string, stdout, stderr io.Writer) int {
+	const usage = "Usage: pureclaw memory prune --before <age|YYYY-MM-DD|RFC3339> [--config <path>]"
+	path := defaultConfigPath
+	var before time.Time
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--config" && i+1 < len(args):
+			i++
+			path = args[i]
+		case args[i] == "--before" && i+1 < len(args):
+			i++
+			t, err := parsePruneBefore(args[i], time.Now())
+			if err != nil {
+				fmt.Fprintf(stderr, "Error: %v\n", err)
+				fmt.Fprintln(stderr, usage)
+				return 1
+			}
+			before = t
+		default:
+			fmt.Fprintln(stderr, usage)
+			return 1
+		}
+	}
+	if before.IsZero() {
+		fmt.Fprintln(stderr, "Error: --before is required")
+		fmt.Fprintln(stderr, usage)
+		return 1
+	}
+
+	cfg, err := configLoad(path)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	ctx, stop := signalContext()
+	defer stop()
+
+	mem := newMemory(cfg.Workspace)
+	removed, err := mem.Prune(ctx, before)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(stdout, "Pruned %d entries older than %s\n", removed, before.UTC().Format(time.RFC3339))
+
+	// Keep an existing index in step with the deleted entries.
+	if removed > 0 {
+		if _, err := mem.LoadIndex(); err == nil {
+			if _, err := mem.Reindex(ctx); err != nil {
+				fmt.Fprintf(stderr, "Warning: memory index not rebuilt: %v\n", err)
+			}
+		}
+	}
+	return 0
+}
+
+// parsePruneBefore turns a prune cutoff into a time: an age before now in days
+// ("30d") or as a Go duration ("72h"), or a date or timestamp as compact accepts.
+func parsePruneBefore(s string, now time.Time) (time.Time, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n > 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil && d > 0 {
+		return now.Add(-d), nil
+	}
+	t, err := parseCompactBefore(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --before %q: use an age like 30d or 72h, YYYY-MM-DD or RFC 3339", s)
+	}
+	return t, nil
+}
+
+// memoryCompactOptions holds the parsed arguments of memory compact.
+type memoryCompactOptions struct {
+	before     time.Time
+	summarize  bool
+	configPath string
+	vaultPath  string
+}
+
+// parseMemoryCompactArgs parses memory compact arguments. --before is required and
+// takes a date (midnight UTC) or an RFC 3339 timestamp.
+func parseMemoryCompactArgs(args []string) (memoryCompactOptions, error) {
+	opts := memoryCompactOptions{configPath: defaultConfigPath, vaultPath: defaultVaultPath}
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--summarize":
+			opts.summarize = true
+		case "--before", "--config", "--vault":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("%s requires an argument", args[i])
+			}
+			value := args[i+1]
+			i++
+			switch args[i-1] {
+			case "--before":
+				t, err := parseCompactBefore(value)
+				if err != nil {
+					return opts, err
+				}
+				opts.before = t
+			case "--config":
+				opts.configPath = value
+			default:
+				opts.vaultPath = value
+			}
+		default:
+			return opts, fmt.Errorf("unexpected argument %q", args[i])
+		}
+	}
+	if opts.before.IsZero() {
+		return opts, fmt.Errorf("--before is required")
+	}
+	return opts, nil
+}
+
+// parseCompactBefore accepts YYYY-MM-DD (midnight UTC) or an RFC 3339 timestamp.
+func parseCompactBefore(s string) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", s, time.UTC); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --before %q: use YYYY-MM-DD or RFC 3339", s)
+	}
+	return t, nil
+}
+
+// llmSummarizer condenses merged memory entries with the LLM, without tools.
+func llmSummarizer(c agent.LLMClient) memory.Summarizer {
+	return func(ctx context.Context, source, content string) (string, error) {
+		resp, err := c.ChatCompletionWithRetry(ctx, []llm.Message{
+			{Role: "system", Content: memorySummaryPrompt},
+			{Role: "user", Content: "Notes from " + source + ":\n\n" + content},
+		}, nil)
+		if err != nil {
+			return "", err
+		}
+		if len(resp.Choices) == 0 {
+			return "", fmt.Errorf("empty LLM response")
+		}
+		parsed, err := llm.ParseAgentResponse(resp.Choices[0].Message.Content)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(parsed.Content), nil
+	}
+}
+
+func printMemoryUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: pureclaw memory <subcommand>")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Subcommands:")
+	fmt.Fprintln(w, "  reindex [--config <path>]   Rebuild the memory index from the memory files")
+	fmt.Fprintln(w, "  compact --before <date> [--summarize] [--config <path>] [--vault <path>]")
+	fmt.Fprintln(w, "                              Merge consecutive same-source entries in older hourly files (keeps .bak copies)")
+	fmt.Fprintln(w, "  stats [--since <duration>] [--config <path>]")
+	fmt.Fprintln(w, "                              Count memory files, entries per source and the time span they cover")
+	fmt.Fprintln(w, "  prune --before <age|date> [--config <path>]")
+	fmt.Fprintln(w, "                              Delete entries older than the cutoff, e.g. --before 30d")
+}
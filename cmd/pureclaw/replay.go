@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/edouard/pureclaw/internal/agent"
+	"github.com/edouard/pureclaw/internal/config"
+	"github.com/edouard/pureclaw/internal/telegram"
+	"github.com/edouard/pureclaw/internal/tool"
+)
+
+// stdoutSender is an agent.Sender that prints replies instead of sending them to Telegram.
+type stdoutSender struct {
+	w io.Writer
+}
+
+func (s *stdoutSender) Send(ctx context.Context, chatID int64, text string) error {
+	_, err := fmt.Fprintf(s.w, "%s\n\n", text)
+	return err
+}
+
+func (s *stdoutSender) React(ctx context.Context, chatID, messageID int64, emoji string) error {
+	return nil
+}
+
+// runReplay re-runs a transcript of user messages through the agent offline:
+// the real LLM answers, replies are printed to stdout, and nothing is sent to
+// Telegram or written to memory. Only read-only tools are available, so a replay
+// never changes the workspace or runs commands.
+//
+// The transcript holds one user message per line; blank lines and lines
+// starting with '#' are ignored.
+func runReplay(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	transcriptPath, configPath, vaultPath, err := parseReplayArgs(args)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		fmt.Fprintln(stderr, "Usage: pureclaw replay <transcript> [--config <path>] [--vault <path>]")
+		return 1
+	}
+
+	lines, err := readTranscript(transcriptPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	cfg, err := configLoad(configPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	llmKey, err := loadLLMKey(vaultPath, cfg, stdin, stderr)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	ws, err := workspaceLoad(cfg.Workspace, cfg.MaxSkills)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	registry := tool.NewRegistry()
+	registry.Register(tool.NewReadFile())
+	registry.Register(tool.NewListDir())
+	registry.Register(tool.NewDescribeWorkspace(ws, cfg.Workspace))
+
+	mem := newMemory(cfg.Workspace)
+	mem.SetMaxResults(cfg.MaxMemoryResults)
+	mem.SetDedup(cfg.MemoryDedup)
+	mem.SetPerChat(cfg.MemoryPerChat)
+	mem.SetStructured(cfg.MemoryJSONL)
+
+	timeouts := cfg.ResolvedTimeouts()
+	llmClient := newLLMClient(llmKey, cfg.ModelText, timeouts.LLM.Duration)
+	setProvider(llmClient, cfg)
+	setResponseFormat(llmClient, cfg.ResponseFormat)
+	setAssistantPrefix(llmClient, cfg.AssistantPrefix)
+	setModelQuirks(llmClient, cfg.ModelQuirks)
+	setSampling(llmClient, cfg)
+	ag := newAgent(agent.NewAgentConfig{
+		Workspace:      ws,
+		LLM:            llmClient,
+		Sender:         &stdoutSender{w: stdout},
+		MemorySearcher: mem,
+		ToolExecutor:   registry,
+		RetryBudget:    cfg.RetryBudget,
+		MessageTimeout: timeouts.Message.Duration,
+		FallbackReply:  cfg.ResolvedFallbackReply(),
+	})
+
+	var chatID int64
+	if len(cfg.TelegramAllowedIDs) > 0 {
+		chatID = cfg.TelegramAllowedIDs[0]
+	}
+
+	ctx, stop := signalContext()
+	defer stop()
+
+	slog.Info("replaying transcript",
+		"component", "cmd",
+		"operation", "replay",
+		"path", transcriptPath,
+		"messages", len(lines),
+	)
+	for i, text := range lines {
+		if ctx.Err() != nil {
+			fmt.Fprintln(stderr, "Replay interrupted")
+			return 1
+		}
+		fmt.Fprintf(stdout, "> %s\n", text)
+		ag.HandleMessage(ctx, telegram.TelegramMessage{
+			Message: telegram.Message{
+				MessageID: int64(i + 1),
+				Chat:      telegram.Chat{ID: chatID},
+				Text:      text,
+			},
+		})
+	}
+	return 0
+}
+
+// loadLLMKey opens the vault, with the passphrase from PURECLAW_VAULT_PASSPHRASE
+// or read from stdin, and returns the LLM API key for offline commands.
+func loadLLMKey(vaultPath string, cfg *config.Config, stdin io.Reader, stderr io.Writer) (string, error) {
+	passphrase := os.Getenv("PURECLAW_VAULT_PASSPHRASE")
+	if passphrase == "" {
+		fmt.Fprint(stderr, "Vault passphrase: ")
+		scanner := bufio.NewScanner(stdin)
+		scanner.Scan()
+		passphrase = strings.TrimSpace(scanner.Text())
+		if passphrase == "" {
+			return "", fmt.Errorf("passphrase cannot be empty")
+		}
+	}
+	salt, err := vaultLoadSalt(vaultPath)
+	if err != nil {
+		return "", err
+	}
+	v, err := vaultOpenFn(vaultDeriveKey(passphrase, salt), vaultPath)
+	if err != nil {
+		return "", err
+	}
+	return llmAPIKey(v, cfg)
+}
+
+// parseReplayArgs extracts the transcript path and optional --config/--vault paths.
+func parseReplayArgs(args []string) (transcriptPath, configPath, vaultPath string, err error) {
+	configPath, vaultPath = defaultConfigPath, defaultVaultPath
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--config", "--vault":
+			if i+1 >= len(args) {
+				return "", "", "", fmt.Errorf("%s requires a path argument", args[i])
+			}
+			if args[i] == "--config" {
+				configPath = args[i+1]
+			} else {
+				vaultPath = args[i+1]
+			}
+			i++
+		default:
+			if transcriptPath != "" {
+				return "", "", "", fmt.Errorf("unexpected argument %q", args[i])
+			}
+			transcriptPath = args[i]
+		}
+	}
+	if transcriptPath == "" {
+		return "", "", "", fmt.Errorf("transcript file is required")
+	}
+	return transcriptPath, configPath, vaultPath, nil
+}
+
+// readTranscript returns the user messages in a transcript file, skipping blank and '#' lines.
+func readTranscript(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("replay: open transcript: %w", err)
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("replay: read transcript: %w", err)
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("replay: transcript %s has no messages", path)
+	}
+	return lines, nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/edouard/pureclaw/internal/agent"
+	"github.com/edouard/pureclaw/internal/llm"
+)
+
+// echoLLM replies "echo: <last user message>" to every request.
+type echoLLM struct {
+	seen []string
+}
+
+func (e *echoLLM) ChatCompletionWithRetry(ctx context.Context, messages []llm.Message, tools []llm.Tool) (*llm.ChatResponse, error) {
+	last := messages[len(messages)-1].Content
+	e.seen = append(e.seen, last)
+	return &llm.ChatResponse{
+		Choices: []llm.Choice{{
+			Message:      llm.Message{Content: `{"type":"message","content":"echo: ` + last + `"}`},
+			FinishReason: "stop",
+		}},
+	}, nil
+}
+
+func writeTranscript(t *testing.T, dir, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, "transcript.txt")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRunReplay_PrintsRepliesInOrder(t *testing.T) {
+	dir := t.TempDir()
+	chdir(t, dir)
+	setupHappyPath(t, dir)
+
+	fake := &echoLLM{}
+	newLLMClient = func(apiKey, model string, timeout time.Duration) agent.LLMClient { return fake }
+	path := writeTranscript(t, dir, "# regression case\nfirst question\n\nsecond question\n")
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"pureclaw", "replay", path}, strings.NewReader("test-pass\n"), &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("exit code = %d; stderr: %s", code, stderr.String())
+	}
+
+	want := "> first question\necho: first question\n\n> second question\necho: second question\n\n"
+	if stdout.String() != want {
+		t.Errorf("stdout =\n%q\nwant\n%q", stdout.String(), want)
+	}
+	if len(fake.seen) != 2 {
+		t.Errorf("LLM calls = %d, want 2", len(fake.seen))
+	}
+
+	// Replay must not write memory.
+	if _, err := os.Stat(filepath.Join(dir, "workspace", "memory")); !os.IsNotExist(err) {
+		t.Errorf("replay should not create memory files (stat err = %v)", err)
+	}
+}
+
+func TestRunReplay_Errors(t *testing.T) {
+	dir := t.TempDir()
+	chdir(t, dir)
+	setupHappyPath(t, dir)
+
+	empty := writeTranscript(t, dir, "# only comments\n\n")
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"no transcript", []string{"pureclaw", "replay"}, "transcript file is required"},
+		{"missing file", []string{"pureclaw", "replay", filepath.Join(dir, "nope.txt")}, "open transcript"},
+		{"empty transcript", []string{"pureclaw", "replay", empty}, "has no messages"},
+		{"dangling flag", []string{"pureclaw", "replay", empty, "--config"}, "--config requires a path"},
+		{"extra argument", []string{"pureclaw", "replay", empty, "other.txt"}, "unexpected argument"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(tt.args, strings.NewReader("test-pass\n"), &stdout, &stderr); code != 1 {
+				t.Fatalf("exit code = %d, want 1", code)
+			}
+			if !strings.Contains(stderr.String(), tt.want) {
+				t.Errorf("stderr = %q, want %q", stderr.String(), tt.want)
+			}
+		})
+	}
+}
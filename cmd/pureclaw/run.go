@@ -2,13 +2,16 @@ package main
 
 import (
 	"bufio"
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 	"syscall"
@@ -17,9 +20,10 @@ import (
 	"github.com/edouard/pureclaw/internal/agent"
 	"github.com/edouard/pureclaw/internal/config"
 	"github.com/edouard/pureclaw/internal/heartbeat"
-	"github.com/edouard/pureclaw/internal/subagent"
 	"github.com/edouard/pureclaw/internal/llm"
 	"github.com/edouard/pureclaw/internal/memory"
+	"github.com/edouard/pureclaw/internal/platform"
+	"github.com/edouard/pureclaw/internal/subagent"
 	"github.com/edouard/pureclaw/internal/telegram"
 	"github.com/edouard/pureclaw/internal/tool"
 	"github.com/edouard/pureclaw/internal/vault"
@@ -27,29 +31,48 @@ import (
 	"github.com/edouard/pureclaw/internal/workspace"
 )
 
+// defaultOffsetPath checkpoints the Telegram update offset across restarts.
+const defaultOffsetPath = "telegram.offset"
+
 // Replaceable for testing.
 var (
-	configLoad    = config.Load
-	vaultLoadSalt = vault.LoadSalt
+	configLoad     = config.Load
+	toolsLoad      = config.LoadTools
+	vaultLoadSalt  = vault.LoadSalt
 	vaultDeriveKey = vault.DeriveKey
-	vaultOpenFn   = vault.Open
-	workspaceLoad = workspace.Load
-	newLLMClient   = func(apiKey, model string) agent.LLMClient { return llm.NewClient(apiKey, model) }
-	newAudioClient = func(apiKey, model string) agent.Transcriber { return llm.NewClient(apiKey, model) }
-	newTGClient    = telegram.NewClient
-	newPoller     = func(client *telegram.Client, allowedIDs []int64, timeout int) *telegram.Poller {
+	vaultOpenFn    = vault.Open
+	workspaceLoad  = workspace.LoadWithMaxSkills
+	newLLMClient   = func(apiKey, model string, timeout time.Duration) agent.LLMClient {
+		return llm.NewClientWithTimeout(apiKey, model, timeout)
+	}
+	newAudioClient = func(apiKey, model string, timeout time.Duration) agent.Transcriber {
+		return llm.NewClientWithTimeout(apiKey, model, timeout)
+	}
+	newTGClient = telegram.NewClientWithPollTimeout
+	newPoller   = func(client *telegram.Client, allowedIDs []int64, timeout int) *telegram.Poller {
 		return telegram.NewPoller(client, allowedIDs, timeout)
 	}
-	newSender = func(client *telegram.Client) agent.Sender { return telegram.NewSender(client) }
-	newMemory = func(root string) *memory.Memory { return memory.New(root) }
-	newAgent  = agent.New
+	newSender = func(client *telegram.Client) agent.Sender {
+		return telegram.NewRateLimitedSender(telegram.NewSender(client))
+	}
+	newMemory     = func(root string) *memory.Memory { return memory.New(root) }
+	newAgent      = agent.New
 	signalContext = func() (context.Context, context.CancelFunc) {
 		return signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
 	}
 	runPollerFn = func(ctx context.Context, p *telegram.Poller, ch chan<- telegram.TelegramMessage) {
+		if err := p.DeleteWebhook(ctx); err != nil {
+			slog.Warn("failed to delete webhook, polling may be refused",
+				"component", "cmd", "operation", "run", "error", err)
+		}
 		p.Run(ctx, ch)
 	}
-	osExecutable = os.Executable
+	newWebhook   = telegram.NewWebhook
+	runWebhookFn = func(ctx context.Context, w *telegram.Webhook, ch chan<- telegram.TelegramMessage) error {
+		return w.Run(ctx, ch)
+	}
+	resolveSubAgentBinary = subagent.ResolveBinary
+	warmUpAgent           = func(ctx context.Context, ag *agent.Agent) error { return ag.WarmUp(ctx) }
 )
 
 func runAgent(stdin io.Reader, stdout, stderr io.Writer) int {
@@ -78,7 +101,9 @@ func runAgent(stdin io.Reader, stdout, stderr io.Writer) int {
 		}
 	}
 
-	// 3. Open vault
+	// 3. Open vault, first removing the leftovers of a save interrupted by the
+	// previous run. No sub-agent is running yet to be saving it.
+	vault.CleanupStaleTemps(defaultVaultPath)
 	salt, err := vaultLoadSalt(defaultVaultPath)
 	if err != nil {
 		slog.Error("failed to load vault salt",
@@ -101,9 +126,10 @@ func runAgent(stdin io.Reader, stdout, stderr io.Writer) int {
 		return 1
 	}
 
-	// 4. Get secrets
+	// 4. Get secrets. The Mistral key is only required when Mistral serves
+	// chat completion; otherwise it just enables voice transcription.
 	mistralKey, err := v.Get("mistral_api_key")
-	if err != nil {
+	if err != nil && (!errors.Is(err, vault.ErrKeyNotFound) || isMistral(cfg)) {
 		slog.Error("failed to get mistral API key",
 			"component", "cmd",
 			"operation", "run",
@@ -112,6 +138,12 @@ func runAgent(stdin io.Reader, stdout, stderr io.Writer) int {
 		fmt.Fprintf(stderr, "Error: %v\n", err)
 		return 1
 	}
+	if mistralKey == "" {
+		slog.Warn("mistral_api_key not set, voice transcription disabled",
+			"component", "cmd",
+			"operation", "run",
+		)
+	}
 	telegramToken, err := v.Get("telegram_bot_token")
 	if err != nil {
 		slog.Error("failed to get telegram bot token",
@@ -123,8 +155,20 @@ func runAgent(stdin io.Reader, stdout, stderr io.Writer) int {
 		return 1
 	}
 
+	llmKey, err := llmAPIKey(v, cfg)
+	if err != nil {
+		slog.Error("failed to get LLM API key",
+			"component", "cmd",
+			"operation", "run",
+			"provider", cfg.LLMProvider,
+			"error", err,
+		)
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+
 	// 5. Load workspace
-	ws, err := workspaceLoad(cfg.Workspace)
+	ws, err := workspaceLoad(cfg.Workspace, cfg.MaxSkills)
 	if err != nil {
 		slog.Error("failed to load workspace",
 			"component", "cmd",
@@ -135,19 +179,62 @@ func runAgent(stdin io.Reader, stdout, stderr io.Writer) int {
 		return 1
 	}
 
+	// 5a. Warn about an uncustomized persona.
+	defaultSoul := isDefaultSoul(ws.SoulMD)
+	if defaultSoul {
+		slog.Warn("SOUL.md is empty or still the default template",
+			"component", "cmd",
+			"operation", "run",
+			"path", filepath.Join(cfg.Workspace, "SOUL.md"),
+		)
+		fmt.Fprintln(stderr, "Warning: SOUL.md is empty or still the default; customize it to shape the agent's persona.")
+	}
+
 	// 6. Create file watcher for workspace hot-reload
-	fileChanges := make(chan struct{}, 1)
-	w := watcher.New(cfg.Workspace, 2*time.Second)
+	fileChanges := make(chan watcher.Change, 1)
+	w := watcher.New(cfg.Workspace, cfg.ResolvedWatchInterval())
 
 	// 6a. Create clients
-	llmClient := newLLMClient(mistralKey, cfg.ModelText)
-	audioClient := newAudioClient(mistralKey, cfg.ModelAudio)
-	tgClient := newTGClient(telegramToken)
-	poller := newPoller(tgClient, cfg.TelegramAllowedIDs, 30)
+	timeouts := cfg.ResolvedTimeouts()
+	llmClient := newLLMClient(llmKey, cfg.ModelText, timeouts.LLM.Duration)
+	setProvider(llmClient, cfg)
+	setResponseFormat(llmClient, cfg.ResponseFormat)
+	setAssistantPrefix(llmClient, cfg.AssistantPrefix)
+	setModelQuirks(llmClient, cfg.ModelQuirks)
+	setSampling(llmClient, cfg)
+	setMaxConcurrent(llmClient, cfg.LLMMaxConcurrent)
+	if cfg.ReplyAttachments {
+		setAttachments(llmClient)
+	}
+	var audioClient agent.Transcriber
+	if mistralKey != "" {
+		audioClient = newAudioClient(mistralKey, cfg.ModelAudio, timeouts.LLM.Duration)
+	}
+	tgClient := newTGClient(telegramToken, timeouts.Poll.Duration)
 	sender := newSender(tgClient)
+	var (
+		poller  *telegram.Poller
+		webhook *telegram.Webhook
+		acker   agent.MessageAcker // webhook updates are confirmed by the HTTP response instead
+	)
+	if cfg.TelegramMode == config.TelegramModeWebhook {
+		webhook = newWebhook(tgClient, cfg.TelegramAllowedIDs, cfg.WebhookURL, cfg.ResolvedWebhookListen())
+	} else {
+		poller = newPoller(tgClient, cfg.TelegramAllowedIDs, int(timeouts.Poll.Duration/time.Second))
+		poller.SetOffsetStore(telegram.NewFileOffsetStore(defaultOffsetPath))
+		poller.SetOutageAlert(cfg.ResolvedPollAlertAfter(), func(ctx context.Context, failures int, _ error) {
+			// The poll error is left out: it may carry the request URL, which holds the bot token.
+			notifyOwners(ctx, sender, cfg.TelegramAllowedIDs, fmt.Sprintf(pollOutageNotice, failures), "poll_outage")
+		})
+		acker = poller
+	}
 
 	// 6b. Create memory (serves both writer and searcher)
 	mem := newMemory(cfg.Workspace)
+	mem.SetMaxResults(cfg.MaxMemoryResults)
+	mem.SetDedup(cfg.MemoryDedup)
+	mem.SetPerChat(cfg.MemoryPerChat)
+	mem.SetStructured(cfg.MemoryJSONL)
 
 	// 6c. Extract vault secret values for exec_command sanitization (NFR9)
 	keys := v.List()
@@ -168,13 +255,57 @@ func runAgent(stdin io.Reader, stdout, stderr io.Writer) int {
 		}
 	}
 
-	// 6d. Create tool registry
+	// The same secrets, and secret-looking strings, are masked in tool results and memory.
+	redactor, err := platform.NewRedactor(secrets, cfg.RedactPatterns)
+	if err != nil {
+		slog.Error("invalid redact pattern",
+			"component", "cmd",
+			"operation", "run",
+			"error", err,
+		)
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	// 6d. Create tool registry from the built-in tools selected by tools.json
+	toolsCfg, err := toolsLoad(cmp.Or(cfg.ToolsFile, config.DefaultToolsPath))
+	if err != nil {
+		slog.Error("failed to load tools config",
+			"component", "cmd",
+			"operation", "run",
+			"error", err,
+		)
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	tools := newToolSelection(toolsCfg)
 	registry := tool.NewRegistry()
-	registry.Register(tool.NewReadFile())
-	registry.Register(tool.NewWriteFile())
-	registry.Register(tool.NewListDir())
-	registry.Register(tool.NewExecCommand(secrets))
-	registry.Register(tool.NewReloadWorkspace(ws))
+	tools.register(registry, tool.NewReadFile())
+	tools.register(registry, tool.NewWriteFile(cfg.AllowedWriteExtensions...))
+	tools.register(registry, tool.NewListDir())
+	tools.register(registry, tool.NewExecCommandWithTimeout(secrets, tools.timeout("exec_command", timeouts.Tool.Duration)))
+	tools.register(registry, tool.NewReloadWorkspace(ws))
+	tools.register(registry, tool.NewMemTag(mem))
+	tools.register(registry, tool.NewReindexMemory(mem))
+	tools.register(registry, tool.NewDescribeWorkspace(ws, cfg.Workspace))
+	tools.register(registry, tool.NewWaitFor(secrets))
+	tools.register(registry, tool.NewSaveSkill(ws, cfg.Workspace, cfg.AllowedWriteExtensions...))
+	tools.register(registry, tool.NewDiffFile(cfg.Workspace))
+	prober, _ := llmClient.(tool.LLMProber)
+	tools.register(registry, tool.NewProbeLLM(prober))
+	tools.register(registry, tool.NewSummarizeURL(llmClient, nil))
+	if cfg.ToolConcurrency > 0 || len(cfg.ToolLimits) > 0 {
+		registry.SetConcurrencyLimits(cfg.ToolConcurrency, cfg.ToolLimits)
+	}
+	if policy := confirmationPolicy(cfg, tools.confirmationRules()...); len(policy.Rules) > 0 {
+		confirmer := telegram.NewConfirmer(tgClient, cfg.TelegramAllowedIDs)
+		if webhook != nil {
+			webhook.SetCallbackHandler(confirmer)
+		} else {
+			poller.SetCallbackHandler(confirmer)
+		}
+		registry.SetConfirmation(policy, confirmer)
+	}
 
 	// 6e. Create heartbeat executor and ticker
 	var heartbeatTick <-chan time.Time
@@ -192,11 +323,13 @@ func runAgent(stdin io.Reader, stdout, stderr io.Writer) int {
 	}
 
 	// 6f. Create sub-agent result channel and runner for event loop integration.
-	subAgentResults := make(chan subagent.SubAgentResult, 1)
-	runner := subagent.NewRunner()
+	// The channel holds one result per concurrently running sub-agent so that
+	// simultaneous completions never block a runner goroutine.
+	subAgentResults := make(chan subagent.SubAgentResult, subagent.ResultBufferSize(cfg.SubAgentMaxConcurrent))
+	runner := subagent.NewConcurrentRunner(cfg.SubAgentMaxConcurrent)
 
 	// 6g. Determine binary path for sub-agent subprocess launch.
-	binaryPath, err := osExecutable()
+	binaryPath, err := resolveSubAgentBinary(cfg.SubAgentBinary)
 	if err != nil {
 		slog.Error("failed to determine binary path",
 			"component", "cmd",
@@ -209,54 +342,138 @@ func runAgent(stdin io.Reader, stdout, stderr io.Writer) int {
 
 	// 6h. Register spawn_agent tool.
 	agentsDir := filepath.Join(cfg.Workspace, "agents")
-	registry.Register(tool.NewSpawnAgent(tool.SpawnAgentDeps{
+	tools.register(registry, tool.NewSpawnAgent(tool.SpawnAgentDeps{
 		Runner:          runner,
 		ParentWorkspace: ws,
 		ResultCh:        subAgentResults,
 		BinaryPath:      binaryPath,
 		ConfigPath:      defaultConfigPath,
 		VaultPath:       defaultVaultPath,
-		Timeout:         cfg.SubAgentTimeout.Duration,
+		Timeout:         timeouts.SubAgent.Duration,
 		AgentsDir:       agentsDir,
+		Soul:            cfg.SubAgentSoul,
 	}))
 
+	// 6i. Status placeholders and document uploads are only provided by the Telegram sender.
+	statusMessenger, _ := sender.(agent.StatusMessenger)
+	documentSender, _ := sender.(agent.DocumentSender)
+	var statusEditor agent.StatusEditor
+	if cfg.StatusProgress && statusMessenger != nil {
+		statusEditor = tgClient
+	}
+
 	// 7. Create agent
 	ag := newAgent(agent.NewAgentConfig{
-		Workspace:       ws,
-		LLM:             llmClient,
-		Sender:          sender,
-		Memory:          mem,
-		MemorySearcher:  mem,
-		ToolExecutor:    registry,
-		FileChanges:     fileChanges,
-		HeartbeatTick:   heartbeatTick,
-		Heartbeat:       hb,
-		Transcriber:     audioClient,
-		VoiceDownloader: tgClient,
-		SubAgentResults: subAgentResults,
-		OwnerIDs:        cfg.TelegramAllowedIDs,
+		Workspace:        ws,
+		LLM:              llmClient,
+		Sender:           sender,
+		Memory:           mem,
+		MemorySearcher:   mem,
+		ToolExecutor:     registry,
+		FileChanges:      fileChanges,
+		HeartbeatTick:    heartbeatTick,
+		Heartbeat:        hb,
+		Transcriber:      audioClient,
+		VoiceDownloader:  tgClient,
+		ChatActions:      tgClient,
+		SubAgentResults:  subAgentResults,
+		SubAgents:        runner,
+		OwnerIDs:         cfg.TelegramAllowedIDs,
+		FormatCodeBlocks: cfg.FormatCodeBlocks,
+		RetryBudget:      cfg.RetryBudget,
+		StatusMessenger:  statusMessenger,
+		StatusEditor:     statusEditor,
+		DocumentSender:   documentSender,
+		StatusText:       cfg.StatusMessage,
+		KeepStatus:       cfg.KeepStatusMessage,
+		MemoryVerbosity:  cfg.MemoryVerbosity,
+		MessageTimeout:   timeouts.Message.Duration,
+		DownloadTimeout:  timeouts.Download.Duration,
+		FallbackReply:    cfg.ResolvedFallbackReply(),
+		Acker:            acker,
+		SubAgentDocument: cfg.SubAgentDocument,
+		Attachments:      cfg.ReplyAttachments,
+		PromptSuffixes:   cfg.ChatPromptSuffix,
+		PromptSections:   cfg.PromptSections,
+		MinFreeDisk:      uint64(max(cfg.MinFreeDiskMB, 0)) << 20,
+		Redactor:         redactor,
+		MaxReplyChunks:   cfg.ResolvedReplyChunkLimit(),
+		Debounce:         cfg.DebounceWindow.Duration,
+		ResultWebhookURL: cfg.ResultWebhookURL,
+		WebhookOnly:      cfg.ResultWebhookOnly,
+		EnvSummary:       cfg.EnvSummary,
+		PresenceWindow:   cfg.PresenceWindow.Duration,
+		TypingMinLatency: cfg.TypingMinLatency.Duration,
+		AllowedModels:    allowedModels(cfg),
+		SaveModel:        modelSaver(cfg),
+		HistoryMaxAge:    cfg.HistoryMaxAge.Duration,
+		SkipUnreachable:  cfg.SkipBlockedChats,
 	})
 
+	// 7a. compact_history and config act on the agent itself, so they are registered last.
+	tools.register(registry, tool.NewCompactHistory(ag))
+	tools.register(registry, tool.NewConfigTool(cfg, defaultConfigPath, runtimeConfigFields(ag, mem)))
+
+	// 7b. Suggest customizing the default persona to the owners.
+	if defaultSoul && cfg.NotifyDefaultSoul {
+		notifyOwners(context.Background(), sender, cfg.TelegramAllowedIDs, defaultSoulNotice, "run")
+	}
+
+	// 7c. Log the effective configuration and record it in memory for support.
+	logStartupBanner(context.Background(), mem, redactor, startupSummary(cfg, keys, redactor))
+
 	// 8. Signal handling
 	ctx, stop := signalContext()
 	defer stop()
 
-	// 9. Start watcher goroutine with WaitGroup tracking
+	// 9. Start watcher goroutine with WaitGroup tracking. It waits for the
+	// warm-up so the AGENT.md rewrites of introspection aren't seen as changes.
 	var wg sync.WaitGroup
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
+		if !waitReady(ctx, ag) {
+			return
+		}
 		w.Run(ctx, fileChanges)
 	}()
 
-	// 10. Start poller goroutine with WaitGroup tracking
+	// 10. Start poller (or webhook) goroutine with WaitGroup tracking. No
+	// message is received before the warm-up is done.
 	messages := make(chan telegram.TelegramMessage, 1)
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		runPollerFn(ctx, poller, messages)
+		if !waitReady(ctx, ag) {
+			return
+		}
+		if webhook == nil {
+			runPollerFn(ctx, poller, messages)
+			return
+		}
+		if err := runWebhookFn(ctx, webhook, messages); err != nil {
+			// Without a webhook the bot can't receive messages: stop rather than run deaf.
+			slog.Error("webhook failed, shutting down",
+				"component", "cmd",
+				"operation", "run",
+				"error", err,
+			)
+			stop()
+		}
 	}()
 
+	// 10a. Warm up: introspection and a full workspace load, bounded by
+	// warm_up_timeout. A failure is logged and the agent starts anyway.
+	warmCtx, warmCancel := context.WithTimeout(ctx, cfg.ResolvedWarmUpTimeout())
+	if err := warmUpAgent(warmCtx, ag); err != nil {
+		slog.Warn("warm-up failed, starting with the workspace loaded at startup",
+			"component", "cmd",
+			"operation", "run",
+			"error", err,
+		)
+	}
+	warmCancel()
+
 	// 11. Run event loop (blocks until ctx cancelled)
 	slog.Info("agent started",
 		"component", "cmd",
@@ -288,7 +505,12 @@ func runAgent(stdin io.Reader, stdout, stderr io.Writer) int {
 			"component", "pureclaw", "operation", "shutdown", "error", err)
 	}
 
-	// 2. Wait for active sub-agent to complete.
+	// 2. Tell the owners the bot is going offline (best-effort, bounded).
+	if cfg.NotifyShutdown {
+		notifyShutdown(shutdownCtx, sender, cfg.TelegramAllowedIDs)
+	}
+
+	// 3. Wait for active sub-agent to complete.
 	if runner.IsActive() {
 		slog.Info("waiting for active sub-agent",
 			"component", "pureclaw", "operation", "shutdown")
@@ -298,7 +520,7 @@ func runAgent(stdin io.Reader, stdout, stderr io.Writer) int {
 		}
 	}
 
-	// 3. Wait for background goroutines (poller + watcher) to exit.
+	// 4. Wait for background goroutines (poller + watcher) to exit.
 	goroutineDone := make(chan struct{})
 	go func() {
 		wg.Wait()
@@ -318,3 +540,209 @@ func runAgent(stdin io.Reader, stdout, stderr io.Writer) int {
 	fmt.Fprintln(stderr, "Agent stopped.")
 	return 0
 }
+
+// waitReady blocks until ag has warmed up and reports whether it did before
+// ctx ended.
+func waitReady(ctx context.Context, ag *agent.Agent) bool {
+	select {
+	case <-ag.Ready():
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// shutdownNotice is sent to the owners on graceful shutdown when notify_shutdown is set.
+const shutdownNotice = "PureClaw is shutting down."
+
+// defaultSoulNotice is sent to the owners at startup when notify_default_soul is set
+// and SOUL.md is still the template written by init.
+const defaultSoulNotice = "SOUL.md still holds the default persona. Edit it in the workspace to give PureClaw your own personality, limits and style."
+
+// pollOutageNotice is sent to the owners once polling has failed poll_alert_after
+// cycles in a row. Sending may itself fail if Telegram is unreachable.
+const pollOutageNotice = "PureClaw can't fetch new messages from Telegram (%d failed attempts in a row). It keeps retrying; messages sent now may be delayed."
+
+// Replaceable for testing.
+var ownerNotifyTimeout = 5 * time.Second
+
+// notifyShutdown tells every owner the bot is going offline.
+func notifyShutdown(ctx context.Context, sender agent.Sender, ownerIDs []int64) {
+	notifyOwners(ctx, sender, ownerIDs, shutdownNotice, "shutdown")
+}
+
+// notifyOwners sends text to every owner, giving up after ownerNotifyTimeout so a
+// slow or failing Telegram API never delays startup or exit. Send failures are only logged.
+func notifyOwners(ctx context.Context, sender agent.Sender, ownerIDs []int64, text, operation string) {
+	if len(ownerIDs) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, ownerNotifyTimeout)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, id := range ownerIDs {
+			if err := sender.Send(ctx, id, text); err != nil {
+				slog.Warn("failed to send owner notification",
+					"component", "pureclaw", "operation", operation, "chat_id", id, "error", err)
+			}
+		}
+	}()
+
+	select {
+	case <-done:
+		slog.Info("owner notification sent",
+			"component", "pureclaw", "operation", operation, "owners", len(ownerIDs))
+	case <-ctx.Done():
+		slog.Warn("owner notification timed out",
+			"component", "pureclaw", "operation", operation, "timeout", ownerNotifyTimeout)
+	}
+}
+
+// isDefaultSoul reports whether SOUL.md is empty or still the template written by init.
+func isDefaultSoul(soulMD string) bool {
+	soul := strings.TrimSpace(soulMD)
+	return soul == "" || soul == strings.TrimSpace(defaultSoulMD)
+}
+
+// setResponseFormat applies the configured structured output mode to LLM clients that support it.
+func setResponseFormat(c agent.LLMClient, format string) {
+	if f, ok := c.(interface{ SetResponseFormat(format string) }); ok {
+		f.SetResponseFormat(format)
+	}
+}
+
+// providerSetter is implemented by LLM clients that can target another provider.
+type providerSetter interface {
+	SetProvider(kind, baseURL string) error
+}
+
+// setProvider points LLM clients that support it at the configured provider.
+func setProvider(c agent.LLMClient, cfg *config.Config) {
+	if f, ok := c.(providerSetter); ok {
+		if err := f.SetProvider(cfg.LLMProvider, cfg.LLMBaseURL); err != nil {
+			slog.Warn("invalid LLM provider, keeping the default",
+				"component", "cmd",
+				"operation", "set_provider",
+				"error", err,
+			)
+		}
+	}
+}
+
+// secretGetter reads a secret, e.g. *vault.Vault.
+type secretGetter interface {
+	Get(key string) (string, error)
+}
+
+// llmAPIKey returns the chat completion API key for the configured provider:
+// mistral_api_key for Mistral, otherwise llm_api_key, which keyless local
+// servers may do without. The Mistral key is never sent to another provider.
+func llmAPIKey(v secretGetter, cfg *config.Config) (string, error) {
+	if isMistral(cfg) {
+		return v.Get("mistral_api_key")
+	}
+	key, err := v.Get("llm_api_key")
+	if errors.Is(err, vault.ErrKeyNotFound) {
+		return "", nil
+	}
+	return key, err
+}
+
+// isMistral reports whether Mistral, the default provider, serves chat completion.
+func isMistral(cfg *config.Config) bool {
+	return cmp.Or(cfg.LLMProvider, llm.ProviderMistral) == llm.ProviderMistral
+}
+
+// setAssistantPrefix seeds responses with an assistant prefix on LLM clients that support it.
+func setAssistantPrefix(c agent.LLMClient, prefix string) {
+	if f, ok := c.(interface{ SetAssistantPrefix(prefix string) }); ok {
+		f.SetAssistantPrefix(prefix)
+	}
+}
+
+// allowedModels returns the text models /model may switch between: the
+// configured model followed by allowed_models, without duplicates. It is empty
+// unless allowed_models is set, which leaves /model disabled.
+func allowedModels(cfg *config.Config) []string {
+	if len(cfg.AllowedModels) == 0 {
+		return nil
+	}
+	models := []string{cfg.ModelText}
+	for _, m := range cfg.AllowedModels {
+		if m != "" && !slices.Contains(models, m) {
+			models = append(models, m)
+		}
+	}
+	return models
+}
+
+// modelSaver returns the agent's SaveModel hook, which stores a /model switch
+// as model_text in config.json, or nil unless persist_model is set.
+func modelSaver(cfg *config.Config) func(string) error {
+	if !cfg.PersistModel {
+		return nil
+	}
+	return func(model string) error {
+		prev := cfg.ModelText
+		cfg.ModelText = model
+		if err := configSave(cfg, defaultConfigPath); err != nil {
+			cfg.ModelText = prev
+			return err
+		}
+		return nil
+	}
+}
+
+// setModelQuirks applies per-model tool-call formatting quirks to LLM clients that support them.
+func setModelQuirks(c agent.LLMClient, quirks map[string]config.ModelQuirks) {
+	f, ok := c.(interface {
+		SetModelQuirks(map[string]llm.ModelQuirks)
+	})
+	if !ok || quirks == nil {
+		return
+	}
+	converted := make(map[string]llm.ModelQuirks, len(quirks))
+	for model, q := range quirks {
+		converted[model] = llm.ModelQuirks(q)
+	}
+	f.SetModelQuirks(converted)
+}
+
+// setSampling applies the configured sampling parameters to LLM clients that support them.
+func setSampling(c agent.LLMClient, cfg *config.Config) {
+	if f, ok := c.(interface{ SetSampling(llm.Sampling) }); ok {
+		s := llm.Sampling{Temperature: cfg.Temperature}
+		if cfg.MaxTokens > 0 {
+			s.MaxTokens = &cfg.MaxTokens
+		}
+		f.SetSampling(s)
+	}
+}
+
+// setMaxConcurrent bounds in-flight requests on LLM clients that support it.
+func setMaxConcurrent(c agent.LLMClient, n int) {
+	if f, ok := c.(interface{ SetMaxConcurrent(n int) }); ok {
+		f.SetMaxConcurrent(n)
+	}
+}
+
+// setAttachments extends the agent response schema with reply attachments on LLM clients that support it.
+func setAttachments(c agent.LLMClient) {
+	if f, ok := c.(interface{ SetAttachments(on bool) }); ok {
+		f.SetAttachments(true)
+	}
+}
+
+// confirmationPolicy converts the configured tool confirmation rules, plus any
+// extra rules (from tools.json), into a registry policy.
+func confirmationPolicy(cfg *config.Config, extra ...tool.ConfirmationRule) tool.ConfirmationPolicy {
+	policy := tool.ConfirmationPolicy{Root: cfg.Workspace}
+	for _, c := range cfg.ToolConfirmations {
+		policy.Rules = append(policy.Rules, tool.ConfirmationRule{Tool: c.Tool, ExceptPaths: c.ExceptPaths})
+	}
+	policy.Rules = append(policy.Rules, extra...)
+	return policy
+}
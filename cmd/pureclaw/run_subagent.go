@@ -18,6 +18,7 @@ import (
 	"github.com/edouard/pureclaw/internal/llm"
 	"github.com/edouard/pureclaw/internal/memory"
 	"github.com/edouard/pureclaw/internal/platform"
+	"github.com/edouard/pureclaw/internal/subagent"
 	"github.com/edouard/pureclaw/internal/tool"
 	"github.com/edouard/pureclaw/internal/workspace"
 )
@@ -28,9 +29,11 @@ var (
 		return signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
 	}
 	subAgentWorkspaceLoad = workspace.Load
-	subAgentNewLLMClient  = func(apiKey, model string) agent.LLMClient { return llm.NewClient(apiKey, model) }
-	subAgentNewMemory     = func(root string) *memory.Memory { return memory.New(root) }
-	subAgentOsStat        = os.Stat
+	subAgentNewLLMClient  = func(apiKey, model string, timeout time.Duration) agent.LLMClient {
+		return llm.NewClientWithTimeout(apiKey, model, timeout)
+	}
+	subAgentNewMemory = func(root string) *memory.Memory { return memory.New(root) }
+	subAgentOsStat    = os.Stat
 )
 
 // pathGuardedHandler wraps a tool handler to validate the "path" argument
@@ -132,10 +135,10 @@ func runSubAgentCmd(workspacePath, configPath, vaultPath string, stdin io.Reader
 		return 1
 	}
 
-	// 5. Get Mistral API key (sub-agent needs LLM access).
-	mistralKey, err := v.Get("mistral_api_key")
+	// 5. Get the LLM API key (sub-agent needs LLM access).
+	llmKey, err := llmAPIKey(v, cfg)
 	if err != nil {
-		slog.Error("failed to get mistral API key",
+		slog.Error("failed to get LLM API key",
 			"component", "cmd", "operation", "run_subagent",
 			"error", err)
 		fmt.Fprintf(stderr, "Error: %v\n", err)
@@ -153,7 +156,14 @@ func runSubAgentCmd(workspacePath, configPath, vaultPath string, stdin io.Reader
 	}
 
 	// 7. Create LLM client.
-	llmClient := subAgentNewLLMClient(mistralKey, cfg.ModelText)
+	timeouts := cfg.ResolvedTimeouts()
+	llmClient := subAgentNewLLMClient(llmKey, cfg.ModelText, timeouts.LLM.Duration)
+	setProvider(llmClient, cfg)
+	setResponseFormat(llmClient, cfg.ResponseFormat)
+	setAssistantPrefix(llmClient, cfg.AssistantPrefix)
+	setModelQuirks(llmClient, cfg.ModelQuirks)
+	setSampling(llmClient, cfg)
+	setMaxConcurrent(llmClient, cfg.LLMMaxConcurrent)
 
 	// 8. Create memory writer (sub-agent logs to its own memory/ directory).
 	mem := subAgentNewMemory(workspacePath)
@@ -172,6 +182,15 @@ func runSubAgentCmd(workspacePath, configPath, vaultPath string, stdin io.Reader
 		}
 	}
 
+	redactor, err := platform.NewRedactor(secrets, cfg.RedactPatterns)
+	if err != nil {
+		slog.Error("invalid redact pattern",
+			"component", "cmd", "operation", "run_subagent",
+			"error", err)
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+
 	registry := tool.NewRegistry()
 
 	// Apply path guard to all file-operation tools, restricting them to the
@@ -180,7 +199,7 @@ func runSubAgentCmd(workspacePath, configPath, vaultPath string, stdin io.Reader
 	readFile.Handler = pathGuardedHandler(workspacePath, readFile.Handler)
 	registry.Register(readFile)
 
-	writeFile := tool.NewWriteFile()
+	writeFile := tool.NewWriteFile(cfg.AllowedWriteExtensions...)
 	writeFile.Handler = pathGuardedHandler(workspacePath, writeFile.Handler)
 	registry.Register(writeFile)
 
@@ -188,7 +207,7 @@ func runSubAgentCmd(workspacePath, configPath, vaultPath string, stdin io.Reader
 	listDir.Handler = pathGuardedHandler(workspacePath, listDir.Handler)
 	registry.Register(listDir)
 
-	registry.Register(tool.NewExecCommand(secrets))
+	registry.Register(tool.NewExecCommandWithTimeout(secrets, timeouts.Tool.Duration))
 	// Deliberately NOT registering spawn_agent (depth=1 enforcement, FR38)
 	// Deliberately NOT registering reload_workspace (no hot-reload for sub-agents)
 
@@ -196,11 +215,11 @@ func runSubAgentCmd(workspacePath, configPath, vaultPath string, stdin io.Reader
 		"component", "cmd", "operation", "run_subagent",
 		"tool_count", len(registry.Definitions()))
 
-	// 10. Determine timeout.
-	timeout := cfg.SubAgentTimeout.Duration
-	if timeout == 0 {
-		timeout = 5 * time.Minute // Default
-	}
+	// 10. Determine timeout (timeouts.sub_agent, then sub_agent_timeout, then 5m).
+	timeout := timeouts.SubAgent.Duration
+
+	// 10a. Stop working early enough to write a partial result.md before the parent's kill.
+	deadline := subAgentDeadline(timeout)
 
 	// 11. Create context with timeout and signal handling.
 	ctx, stop := subAgentSignalContext()
@@ -215,6 +234,9 @@ func runSubAgentCmd(workspacePath, configPath, vaultPath string, stdin io.Reader
 		Sender:       nil, // No Telegram
 		Memory:       mem,
 		ToolExecutor: registry,
+		Redactor:     redactor,
+		// Stop before the parent's kill and keep the partial work.
+		SubAgentDeadline: deadline,
 		// FileChanges: nil — no file watcher
 		// HeartbeatTick: nil — no heartbeat
 		// Heartbeat: nil
@@ -225,7 +247,7 @@ func runSubAgentCmd(workspacePath, configPath, vaultPath string, stdin io.Reader
 	// 13. Run sub-agent in autonomous mode.
 	slog.Info("sub-agent started",
 		"component", "cmd", "operation", "run_subagent",
-		"workspace", workspacePath, "timeout", timeout)
+		"workspace", workspacePath, "timeout", timeout, "deadline", deadline)
 
 	if err := ag.RunSubAgent(ctx); err != nil {
 		slog.Error("sub-agent exited with error",
@@ -240,3 +262,19 @@ func runSubAgentCmd(workspacePath, configPath, vaultPath string, stdin io.Reader
 		"workspace", workspacePath)
 	return 0
 }
+
+// subAgentDeadline returns the working deadline passed by the parent in
+// subagent.DeadlineEnv, or one derived from timeout when it is missing or
+// not shorter than timeout.
+func subAgentDeadline(timeout time.Duration) time.Duration {
+	if v := os.Getenv(subagent.DeadlineEnv); v != "" {
+		d, err := time.ParseDuration(v)
+		if err == nil && d > 0 && d < timeout {
+			return d
+		}
+		slog.Warn("invalid sub-agent deadline, deriving it from the timeout",
+			"component", "cmd", "operation", "run_subagent",
+			"value", v)
+	}
+	return subagent.ResultDeadline(timeout)
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/edouard/pureclaw/internal/subagent"
+)
+
+func TestSubAgentDeadline(t *testing.T) {
+	tests := []struct {
+		env  string
+		want time.Duration
+	}{
+		{"", 4*time.Minute + 30*time.Second},
+		{"2m", 2 * time.Minute},
+		{"10m", 4*time.Minute + 30*time.Second}, // not shorter than the timeout
+		{"-1s", 4*time.Minute + 30*time.Second},
+		{"soon", 4*time.Minute + 30*time.Second},
+	}
+	for _, tt := range tests {
+		t.Setenv(subagent.DeadlineEnv, tt.env)
+		if got := subAgentDeadline(5 * time.Minute); got != tt.want {
+			t.Errorf("%s=%q: subAgentDeadline = %s, want %s", subagent.DeadlineEnv, tt.env, got, tt.want)
+		}
+	}
+}
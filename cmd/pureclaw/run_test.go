@@ -4,10 +4,14 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -23,6 +27,7 @@ import (
 func saveRunVars(t *testing.T) {
 	t.Helper()
 	origConfigLoad := configLoad
+	origToolsLoad := toolsLoad
 	origVaultLoadSalt := vaultLoadSalt
 	origVaultDeriveKey := vaultDeriveKey
 	origVaultOpenFn := vaultOpenFn
@@ -36,9 +41,13 @@ func saveRunVars(t *testing.T) {
 	origNewAgent := newAgent
 	origSignalContext := signalContext
 	origRunPollerFn := runPollerFn
-	origOsExecutable := osExecutable
+	origNewWebhook := newWebhook
+	origRunWebhookFn := runWebhookFn
+	origResolveSubAgentBinary := resolveSubAgentBinary
+	origWarmUpAgent := warmUpAgent
 	t.Cleanup(func() {
 		configLoad = origConfigLoad
+		toolsLoad = origToolsLoad
 		vaultLoadSalt = origVaultLoadSalt
 		vaultDeriveKey = origVaultDeriveKey
 		vaultOpenFn = origVaultOpenFn
@@ -52,7 +61,10 @@ func saveRunVars(t *testing.T) {
 		newAgent = origNewAgent
 		signalContext = origSignalContext
 		runPollerFn = origRunPollerFn
-		osExecutable = origOsExecutable
+		newWebhook = origNewWebhook
+		runWebhookFn = origRunWebhookFn
+		resolveSubAgentBinary = origResolveSubAgentBinary
+		warmUpAgent = origWarmUpAgent
 	})
 }
 
@@ -96,7 +108,7 @@ func setupHappyPath(t *testing.T, dir string) {
 	// Create real config and vault files.
 	cfg := &config.Config{
 		Workspace:          dir + "/workspace",
-		ModelText:           "test-model",
+		ModelText:          "test-model",
 		TelegramAllowedIDs: []int64{123},
 	}
 	if err := config.Save(cfg, dir+"/config.json"); err != nil {
@@ -111,8 +123,10 @@ func setupHappyPath(t *testing.T, dir string) {
 	os.WriteFile(wsDir+"/SOUL.md", []byte("# Soul"), 0644)
 
 	// Replace clients with stubs that don't make network calls.
-	newLLMClient = func(apiKey, model string) agent.LLMClient { return &stubLLM{} }
-	newAudioClient = func(apiKey, model string) agent.Transcriber { return llm.NewClient(apiKey, model) }
+	newLLMClient = func(apiKey, model string, timeout time.Duration) agent.LLMClient { return &stubLLM{} }
+	newAudioClient = func(apiKey, model string, timeout time.Duration) agent.Transcriber {
+		return llm.NewClient(apiKey, model)
+	}
 	newSender = func(client *telegram.Client) agent.Sender { return &stubSender{} }
 	newMemory = func(root string) *memory.Memory { return memory.New(root) }
 }
@@ -226,6 +240,47 @@ func TestRunAgent_MistralKeyMissing(t *testing.T) {
 	}
 }
 
+func TestRunAgent_MistralKeyOptionalForOtherProviders(t *testing.T) {
+	dir := t.TempDir()
+	chdir(t, dir)
+	setupHappyPath(t, dir)
+	createTestVault(t, dir, "test-pass", map[string]string{
+		"telegram_bot_token": "bot-test",
+	})
+	configLoad = func(path string) (*config.Config, error) {
+		cfg, err := config.Load(path)
+		if err != nil {
+			return nil, err
+		}
+		cfg.LLMProvider = llm.ProviderOllama
+		return cfg, nil
+	}
+	signalContext = func() (context.Context, context.CancelFunc) {
+		return context.WithTimeout(context.Background(), 100*time.Millisecond)
+	}
+	runPollerFn = func(ctx context.Context, p *telegram.Poller, ch chan<- telegram.TelegramMessage) {
+		<-ctx.Done()
+	}
+	audioCreated := false
+	newAudioClient = func(apiKey, model string, timeout time.Duration) agent.Transcriber {
+		audioCreated = true
+		return nil
+	}
+	var transcriber agent.Transcriber
+	newAgent = func(cfg agent.NewAgentConfig) *agent.Agent {
+		transcriber = cfg.Transcriber
+		return agent.New(cfg)
+	}
+
+	var stderr bytes.Buffer
+	if code := runAgent(strings.NewReader("test-pass\n"), io.Discard, &stderr); code != 0 {
+		t.Fatalf("exit code = %d; stderr: %s", code, stderr.String())
+	}
+	if audioCreated || transcriber != nil {
+		t.Error("voice transcription enabled without a Mistral key, want it disabled")
+	}
+}
+
 func TestRunAgent_TelegramTokenMissing(t *testing.T) {
 	dir := t.TempDir()
 	chdir(t, dir)
@@ -301,6 +356,127 @@ func TestRunAgent_FullStartup(t *testing.T) {
 	}
 }
 
+func TestRunAgent_WarmUpBeforePolling(t *testing.T) {
+	dir := t.TempDir()
+	chdir(t, dir)
+	setupHappyPath(t, dir)
+
+	signalContext = func() (context.Context, context.CancelFunc) {
+		return context.WithTimeout(context.Background(), 2*time.Second)
+	}
+	var (
+		mu     sync.Mutex
+		events []string
+	)
+	record := func(event string) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+	}
+	warmUpAgent = func(ctx context.Context, ag *agent.Agent) error {
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("warm-up context has no deadline")
+		}
+		record("warm-up start")
+		time.Sleep(100 * time.Millisecond) // leave time for an early poller to show up
+		err := ag.WarmUp(ctx)
+		record("warm-up done")
+		return err
+	}
+	var agentMD string
+	runPollerFn = func(ctx context.Context, p *telegram.Poller, ch chan<- telegram.TelegramMessage) {
+		data, _ := os.ReadFile(filepath.Join(dir, "workspace", "AGENT.md"))
+		agentMD = string(data)
+		record("poll")
+	}
+
+	var stderr bytes.Buffer
+	if code := runAgent(strings.NewReader("test-pass\n"), io.Discard, &stderr); code != 0 {
+		t.Fatalf("exit code = %d; stderr: %s", code, stderr.String())
+	}
+	if want := []string{"warm-up start", "warm-up done", "poll"}; !slices.Equal(events, want) {
+		t.Errorf("events = %v, want %v", events, want)
+	}
+	if !strings.Contains(agentMD, "## Environment") {
+		t.Errorf("AGENT.md when polling started = %q, want the introspection done", agentMD)
+	}
+}
+
+// useWebhookMode makes the next runAgent load cfg in webhook mode.
+func useWebhookMode(t *testing.T, dir string) {
+	t.Helper()
+	cfg, err := config.Load(dir + "/config.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.TelegramMode = config.TelegramModeWebhook
+	cfg.WebhookURL = "https://bot.example.com/telegram"
+	cfg.WebhookListen = "127.0.0.1:9443"
+	if err := config.Save(cfg, dir+"/config.json"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRunAgent_WebhookMode(t *testing.T) {
+	dir := t.TempDir()
+	chdir(t, dir)
+	setupHappyPath(t, dir)
+	useWebhookMode(t, dir)
+
+	signalContext = func() (context.Context, context.CancelFunc) {
+		return context.WithTimeout(context.Background(), 100*time.Millisecond)
+	}
+	var gotURL, gotListen string
+	newWebhook = func(client *telegram.Client, allowedIDs []int64, url, listenAddr string) *telegram.Webhook {
+		gotURL, gotListen = url, listenAddr
+		return telegram.NewWebhook(client, allowedIDs, url, listenAddr)
+	}
+	var webhookRan atomic.Bool
+	runWebhookFn = func(ctx context.Context, w *telegram.Webhook, ch chan<- telegram.TelegramMessage) error {
+		webhookRan.Store(true)
+		<-ctx.Done()
+		return nil
+	}
+	runPollerFn = func(ctx context.Context, p *telegram.Poller, ch chan<- telegram.TelegramMessage) {
+		t.Error("poller started in webhook mode")
+	}
+
+	var stderr bytes.Buffer
+	if code := runAgent(strings.NewReader("test-pass\n"), io.Discard, &stderr); code != 0 {
+		t.Fatalf("exit code = %d; stderr: %s", code, stderr.String())
+	}
+	if !webhookRan.Load() {
+		t.Error("webhook was not started")
+	}
+	if gotURL != "https://bot.example.com/telegram" || gotListen != "127.0.0.1:9443" {
+		t.Errorf("webhook url, listen = %q, %q; want the configured ones", gotURL, gotListen)
+	}
+}
+
+func TestRunAgent_WebhookFailureStops(t *testing.T) {
+	dir := t.TempDir()
+	chdir(t, dir)
+	setupHappyPath(t, dir)
+	useWebhookMode(t, dir)
+
+	// Only the webhook failure can end the run before the test times out.
+	signalContext = func() (context.Context, context.CancelFunc) {
+		return context.WithTimeout(context.Background(), 10*time.Second)
+	}
+	runWebhookFn = func(ctx context.Context, w *telegram.Webhook, ch chan<- telegram.TelegramMessage) error {
+		return errors.New("listen tcp: address already in use")
+	}
+
+	start := time.Now()
+	var stderr bytes.Buffer
+	if code := runAgent(strings.NewReader("test-pass\n"), io.Discard, &stderr); code != 0 {
+		t.Fatalf("exit code = %d; stderr: %s", code, stderr.String())
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("run lasted %v after the webhook failed, want a prompt stop", elapsed)
+	}
+}
+
 func TestRunAgent_GracefulShutdown(t *testing.T) {
 	dir := t.TempDir()
 	chdir(t, dir)
@@ -445,3 +621,424 @@ func TestRunAgent_ShutdownGracefulCancel(t *testing.T) {
 		t.Fatal("runAgent did not complete within 35 seconds")
 	}
 }
+
+func TestConfirmationPolicy(t *testing.T) {
+	cfg := &config.Config{
+		Workspace: "/ws",
+		ToolConfirmations: []config.ToolConfirmation{
+			{Tool: "exec_command"},
+			{Tool: "write_file", ExceptPaths: []string{"memory"}},
+		},
+	}
+	policy := confirmationPolicy(cfg)
+	if policy.Root != "/ws" {
+		t.Errorf("Root = %q, want %q", policy.Root, "/ws")
+	}
+	if len(policy.Rules) != 2 {
+		t.Fatalf("len(Rules) = %d, want 2", len(policy.Rules))
+	}
+	if policy.Rules[1].Tool != "write_file" || len(policy.Rules[1].ExceptPaths) != 1 {
+		t.Errorf("Rules[1] = %+v, want write_file with one exempt path", policy.Rules[1])
+	}
+}
+
+// capturedTimeouts records the timeouts runAgent hands to each component.
+type capturedTimeouts struct {
+	llm, audio, tgPoll time.Duration
+	pollSeconds        int
+	message, download  time.Duration
+}
+
+// runWithTimeouts stubs the client constructors and agent factory to capture
+// their timeouts, then runs a short startup with the given config timeouts.
+func runWithTimeouts(t *testing.T, timeouts config.Timeouts) capturedTimeouts {
+	t.Helper()
+	dir := t.TempDir()
+	chdir(t, dir)
+	setupHappyPath(t, dir)
+
+	var got capturedTimeouts
+	configLoad = func(path string) (*config.Config, error) {
+		cfg, err := config.Load(path)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Timeouts = timeouts
+		return cfg, nil
+	}
+	newLLMClient = func(apiKey, model string, timeout time.Duration) agent.LLMClient {
+		got.llm = timeout
+		return &stubLLM{}
+	}
+	newAudioClient = func(apiKey, model string, timeout time.Duration) agent.Transcriber {
+		got.audio = timeout
+		return llm.NewClient(apiKey, model)
+	}
+	newTGClient = func(token string, pollTimeout time.Duration) *telegram.Client {
+		got.tgPoll = pollTimeout
+		return telegram.NewClient(token)
+	}
+	newPoller = func(client *telegram.Client, allowedIDs []int64, timeout int) *telegram.Poller {
+		got.pollSeconds = timeout
+		return telegram.NewPoller(client, allowedIDs, timeout)
+	}
+	newAgent = func(cfg agent.NewAgentConfig) *agent.Agent {
+		got.message = cfg.MessageTimeout
+		got.download = cfg.DownloadTimeout
+		return agent.New(cfg)
+	}
+	signalContext = func() (context.Context, context.CancelFunc) {
+		return context.WithTimeout(context.Background(), 50*time.Millisecond)
+	}
+	runPollerFn = func(ctx context.Context, p *telegram.Poller, ch chan<- telegram.TelegramMessage) {
+		<-ctx.Done()
+	}
+
+	var stderr bytes.Buffer
+	if code := runAgent(strings.NewReader("test-pass\n"), io.Discard, &stderr); code != 0 {
+		t.Fatalf("exit code = %d; stderr: %s", code, stderr.String())
+	}
+	return got
+}
+
+func TestRunAgent_ConfiguredTimeouts(t *testing.T) {
+	got := runWithTimeouts(t, config.Timeouts{
+		LLM:      config.Duration{Duration: 45 * time.Second},
+		Poll:     config.Duration{Duration: 20 * time.Second},
+		Download: config.Duration{Duration: 15 * time.Second},
+		Message:  config.Duration{Duration: 2 * time.Minute},
+	})
+
+	want := capturedTimeouts{
+		llm:         45 * time.Second,
+		audio:       45 * time.Second,
+		tgPoll:      20 * time.Second,
+		pollSeconds: 20,
+		message:     2 * time.Minute,
+		download:    15 * time.Second,
+	}
+	if got != want {
+		t.Errorf("timeouts = %+v, want %+v", got, want)
+	}
+}
+
+func TestRunAgent_DefaultTimeouts(t *testing.T) {
+	got := runWithTimeouts(t, config.Timeouts{})
+
+	want := capturedTimeouts{
+		llm:         config.DefaultLLMTimeout,
+		audio:       config.DefaultLLMTimeout,
+		tgPoll:      config.DefaultPollTimeout,
+		pollSeconds: 30,
+		message:     config.DefaultMessageTimeout,
+		download:    config.DefaultDownloadTimeout,
+	}
+	if got != want {
+		t.Errorf("timeouts = %+v, want %+v", got, want)
+	}
+}
+
+func TestRunAgent_SubAgentBinaryUnresolved(t *testing.T) {
+	dir := t.TempDir()
+	chdir(t, dir)
+	setupHappyPath(t, dir)
+
+	var gotConfigured string
+	configLoad = func(path string) (*config.Config, error) {
+		cfg, err := config.Load(path)
+		if err != nil {
+			return nil, err
+		}
+		cfg.SubAgentBinary = "/opt/pureclaw/bin/pureclaw"
+		return cfg, nil
+	}
+	resolveSubAgentBinary = func(configured string) (string, error) {
+		gotConfigured = configured
+		return "", errors.New("no usable pureclaw binary")
+	}
+
+	var stderr bytes.Buffer
+	if code := runAgent(strings.NewReader("test-pass\n"), io.Discard, &stderr); code != 1 {
+		t.Fatalf("exit code = %d, want 1", code)
+	}
+	if gotConfigured != "/opt/pureclaw/bin/pureclaw" {
+		t.Errorf("resolver got %q, want configured sub_agent_binary", gotConfigured)
+	}
+	if !strings.Contains(stderr.String(), "no usable pureclaw binary") {
+		t.Errorf("stderr = %q", stderr.String())
+	}
+}
+
+// recordingSender records sent messages and can fail or block on Send.
+type recordingSender struct {
+	mu      sync.Mutex
+	sent    []sentText
+	err     error
+	release chan struct{} // if set, Send blocks until it is closed, ignoring ctx
+}
+
+type sentText struct {
+	chatID int64
+	text   string
+}
+
+func (s *recordingSender) Send(ctx context.Context, chatID int64, text string) error {
+	s.mu.Lock()
+	s.sent = append(s.sent, sentText{chatID, text})
+	s.mu.Unlock()
+	if s.release != nil {
+		<-s.release
+	}
+	return s.err
+}
+
+func (s *recordingSender) React(ctx context.Context, chatID, messageID int64, emoji string) error {
+	return nil
+}
+
+func (s *recordingSender) messages() []sentText {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]sentText(nil), s.sent...)
+}
+
+func TestNotifyShutdown_SendsToOwners(t *testing.T) {
+	sender := &recordingSender{}
+	notifyShutdown(context.Background(), sender, []int64{1, 2})
+
+	got := sender.messages()
+	want := []sentText{{1, shutdownNotice}, {2, shutdownNotice}}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("sent = %+v, want %+v", got, want)
+	}
+}
+
+func TestNotifyShutdown_SendErrorDoesNotStop(t *testing.T) {
+	sender := &recordingSender{err: errors.New("telegram down")}
+	notifyShutdown(context.Background(), sender, []int64{1, 2})
+
+	if got := sender.messages(); len(got) != 2 {
+		t.Errorf("expected a send attempt per owner, got %+v", got)
+	}
+}
+
+func TestNotifyShutdown_BoundedByTimeout(t *testing.T) {
+	orig := ownerNotifyTimeout
+	t.Cleanup(func() { ownerNotifyTimeout = orig })
+	ownerNotifyTimeout = 50 * time.Millisecond
+
+	sender := &recordingSender{release: make(chan struct{})}
+	t.Cleanup(func() { close(sender.release) })
+	start := time.Now()
+	notifyShutdown(context.Background(), sender, []int64{1})
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("notifyShutdown took %v, want it bounded by the %v grace window", elapsed, ownerNotifyTimeout)
+	}
+	if got := sender.messages(); len(got) != 1 {
+		t.Errorf("expected the notification to be attempted, got %+v", got)
+	}
+}
+
+func TestRunAgent_NotifyShutdown(t *testing.T) {
+	for _, enabled := range []bool{true, false} {
+		t.Run(fmt.Sprintf("enabled=%v", enabled), func(t *testing.T) {
+			dir := t.TempDir()
+			chdir(t, dir)
+			setupHappyPath(t, dir)
+
+			configLoad = func(path string) (*config.Config, error) {
+				cfg, err := config.Load(path)
+				if err != nil {
+					return nil, err
+				}
+				cfg.NotifyShutdown = enabled
+				return cfg, nil
+			}
+			sender := &recordingSender{err: errors.New("telegram down")}
+			newSender = func(client *telegram.Client) agent.Sender { return sender }
+			signalContext = func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 50*time.Millisecond)
+			}
+			runPollerFn = func(ctx context.Context, p *telegram.Poller, ch chan<- telegram.TelegramMessage) {
+				<-ctx.Done()
+			}
+
+			var stderr bytes.Buffer
+			if code := runAgent(strings.NewReader("test-pass\n"), io.Discard, &stderr); code != 0 {
+				t.Fatalf("exit code = %d; stderr: %s", code, stderr.String())
+			}
+
+			var notices int
+			for _, m := range sender.messages() {
+				if m.text == shutdownNotice && m.chatID == 123 {
+					notices++
+				}
+			}
+			if want := map[bool]int{true: 1, false: 0}[enabled]; notices != want {
+				t.Errorf("shutdown notices = %d, want %d", notices, want)
+			}
+		})
+	}
+}
+
+func TestIsDefaultSoul(t *testing.T) {
+	tests := []struct {
+		name string
+		soul string
+		want bool
+	}{
+		{"default", defaultSoulMD, true},
+		{"default with trailing whitespace", defaultSoulMD + "\n\n", true},
+		{"empty", "  \n", true},
+		{"customized", "# Soul\n\nYou are a terse sysadmin named Claw.", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isDefaultSoul(tt.soul); got != tt.want {
+				t.Errorf("isDefaultSoul() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunAgent_DefaultSoulWarning(t *testing.T) {
+	tests := []struct {
+		name       string
+		soul       string
+		notify     bool
+		wantWarn   bool
+		wantNotice bool
+	}{
+		{"default soul", defaultSoulMD, false, true, false},
+		{"default soul notifies owner", defaultSoulMD, true, true, true},
+		{"customized soul", "# Soul\n\nCustom persona.", true, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			chdir(t, dir)
+			setupHappyPath(t, dir)
+			os.WriteFile(dir+"/workspace/SOUL.md", []byte(tt.soul), 0644)
+
+			configLoad = func(path string) (*config.Config, error) {
+				cfg, err := config.Load(path)
+				if err != nil {
+					return nil, err
+				}
+				cfg.NotifyDefaultSoul = tt.notify
+				return cfg, nil
+			}
+			sender := &recordingSender{}
+			newSender = func(client *telegram.Client) agent.Sender { return sender }
+			signalContext = func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 50*time.Millisecond)
+			}
+			runPollerFn = func(ctx context.Context, p *telegram.Poller, ch chan<- telegram.TelegramMessage) {
+				<-ctx.Done()
+			}
+
+			var stderr bytes.Buffer
+			if code := runAgent(strings.NewReader("test-pass\n"), io.Discard, &stderr); code != 0 {
+				t.Fatalf("exit code = %d; stderr: %s", code, stderr.String())
+			}
+
+			if got := strings.Contains(stderr.String(), "SOUL.md is empty or still the default"); got != tt.wantWarn {
+				t.Errorf("warning shown = %v, want %v; stderr: %s", got, tt.wantWarn, stderr.String())
+			}
+			var notices int
+			for _, m := range sender.messages() {
+				if m.text == defaultSoulNotice && m.chatID == 123 {
+					notices++
+				}
+			}
+			if got := notices == 1; got != tt.wantNotice {
+				t.Errorf("owner notices = %d, want notice %v", notices, tt.wantNotice)
+			}
+		})
+	}
+}
+
+func TestAllowedModels(t *testing.T) {
+	cfg := &config.Config{ModelText: "large"}
+	if got := allowedModels(cfg); got != nil {
+		t.Errorf("allowedModels without allowed_models = %v, want nil", got)
+	}
+	cfg.AllowedModels = []string{"small", "large", "", "small", "medium"}
+	if got, want := allowedModels(cfg), []string{"large", "small", "medium"}; !slices.Equal(got, want) {
+		t.Errorf("allowedModels = %v, want %v", got, want)
+	}
+}
+
+func TestModelSaver(t *testing.T) {
+	if modelSaver(&config.Config{}) != nil {
+		t.Error("modelSaver without persist_model should be nil")
+	}
+
+	original := configSave
+	defer func() { configSave = original }()
+	var savedModel, savedPath string
+	var saveErr error
+	configSave = func(cfg *config.Config, path string) error {
+		savedModel, savedPath = cfg.ModelText, path
+		return saveErr
+	}
+
+	cfg := &config.Config{ModelText: "large", PersistModel: true}
+	save := modelSaver(cfg)
+	if err := save("small"); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if savedModel != "small" || savedPath != defaultConfigPath || cfg.ModelText != "small" {
+		t.Errorf("saved %q to %q, cfg.ModelText = %q; want small saved to %s", savedModel, savedPath, cfg.ModelText, defaultConfigPath)
+	}
+
+	saveErr = errors.New("disk full")
+	if err := save("medium"); err == nil {
+		t.Fatal("expected the save error")
+	}
+	if cfg.ModelText != "small" {
+		t.Errorf("cfg.ModelText = %q after a failed save, want small", cfg.ModelText)
+	}
+}
+
+// mapSecrets is a secretGetter over a map.
+type mapSecrets map[string]string
+
+func (m mapSecrets) Get(key string) (string, error) {
+	v, ok := m[key]
+	if !ok {
+		return "", vault.ErrKeyNotFound
+	}
+	return v, nil
+}
+
+func TestLLMAPIKey(t *testing.T) {
+	both := mapSecrets{"mistral_api_key": "sk-mistral", "llm_api_key": "sk-local"}
+	tests := []struct {
+		provider string
+		secrets  mapSecrets
+		want     string
+		wantErr  bool
+	}{
+		{"", both, "sk-mistral", false},
+		{llm.ProviderMistral, mapSecrets{}, "", true},
+		{llm.ProviderOpenAI, both, "sk-local", false},
+		{llm.ProviderOllama, mapSecrets{"mistral_api_key": "sk-mistral"}, "", false},
+	}
+	for _, tt := range tests {
+		got, err := llmAPIKey(tt.secrets, &config.Config{LLMProvider: tt.provider})
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("llmAPIKey(%q) = %q, %v; want %q, error %v", tt.provider, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestSetProvider(t *testing.T) {
+	c := llm.NewClient("", "llama3.2")
+	setProvider(c, &config.Config{LLMProvider: llm.ProviderOllama, LLMBaseURL: "http://pi.local:11434/v1"})
+	if info := c.Info(); info.Provider != llm.ProviderOllama || info.BaseURL != "http://pi.local:11434/v1/" || info.ResponseFormat != llm.ResponseFormatJSONObject {
+		t.Errorf("info = %+v, want the ollama provider at the configured URL", info)
+	}
+	setProvider(&stubLLM{}, &config.Config{LLMProvider: llm.ProviderOpenAI}) // clients without providers are left alone
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/edouard/pureclaw/internal/agent"
+	"github.com/edouard/pureclaw/internal/telegram"
+	"github.com/edouard/pureclaw/internal/tool"
+)
+
+// selftestPrompt asks for a list_dir call on the workspace root (%s) and a reply built from its result.
+const selftestPrompt = "This is an automated self-test of your tool loop. Call the list_dir tool with path %q, " +
+	"then reply with a message listing the file names it returned."
+
+// selftestExpectedFile is a file every workspace has, so a reply built from list_dir names it.
+const selftestExpectedFile = "AGENT.md"
+
+// toolCallRecorder is an agent.ToolExecutor that records the tool calls it runs.
+type toolCallRecorder struct {
+	agent.ToolExecutor
+	mu    sync.Mutex
+	calls []recordedCall
+}
+
+type recordedCall struct {
+	name    string
+	success bool
+}
+
+func (r *toolCallRecorder) Execute(ctx context.Context, name string, args json.RawMessage) tool.ToolResult {
+	result := r.ToolExecutor.Execute(ctx, name, args)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, recordedCall{name: name, success: result.Success})
+	return result
+}
+
+// replyRecorder prints replies like stdoutSender and keeps them for inspection.
+type replyRecorder struct {
+	stdoutSender
+	replies []string
+}
+
+func (s *replyRecorder) Send(ctx context.Context, chatID int64, text string) error {
+	s.replies = append(s.replies, text)
+	return s.stdoutSender.Send(ctx, chatID, text)
+}
+
+// runSelftest checks the tool loop end to end before a deployment is relied on:
+// a canned prompt asks the real LLM to call list_dir on the workspace and report
+// what it found. It passes if the tool call succeeded and the reply names a
+// workspace file. Nothing is sent to Telegram or written to memory.
+func runSelftest(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	configPath, vaultPath, err := parseSelftestArgs(args)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		fmt.Fprintln(stderr, "Usage: pureclaw selftest [--config <path>] [--vault <path>]")
+		return 1
+	}
+
+	cfg, err := configLoad(configPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	llmKey, err := loadLLMKey(vaultPath, cfg, stdin, stderr)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	ws, err := workspaceLoad(cfg.Workspace, cfg.MaxSkills)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	registry := tool.NewRegistry()
+	registry.Register(tool.NewListDir())
+	executor := &toolCallRecorder{ToolExecutor: registry}
+	sender := &replyRecorder{stdoutSender: stdoutSender{w: stdout}}
+
+	timeouts := cfg.ResolvedTimeouts()
+	llmClient := newLLMClient(llmKey, cfg.ModelText, timeouts.LLM.Duration)
+	setProvider(llmClient, cfg)
+	setResponseFormat(llmClient, cfg.ResponseFormat)
+	setAssistantPrefix(llmClient, cfg.AssistantPrefix)
+	setModelQuirks(llmClient, cfg.ModelQuirks)
+	setSampling(llmClient, cfg)
+	ag := newAgent(agent.NewAgentConfig{
+		Workspace:      ws,
+		LLM:            llmClient,
+		Sender:         sender,
+		ToolExecutor:   executor,
+		RetryBudget:    cfg.RetryBudget,
+		MessageTimeout: timeouts.Message.Duration,
+	})
+
+	ctx, stop := signalContext()
+	defer stop()
+
+	slog.Info("running self-test", "component", "cmd", "operation", "selftest", "model", cfg.ModelText)
+	prompt := fmt.Sprintf(selftestPrompt, cfg.Workspace)
+	fmt.Fprintf(stdout, "> %s\n", prompt)
+	ag.HandleMessage(ctx, telegram.TelegramMessage{
+		Message: telegram.Message{MessageID: 1, Text: prompt},
+	})
+
+	toolOK := slices.Contains(executor.calls, recordedCall{name: "list_dir", success: true})
+	replyOK := slices.ContainsFunc(sender.replies, func(r string) bool { return strings.Contains(r, selftestExpectedFile) })
+	report := func(ok bool, check string) {
+		status := "PASS"
+		if !ok {
+			status = "FAIL"
+		}
+		fmt.Fprintf(stdout, "%s  %s\n", status, check)
+	}
+	report(toolOK, "tool call: list_dir ran successfully")
+	report(replyOK, "final message: reply names "+selftestExpectedFile)
+
+	if !toolOK || !replyOK {
+		slog.Warn("self-test failed", "component", "cmd", "operation", "selftest",
+			"tool_calls", len(executor.calls), "replies", len(sender.replies))
+		fmt.Fprintln(stdout, "Self-test failed")
+		return 1
+	}
+	fmt.Fprintln(stdout, "Self-test passed")
+	return 0
+}
+
+// parseSelftestArgs extracts the optional --config/--vault paths.
+func parseSelftestArgs(args []string) (configPath, vaultPath string, err error) {
+	configPath, vaultPath = defaultConfigPath, defaultVaultPath
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--config", "--vault":
+			if i+1 >= len(args) {
+				return "", "", fmt.Errorf("%s requires a path argument", args[i])
+			}
+			if args[i] == "--config" {
+				configPath = args[i+1]
+			} else {
+				vaultPath = args[i+1]
+			}
+			i++
+		default:
+			return "", "", fmt.Errorf("unexpected argument %q", args[i])
+		}
+	}
+	return configPath, vaultPath, nil
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/edouard/pureclaw/internal/agent"
+	"github.com/edouard/pureclaw/internal/llm"
+)
+
+// scriptedLLM answers each request with the next response in its script.
+type scriptedLLM struct {
+	responses []llm.Message
+	calls     int
+}
+
+func (s *scriptedLLM) ChatCompletionWithRetry(ctx context.Context, messages []llm.Message, tools []llm.Tool) (*llm.ChatResponse, error) {
+	msg := s.responses[min(s.calls, len(s.responses)-1)]
+	s.calls++
+	reason := "stop"
+	if len(msg.ToolCalls) > 0 {
+		reason = "tool_calls"
+	}
+	return &llm.ChatResponse{Choices: []llm.Choice{{Message: msg, FinishReason: reason}}}, nil
+}
+
+func listDirCall(path string) llm.Message {
+	args, _ := json.Marshal(map[string]string{"path": path})
+	return llm.Message{
+		Role: "assistant",
+		ToolCalls: []llm.ToolCall{{
+			ID:       "call_1",
+			Type:     "function",
+			Function: llm.ToolCallFunction{Name: "list_dir", Arguments: string(args)},
+		}},
+	}
+}
+
+func TestRunSelftest_Passes(t *testing.T) {
+	dir := t.TempDir()
+	chdir(t, dir)
+	setupHappyPath(t, dir)
+
+	fake := &scriptedLLM{responses: []llm.Message{
+		listDirCall(dir + "/workspace"),
+		{Role: "assistant", Content: `{"type":"message","content":"The workspace holds AGENT.md and SOUL.md."}`},
+	}}
+	newLLMClient = func(apiKey, model string, timeout time.Duration) agent.LLMClient { return fake }
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"pureclaw", "selftest"}, strings.NewReader("test-pass\n"), &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("exit code = %d; stdout: %s; stderr: %s", code, stdout.String(), stderr.String())
+	}
+	out := stdout.String()
+	for _, want := range []string{"PASS  tool call", "PASS  final message", "Self-test passed"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("stdout missing %q:\n%s", want, out)
+		}
+	}
+	if fake.calls != 2 {
+		t.Errorf("LLM calls = %d, want 2 (tool call, then reply)", fake.calls)
+	}
+}
+
+func TestRunSelftest_FailsWithoutToolCall(t *testing.T) {
+	dir := t.TempDir()
+	chdir(t, dir)
+	setupHappyPath(t, dir)
+
+	// The model answers without calling list_dir.
+	fake := &scriptedLLM{responses: []llm.Message{
+		{Role: "assistant", Content: `{"type":"message","content":"I'd rather not use tools."}`},
+	}}
+	newLLMClient = func(apiKey, model string, timeout time.Duration) agent.LLMClient { return fake }
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"pureclaw", "selftest"}, strings.NewReader("test-pass\n"), &stdout, &stderr)
+	if code != 1 {
+		t.Fatalf("exit code = %d, want 1; stdout: %s", code, stdout.String())
+	}
+	out := stdout.String()
+	for _, want := range []string{"FAIL  tool call", "FAIL  final message", "Self-test failed"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("stdout missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestRunSelftest_BadArgs(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"pureclaw", "selftest", "--config"}, strings.NewReader(""), &stdout, &stderr); code != 1 {
+		t.Fatalf("exit code = %d, want 1", code)
+	}
+	if !strings.Contains(stderr.String(), "--config requires a path") {
+		t.Errorf("stderr = %q, want the missing path error", stderr.String())
+	}
+}
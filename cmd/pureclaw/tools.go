@@ -0,0 +1,129 @@
+package main
+
+import (
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/edouard/pureclaw/internal/agent"
+	"github.com/edouard/pureclaw/internal/config"
+	"github.com/edouard/pureclaw/internal/memory"
+	"github.com/edouard/pureclaw/internal/tool"
+)
+
+// builtinToolNames lists every tool the run command can register.
+var builtinToolNames = []string{
+	"read_file",
+	"write_file",
+	"list_dir",
+	"exec_command",
+	"reload_workspace",
+	"mem_tag",
+	"reindex_memory",
+	"describe_workspace",
+	"spawn_agent",
+	"compact_history",
+	"wait_for",
+	"save_skill",
+	"diff_file",
+	"config",
+	"probe_llm",
+	"summarize_url",
+}
+
+// toolSelection applies tools.json to tool registration. Without a tools file
+// every built-in tool is registered with its default settings.
+type toolSelection struct {
+	settings map[string]config.ToolSettings // nil = register everything
+}
+
+// newToolSelection indexes tc by tool name. Unknown tool names are logged and skipped.
+func newToolSelection(tc *config.ToolsConfig) *toolSelection {
+	if tc == nil {
+		return &toolSelection{}
+	}
+	s := &toolSelection{settings: make(map[string]config.ToolSettings, len(tc.Tools))}
+	for _, t := range tc.Tools {
+		if !slices.Contains(builtinToolNames, t.Name) {
+			slog.Warn("unknown tool in tools config, skipping",
+				"component", "cmd",
+				"operation", "tools",
+				"tool_name", t.Name,
+			)
+			continue
+		}
+		s.settings[t.Name] = t
+	}
+	return s
+}
+
+// enabled reports whether the named tool should be registered.
+func (s *toolSelection) enabled(name string) bool {
+	if s.settings == nil {
+		return true
+	}
+	t, ok := s.settings[name]
+	return ok && t.IsEnabled()
+}
+
+// timeout returns the configured call time limit for name, or def when none is set.
+func (s *toolSelection) timeout(name string, def time.Duration) time.Duration {
+	if d := s.settings[name].Timeout.Duration; d > 0 {
+		return d
+	}
+	return def
+}
+
+// register adds def to r if the selection enables it, applying its timeout.
+func (s *toolSelection) register(r *tool.Registry, def tool.Definition) {
+	if !s.enabled(def.Name) {
+		slog.Info("tool disabled by tools config",
+			"component", "cmd",
+			"operation", "tools",
+			"tool_name", def.Name,
+		)
+		return
+	}
+	r.Register(def)
+	if d := s.settings[def.Name].Timeout.Duration; d > 0 {
+		r.SetTimeout(def.Name, d)
+	}
+}
+
+// confirmationRules returns the rules for enabled tools marked confirm in tools.json.
+func (s *toolSelection) confirmationRules() []tool.ConfirmationRule {
+	var rules []tool.ConfirmationRule
+	for _, name := range builtinToolNames {
+		if t, ok := s.settings[name]; ok && t.Confirm && t.IsEnabled() {
+			rules = append(rules, tool.ConfirmationRule{Tool: name, ExceptPaths: t.ExceptPaths})
+		}
+	}
+	return rules
+}
+
+// runtimeConfigFields lists the config fields the config tool may change while
+// the agent runs, each applied to the component that reads it.
+func runtimeConfigFields(ag *agent.Agent, mem *memory.Memory) map[string]tool.ConfigField {
+	return map[string]tool.ConfigField{
+		"reply_chunk_limit": {
+			Value: func(cfg *config.Config) any { return &cfg.ReplyChunkLimit },
+			Apply: func(cfg *config.Config) { ag.SetReplyChunkLimit(cfg.ResolvedReplyChunkLimit()) },
+		},
+		"debounce_window": {
+			Value: func(cfg *config.Config) any { return &cfg.DebounceWindow },
+			Apply: func(cfg *config.Config) { ag.SetDebounce(cfg.DebounceWindow.Duration) },
+		},
+		"presence_window": {
+			Value: func(cfg *config.Config) any { return &cfg.PresenceWindow },
+			Apply: func(cfg *config.Config) { ag.SetPresenceWindow(cfg.PresenceWindow.Duration) },
+		},
+		"max_memory_results": {
+			Value: func(cfg *config.Config) any { return &cfg.MaxMemoryResults },
+			Apply: func(cfg *config.Config) { mem.SetMaxResults(cfg.MaxMemoryResults) },
+		},
+		"memory_dedup": {
+			Value: func(cfg *config.Config) any { return &cfg.MemoryDedup },
+			Apply: func(cfg *config.Config) { mem.SetDedup(cfg.MemoryDedup) },
+		},
+	}
+}
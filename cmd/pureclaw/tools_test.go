@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/edouard/pureclaw/internal/agent"
+	"github.com/edouard/pureclaw/internal/config"
+	"github.com/edouard/pureclaw/internal/memory"
+	"github.com/edouard/pureclaw/internal/tool"
+)
+
+// deadlineTool reports the time left before its context deadline, or "none".
+func deadlineTool(name string) tool.Definition {
+	return tool.Definition{
+		Name: name,
+		Handler: func(ctx context.Context, _ json.RawMessage) tool.ToolResult {
+			deadline, ok := ctx.Deadline()
+			if !ok {
+				return tool.ToolResult{Success: true, Output: "none"}
+			}
+			return tool.ToolResult{Success: true, Output: time.Until(deadline).Round(time.Minute).String()}
+		},
+	}
+}
+
+func registeredNames(r *tool.Registry) []string {
+	var names []string
+	for _, d := range r.Definitions() {
+		names = append(names, d.Function.Name)
+	}
+	return names
+}
+
+func TestToolSelection_SubsetWithTimeouts(t *testing.T) {
+	disabled := false
+	tools := newToolSelection(&config.ToolsConfig{Tools: []config.ToolSettings{
+		{Name: "read_file"},
+		{Name: "exec_command", Timeout: config.Duration{Duration: 5 * time.Minute}},
+		{Name: "write_file", Enabled: &disabled},
+		{Name: "teleport"},
+	}})
+
+	registry := tool.NewRegistry()
+	for _, name := range builtinToolNames {
+		tools.register(registry, deadlineTool(name))
+	}
+
+	if got := registeredNames(registry); !slices.Equal(got, []string{"read_file", "exec_command"}) {
+		t.Fatalf("registered = %v, want [read_file exec_command]", got)
+	}
+	if out := registry.Execute(context.Background(), "exec_command", nil).Output; out != "5m0s" {
+		t.Errorf("exec_command deadline = %s, want 5m0s", out)
+	}
+	if out := registry.Execute(context.Background(), "read_file", nil).Output; out != "none" {
+		t.Errorf("read_file deadline = %s, want none", out)
+	}
+	if d := tools.timeout("exec_command", time.Second); d != 5*time.Minute {
+		t.Errorf("timeout(exec_command) = %s, want 5m", d)
+	}
+	if d := tools.timeout("read_file", time.Second); d != time.Second {
+		t.Errorf("timeout(read_file) = %s, want default 1s", d)
+	}
+}
+
+func TestToolSelection_NoConfigRegistersAll(t *testing.T) {
+	tools := newToolSelection(nil)
+	registry := tool.NewRegistry()
+	for _, name := range builtinToolNames {
+		tools.register(registry, deadlineTool(name))
+	}
+	if got := registeredNames(registry); !slices.Equal(got, builtinToolNames) {
+		t.Errorf("registered = %v, want every built-in tool", got)
+	}
+	if rules := tools.confirmationRules(); len(rules) != 0 {
+		t.Errorf("confirmation rules = %v, want none", rules)
+	}
+}
+
+func TestToolSelection_ConfirmationRules(t *testing.T) {
+	disabled := false
+	tools := newToolSelection(&config.ToolsConfig{Tools: []config.ToolSettings{
+		{Name: "write_file", Confirm: true, ExceptPaths: []string{"memory"}},
+		{Name: "exec_command", Confirm: true, Enabled: &disabled},
+		{Name: "read_file"},
+	}})
+
+	policy := confirmationPolicy(&config.Config{
+		Workspace:         "/ws",
+		ToolConfirmations: []config.ToolConfirmation{{Tool: "list_dir"}},
+	}, tools.confirmationRules()...)
+
+	if len(policy.Rules) != 2 {
+		t.Fatalf("rules = %+v, want list_dir and write_file", policy.Rules)
+	}
+	if r := policy.Rules[1]; r.Tool != "write_file" || !slices.Equal(r.ExceptPaths, []string{"memory"}) {
+		t.Errorf("tools.json rule = %+v", r)
+	}
+}
+
+// Every runtime field must read the config.json field of the same name.
+func TestRuntimeConfigFields_MatchConfigJSON(t *testing.T) {
+	cfg := &config.Config{
+		ReplyChunkLimit:  2,
+		DebounceWindow:   config.Duration{Duration: time.Second},
+		PresenceWindow:   config.Duration{Duration: time.Hour},
+		MaxMemoryResults: 10,
+		MemoryDedup:      true,
+	}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatal(err)
+	}
+
+	ag := agent.New(agent.NewAgentConfig{})
+	mem := memory.New(t.TempDir())
+	for name, field := range runtimeConfigFields(ag, mem) {
+		got, err := json.Marshal(field.Value(cfg))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if string(got) != string(raw[name]) {
+			t.Errorf("%s: value = %s, want config.json field %s", name, got, raw[name])
+		}
+		field.Apply(cfg)
+	}
+}
@@ -2,13 +2,19 @@ package main
 
 import (
 	"bufio"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"maps"
 	"os"
+	"slices"
+	"sort"
 	"strings"
+	"time"
 
+	"github.com/edouard/pureclaw/internal/platform"
 	"github.com/edouard/pureclaw/internal/vault"
 )
 
@@ -18,9 +24,17 @@ const defaultVaultPath = "vault.enc"
 var (
 	generateSalt = vault.GenerateSalt
 	vaultOpen    = vault.Open
+	isTerminal   = writerIsTerminal
 )
 
-// runVault dispatches vault subcommands: get, set, delete, list.
+// plaintextSecretsWarning is shown before any command writes decrypted secrets out of the vault.
+const plaintextSecretsWarning = "WARNING: this writes every secret in the vault as plaintext. Anyone who can read the output can use them; do not commit it or share it."
+
+// defaultImportPrefix selects the environment variables read by vault import-from-env.
+const defaultImportPrefix = "PURECLAW_SECRET_"
+
+// runVault dispatches vault subcommands: get, set, delete, list, dump-env,
+// import-from-env, export, import, change-passphrase.
 func runVault(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
 	if len(args) == 0 {
 		printVaultUsage(stderr)
@@ -38,6 +52,16 @@ func runVault(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
 		return vaultDelete(args[1:], scanner, stdout, stderr)
 	case "list":
 		return vaultList(args[1:], scanner, stdout, stderr)
+	case "dump-env":
+		return vaultDumpEnv(args[1:], scanner, stdout, stderr)
+	case "import-from-env":
+		return vaultImportFromEnv(args[1:], scanner, stdout, stderr)
+	case "export":
+		return vaultExport(args[1:], scanner, stdout, stderr)
+	case "import":
+		return vaultImport(args[1:], scanner, stdout, stderr)
+	case "change-passphrase":
+		return vaultChangePassphrase(args[1:], scanner, stdout, stderr)
 	default:
 		fmt.Fprintf(stderr, "vault: unknown subcommand %q\n", args[0])
 		printVaultUsage(stderr)
@@ -144,9 +168,12 @@ func vaultDelete(args []string, scanner *bufio.Scanner, stdout, stderr io.Writer
 	return 0
 }
 
+// vaultList prints the secret keys, one per line. With --long each key is
+// followed by a tab and the time it was last updated ("-" if not recorded).
 func vaultList(args []string, scanner *bufio.Scanner, stdout, stderr io.Writer) int {
-	if len(args) != 0 {
-		fmt.Fprintln(stderr, "Usage: pureclaw vault list")
+	long := len(args) == 1 && args[0] == "--long"
+	if len(args) != 0 && !long {
+		fmt.Fprintln(stderr, "Usage: pureclaw vault list [--long]")
 		return 1
 	}
 
@@ -164,12 +191,348 @@ func vaultList(args []string, scanner *bufio.Scanner, stdout, stderr io.Writer)
 
 	keys := v.List()
 	for _, k := range keys {
-		fmt.Fprintln(stdout, k)
+		if !long {
+			fmt.Fprintln(stdout, k)
+			continue
+		}
+		updated := "-"
+		if meta, _ := v.Metadata(k); !meta.UpdatedAt.IsZero() {
+			updated = meta.UpdatedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(stdout, "%s\t%s\n", k, updated)
 	}
 	slog.Info("vault listed", "component", "vault-cli", "operation", "list", "count", len(keys))
 	return 0
 }
 
+// vaultDumpEnv writes all secrets as KEY=value lines to stdout or --output <file>.
+// Because the result is plaintext, it refuses to run without --yes.
+func vaultDumpEnv(args []string, scanner *bufio.Scanner, stdout, stderr io.Writer) int {
+	const usage = "Usage: pureclaw vault dump-env --yes [--output <file>]"
+	var confirmed bool
+	var output string
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--yes":
+			confirmed = true
+		case "--output":
+			if i+1 >= len(args) {
+				fmt.Fprintln(stderr, usage)
+				return 1
+			}
+			i++
+			output = args[i]
+		default:
+			fmt.Fprintln(stderr, usage)
+			return 1
+		}
+	}
+
+	fmt.Fprintln(stderr, plaintextSecretsWarning)
+	if !confirmed {
+		fmt.Fprintln(stderr, "Error: refusing to dump secrets without --yes")
+		return 1
+	}
+
+	passphrase, err := readPassphrase(scanner, stderr)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	v, err := openVault(passphrase, defaultVaultPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %s\n", vaultUserError(err))
+		return 1
+	}
+
+	var b strings.Builder
+	keys := v.List()
+	for _, k := range keys {
+		value, err := v.Get(k)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %s\n", vaultUserError(err))
+			return 1
+		}
+		fmt.Fprintf(&b, "%s=%s\n", envKey(k), envValue(value))
+	}
+
+	if output == "" {
+		fmt.Fprint(stdout, b.String())
+	} else {
+		if err := platform.AtomicWrite(output, []byte(b.String()), 0o600); err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Fprintf(stderr, "Wrote %d secret(s) to %s\n", len(keys), output)
+	}
+	slog.Info("vault dumped to env format", "component", "vault-cli", "operation", "dump_env", "count", len(keys), "to_file", output != "")
+	return 0
+}
+
+// vaultImportFromEnv stores every environment variable starting with --prefix in the
+// vault, keyed by the rest of its name lowercased (PURECLAW_SECRET_API_KEY -> api_key).
+func vaultImportFromEnv(args []string, scanner *bufio.Scanner, stdout, stderr io.Writer) int {
+	const usage = "Usage: pureclaw vault import-from-env [--prefix <prefix>]"
+	prefix := defaultImportPrefix
+	switch {
+	case len(args) == 0:
+	case len(args) == 2 && args[0] == "--prefix" && args[1] != "":
+		prefix = args[1]
+	default:
+		fmt.Fprintln(stderr, usage)
+		return 1
+	}
+
+	secrets := make(map[string]string)
+	for _, kv := range os.Environ() {
+		name, value, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		key := strings.ToLower(strings.TrimPrefix(name, prefix))
+		if key == "" {
+			continue
+		}
+		secrets[key] = value
+	}
+	if len(secrets) == 0 {
+		fmt.Fprintf(stderr, "Error: no environment variables found with prefix %s\n", prefix)
+		return 1
+	}
+
+	passphrase, err := readPassphrase(scanner, stderr)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	v, err := createOrOpenVault(passphrase, defaultVaultPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %s\n", vaultUserError(err))
+		return 1
+	}
+
+	keys := make([]string, 0, len(secrets))
+	for k := range secrets {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if err := v.Set(k, secrets[k]); err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
+	slog.Info("secrets imported from environment", "component", "vault-cli", "operation", "import_from_env", "count", len(keys))
+	fmt.Fprintf(stderr, "Imported %d secret(s): %s\n", len(keys), strings.Join(keys, ", "))
+	return 0
+}
+
+// vaultExport writes every secret as a JSON object of key to value, to stdout or
+// --out <file>. It refuses to print to a terminal unless --force is given.
+func vaultExport(args []string, scanner *bufio.Scanner, stdout, stderr io.Writer) int {
+	const usage = "Usage: pureclaw vault export [--out <file>] [--force]"
+	var force bool
+	var output string
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--force":
+			force = true
+		case args[i] == "--out" && i+1 < len(args):
+			i++
+			output = args[i]
+		default:
+			fmt.Fprintln(stderr, usage)
+			return 1
+		}
+	}
+
+	fmt.Fprintln(stderr, plaintextSecretsWarning)
+	if output == "" && !force && isTerminal(stdout) {
+		fmt.Fprintln(stderr, "Error: refusing to print secrets to a terminal; redirect stdout, use --out <file>, or pass --force")
+		return 1
+	}
+
+	passphrase, err := readPassphrase(scanner, stderr)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	v, err := openVault(passphrase, defaultVaultPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %s\n", vaultUserError(err))
+		return 1
+	}
+
+	secrets := make(map[string]string)
+	for _, k := range v.List() {
+		value, err := v.Get(k)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %s\n", vaultUserError(err))
+			return 1
+		}
+		secrets[k] = value
+	}
+	data, err := json.MarshalIndent(secrets, "", "  ")
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	data = append(data, '\n')
+
+	if output == "" {
+		stdout.Write(data)
+	} else {
+		if err := platform.AtomicWrite(output, data, 0o600); err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Fprintf(stderr, "Exported %d secret(s) to %s\n", len(secrets), output)
+	}
+	slog.Info("vault exported", "component", "vault-cli", "operation", "export", "count", len(secrets), "to_file", output != "")
+	return 0
+}
+
+// vaultImport stores every key of a JSON object file made by vault export,
+// creating the vault if needed. It stops at the first failed write; keys
+// stored before it are kept.
+func vaultImport(args []string, scanner *bufio.Scanner, stdout, stderr io.Writer) int {
+	if len(args) != 1 {
+		fmt.Fprintln(stderr, "Usage: pureclaw vault import <file>")
+		return 1
+	}
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	var secrets map[string]string
+	if err := json.Unmarshal(data, &secrets); err != nil {
+		fmt.Fprintf(stderr, "Error: %s is not a JSON object of key to string value: %v\n", args[0], err)
+		return 1
+	}
+
+	passphrase, err := readPassphrase(scanner, stderr)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	v, err := createOrOpenVault(passphrase, defaultVaultPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %s\n", vaultUserError(err))
+		return 1
+	}
+
+	existing := v.List()
+	var added, overwritten int
+	for _, k := range slices.Sorted(maps.Keys(secrets)) {
+		if err := v.Set(k, secrets[k]); err != nil {
+			fmt.Fprintf(stderr, "Error: storing %q: %v (%d added, %d overwritten before the failure)\n", k, err, added, overwritten)
+			return 1
+		}
+		if slices.Contains(existing, k) {
+			overwritten++
+		} else {
+			added++
+		}
+	}
+	slog.Info("vault imported", "component", "vault-cli", "operation", "import", "added", added, "overwritten", overwritten)
+	fmt.Fprintf(stderr, "Imported %d secret(s): %d added, %d overwritten\n", added+overwritten, added, overwritten)
+	return 0
+}
+
+// writerIsTerminal reports whether w is a character device such as a terminal.
+func writerIsTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// vaultChangePassphrase re-encrypts every secret under a new passphrase and a
+// fresh salt. The vault file is replaced atomically, so a failure leaves the
+// old vault readable with the old passphrase.
+func vaultChangePassphrase(args []string, scanner *bufio.Scanner, stdout, stderr io.Writer) int {
+	if len(args) != 0 {
+		fmt.Fprintln(stderr, "Usage: pureclaw vault change-passphrase")
+		return 1
+	}
+
+	passphrase, err := readPassphrase(scanner, stderr)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	v, err := openVault(passphrase, defaultVaultPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %s\n", vaultUserError(err))
+		return 1
+	}
+
+	newPassphrase, err := readLine(scanner, stderr, "New passphrase: ", "new passphrase")
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	if newPassphrase == "" {
+		fmt.Fprintln(stderr, "Error: new passphrase must not be empty")
+		return 1
+	}
+	confirmation, err := readLine(scanner, stderr, "Confirm new passphrase: ", "passphrase confirmation")
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	if confirmation != newPassphrase {
+		fmt.Fprintln(stderr, "Error: passphrases do not match")
+		return 1
+	}
+
+	salt, err := generateSalt()
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: vault: generate salt: %v\n", err)
+		return 1
+	}
+	if err := v.Rekey(vault.DeriveKey(newPassphrase, salt), salt); err != nil {
+		fmt.Fprintf(stderr, "Error: %s\n", vaultUserError(err))
+		return 1
+	}
+	slog.Info("vault passphrase changed", "component", "vault-cli", "operation", "change_passphrase", "count", len(v.List()))
+	fmt.Fprintf(stderr, "Passphrase changed; %d secret(s) re-encrypted\n", len(v.List()))
+	return 0
+}
+
+// envKey converts a vault key to an environment variable name: uppercased, with
+// every character other than letters, digits and underscores replaced by '_'.
+func envKey(key string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, key)
+}
+
+// envValue returns value as-is when it only holds shell-safe characters, otherwise
+// double-quoted with backslashes, quotes, dollar signs, backticks and newlines escaped.
+func envValue(value string) string {
+	safe := value != "" && strings.IndexFunc(value, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("_-.,:/@+%", r))
+	}) < 0
+	if safe {
+		return value
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`, "`", "\\`", "\n", `\n`)
+	return `"` + r.Replace(value) + `"`
+}
+
 // readPassphrase prompts on w and reads a line from the scanner.
 func readPassphrase(scanner *bufio.Scanner, w io.Writer) (string, error) {
 	fmt.Fprint(w, "Passphrase: ")
@@ -182,6 +545,19 @@ func readPassphrase(scanner *bufio.Scanner, w io.Writer) (string, error) {
 	return strings.TrimRight(scanner.Text(), "\r\n"), nil
 }
 
+// readLine prints prompt on w and reads a line from the scanner; what names
+// the value in errors.
+func readLine(scanner *bufio.Scanner, w io.Writer, prompt, what string) (string, error) {
+	fmt.Fprint(w, prompt)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return "", fmt.Errorf("reading %s: %w", what, err)
+		}
+		return "", fmt.Errorf("reading %s: unexpected end of input", what)
+	}
+	return strings.TrimRight(scanner.Text(), "\r\n"), nil
+}
+
 // readValue prompts on w and reads a line from the scanner.
 func readValue(scanner *bufio.Scanner, w io.Writer) (string, error) {
 	fmt.Fprint(w, "Value: ")
@@ -249,5 +625,10 @@ func printVaultUsage(w io.Writer) {
 	fmt.Fprintln(w, "  set <key>     Store a secret")
 	fmt.Fprintln(w, "  get <key>     Retrieve a secret")
 	fmt.Fprintln(w, "  delete <key>  Delete a secret")
-	fmt.Fprintln(w, "  list          List all secret keys")
+	fmt.Fprintln(w, "  list          List all secret keys (--long adds when each was last updated)")
+	fmt.Fprintln(w, "  dump-env      Write all secrets as KEY=value lines (requires --yes; --output <file>)")
+	fmt.Fprintln(w, "  import-from-env  Store PURECLAW_SECRET_* environment variables (--prefix <prefix>)")
+	fmt.Fprintln(w, "  export        Write all secrets as a JSON object (--out <file>; --force to print to a terminal)")
+	fmt.Fprintln(w, "  import <file> Store every secret of a JSON object written by export")
+	fmt.Fprintln(w, "  change-passphrase  Re-encrypt every secret under a new passphrase")
 }
@@ -3,11 +3,14 @@ package main
 import (
 	"bufio"
 	"bytes"
+	"encoding/json"
 	"errors"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/edouard/pureclaw/internal/vault"
 )
@@ -313,6 +316,26 @@ func TestVaultList(t *testing.T) {
 		}
 	})
 
+	t.Run("long", func(t *testing.T) {
+		dir := t.TempDir()
+		chdir(t, dir)
+		before := time.Now().Add(-time.Second)
+		createTestVault(t, dir, "pass123", map[string]string{"alpha_key": "val1"})
+
+		var stdout, stderr bytes.Buffer
+		code := runVault([]string{"list", "--long"}, strings.NewReader("pass123\n"), &stdout, &stderr)
+		if code != 0 {
+			t.Fatalf("exit code = %d, want 0; stderr: %s", code, stderr.String())
+		}
+		key, updated, ok := strings.Cut(strings.TrimSpace(stdout.String()), "\t")
+		if !ok || key != "alpha_key" {
+			t.Fatalf("output = %q, want alpha_key<TAB>updated-at", stdout.String())
+		}
+		if ts, err := time.Parse(time.RFC3339, updated); err != nil || ts.Before(before.Truncate(time.Second)) {
+			t.Errorf("updated-at = %q (%v), want an RFC 3339 time from this run", updated, err)
+		}
+	})
+
 	t.Run("no vault file", func(t *testing.T) {
 		dir := t.TempDir()
 		chdir(t, dir)
@@ -588,3 +611,415 @@ func TestOpenVault_openError(t *testing.T) {
 func newTestScanner(input string) *bufio.Scanner {
 	return bufio.NewScanner(strings.NewReader(input))
 }
+
+func TestVaultDumpEnv(t *testing.T) {
+	entries := map[string]string{
+		"telegram.token":  "123:abc-DEF",
+		"mistral_api_key": "sk plain words",
+		"quote-me":        `a"b$c\d`,
+	}
+	wantLines := []string{
+		`MISTRAL_API_KEY="sk plain words"`,
+		`QUOTE_ME="a\"b\$c\\d"`,
+		`TELEGRAM_TOKEN=123:abc-DEF`,
+	}
+
+	t.Run("stdout", func(t *testing.T) {
+		dir := t.TempDir()
+		chdir(t, dir)
+		createTestVault(t, dir, "pass123", entries)
+
+		var stdout, stderr bytes.Buffer
+		code := runVault([]string{"dump-env", "--yes"}, strings.NewReader("pass123\n"), &stdout, &stderr)
+		if code != 0 {
+			t.Fatalf("exit code = %d, want 0; stderr: %s", code, stderr.String())
+		}
+		if got := strings.Split(strings.TrimSpace(stdout.String()), "\n"); strings.Join(got, "\n") != strings.Join(wantLines, "\n") {
+			t.Fatalf("output =\n%s\nwant\n%s", stdout.String(), strings.Join(wantLines, "\n"))
+		}
+		if !strings.Contains(stderr.String(), plaintextSecretsWarning) {
+			t.Errorf("expected safety warning on stderr, got %q", stderr.String())
+		}
+	})
+
+	t.Run("output file", func(t *testing.T) {
+		dir := t.TempDir()
+		chdir(t, dir)
+		createTestVault(t, dir, "pass123", entries)
+
+		var stdout, stderr bytes.Buffer
+		code := runVault([]string{"dump-env", "--output", "secrets.env", "--yes"}, strings.NewReader("pass123\n"), &stdout, &stderr)
+		if code != 0 {
+			t.Fatalf("exit code = %d, want 0; stderr: %s", code, stderr.String())
+		}
+		if stdout.Len() != 0 {
+			t.Errorf("expected nothing on stdout, got %q", stdout.String())
+		}
+		data, err := os.ReadFile(dir + "/secrets.env")
+		if err != nil {
+			t.Fatalf("read env file: %v", err)
+		}
+		if string(data) != strings.Join(wantLines, "\n")+"\n" {
+			t.Errorf("env file =\n%s", data)
+		}
+		info, err := os.Stat(dir + "/secrets.env")
+		if err != nil {
+			t.Fatalf("stat: %v", err)
+		}
+		if perm := info.Mode().Perm(); perm != 0o600 {
+			t.Errorf("env file perm = %o, want 600", perm)
+		}
+	})
+
+	t.Run("requires --yes", func(t *testing.T) {
+		dir := t.TempDir()
+		chdir(t, dir)
+		createTestVault(t, dir, "pass123", entries)
+
+		var stdout, stderr bytes.Buffer
+		code := runVault([]string{"dump-env"}, strings.NewReader("pass123\n"), &stdout, &stderr)
+		if code != 1 {
+			t.Fatalf("exit code = %d, want 1", code)
+		}
+		if stdout.Len() != 0 {
+			t.Errorf("secrets written without confirmation: %q", stdout.String())
+		}
+		if !strings.Contains(stderr.String(), "--yes") {
+			t.Errorf("expected --yes hint, got %q", stderr.String())
+		}
+	})
+
+	t.Run("bad args", func(t *testing.T) {
+		for _, args := range [][]string{{"dump-env", "--output"}, {"dump-env", "--bogus"}} {
+			var stderr bytes.Buffer
+			if code := runVault(args, strings.NewReader(""), io.Discard, &stderr); code != 1 {
+				t.Errorf("%v: exit code = %d, want 1", args, code)
+			}
+		}
+	})
+
+	t.Run("wrong passphrase", func(t *testing.T) {
+		dir := t.TempDir()
+		chdir(t, dir)
+		createTestVault(t, dir, "pass123", entries)
+
+		var stdout bytes.Buffer
+		code := runVault([]string{"dump-env", "--yes"}, strings.NewReader("wrong\n"), &stdout, io.Discard)
+		if code != 1 {
+			t.Fatalf("exit code = %d, want 1", code)
+		}
+		if stdout.Len() != 0 {
+			t.Errorf("unexpected output %q", stdout.String())
+		}
+	})
+}
+
+func TestEnvValue(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"simple", "simple"},
+		{"https://x.example/path?q", `"https://x.example/path?q"`},
+		{"", `""`},
+		{"two words", `"two words"`},
+		{"line1\nline2", `"line1\nline2"`},
+		{"`cmd`", "\"\\`cmd\\`\""},
+	}
+	for _, tt := range tests {
+		if got := envValue(tt.in); got != tt.want {
+			t.Errorf("envValue(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestVaultImportFromEnv(t *testing.T) {
+	t.Run("default prefix", func(t *testing.T) {
+		dir := t.TempDir()
+		chdir(t, dir)
+		createTestVault(t, dir, "pass123", map[string]string{"existing": "keep"})
+		t.Setenv("PURECLAW_SECRET_MISTRAL_API_KEY", "sk-123")
+		t.Setenv("PURECLAW_SECRET_TELEGRAM_BOT_TOKEN", "bot:token=with=equals")
+		t.Setenv("OTHER_SECRET", "ignored")
+
+		var stderr bytes.Buffer
+		code := runVault([]string{"import-from-env"}, strings.NewReader("pass123\n"), io.Discard, &stderr)
+		if code != 0 {
+			t.Fatalf("exit code = %d, want 0; stderr: %s", code, stderr.String())
+		}
+		if !strings.Contains(stderr.String(), "Imported 2 secret(s)") {
+			t.Errorf("expected import count, got %q", stderr.String())
+		}
+
+		v, err := openVault("pass123", dir+"/vault.enc")
+		if err != nil {
+			t.Fatalf("open vault: %v", err)
+		}
+		want := map[string]string{
+			"mistral_api_key":    "sk-123",
+			"telegram_bot_token": "bot:token=with=equals",
+			"existing":           "keep",
+		}
+		for k, w := range want {
+			got, err := v.Get(k)
+			if err != nil || got != w {
+				t.Errorf("Get(%q) = %q, %v; want %q", k, got, err, w)
+			}
+		}
+		if _, err := v.Get("other_secret"); !errors.Is(err, vault.ErrKeyNotFound) {
+			t.Errorf("unprefixed variable imported: %v", err)
+		}
+	})
+
+	t.Run("custom prefix creates vault", func(t *testing.T) {
+		dir := t.TempDir()
+		chdir(t, dir)
+		t.Setenv("CI_VAULT_Api_Key", "abc")
+
+		var stderr bytes.Buffer
+		code := runVault([]string{"import-from-env", "--prefix", "CI_VAULT_"}, strings.NewReader("pass123\n"), io.Discard, &stderr)
+		if code != 0 {
+			t.Fatalf("exit code = %d, want 0; stderr: %s", code, stderr.String())
+		}
+		v, err := openVault("pass123", dir+"/vault.enc")
+		if err != nil {
+			t.Fatalf("open vault: %v", err)
+		}
+		if got, err := v.Get("api_key"); err != nil || got != "abc" {
+			t.Errorf("Get(api_key) = %q, %v; want abc", got, err)
+		}
+	})
+
+	t.Run("no matching variables", func(t *testing.T) {
+		dir := t.TempDir()
+		chdir(t, dir)
+
+		var stderr bytes.Buffer
+		code := runVault([]string{"import-from-env", "--prefix", "PURECLAW_TEST_NONE_"}, strings.NewReader("pass123\n"), io.Discard, &stderr)
+		if code != 1 {
+			t.Fatalf("exit code = %d, want 1", code)
+		}
+		if _, err := os.Stat(dir + "/vault.enc"); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("vault created without secrets: %v", err)
+		}
+	})
+
+	t.Run("bad args", func(t *testing.T) {
+		for _, args := range [][]string{{"import-from-env", "--prefix"}, {"import-from-env", "--bogus", "x"}, {"import-from-env", "--prefix", ""}} {
+			if code := runVault(args, strings.NewReader(""), io.Discard, io.Discard); code != 1 {
+				t.Errorf("%v: exit code = %d, want 1", args, code)
+			}
+		}
+	})
+}
+
+func TestVaultChangePassphrase(t *testing.T) {
+	t.Run("re-encrypts under the new passphrase", func(t *testing.T) {
+		dir := t.TempDir()
+		chdir(t, dir)
+		createTestVault(t, dir, "old-pass", map[string]string{"api_key": "sk-secret", "token": "t0k"})
+
+		var stderr bytes.Buffer
+		code := runVault([]string{"change-passphrase"}, strings.NewReader("old-pass\nnew-pass\nnew-pass\n"), io.Discard, &stderr)
+		if code != 0 {
+			t.Fatalf("exit code = %d, want 0; stderr: %s", code, stderr.String())
+		}
+		if !strings.Contains(stderr.String(), "2 secret(s) re-encrypted") {
+			t.Errorf("stderr = %q, want the re-encrypted count", stderr.String())
+		}
+
+		var stdout bytes.Buffer
+		if code := runVault([]string{"get", "api_key"}, strings.NewReader("new-pass\n"), &stdout, io.Discard); code != 0 {
+			t.Fatalf("get with new passphrase: exit code = %d", code)
+		}
+		if got := strings.TrimSpace(stdout.String()); got != "sk-secret" {
+			t.Errorf("got %q, want %q", got, "sk-secret")
+		}
+		stderr.Reset()
+		if code := runVault([]string{"get", "api_key"}, strings.NewReader("old-pass\n"), io.Discard, &stderr); code != 1 || !strings.Contains(stderr.String(), "wrong passphrase") {
+			t.Errorf("get with old passphrase: exit code = %d, stderr = %q; want rejected", code, stderr.String())
+		}
+	})
+
+	t.Run("wrong old passphrase", func(t *testing.T) {
+		dir := t.TempDir()
+		chdir(t, dir)
+		createTestVault(t, dir, "old-pass", map[string]string{"api_key": "sk-secret"})
+		before, _ := os.ReadFile(dir + "/vault.enc")
+
+		var stderr bytes.Buffer
+		code := runVault([]string{"change-passphrase"}, strings.NewReader("bad-pass\nnew-pass\nnew-pass\n"), io.Discard, &stderr)
+		if code != 1 {
+			t.Fatalf("exit code = %d, want 1", code)
+		}
+		if !strings.Contains(stderr.String(), "wrong passphrase or corrupted vault") {
+			t.Errorf("stderr = %q, want the wrong passphrase error", stderr.String())
+		}
+		if after, _ := os.ReadFile(dir + "/vault.enc"); !bytes.Equal(before, after) {
+			t.Error("vault.enc changed after a failed passphrase change")
+		}
+	})
+
+	t.Run("confirmation mismatch", func(t *testing.T) {
+		dir := t.TempDir()
+		chdir(t, dir)
+		createTestVault(t, dir, "old-pass", map[string]string{"api_key": "sk-secret"})
+
+		var stderr bytes.Buffer
+		code := runVault([]string{"change-passphrase"}, strings.NewReader("old-pass\nnew-pass\nnew-typo\n"), io.Discard, &stderr)
+		if code != 1 || !strings.Contains(stderr.String(), "do not match") {
+			t.Errorf("exit code = %d, stderr = %q; want a mismatch error", code, stderr.String())
+		}
+	})
+
+	t.Run("empty new passphrase", func(t *testing.T) {
+		dir := t.TempDir()
+		chdir(t, dir)
+		createTestVault(t, dir, "old-pass", nil)
+
+		var stderr bytes.Buffer
+		code := runVault([]string{"change-passphrase"}, strings.NewReader("old-pass\n\n\n"), io.Discard, &stderr)
+		if code != 1 || !strings.Contains(stderr.String(), "must not be empty") {
+			t.Errorf("exit code = %d, stderr = %q; want an empty passphrase error", code, stderr.String())
+		}
+	})
+
+	t.Run("salt generation error", func(t *testing.T) {
+		dir := t.TempDir()
+		chdir(t, dir)
+		createTestVault(t, dir, "old-pass", nil)
+		orig := generateSalt
+		generateSalt = func() ([]byte, error) { return nil, errors.New("no entropy") }
+		t.Cleanup(func() { generateSalt = orig })
+
+		var stderr bytes.Buffer
+		code := runVault([]string{"change-passphrase"}, strings.NewReader("old-pass\nnew\nnew\n"), io.Discard, &stderr)
+		if code != 1 || !strings.Contains(stderr.String(), "no entropy") {
+			t.Errorf("exit code = %d, stderr = %q; want the salt error", code, stderr.String())
+		}
+	})
+
+	t.Run("extra args", func(t *testing.T) {
+		if code := runVault([]string{"change-passphrase", "x"}, strings.NewReader(""), io.Discard, io.Discard); code != 1 {
+			t.Errorf("exit code = %d, want 1", code)
+		}
+	})
+}
+
+func TestVaultExportImport(t *testing.T) {
+	t.Run("round trip between vaults", func(t *testing.T) {
+		src := t.TempDir()
+		chdir(t, src)
+		createTestVault(t, src, "pass", map[string]string{"api_key": "sk-secret", "token": "t0k"})
+
+		var out, stderr bytes.Buffer
+		if code := runVault([]string{"export"}, strings.NewReader("pass\n"), &out, &stderr); code != 0 {
+			t.Fatalf("export exit code = %d; stderr: %s", code, stderr.String())
+		}
+		var exported map[string]string
+		if err := json.Unmarshal(out.Bytes(), &exported); err != nil {
+			t.Fatalf("export output is not JSON: %v\n%s", err, out.String())
+		}
+		if exported["api_key"] != "sk-secret" || exported["token"] != "t0k" {
+			t.Errorf("exported = %v", exported)
+		}
+
+		backup := filepath.Join(t.TempDir(), "secrets.json")
+		if err := os.WriteFile(backup, out.Bytes(), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		dst := t.TempDir()
+		chdir(t, dst)
+		createTestVault(t, dst, "other", map[string]string{"token": "old"})
+
+		stderr.Reset()
+		if code := runVault([]string{"import", backup}, strings.NewReader("other\n"), io.Discard, &stderr); code != 0 {
+			t.Fatalf("import exit code = %d; stderr: %s", code, stderr.String())
+		}
+		if !strings.Contains(stderr.String(), "1 added, 1 overwritten") {
+			t.Errorf("stderr = %q, want the added/overwritten counts", stderr.String())
+		}
+		var got bytes.Buffer
+		runVault([]string{"get", "token"}, strings.NewReader("other\n"), &got, io.Discard)
+		if strings.TrimSpace(got.String()) != "t0k" {
+			t.Errorf("token = %q, want the imported value", got.String())
+		}
+	})
+
+	t.Run("export to file", func(t *testing.T) {
+		dir := t.TempDir()
+		chdir(t, dir)
+		createTestVault(t, dir, "pass", map[string]string{"api_key": "sk-secret"})
+
+		var stdout bytes.Buffer
+		if code := runVault([]string{"export", "--out", "backup.json"}, strings.NewReader("pass\n"), &stdout, io.Discard); code != 0 {
+			t.Fatalf("exit code = %d", code)
+		}
+		if stdout.Len() != 0 {
+			t.Errorf("stdout = %q, want nothing when writing to a file", stdout.String())
+		}
+		info, err := os.Stat(filepath.Join(dir, "backup.json"))
+		if err != nil {
+			t.Fatalf("backup not written: %v", err)
+		}
+		if info.Mode().Perm() != 0o600 {
+			t.Errorf("backup mode = %v, want 0600", info.Mode().Perm())
+		}
+	})
+
+	t.Run("export refuses a terminal without --force", func(t *testing.T) {
+		dir := t.TempDir()
+		chdir(t, dir)
+		createTestVault(t, dir, "pass", map[string]string{"api_key": "sk-secret"})
+		orig := isTerminal
+		isTerminal = func(io.Writer) bool { return true }
+		t.Cleanup(func() { isTerminal = orig })
+
+		var stdout, stderr bytes.Buffer
+		if code := runVault([]string{"export"}, strings.NewReader("pass\n"), &stdout, &stderr); code != 1 {
+			t.Fatalf("exit code = %d, want 1", code)
+		}
+		if strings.Contains(stdout.String(), "sk-secret") || !strings.Contains(stderr.String(), "--force") {
+			t.Errorf("stdout = %q, stderr = %q; want a refusal mentioning --force", stdout.String(), stderr.String())
+		}
+
+		stdout.Reset()
+		if code := runVault([]string{"export", "--force"}, strings.NewReader("pass\n"), &stdout, io.Discard); code != 0 || !strings.Contains(stdout.String(), "sk-secret") {
+			t.Errorf("--force: exit code = %d, stdout = %q", code, stdout.String())
+		}
+	})
+
+	t.Run("import rejects invalid JSON", func(t *testing.T) {
+		dir := t.TempDir()
+		chdir(t, dir)
+		if err := os.WriteFile("bad.json", []byte(`["not", "an", "object"]`), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		var stderr bytes.Buffer
+		if code := runVault([]string{"import", "bad.json"}, strings.NewReader("pass\n"), io.Discard, &stderr); code != 1 {
+			t.Fatalf("exit code = %d, want 1", code)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "vault.enc")); !os.IsNotExist(err) {
+			t.Error("vault created despite the invalid import file")
+		}
+	})
+
+	t.Run("usage errors", func(t *testing.T) {
+		for _, args := range [][]string{{"export", "--out"}, {"export", "extra"}, {"import"}} {
+			if code := runVault(args, strings.NewReader(""), io.Discard, io.Discard); code != 1 {
+				t.Errorf("%v: exit code = %d, want 1", args, code)
+			}
+		}
+	})
+}
+
+func TestWriterIsTerminal(t *testing.T) {
+	if writerIsTerminal(&bytes.Buffer{}) {
+		t.Error("buffer reported as a terminal")
+	}
+	f, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if writerIsTerminal(f) {
+		t.Error("regular file reported as a terminal")
+	}
+}
@@ -2,11 +2,17 @@ package agent
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 	"log/slog"
-	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/edouard/pureclaw/internal/llm"
 	"github.com/edouard/pureclaw/internal/memory"
@@ -14,13 +20,34 @@ import (
 	"github.com/edouard/pureclaw/internal/subagent"
 	"github.com/edouard/pureclaw/internal/telegram"
 	"github.com/edouard/pureclaw/internal/tool"
+	"github.com/edouard/pureclaw/internal/watcher"
 	"github.com/edouard/pureclaw/internal/workspace"
 )
 
 const maxToolRounds = 10
 
+// Memory verbosity levels select which sources logMemory persists.
+const (
+	MemoryVerbosityAll          = "all"           // persist every source (default)
+	MemoryVerbosityMessagesOnly = "messages-only" // persist only conversation turns
+	MemoryVerbosityNone         = "none"          // persist nothing
+)
+
+// conversationSources are the memory sources kept at MemoryVerbosityMessagesOnly.
+var conversationSources = map[string]bool{
+	"owner":               true,
+	"voice-transcription": true,
+	"agent":               true,
+}
+
+// truncatedNote is appended to replies recovered from a response cut off by the token limit.
+const truncatedNote = "\n\n(response was truncated)"
+
+// fallbackSendTimeout bounds the fallback reply, which runs after the message deadline.
+const fallbackSendTimeout = 10 * time.Second
+
 // Replaceable for testing.
-var agentWorkspaceLoadFn = workspace.Load
+var agentWorkspaceLoadFn = workspace.LoadWithMaxSkills
 
 // LLMClient abstracts the LLM provider for testability.
 type LLMClient interface {
@@ -38,9 +65,14 @@ type MemoryWriter interface {
 	Write(ctx context.Context, source, content string) error
 }
 
+// taggedMemoryWriter is implemented by memory writers that can label entries with tags.
+type taggedMemoryWriter interface {
+	WriteTagged(ctx context.Context, source, content string, tags []string) error
+}
+
 // MemorySearcher abstracts memory search and temporal reading capabilities.
 type MemorySearcher interface {
-	Search(ctx context.Context, keyword string, start, end time.Time) ([]memory.SearchResult, error)
+	Search(ctx context.Context, keyword string, start, end time.Time, tags ...string) ([]memory.SearchResult, error)
 	ReadRange(ctx context.Context, start, end time.Time) ([]memory.SearchResult, error)
 }
 
@@ -60,87 +92,285 @@ type Transcriber interface {
 	Transcribe(ctx context.Context, audioData []byte, filename string) (string, error)
 }
 
+// MessageAcker is notified once a polled message has been fully processed.
+// Release lets further messages be fetched while one is held back unprocessed,
+// as a debounce does.
+type MessageAcker interface {
+	Ack(updateID int64)
+	Release(updateID int64)
+}
+
 // VoiceDownloader abstracts the Telegram voice file download for testability.
 type VoiceDownloader interface {
 	GetFile(ctx context.Context, fileID string) (string, error)
 	DownloadFile(ctx context.Context, filePath string) ([]byte, error)
 }
 
+// latencyEstimator is implemented by LLM clients that track how long their
+// completions usually take.
+type latencyEstimator interface {
+	Latency() (time.Duration, bool)
+}
+
+// memoryStatter is implemented by memory stores that can count their entries
+// (used by /status).
+type memoryStatter interface {
+	Stats(ctx context.Context, since time.Duration) (memory.Stats, error)
+}
+
+// modelSwitcher is implemented by LLM clients whose text model can be changed
+// while the agent runs (used by /model).
+type modelSwitcher interface {
+	Model() string
+	SetModel(model string)
+}
+
+// StatusMessenger posts and removes transient status messages (e.g. "Working…").
+type StatusMessenger interface {
+	SendMessage(ctx context.Context, chatID int64, text string) (int64, error)
+	DeleteMessage(ctx context.Context, chatID, messageID int64) error
+}
+
+// StatusEditor edits a message the bot sent, used to update the status
+// placeholder as tool calls run.
+type StatusEditor interface {
+	EditMessageText(ctx context.Context, chatID, messageID int64, text string) error
+}
+
+// SubAgentMonitor reports whether a sub-agent is running (shown by /status).
+type SubAgentMonitor interface {
+	IsActive() bool
+}
+
+// DocumentSender uploads a file to a chat (used by /export).
+type DocumentSender interface {
+	SendDocument(ctx context.Context, chatID int64, fileName string, data []byte, caption string) error
+}
+
+// ChatActionSender shows a chat action such as "typing" while a message is processed.
+type ChatActionSender interface {
+	SendChatAction(ctx context.Context, chatID int64, action string) error
+}
+
 // NewAgentConfig holds all dependencies for Agent construction.
 type NewAgentConfig struct {
-	Workspace       *workspace.Workspace
-	LLM             LLMClient
-	Sender          Sender
-	Memory          MemoryWriter
-	MemorySearcher  MemorySearcher
-	ToolExecutor    ToolExecutor
-	FileChanges     <-chan struct{}
-	HeartbeatTick   <-chan time.Time
-	Heartbeat       HeartbeatExecutor
-	Transcriber     Transcriber
-	VoiceDownloader VoiceDownloader
-	SubAgentResults <-chan subagent.SubAgentResult
-	OwnerIDs        []int64 // Telegram chat IDs for unsolicited messages (sub-agent results)
+	Workspace        *workspace.Workspace
+	LLM              LLMClient
+	Sender           Sender
+	Memory           MemoryWriter
+	MemorySearcher   MemorySearcher
+	ToolExecutor     ToolExecutor
+	FileChanges      <-chan watcher.Change
+	HeartbeatTick    <-chan time.Time
+	Heartbeat        HeartbeatExecutor
+	Transcriber      Transcriber
+	VoiceDownloader  VoiceDownloader
+	ChatActions      ChatActionSender // Shows "typing…" while the LLM and tools work (nil = no indicator)
+	SubAgentResults  <-chan subagent.SubAgentResult
+	SubAgents        SubAgentMonitor
+	OwnerIDs         []int64 // Telegram chat IDs for unsolicited messages (sub-agent results)
+	FormatCodeBlocks bool    // Convert fenced code blocks in replies to Telegram HTML code blocks
+	RetryBudget      int     // Total retries allowed across all operations for one message (0 = unlimited)
+	StatusMessenger  StatusMessenger
+	StatusEditor     StatusEditor
+	StatusText       string // Placeholder posted while a message is processed (empty = none)
+	KeepStatus       bool   // Leave the placeholder in chat instead of deleting it after the reply
+	MemoryVerbosity  string // all (default), messages-only, or none
+	DocumentSender   DocumentSender
+	MessageTimeout   time.Duration      // Time limit for handling one message end to end (0 = none)
+	DownloadTimeout  time.Duration      // Time limit for fetching a voice file from Telegram (0 = none)
+	FallbackReply    string             // Sent when handling a message ends without any reply (empty = none)
+	Acker            MessageAcker       // Acknowledges processed messages to the poller (nil = no checkpointing)
+	SubAgentDocument bool               // Also upload each full sub-agent result to the owners as a document
+	Attachments      bool               // Let replies name workspace files to upload alongside the text
+	MinFreeDisk      uint64             // Free bytes below which non-critical memory writes are skipped (0 = no check)
+	Redactor         *platform.Redactor // Masks secrets in tool results and memory entries (nil = none)
+	SubAgentDeadline time.Duration      // RunSubAgent stops working after this long and writes a partial result.md (0 = none)
+	MaxReplyChunks   int                // Messages a long reply is split into at most; the full text follows as a document (0 = unlimited)
+	Debounce         time.Duration      // Wait this long for more text from the same chat and answer the burst as one turn (0 = off)
+	ResultWebhookURL string             // Sub-agent results are also POSTed as JSON to this URL (empty = off)
+	WebhookOnly      bool               // Skip Telegram for sub-agent results the webhook accepted
+	EnvSummary       string             // text/template over SystemInfo logged to memory after introspection instead of the full section (empty = full section)
+	PresenceWindow   time.Duration      // Heartbeats are skipped unless the owner wrote within this long, per memory (0 = always run)
+	TypingMinLatency time.Duration      // Show typing only if the model's rolling latency is at least this (0 = always)
+	AllowedModels    []string           // Text models /model may switch to (empty = switching disabled)
+	HistoryMaxAge    time.Duration      // Clear the conversation history when its newest turn is older than this at a new message (0 = never)
+	SkipUnreachable  bool               // Stop sending to a chat once Telegram reports the bot blocked or the chat gone, until it writes again
+	// Extra system instruction per chat ID, e.g. to trial a prompt variant (nil = none).
+	PromptSuffixes map[int64]string
+	// System prompt sections in order, see workspace.SystemPromptSections (nil = workspace.DefaultPromptSections).
+	PromptSections []string
+	// Persists a /model switch, e.g. to config.json (nil = the switch lasts until restart).
+	SaveModel func(model string) error
 }
 
 // Agent orchestrates the event loop: receives messages, calls LLM, sends responses.
 type Agent struct {
-	workspace       *workspace.Workspace
-	llm             LLMClient
-	sender          Sender
-	memory          MemoryWriter
-	memorySearcher  MemorySearcher
-	toolExecutor    ToolExecutor
-	fileChanges     <-chan struct{}
-	heartbeatTick   <-chan time.Time
-	heartbeat       HeartbeatExecutor
-	transcriber     Transcriber
-	voiceDownloader VoiceDownloader
-	subAgentResults <-chan subagent.SubAgentResult
-	ownerIDs        []int64 // Telegram chat IDs for unsolicited messages
-	history         []llm.Message
+	workspace        *workspace.Workspace
+	llm              LLMClient
+	sender           Sender
+	memory           MemoryWriter
+	memorySearcher   MemorySearcher
+	toolExecutor     ToolExecutor
+	fileChanges      <-chan watcher.Change
+	heartbeatTick    <-chan time.Time
+	heartbeat        HeartbeatExecutor
+	transcriber      Transcriber
+	voiceDownloader  VoiceDownloader
+	subAgentResults  <-chan subagent.SubAgentResult
+	subAgents        SubAgentMonitor
+	ownerIDs         []int64 // Telegram chat IDs for unsolicited messages
+	formatCodeBlocks bool
+	retryBudget      int
+	statusMessenger  StatusMessenger
+	statusText       string
+	keepStatus       bool
+	statusEditor     StatusEditor
+	memoryVerbosity  string
+	documentSender   DocumentSender
+	chatActions      ChatActionSender
+	messageTimeout   time.Duration
+	downloadTimeout  time.Duration
+	fallbackReply    string
+	acker            MessageAcker
+	subAgentDocument bool
+	attachments      bool
+	promptSuffixes   map[int64]string
+	promptSections   []string
+	minFreeDisk      uint64
+	redactor         *platform.Redactor
+	subAgentDeadline time.Duration
+	maxReplyChunks   int
+	debounce         time.Duration
+	webhookURL       string
+	webhookOnly      bool
+	envSummary       string
+	presenceWindow   time.Duration
+	typingMinLatency time.Duration
+	allowedModels    []string
+	saveModel        func(string) error
+	unreachable      *unreachableSender // nil unless SkipUnreachable is set; also a.sender
+	historyMaxAge    time.Duration
+	historyAt        time.Time   // when the newest history turn was added
+	startedAt        time.Time   // when the agent was created, for /status
+	diskLow          atomic.Bool // set while free disk is below minFreeDisk; the owners are alerted once
+	heartbeatRunning atomic.Bool // set while a heartbeat executes; overlapping ticks are skipped
+	history          []llm.Message
+	// warmUp runs the startup work once, see WarmUp; ready is closed when it is done.
+	warmUp sync.Once
+	ready  chan struct{}
+	// webhookPosts tracks result webhook posts, which run off the event loop.
+	webhookPosts sync.WaitGroup
+	// ownerSeenAt is when the owner last wrote, in Unix nanoseconds (0 = not yet), see ownerPresent.
+	ownerSeenAt atomic.Int64
 }
 
 // New creates a new Agent with the given dependencies.
 func New(cfg NewAgentConfig) *Agent {
-	return &Agent{
-		workspace:       cfg.Workspace,
-		llm:             cfg.LLM,
-		sender:          cfg.Sender,
-		memory:          cfg.Memory,
-		memorySearcher:  cfg.MemorySearcher,
-		toolExecutor:    cfg.ToolExecutor,
-		fileChanges:     cfg.FileChanges,
-		heartbeatTick:   cfg.HeartbeatTick,
-		heartbeat:       cfg.Heartbeat,
-		transcriber:     cfg.Transcriber,
-		voiceDownloader: cfg.VoiceDownloader,
-		subAgentResults: cfg.SubAgentResults,
-		ownerIDs:        cfg.OwnerIDs,
+	a := &Agent{
+		workspace:        cfg.Workspace,
+		llm:              cfg.LLM,
+		sender:           cfg.Sender,
+		memory:           cfg.Memory,
+		memorySearcher:   cfg.MemorySearcher,
+		toolExecutor:     cfg.ToolExecutor,
+		fileChanges:      cfg.FileChanges,
+		heartbeatTick:    cfg.HeartbeatTick,
+		heartbeat:        cfg.Heartbeat,
+		transcriber:      cfg.Transcriber,
+		voiceDownloader:  cfg.VoiceDownloader,
+		subAgentResults:  cfg.SubAgentResults,
+		subAgents:        cfg.SubAgents,
+		ownerIDs:         cfg.OwnerIDs,
+		formatCodeBlocks: cfg.FormatCodeBlocks,
+		retryBudget:      cfg.RetryBudget,
+		statusMessenger:  cfg.StatusMessenger,
+		statusText:       cfg.StatusText,
+		keepStatus:       cfg.KeepStatus,
+		statusEditor:     cfg.StatusEditor,
+		memoryVerbosity:  memoryVerbosity(cfg.MemoryVerbosity),
+		documentSender:   cfg.DocumentSender,
+		chatActions:      cfg.ChatActions,
+		messageTimeout:   cfg.MessageTimeout,
+		downloadTimeout:  cfg.DownloadTimeout,
+		fallbackReply:    cfg.FallbackReply,
+		acker:            cfg.Acker,
+		subAgentDocument: cfg.SubAgentDocument,
+		attachments:      cfg.Attachments,
+		promptSuffixes:   cfg.PromptSuffixes,
+		promptSections:   cfg.PromptSections,
+		minFreeDisk:      cfg.MinFreeDisk,
+		redactor:         cfg.Redactor,
+		subAgentDeadline: cfg.SubAgentDeadline,
+		maxReplyChunks:   cfg.MaxReplyChunks,
+		debounce:         cfg.Debounce,
+		webhookURL:       cfg.ResultWebhookURL,
+		webhookOnly:      cfg.WebhookOnly,
+		envSummary:       cfg.EnvSummary,
+		presenceWindow:   cfg.PresenceWindow,
+		typingMinLatency: cfg.TypingMinLatency,
+		allowedModels:    cfg.AllowedModels,
+		saveModel:        cfg.SaveModel,
+		historyMaxAge:    cfg.HistoryMaxAge,
+		startedAt:        commandNow(),
+		ready:            make(chan struct{}),
 	}
+	if cfg.SkipUnreachable && cfg.Sender != nil {
+		a.unreachable = newUnreachableSender(cfg.Sender)
+		a.sender = a.unreachable
+		a.statusMessenger = a.unreachable.status(cfg.StatusMessenger)
+		a.statusEditor = a.unreachable.editor(cfg.StatusEditor)
+		a.documentSender = a.unreachable.documents(cfg.DocumentSender)
+		a.chatActions = a.unreachable.chatActions(cfg.ChatActions)
+	}
+	return a
 }
 
-// Run starts the event loop, processing messages sequentially until the context is cancelled.
-func (a *Agent) Run(ctx context.Context, messages <-chan telegram.TelegramMessage) error {
-	slog.Info("event loop started", "component", "agent", "operation", "run")
-
-	if err := a.runIntrospectionIfNeeded(ctx); err != nil {
-		slog.Warn("introspection failed",
+// memoryVerbosity validates a configured verbosity, falling back to MemoryVerbosityAll.
+func memoryVerbosity(v string) string {
+	switch v {
+	case MemoryVerbosityAll, MemoryVerbosityMessagesOnly, MemoryVerbosityNone:
+		return v
+	case "":
+		return MemoryVerbosityAll
+	default:
+		slog.Warn("unknown memory verbosity, persisting everything",
 			"component", "agent",
-			"operation", "introspection",
-			"error", err,
+			"operation", "new",
+			"memory_verbosity", v,
 		)
+		return MemoryVerbosityAll
 	}
+}
+
+// Run starts the event loop, processing messages sequentially until the context is cancelled.
+func (a *Agent) Run(ctx context.Context, messages <-chan telegram.TelegramMessage) error {
+	platform.Log(ctx).Info("event loop started", "component", "agent", "operation", "run")
+
+	// Without an explicit WarmUp, the workspace given to New is trusted as loaded.
+	a.warmUp.Do(func() {
+		defer close(a.ready)
+		a.seedOwnerSeen(ctx)
+		a.introspect(ctx)
+		if err := a.refreshCapabilities(); err != nil {
+			platform.Log(ctx).Warn("capabilities refresh failed",
+				"component", "agent",
+				"operation", "capabilities",
+				"error", err,
+			)
+		}
+	})
 
 	for {
 		select {
 		case <-ctx.Done():
-			slog.Info("event loop stopped", "component", "agent", "operation", "run")
+			a.webhookPosts.Wait()
+			platform.Log(ctx).Info("event loop stopped", "component", "agent", "operation", "run")
 			return nil
 		case msg := <-messages:
-			a.handleMessage(ctx, msg)
-		case <-a.fileChanges:
-			a.handleFileChange(ctx)
+			a.handleBurst(ctx, msg, messages)
+		case change := <-a.fileChanges:
+			a.handleWorkspaceChange(ctx, change)
 		case <-a.heartbeatTick:
 			a.handleHeartbeat(ctx)
 		case result := <-a.subAgentResults:
@@ -149,25 +379,151 @@ func (a *Agent) Run(ctx context.Context, messages <-chan telegram.TelegramMessag
 	}
 }
 
+// WarmUp does the startup work before the agent receives messages: it runs
+// the first-run introspection, then reloads the workspace from disk so the
+// prompt reflects AGENT.md as introspection left it, and refreshes the
+// capabilities section. Ready is closed once it returns. Only the first call
+// has an effect; Run skips the work if WarmUp already ran, and otherwise does
+// it without the reload. A reload failure is returned, and the workspace given
+// to New is kept.
+func (a *Agent) WarmUp(ctx context.Context) error {
+	var err error
+	a.warmUp.Do(func() {
+		defer close(a.ready)
+		started := time.Now()
+		a.seedOwnerSeen(ctx)
+		a.introspect(ctx)
+		if a.workspace == nil || a.workspace.Root == "" {
+			return
+		}
+		if err = a.reloadWorkspace(ctx); err != nil {
+			err = fmt.Errorf("agent: warm-up: %w", err)
+			return
+		}
+		platform.Log(ctx).Info("warm-up complete",
+			"component", "agent",
+			"operation", "warm_up",
+			"skills", len(a.workspace.Skills),
+			"duration", time.Since(started),
+		)
+	})
+	return err
+}
+
+// Ready returns a channel closed once the startup work is done, by WarmUp or
+// at the start of Run.
+func (a *Agent) Ready() <-chan struct{} {
+	return a.ready
+}
+
+// introspect runs the first-run introspection, logging a failure.
+func (a *Agent) introspect(ctx context.Context) {
+	if err := a.runIntrospectionIfNeeded(ctx); err != nil {
+		platform.Log(ctx).Warn("introspection failed",
+			"component", "agent",
+			"operation", "introspection",
+			"error", err,
+		)
+	}
+}
+
+// handleBurst handles msg, first coalescing it with the text messages that
+// quickly follow it when debouncing is enabled. Every message is acknowledged
+// once its turn is processed.
+func (a *Agent) handleBurst(ctx context.Context, msg telegram.TelegramMessage, messages <-chan telegram.TelegramMessage) {
+	for {
+		burst, next := a.collectBurst(ctx, msg, messages)
+		a.handleMessage(ctx, mergeBurst(ctx, burst))
+		for _, m := range burst {
+			a.ackMessage(m)
+		}
+		if next == nil {
+			return
+		}
+		msg = *next
+	}
+}
+
+// ackMessage tells the poller a message has been processed so its offset can be checkpointed.
+func (a *Agent) ackMessage(msg telegram.TelegramMessage) {
+	if a.acker != nil && msg.UpdateID != 0 {
+		a.acker.Ack(msg.UpdateID)
+	}
+}
+
+// releaseMessage lets the poller fetch past a message held for a debounce.
+func (a *Agent) releaseMessage(msg telegram.TelegramMessage) {
+	if a.acker != nil && msg.UpdateID != 0 {
+		a.acker.Release(msg.UpdateID)
+	}
+}
+
+// HandleMessage processes one message synchronously, outside the event loop.
+// It is meant for offline drivers such as transcript replay; Run remains the
+// entry point for live operation.
+func (a *Agent) HandleMessage(ctx context.Context, msg telegram.TelegramMessage) {
+	a.handleMessage(ctx, msg)
+}
+
 // handleMessage processes a single incoming Telegram message through the LLM pipeline.
 func (a *Agent) handleMessage(ctx context.Context, msg telegram.TelegramMessage) {
 	// Skip zero-value messages (closed channel).
 	if msg.Message.Text == "" && msg.Message.Voice == nil && msg.Message.Chat.ID == 0 {
-		slog.Debug("skipping empty message", "component", "agent", "operation", "handle_message")
+		platform.Log(ctx).Debug("skipping empty message", "component", "agent", "operation", "handle_message")
 		return
 	}
 
-	slog.Info("processing message",
+	a.markOwnerSeen()
+
+	// A chat that writes has unblocked the bot.
+	if a.unreachable != nil {
+		a.unreachable.markReachable(msg.Message.Chat.ID)
+	}
+
+	// Correlate every log line emitted while handling this message.
+	ctx = platform.WithTraceID(ctx, platform.NewTraceID())
+	// Let per-chat memory file and read this message's entries under its chat.
+	ctx = platform.WithChatID(ctx, msg.Message.Chat.ID)
+	platform.Log(ctx).Info("processing message",
 		"component", "agent",
 		"operation", "handle_message",
 		"chat_id", msg.Message.Chat.ID,
 	)
 
+	// Guarantee the owner some response when every processing path fails.
+	if a.fallbackReply != "" {
+		state := &replyState{}
+		ctx = context.WithValue(ctx, replyStateKey{}, state)
+		defer a.sendFallback(ctx, msg.Message.Chat.ID, state)
+	}
+
+	if a.messageTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.messageTimeout)
+		defer cancel()
+	}
+
+	// Share one retry budget across LLM, transcription, and send retries so a
+	// provider outage fails the message fast instead of compounding backoffs.
+	if a.retryBudget > 0 {
+		ctx = platform.WithRetryBudget(ctx, platform.NewRetryBudget(a.retryBudget))
+	}
+
 	// Acknowledge receipt with a reaction emoji.
-	if a.sender != nil {
-		if err := a.sender.React(ctx, msg.Message.Chat.ID, msg.Message.MessageID, "\U0001F440"); err != nil {
-			slog.Debug("failed to set reaction", "component", "agent", "operation", "react", "error", err)
+	a.react(ctx, msg.Message.Chat.ID, msg.Message.MessageID)
+
+	// Tell the owner about message kinds we can't handle instead of ignoring them.
+	if kind, ok := msg.Message.UnsupportedType(); ok {
+		a.handleUnsupported(ctx, msg.Message.Chat.ID, kind)
+		return
+	}
+
+	// Post a transient status placeholder, removed once the reply has been sent.
+	if statusID, ok := a.postStatus(ctx, msg.Message.Chat.ID); ok {
+		if !a.keepStatus {
+			defer a.clearStatus(ctx, msg.Message.Chat.ID, statusID)
 		}
+		ctx = a.withStatusProgress(ctx, msg.Message.Chat.ID, statusID)
 	}
 
 	// Determine user text — either from text or voice transcription.
@@ -175,17 +531,17 @@ func (a *Agent) handleMessage(ctx context.Context, msg telegram.TelegramMessage)
 	if msg.Message.Voice != nil {
 		transcribed, err := a.transcribeVoice(ctx, msg.Message.Voice.FileID)
 		if err != nil {
-			slog.Error("voice transcription failed",
+			platform.Log(ctx).Error("voice transcription failed",
 				"component", "agent",
 				"operation", "transcribe_voice",
 				"error", err,
 			)
-			a.sender.Send(ctx, msg.Message.Chat.ID,
+			a.send(ctx, msg.Message.Chat.ID,
 				fmt.Sprintf("Failed to transcribe voice message: %v", err))
 			return
 		}
 		userText = transcribed
-		slog.Info("voice message transcribed",
+		platform.Log(ctx).Info("voice message transcribed",
 			"component", "agent",
 			"operation", "transcribe_voice",
 			"duration", msg.Message.Voice.Duration,
@@ -197,22 +553,43 @@ func (a *Agent) handleMessage(ctx context.Context, msg telegram.TelegramMessage)
 		return
 	}
 
+	// Slash commands typed by the owner are handled directly, without the LLM.
+	if msg.Message.Voice == nil && a.handleCommand(ctx, msg.Message.Chat.ID, userText) {
+		return
+	}
+
+	// Give the LLM the original author of forwarded messages. The allowlist has
+	// already been applied to the forwarding sender by the poller.
+	if origin, ok := msg.Message.ForwardedFrom(); ok {
+		userText = "[forwarded from " + origin + "]\n" + userText
+	}
+	// Keep the thread when the owner replies to an earlier message.
+	if quote, ok := msg.Message.QuotedText(); ok {
+		userText = "[in reply to: " + quote + "]\n" + userText
+	}
+
+	a.expireHistory(ctx)
+
 	if msg.Message.Voice != nil {
 		a.logMemory(ctx, "voice-transcription", userText)
 	} else {
 		a.logMemory(ctx, "owner", userText)
 	}
 
-	msgs := a.buildMessages(userText)
+	msgs := a.buildMessages(msg.Message.Chat.ID, userText)
 	tools := a.toolDefinitions()
 
 	var resp *llm.ChatResponse
 	var err error
 
+	// Show "typing…" until the tool loop has produced the reply.
+	stopTyping := a.startTyping(ctx, msg.Message.Chat.ID)
+	defer stopTyping()
+
 	for round := range maxToolRounds {
 		resp, err = a.llm.ChatCompletionWithRetry(ctx, msgs, tools)
 		if err != nil {
-			slog.Error("LLM call failed",
+			platform.Log(ctx).Error("LLM call failed",
 				"component", "agent",
 				"operation", "handle_message",
 				"error", err,
@@ -221,7 +598,7 @@ func (a *Agent) handleMessage(ctx context.Context, msg telegram.TelegramMessage)
 		}
 
 		if len(resp.Choices) == 0 {
-			slog.Error("LLM returned no choices",
+			platform.Log(ctx).Error("LLM returned no choices",
 				"component", "agent",
 				"operation", "handle_message",
 			)
@@ -233,7 +610,7 @@ func (a *Agent) handleMessage(ctx context.Context, msg telegram.TelegramMessage)
 		}
 
 		if a.toolExecutor == nil {
-			slog.Warn("LLM returned tool calls but no executor configured",
+			platform.Log(ctx).Warn("LLM returned tool calls but no executor configured",
 				"component", "agent",
 				"operation", "handle_message",
 			)
@@ -241,12 +618,10 @@ func (a *Agent) handleMessage(ctx context.Context, msg telegram.TelegramMessage)
 		}
 
 		toolMsgs := a.executeToolCalls(ctx, resp.Choices[0].Message)
-		assistantMsg := resp.Choices[0].Message
-		normalizeToolCallTypes(&assistantMsg)
-		msgs = append(msgs, assistantMsg)
+		msgs = append(msgs, resp.Choices[0].Message)
 		msgs = append(msgs, toolMsgs...)
 
-		slog.Info("tool round completed",
+		platform.Log(ctx).Info("tool round completed",
 			"component", "agent",
 			"operation", "handle_message",
 			"round", round+1,
@@ -254,9 +629,11 @@ func (a *Agent) handleMessage(ctx context.Context, msg telegram.TelegramMessage)
 		)
 	}
 
+	stopTyping()
+
 	// Check if loop exhausted without a text response.
 	if llm.HasToolCalls(&resp.Choices[0]) {
-		slog.Warn("max tool rounds exceeded without final response",
+		platform.Log(ctx).Warn("max tool rounds exceeded without final response",
 			"component", "agent",
 			"operation", "handle_message",
 			"max_rounds", maxToolRounds,
@@ -264,47 +641,143 @@ func (a *Agent) handleMessage(ctx context.Context, msg telegram.TelegramMessage)
 		return
 	}
 
+	platform.Log(ctx).Info("LLM response received",
+		"component", "agent",
+		"operation", "handle_message",
+		"model", resp.Model,
+	)
+
 	content := resp.Choices[0].Message.Content
 	agentResp, err := llm.ParseAgentResponse(content)
 	if err != nil {
-		slog.Error("failed to parse agent response",
+		platform.Log(ctx).Error("failed to parse agent response",
 			"component", "agent",
 			"operation", "handle_message",
 			"error", err,
 		)
 		return
 	}
+	if resp.Choices[0].FinishReason == "length" {
+		if recovered, ok := llm.RecoverTruncatedMessage(content); ok {
+			platform.Log(ctx).Warn("recovered truncated response",
+				"component", "agent",
+				"operation", "handle_message",
+				"content_length", len(recovered.Content),
+			)
+			recovered.Content += truncatedNote
+			agentResp = recovered
+		}
+	}
 
 	switch agentResp.Type {
 	case "message":
-		if err := a.sender.Send(ctx, msg.Message.Chat.ID, agentResp.Content); err != nil {
-			slog.Error("failed to send message",
+		if err := a.sendReply(ctx, msg.Message.Chat.ID, agentResp.Content); err != nil {
+			platform.Log(ctx).Error("failed to send message",
 				"component", "agent",
 				"operation", "handle_message",
 				"error", err,
 			)
 		}
-		a.logMemory(ctx, "agent", agentResp.Content)
+		if a.attachments {
+			a.sendAttachments(ctx, msg.Message.Chat.ID, agentResp.Attachments)
+		}
+		a.logMemory(ctx, "agent", agentResp.Content, modelTag(resp.Model)...)
 		a.addToHistory(userText, agentResp.Content)
 	case "think":
-		slog.Debug("think response",
+		markQuiet(ctx)
+		platform.Log(ctx).Debug("think response",
 			"component", "agent",
 			"operation", "handle_message",
 			"content", agentResp.Content,
 		)
 	case "noop":
-		slog.Debug("noop response",
+		markQuiet(ctx)
+		platform.Log(ctx).Debug("noop response",
 			"component", "agent",
 			"operation", "handle_message",
 		)
 	}
 }
 
+// react acknowledges an owner message with an emoji. Heartbeat-originated work
+// answers no message, so it never reacts.
+func (a *Agent) react(ctx context.Context, chatID, messageID int64) {
+	if a.sender == nil || fromHeartbeat(ctx) {
+		return
+	}
+	if err := a.sender.React(ctx, chatID, messageID, "\U0001F440"); err != nil {
+		platform.Log(ctx).Debug("failed to set reaction", "component", "agent", "operation", "react", "error", err)
+	}
+}
+
+// fromHeartbeat reports whether ctx belongs to a heartbeat cycle.
+func fromHeartbeat(ctx context.Context) bool {
+	return platform.OriginFrom(ctx) == platform.OriginHeartbeat
+}
+
+// postStatus sends the configured status placeholder to chatID and returns its message ID.
+func (a *Agent) postStatus(ctx context.Context, chatID int64) (int64, bool) {
+	if a.statusMessenger == nil || a.statusText == "" || fromHeartbeat(ctx) {
+		return 0, false
+	}
+	id, err := a.statusMessenger.SendMessage(ctx, chatID, a.statusText)
+	if err != nil {
+		platform.Log(ctx).Debug("failed to send status placeholder", "component", "agent", "operation", "status", "error", err)
+		return 0, false
+	}
+	return id, true
+}
+
+// expectSlowReply reports whether the model is slow enough to answer that the
+// typing indicator is worth showing: its rolling latency is at least
+// typingMinLatency. Without a threshold, or a latency estimate to judge by, it is.
+func (a *Agent) expectSlowReply() bool {
+	if a.typingMinLatency <= 0 {
+		return true
+	}
+	est, ok := a.llm.(latencyEstimator)
+	if !ok {
+		return true
+	}
+	d, ok := est.Latency()
+	return !ok || d >= a.typingMinLatency
+}
+
+// clearStatus deletes a status placeholder. Failures are logged and otherwise ignored:
+// a lingering placeholder is cosmetic and must not affect the reply.
+func (a *Agent) clearStatus(ctx context.Context, chatID, messageID int64) {
+	if err := a.statusMessenger.DeleteMessage(ctx, chatID, messageID); err != nil {
+		platform.Log(ctx).Warn("failed to delete status placeholder",
+			"component", "agent",
+			"operation", "status",
+			"chat_id", chatID,
+			"message_id", messageID,
+			"error", err,
+		)
+	}
+}
+
 // executeToolCalls runs each tool call and returns tool result messages.
 func (a *Agent) executeToolCalls(ctx context.Context, assistantMsg llm.Message) []llm.Message {
+	ctx = tool.WithContext(ctx, a.toolContext(ctx))
 	var toolMsgs []llm.Message
-	for _, tc := range assistantMsg.ToolCalls {
+	for i, tc := range assistantMsg.ToolCalls {
+		reportToolProgress(ctx, i+1, len(assistantMsg.ToolCalls), tc.Function.Name)
 		result := a.toolExecutor.Execute(ctx, tc.Function.Name, json.RawMessage(tc.Function.Arguments))
+		if summary, ok := binarySummary(result.Output); ok {
+			platform.Log(ctx).Warn("binary tool output replaced with summary",
+				"component", "agent",
+				"operation", "execute_tool",
+				"tool_name", tc.Function.Name,
+				"bytes", len(result.Output),
+			)
+			result.Output = summary
+		}
+		if summary, ok := binarySummary(result.Error); ok {
+			result.Error = summary
+		}
+		result.Output = a.redactor.Redact(result.Output)
+		result.Error = a.redactor.Redact(result.Error)
 
 		resultJSON, _ := json.Marshal(result)
 
@@ -315,7 +788,7 @@ func (a *Agent) executeToolCalls(ctx context.Context, assistantMsg llm.Message)
 			ToolCallID: tc.ID,
 		})
 
-		slog.Info("tool executed",
+		platform.Log(ctx).Info("tool executed",
 			"component", "agent",
 			"operation", "execute_tool",
 			"tool_name", tc.Function.Name,
@@ -326,6 +799,30 @@ func (a *Agent) executeToolCalls(ctx context.Context, assistantMsg llm.Message)
 	return toolMsgs
 }
 
+// binarySummary reports whether s is binary (invalid UTF-8 or containing NUL bytes)
+// and, if so, returns a short description to send to the LLM instead of the raw bytes.
+func binarySummary(s string) (string, bool) {
+	if utf8.ValidString(s) && !strings.ContainsRune(s, 0) {
+		return "", false
+	}
+	sum := sha256.Sum256([]byte(s))
+	return fmt.Sprintf("[binary content, %d bytes, sha256 %x]", len(s), sum), true
+}
+
+// toolContext describes the agent's environment to tool handlers. Calls made
+// while handling a message count as the owner's, since only allowlisted
+// senders reach the agent; heartbeat work does not.
+func (a *Agent) toolContext(ctx context.Context) tool.ToolContext {
+	tc := tool.ToolContext{
+		History: slices.Clone(a.history),
+		Owner:   platform.OriginFrom(ctx) != platform.OriginHeartbeat,
+	}
+	if a.workspace != nil {
+		tc.WorkspaceRoot = a.workspace.Root
+	}
+	return tc
+}
+
 // toolDefinitions returns LLM tool definitions if a tool executor is configured.
 func (a *Agent) toolDefinitions() []llm.Tool {
 	if a.toolExecutor == nil {
@@ -334,16 +831,48 @@ func (a *Agent) toolDefinitions() []llm.Tool {
 	return a.toolExecutor.Definitions()
 }
 
+// handleWorkspaceChange reloads the workspace once for change and any changes
+// already queued behind it, provided one of them touches a file under the
+// workspace root. Watchers of other workspaces may share the channel, so
+// changes elsewhere are ignored.
+func (a *Agent) handleWorkspaceChange(ctx context.Context, change watcher.Change) {
+	changes := []watcher.Change{change}
+	for drained := false; !drained; {
+		select {
+		case more := <-a.fileChanges:
+			changes = append(changes, more)
+		default:
+			drained = true
+		}
+	}
+
+	if a.workspace == nil || !slices.ContainsFunc(changes, a.touchesWorkspace) {
+		platform.Log(ctx).Debug("ignoring file change outside the workspace",
+			"component", "agent",
+			"operation", "file_change",
+			"events", len(changes),
+		)
+		return
+	}
+	a.handleFileChange(ctx)
+}
+
+// touchesWorkspace reports whether any path in change lies under the workspace root.
+func (a *Agent) touchesWorkspace(change watcher.Change) bool {
+	return slices.ContainsFunc(change.Paths, func(path string) bool {
+		return platform.ValidatePath(a.workspace.Root, path) == nil
+	})
+}
+
 // handleFileChange reloads the workspace from disk after a file change is detected.
 func (a *Agent) handleFileChange(ctx context.Context) {
-	slog.Info("workspace file change detected",
+	platform.Log(ctx).Info("workspace file change detected",
 		"component", "agent",
 		"operation", "file_change",
 	)
 
-	newWS, err := agentWorkspaceLoadFn(a.workspace.Root)
-	if err != nil {
-		slog.Error("workspace reload failed on file change",
+	if err := a.reloadWorkspace(ctx); err != nil {
+		platform.Log(ctx).Error("workspace reload failed on file change",
 			"component", "agent",
 			"operation", "file_change",
 			"error", err,
@@ -351,41 +880,80 @@ func (a *Agent) handleFileChange(ctx context.Context) {
 		return
 	}
 
-	*a.workspace = *newWS
-
-	slog.Info("workspace hot-reloaded",
+	platform.Log(ctx).Info("workspace hot-reloaded",
 		"component", "agent",
 		"operation", "file_change",
 		"skills", len(a.workspace.Skills),
 	)
 }
 
+// reloadWorkspace reloads the workspace from disk in place and refreshes the
+// capabilities derived from it. On error the current workspace is kept.
+func (a *Agent) reloadWorkspace(ctx context.Context) error {
+	newWS, err := agentWorkspaceLoadFn(a.workspace.Root, a.workspace.MaxSkills)
+	if err != nil {
+		return err
+	}
+
+	*a.workspace = *newWS
+	if err := a.refreshCapabilities(); err != nil {
+		platform.Log(ctx).Warn("capabilities refresh failed",
+			"component", "agent",
+			"operation", "capabilities",
+			"error", err,
+		)
+	}
+	return nil
+}
+
 // handleHeartbeat runs one heartbeat cycle using the configured executor.
 func (a *Agent) handleHeartbeat(ctx context.Context) {
 	if a.heartbeat == nil {
-		slog.Warn("heartbeat tick received but no executor configured",
+		platform.Log(ctx).Warn("heartbeat tick received but no executor configured",
 			"component", "agent",
 			"operation", "heartbeat",
 		)
 		return
 	}
 
+	// A tick arriving while the previous heartbeat still runs is dropped rather
+	// than queued, so a slow heartbeat can't pile up duplicate side effects.
+	if !a.heartbeatRunning.CompareAndSwap(false, true) {
+		platform.Log(ctx).Warn("previous heartbeat still running, skipping tick",
+			"component", "agent",
+			"operation", "heartbeat",
+		)
+		return
+	}
+	defer a.heartbeatRunning.Store(false)
+	ctx = platform.WithOrigin(ctx, platform.OriginHeartbeat)
+
 	heartbeatContent := a.workspace.HeartbeatMD
 	if heartbeatContent == "" {
-		slog.Warn("heartbeat tick received but HEARTBEAT.md is empty",
+		platform.Log(ctx).Warn("heartbeat tick received but HEARTBEAT.md is empty",
 			"component", "agent",
 			"operation", "heartbeat",
 		)
 		return
 	}
 
-	slog.Info("heartbeat cycle starting",
+	// The checklist still runs for an owner who seems away, but doesn't notify them.
+	if !a.ownerPresent() {
+		platform.Log(ctx).Info("owner away, heartbeat alerts suppressed",
+			"component", "agent",
+			"operation", "heartbeat",
+			"presence_window", a.presenceWindow,
+		)
+		ctx = platform.WithQuiet(ctx)
+	}
+
+	platform.Log(ctx).Info("heartbeat cycle starting",
 		"component", "agent",
 		"operation", "heartbeat",
 	)
 
 	if err := a.heartbeat.Execute(ctx, heartbeatContent); err != nil {
-		slog.Error("heartbeat execution failed",
+		platform.Log(ctx).Error("heartbeat execution failed",
 			"component", "agent",
 			"operation", "heartbeat",
 			"error", err,
@@ -393,20 +961,99 @@ func (a *Agent) handleHeartbeat(ctx context.Context) {
 	}
 }
 
+// send sends a reply to the message being handled and records that it was
+// answered.
+func (a *Agent) send(ctx context.Context, chatID int64, text string) error {
+	err := a.sender.Send(ctx, chatID, text)
+	if err == nil {
+		markSent(ctx)
+	}
+	return err
+}
+
+// sendDocument uploads a file to the chat and, like send, counts it as a
+// reply to the message being handled. The caller checks documentSender.
+func (a *Agent) sendDocument(ctx context.Context, chatID int64, name string, data []byte, caption string) error {
+	err := a.documentSender.SendDocument(ctx, chatID, name, data, caption)
+	if err == nil {
+		markSent(ctx)
+	}
+	return err
+}
+
+// replyState records whether the message being handled got a response.
+type replyState struct {
+	sent  bool // a reply was delivered
+	quiet bool // the model deliberately chose not to reply (think or noop)
+}
+
+type replyStateKey struct{}
+
+// markSent records that the message being handled got a response.
+func markSent(ctx context.Context) {
+	if state, ok := ctx.Value(replyStateKey{}).(*replyState); ok {
+		state.sent = true
+	}
+}
+
+// markQuiet records that the model deliberately chose not to reply.
+func markQuiet(ctx context.Context) {
+	if state, ok := ctx.Value(replyStateKey{}).(*replyState); ok {
+		state.quiet = true
+	}
+}
+
+// sendFallback sends the fallback reply if handling ended without any reply
+// and without a deliberate think/noop. It ignores the message deadline so a
+// timed-out message still gets an answer.
+func (a *Agent) sendFallback(ctx context.Context, chatID int64, state *replyState) {
+	if state.sent || state.quiet {
+		return
+	}
+	platform.Log(ctx).Warn("message produced no reply, sending fallback",
+		"component", "agent",
+		"operation", "handle_message",
+		"chat_id", chatID,
+	)
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), fallbackSendTimeout)
+	defer cancel()
+	if err := a.send(ctx, chatID, a.fallbackReply); err != nil {
+		platform.Log(ctx).Error("failed to send fallback reply",
+			"component", "agent",
+			"operation", "handle_message",
+			"error", err,
+		)
+	}
+}
+
+// handleUnsupported records an unsupported message kind in memory and tells the
+// owner it can't be processed.
+func (a *Agent) handleUnsupported(ctx context.Context, chatID int64, kind string) {
+	platform.Log(ctx).Info("unsupported message type",
+		"component", "agent",
+		"operation", "handle_message",
+		"chat_id", chatID,
+		"type", kind,
+	)
+	a.logMemory(ctx, "owner", "["+kind+" message]")
+	if err := a.send(ctx, chatID, "I can't process "+kind+" messages yet."); err != nil {
+		platform.Log(ctx).Error("failed to send unsupported-type reply",
+			"component", "agent",
+			"operation", "handle_message",
+			"error", err,
+		)
+	}
+}
+
 // transcribeVoice downloads a voice file from Telegram and transcribes it via the Voxtral API.
 func (a *Agent) transcribeVoice(ctx context.Context, fileID string) (string, error) {
 	if a.voiceDownloader == nil || a.transcriber == nil {
 		return "", fmt.Errorf("voice transcription not configured")
 	}
 
-	filePath, err := a.voiceDownloader.GetFile(ctx, fileID)
-	if err != nil {
-		return "", fmt.Errorf("get voice file path: %w", err)
-	}
-
-	audioData, err := a.voiceDownloader.DownloadFile(ctx, filePath)
+	audioData, err := a.downloadVoice(ctx, fileID)
 	if err != nil {
-		return "", fmt.Errorf("download voice file: %w", err)
+		return "", err
 	}
 
 	text, err := a.transcriber.Transcribe(ctx, audioData, "voice.ogg")
@@ -417,10 +1064,30 @@ func (a *Agent) transcribeVoice(ctx context.Context, fileID string) (string, err
 	return text, nil
 }
 
+// downloadVoice fetches a voice file from Telegram within the configured download timeout.
+func (a *Agent) downloadVoice(ctx context.Context, fileID string) ([]byte, error) {
+	if a.downloadTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.downloadTimeout)
+		defer cancel()
+	}
+
+	filePath, err := a.voiceDownloader.GetFile(ctx, fileID)
+	if err != nil {
+		return nil, fmt.Errorf("get voice file path: %w", err)
+	}
+
+	audioData, err := a.voiceDownloader.DownloadFile(ctx, filePath)
+	if err != nil {
+		return nil, fmt.Errorf("download voice file: %w", err)
+	}
+	return audioData, nil
+}
+
 // handleSubAgentResult processes the result of a completed sub-agent.
 // Sends result summary to owner via Telegram and logs to memory.
 func (a *Agent) handleSubAgentResult(ctx context.Context, result subagent.SubAgentResult) {
-	slog.Info("sub-agent completed",
+	platform.Log(ctx).Info("sub-agent completed",
 		"component", "agent", "operation", "handle_sub_agent_result",
 		"task_id", result.TaskID, "timed_out", result.TimedOut,
 		"has_result", result.ResultContent != "")
@@ -430,7 +1097,7 @@ func (a *Agent) handleSubAgentResult(ctx context.Context, result subagent.SubAge
 
 	switch {
 	case result.TimedOut && result.ResultContent != "":
-		memoryEntry = fmt.Sprintf("Sub-agent '%s' timed out but partial result collected (%d bytes).", result.TaskID, len(result.ResultContent))
+		memoryEntry = fmt.Sprintf("Sub-agent '%s' timed out but partial result collected (%d bytes).\n\n%s", result.TaskID, len(result.ResultContent), result.ResultContent)
 		content := truncateForTelegram(result.ResultContent)
 		telegramMsg = fmt.Sprintf("[Sub-agent '%s' timed out — partial result]\n\n%s", result.TaskID, content)
 	case result.TimedOut:
@@ -442,6 +1109,7 @@ func (a *Agent) handleSubAgentResult(ctx context.Context, result subagent.SubAge
 	default:
 		memoryEntry = fmt.Sprintf("Sub-agent '%s' completed successfully.", result.TaskID)
 		if result.ResultContent != "" {
+			memoryEntry += "\n\n" + result.ResultContent
 			content := truncateForTelegram(result.ResultContent)
 			telegramMsg = fmt.Sprintf("[Sub-agent '%s' completed]\n\n%s", result.TaskID, content)
 		} else {
@@ -449,24 +1117,59 @@ func (a *Agent) handleSubAgentResult(ctx context.Context, result subagent.SubAge
 		}
 	}
 
+	// Memory keeps the full result; only the chat message is truncated.
 	a.logMemory(ctx, "sub-agent-result", memoryEntry)
 
+	if a.webhookURL == "" {
+		a.deliverSubAgentResult(ctx, result, telegramMsg)
+		return
+	}
+	if !a.webhookOnly {
+		a.deliverSubAgentResult(ctx, result, telegramMsg)
+	}
+	// The post runs off the event loop, after the Telegram delivery. With
+	// webhook-only delivery, Telegram is the fallback when the post fails.
+	ctx = context.WithoutCancel(ctx)
+	a.webhookPosts.Go(func() {
+		if a.postResultWebhook(ctx, result) != nil && a.webhookOnly {
+			a.deliverSubAgentResult(ctx, result, telegramMsg)
+		}
+	})
+}
+
+// deliverSubAgentResult sends a sub-agent result to the owners on Telegram,
+// with the full result as a document when enabled.
+func (a *Agent) deliverSubAgentResult(ctx context.Context, result subagent.SubAgentResult, telegramMsg string) {
 	// Send to Telegram if sender is available (not in sub-agent mode).
 	if a.sender != nil {
-		for _, id := range a.ownerIDs {
-			if err := a.sender.Send(ctx, id, telegramMsg); err != nil {
-				slog.Error("failed to send sub-agent result to Telegram",
-					"component", "agent", "operation", "handle_sub_agent_result",
-					"task_id", result.TaskID, "chat_id", id, "error", err)
-			}
-		}
+		a.deliverToOwners(ctx, "handle_sub_agent_result", fmt.Sprintf("sub-agent '%s' result", result.TaskID), func(id int64) error {
+			return a.sender.Send(ctx, id, telegramMsg)
+		})
 	}
+	if a.subAgentDocument && result.ResultContent != "" {
+		a.sendSubAgentDocument(ctx, result)
+	}
+}
+
+// sendSubAgentDocument uploads the full sub-agent result to every owner as a file.
+func (a *Agent) sendSubAgentDocument(ctx context.Context, result subagent.SubAgentResult) {
+	if a.documentSender == nil {
+		platform.Log(ctx).Warn("sub-agent result document requested but document upload is unavailable",
+			"component", "agent", "operation", "handle_sub_agent_result",
+			"task_id", result.TaskID)
+		return
+	}
+	name := result.TaskID + "-result.md"
+	caption := fmt.Sprintf("Full result of sub-agent '%s'", result.TaskID)
+	a.deliverToOwners(ctx, "handle_sub_agent_result", fmt.Sprintf("sub-agent '%s' result document", result.TaskID), func(id int64) error {
+		return a.sendDocument(ctx, id, name, []byte(result.ResultContent), caption)
+	})
 }
 
 // truncateForTelegram limits text to a reasonable Telegram message size.
 // Uses rune count to avoid splitting multi-byte UTF-8 characters.
 func truncateForTelegram(text string) string {
-	const maxRunes = 3500 // Telegram max is 4096, leave room for prefix
+	const maxRunes = 3500 // below telegram.MaxMessageLength, leaving room for a prefix
 	runes := []rune(text)
 	if len(runes) <= maxRunes {
 		return text
@@ -476,9 +1179,11 @@ func truncateForTelegram(text string) string {
 
 // RunSubAgent runs the agent in autonomous sub-agent mode.
 // It reads the mission from AGENT.md, processes it through the LLM pipeline,
-// and writes the result to result.md in the workspace root.
+// and writes the result to result.md in the workspace root. With a sub-agent
+// deadline set, work stops once it passes and the progress made so far is
+// written to result.md instead, before the parent kills the process.
 func (a *Agent) RunSubAgent(ctx context.Context) error {
-	slog.Info("sub-agent autonomous mode started",
+	platform.Log(ctx).Info("sub-agent autonomous mode started",
 		"component", "agent", "operation", "run_subagent")
 
 	mission := a.workspace.AgentMD
@@ -486,16 +1191,27 @@ func (a *Agent) RunSubAgent(ctx context.Context) error {
 		return fmt.Errorf("no AGENT.md mission found in workspace")
 	}
 
+	workCtx := ctx
+	if a.subAgentDeadline > 0 {
+		var cancel context.CancelFunc
+		workCtx, cancel = context.WithTimeout(ctx, a.subAgentDeadline)
+		defer cancel()
+	}
+
 	// Build messages with mission as user message.
-	msgs := a.buildMessages(mission)
+	msgs := a.buildMessages(0, mission)
+	start := len(msgs)
 	tools := a.toolDefinitions()
 
 	var lastContent string
 	exhausted := true
 
 	for round := range maxToolRounds {
-		resp, err := a.llm.ChatCompletionWithRetry(ctx, msgs, tools)
+		resp, err := a.llm.ChatCompletionWithRetry(workCtx, msgs, tools)
 		if err != nil {
+			if deadlinePassed(ctx, workCtx) {
+				return a.writePartialResult(ctx, msgs[start:], round)
+			}
 			return fmt.Errorf("LLM call failed (round %d): %w", round+1, err)
 		}
 
@@ -513,21 +1229,23 @@ func (a *Agent) RunSubAgent(ctx context.Context) error {
 			return fmt.Errorf("LLM returned tool calls but no executor configured")
 		}
 
-		toolMsgs := a.executeToolCalls(ctx, resp.Choices[0].Message)
-		assistantMsg := resp.Choices[0].Message
-		normalizeToolCallTypes(&assistantMsg)
-		msgs = append(msgs, assistantMsg)
+		toolMsgs := a.executeToolCalls(workCtx, resp.Choices[0].Message)
+		msgs = append(msgs, resp.Choices[0].Message)
 		msgs = append(msgs, toolMsgs...)
 
-		slog.Info("sub-agent tool round completed",
+		platform.Log(ctx).Info("sub-agent tool round completed",
 			"component", "agent", "operation", "run_subagent",
 			"round", round+1,
 			"tool_calls", len(resp.Choices[0].Message.ToolCalls))
+
+		if deadlinePassed(ctx, workCtx) {
+			return a.writePartialResult(ctx, msgs[start:], round+1)
+		}
 	}
 
 	// Check if tool rounds were exhausted without a final text response.
 	if exhausted {
-		slog.Warn("sub-agent exhausted tool rounds without producing a result",
+		platform.Log(ctx).Warn("sub-agent exhausted tool rounds without producing a result",
 			"component", "agent", "operation", "run_subagent",
 			"max_rounds", maxToolRounds)
 		return fmt.Errorf("sub-agent exhausted %d tool rounds without producing a result", maxToolRounds)
@@ -537,7 +1255,7 @@ func (a *Agent) RunSubAgent(ctx context.Context) error {
 	if lastContent != "" {
 		agentResp, err := llm.ParseAgentResponse(lastContent)
 		if err != nil {
-			slog.Warn("failed to parse sub-agent response, using raw content",
+			platform.Log(ctx).Warn("failed to parse sub-agent response, using raw content",
 				"component", "agent", "operation", "run_subagent",
 				"error", err)
 			// Keep lastContent as-is (raw LLM output as fallback).
@@ -546,42 +1264,70 @@ func (a *Agent) RunSubAgent(ctx context.Context) error {
 		}
 	}
 
-	// Write result.md via AtomicWrite.
-	resultPath := filepath.Join(a.workspace.Root, "result.md")
 	if lastContent != "" {
-		if err := platform.AtomicWrite(resultPath, []byte(lastContent), 0644); err != nil {
+		resultPath, err := a.writeResultFile(lastContent)
+		if err != nil {
 			return fmt.Errorf("write result.md: %w", err)
 		}
-		slog.Info("sub-agent result written",
+		platform.Log(ctx).Info("sub-agent result written",
 			"component", "agent", "operation", "run_subagent",
 			"path", resultPath, "bytes", len(lastContent))
 	} else {
-		slog.Warn("sub-agent completed without generating a result",
+		platform.Log(ctx).Warn("sub-agent completed without generating a result",
 			"component", "agent", "operation", "run_subagent")
 	}
 
 	a.logMemory(ctx, "sub-agent", "Mission completed")
-	slog.Info("sub-agent autonomous mode completed",
+	platform.Log(ctx).Info("sub-agent autonomous mode completed",
 		"component", "agent", "operation", "run_subagent")
 	return nil
 }
 
-// normalizeToolCallTypes ensures all tool calls have Type set to "function".
-// Some LLM providers omit the type field in responses; Mistral rejects empty type on re-send.
-func normalizeToolCallTypes(msg *llm.Message) {
-	for i := range msg.ToolCalls {
-		if msg.ToolCalls[i].Type == "" {
-			msg.ToolCalls[i].Type = "function"
-		}
+// persistsSource reports whether the memory verbosity allows writing entries from source.
+func (a *Agent) persistsSource(source string) bool {
+	switch a.memoryVerbosity {
+	case MemoryVerbosityNone:
+		return false
+	case MemoryVerbosityMessagesOnly:
+		return conversationSources[source]
+	default:
+		return true
+	}
+}
+
+// modelTag returns the memory tag naming the model that produced a reply, e.g.
+// "model-mistral-large-latest", with characters tags don't allow replaced by '-'.
+// It returns no tag when the model is unknown.
+func modelTag(model string) []string {
+	if model == "" {
+		return nil
 	}
+	tag := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_' {
+			return unicode.ToLower(r)
+		}
+		return '-'
+	}, model)
+	return []string{"model-" + tag}
 }
 
-func (a *Agent) logMemory(ctx context.Context, source, content string) {
-	if a.memory == nil {
+// logMemory writes an entry, labelled with tags when the memory writer supports them.
+func (a *Agent) logMemory(ctx context.Context, source, content string, tags ...string) {
+	if fromHeartbeat(ctx) {
+		source = "heartbeat"
+	}
+	if a.memory == nil || !a.persistsSource(source) || !a.diskAllows(ctx, source) {
 		return
 	}
-	if err := a.memory.Write(ctx, source, content); err != nil {
-		slog.Error("failed to write memory",
+	content = a.redactor.Redact(content)
+	var err error
+	if tw, ok := a.memory.(taggedMemoryWriter); ok && len(tags) > 0 {
+		err = tw.WriteTagged(ctx, source, content, tags)
+	} else {
+		err = a.memory.Write(ctx, source, content)
+	}
+	if err != nil {
+		platform.Log(ctx).Error("failed to write memory",
 			"component", "agent",
 			"operation", "log_memory",
 			"source", source,
@@ -2,19 +2,27 @@ package agent
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
+	"unicode/utf8"
 
 	"github.com/edouard/pureclaw/internal/llm"
+	"github.com/edouard/pureclaw/internal/platform"
 	"github.com/edouard/pureclaw/internal/subagent"
 	"github.com/edouard/pureclaw/internal/telegram"
 	"github.com/edouard/pureclaw/internal/tool"
+	"github.com/edouard/pureclaw/internal/watcher"
 	"github.com/edouard/pureclaw/internal/workspace"
 )
 
@@ -260,6 +268,55 @@ func TestRun_NoopType(t *testing.T) {
 	}
 }
 
+// recordingAcker records acknowledged and released update IDs.
+type recordingAcker struct {
+	mu       sync.Mutex
+	ids      []int64
+	released []int64
+}
+
+func (r *recordingAcker) Ack(updateID int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ids = append(r.ids, updateID)
+}
+
+func (r *recordingAcker) Release(updateID int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.released = append(r.released, updateID)
+}
+
+func TestRun_AcksProcessedMessages(t *testing.T) {
+	ws := testWorkspace(t)
+	llmFake := &fakeLLM{responses: []*llm.ChatResponse{makeResponse("message", "hello")}}
+	sender := &fakeSender{}
+	acker := &recordingAcker{}
+	ag := New(NewAgentConfig{Workspace: ws, LLM: llmFake, Sender: sender, Acker: acker})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	messages := make(chan telegram.TelegramMessage, 1)
+
+	done := make(chan error, 1)
+	go func() { done <- ag.Run(ctx, messages) }()
+
+	msg := testMsg(42, "hi")
+	msg.UpdateID = 77
+	sendAndWait(t, messages, msg)
+	sendAndWait(t, messages, testMsg(42, "no update id"))
+	cancel()
+	<-done
+
+	acker.mu.Lock()
+	defer acker.mu.Unlock()
+	if len(acker.ids) != 1 || acker.ids[0] != 77 {
+		t.Errorf("acked = %v, want [77]", acker.ids)
+	}
+	if len(sender.sent) == 0 {
+		t.Error("message was not processed before ack")
+	}
+}
+
 func TestRun_ContextCancellation(t *testing.T) {
 	ws := testWorkspace(t)
 	llmFake := &fakeLLM{responses: []*llm.ChatResponse{makeResponse("message", "ok")}}
@@ -1159,7 +1216,7 @@ func TestHandleFileChange_Success(t *testing.T) {
 	original := ws.AgentMD
 
 	origLoad := agentWorkspaceLoadFn
-	agentWorkspaceLoadFn = func(root string) (*workspace.Workspace, error) {
+	agentWorkspaceLoadFn = func(root string, _ int) (*workspace.Workspace, error) {
 		return &workspace.Workspace{
 			Root:    root,
 			AgentMD: "updated agent",
@@ -1188,7 +1245,7 @@ func TestHandleFileChange_Error(t *testing.T) {
 	originalSoul := ws.SoulMD
 
 	origLoad := agentWorkspaceLoadFn
-	agentWorkspaceLoadFn = func(root string) (*workspace.Workspace, error) {
+	agentWorkspaceLoadFn = func(root string, _ int) (*workspace.Workspace, error) {
 		return nil, errors.New("load failed")
 	}
 	defer func() { agentWorkspaceLoadFn = origLoad }()
@@ -1209,7 +1266,7 @@ func TestRun_FileChangeEvent(t *testing.T) {
 
 	origLoad := agentWorkspaceLoadFn
 	loadCalled := false
-	agentWorkspaceLoadFn = func(root string) (*workspace.Workspace, error) {
+	agentWorkspaceLoadFn = func(root string, _ int) (*workspace.Workspace, error) {
 		loadCalled = true
 		return &workspace.Workspace{
 			Root:    root,
@@ -1219,7 +1276,7 @@ func TestRun_FileChangeEvent(t *testing.T) {
 	}
 	defer func() { agentWorkspaceLoadFn = origLoad }()
 
-	fileChanges := make(chan struct{}, 1)
+	fileChanges := make(chan watcher.Change, 1)
 	ag := New(NewAgentConfig{
 		Workspace:   ws,
 		LLM:         &fakeLLM{responses: []*llm.ChatResponse{makeResponse("message", "ok")}},
@@ -1233,7 +1290,7 @@ func TestRun_FileChangeEvent(t *testing.T) {
 	done := make(chan error, 1)
 	go func() { done <- ag.Run(ctx, messages) }()
 
-	fileChanges <- struct{}{}
+	fileChanges <- watcher.Change{Root: ws.Root, Paths: []string{filepath.Join(ws.Root, "AGENT.md")}}
 	time.Sleep(50 * time.Millisecond)
 
 	cancel()
@@ -1247,16 +1304,80 @@ func TestRun_FileChangeEvent(t *testing.T) {
 	}
 }
 
+// countWorkspaceLoads stubs agentWorkspaceLoadFn and counts its calls.
+func countWorkspaceLoads(t *testing.T) *int {
+	t.Helper()
+	orig := agentWorkspaceLoadFn
+	t.Cleanup(func() { agentWorkspaceLoadFn = orig })
+	loads := 0
+	agentWorkspaceLoadFn = func(root string, _ int) (*workspace.Workspace, error) {
+		loads++
+		return &workspace.Workspace{Root: root, AgentMD: "reloaded", SoulMD: "soul"}, nil
+	}
+	return &loads
+}
+
+func TestHandleWorkspaceChange_OutsideRootIgnored(t *testing.T) {
+	loads := countWorkspaceLoads(t)
+	ws := testWorkspace(t)
+	other := t.TempDir()
+	ag := New(NewAgentConfig{Workspace: ws, LLM: &fakeLLM{}, Sender: &fakeSender{}})
+
+	ag.handleWorkspaceChange(context.Background(), watcher.Change{
+		Root:  other,
+		Paths: []string{filepath.Join(other, "AGENT.md")},
+	})
+	// A sibling directory sharing the root as a name prefix is outside too.
+	ag.handleWorkspaceChange(context.Background(), watcher.Change{
+		Root:  ws.Root + "-sub",
+		Paths: []string{ws.Root + "-sub/AGENT.md"},
+	})
+
+	if *loads != 0 {
+		t.Errorf("reloads = %d, want 0 for changes outside the workspace", *loads)
+	}
+	if ws.AgentMD == "reloaded" {
+		t.Error("workspace replaced by a change outside its root")
+	}
+}
+
+func TestHandleWorkspaceChange_CoalescesQueuedChanges(t *testing.T) {
+	loads := countWorkspaceLoads(t)
+	ws := testWorkspace(t)
+	other := t.TempDir()
+	fileChanges := make(chan watcher.Change, 2)
+	ag := New(NewAgentConfig{Workspace: ws, LLM: &fakeLLM{}, Sender: &fakeSender{}, FileChanges: fileChanges})
+
+	fileChanges <- watcher.Change{Root: other, Paths: []string{filepath.Join(other, "SOUL.md")}}
+	fileChanges <- watcher.Change{Root: ws.Root, Paths: []string{filepath.Join(ws.Root, "skills", "a", "SKILL.md")}}
+	ag.handleWorkspaceChange(context.Background(), watcher.Change{
+		Root:  ws.Root,
+		Paths: []string{filepath.Join(ws.Root, "AGENT.md")},
+	})
+
+	if *loads != 1 {
+		t.Errorf("reloads = %d, want exactly 1 for the queued changes", *loads)
+	}
+	if len(fileChanges) != 0 {
+		t.Errorf("queued changes = %d, want them drained", len(fileChanges))
+	}
+	if ws.AgentMD != "reloaded" {
+		t.Errorf("AgentMD = %q, want the reloaded workspace", ws.AgentMD)
+	}
+}
+
 // --- Heartbeat tests ---
 
 type fakeHeartbeatExecutor struct {
 	called  bool
+	quiet   bool
 	content string
 	err     error
 }
 
 func (f *fakeHeartbeatExecutor) Execute(ctx context.Context, heartbeatContent string) error {
 	f.called = true
+	f.quiet = platform.IsQuiet(ctx)
 	f.content = heartbeatContent
 	return f.err
 }
@@ -1282,6 +1403,116 @@ func TestHandleHeartbeat_Success(t *testing.T) {
 	}
 }
 
+// slowHeartbeat blocks each execution until released and counts executions.
+type slowHeartbeat struct {
+	runs    atomic.Int32
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s *slowHeartbeat) Execute(ctx context.Context, heartbeatContent string) error {
+	s.runs.Add(1)
+	s.started <- struct{}{}
+	<-s.release
+	return nil
+}
+
+func TestHandleHeartbeat_SkipsOverlappingTick(t *testing.T) {
+	ws := testWorkspace(t)
+	ws.HeartbeatMD = "- [ ] Slow check"
+	hb := &slowHeartbeat{started: make(chan struct{}, 2), release: make(chan struct{})}
+	ag := New(NewAgentConfig{Workspace: ws, LLM: &fakeLLM{}, Sender: &fakeSender{}, Heartbeat: hb})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ag.handleHeartbeat(context.Background())
+	}()
+	<-hb.started
+
+	// Second tick while the first heartbeat is still running: skipped, returns immediately.
+	ag.handleHeartbeat(context.Background())
+	if n := hb.runs.Load(); n != 1 {
+		t.Fatalf("executions = %d while the first is running, want 1", n)
+	}
+
+	close(hb.release)
+	<-done
+
+	// Once the first finished, the next tick runs again.
+	ag.handleHeartbeat(context.Background())
+	if n := hb.runs.Load(); n != 2 {
+		t.Errorf("executions = %d after the first finished, want 2", n)
+	}
+}
+
+// reactCountingSender counts reactions on top of fakeSender.
+type reactCountingSender struct {
+	fakeSender
+	reactions int
+}
+
+func (r *reactCountingSender) React(ctx context.Context, chatID, messageID int64, emoji string) error {
+	r.reactions++
+	return nil
+}
+
+// actingHeartbeat runs act with the context the agent hands to the heartbeat,
+// standing in for heartbeat work that messages the owner through the agent.
+type actingHeartbeat struct {
+	act func(ctx context.Context)
+}
+
+func (h *actingHeartbeat) Execute(ctx context.Context, heartbeatContent string) error {
+	h.act(ctx)
+	return nil
+}
+
+func TestHandleHeartbeat_TagsOrigin(t *testing.T) {
+	ws := testWorkspace(t)
+	ws.HeartbeatMD = "- [ ] Check disk"
+	sender := &reactCountingSender{}
+	status := &fakeStatusMessenger{sender: &sender.fakeSender}
+	mem := &fakeMemoryWriter{}
+	notify := func(ctx context.Context, ag *Agent) {
+		ag.react(ctx, 100, 1)
+		if id, ok := ag.postStatus(ctx, 100); ok {
+			ag.clearStatus(ctx, 100, id)
+		}
+		ag.send(ctx, 100, "disk almost full")
+		ag.logMemory(ctx, "agent", "disk almost full")
+	}
+	hb := &actingHeartbeat{}
+	ag := New(NewAgentConfig{
+		Workspace:       ws,
+		LLM:             &fakeLLM{},
+		Sender:          sender,
+		Memory:          mem,
+		Heartbeat:       hb,
+		StatusMessenger: status,
+		StatusText:      "Working…",
+	})
+	hb.act = func(ctx context.Context) { notify(ctx, ag) }
+
+	ag.handleHeartbeat(context.Background())
+
+	if sender.reactions != 0 || len(status.posted) != 0 {
+		t.Errorf("heartbeat used message affordances: %d reactions, %d status posts", sender.reactions, len(status.posted))
+	}
+	if len(sender.sent) != 1 {
+		t.Fatalf("sent = %d, want the heartbeat notification", len(sender.sent))
+	}
+	if len(mem.entries) != 1 || mem.entries[0].source != "heartbeat" {
+		t.Errorf("memory entries = %+v, want one sourced heartbeat", mem.entries)
+	}
+
+	// The same actions outside a heartbeat keep their usual behaviour.
+	notify(context.Background(), ag)
+	if sender.reactions != 1 || len(status.posted) != 1 || mem.entries[1].source != "agent" {
+		t.Errorf("message path changed: %d reactions, %d status posts, entries %+v", sender.reactions, len(status.posted), mem.entries)
+	}
+}
+
 func TestHandleHeartbeat_EmptyHeartbeatMD(t *testing.T) {
 	ws := testWorkspace(t)
 	ws.HeartbeatMD = ""
@@ -2400,6 +2631,73 @@ func TestHandleSubAgentResult_LongResult_Truncated(t *testing.T) {
 	}
 }
 
+func TestHandleSubAgentResult_MemoryKeepsFullResult(t *testing.T) {
+	sender := &fakeSender{}
+	mem := &fakeMemoryWriter{}
+	ag := New(NewAgentConfig{Workspace: testWorkspace(t), LLM: &fakeLLM{}, Sender: sender, Memory: mem, OwnerIDs: []int64{100}})
+
+	longResult := strings.Repeat("y", 4000) + "END"
+	for _, result := range []subagent.SubAgentResult{
+		{TaskID: "done-task", ResultContent: longResult},
+		{TaskID: "partial-task", ResultContent: longResult, TimedOut: true},
+	} {
+		ag.handleSubAgentResult(context.Background(), result)
+	}
+
+	if len(mem.entries) != 2 {
+		t.Fatalf("memory entries = %d, want 2", len(mem.entries))
+	}
+	for _, e := range mem.entries {
+		if !strings.Contains(e.content, longResult) {
+			t.Errorf("memory entry lost the full result (len %d)", len(e.content))
+		}
+	}
+	for _, m := range sender.sent {
+		if strings.Contains(m.text, "END") || !strings.Contains(m.text, "[...truncated]") {
+			t.Errorf("chat message not truncated (len %d)", len(m.text))
+		}
+	}
+}
+
+func TestHandleSubAgentResult_SubAgentDocument(t *testing.T) {
+	docs := &fakeDocumentSender{}
+	sender := &fakeSender{}
+	ag := New(NewAgentConfig{
+		Workspace:        testWorkspace(t),
+		LLM:              &fakeLLM{},
+		Sender:           sender,
+		DocumentSender:   docs,
+		OwnerIDs:         []int64{100, 200},
+		SubAgentDocument: true,
+	})
+
+	longResult := strings.Repeat("z", 5000)
+	ag.handleSubAgentResult(context.Background(), subagent.SubAgentResult{TaskID: "report", ResultContent: longResult})
+	ag.handleSubAgentResult(context.Background(), subagent.SubAgentResult{TaskID: "failed", Err: errors.New("boom")})
+
+	if len(docs.docs) != 2 {
+		t.Fatalf("documents = %d, want one per owner for the successful result", len(docs.docs))
+	}
+	for _, d := range docs.docs {
+		if d.fileName != "report-result.md" || string(d.data) != longResult {
+			t.Errorf("document = %s (%d bytes), want full report-result.md", d.fileName, len(d.data))
+		}
+	}
+	if len(sender.sent) != 4 {
+		t.Errorf("chat messages = %d, want the usual notifications too", len(sender.sent))
+	}
+}
+
+func TestHandleSubAgentResult_NoDocumentByDefault(t *testing.T) {
+	docs := &fakeDocumentSender{}
+	ag := New(NewAgentConfig{Workspace: testWorkspace(t), LLM: &fakeLLM{}, Sender: &fakeSender{}, DocumentSender: docs, OwnerIDs: []int64{100}})
+
+	ag.handleSubAgentResult(context.Background(), subagent.SubAgentResult{TaskID: "t", ResultContent: "result"})
+	if len(docs.docs) != 0 {
+		t.Errorf("documents = %d, want 0 when sub_agent_document is off", len(docs.docs))
+	}
+}
+
 func TestHandleSubAgentResult_NoOwnerIDs_NoTelegramSent(t *testing.T) {
 	ws := testWorkspace(t)
 	llmFake := &fakeLLM{responses: []*llm.ChatResponse{makeResponse("noop", "")}}
@@ -2606,3 +2904,1109 @@ func TestRunSubAgent_ExhaustedToolRounds(t *testing.T) {
 		t.Errorf("error = %q, want to contain 'exhausted'", err)
 	}
 }
+
+func TestHandleMessage_FormatCodeBlocks(t *testing.T) {
+	ws := testWorkspace(t)
+	content := "Compare a < b:\n```go\nfmt.Println(\"<hi>\")\n```"
+	llmFake := &fakeLLM{responses: []*llm.ChatResponse{{
+		Choices: []llm.Choice{{
+			Message:      llm.Message{Content: content},
+			FinishReason: "stop",
+		}},
+	}}}
+	sender := &fakeSender{}
+	ag := New(NewAgentConfig{
+		Workspace:        ws,
+		LLM:              llmFake,
+		Sender:           sender,
+		FormatCodeBlocks: true,
+	})
+
+	ag.handleMessage(context.Background(), testMsg(42, "show code"))
+
+	if len(sender.sent) != 1 {
+		t.Fatalf("expected 1 sent message, got %d", len(sender.sent))
+	}
+	want := "Compare a &lt; b:\n" + `<pre><code class="language-go">fmt.Println("&lt;hi&gt;")</code></pre>`
+	if sender.sent[0].text != want {
+		t.Errorf("sent text =\n%q\nwant\n%q", sender.sent[0].text, want)
+	}
+	// History keeps the raw model content, not the rendered HTML.
+	if ag.history[1].Content != content {
+		t.Errorf("history content = %q, want raw %q", ag.history[1].Content, content)
+	}
+}
+
+func TestHandleMessage_FormatCodeBlocksDisabled(t *testing.T) {
+	ws := testWorkspace(t)
+	content := "```go\nx := 1\n```"
+	llmFake := &fakeLLM{responses: []*llm.ChatResponse{{
+		Choices: []llm.Choice{{
+			Message:      llm.Message{Content: content},
+			FinishReason: "stop",
+		}},
+	}}}
+	sender := &fakeSender{}
+	ag := newTestAgent(ws, llmFake, sender)
+
+	ag.handleMessage(context.Background(), testMsg(42, "show code"))
+
+	if len(sender.sent) != 1 || sender.sent[0].text != content {
+		t.Fatalf("sent = %+v, want raw content", sender.sent)
+	}
+}
+
+// flakyLLM fails its first failures attempts through platform.Retry, like the real client.
+type flakyLLM struct {
+	failures int
+	attempts int
+}
+
+func (f *flakyLLM) ChatCompletionWithRetry(ctx context.Context, messages []llm.Message, tools []llm.Tool) (*llm.ChatResponse, error) {
+	var resp *llm.ChatResponse
+	err := platform.Retry(ctx, 3, 20*time.Millisecond, func() error {
+		f.attempts++
+		if f.attempts <= f.failures {
+			return errors.New("llm unavailable")
+		}
+		resp = makeResponse("message", "hello")
+		return nil
+	})
+	return resp, err
+}
+
+// flakySender always fails, retrying through platform.Retry like the real sender.
+type flakySender struct {
+	attempts int
+	lastErr  error
+}
+
+func (f *flakySender) Send(ctx context.Context, chatID int64, text string) error {
+	f.lastErr = platform.Retry(ctx, 3, 20*time.Millisecond, func() error {
+		f.attempts++
+		return errors.New("telegram unavailable")
+	})
+	return f.lastErr
+}
+
+func (f *flakySender) React(ctx context.Context, chatID, messageID int64, emoji string) error {
+	return nil
+}
+
+func TestHandleMessage_RetryBudgetSharedAcrossStages(t *testing.T) {
+	ws := testWorkspace(t)
+	llmFake := &flakyLLM{failures: 2}
+	sender := &flakySender{}
+	ag := New(NewAgentConfig{
+		Workspace:   ws,
+		LLM:         llmFake,
+		Sender:      sender,
+		RetryBudget: 2,
+	})
+
+	start := time.Now()
+	ag.handleMessage(context.Background(), testMsg(42, "hi"))
+	elapsed := time.Since(start)
+
+	if llmFake.attempts != 3 {
+		t.Errorf("LLM attempts = %d, want 3 (2 budgeted retries)", llmFake.attempts)
+	}
+	if sender.attempts != 1 {
+		t.Errorf("sender attempts = %d, want 1 (budget already spent)", sender.attempts)
+	}
+	if !errors.Is(sender.lastErr, platform.ErrRetryBudgetExhausted) {
+		t.Errorf("sender error = %v, want ErrRetryBudgetExhausted", sender.lastErr)
+	}
+	// Without a budget the sender would add 20ms+40ms of backoff on top of the LLM's.
+	if elapsed > 150*time.Millisecond {
+		t.Errorf("message took %v, expected prompt failure", elapsed)
+	}
+}
+
+func TestHandleMessage_NoRetryBudgetRetriesEachStage(t *testing.T) {
+	ws := testWorkspace(t)
+	llmFake := &flakyLLM{failures: 2}
+	sender := &flakySender{}
+	ag := New(NewAgentConfig{
+		Workspace: ws,
+		LLM:       llmFake,
+		Sender:    sender,
+	})
+
+	ag.handleMessage(context.Background(), testMsg(42, "hi"))
+
+	if llmFake.attempts != 3 {
+		t.Errorf("LLM attempts = %d, want 3", llmFake.attempts)
+	}
+	if sender.attempts != 3 {
+		t.Errorf("sender attempts = %d, want 3 (unlimited budget)", sender.attempts)
+	}
+}
+
+func TestHandleMessage_TruncatedResponseRecovered(t *testing.T) {
+	ws := testWorkspace(t)
+	llmFake := &fakeLLM{responses: []*llm.ChatResponse{{
+		Choices: []llm.Choice{{
+			Message:      llm.Message{Content: `{"type":"message","content":"Step 1: install Go. Step 2: run the bui`},
+			FinishReason: "length",
+		}},
+	}}}
+	sender := &fakeSender{}
+	ag := newTestAgent(ws, llmFake, sender)
+
+	ag.handleMessage(context.Background(), testMsg(42, "explain"))
+
+	if len(sender.sent) != 1 {
+		t.Fatalf("expected 1 sent message, got %d", len(sender.sent))
+	}
+	want := "Step 1: install Go. Step 2: run the bui\n\n(response was truncated)"
+	if sender.sent[0].text != want {
+		t.Errorf("sent text = %q, want %q", sender.sent[0].text, want)
+	}
+}
+
+func TestHandleMessage_TruncatedFinishReasonStopNotRecovered(t *testing.T) {
+	ws := testWorkspace(t)
+	raw := `{"type":"message","content":"unterminated`
+	llmFake := &fakeLLM{responses: []*llm.ChatResponse{{
+		Choices: []llm.Choice{{
+			Message:      llm.Message{Content: raw},
+			FinishReason: "stop",
+		}},
+	}}}
+	sender := &fakeSender{}
+	ag := newTestAgent(ws, llmFake, sender)
+
+	ag.handleMessage(context.Background(), testMsg(42, "explain"))
+
+	if len(sender.sent) != 1 {
+		t.Fatalf("expected 1 sent message, got %d", len(sender.sent))
+	}
+	if sender.sent[0].text != raw {
+		t.Errorf("sent text = %q, want raw fallback %q", sender.sent[0].text, raw)
+	}
+}
+
+// fakeStatusMessenger records status placeholders and deletions.
+type fakeStatusMessenger struct {
+	sender           *fakeSender // to observe what was sent before deletion
+	posted           []string
+	deleted          []int64
+	sentBeforeDelete int
+	deleteErr        error
+}
+
+func (f *fakeStatusMessenger) SendMessage(ctx context.Context, chatID int64, text string) (int64, error) {
+	f.posted = append(f.posted, text)
+	return 777, nil
+}
+
+func (f *fakeStatusMessenger) DeleteMessage(ctx context.Context, chatID, messageID int64) error {
+	f.deleted = append(f.deleted, messageID)
+	f.sentBeforeDelete = len(f.sender.sent)
+	return f.deleteErr
+}
+
+func TestHandleMessage_StatusPlaceholderDeletedAfterReply(t *testing.T) {
+	ws := testWorkspace(t)
+	llmFake := &fakeLLM{responses: []*llm.ChatResponse{makeResponse("message", "Done!")}}
+	sender := &fakeSender{}
+	status := &fakeStatusMessenger{sender: sender}
+	ag := New(NewAgentConfig{
+		Workspace:       ws,
+		LLM:             llmFake,
+		Sender:          sender,
+		StatusMessenger: status,
+		StatusText:      "Working…",
+	})
+
+	ag.handleMessage(context.Background(), testMsg(42, "do it"))
+
+	if len(status.posted) != 1 || status.posted[0] != "Working…" {
+		t.Fatalf("posted = %v, want [Working…]", status.posted)
+	}
+	if len(status.deleted) != 1 || status.deleted[0] != 777 {
+		t.Fatalf("deleted = %v, want [777]", status.deleted)
+	}
+	if status.sentBeforeDelete != 1 {
+		t.Errorf("placeholder deleted after %d sends, want after the final reply", status.sentBeforeDelete)
+	}
+}
+
+func TestHandleMessage_StatusDeleteErrorSwallowed(t *testing.T) {
+	ws := testWorkspace(t)
+	llmFake := &fakeLLM{responses: []*llm.ChatResponse{makeResponse("message", "Done!")}}
+	sender := &fakeSender{}
+	status := &fakeStatusMessenger{sender: sender, deleteErr: errors.New("message can't be deleted")}
+	ag := New(NewAgentConfig{
+		Workspace:       ws,
+		LLM:             llmFake,
+		Sender:          sender,
+		StatusMessenger: status,
+		StatusText:      "Working…",
+	})
+
+	ag.handleMessage(context.Background(), testMsg(42, "do it"))
+
+	if len(sender.sent) != 1 || sender.sent[0].text != "Done!" {
+		t.Errorf("sent = %v, want the reply despite delete failure", sender.sent)
+	}
+	if len(status.deleted) != 1 {
+		t.Errorf("delete attempts = %d, want 1", len(status.deleted))
+	}
+}
+
+func TestHandleMessage_KeepStatusPlaceholder(t *testing.T) {
+	ws := testWorkspace(t)
+	llmFake := &fakeLLM{responses: []*llm.ChatResponse{makeResponse("message", "Done!")}}
+	sender := &fakeSender{}
+	status := &fakeStatusMessenger{sender: sender}
+	ag := New(NewAgentConfig{
+		Workspace:       ws,
+		LLM:             llmFake,
+		Sender:          sender,
+		StatusMessenger: status,
+		StatusText:      "Working…",
+		KeepStatus:      true,
+	})
+
+	ag.handleMessage(context.Background(), testMsg(42, "do it"))
+
+	if len(status.posted) != 1 {
+		t.Errorf("posted = %v, want one placeholder", status.posted)
+	}
+	if len(status.deleted) != 0 {
+		t.Errorf("deleted = %v, want none when KeepStatus is set", status.deleted)
+	}
+}
+
+func TestLogMemory_Verbosity(t *testing.T) {
+	sources := []string{"owner", "voice-transcription", "agent", "sub-agent-result", "sub-agent", "introspection"}
+	tests := []struct {
+		verbosity string
+		want      []string
+	}{
+		{"", sources},
+		{MemoryVerbosityAll, sources},
+		{MemoryVerbosityMessagesOnly, []string{"owner", "voice-transcription", "agent"}},
+		{MemoryVerbosityNone, nil},
+		{"bogus", sources},
+	}
+	for _, tt := range tests {
+		t.Run("verbosity="+tt.verbosity, func(t *testing.T) {
+			mem := &fakeMemoryWriter{}
+			ag := New(NewAgentConfig{Workspace: testWorkspace(t), Memory: mem, MemoryVerbosity: tt.verbosity})
+
+			for _, src := range sources {
+				ag.logMemory(context.Background(), src, "content")
+			}
+
+			var got []string
+			for _, e := range mem.entries {
+				got = append(got, e.source)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("persisted sources = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleMessage_MemoryVerbosityMessagesOnly(t *testing.T) {
+	ws := testWorkspace(t)
+	llmFake := &fakeLLM{responses: []*llm.ChatResponse{makeResponse("message", "Hi there")}}
+	mem := &fakeMemoryWriter{}
+	ag := New(NewAgentConfig{
+		Workspace:       ws,
+		LLM:             llmFake,
+		Sender:          &fakeSender{},
+		Memory:          mem,
+		MemoryVerbosity: MemoryVerbosityMessagesOnly,
+	})
+
+	ag.handleMessage(context.Background(), testMsg(42, "Hello"))
+	ag.handleSubAgentResult(context.Background(), subagent.SubAgentResult{TaskID: "t1", ResultContent: "done"})
+
+	var got []string
+	for _, e := range mem.entries {
+		got = append(got, e.source)
+	}
+	if strings.Join(got, ",") != "owner,agent" {
+		t.Errorf("persisted sources = %v, want [owner agent]", got)
+	}
+}
+
+func TestHandleMessage_ForwardedMessageAnnotated(t *testing.T) {
+	ws := testWorkspace(t)
+	llmFake := &fakeLLM{responses: []*llm.ChatResponse{makeResponse("message", "Noted")}}
+	mem := &fakeMemoryWriter{}
+	ag := New(NewAgentConfig{Workspace: ws, LLM: llmFake, Sender: &fakeSender{}, Memory: mem})
+
+	msg := testMsg(42, "Meeting moved to 3pm")
+	msg.Message.ForwardOrigin = &telegram.MessageOrigin{Type: "user", SenderUser: &telegram.User{ID: 7, FirstName: "Alice"}}
+	ag.handleMessage(context.Background(), msg)
+
+	want := "[forwarded from Alice]\nMeeting moved to 3pm"
+	if len(llmFake.calls) == 0 {
+		t.Fatal("expected an LLM call")
+	}
+	msgs := llmFake.calls[0]
+	if got := msgs[len(msgs)-1].Content; got != want {
+		t.Errorf("user text = %q, want %q", got, want)
+	}
+	if len(mem.entries) == 0 || mem.entries[0].content != want {
+		t.Errorf("memory entries = %+v, want annotated owner entry", mem.entries)
+	}
+}
+
+func TestHandleMessage_ReplyAnnotatedWithQuote(t *testing.T) {
+	llmFake := &fakeLLM{responses: []*llm.ChatResponse{makeResponse("message", "Deploying")}}
+	mem := &fakeMemoryWriter{}
+	ag := New(NewAgentConfig{Workspace: testWorkspace(t), LLM: llmFake, Sender: &fakeSender{}, Memory: mem})
+
+	msg := testMsg(42, "yes, go ahead")
+	msg.Message.ReplyToMessage = &telegram.Message{MessageID: 7, Text: "Shall I deploy\nthe new build?"}
+	ag.handleMessage(context.Background(), msg)
+
+	want := "[in reply to: Shall I deploy the new build?]\nyes, go ahead"
+	if len(llmFake.calls) == 0 {
+		t.Fatal("expected an LLM call")
+	}
+	msgs := llmFake.calls[0]
+	if got := msgs[len(msgs)-1].Content; got != want {
+		t.Errorf("user text = %q, want %q", got, want)
+	}
+	if len(mem.entries) == 0 || mem.entries[0].content != want {
+		t.Errorf("memory entries = %+v, want annotated owner entry", mem.entries)
+	}
+}
+
+func TestHandleMessage_NonReplyNotAnnotated(t *testing.T) {
+	llmFake := &fakeLLM{responses: []*llm.ChatResponse{makeResponse("message", "Hi")}}
+	ag := New(NewAgentConfig{Workspace: testWorkspace(t), LLM: llmFake, Sender: &fakeSender{}})
+
+	ag.handleMessage(context.Background(), testMsg(42, "hello"))
+
+	if len(llmFake.calls) == 0 {
+		t.Fatal("expected an LLM call")
+	}
+	msgs := llmFake.calls[0]
+	if got := msgs[len(msgs)-1].Content; got != "hello" {
+		t.Errorf("user text = %q, want %q", got, "hello")
+	}
+}
+
+// ctxCapturingExecutor records the tool context seen by tool calls.
+type ctxCapturingExecutor struct {
+	tc tool.ToolContext
+	ok bool
+}
+
+func (c *ctxCapturingExecutor) Execute(ctx context.Context, name string, args json.RawMessage) tool.ToolResult {
+	c.tc, c.ok = tool.FromContext(ctx)
+	return tool.ToolResult{Success: true}
+}
+
+func (c *ctxCapturingExecutor) Definitions() []llm.Tool { return nil }
+
+func TestExecuteToolCalls_PassesToolContext(t *testing.T) {
+	ws := testWorkspace(t)
+	exec := &ctxCapturingExecutor{}
+	ag := newTestAgentWithTools(ws, &fakeLLM{}, &fakeSender{}, exec)
+
+	ag.executeToolCalls(context.Background(), llm.Message{ToolCalls: []llm.ToolCall{tc("1", "exec_command", `{}`)}})
+
+	if !exec.ok {
+		t.Fatal("tool call context should carry a ToolContext")
+	}
+	if exec.tc.WorkspaceRoot != ws.Root {
+		t.Errorf("WorkspaceRoot = %q, want %q", exec.tc.WorkspaceRoot, ws.Root)
+	}
+}
+
+func TestExecuteToolCalls_PassesHistory(t *testing.T) {
+	exec := &ctxCapturingExecutor{}
+	ag := newTestAgentWithTools(testWorkspace(t), &fakeLLM{}, &fakeSender{}, exec)
+	ag.addToHistory("where are the logs?", "In /var/log.")
+
+	ag.executeToolCalls(context.Background(), llm.Message{ToolCalls: []llm.ToolCall{tc("1", "spawn_agent", `{}`)}})
+
+	if len(exec.tc.History) != 2 || exec.tc.History[0].Content != "where are the logs?" || exec.tc.History[1].Content != "In /var/log." {
+		t.Errorf("History = %+v, want the agent's conversation", exec.tc.History)
+	}
+	exec.tc.History[0].Content = "mutated"
+	if ag.history[0].Content != "where are the logs?" {
+		t.Error("tools must receive a copy of the history")
+	}
+}
+
+func TestExecuteToolCalls_OwnerOnlyOutsideHeartbeat(t *testing.T) {
+	exec := &ctxCapturingExecutor{}
+	ag := newTestAgentWithTools(testWorkspace(t), &fakeLLM{}, &fakeSender{}, exec)
+	call := llm.Message{ToolCalls: []llm.ToolCall{tc("1", "config", `{}`)}}
+
+	ag.executeToolCalls(context.Background(), call)
+	if !exec.tc.Owner {
+		t.Error("tool calls while handling a message should be marked as the owner's")
+	}
+	ag.executeToolCalls(platform.WithOrigin(context.Background(), platform.OriginHeartbeat), call)
+	if exec.tc.Owner {
+		t.Error("tool calls from a heartbeat must not be marked as the owner's")
+	}
+}
+
+// deadlineLLM records the deadline of each completion context.
+type deadlineLLM struct {
+	fakeLLM
+	deadline    time.Time
+	hasDeadline bool
+}
+
+func (d *deadlineLLM) ChatCompletionWithRetry(ctx context.Context, messages []llm.Message, tools []llm.Tool) (*llm.ChatResponse, error) {
+	d.deadline, d.hasDeadline = ctx.Deadline()
+	return d.fakeLLM.ChatCompletionWithRetry(ctx, messages, tools)
+}
+
+// deadlineDownloader records the deadline of the download context.
+type deadlineDownloader struct {
+	fakeVoiceDownloader
+	deadline    time.Time
+	hasDeadline bool
+}
+
+func (d *deadlineDownloader) DownloadFile(ctx context.Context, filePath string) ([]byte, error) {
+	d.deadline, d.hasDeadline = ctx.Deadline()
+	return d.fakeVoiceDownloader.DownloadFile(ctx, filePath)
+}
+
+func TestHandleMessage_MessageTimeout(t *testing.T) {
+	llmFake := &deadlineLLM{fakeLLM: fakeLLM{responses: []*llm.ChatResponse{makeResponse("message", "ok")}}}
+	ag := New(NewAgentConfig{
+		Workspace:      testWorkspace(t),
+		LLM:            llmFake,
+		Sender:         &fakeSender{},
+		Memory:         &fakeMemoryWriter{},
+		MessageTimeout: 90 * time.Second,
+	})
+
+	start := time.Now()
+	ag.handleMessage(context.Background(), telegram.TelegramMessage{Message: telegram.Message{Chat: telegram.Chat{ID: 42}, Text: "hi"}})
+
+	if !llmFake.hasDeadline {
+		t.Fatal("LLM context should carry the message deadline")
+	}
+	if d := llmFake.deadline.Sub(start); d < 89*time.Second || d > 91*time.Second {
+		t.Errorf("deadline in %s, want ~90s", d)
+	}
+}
+
+func TestHandleMessage_NoMessageTimeout(t *testing.T) {
+	llmFake := &deadlineLLM{fakeLLM: fakeLLM{responses: []*llm.ChatResponse{makeResponse("message", "ok")}}}
+	ag := New(NewAgentConfig{
+		Workspace: testWorkspace(t),
+		LLM:       llmFake,
+		Sender:    &fakeSender{},
+		Memory:    &fakeMemoryWriter{},
+	})
+
+	ag.handleMessage(context.Background(), telegram.TelegramMessage{Message: telegram.Message{Chat: telegram.Chat{ID: 42}, Text: "hi"}})
+
+	if llmFake.hasDeadline {
+		t.Error("without MessageTimeout the LLM context should have no deadline")
+	}
+}
+
+func TestTranscribeVoice_DownloadTimeout(t *testing.T) {
+	downloader := &deadlineDownloader{fakeVoiceDownloader: fakeVoiceDownloader{filePath: "voice/file.oga", fileData: []byte("ogg")}}
+	ag := New(NewAgentConfig{
+		Workspace:       testWorkspace(t),
+		Transcriber:     &fakeTranscriber{text: "hello"},
+		VoiceDownloader: downloader,
+		DownloadTimeout: 15 * time.Second,
+	})
+
+	start := time.Now()
+	if _, err := ag.transcribeVoice(context.Background(), "file-id"); err != nil {
+		t.Fatalf("transcribeVoice: %v", err)
+	}
+	if !downloader.hasDeadline {
+		t.Fatal("download context should carry the download deadline")
+	}
+	if d := downloader.deadline.Sub(start); d < 14*time.Second || d > 16*time.Second {
+		t.Errorf("deadline in %s, want ~15s", d)
+	}
+}
+
+func TestExecuteToolCalls_BinaryOutputSummarized(t *testing.T) {
+	raw := "\x89PNG\r\n\x1a\n\x00\x00\xff\xfe"
+	exec := &fakeToolExecutor{results: []tool.ToolResult{{Success: true, Output: raw}}}
+	ag := newTestAgentWithTools(testWorkspace(t), &fakeLLM{}, &fakeSender{}, exec)
+
+	msgs := ag.executeToolCalls(context.Background(), llm.Message{ToolCalls: []llm.ToolCall{tc("1", "read_file", `{"path":"logo.png"}`)}})
+	if len(msgs) != 1 {
+		t.Fatalf("tool messages = %d, want 1", len(msgs))
+	}
+	content := msgs[0].Content
+
+	sum := sha256.Sum256([]byte(raw))
+	want := fmt.Sprintf("[binary content, %d bytes, sha256 %x]", len(raw), sum)
+	if !strings.Contains(content, want) {
+		t.Errorf("content = %q, want summary %q", content, want)
+	}
+	if strings.Contains(content, "PNG") || !utf8.ValidString(content) {
+		t.Errorf("content should not embed the raw bytes: %q", content)
+	}
+
+	// The whole request payload must marshal to valid JSON.
+	data, err := json.Marshal(msgs)
+	if err != nil {
+		t.Fatalf("marshal tool messages: %v", err)
+	}
+	if !json.Valid(data) {
+		t.Error("marshaled tool messages are not valid JSON")
+	}
+}
+
+func TestBinarySummary(t *testing.T) {
+	for _, tt := range []struct {
+		in     string
+		binary bool
+	}{
+		{"", false},
+		{"plain text\nwith lines", false},
+		{"héllo wörld ✓", false},
+		{"bad \xff utf8", true},
+		{"nul\x00byte", true},
+	} {
+		summary, ok := binarySummary(tt.in)
+		if ok != tt.binary {
+			t.Errorf("binarySummary(%q) binary = %v, want %v", tt.in, ok, tt.binary)
+		}
+		if ok && !strings.HasPrefix(summary, "[binary content, ") {
+			t.Errorf("binarySummary(%q) = %q", tt.in, summary)
+		}
+	}
+}
+
+func TestHandleMessage_UnsupportedTypeReplies(t *testing.T) {
+	ws := testWorkspace(t)
+	llmFake := &fakeLLM{responses: []*llm.ChatResponse{makeResponse("message", "should not be called")}}
+	sender := &fakeSender{}
+	mem := &fakeMemoryWriter{}
+	ag := New(NewAgentConfig{Workspace: ws, LLM: llmFake, Sender: sender, Memory: mem})
+
+	msg := telegram.TelegramMessage{Message: telegram.Message{
+		MessageID: 1,
+		Chat:      telegram.Chat{ID: 42},
+		Sticker:   &telegram.Sticker{FileID: "stk1"},
+	}}
+	ag.handleMessage(context.Background(), msg)
+
+	if len(llmFake.calls) != 0 {
+		t.Errorf("expected no LLM call, got %d", len(llmFake.calls))
+	}
+	if len(sender.sent) != 1 || sender.sent[0].text != "I can't process sticker messages yet." {
+		t.Fatalf("sent = %+v, want unsupported-type reply", sender.sent)
+	}
+	if len(mem.entries) != 1 || mem.entries[0].source != "owner" || mem.entries[0].content != "[sticker message]" {
+		t.Errorf("memory entries = %+v, want [sticker message] from owner", mem.entries)
+	}
+}
+
+func TestHandleMessage_TextNotTreatedAsUnsupported(t *testing.T) {
+	ws := testWorkspace(t)
+	llmFake := &fakeLLM{responses: []*llm.ChatResponse{makeResponse("message", "hello back")}}
+	sender := &fakeSender{}
+	ag := newTestAgent(ws, llmFake, sender)
+
+	ag.handleMessage(context.Background(), testMsg(42, "hi"))
+
+	if len(llmFake.calls) != 1 {
+		t.Errorf("expected 1 LLM call, got %d", len(llmFake.calls))
+	}
+	if len(sender.sent) != 1 || sender.sent[0].text != "hello back" {
+		t.Errorf("sent = %+v, want LLM reply", sender.sent)
+	}
+}
+
+const testFallback = "Sorry, I couldn't process that."
+
+func TestHandleMessage_FallbackReply(t *testing.T) {
+	voiceMsg := telegram.TelegramMessage{Message: telegram.Message{
+		Chat:  telegram.Chat{ID: 42},
+		Voice: &telegram.Voice{FileID: "v1", Duration: 2},
+	}}
+	toolLoop := makeToolCallResponse(llm.ToolCall{ID: "c1", Type: "function", Function: llm.ToolCallFunction{Name: "read_file", Arguments: `{}`}})
+
+	tests := []struct {
+		name        string
+		llm         *fakeLLM
+		msg         telegram.TelegramMessage
+		sendErr     error
+		tools       ToolExecutor
+		wantSent    []string
+		transcriber *fakeTranscriber
+	}{
+		{
+			name:     "LLM failure",
+			llm:      &fakeLLM{errs: []error{errors.New("provider down")}},
+			msg:      testMsg(42, "hi"),
+			wantSent: []string{testFallback},
+		},
+		{
+			name:     "no choices",
+			llm:      &fakeLLM{responses: []*llm.ChatResponse{{}}},
+			msg:      testMsg(42, "hi"),
+			wantSent: []string{testFallback},
+		},
+		{
+			name:     "tool rounds exhausted",
+			llm:      &fakeLLM{responses: []*llm.ChatResponse{toolLoop}},
+			msg:      testMsg(42, "hi"),
+			tools:    &fakeToolExecutor{},
+			wantSent: []string{testFallback},
+		},
+		{
+			name:        "empty transcription",
+			llm:         &fakeLLM{},
+			msg:         voiceMsg,
+			transcriber: &fakeTranscriber{text: ""},
+			wantSent:    []string{testFallback},
+		},
+		{
+			name:     "send failure",
+			llm:      &fakeLLM{responses: []*llm.ChatResponse{makeResponse("message", "hello")}},
+			msg:      testMsg(42, "hi"),
+			sendErr:  errors.New("telegram down"),
+			wantSent: []string{"hello", testFallback},
+		},
+		{
+			name:     "successful reply",
+			llm:      &fakeLLM{responses: []*llm.ChatResponse{makeResponse("message", "hello")}},
+			msg:      testMsg(42, "hi"),
+			wantSent: []string{"hello"},
+		},
+		{
+			name: "noop",
+			llm:  &fakeLLM{responses: []*llm.ChatResponse{makeResponse("noop", "")}},
+			msg:  testMsg(42, "hi"),
+		},
+		{
+			name: "think",
+			llm:  &fakeLLM{responses: []*llm.ChatResponse{makeResponse("think", "pondering")}},
+			msg:  testMsg(42, "hi"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sender := &fakeSender{err: tt.sendErr}
+			cfg := NewAgentConfig{
+				Workspace:     testWorkspace(t),
+				LLM:           tt.llm,
+				Sender:        sender,
+				ToolExecutor:  tt.tools,
+				FallbackReply: testFallback,
+			}
+			if tt.transcriber != nil {
+				cfg.Transcriber = tt.transcriber
+				cfg.VoiceDownloader = &fakeVoiceDownloader{filePath: "voice/file.oga", fileData: []byte("ogg")}
+			}
+			ag := New(cfg)
+
+			ag.handleMessage(context.Background(), tt.msg)
+
+			var got []string
+			for _, m := range sender.sent {
+				got = append(got, m.text)
+			}
+			if strings.Join(got, "|") != strings.Join(tt.wantSent, "|") {
+				t.Errorf("sent = %q, want %q", got, tt.wantSent)
+			}
+		})
+	}
+}
+
+// blockingLLM waits for the context to end and returns its error.
+type blockingLLM struct{}
+
+func (blockingLLM) ChatCompletionWithRetry(ctx context.Context, messages []llm.Message, tools []llm.Tool) (*llm.ChatResponse, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestHandleMessage_FallbackAfterDeadline(t *testing.T) {
+	sender := &fakeSender{}
+	ag := New(NewAgentConfig{
+		Workspace:      testWorkspace(t),
+		LLM:            blockingLLM{},
+		Sender:         sender,
+		MessageTimeout: 20 * time.Millisecond,
+		FallbackReply:  testFallback,
+	})
+
+	ag.handleMessage(context.Background(), testMsg(42, "hi"))
+
+	if len(sender.sent) != 1 || sender.sent[0].text != testFallback {
+		t.Errorf("sent = %+v, want a single fallback reply", sender.sent)
+	}
+}
+
+func TestHandleMessage_NoFallbackConfigured(t *testing.T) {
+	sender := &fakeSender{}
+	ag := New(NewAgentConfig{
+		Workspace: testWorkspace(t),
+		LLM:       &fakeLLM{errs: []error{errors.New("provider down")}},
+		Sender:    sender,
+	})
+
+	ag.handleMessage(context.Background(), testMsg(42, "hi"))
+
+	if len(sender.sent) != 0 {
+		t.Errorf("sent = %+v, want nothing without a fallback reply", sender.sent)
+	}
+}
+
+// recordHandler captures slog records for inspection.
+type recordHandler struct {
+	mu      *sync.Mutex
+	records *[]map[string]string
+	attrs   []slog.Attr
+}
+
+func newRecordHandler() *recordHandler {
+	return &recordHandler{mu: &sync.Mutex{}, records: &[]map[string]string{}}
+}
+
+func (h *recordHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *recordHandler) Handle(_ context.Context, r slog.Record) error {
+	rec := map[string]string{"msg": r.Message}
+	for _, a := range h.attrs {
+		rec[a.Key] = a.Value.String()
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		rec[a.Key] = a.Value.String()
+		return true
+	})
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	*h.records = append(*h.records, rec)
+	return nil
+}
+
+func (h *recordHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &recordHandler{mu: h.mu, records: h.records, attrs: append(slices.Clone(h.attrs), attrs...)}
+}
+
+func (h *recordHandler) WithGroup(string) slog.Handler { return h }
+
+func TestHandleMessage_TraceIDCorrelatesLogs(t *testing.T) {
+	h := newRecordHandler()
+	orig := slog.Default()
+	slog.SetDefault(slog.New(h))
+	t.Cleanup(func() { slog.SetDefault(orig) })
+
+	registry := tool.NewRegistry()
+	registry.Register(tool.Definition{
+		Name: "probe",
+		Handler: func(ctx context.Context, args json.RawMessage) tool.ToolResult {
+			platform.Log(ctx).Info("probe ran", "component", "probe")
+			return tool.ToolResult{Success: true, Output: "ok"}
+		},
+	})
+	llmFake := &fakeLLM{responses: []*llm.ChatResponse{
+		makeToolCallResponse(tc("1", "probe", `{}`)),
+		makeResponse("message", "done"),
+	}}
+	ag := New(NewAgentConfig{Workspace: testWorkspace(t), LLM: llmFake, Sender: &fakeSender{}, ToolExecutor: registry})
+
+	ag.handleMessage(context.Background(), testMsg(42, "hi"))
+	ag.handleMessage(context.Background(), testMsg(42, "again"))
+
+	traces := map[string]map[string]bool{} // trace_id -> components seen
+	for _, rec := range *h.records {
+		id := rec["trace_id"]
+		if id == "" {
+			continue
+		}
+		if traces[id] == nil {
+			traces[id] = map[string]bool{}
+		}
+		traces[id][rec["component"]] = true
+	}
+	if len(traces) != 2 {
+		t.Fatalf("got %d trace IDs, want one per message: %v", len(traces), traces)
+	}
+	var first map[string]bool
+	for _, rec := range *h.records {
+		if rec["msg"] == "probe ran" {
+			first = traces[rec["trace_id"]]
+		}
+	}
+	for _, component := range []string{"agent", "tool", "probe"} {
+		if !first[component] {
+			t.Errorf("no %s log carries the message's trace_id: %v", component, first)
+		}
+	}
+}
+
+// fakeTaggedMemory records the tags of each tagged entry.
+type fakeTaggedMemory struct {
+	fakeMemoryWriter
+	tags [][]string
+}
+
+func (f *fakeTaggedMemory) WriteTagged(ctx context.Context, source, content string, tags []string) error {
+	f.tags = append(f.tags, tags)
+	return f.Write(ctx, source, content)
+}
+
+func TestHandleMessage_RecordsAnsweringModel(t *testing.T) {
+	for _, tt := range []struct{ name, model, wantTag string }{
+		{"primary", "mistral-large-latest", "model-mistral-large-latest"},
+		{"fallback", "mistral-small-2409.1", "model-mistral-small-2409-1"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newRecordHandler()
+			orig := slog.Default()
+			slog.SetDefault(slog.New(h))
+			t.Cleanup(func() { slog.SetDefault(orig) })
+
+			resp := makeResponse("message", "Hello")
+			resp.Model = tt.model
+			mem := &fakeTaggedMemory{}
+			ag := New(NewAgentConfig{Workspace: testWorkspace(t), LLM: &fakeLLM{responses: []*llm.ChatResponse{resp}}, Sender: &fakeSender{}, Memory: mem})
+
+			ag.handleMessage(context.Background(), testMsg(42, "hi"))
+
+			if len(mem.entries) != 2 || mem.entries[1].source != "agent" {
+				t.Fatalf("entries = %+v, want owner then agent", mem.entries)
+			}
+			if len(mem.tags) != 1 || !slices.Equal(mem.tags[0], []string{tt.wantTag}) {
+				t.Errorf("agent entry tags = %v, want [%s]", mem.tags, tt.wantTag)
+			}
+			var logged string
+			for _, r := range *h.records {
+				if r["msg"] == "LLM response received" {
+					logged = r["model"]
+				}
+			}
+			if logged != tt.model {
+				t.Errorf("logged model = %q, want %q", logged, tt.model)
+			}
+		})
+	}
+}
+
+// chatMemoryWriter records the chat each memory entry was written for.
+type chatMemoryWriter struct {
+	chats []int64
+}
+
+func (c *chatMemoryWriter) Write(ctx context.Context, source, content string) error {
+	id, _ := platform.ChatIDFrom(ctx)
+	c.chats = append(c.chats, id)
+	return nil
+}
+
+func TestHandleMessage_TagsContextWithChat(t *testing.T) {
+	mem := &chatMemoryWriter{}
+	ag := New(NewAgentConfig{
+		Workspace: testWorkspace(t),
+		LLM:       &fakeLLM{responses: []*llm.ChatResponse{makeResponse("message", "hi")}},
+		Sender:    &fakeSender{},
+		Memory:    mem,
+	})
+
+	ag.handleMessage(context.Background(), textMessage(42, "hello"))
+
+	if len(mem.chats) == 0 {
+		t.Fatal("expected memory writes")
+	}
+	for _, id := range mem.chats {
+		if id != 42 {
+			t.Errorf("memory written for chat %d, want 42", id)
+		}
+	}
+}
+
+// --- Warm-up tests ---
+
+// startupEvents records, in order, the startup work and LLM calls of a test.
+type startupEvents struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (e *startupEvents) add(event string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.events = append(e.events, event)
+}
+
+func (e *startupEvents) list() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return slices.Clone(e.events)
+}
+
+// orderedLLM records an "llm" event and signals called before answering like fakeLLM.
+type orderedLLM struct {
+	fakeLLM
+	events *startupEvents
+	called chan struct{}
+}
+
+func (o *orderedLLM) ChatCompletionWithRetry(ctx context.Context, messages []llm.Message, tools []llm.Tool) (*llm.ChatResponse, error) {
+	o.events.add("llm")
+	defer func() { o.called <- struct{}{} }()
+	return o.fakeLLM.ChatCompletionWithRetry(ctx, messages, tools)
+}
+
+// stubBlockingIntrospection makes introspection wait for release, recording an
+// "introspection" event for each command it runs.
+func stubBlockingIntrospection(t *testing.T, events *startupEvents, release <-chan struct{}) {
+	t.Helper()
+	t.Cleanup(saveIntrospectVars(t))
+	introspectGetOS = func() string { return "linux" }
+	introspectGetArch = func() string { return "arm64" }
+	introspectGetCPU = func() int { return 4 }
+	introspectReadFile = func(string) ([]byte, error) { return nil, errors.New("no meminfo") }
+	introspectLookPath = func(string) (string, error) { return "", errors.New("not found") }
+	introspectRunCmd = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		<-release
+		events.add("introspection")
+		return nil, errors.New("no df")
+	}
+	introspectNow = func() time.Time { return fixedTime }
+}
+
+func TestRun_NoMessageBeforeIntrospection(t *testing.T) {
+	events := &startupEvents{}
+	release := make(chan struct{})
+	stubBlockingIntrospection(t, events, release)
+	ws := testWorkspace(t)
+	ws.AgentMD = "# Test Agent"
+	l := &orderedLLM{fakeLLM: fakeLLM{responses: []*llm.ChatResponse{makeResponse("message", "hello")}}, events: events, called: make(chan struct{}, 1)}
+	ag := newTestAgent(ws, l, &fakeSender{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	messages := make(chan telegram.TelegramMessage, 1)
+	messages <- testMsg(42, "hi")
+	done := make(chan error, 1)
+	go func() { done <- ag.Run(ctx, messages) }()
+
+	select {
+	case <-l.called:
+		t.Fatal("message handled while introspection was running")
+	case <-ag.Ready():
+		t.Fatal("agent ready while introspection was running")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	select {
+	case <-l.called:
+	case <-time.After(time.Second):
+		t.Fatal("message not handled after introspection")
+	}
+	cancel()
+	<-done
+
+	got := events.list()
+	if len(got) < 2 || got[len(got)-1] != "llm" || slices.Index(got, "llm") != len(got)-1 {
+		t.Errorf("events = %v, want every introspection step before the LLM call", got)
+	}
+}
+
+func TestWarmUp_LoadsWorkspaceBeforeRun(t *testing.T) {
+	events := &startupEvents{}
+	release := make(chan struct{})
+	stubBlockingIntrospection(t, events, release)
+	root := t.TempDir()
+	os.WriteFile(filepath.Join(root, "AGENT.md"), []byte("# Agent"), 0o644)
+	os.WriteFile(filepath.Join(root, "SOUL.md"), []byte("# Soul"), 0o644)
+	ws, err := workspace.Load(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A skill added after the startup load is only seen by a full reload.
+	os.MkdirAll(filepath.Join(root, "skills", "deploy"), 0o755)
+	os.WriteFile(filepath.Join(root, "skills", "deploy", "SKILL.md"), []byte("# Deploy"), 0o644)
+	l := &orderedLLM{fakeLLM: fakeLLM{responses: []*llm.ChatResponse{makeResponse("message", "hello")}}, events: events, called: make(chan struct{}, 1)}
+	ag := newTestAgent(ws, l, &fakeSender{})
+
+	warmed := make(chan error, 1)
+	go func() { warmed <- ag.WarmUp(context.Background()) }()
+	select {
+	case <-ag.Ready():
+		t.Fatal("agent ready before introspection finished")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	if err := <-warmed; err != nil {
+		t.Fatalf("WarmUp: %v", err)
+	}
+	select {
+	case <-ag.Ready():
+	default:
+		t.Fatal("Ready not closed after WarmUp")
+	}
+	if !strings.Contains(ws.AgentMD, envSectionHeader) || len(ws.Skills) != 1 || ws.Skills[0].Name != "deploy" {
+		t.Errorf("workspace after warm-up: AgentMD = %q, skills = %+v; want the environment section and the deploy skill", ws.AgentMD, ws.Skills)
+	}
+	introspections := len(events.list())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	messages := make(chan telegram.TelegramMessage, 1)
+	messages <- testMsg(42, "hi")
+	done := make(chan error, 1)
+	go func() { done <- ag.Run(ctx, messages) }()
+	select {
+	case <-l.called:
+	case <-time.After(time.Second):
+		t.Fatal("message not handled")
+	}
+	cancel()
+	<-done
+
+	if got := events.list(); len(got) != introspections+1 {
+		t.Errorf("events = %v, want Run not to repeat the warm-up", got)
+	}
+	if prompt := l.calls[0][0].Content; !strings.Contains(prompt, envSectionHeader) || !strings.Contains(prompt, "# Deploy") {
+		t.Errorf("system prompt = %q, want the warmed-up workspace", prompt)
+	}
+}
+
+func TestWarmUp_ReloadError(t *testing.T) {
+	stubBlockingIntrospection(t, &startupEvents{}, closedChan())
+	origLoad := agentWorkspaceLoadFn
+	t.Cleanup(func() { agentWorkspaceLoadFn = origLoad })
+	agentWorkspaceLoadFn = func(string, int) (*workspace.Workspace, error) {
+		return nil, errors.New("disk gone")
+	}
+	ws := testWorkspace(t)
+	ag := newTestAgent(ws, &fakeLLM{}, &fakeSender{})
+
+	if err := ag.WarmUp(context.Background()); err == nil || !strings.Contains(err.Error(), "disk gone") {
+		t.Errorf("WarmUp error = %v, want the reload failure", err)
+	}
+	select {
+	case <-ag.Ready():
+	default:
+		t.Error("Ready not closed after a failed warm-up")
+	}
+	if ws.SoulMD != "You are a test agent." {
+		t.Errorf("SoulMD = %q, want the startup workspace kept", ws.SoulMD)
+	}
+	if err := ag.WarmUp(context.Background()); err != nil {
+		t.Errorf("second WarmUp = %v, want no effect", err)
+	}
+}
+
+func closedChan() <-chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}
@@ -0,0 +1,73 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/edouard/pureclaw/internal/platform"
+)
+
+// maxAttachmentSize caps a reply attachment; Telegram bots may upload up to 50 MB,
+// but reading larger files into memory would exceed the Pi's budget.
+const maxAttachmentSize = 20 << 20
+
+// sendAttachments uploads the workspace files named by a reply to chatID.
+// Files that cannot be sent are reported to the chat so the user knows why
+// an announced attachment is missing.
+func (a *Agent) sendAttachments(ctx context.Context, chatID int64, paths []string) {
+	if len(paths) == 0 {
+		return
+	}
+	if a.documentSender == nil {
+		platform.Log(ctx).Warn("reply attachments requested but document upload is unavailable",
+			"component", "agent", "operation", "send_attachments",
+			"attachments", len(paths))
+		return
+	}
+	for _, p := range paths {
+		data, err := a.readAttachment(p)
+		if err == nil {
+			err = a.sendDocument(ctx, chatID, filepath.Base(p), data, "")
+		}
+		if err != nil {
+			platform.Log(ctx).Warn("reply attachment not sent",
+				"component", "agent", "operation", "send_attachments",
+				"path", p, "error", err)
+			if sendErr := a.send(ctx, chatID, fmt.Sprintf("Attachment %s not sent: %v", p, err)); sendErr != nil {
+				platform.Log(ctx).Error("failed to report attachment error",
+					"component", "agent", "operation", "send_attachments",
+					"error", sendErr)
+			}
+			continue
+		}
+		platform.Log(ctx).Info("reply attachment sent",
+			"component", "agent", "operation", "send_attachments",
+			"path", p, "bytes", len(data))
+	}
+}
+
+// readAttachment reads a workspace-relative attachment, rejecting paths that
+// escape the workspace, directories, and files over maxAttachmentSize.
+func (a *Agent) readAttachment(p string) ([]byte, error) {
+	if p == "" || filepath.IsAbs(p) {
+		return nil, fmt.Errorf("attachment path must be relative to the workspace")
+	}
+	root := a.workspace.Root
+	path := filepath.Join(root, p)
+	if err := platform.ValidatePath(root, path); err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("attachment is a directory")
+	}
+	if info.Size() > maxAttachmentSize {
+		return nil, fmt.Errorf("attachment is %d bytes, limit is %d", info.Size(), maxAttachmentSize)
+	}
+	return os.ReadFile(path)
+}
@@ -0,0 +1,103 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/edouard/pureclaw/internal/llm"
+)
+
+func attachmentResponse(content string) *llm.ChatResponse {
+	return &llm.ChatResponse{Choices: []llm.Choice{{
+		Message:      llm.Message{Content: content},
+		FinishReason: "stop",
+	}}}
+}
+
+func TestHandleMessage_UploadsAttachment(t *testing.T) {
+	ws := testWorkspace(t)
+	if err := os.MkdirAll(filepath.Join(ws.Root, "reports"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(ws.Root, "reports", "disk.md"), []byte("# Disk report"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	docs := &fakeDocumentSender{}
+	sender := &fakeSender{}
+	ag := New(NewAgentConfig{
+		Workspace:      ws,
+		LLM:            &fakeLLM{responses: []*llm.ChatResponse{attachmentResponse(`{"type":"message","content":"Here is the report.","attachments":["reports/disk.md"]}`)}},
+		Sender:         sender,
+		DocumentSender: docs,
+		Attachments:    true,
+	})
+
+	ag.handleMessage(context.Background(), testMsg(42, "send me the disk report"))
+
+	if len(sender.sent) != 1 || sender.sent[0].text != "Here is the report." {
+		t.Errorf("sent = %+v, want the reply text", sender.sent)
+	}
+	if len(docs.docs) != 1 {
+		t.Fatalf("documents = %d, want 1", len(docs.docs))
+	}
+	if d := docs.docs[0]; d.chatID != 42 || d.fileName != "disk.md" || string(d.data) != "# Disk report" {
+		t.Errorf("document = %+v, want reports/disk.md sent to chat 42", d)
+	}
+}
+
+func TestHandleMessage_RejectsAttachmentOutsideWorkspace(t *testing.T) {
+	ws := testWorkspace(t)
+	outside := filepath.Join(filepath.Dir(ws.Root), "secret.txt")
+	if err := os.WriteFile(outside, []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	docs := &fakeDocumentSender{}
+	sender := &fakeSender{}
+	ag := New(NewAgentConfig{
+		Workspace:      ws,
+		LLM:            &fakeLLM{responses: []*llm.ChatResponse{attachmentResponse(`{"type":"message","content":"Done.","attachments":["../secret.txt","` + outside + `"]}`)}},
+		Sender:         sender,
+		DocumentSender: docs,
+		Attachments:    true,
+	})
+
+	ag.handleMessage(context.Background(), testMsg(42, "leak it"))
+
+	if len(docs.docs) != 0 {
+		t.Fatalf("documents = %+v, want none for paths outside the workspace", docs.docs)
+	}
+	if len(sender.sent) != 3 {
+		t.Fatalf("sent = %+v, want the reply and one notice per rejected attachment", sender.sent)
+	}
+	for _, m := range sender.sent[1:] {
+		if !strings.Contains(m.text, "not sent") {
+			t.Errorf("notice = %q, want an attachment error", m.text)
+		}
+	}
+}
+
+func TestHandleMessage_AttachmentsIgnoredWhenDisabled(t *testing.T) {
+	ws := testWorkspace(t)
+	if err := os.WriteFile(filepath.Join(ws.Root, "a.md"), []byte("a"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	docs := &fakeDocumentSender{}
+	ag := New(NewAgentConfig{
+		Workspace:      ws,
+		LLM:            &fakeLLM{responses: []*llm.ChatResponse{attachmentResponse(`{"type":"message","content":"ok","attachments":["a.md"]}`)}},
+		Sender:         &fakeSender{},
+		DocumentSender: docs,
+	})
+
+	ag.handleMessage(context.Background(), testMsg(42, "hi"))
+
+	if len(docs.docs) != 0 {
+		t.Errorf("documents = %d, want 0 when attachments are off", len(docs.docs))
+	}
+	if strings.Contains(ag.systemPrompt(), "attachments") {
+		t.Error("system prompt should not mention attachments when they are off")
+	}
+}
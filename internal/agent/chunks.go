@@ -0,0 +1,134 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/edouard/pureclaw/internal/platform"
+	"github.com/edouard/pureclaw/internal/telegram"
+)
+
+// replyChunkRunes leaves room in each chunk for formatting added after the split.
+const replyChunkRunes = telegram.MaxMessageLength - 96
+
+// codeFence opens and closes Markdown code blocks.
+const codeFence = "```"
+
+// splitForTelegram splits text into chunks of at most limit runes, breaking at
+// the last paragraph break, or failing that the last newline or space, that
+// keeps a chunk in bounds. A code block cut in two is closed at the end of its
+// chunk and reopened, with its language, at the start of the next one. It
+// never splits a multi-byte character.
+func splitForTelegram(text string, limit int) []string {
+	var chunks []string
+	runes := []rune(text)
+	for len(runes) > limit {
+		cut := lastBreak(runes[:limit])
+		fence, open := openFence(string(runes[:cut]))
+		// Only carry a fence over when it leaves most of each chunk for content.
+		reopen := open && 4*(len([]rune(fence))+len(codeFence)+2) < limit
+		if reopen {
+			cut = lastBreak(runes[:limit-len(codeFence)-1])
+			fence, reopen = openFence(string(runes[:cut]))
+		}
+
+		chunk := strings.TrimRight(string(runes[:cut]), " \n")
+		runes = runes[cut:]
+		// Leading spaces are kept inside a code block, where they are indentation.
+		for len(runes) > 0 && (runes[0] == '\n' || (runes[0] == ' ' && !open)) {
+			runes = runes[1:]
+		}
+		if reopen {
+			chunk += "\n" + codeFence
+			runes = append([]rune(fence+"\n"), runes...)
+		}
+		chunks = append(chunks, chunk)
+	}
+	if len(runes) > 0 || len(chunks) == 0 {
+		chunks = append(chunks, string(runes))
+	}
+	return chunks
+}
+
+// lastBreak returns where to cut runes: after its last paragraph break, else
+// its last newline, else its last space, else at its end. Breaks in the first
+// half are ignored so chunks stay large.
+func lastBreak(runes []rune) int {
+	for i := len(runes) - 1; i >= len(runes)/2 && i > 0; i-- {
+		if runes[i] == '\n' && runes[i-1] == '\n' {
+			return i + 1
+		}
+	}
+	for _, sep := range []rune{'\n', ' '} {
+		for i := len(runes) - 1; i >= len(runes)/2; i-- {
+			if runes[i] == sep {
+				return i + 1
+			}
+		}
+	}
+	return len(runes)
+}
+
+// openFence reports whether text ends inside a code block, and if so returns
+// the line that opened it (e.g. "```go").
+func openFence(text string) (fence string, open bool) {
+	for line := range strings.Lines(text) {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, codeFence) {
+			continue
+		}
+		if open {
+			fence, open = "", false
+		} else {
+			fence, open = line, true
+		}
+	}
+	return fence, open
+}
+
+// sendReply sends a reply split into Telegram-sized messages, each numbered
+// "(1/3)" style when there is more than one. Past the chunk
+// limit, only the first maxReplyChunks messages are sent, followed by the full
+// reply as a document, or by a note when documents can't be uploaded. Numbers
+// count every chunk, and the last one sent says the rest is attached.
+func (a *Agent) sendReply(ctx context.Context, chatID int64, reply string) error {
+	chunks := splitForTelegram(reply, replyChunkRunes)
+	total := len(chunks)
+	capped := a.maxReplyChunks > 0 && len(chunks) > a.maxReplyChunks
+	if capped {
+		platform.Log(ctx).Warn("reply over the chunk limit, sending the rest as a document",
+			"component", "agent",
+			"operation", "send_reply",
+			"chunks", len(chunks),
+			"max_chunks", a.maxReplyChunks,
+		)
+		chunks = chunks[:a.maxReplyChunks]
+	}
+	for i, chunk := range chunks {
+		if a.formatCodeBlocks {
+			chunk = telegram.FormatCodeBlocks(chunk)
+		}
+		switch {
+		case capped && i == len(chunks)-1 && a.documentSender != nil:
+			chunk = fmt.Sprintf("(%d/%d, rest attached) %s", i+1, total, chunk)
+		case total > 1:
+			chunk = fmt.Sprintf("(%d/%d) %s", i+1, total, chunk)
+		}
+		if err := a.send(ctx, chatID, chunk); err != nil {
+			return err
+		}
+	}
+	if !capped {
+		return nil
+	}
+
+	if a.documentSender == nil {
+		return a.send(ctx, chatID, "(reply truncated: too long to send in full)")
+	}
+	caption := fmt.Sprintf("Full reply (%d characters)", len([]rune(reply)))
+	if err := a.sendDocument(ctx, chatID, "reply.md", []byte(reply), caption); err != nil {
+		return fmt.Errorf("send full reply as document: %w", err)
+	}
+	return nil
+}
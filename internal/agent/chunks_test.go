@@ -0,0 +1,165 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSplitForTelegram(t *testing.T) {
+	if got := splitForTelegram("hello", 10); len(got) != 1 || got[0] != "hello" {
+		t.Errorf("short text = %q, want one chunk", got)
+	}
+	if got := splitForTelegram("", 10); len(got) != 1 || got[0] != "" {
+		t.Errorf("empty text = %q, want one empty chunk", got)
+	}
+
+	got := splitForTelegram("first line\nsecond line here", 20)
+	if len(got) != 2 || got[0] != "first line" || got[1] != "second line here" {
+		t.Errorf("split = %q, want a break at the newline", got)
+	}
+
+	text := strings.Repeat("é", 25)
+	got = splitForTelegram(text, 10)
+	if len(got) != 3 {
+		t.Fatalf("got %d chunks, want 3", len(got))
+	}
+	for i, c := range got {
+		if !utf8.ValidString(c) || utf8.RuneCountInString(c) > 10 {
+			t.Errorf("chunk %d = %q, want valid UTF-8 of at most 10 runes", i, c)
+		}
+	}
+	if strings.Join(got, "") != text {
+		t.Error("chunks without break points don't add back up to the text")
+	}
+
+	got = splitForTelegram("first line\n   second line here", 20)
+	if len(got) != 2 || got[1] != "second line here" {
+		t.Errorf("split = %q, want the spaces after the cut dropped", got)
+	}
+}
+
+func TestSplitForTelegram_PrefersParagraphBreaks(t *testing.T) {
+	got := splitForTelegram("intro paragraph\n\nline one\nline two", 30)
+	if len(got) != 2 || got[0] != "intro paragraph" || got[1] != "line one\nline two" {
+		t.Errorf("split = %q, want a break between the paragraphs", got)
+	}
+}
+
+func TestSplitForTelegram_ReopensCodeFence(t *testing.T) {
+	var code strings.Builder
+	for i := range 30 {
+		fmt.Fprintf(&code, "    fmt.Println(%d)\n", i)
+	}
+	text := "Here is the program:\n\n```go\n" + code.String() + "```\n\nDone."
+
+	got := splitForTelegram(text, 200)
+	if len(got) < 3 {
+		t.Fatalf("got %d chunks, want the code block spread over several", len(got))
+	}
+	for i, c := range got {
+		if n := utf8.RuneCountInString(c); n > 200 {
+			t.Errorf("chunk %d has %d runes, want at most 200", i, n)
+		}
+		if n := strings.Count(c, "```"); n%2 != 0 {
+			t.Errorf("chunk %d has %d fences, want balanced code blocks:\n%s", i, n, c)
+		}
+	}
+	for i, c := range got[1 : len(got)-1] {
+		if !strings.HasPrefix(c, "```go\n") {
+			t.Errorf("chunk %d = %q, want the code block reopened with its language", i+1, c)
+		}
+	}
+	if !strings.Contains(got[1], "\n    fmt.Println(") {
+		t.Errorf("chunk 1 = %q, want code indentation kept", got[1])
+	}
+	if !strings.HasSuffix(got[len(got)-1], "Done.") {
+		t.Errorf("last chunk = %q, want the text after the code block", got[len(got)-1])
+	}
+}
+
+func TestSendReply_NumbersChunks(t *testing.T) {
+	sender := &fakeSender{}
+	ag := New(NewAgentConfig{Sender: sender})
+
+	reply := strings.Repeat(strings.Repeat("word ", 700)+"word\n\n", 3) // one chunk per paragraph
+	if err := ag.sendReply(context.Background(), 42, reply); err != nil {
+		t.Fatalf("sendReply() error = %v", err)
+	}
+	if len(sender.sent) != 3 {
+		t.Fatalf("sent %d messages, want 3", len(sender.sent))
+	}
+	for i, m := range sender.sent {
+		if want := fmt.Sprintf("(%d/3) word", i+1); !strings.HasPrefix(m.text, want) {
+			t.Errorf("message %d = %.20q..., want prefix %q", i, m.text, want)
+		}
+	}
+}
+
+func TestSendReply_CapsChunksAndSendsDocument(t *testing.T) {
+	sender := &fakeSender{}
+	docs := &fakeDocumentSender{}
+	ag := New(NewAgentConfig{
+		Sender:         sender,
+		DocumentSender: docs,
+		MaxReplyChunks: 3,
+	})
+
+	reply := strings.Repeat(strings.Repeat("word ", 199)+"word\n", 50) // ~50 chunks
+	if err := ag.sendReply(context.Background(), 42, reply); err != nil {
+		t.Fatalf("sendReply() error = %v", err)
+	}
+
+	if len(sender.sent) != 3 {
+		t.Errorf("sent %d messages, want the cap of 3", len(sender.sent))
+	}
+	for i, m := range sender.sent {
+		if n := utf8.RuneCountInString(m.text); n > 4096 {
+			t.Errorf("message %d has %d runes, over Telegram's limit", i, n)
+		}
+	}
+	total := len(splitForTelegram(reply, replyChunkRunes))
+	if want := fmt.Sprintf("(1/%d) ", total); !strings.HasPrefix(sender.sent[0].text, want) {
+		t.Errorf("first message = %.20q..., want prefix %q", sender.sent[0].text, want)
+	}
+	if want := fmt.Sprintf("(3/%d, rest attached) ", total); !strings.HasPrefix(sender.sent[2].text, want) {
+		t.Errorf("last message = %.30q..., want prefix %q", sender.sent[2].text, want)
+	}
+	if len(docs.docs) != 1 {
+		t.Fatalf("sent %d documents, want 1", len(docs.docs))
+	}
+	if d := docs.docs[0]; string(d.data) != reply || d.chatID != 42 || d.fileName != "reply.md" {
+		t.Errorf("document = %s to %d with %d bytes, want the full %d-byte reply as reply.md", d.fileName, d.chatID, len(d.data), len(reply))
+	}
+}
+
+func TestSendReply_WithinCap(t *testing.T) {
+	sender := &fakeSender{}
+	docs := &fakeDocumentSender{}
+	ag := New(NewAgentConfig{Sender: sender, DocumentSender: docs, MaxReplyChunks: 3})
+
+	if err := ag.sendReply(context.Background(), 42, "short answer"); err != nil {
+		t.Fatalf("sendReply() error = %v", err)
+	}
+	if len(sender.sent) != 1 || sender.sent[0].text != "short answer" {
+		t.Errorf("sent = %+v, want the reply as one message", sender.sent)
+	}
+	if len(docs.docs) != 0 {
+		t.Errorf("sent %d documents, want none", len(docs.docs))
+	}
+}
+
+func TestSendReply_CappedWithoutDocumentSender(t *testing.T) {
+	sender := &fakeSender{}
+	ag := New(NewAgentConfig{Sender: sender, MaxReplyChunks: 2})
+
+	reply := strings.Repeat("x", 20000)
+	if err := ag.sendReply(context.Background(), 42, reply); err != nil {
+		t.Fatalf("sendReply() error = %v", err)
+	}
+	if len(sender.sent) != 3 || !strings.Contains(sender.sent[2].text, "truncated") {
+		t.Errorf("sent %d messages, want 2 chunks and a truncation note", len(sender.sent))
+	}
+}
@@ -0,0 +1,333 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/edouard/pureclaw/internal/memory"
+	"github.com/edouard/pureclaw/internal/platform"
+	"github.com/edouard/pureclaw/internal/workspace"
+)
+
+// defaultExportWindow is the memory range /export covers when no duration is given.
+const defaultExportWindow = 24 * time.Hour
+
+// Replaceable for testing.
+var commandNow = time.Now
+
+// commandHelp lists the commands handleCommand runs, in the order /help shows them.
+var commandHelp = []struct{ usage, description string }{
+	{"/status", "uptime, model, today's memory entries, sub-agent and heartbeat state"},
+	{"/export [duration]", "send the last 24h (or duration) of memory as a transcript"},
+	{"/model [name]", "show or switch the text model"},
+	{"/skill name on|off", "enable or disable a workspace skill (owners only)"},
+	{"/help", "this list"},
+}
+
+// handleCommand runs a slash command and reports whether text was one.
+// Unknown commands are left for the LLM.
+func (a *Agent) handleCommand(ctx context.Context, chatID int64, text string) bool {
+	name, arg, _ := strings.Cut(strings.TrimSpace(text), " ")
+	name, _, _ = strings.Cut(name, "@") // "/export@my_bot" in group chats
+	switch name {
+	case "/export":
+		a.handleExport(ctx, chatID, strings.TrimSpace(arg))
+	case "/model":
+		a.handleModel(ctx, chatID, strings.TrimSpace(arg))
+	case "/skill":
+		a.handleSkill(ctx, chatID, strings.TrimSpace(arg))
+	case "/status":
+		a.handleStatus(ctx, chatID)
+	case "/help":
+		a.reply(ctx, chatID, helpText())
+	default:
+		return false
+	}
+	platform.Log(ctx).Info("command handled",
+		"component", "agent",
+		"operation", "command",
+		"command", name,
+	)
+	return true
+}
+
+// handleExport writes the recent memory range to exports/<timestamp>.md as a
+// markdown transcript and uploads it to the owner. arg is an optional window
+// such as "48h" (default 24h).
+func (a *Agent) handleExport(ctx context.Context, chatID int64, arg string) {
+	if a.memorySearcher == nil || a.workspace == nil {
+		a.reply(ctx, chatID, "Export is not available in this deployment.")
+		return
+	}
+
+	window := defaultExportWindow
+	if arg != "" {
+		d, err := time.ParseDuration(arg)
+		if err != nil || d <= 0 {
+			a.reply(ctx, chatID, "Usage: /export [duration], e.g. /export 48h")
+			return
+		}
+		window = d
+	}
+
+	now := commandNow()
+	start := now.Add(-window)
+	entries, err := a.memorySearcher.ReadRange(ctx, start, now)
+	if err != nil {
+		platform.Log(ctx).Error("export read failed",
+			"component", "agent",
+			"operation", "export",
+			"error", err,
+		)
+		a.reply(ctx, chatID, fmt.Sprintf("Export failed: %v", err))
+		return
+	}
+	if len(entries) == 0 {
+		a.reply(ctx, chatID, fmt.Sprintf("Nothing to export from the last %s.", window))
+		return
+	}
+
+	data := []byte(formatTranscript(entries, start, now))
+	name := now.Format("2006-01-02T15-04-05") + ".md"
+	path := filepath.Join(a.workspace.Root, "exports", name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		a.reply(ctx, chatID, fmt.Sprintf("Export failed: %v", err))
+		return
+	}
+	if err := platform.AtomicWrite(path, data, 0o644); err != nil {
+		platform.Log(ctx).Error("export write failed",
+			"component", "agent",
+			"operation", "export",
+			"path", path,
+			"error", err,
+		)
+		a.reply(ctx, chatID, fmt.Sprintf("Export failed: %v", err))
+		return
+	}
+
+	platform.Log(ctx).Info("conversation exported",
+		"component", "agent",
+		"operation", "export",
+		"path", path,
+		"entries", len(entries),
+	)
+
+	saved := fmt.Sprintf("Exported %d entries from the last %s to exports/%s", len(entries), window, name)
+	if a.documentSender == nil {
+		a.reply(ctx, chatID, saved)
+		return
+	}
+	if err := a.sendDocument(ctx, chatID, name, data, saved); err != nil {
+		platform.Log(ctx).Error("export upload failed",
+			"component", "agent",
+			"operation", "export",
+			"error", err,
+		)
+		a.reply(ctx, chatID, saved+" (upload failed: "+err.Error()+")")
+	}
+}
+
+// handleModel switches the text model used for the following LLM calls to
+// name, which must be one of the allowed models. Without a name it reports the
+// current model and the allowed ones. The switch is persisted when SaveModel is set.
+func (a *Agent) handleModel(ctx context.Context, chatID int64, name string) {
+	switcher, ok := a.llm.(modelSwitcher)
+	if !ok || len(a.allowedModels) == 0 {
+		a.reply(ctx, chatID, "Switching models is not enabled: list the models in allowed_models in config.json.")
+		return
+	}
+	allowed := strings.Join(a.allowedModels, ", ")
+	current := switcher.Model()
+	if name == "" {
+		a.reply(ctx, chatID, fmt.Sprintf("Current model: %s\nAllowed: %s", current, allowed))
+		return
+	}
+	if !slices.Contains(a.allowedModels, name) {
+		a.reply(ctx, chatID, fmt.Sprintf("Unknown model %q. Allowed: %s", name, allowed))
+		return
+	}
+	if name == current {
+		a.reply(ctx, chatID, fmt.Sprintf("Already using %s.", name))
+		return
+	}
+
+	switcher.SetModel(name)
+	platform.Log(ctx).Info("model switched",
+		"component", "agent",
+		"operation", "model",
+		"from", current,
+		"to", name,
+	)
+	if a.saveModel == nil {
+		a.reply(ctx, chatID, fmt.Sprintf("Switched to %s until restart.", name))
+		return
+	}
+	if err := a.saveModel(name); err != nil {
+		platform.Log(ctx).Error("model switch not saved",
+			"component", "agent",
+			"operation", "model",
+			"model", name,
+			"error", err,
+		)
+		a.reply(ctx, chatID, fmt.Sprintf("Switched to %s until restart (saving it failed: %v).", name, err))
+		return
+	}
+	a.reply(ctx, chatID, fmt.Sprintf("Switched to %s and saved it to the config.", name))
+}
+
+// handleSkill turns a workspace skill on or off for an owner. arg is
+// "<name> on|off"; the choice is saved in the skill's front matter and the
+// workspace is reloaded so the system prompt reflects it.
+func (a *Agent) handleSkill(ctx context.Context, chatID int64, arg string) {
+	if a.workspace == nil {
+		a.reply(ctx, chatID, "Skills are not available in this deployment.")
+		return
+	}
+	if !slices.Contains(a.ownerIDs, chatID) {
+		platform.Log(ctx).Warn("skill toggle denied",
+			"component", "agent",
+			"operation", "skill",
+			"chat_id", chatID,
+		)
+		a.reply(ctx, chatID, "Only an owner can enable or disable skills.")
+		return
+	}
+	fields := strings.Fields(arg)
+	if len(fields) != 2 || (fields[1] != "on" && fields[1] != "off") {
+		a.reply(ctx, chatID, "Usage: /skill name on|off")
+		return
+	}
+	name, enabled := fields[0], fields[1] == "on"
+
+	if err := workspace.SetSkillEnabled(a.workspace.Root, name, enabled); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			a.reply(ctx, chatID, fmt.Sprintf("Unknown skill %q: no skills/%s/SKILL.md in the workspace.", name, name))
+			return
+		}
+		platform.Log(ctx).Error("skill toggle failed",
+			"component", "agent",
+			"operation", "skill",
+			"skill", name,
+			"error", err,
+		)
+		a.reply(ctx, chatID, fmt.Sprintf("Changing skill %q failed: %v", name, err))
+		return
+	}
+
+	state := "disabled"
+	if enabled {
+		state = "enabled"
+	}
+	platform.Log(ctx).Info("skill toggled",
+		"component", "agent",
+		"operation", "skill",
+		"skill", name,
+		"enabled", enabled,
+	)
+	if err := a.reloadWorkspace(ctx); err != nil {
+		platform.Log(ctx).Error("workspace reload failed after skill toggle",
+			"component", "agent",
+			"operation", "skill",
+			"error", err,
+		)
+		a.reply(ctx, chatID, fmt.Sprintf("Skill %q %s, but reloading the workspace failed: %v", name, state, err))
+		return
+	}
+	if enabled && !slices.ContainsFunc(a.workspace.Skills, func(s workspace.Skill) bool { return s.Name == name }) {
+		a.reply(ctx, chatID, fmt.Sprintf("Skill %q enabled but not loaded: the skill limit (%d) keeps higher-priority skills.", name, a.workspace.MaxSkills))
+		return
+	}
+	a.reply(ctx, chatID, fmt.Sprintf("Skill %q %s.", name, state))
+}
+
+// handleStatus reports the agent's uptime, model, today's memory entry count,
+// and sub-agent and heartbeat activity. Parts the deployment doesn't provide
+// are left out.
+func (a *Agent) handleStatus(ctx context.Context, chatID int64) {
+	now := commandNow()
+	var b strings.Builder
+	fmt.Fprintf(&b, "Uptime: %s\n", now.Sub(a.startedAt).Round(time.Second))
+	if switcher, ok := a.llm.(modelSwitcher); ok {
+		fmt.Fprintf(&b, "Model: %s\n", switcher.Model())
+	}
+	if statter, ok := a.memorySearcher.(memoryStatter); ok {
+		midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		if stats, err := statter.Stats(ctx, now.Sub(midnight)); err != nil {
+			platform.Log(ctx).Warn("memory stats failed",
+				"component", "agent",
+				"operation", "status",
+				"error", err,
+			)
+			fmt.Fprintf(&b, "Memory entries today: unknown (%v)\n", err)
+		} else {
+			fmt.Fprintf(&b, "Memory entries today: %d\n", stats.Entries)
+		}
+	}
+	if a.subAgents != nil {
+		state := "idle"
+		if a.subAgents.IsActive() {
+			state = "running"
+		}
+		fmt.Fprintf(&b, "Sub-agent: %s\n", state)
+	}
+	heartbeat := "off"
+	switch {
+	case a.heartbeat == nil || a.heartbeatTick == nil:
+	case a.heartbeatRunning.Load():
+		heartbeat = "on, running now"
+	default:
+		heartbeat = "on"
+	}
+	fmt.Fprintf(&b, "Heartbeat: %s\n", heartbeat)
+	if a.workspace != nil {
+		fmt.Fprintf(&b, "Skills: %d loaded", len(a.workspace.Skills))
+	}
+	a.reply(ctx, chatID, strings.TrimSpace(b.String()))
+}
+
+// helpText lists the built-in commands.
+func helpText() string {
+	var b strings.Builder
+	b.WriteString("Commands:\n")
+	for _, c := range commandHelp {
+		fmt.Fprintf(&b, "%s — %s\n", c.usage, c.description)
+	}
+	b.WriteString("Anything else goes to the assistant.")
+	return b.String()
+}
+
+// formatTranscript renders memory entries as a readable markdown transcript.
+func formatTranscript(entries []memory.SearchResult, start, end time.Time) string {
+	var b strings.Builder
+	b.WriteString("# Conversation export\n\n")
+	fmt.Fprintf(&b, "%s — %s (%d entries)\n", start.Format("2006-01-02 15:04"), end.Format("2006-01-02 15:04"), len(entries))
+	for _, e := range entries {
+		fmt.Fprintf(&b, "\n**%s** — %s", e.Time.Format("2006-01-02 15:04"), e.Source)
+		for _, tag := range e.Tags {
+			b.WriteString(" #" + tag)
+		}
+		fmt.Fprintf(&b, "\n\n%s\n", e.Content)
+	}
+	return b.String()
+}
+
+// reply sends a plain text message to the chat, logging send failures.
+func (a *Agent) reply(ctx context.Context, chatID int64, text string) {
+	if a.sender == nil {
+		return
+	}
+	if err := a.send(ctx, chatID, text); err != nil {
+		platform.Log(ctx).Error("failed to send reply",
+			"component", "agent",
+			"operation", "reply",
+			"error", err,
+		)
+	}
+}
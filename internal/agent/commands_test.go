@@ -0,0 +1,468 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/edouard/pureclaw/internal/llm"
+	"github.com/edouard/pureclaw/internal/memory"
+	"github.com/edouard/pureclaw/internal/telegram"
+	"github.com/edouard/pureclaw/internal/workspace"
+)
+
+type sentDocument struct {
+	chatID   int64
+	fileName string
+	data     []byte
+	caption  string
+}
+
+type fakeDocumentSender struct {
+	docs []sentDocument
+	err  error
+}
+
+func (f *fakeDocumentSender) SendDocument(ctx context.Context, chatID int64, fileName string, data []byte, caption string) error {
+	f.docs = append(f.docs, sentDocument{chatID, fileName, data, caption})
+	return f.err
+}
+
+func stubCommandNow(t *testing.T, now time.Time) {
+	t.Helper()
+	orig := commandNow
+	t.Cleanup(func() { commandNow = orig })
+	commandNow = func() time.Time { return now }
+}
+
+func textMessage(chatID int64, text string) telegram.TelegramMessage {
+	return telegram.TelegramMessage{Message: telegram.Message{Chat: telegram.Chat{ID: chatID}, Text: text}}
+}
+
+func TestHandleMessage_Export(t *testing.T) {
+	ws := testWorkspace(t)
+	mem := memory.New(ws.Root)
+	if err := mem.Write(context.Background(), "owner", "What is on my calendar?"); err != nil {
+		t.Fatal(err)
+	}
+	if err := mem.Write(context.Background(), "agent", "Dentist at 3pm."); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().Add(time.Minute)
+	stubCommandNow(t, now)
+
+	llmFake := &fakeLLM{}
+	sender := &fakeSender{}
+	docs := &fakeDocumentSender{}
+	ag := New(NewAgentConfig{
+		Workspace:      ws,
+		LLM:            llmFake,
+		Sender:         sender,
+		MemorySearcher: mem,
+		DocumentSender: docs,
+	})
+
+	ag.handleMessage(context.Background(), textMessage(42, "/export"))
+
+	if len(llmFake.calls) != 0 {
+		t.Errorf("LLM calls = %d, /export should not reach the LLM", len(llmFake.calls))
+	}
+
+	name := now.Format("2006-01-02T15-04-05") + ".md"
+	data, err := os.ReadFile(filepath.Join(ws.Root, "exports", name))
+	if err != nil {
+		t.Fatalf("export file not written: %v", err)
+	}
+	content := string(data)
+	for _, want := range []string{"# Conversation export", "(2 entries)", "— owner\n\nWhat is on my calendar?", "— agent\n\nDentist at 3pm."} {
+		if !strings.Contains(content, want) {
+			t.Errorf("export missing %q:\n%s", want, content)
+		}
+	}
+	if strings.Index(content, "calendar") > strings.Index(content, "Dentist") {
+		t.Error("entries should be in chronological order")
+	}
+
+	if len(docs.docs) != 1 {
+		t.Fatalf("documents sent = %d, want 1", len(docs.docs))
+	}
+	doc := docs.docs[0]
+	if doc.chatID != 42 || doc.fileName != name || string(doc.data) != content {
+		t.Errorf("document = (%d, %q), want upload of %s to chat 42", doc.chatID, doc.fileName, name)
+	}
+	if !strings.Contains(doc.caption, "Exported 2 entries from the last 24h0m0s") {
+		t.Errorf("caption = %q", doc.caption)
+	}
+	if len(sender.sent) != 0 {
+		t.Errorf("unexpected text replies: %+v", sender.sent)
+	}
+}
+
+func TestHandleMessage_ExportWithoutDocumentSender(t *testing.T) {
+	ws := testWorkspace(t)
+	mem := memory.New(ws.Root)
+	if err := mem.Write(context.Background(), "owner", "hello"); err != nil {
+		t.Fatal(err)
+	}
+	stubCommandNow(t, time.Now().Add(time.Minute))
+
+	sender := &fakeSender{}
+	ag := New(NewAgentConfig{Workspace: ws, LLM: &fakeLLM{}, Sender: sender, MemorySearcher: mem})
+	ag.handleMessage(context.Background(), textMessage(42, "/export 1h"))
+
+	if len(sender.sent) != 1 || !strings.Contains(sender.sent[0].text, "Exported 1 entries from the last 1h0m0s to exports/") {
+		t.Errorf("replies = %+v, want saved-path confirmation", sender.sent)
+	}
+}
+
+func TestHandleMessage_ExportUploadFailure(t *testing.T) {
+	ws := testWorkspace(t)
+	mem := memory.New(ws.Root)
+	if err := mem.Write(context.Background(), "owner", "hello"); err != nil {
+		t.Fatal(err)
+	}
+	stubCommandNow(t, time.Now().Add(time.Minute))
+
+	sender := &fakeSender{}
+	ag := New(NewAgentConfig{Workspace: ws, LLM: &fakeLLM{}, Sender: sender, MemorySearcher: mem,
+		DocumentSender: &fakeDocumentSender{err: errors.New("network down")}})
+	ag.handleMessage(context.Background(), textMessage(42, "/export"))
+
+	if len(sender.sent) != 1 || !strings.Contains(sender.sent[0].text, "upload failed: network down") {
+		t.Errorf("replies = %+v, want upload failure notice", sender.sent)
+	}
+}
+
+func TestHandleMessage_ExportEdgeCases(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		searcher MemorySearcher
+		want     string
+	}{
+		{"empty range", "/export", memory.New(t.TempDir()), "Nothing to export from the last 24h0m0s."},
+		{"bad duration", "/export soon", memory.New(t.TempDir()), "Usage: /export [duration]"},
+		{"negative duration", "/export -1h", memory.New(t.TempDir()), "Usage: /export [duration]"},
+		{"no memory", "/export", nil, "Export is not available in this deployment."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sender := &fakeSender{}
+			ag := New(NewAgentConfig{Workspace: testWorkspace(t), LLM: &fakeLLM{}, Sender: sender, MemorySearcher: tt.searcher})
+			ag.handleMessage(context.Background(), textMessage(42, tt.text))
+			if len(sender.sent) != 1 || !strings.Contains(sender.sent[0].text, tt.want) {
+				t.Errorf("replies = %+v, want %q", sender.sent, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleMessage_UnknownCommandGoesToLLM(t *testing.T) {
+	llmFake := &fakeLLM{responses: []*llm.ChatResponse{makeResponse("message", "ok")}}
+	ag := New(NewAgentConfig{Workspace: testWorkspace(t), LLM: llmFake, Sender: &fakeSender{}})
+
+	ag.handleMessage(context.Background(), textMessage(42, "/start"))
+
+	if len(llmFake.calls) != 1 {
+		t.Errorf("LLM calls = %d, unknown commands should reach the LLM", len(llmFake.calls))
+	}
+}
+
+// switchableLLM is a fakeLLM recording which model each completion used.
+type switchableLLM struct {
+	fakeLLM
+	model  string
+	models []string
+}
+
+func (s *switchableLLM) Model() string         { return s.model }
+func (s *switchableLLM) SetModel(model string) { s.model = model }
+
+func (s *switchableLLM) ChatCompletionWithRetry(ctx context.Context, messages []llm.Message, tools []llm.Tool) (*llm.ChatResponse, error) {
+	s.models = append(s.models, s.model)
+	return s.fakeLLM.ChatCompletionWithRetry(ctx, messages, tools)
+}
+
+func TestHandleMessage_ModelSwitch(t *testing.T) {
+	llmFake := &switchableLLM{
+		fakeLLM: fakeLLM{responses: []*llm.ChatResponse{makeResponse("message", "hi")}},
+		model:   "mistral-large-latest",
+	}
+	sender := &fakeSender{}
+	var saved []string
+	ag := New(NewAgentConfig{
+		Workspace:     testWorkspace(t),
+		LLM:           llmFake,
+		Sender:        sender,
+		AllowedModels: []string{"mistral-large-latest", "mistral-small-latest"},
+		SaveModel:     func(model string) error { saved = append(saved, model); return nil },
+	})
+
+	ag.handleMessage(context.Background(), textMessage(42, "/model mistral-small-latest"))
+	if len(llmFake.models) != 0 {
+		t.Fatalf("/model reached the LLM")
+	}
+	if got := sender.sent[len(sender.sent)-1].text; !strings.Contains(got, "Switched to mistral-small-latest") {
+		t.Errorf("reply = %q, want a switch confirmation", got)
+	}
+	if len(saved) != 1 || saved[0] != "mistral-small-latest" {
+		t.Errorf("saved = %v, want [mistral-small-latest]", saved)
+	}
+
+	ag.handleMessage(context.Background(), textMessage(42, "hello"))
+	if len(llmFake.models) != 1 || llmFake.models[0] != "mistral-small-latest" {
+		t.Errorf("models used = %v, want [mistral-small-latest]", llmFake.models)
+	}
+}
+
+func TestHandleMessage_ModelSwitchRejectsUnknown(t *testing.T) {
+	llmFake := &switchableLLM{model: "mistral-large-latest"}
+	sender := &fakeSender{}
+	ag := New(NewAgentConfig{
+		Workspace:     testWorkspace(t),
+		LLM:           llmFake,
+		Sender:        sender,
+		AllowedModels: []string{"mistral-large-latest", "mistral-small-latest"},
+	})
+
+	ag.handleMessage(context.Background(), textMessage(42, "/model gpt-9"))
+
+	if llmFake.model != "mistral-large-latest" {
+		t.Errorf("model = %q, want it unchanged", llmFake.model)
+	}
+	got := sender.sent[len(sender.sent)-1].text
+	if !strings.Contains(got, `Unknown model "gpt-9"`) || !strings.Contains(got, "mistral-small-latest") {
+		t.Errorf("reply = %q, want a rejection listing the allowed models", got)
+	}
+}
+
+func TestHandleMessage_ModelSwitchSaveFails(t *testing.T) {
+	llmFake := &switchableLLM{model: "a"}
+	sender := &fakeSender{}
+	ag := New(NewAgentConfig{
+		Workspace:     testWorkspace(t),
+		LLM:           llmFake,
+		Sender:        sender,
+		AllowedModels: []string{"a", "b"},
+		SaveModel:     func(string) error { return errors.New("read-only") },
+	})
+
+	ag.handleMessage(context.Background(), textMessage(42, "/model b"))
+
+	if llmFake.model != "b" {
+		t.Errorf("model = %q, want b for this session", llmFake.model)
+	}
+	if got := sender.sent[len(sender.sent)-1].text; !strings.Contains(got, "until restart") || !strings.Contains(got, "read-only") {
+		t.Errorf("reply = %q, want the switch kept with the save error", got)
+	}
+}
+
+func TestHandleMessage_ModelSwitchDisabled(t *testing.T) {
+	llmFake := &switchableLLM{model: "a"}
+	sender := &fakeSender{}
+	ag := New(NewAgentConfig{Workspace: testWorkspace(t), LLM: llmFake, Sender: sender})
+
+	ag.handleMessage(context.Background(), textMessage(42, "/model b"))
+
+	if llmFake.model != "a" {
+		t.Errorf("model = %q, want it unchanged", llmFake.model)
+	}
+	if got := sender.sent[len(sender.sent)-1].text; !strings.Contains(got, "allowed_models") {
+		t.Errorf("reply = %q, want a hint about allowed_models", got)
+	}
+}
+
+func TestHandleMessage_SkillToggle(t *testing.T) {
+	root := t.TempDir()
+	for name, content := range map[string]string{
+		"AGENT.md":                "# Agent",
+		"SOUL.md":                 "# Soul",
+		"skills/deploy/SKILL.md":  "Deploy with make release.",
+		"skills/weather/SKILL.md": "Check the forecast.",
+	} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ws, err := workspace.Load(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	llmFake := &fakeLLM{}
+	sender := &fakeSender{}
+	ag := New(NewAgentConfig{Workspace: ws, LLM: llmFake, Sender: sender, OwnerIDs: []int64{42}})
+
+	ag.handleMessage(context.Background(), textMessage(42, "/skill deploy off"))
+	if got := sender.sent[len(sender.sent)-1].text; got != `Skill "deploy" disabled.` {
+		t.Errorf("reply = %q, want a disable confirmation", got)
+	}
+	if prompt := ag.systemPrompt(); strings.Contains(prompt, "make release") || !strings.Contains(prompt, "forecast") {
+		t.Errorf("system prompt = %q, want deploy left out and weather kept", prompt)
+	}
+
+	ag.handleMessage(context.Background(), textMessage(42, "/skill deploy on"))
+	if got := sender.sent[len(sender.sent)-1].text; got != `Skill "deploy" enabled.` {
+		t.Errorf("reply = %q, want an enable confirmation", got)
+	}
+	if prompt := ag.systemPrompt(); !strings.Contains(prompt, "make release") {
+		t.Errorf("system prompt = %q, want deploy back", prompt)
+	}
+	if len(llmFake.calls) != 0 {
+		t.Errorf("LLM calls = %d, want 0 for commands", len(llmFake.calls))
+	}
+}
+
+func TestHandleMessage_SkillToggleRejected(t *testing.T) {
+	tests := []struct {
+		name   string
+		chatID int64
+		text   string
+		want   string
+	}{
+		{"NotOwner", 7, "/skill deploy off", "Only an owner"},
+		{"MissingState", 42, "/skill deploy", "Usage: /skill"},
+		{"BadState", 42, "/skill deploy maybe", "Usage: /skill"},
+		{"UnknownSkill", 42, "/skill nope off", `Unknown skill "nope"`},
+		{"InvalidName", 42, "/skill ../x off", "invalid skill name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sender := &fakeSender{}
+			ag := New(NewAgentConfig{Workspace: testWorkspace(t), LLM: &fakeLLM{}, Sender: sender, OwnerIDs: []int64{42}})
+
+			ag.handleMessage(context.Background(), textMessage(tt.chatID, tt.text))
+
+			if len(sender.sent) == 0 {
+				t.Fatal("no reply sent")
+			}
+			if got := sender.sent[len(sender.sent)-1].text; !strings.Contains(got, tt.want) {
+				t.Errorf("reply = %q, want it to contain %q", got, tt.want)
+			}
+		})
+	}
+}
+
+type fakeSubAgents struct{ active bool }
+
+func (f fakeSubAgents) IsActive() bool { return f.active }
+
+func TestHandleMessage_Status(t *testing.T) {
+	ws := testWorkspace(t)
+	ws.Skills = []workspace.Skill{{Name: "deploy"}}
+	mem := memory.New(ws.Root)
+	for _, text := range []string{"hello", "hi there"} {
+		if err := mem.Write(context.Background(), "owner", text); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now := time.Now()
+	stubCommandNow(t, now)
+
+	llmFake := &switchableLLM{model: "mistral-large-latest"}
+	sender := &fakeSender{}
+	ag := New(NewAgentConfig{
+		Workspace:      ws,
+		LLM:            llmFake,
+		Sender:         sender,
+		MemorySearcher: mem,
+		SubAgents:      fakeSubAgents{active: true},
+		Heartbeat:      &fakeHeartbeatExecutor{},
+		HeartbeatTick:  make(chan time.Time),
+	})
+	stubCommandNow(t, now.Add(90*time.Minute+400*time.Millisecond))
+
+	ag.handleMessage(context.Background(), textMessage(42, "/status"))
+
+	if len(llmFake.models) != 0 {
+		t.Errorf("LLM calls = %d, /status should not reach the LLM", len(llmFake.models))
+	}
+	got := sender.sent[len(sender.sent)-1].text
+	for _, want := range []string{
+		"Uptime: 1h30m0s",
+		"Model: mistral-large-latest",
+		"Memory entries today: 2",
+		"Sub-agent: running",
+		"Heartbeat: on",
+		"Skills: 1 loaded",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("status = %q, want it to contain %q", got, want)
+		}
+	}
+}
+
+func TestHandleMessage_StatusMinimal(t *testing.T) {
+	sender := &fakeSender{}
+	ag := New(NewAgentConfig{Workspace: testWorkspace(t), LLM: &fakeLLM{}, Sender: sender})
+
+	ag.handleMessage(context.Background(), textMessage(42, "/status"))
+
+	got := sender.sent[len(sender.sent)-1].text
+	if !strings.Contains(got, "Uptime:") || !strings.Contains(got, "Heartbeat: off") {
+		t.Errorf("status = %q, want uptime and the heartbeat off", got)
+	}
+	for _, absent := range []string{"Model:", "Memory entries", "Sub-agent:"} {
+		if strings.Contains(got, absent) {
+			t.Errorf("status = %q, want no %q line without its dependency", got, absent)
+		}
+	}
+}
+
+func TestHandleMessage_Help(t *testing.T) {
+	llmFake := &fakeLLM{}
+	sender := &fakeSender{}
+	ag := New(NewAgentConfig{Workspace: testWorkspace(t), LLM: llmFake, Sender: sender})
+
+	ag.handleMessage(context.Background(), textMessage(42, "/help"))
+
+	if len(llmFake.calls) != 0 {
+		t.Errorf("LLM calls = %d, /help should not reach the LLM", len(llmFake.calls))
+	}
+	got := sender.sent[len(sender.sent)-1].text
+	for _, c := range commandHelp {
+		name, _, _ := strings.Cut(c.usage, " ")
+		if !strings.Contains(got, c.usage) {
+			t.Errorf("help = %q, want it to list %s", got, c.usage)
+		}
+		// Every listed command must be handled, not passed to the LLM.
+		if name != "/help" && !ag.handleCommand(context.Background(), 42, name) {
+			t.Errorf("%s is listed by /help but not handled", name)
+		}
+	}
+}
+
+func TestHandleMessage_ExportDocumentCountsAsReply(t *testing.T) {
+	ws := testWorkspace(t)
+	mem := memory.New(ws.Root)
+	if err := mem.Write(context.Background(), "owner", "Remember the milk"); err != nil {
+		t.Fatal(err)
+	}
+	stubCommandNow(t, time.Now().Add(time.Minute))
+	sender := &fakeSender{}
+	docs := &fakeDocumentSender{}
+	ag := New(NewAgentConfig{
+		Workspace:      ws,
+		LLM:            &fakeLLM{},
+		Sender:         sender,
+		MemorySearcher: mem,
+		DocumentSender: docs,
+		FallbackReply:  testFallback,
+	})
+
+	ag.handleMessage(context.Background(), textMessage(42, "/export"))
+
+	if len(docs.docs) != 1 {
+		t.Fatalf("documents sent = %d, want 1", len(docs.docs))
+	}
+	if len(sender.sent) != 0 {
+		t.Errorf("sent = %+v, want no fallback after the uploaded export", sender.sent)
+	}
+}
@@ -0,0 +1,89 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/edouard/pureclaw/internal/llm"
+)
+
+// compactSummaryPrefix marks the history turn that replaces a compacted conversation.
+const compactSummaryPrefix = "[Summary of the earlier conversation]\n"
+
+// compactRequest is the user turn the summary answers, so compacted history
+// still starts with the owner.
+const compactRequest = "Summarize our conversation so far."
+
+// compactPrompt instructs the LLM to summarize the conversation history.
+const compactPrompt = "Summarize the following conversation between the owner and the assistant so it can replace the full transcript. " +
+	"Keep facts, decisions, open questions and pending tasks; drop greetings and small talk. " +
+	"Reply with the summary as plain text only."
+
+// CompactHistory summarizes the conversation history through the LLM and replaces
+// it with a single summary exchange, freeing context budget for later messages. focus
+// optionally tells the summarizer what to preserve. It returns the number of
+// history turns replaced.
+func (a *Agent) CompactHistory(ctx context.Context, focus string) (int, error) {
+	turns := len(a.history)
+	if turns < 2 {
+		return 0, fmt.Errorf("agent: compact_history: nothing to compact (%d turn(s))", turns)
+	}
+
+	prompt := compactPrompt
+	if focus = strings.TrimSpace(focus); focus != "" {
+		prompt += "\nPay particular attention to: " + focus
+	}
+	msgs := []llm.Message{
+		{Role: "system", Content: prompt},
+		{Role: "user", Content: formatHistory(a.history)},
+	}
+	resp, err := a.llm.ChatCompletionWithRetry(ctx, msgs, nil)
+	if err != nil {
+		return 0, fmt.Errorf("agent: compact_history: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return 0, fmt.Errorf("agent: compact_history: LLM returned no choices")
+	}
+	summary := summaryText(resp.Choices[0].Message.Content)
+	if summary == "" {
+		return 0, fmt.Errorf("agent: compact_history: LLM returned an empty summary")
+	}
+
+	a.history = []llm.Message{
+		{Role: "user", Content: compactRequest},
+		{Role: "assistant", Content: compactSummaryPrefix + summary},
+	}
+	slog.Info("history compacted",
+		"component", "agent",
+		"operation", "compact_history",
+		"turns", turns,
+		"summary_length", len(summary),
+	)
+	return turns, nil
+}
+
+// formatHistory renders history as "role: content" lines for the summarizer.
+func formatHistory(history []llm.Message) string {
+	var b strings.Builder
+	for _, m := range history {
+		b.WriteString(m.Role)
+		b.WriteString(": ")
+		b.WriteString(m.Content)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// summaryText extracts the summary from an LLM reply. Models primed with the
+// agent's JSON contract sometimes wrap it in a response object anyway.
+func summaryText(content string) string {
+	content = strings.TrimSpace(content)
+	if strings.HasPrefix(content, "{") {
+		if resp, err := llm.ParseAgentResponse(content); err == nil {
+			return strings.TrimSpace(resp.Content)
+		}
+	}
+	return content
+}
@@ -0,0 +1,98 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/edouard/pureclaw/internal/llm"
+	"github.com/edouard/pureclaw/internal/tool"
+)
+
+func plainResponse(content string) *llm.ChatResponse {
+	return &llm.ChatResponse{Choices: []llm.Choice{{Message: llm.Message{Role: "assistant", Content: content}, FinishReason: "stop"}}}
+}
+
+func TestCompactHistoryTool_ReplacesHistoryWithSummary(t *testing.T) {
+	ws := testWorkspace(t)
+	llmFake := &fakeLLM{responses: []*llm.ChatResponse{
+		makeToolCallResponse(tc("1", "compact_history", `{}`)),
+		plainResponse("Owner is planning a trip to Lyon."),
+		makeResponse("message", "compacted"),
+		makeResponse("message", "next"),
+	}}
+	registry := tool.NewRegistry()
+	ag := New(NewAgentConfig{Workspace: ws, LLM: llmFake, Sender: &fakeSender{}, ToolExecutor: registry})
+	registry.Register(tool.NewCompactHistory(ag))
+
+	ag.addToHistory("hello", "hi")
+	ag.addToHistory("plan a trip to Lyon", "sure")
+
+	ag.handleMessage(context.Background(), testMsg(42, "please compact"))
+
+	// The summary exchange replaces the four earlier turns; the compacting exchange follows it.
+	if len(ag.history) != 4 {
+		t.Fatalf("history has %d turns, want summary + 1 exchange: %+v", len(ag.history), ag.history)
+	}
+	if ag.history[0].Role != "user" {
+		t.Errorf("history starts with a %s turn, want user", ag.history[0].Role)
+	}
+	if !strings.HasPrefix(ag.history[1].Content, compactSummaryPrefix) || !strings.Contains(ag.history[1].Content, "Lyon") {
+		t.Errorf("summary turn = %q", ag.history[1].Content)
+	}
+
+	summarizeCall := llmFake.calls[1]
+	if !strings.Contains(summarizeCall[1].Content, "plan a trip to Lyon") {
+		t.Errorf("summarizer did not receive the transcript: %+v", summarizeCall)
+	}
+
+	ag.history = ag.history[:2] // keep only the summary to check what the next call sees
+	ag.handleMessage(context.Background(), testMsg(42, "what next?"))
+	last := llmFake.calls[len(llmFake.calls)-1]
+	if len(last) != 4 {
+		t.Fatalf("next LLM call has %d messages, want system + summary exchange + user", len(last))
+	}
+	for _, m := range last {
+		if strings.Contains(m.Content, "hello") {
+			t.Errorf("compacted turn still sent to the LLM: %q", m.Content)
+		}
+	}
+	if !strings.HasPrefix(last[2].Content, compactSummaryPrefix) {
+		t.Errorf("third message = %q, want the summary", last[2].Content)
+	}
+}
+
+func TestCompactHistory_NothingToCompact(t *testing.T) {
+	ag := newTestAgent(testWorkspace(t), &fakeLLM{}, &fakeSender{})
+	if _, err := ag.CompactHistory(context.Background(), ""); err == nil {
+		t.Error("expected error for empty history")
+	}
+}
+
+func TestCompactHistory_LLMErrorKeepsHistory(t *testing.T) {
+	llmFake := &fakeLLM{responses: []*llm.ChatResponse{plainResponse("")}}
+	ag := newTestAgent(testWorkspace(t), llmFake, &fakeSender{})
+	ag.addToHistory("a", "b")
+
+	if _, err := ag.CompactHistory(context.Background(), "tasks"); err == nil {
+		t.Fatal("expected error for empty summary")
+	}
+	if len(ag.history) != 2 {
+		t.Errorf("history modified on failure: %+v", ag.history)
+	}
+	if !strings.Contains(llmFake.calls[0][0].Content, "tasks") {
+		t.Error("focus not passed to the summarizer")
+	}
+}
+
+func TestSummaryText(t *testing.T) {
+	tests := map[string]string{
+		"  plain summary ":                       "plain summary",
+		`{"type":"message","content":"wrapped"}`: "wrapped",
+	}
+	for in, want := range tests {
+		if got := summaryText(in); got != want {
+			t.Errorf("summaryText(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -1,18 +1,29 @@
 package agent
 
 import (
+	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/edouard/pureclaw/internal/llm"
+	"github.com/edouard/pureclaw/internal/platform"
 )
 
 const maxHistory = 40 // 20 user+assistant pairs
 
-// systemPrompt combines workspace content with the JSON response format contract.
+// historyNow is replaceable for testing.
+var historyNow = time.Now
+
+// systemPrompt combines workspace content, in the configured section order, with
+// the JSON response format contract.
 func (a *Agent) systemPrompt() string {
 	var b strings.Builder
-	b.WriteString(a.workspace.SystemPrompt())
+	if len(a.promptSections) > 0 {
+		b.WriteString(a.workspace.SystemPromptSections(a.promptSections))
+	} else {
+		b.WriteString(a.workspace.SystemPrompt())
+	}
 	b.WriteString("\n\n")
 	b.WriteString("## Workspace Files\n\n")
 	b.WriteString(fmt.Sprintf("Root: %s\n", a.workspace.Root))
@@ -32,6 +43,11 @@ func (a *Agent) systemPrompt() string {
 	b.WriteString(`{"type": "message", "content": "text for user"}` + "\n")
 	b.WriteString(`{"type": "think", "content": "internal reasoning"}` + "\n")
 	b.WriteString(`{"type": "noop", "content": "nothing to do"}` + "\n\n")
+	if a.attachments {
+		b.WriteString("A \"message\" may also carry an optional \"attachments\" field: a list of workspace-relative ")
+		b.WriteString("paths of files (e.g. a report you wrote with write_file) to send to the user as documents.\n")
+		b.WriteString(`{"type": "message", "content": "Here is the report.", "attachments": ["reports/disk.md"]}` + "\n\n")
+	}
 	b.WriteString("## Message Formatting\n\n")
 	b.WriteString("Messages are sent via Telegram with parse_mode HTML. Use ONLY Telegram HTML tags:\n")
 	b.WriteString("<b>bold</b>, <i>italic</i>, <u>underline</u>, <s>strikethrough</s>, ")
@@ -42,21 +58,61 @@ func (a *Agent) systemPrompt() string {
 }
 
 // buildMessages assembles the full message list for the LLM: system prompt + history + current user message.
-func (a *Agent) buildMessages(userText string) []llm.Message {
+// A prompt suffix configured for chatID is appended to the system prompt.
+func (a *Agent) buildMessages(chatID int64, userText string) []llm.Message {
+	system := a.systemPrompt()
+	if suffix := a.promptSuffixes[chatID]; suffix != "" {
+		system += "\n" + suffix + "\n"
+	}
 	msgs := make([]llm.Message, 0, 1+len(a.history)+1)
-	msgs = append(msgs, llm.Message{Role: "system", Content: a.systemPrompt()})
+	msgs = append(msgs, llm.Message{Role: "system", Content: system})
 	msgs = append(msgs, a.history...)
 	msgs = append(msgs, llm.Message{Role: "user", Content: userText})
 	return msgs
 }
 
-// addToHistory appends a user+assistant exchange and trims to maxHistory.
+// addToHistory appends a user+assistant exchange and trims to maxHistory,
+// dropping whole turns.
 func (a *Agent) addToHistory(userText, assistantContent string) {
 	a.history = append(a.history,
 		llm.Message{Role: "user", Content: userText},
 		llm.Message{Role: "assistant", Content: assistantContent},
 	)
-	if len(a.history) > maxHistory {
-		a.history = a.history[len(a.history)-maxHistory:]
+	a.historyAt = historyNow()
+	a.history = trimHistory(a.history, maxHistory)
+}
+
+// trimHistory drops the oldest messages until history holds at most limit. It
+// only cuts before a user message, so the kept history never opens with an
+// assistant reply or a tool result.
+func trimHistory(history []llm.Message, limit int) []llm.Message {
+	start := 0
+	for start < len(history) && (len(history)-start > limit || history[start].Role != "user") {
+		start++
 	}
+	return history[start:]
+}
+
+// expireHistory clears the conversation history when its newest turn is older
+// than historyMaxAge, so a conversation resumed after a long pause starts fresh
+// instead of carrying stale context. The reset is noted in memory.
+func (a *Agent) expireHistory(ctx context.Context) {
+	if a.historyMaxAge <= 0 || len(a.history) == 0 {
+		return
+	}
+	age := historyNow().Sub(a.historyAt)
+	if age <= a.historyMaxAge {
+		return
+	}
+	turns := len(a.history)
+	a.history = nil
+	platform.Log(ctx).Info("conversation history reset",
+		"component", "agent",
+		"operation", "history_reset",
+		"turns", turns,
+		"age", age,
+		"max_age", a.historyMaxAge,
+	)
+	a.logMemory(ctx, "history", fmt.Sprintf("Conversation history reset: the last turn was %s old (limit %s), %d turn(s) dropped.",
+		age.Round(time.Minute), a.historyMaxAge, turns))
 }
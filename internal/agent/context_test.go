@@ -1,9 +1,12 @@
 package agent
 
 import (
+	"context"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/edouard/pureclaw/internal/llm"
 	"github.com/edouard/pureclaw/internal/workspace"
 )
 
@@ -51,7 +54,7 @@ func TestBuildMessages_Empty(t *testing.T) {
 	}
 	ag := New(NewAgentConfig{Workspace: ws})
 
-	msgs := ag.buildMessages("hello")
+	msgs := ag.buildMessages(42, "hello")
 
 	if len(msgs) != 2 {
 		t.Fatalf("expected 2 messages (system + user), got %d", len(msgs))
@@ -78,7 +81,7 @@ func TestBuildMessages_WithHistory(t *testing.T) {
 	ag.addToHistory("q1", "a1")
 	ag.addToHistory("q2", "a2")
 
-	msgs := ag.buildMessages("q3")
+	msgs := ag.buildMessages(42, "q3")
 
 	// system + 4 history + user = 6
 	if len(msgs) != 6 {
@@ -204,6 +207,33 @@ func TestAddToHistory_Trim(t *testing.T) {
 	}
 }
 
+func TestTrimHistory_WholeTurns(t *testing.T) {
+	turn := func(role string) llm.Message { return llm.Message{Role: role} }
+	tests := []struct {
+		name    string
+		history []llm.Message
+		limit   int
+		want    int
+	}{
+		{"fits", []llm.Message{turn("user"), turn("assistant")}, 4, 2},
+		{"cut mid-turn", []llm.Message{turn("user"), turn("assistant"), turn("user"), turn("assistant"), turn("user"), turn("assistant")}, 3, 2},
+		{"leading assistant", []llm.Message{turn("assistant"), turn("user"), turn("assistant")}, 4, 2},
+		{"leading tool", []llm.Message{turn("user"), turn("tool"), turn("assistant"), turn("user"), turn("assistant")}, 4, 2},
+		{"no user turn", []llm.Message{turn("assistant")}, 4, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := trimHistory(tt.history, tt.limit)
+			if len(got) != tt.want {
+				t.Fatalf("len = %d, want %d: %+v", len(got), tt.want, got)
+			}
+			if len(got) > 0 && got[0].Role != "user" {
+				t.Errorf("history starts with a %s turn, want user", got[0].Role)
+			}
+		})
+	}
+}
+
 func TestAddToHistory_ExactMax(t *testing.T) {
 	ws := &workspace.Workspace{Root: t.TempDir(), SoulMD: "S", AgentMD: "A"}
 	ag := New(NewAgentConfig{Workspace: ws})
@@ -217,3 +247,112 @@ func TestAddToHistory_ExactMax(t *testing.T) {
 		t.Fatalf("expected history length %d, got %d", maxHistory, len(ag.history))
 	}
 }
+
+func TestBuildMessages_PromptSuffixPerChat(t *testing.T) {
+	ws := &workspace.Workspace{
+		Root:    t.TempDir(),
+		SoulMD:  "Soul",
+		AgentMD: "Agent",
+	}
+	ag := New(NewAgentConfig{
+		Workspace:      ws,
+		PromptSuffixes: map[int64]string{42: "Variant B: answer in one sentence."},
+	})
+
+	mapped := ag.buildMessages(42, "hello")
+	if !strings.HasSuffix(strings.TrimSpace(mapped[0].Content), "Variant B: answer in one sentence.") {
+		t.Errorf("chat 42 system prompt should end with its suffix, got %q", mapped[0].Content)
+	}
+	unmapped := ag.buildMessages(7, "hello")
+	if strings.Contains(unmapped[0].Content, "Variant B") {
+		t.Error("unmapped chat should not get the suffix")
+	}
+	if unmapped[0].Content != ag.systemPrompt() {
+		t.Error("unmapped chat should get the plain system prompt")
+	}
+}
+
+func TestBuildMessages_PromptSections(t *testing.T) {
+	ws := &workspace.Workspace{
+		Root:    t.TempDir(),
+		SoulMD:  "SOUL_CONTENT",
+		AgentMD: "AGENT_CONTENT\n\n## Environment\n\n- **OS:** linux",
+		Skills:  []workspace.Skill{{Name: "coding", Content: "SKILL_CONTENT"}},
+	}
+	ag := New(NewAgentConfig{
+		Workspace:      ws,
+		PromptSections: []string{"skills", "environment", "agent", "soul"},
+	})
+
+	system := ag.buildMessages(42, "hello")[0].Content
+	pos := -1
+	for _, s := range []string{"SKILL_CONTENT", "## Environment", "AGENT_CONTENT", "SOUL_CONTENT", "## Response Format"} {
+		i := strings.Index(system, s)
+		if i <= pos {
+			t.Fatalf("%q missing or out of order in system prompt:\n%s", s, system)
+		}
+		pos = i
+	}
+
+	// Without a configured order, the workspace default applies.
+	def := New(NewAgentConfig{Workspace: ws}).buildMessages(42, "hello")[0].Content
+	if !strings.HasPrefix(def, ws.SystemPrompt()) {
+		t.Errorf("default system prompt should start with the workspace's, got %q", def)
+	}
+}
+
+func stubHistoryNow(t *testing.T, now *time.Time) {
+	t.Helper()
+	orig := historyNow
+	t.Cleanup(func() { historyNow = orig })
+	historyNow = func() time.Time { return *now }
+}
+
+func TestHandleMessage_HistoryMaxAge(t *testing.T) {
+	tests := []struct {
+		name      string
+		idle      time.Duration
+		wantReset bool
+	}{
+		{"fresh history retained", 2 * time.Hour, false},
+		{"aged history reset", 13 * time.Hour, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Date(2026, 3, 15, 8, 0, 0, 0, time.UTC)
+			stubHistoryNow(t, &now)
+			llmFake := &fakeLLM{responses: []*llm.ChatResponse{makeResponse("message", "ok")}}
+			mem := &fakeMemoryWriter{}
+			ag := New(NewAgentConfig{
+				Workspace:     testWorkspace(t),
+				LLM:           llmFake,
+				Sender:        &fakeSender{},
+				Memory:        mem,
+				HistoryMaxAge: 12 * time.Hour,
+			})
+
+			ag.handleMessage(context.Background(), textMessage(42, "first"))
+			now = now.Add(tt.idle)
+			ag.handleMessage(context.Background(), textMessage(42, "second"))
+
+			// The first exchange adds two history turns, unless it was dropped.
+			wantHistory := 2
+			if tt.wantReset {
+				wantHistory = 0
+			}
+			if got := len(llmFake.calls[1]); got != wantHistory+2 {
+				t.Errorf("second call sent %d messages, want system + %d history turns + user", got, wantHistory)
+			}
+			var noted bool
+			for _, e := range mem.entries {
+				noted = noted || (e.source == "history" && strings.Contains(e.content, "reset"))
+			}
+			if noted != tt.wantReset {
+				t.Errorf("history reset noted in memory = %v, want %v", noted, tt.wantReset)
+			}
+			if got := len(ag.history); got != wantHistory+2 {
+				t.Errorf("history holds %d turns after the second message, want %d", got, wantHistory+2)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/edouard/pureclaw/internal/platform"
+	"github.com/edouard/pureclaw/internal/telegram"
+)
+
+// coalescable reports whether msg is a plain text message that may be merged
+// with others into one turn. Voice, commands, forwards and replies keep their
+// own turn so their handling and annotations stay intact.
+func coalescable(msg telegram.TelegramMessage) bool {
+	m := msg.Message
+	if m.Text == "" || m.Voice != nil || strings.HasPrefix(m.Text, "/") || m.ReplyToMessage != nil {
+		return false
+	}
+	_, forwarded := m.ForwardedFrom()
+	return !forwarded
+}
+
+// collectBurst waits up to the debounce window after first for further text
+// messages from the same chat, restarting the wait after each one. Each held
+// message is released to the poller so later ones can arrive. It returns
+// the messages to handle as one turn and, when a message that can't join the
+// burst arrived, that message to handle next.
+func (a *Agent) collectBurst(ctx context.Context, first telegram.TelegramMessage, messages <-chan telegram.TelegramMessage) (burst []telegram.TelegramMessage, next *telegram.TelegramMessage) {
+	burst = []telegram.TelegramMessage{first}
+	if a.debounce <= 0 || !coalescable(first) {
+		return burst, nil
+	}
+	a.releaseMessage(first)
+	timer := time.NewTimer(a.debounce)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return burst, nil
+		case <-timer.C:
+			return burst, nil
+		case msg, ok := <-messages:
+			if !ok {
+				return burst, nil
+			}
+			if msg.Message.Chat.ID != first.Message.Chat.ID || !coalescable(msg) {
+				return burst, &msg
+			}
+			burst = append(burst, msg)
+			a.releaseMessage(msg)
+			timer.Reset(a.debounce)
+		}
+	}
+}
+
+// mergeBurst combines a burst into one message carrying every text in order,
+// one per line. The last message's ID is kept so the receipt reaction lands
+// on the newest message.
+func mergeBurst(ctx context.Context, burst []telegram.TelegramMessage) telegram.TelegramMessage {
+	if len(burst) == 1 {
+		return burst[0]
+	}
+	texts := make([]string, len(burst))
+	for i, m := range burst {
+		texts[i] = m.Message.Text
+	}
+	merged := burst[len(burst)-1]
+	merged.Message.Text = strings.Join(texts, "\n")
+	platform.Log(ctx).Info("coalesced rapid messages into one turn",
+		"component", "agent",
+		"operation", "debounce",
+		"chat_id", merged.Message.Chat.ID,
+		"messages", len(burst),
+	)
+	return merged
+}
@@ -0,0 +1,184 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/edouard/pureclaw/internal/llm"
+	"github.com/edouard/pureclaw/internal/telegram"
+)
+
+// runWithMessages runs ag until every queued message has been handled and the
+// debounce window has passed, then stops it.
+func runWithMessages(t *testing.T, ag *Agent, msgs ...telegram.TelegramMessage) {
+	t.Helper()
+	messages := make(chan telegram.TelegramMessage, len(msgs))
+	for _, m := range msgs {
+		messages <- m
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- ag.Run(ctx, messages) }()
+	time.Sleep(300 * time.Millisecond)
+	cancel()
+	<-done
+}
+
+func lastUserContent(msgs []llm.Message) string {
+	for i := len(msgs) - 1; i >= 0; i-- {
+		if msgs[i].Role == "user" {
+			return msgs[i].Content
+		}
+	}
+	return ""
+}
+
+func TestRun_DebounceCoalescesBurst(t *testing.T) {
+	ws := testWorkspace(t)
+	llmFake := &fakeLLM{responses: []*llm.ChatResponse{makeResponse("message", "got it")}}
+	sender := &fakeSender{}
+	acker := &recordingAcker{}
+	ag := New(NewAgentConfig{Workspace: ws, LLM: llmFake, Sender: sender, Acker: acker, Debounce: 100 * time.Millisecond})
+
+	var burst []telegram.TelegramMessage
+	for i, text := range []string{"hey", "can you check", "the logs?"} {
+		m := testMsg(42, text)
+		m.UpdateID = int64(10 + i)
+		burst = append(burst, m)
+	}
+	runWithMessages(t, ag, burst...)
+
+	if len(llmFake.calls) != 1 {
+		t.Fatalf("LLM called %d times, want 1 for the whole burst", len(llmFake.calls))
+	}
+	if got, want := lastUserContent(llmFake.calls[0]), "hey\ncan you check\nthe logs?"; got != want {
+		t.Errorf("user turn = %q, want %q", got, want)
+	}
+	if len(sender.sent) != 1 {
+		t.Errorf("sent %d replies, want 1", len(sender.sent))
+	}
+	acker.mu.Lock()
+	defer acker.mu.Unlock()
+	if !slices.Equal(acker.ids, []int64{10, 11, 12}) {
+		t.Errorf("acked = %v, want every message of the burst", acker.ids)
+	}
+	if !slices.Equal(acker.released, []int64{10, 11, 12}) {
+		t.Errorf("released = %v, want every held message", acker.released)
+	}
+}
+
+// updatesTransport answers getUpdates with one batch per call, then with
+// empty batches, like a Telegram Bot API long poll.
+type updatesTransport struct {
+	mu      sync.Mutex
+	batches []string
+}
+
+func (u *updatesTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+	body := `{"ok":true,"result":[]}`
+	u.mu.Lock()
+	if strings.HasSuffix(req.URL.Path, "/getUpdates") && len(u.batches) > 0 {
+		body = `{"ok":true,"result":[` + u.batches[0] + `]}`
+		u.batches = u.batches[1:]
+	} else {
+		time.Sleep(10 * time.Millisecond)
+	}
+	u.mu.Unlock()
+	return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(body)), Request: req}, nil
+}
+
+func TestRun_DebounceMergesAcrossPolls(t *testing.T) {
+	update := func(id int, text string) string {
+		return fmt.Sprintf(`{"update_id":%d,"message":{"message_id":%d,"from":{"id":42},"chat":{"id":42},"text":%q}}`, id, id, text)
+	}
+	orig := http.DefaultTransport
+	http.DefaultTransport = &updatesTransport{batches: []string{update(10, "check the logs"), update(11, "and the disk")}}
+	t.Cleanup(func() { http.DefaultTransport = orig })
+
+	poller := telegram.NewPoller(telegram.NewClient("test-token"), []int64{42}, 1)
+	poller.SetOffsetStore(telegram.NewFileOffsetStore(filepath.Join(t.TempDir(), "telegram.offset")))
+	llmFake := &fakeLLM{responses: []*llm.ChatResponse{makeResponse("message", "on it")}}
+	ag := New(NewAgentConfig{Workspace: testWorkspace(t), LLM: llmFake, Sender: &fakeSender{}, Acker: poller, Debounce: 300 * time.Millisecond})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	messages := make(chan telegram.TelegramMessage, 1)
+	polled := make(chan struct{})
+	go func() { poller.Run(ctx, messages); close(polled) }()
+	done := make(chan error, 1)
+	go func() { done <- ag.Run(ctx, messages) }()
+	time.Sleep(time.Second)
+	cancel()
+	<-done
+	<-polled
+
+	if len(llmFake.calls) != 1 {
+		t.Fatalf("LLM called %d times, want 1 for messages from two polls", len(llmFake.calls))
+	}
+	if got, want := lastUserContent(llmFake.calls[0]), "check the logs\nand the disk"; got != want {
+		t.Errorf("user turn = %q, want %q", got, want)
+	}
+}
+
+func TestRun_DebounceKeepsChatsApart(t *testing.T) {
+	ws := testWorkspace(t)
+	llmFake := &fakeLLM{responses: []*llm.ChatResponse{makeResponse("message", "ok")}}
+	sender := &fakeSender{}
+	ag := New(NewAgentConfig{Workspace: ws, LLM: llmFake, Sender: sender, Debounce: 50 * time.Millisecond})
+
+	runWithMessages(t, ag, testMsg(42, "one"), testMsg(42, "two"), testMsg(7, "other chat"), testMsg(7, "more"))
+
+	if len(llmFake.calls) != 2 {
+		t.Fatalf("LLM called %d times, want one per chat", len(llmFake.calls))
+	}
+	if got := lastUserContent(llmFake.calls[0]); got != "one\ntwo" {
+		t.Errorf("first turn = %q, want %q", got, "one\ntwo")
+	}
+	if got := lastUserContent(llmFake.calls[1]); got != "other chat\nmore" {
+		t.Errorf("second turn = %q, want %q", got, "other chat\nmore")
+	}
+}
+
+func TestRun_DebounceDisabled(t *testing.T) {
+	ws := testWorkspace(t)
+	llmFake := &fakeLLM{responses: []*llm.ChatResponse{makeResponse("message", "ok")}}
+	ag := newTestAgent(ws, llmFake, &fakeSender{})
+
+	runWithMessages(t, ag, testMsg(42, "one"), testMsg(42, "two"), testMsg(42, "three"))
+
+	if len(llmFake.calls) != 3 {
+		t.Errorf("LLM called %d times, want one per message without debouncing", len(llmFake.calls))
+	}
+}
+
+func TestCoalescable(t *testing.T) {
+	reply := testMsg(42, "yes")
+	reply.Message.ReplyToMessage = &telegram.Message{Text: "earlier"}
+	voice := testMsg(42, "")
+	voice.Message.Voice = &telegram.Voice{FileID: "v"}
+
+	for _, tt := range []struct {
+		name string
+		msg  telegram.TelegramMessage
+		want bool
+	}{
+		{"text", testMsg(42, "hello"), true},
+		{"command", testMsg(42, "/status"), false},
+		{"reply", reply, false},
+		{"voice", voice, false},
+	} {
+		if got := coalescable(tt.msg); got != tt.want {
+			t.Errorf("coalescable(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
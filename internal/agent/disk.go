@@ -0,0 +1,87 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"syscall"
+
+	"github.com/edouard/pureclaw/internal/platform"
+)
+
+// criticalSources are the memory sources still written when disk space is low:
+// conversation turns, sub-agent results and delivery failures would be lost otherwise.
+var criticalSources = map[string]bool{
+	"owner":               true,
+	"voice-transcription": true,
+	"agent":               true,
+	"sub-agent-result":    true,
+	"delivery-failure":    true,
+}
+
+// lowDiskNotice is sent to the owners when free disk first drops below the minimum.
+const lowDiskNotice = "PureClaw is low on disk space (%s free, minimum %s). Non-critical memory entries are skipped until space is freed."
+
+// Replaceable for testing.
+var diskFreeFn = diskFree
+
+// diskFree returns the bytes available to unprivileged users on the filesystem holding path.
+func diskFree(path string) (uint64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, fmt.Errorf("agent: statfs: %w", err)
+	}
+	return st.Bavail * uint64(st.Bsize), nil
+}
+
+// diskAllows reports whether a memory entry from source may be written. Below
+// minFreeDisk only critical sources are written, and the owners are alerted once
+// until free space recovers. A failed check never blocks a write.
+func (a *Agent) diskAllows(ctx context.Context, source string) bool {
+	if a.minFreeDisk == 0 || a.workspace == nil {
+		return true
+	}
+	free, err := diskFreeFn(a.workspace.Root)
+	if err != nil {
+		platform.Log(ctx).Debug("disk space check failed",
+			"component", "agent",
+			"operation", "disk_guard",
+			"error", err,
+		)
+		return true
+	}
+	if free >= a.minFreeDisk {
+		if a.diskLow.CompareAndSwap(true, false) {
+			platform.Log(ctx).Info("disk space recovered",
+				"component", "agent",
+				"operation", "disk_guard",
+				"free_bytes", free,
+			)
+		}
+		return true
+	}
+
+	if !a.diskLow.Swap(true) {
+		platform.Log(ctx).Warn("disk space low",
+			"component", "agent",
+			"operation", "disk_guard",
+			"free_bytes", free,
+			"min_free_bytes", a.minFreeDisk,
+		)
+		if a.sender != nil {
+			text := fmt.Sprintf(lowDiskNotice, formatBytesUint(free), formatBytesUint(a.minFreeDisk))
+			a.deliverToOwners(ctx, "disk_guard", "low disk alert", func(id int64) error {
+				return a.sender.Send(ctx, id, text)
+			})
+		}
+	}
+	if criticalSources[source] {
+		return true
+	}
+	platform.Log(ctx).Warn("memory entry skipped, disk space low",
+		"component", "agent",
+		"operation", "disk_guard",
+		"source", source,
+		"free_bytes", free,
+	)
+	return false
+}
@@ -0,0 +1,73 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func fakeDiskFree(t *testing.T, free *uint64, err error) {
+	t.Helper()
+	orig := diskFreeFn
+	diskFreeFn = func(string) (uint64, error) { return *free, err }
+	t.Cleanup(func() { diskFreeFn = orig })
+}
+
+func TestLogMemory_LowDiskSkipsNonCritical(t *testing.T) {
+	free := uint64(10 << 20)
+	fakeDiskFree(t, &free, nil)
+	sender := &fakeSender{}
+	mem := &fakeMemoryWriter{}
+	ag := New(NewAgentConfig{
+		Workspace:   testWorkspace(t),
+		LLM:         &fakeLLM{},
+		Sender:      sender,
+		Memory:      mem,
+		OwnerIDs:    []int64{100, 200},
+		MinFreeDisk: 100 << 20,
+	})
+	ctx := context.Background()
+
+	ag.logMemory(ctx, "introspection", "env")
+	ag.logMemory(ctx, "sub-agent", "Mission completed")
+	ag.logMemory(ctx, "owner", "hello")
+	ag.logMemory(ctx, "sub-agent-result", "result")
+
+	if len(mem.entries) != 2 || mem.entries[0].source != "owner" || mem.entries[1].source != "sub-agent-result" {
+		t.Errorf("entries = %+v, want only the critical owner and sub-agent-result entries", mem.entries)
+	}
+	if len(sender.sent) != 2 {
+		t.Fatalf("alerts = %d, want one per owner, sent once", len(sender.sent))
+	}
+	if !strings.Contains(sender.sent[0].text, "low on disk space (10.0 MB free, minimum 100.0 MB)") {
+		t.Errorf("alert = %q", sender.sent[0].text)
+	}
+
+	// Once space recovers, everything is written and a new drop alerts again.
+	free = 200 << 20
+	ag.logMemory(ctx, "introspection", "env")
+	if len(mem.entries) != 3 {
+		t.Errorf("entries = %d, want the non-critical entry written after recovery", len(mem.entries))
+	}
+	free = 1 << 20
+	ag.logMemory(ctx, "introspection", "env")
+	if len(sender.sent) != 4 {
+		t.Errorf("alerts = %d, want a second alert after a new drop", len(sender.sent))
+	}
+}
+
+func TestLogMemory_DiskGuardDisabledOrUnknown(t *testing.T) {
+	free := uint64(0)
+	fakeDiskFree(t, &free, errors.New("statfs failed"))
+	for _, limit := range []uint64{0, 100 << 20} {
+		sender := &fakeSender{}
+		mem := &fakeMemoryWriter{}
+		ag := New(NewAgentConfig{Workspace: testWorkspace(t), LLM: &fakeLLM{}, Sender: sender, Memory: mem, OwnerIDs: []int64{100}, MinFreeDisk: limit})
+
+		ag.logMemory(context.Background(), "introspection", "env")
+		if len(mem.entries) != 1 || len(sender.sent) != 0 {
+			t.Errorf("limit %d: entries = %d, alerts = %d, want the write and no alert", limit, len(mem.entries), len(sender.sent))
+		}
+	}
+}
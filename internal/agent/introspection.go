@@ -8,15 +8,21 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
+	"text/template"
 	"time"
 
+	"github.com/edouard/pureclaw/internal/llm"
 	"github.com/edouard/pureclaw/internal/platform"
 )
 
-const envSectionHeader = "## Environment"
+const (
+	envSectionHeader          = "## Environment"
+	capabilitiesSectionHeader = "## Capabilities"
+)
 
 // SystemInfo holds discovered system information.
 type SystemInfo struct {
@@ -90,7 +96,7 @@ func (a *Agent) runIntrospectionIfNeeded(ctx context.Context) error {
 		return fmt.Errorf("agent: introspection: %w", err)
 	}
 
-	a.logMemory(ctx, "introspection", envSection)
+	a.logMemory(ctx, "introspection", a.introspectionEntry(info, envSection))
 
 	slog.Info("introspection complete",
 		"component", "agent",
@@ -107,6 +113,29 @@ func (a *Agent) runIntrospectionIfNeeded(ctx context.Context) error {
 	return nil
 }
 
+// introspectionEntry returns the memory entry for an introspection run: the
+// configured summary template rendered over info, or the full environment
+// section when no template is set or it fails to render.
+func (a *Agent) introspectionEntry(info SystemInfo, envSection string) string {
+	if a.envSummary == "" {
+		return envSection
+	}
+	var b strings.Builder
+	tmpl, err := template.New("introspection_summary").Option("missingkey=error").Parse(a.envSummary)
+	if err == nil {
+		err = tmpl.Execute(&b, info)
+	}
+	if err != nil {
+		slog.Warn("introspection summary template failed, logging the full section",
+			"component", "agent",
+			"operation", "introspection",
+			"error", err,
+		)
+		return envSection
+	}
+	return b.String()
+}
+
 // gatherSystemInfo orchestrates all discovery functions. Never returns error; uses "unknown" fallback.
 func gatherSystemInfo(ctx context.Context) SystemInfo {
 	diskTotal, diskAvailable := discoverDisk(ctx)
@@ -300,3 +329,88 @@ func (a *Agent) updateAgentMD(envSection string) error {
 	)
 	return nil
 }
+
+// refreshCapabilities rewrites the capabilities section of AGENT.md from the currently
+// registered tools, replacing any previous copy. AGENT.md is only written when the
+// section changes, so refreshing after a hot-reload doesn't retrigger the file watcher.
+func (a *Agent) refreshCapabilities() error {
+	if a.workspace == nil || a.workspace.Root == "" {
+		return nil
+	}
+	tools := a.toolDefinitions()
+	if len(tools) == 0 {
+		return nil
+	}
+
+	newContent := replaceSection(a.workspace.AgentMD, capabilitiesSectionHeader, formatCapabilitiesSection(tools))
+	if newContent == a.workspace.AgentMD {
+		return nil
+	}
+	path := filepath.Join(a.workspace.Root, "AGENT.md")
+	if err := platform.AtomicWrite(path, []byte(newContent), 0o644); err != nil {
+		return fmt.Errorf("agent: capabilities: write AGENT.md: %w", err)
+	}
+	a.workspace.AgentMD = newContent
+	slog.Info("AGENT.md updated with capabilities section",
+		"component", "agent",
+		"operation", "capabilities",
+		"path", path,
+		"tools", len(tools),
+	)
+	return nil
+}
+
+// formatCapabilitiesSection renders the registered tools as a Markdown section, sorted by name.
+func formatCapabilitiesSection(tools []llm.Tool) string {
+	sorted := slices.Clone(tools)
+	slices.SortFunc(sorted, func(x, y llm.Tool) int {
+		return strings.Compare(x.Function.Name, y.Function.Name)
+	})
+
+	var b strings.Builder
+	b.WriteString(capabilitiesSectionHeader)
+	b.WriteString("\n\nTools you can call:\n")
+	for _, t := range sorted {
+		desc := strings.Join(strings.Fields(t.Function.Description), " ")
+		fmt.Fprintf(&b, "\n- **%s:** %s", t.Function.Name, desc)
+	}
+	return b.String()
+}
+
+// replaceSection replaces the "## " section starting with header (up to the next
+// "## " header) with section, or appends section if content has no such header.
+func replaceSection(content, header, section string) string {
+	lines := strings.Split(content, "\n")
+	start := -1
+	for i, line := range lines {
+		if strings.TrimSpace(line) == header {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return strings.TrimRight(content, "\n") + "\n\n" + section
+	}
+
+	end := len(lines)
+	for i := start + 1; i < len(lines); i++ {
+		if strings.HasPrefix(lines[i], "## ") {
+			end = i
+			break
+		}
+	}
+
+	before := strings.TrimRight(strings.Join(lines[:start], "\n"), "\n")
+	after := strings.Join(lines[end:], "\n")
+	var b strings.Builder
+	if before != "" {
+		b.WriteString(before)
+		b.WriteString("\n\n")
+	}
+	b.WriteString(section)
+	if after != "" {
+		b.WriteString("\n\n")
+		b.WriteString(after)
+	}
+	return b.String()
+}
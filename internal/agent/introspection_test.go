@@ -9,6 +9,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/edouard/pureclaw/internal/llm"
 	"github.com/edouard/pureclaw/internal/workspace"
 )
 
@@ -664,3 +665,171 @@ func TestUpdateAgentMD_InMemoryUpdated(t *testing.T) {
 		t.Errorf("in-memory AgentMD = %q, want %q", ag.workspace.AgentMD, want)
 	}
 }
+
+func testToolDefs(names ...string) []llm.Tool {
+	tools := make([]llm.Tool, len(names))
+	for i, n := range names {
+		tools[i] = llm.Tool{Type: "function", Function: llm.ToolFunction{Name: n, Description: "Does " + n + ".\nSecond line."}}
+	}
+	return tools
+}
+
+func TestFormatCapabilitiesSection(t *testing.T) {
+	got := formatCapabilitiesSection(testToolDefs("write_file", "read_file"))
+	want := "## Capabilities\n\nTools you can call:\n\n- **read_file:** Does read_file. Second line.\n- **write_file:** Does write_file. Second line."
+	if got != want {
+		t.Errorf("section =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestReplaceSection(t *testing.T) {
+	section := "## Capabilities\n\nnew"
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"Append", "# Agent\n\n## Environment\n\n- env", "# Agent\n\n## Environment\n\n- env\n\n## Capabilities\n\nnew"},
+		{"ReplaceLast", "# Agent\n\n## Capabilities\n\nold\n", "# Agent\n\n## Capabilities\n\nnew"},
+		{"ReplaceMiddle", "# Agent\n\n## Capabilities\n\nold\n\n## Environment\n\n- env", "# Agent\n\n## Capabilities\n\nnew\n\n## Environment\n\n- env"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := replaceSection(tt.content, capabilitiesSectionHeader, section); got != tt.want {
+				t.Errorf("replaceSection() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRefreshCapabilities_ListsToolsWithoutDuplicating(t *testing.T) {
+	ws := testWorkspace(t)
+	te := &fakeToolExecutor{definitions: testToolDefs("read_file", "exec_command")}
+	ag := newTestAgentWithTools(ws, &fakeLLM{}, &fakeSender{}, te)
+
+	for range 2 {
+		if err := ag.refreshCapabilities(); err != nil {
+			t.Fatalf("refreshCapabilities: %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(ws.Root, "AGENT.md"))
+	if err != nil {
+		t.Fatalf("read AGENT.md: %v", err)
+	}
+	content := string(data)
+	if content != ws.AgentMD {
+		t.Error("in-memory AGENT.md differs from disk")
+	}
+	if n := strings.Count(content, capabilitiesSectionHeader); n != 1 {
+		t.Errorf("capabilities header appears %d times, want 1", n)
+	}
+	for _, name := range []string{"**read_file:**", "**exec_command:**", envSectionHeader} {
+		if !strings.Contains(content, name) {
+			t.Errorf("AGENT.md missing %q:\n%s", name, content)
+		}
+	}
+}
+
+func TestRefreshCapabilities_NoTools(t *testing.T) {
+	ws := testWorkspace(t)
+	ag := newTestAgent(ws, &fakeLLM{}, &fakeSender{})
+
+	if err := ag.refreshCapabilities(); err != nil {
+		t.Fatalf("refreshCapabilities: %v", err)
+	}
+	if strings.Contains(ws.AgentMD, capabilitiesSectionHeader) {
+		t.Error("capabilities section written without registered tools")
+	}
+	if _, err := os.Stat(filepath.Join(ws.Root, "AGENT.md")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("AGENT.md should not be written, stat err = %v", err)
+	}
+}
+
+func TestHandleFileChange_RefreshesCapabilities(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "AGENT.md"), []byte("# Agent"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "SOUL.md"), []byte("# Soul"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ws, err := workspace.Load(root)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	te := &fakeToolExecutor{definitions: testToolDefs("read_file")}
+	ag := newTestAgentWithTools(ws, &fakeLLM{}, &fakeSender{}, te)
+	if err := ag.refreshCapabilities(); err != nil {
+		t.Fatalf("refreshCapabilities: %v", err)
+	}
+
+	te.definitions = testToolDefs("read_file", "save_note")
+	ag.handleFileChange(context.Background())
+
+	if !strings.Contains(ws.AgentMD, "**save_note:**") {
+		t.Errorf("reloaded AGENT.md missing new tool:\n%s", ws.AgentMD)
+	}
+	if n := strings.Count(ws.AgentMD, capabilitiesSectionHeader); n != 1 {
+		t.Errorf("capabilities header appears %d times, want 1", n)
+	}
+	data, _ := os.ReadFile(filepath.Join(root, "AGENT.md"))
+	if string(data) != ws.AgentMD {
+		t.Error("AGENT.md on disk not updated after reload")
+	}
+}
+
+func TestIntrospectionEntry(t *testing.T) {
+	info := SystemInfo{OS: "linux", Arch: "arm64", DiskAvailable: "15.0 GB"}
+	section := "## Environment\n- **OS:** linux"
+
+	full := &Agent{}
+	if got := full.introspectionEntry(info, section); got != section {
+		t.Errorf("without a template = %q, want the full section", got)
+	}
+
+	summary := &Agent{envSummary: "Detected: {{.OS}}/{{.Arch}}, {{.DiskAvailable}} free"}
+	if got, want := summary.introspectionEntry(info, section), "Detected: linux/arm64, 15.0 GB free"; got != want {
+		t.Errorf("with a template = %q, want %q", got, want)
+	}
+
+	broken := &Agent{envSummary: "{{.NoSuchField}}"}
+	if got := broken.introspectionEntry(info, section); got != section {
+		t.Errorf("with a failing template = %q, want the full section", got)
+	}
+}
+
+func TestRunIntrospectionIfNeeded_LogsSummary(t *testing.T) {
+	restore := saveIntrospectVars(t)
+	defer restore()
+
+	introspectGetOS = func() string { return "linux" }
+	introspectGetArch = func() string { return "arm64" }
+	introspectGetCPU = func() int { return 4 }
+	introspectReadFile = func(name string) ([]byte, error) { return nil, errors.New("no meminfo") }
+	introspectRunCmd = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		return []byte("Filesystem     1K-blocks     Used Available Use% Mounted on\n/dev/sda1       31457280 15728640  15728640  50% /\n"), nil
+	}
+	introspectLookPath = func(file string) (string, error) { return "", errors.New("not found") }
+	introspectNow = func() time.Time { return fixedTime }
+
+	mem := &fakeMemoryWriter{}
+	ag := &Agent{
+		workspace:  &workspace.Workspace{Root: t.TempDir(), AgentMD: "# Test Agent"},
+		memory:     mem,
+		envSummary: "Detected: {{.OS}}/{{.Arch}}, {{.DiskAvailable}} free",
+	}
+	if err := ag.runIntrospectionIfNeeded(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(mem.entries) != 1 {
+		t.Fatalf("expected 1 memory entry, got %d", len(mem.entries))
+	}
+	if got, want := mem.entries[0].content, "Detected: linux/arm64, 15.0 GB free"; got != want {
+		t.Errorf("memory content = %q, want %q", got, want)
+	}
+	if !strings.Contains(ag.workspace.AgentMD, "## Environment") {
+		t.Error("AGENT.md should still get the full environment section")
+	}
+}
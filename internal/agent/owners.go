@@ -0,0 +1,75 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/edouard/pureclaw/internal/platform"
+)
+
+// ownerDelivery is the outcome of sending one notification to every owner.
+type ownerDelivery struct {
+	sent   []int64
+	failed map[int64]error
+}
+
+// allFailed reports whether there were owners to notify and none was reached.
+func (d ownerDelivery) allFailed() bool {
+	return len(d.sent) == 0 && len(d.failed) > 0
+}
+
+// deliverToOwners calls send for every owner and logs one summary of the outcome,
+// described by what. When no owner could be reached, the failure is also written
+// to memory, whatever the memory verbosity, so the lost notification is not silent.
+func (a *Agent) deliverToOwners(ctx context.Context, operation, what string, send func(chatID int64) error) ownerDelivery {
+	d := ownerDelivery{failed: make(map[int64]error)}
+	for _, id := range a.ownerIDs {
+		if err := send(id); err != nil {
+			d.failed[id] = err
+			continue
+		}
+		d.sent = append(d.sent, id)
+	}
+	if len(a.ownerIDs) == 0 {
+		return d
+	}
+
+	errs := make([]string, 0, len(d.failed))
+	for _, id := range a.ownerIDs {
+		if err, ok := d.failed[id]; ok {
+			errs = append(errs, fmt.Sprintf("%d: %v", id, err))
+		}
+	}
+	attrs := []any{
+		"component", "agent", "operation", operation,
+		"notification", what,
+		"owners", len(a.ownerIDs), "sent", len(d.sent), "failed", len(d.failed),
+	}
+	switch {
+	case len(d.failed) == 0:
+		platform.Log(ctx).Info("owner notification delivered", attrs...)
+	case d.allFailed():
+		platform.Log(ctx).Error("owner notification reached no owner", append(attrs, "errors", errs)...)
+		a.escalateDelivery(ctx, what, errs)
+	default:
+		platform.Log(ctx).Warn("owner notification partially delivered", append(attrs, "errors", errs)...)
+	}
+	return d
+}
+
+// escalateDelivery records in memory that a notification reached no owner.
+func (a *Agent) escalateDelivery(ctx context.Context, what string, errs []string) {
+	if a.memory == nil {
+		return
+	}
+	entry := fmt.Sprintf("UNDELIVERED: %s could not be sent to any owner.\n\n%s", what, strings.Join(errs, "\n"))
+	if err := a.memory.Write(ctx, "delivery-failure", a.redactor.Redact(entry)); err != nil {
+		platform.Log(ctx).Error("failed to write memory",
+			"component", "agent",
+			"operation", "log_memory",
+			"source", "delivery-failure",
+			"error", err,
+		)
+	}
+}
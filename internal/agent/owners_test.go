@@ -0,0 +1,127 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/edouard/pureclaw/internal/subagent"
+)
+
+// ownerFailSender fails sends to the chat IDs in fail and records the others.
+type ownerFailSender struct {
+	fakeSender
+	fail map[int64]bool
+}
+
+func (f *ownerFailSender) Send(ctx context.Context, chatID int64, text string) error {
+	if f.fail[chatID] {
+		return errors.New("forbidden: bot was blocked by the user")
+	}
+	return f.fakeSender.Send(ctx, chatID, text)
+}
+
+func captureLogs(t *testing.T) *recordHandler {
+	t.Helper()
+	h := newRecordHandler()
+	orig := slog.Default()
+	slog.SetDefault(slog.New(h))
+	t.Cleanup(func() { slog.SetDefault(orig) })
+	return h
+}
+
+func findRecord(h *recordHandler, msg string) map[string]string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, r := range *h.records {
+		if r["msg"] == msg {
+			return r
+		}
+	}
+	return nil
+}
+
+func TestHandleSubAgentResult_OneOwnerFails(t *testing.T) {
+	h := captureLogs(t)
+	sender := &ownerFailSender{fail: map[int64]bool{200: true}}
+	mem := &fakeMemoryWriter{}
+	ag := New(NewAgentConfig{
+		Workspace: testWorkspace(t),
+		LLM:       &fakeLLM{},
+		Sender:    sender,
+		Memory:    mem,
+		OwnerIDs:  []int64{100, 200, 300},
+	})
+
+	ag.handleSubAgentResult(context.Background(), subagent.SubAgentResult{TaskID: "report", ResultContent: "done"})
+
+	var got []int64
+	for _, m := range sender.sent {
+		got = append(got, m.chatID)
+	}
+	if len(got) != 2 || got[0] != 100 || got[1] != 300 {
+		t.Errorf("delivered to %v, want [100 300]", got)
+	}
+
+	rec := findRecord(h, "owner notification partially delivered")
+	if rec == nil {
+		t.Fatal("no partial delivery summary logged")
+	}
+	if rec["owners"] != "3" || rec["sent"] != "2" || rec["failed"] != "1" {
+		t.Errorf("summary = owners %s sent %s failed %s, want 3/2/1", rec["owners"], rec["sent"], rec["failed"])
+	}
+	if !strings.Contains(rec["errors"], "200: forbidden") {
+		t.Errorf("summary errors = %q, want the failing owner", rec["errors"])
+	}
+	for _, e := range mem.entries {
+		if e.source == "delivery-failure" {
+			t.Errorf("unexpected escalation on partial failure: %q", e.content)
+		}
+	}
+}
+
+func TestHandleSubAgentResult_AllOwnersFail(t *testing.T) {
+	h := captureLogs(t)
+	sender := &ownerFailSender{fail: map[int64]bool{100: true, 200: true}}
+	mem := &fakeMemoryWriter{}
+	ag := New(NewAgentConfig{
+		Workspace: testWorkspace(t),
+		LLM:       &fakeLLM{},
+		Sender:    sender,
+		Memory:    mem,
+		OwnerIDs:  []int64{100, 200},
+	})
+
+	ag.handleSubAgentResult(context.Background(), subagent.SubAgentResult{TaskID: "report", ResultContent: "done"})
+
+	if rec := findRecord(h, "owner notification reached no owner"); rec == nil || rec["failed"] != "2" {
+		t.Errorf("summary = %v, want an error record with 2 failures", rec)
+	}
+	var escalated string
+	for _, e := range mem.entries {
+		if e.source == "delivery-failure" {
+			escalated = e.content
+		}
+	}
+	if !strings.Contains(escalated, "UNDELIVERED: sub-agent 'report' result") {
+		t.Errorf("escalation entry = %q, want the undelivered result named", escalated)
+	}
+}
+
+func TestHandleSubAgentResult_AllOwnersReached(t *testing.T) {
+	h := captureLogs(t)
+	ag := New(NewAgentConfig{
+		Workspace: testWorkspace(t),
+		LLM:       &fakeLLM{},
+		Sender:    &fakeSender{},
+		OwnerIDs:  []int64{100, 200},
+	})
+
+	ag.handleSubAgentResult(context.Background(), subagent.SubAgentResult{TaskID: "report", ResultContent: "done"})
+
+	if rec := findRecord(h, "owner notification delivered"); rec == nil || rec["sent"] != "2" || rec["failed"] != "0" {
+		t.Errorf("summary = %v, want 2 sent and none failed", rec)
+	}
+}
@@ -0,0 +1,79 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/edouard/pureclaw/internal/llm"
+	"github.com/edouard/pureclaw/internal/platform"
+)
+
+// maxPartialStepRunes caps each tool call and result quoted in a partial result.
+const maxPartialStepRunes = 500
+
+// deadlinePassed reports whether the sub-agent's own deadline ended workCtx
+// while the process context is still live.
+func deadlinePassed(ctx, workCtx context.Context) bool {
+	return workCtx.Err() != nil && ctx.Err() == nil
+}
+
+// writePartialResult writes result.md from the work done before the sub-agent
+// deadline: each tool call with its (truncated) result, and any text the model
+// produced along the way.
+func (a *Agent) writePartialResult(ctx context.Context, progress []llm.Message, rounds int) error {
+	platform.Log(ctx).Warn("sub-agent deadline reached, writing partial result",
+		"component", "agent", "operation", "run_subagent",
+		"deadline", a.subAgentDeadline, "rounds", rounds)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Partial result\n\nThe sub-agent reached its %s deadline after %d tool round(s) without finishing the mission.\n", a.subAgentDeadline, rounds)
+	if len(progress) == 0 {
+		b.WriteString("\nNo work was completed.\n")
+	} else {
+		b.WriteString("\n## Work done so far\n\n")
+	}
+	for _, m := range progress {
+		switch {
+		case m.Role == "tool":
+			fmt.Fprintf(&b, "  → %s\n", clipRunes(m.Content, maxPartialStepRunes))
+		case len(m.ToolCalls) > 0:
+			if text := strings.TrimSpace(m.Content); text != "" {
+				fmt.Fprintf(&b, "%s\n", clipRunes(text, maxPartialStepRunes))
+			}
+			for _, tc := range m.ToolCalls {
+				fmt.Fprintf(&b, "- %s %s\n", tc.Function.Name, clipRunes(tc.Function.Arguments, maxPartialStepRunes))
+			}
+		}
+	}
+
+	if _, err := a.writeResultFile(b.String()); err != nil {
+		return fmt.Errorf("write partial result.md: %w", err)
+	}
+	a.logMemory(ctx, "sub-agent", "Mission stopped at deadline, partial result written")
+	return nil
+}
+
+// writeResultFile atomically writes content to result.md in the workspace root,
+// refusing a result.md that resolves outside the workspace (e.g. a symlink the
+// sub-agent planted). It returns the path written.
+func (a *Agent) writeResultFile(content string) (string, error) {
+	resultPath := filepath.Join(a.workspace.Root, "result.md")
+	if err := platform.ValidatePath(a.workspace.Root, resultPath); err != nil {
+		return "", err
+	}
+	if err := platform.AtomicWrite(resultPath, []byte(content), 0644); err != nil {
+		return "", err
+	}
+	return resultPath, nil
+}
+
+// clipRunes shortens s to at most n runes, marking the cut with "…".
+func clipRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + "…"
+}
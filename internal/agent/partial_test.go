@@ -0,0 +1,115 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/edouard/pureclaw/internal/llm"
+	"github.com/edouard/pureclaw/internal/tool"
+)
+
+// slowLLM answers with a tool call first, then blocks until ctx ends,
+// like a provider that stalls mid-mission.
+type slowLLM struct {
+	calls int
+}
+
+func (s *slowLLM) ChatCompletionWithRetry(ctx context.Context, messages []llm.Message, tools []llm.Tool) (*llm.ChatResponse, error) {
+	s.calls++
+	if s.calls == 1 {
+		return makeToolCallResponse(tc("1", "list_dir", `{"path":"data"}`)), nil
+	}
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestRunSubAgent_DeadlineWritesPartialResult(t *testing.T) {
+	ws := testWorkspace(t)
+	mem := &fakeMemoryWriter{}
+	exec := &fakeToolExecutor{results: []tool.ToolResult{{Success: true, Output: "report.csv"}}}
+	ag := New(NewAgentConfig{
+		Workspace:        ws,
+		LLM:              &slowLLM{},
+		Memory:           mem,
+		ToolExecutor:     exec,
+		SubAgentDeadline: 50 * time.Millisecond,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	start := time.Now()
+	if err := ag.RunSubAgent(ctx); err != nil {
+		t.Fatalf("RunSubAgent() error = %v, want a clean exit at the deadline", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("RunSubAgent took %s, want it to stop at its 50ms deadline", elapsed)
+	}
+
+	data, err := os.ReadFile(filepath.Join(ws.Root, "result.md"))
+	if err != nil {
+		t.Fatalf("read result.md: %v", err)
+	}
+	got := string(data)
+	for _, want := range []string{"# Partial result", "50ms deadline after 1 tool round(s)", `- list_dir {"path":"data"}`, "report.csv"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("result.md missing %q:\n%s", want, got)
+		}
+	}
+	if len(mem.entries) != 1 || !strings.Contains(mem.entries[0].content, "partial result") {
+		t.Errorf("memory entries = %+v, want the partial result noted", mem.entries)
+	}
+}
+
+func TestRunSubAgent_ParentCancelIsNotADeadline(t *testing.T) {
+	ws := testWorkspace(t)
+	ag := New(NewAgentConfig{
+		Workspace:        ws,
+		LLM:              &slowLLM{calls: 1},
+		SubAgentDeadline: time.Hour,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := ag.RunSubAgent(ctx); err == nil {
+		t.Fatal("RunSubAgent() = nil, want the LLM error when the process context ends")
+	}
+	if _, err := os.Stat(filepath.Join(ws.Root, "result.md")); !os.IsNotExist(err) {
+		t.Errorf("result.md written on parent cancellation: %v", err)
+	}
+}
+
+func TestClipRunes(t *testing.T) {
+	if got := clipRunes("héllo wörld", 5); got != "héllo…" {
+		t.Errorf("clipRunes = %q, want %q", got, "héllo…")
+	}
+	if got := clipRunes("short", 10); got != "short" {
+		t.Errorf("clipRunes = %q, want unchanged", got)
+	}
+}
+
+func TestRunSubAgent_ResultSymlinkOutsideWorkspace(t *testing.T) {
+	ws := testWorkspace(t)
+	outside := filepath.Join(t.TempDir(), "stolen.md")
+	if err := os.WriteFile(outside, []byte("untouched"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(ws.Root, "result.md")); err != nil {
+		t.Fatal(err)
+	}
+	ag := New(NewAgentConfig{
+		Workspace: ws,
+		LLM:       &fakeLLM{responses: []*llm.ChatResponse{makeResponse("message", "the result")}},
+		Memory:    &fakeMemoryWriter{},
+	})
+
+	if err := ag.RunSubAgent(context.Background()); err == nil || !strings.Contains(err.Error(), "result.md") {
+		t.Fatalf("RunSubAgent() error = %v, want a result.md error", err)
+	}
+	if data, _ := os.ReadFile(outside); string(data) != "untouched" {
+		t.Errorf("file outside the workspace = %q, want untouched", data)
+	}
+}
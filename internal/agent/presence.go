@@ -0,0 +1,63 @@
+package agent
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/edouard/pureclaw/internal/platform"
+)
+
+// presenceNow is replaceable for testing.
+var presenceNow = time.Now
+
+// ownerSources are the memory sources written for messages from the owner.
+var ownerSources = []string{"owner", "voice-transcription"}
+
+// ownerPresent reports whether the owner wrote within the presence window.
+// The last message is tracked in process, seeded from memory at startup; until
+// either is known, the agent's start counts as the owner's last message.
+// Without a window every check passes.
+func (a *Agent) ownerPresent() bool {
+	if a.presenceWindow <= 0 {
+		return true
+	}
+	last := a.startedAt
+	if ns := a.ownerSeenAt.Load(); ns != 0 {
+		last = time.Unix(0, ns)
+	}
+	return presenceNow().Sub(last) <= a.presenceWindow
+}
+
+// markOwnerSeen records that the owner just wrote.
+func (a *Agent) markOwnerSeen() {
+	a.ownerSeenAt.Store(presenceNow().UnixNano())
+}
+
+// seedOwnerSeen derives the owner's last activity from the newest owner entry
+// in memory, so a restart does not count an away owner as present. With no such
+// entry within the window, the owner was last seen before it began. A message
+// that already arrived wins, and a failed read leaves the start as fallback.
+func (a *Agent) seedOwnerSeen(ctx context.Context) {
+	if a.presenceWindow <= 0 || a.memorySearcher == nil {
+		return
+	}
+	now := presenceNow()
+	start := now.Add(-a.presenceWindow)
+	entries, err := a.memorySearcher.ReadRange(ctx, start, now)
+	if err != nil {
+		platform.Log(ctx).Warn("failed to read owner activity from memory",
+			"component", "agent",
+			"operation", "presence",
+			"error", err,
+		)
+		return
+	}
+	last := start.Add(-time.Nanosecond)
+	for _, e := range entries {
+		if slices.Contains(ownerSources, e.Source) && e.Time.After(last) {
+			last = e.Time
+		}
+	}
+	a.ownerSeenAt.CompareAndSwap(0, last.UnixNano())
+}
@@ -0,0 +1,155 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/edouard/pureclaw/internal/llm"
+	"github.com/edouard/pureclaw/internal/memory"
+)
+
+func stubPresenceNow(t *testing.T, now time.Time) {
+	t.Helper()
+	orig := presenceNow
+	presenceNow = func() time.Time { return now }
+	t.Cleanup(func() { presenceNow = orig })
+}
+
+func TestHandleHeartbeat_OwnerPresence(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		seen      time.Time // zero = no owner message since start
+		wantQuiet bool
+	}{
+		{"recent owner message", now.Add(-5 * time.Hour), false},
+		{"stale owner message", now.Add(-72 * time.Hour), true},
+		{"no message since a recent start", time.Time{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ws := testWorkspace(t)
+			ws.HeartbeatMD = "- [ ] Check disk"
+			hb := &fakeHeartbeatExecutor{}
+			ag := New(NewAgentConfig{
+				Workspace:      ws,
+				Heartbeat:      hb,
+				PresenceWindow: 48 * time.Hour,
+			})
+			if !tt.seen.IsZero() {
+				stubPresenceNow(t, tt.seen)
+				ag.markOwnerSeen()
+			}
+			ag.startedAt = now.Add(-time.Hour)
+			stubPresenceNow(t, now)
+
+			ag.handleHeartbeat(context.Background())
+			if !hb.called {
+				t.Fatal("heartbeat did not run")
+			}
+			if hb.quiet != tt.wantQuiet {
+				t.Errorf("quiet = %v, want %v", hb.quiet, tt.wantQuiet)
+			}
+		})
+	}
+}
+
+func TestHandleMessage_MarksOwnerSeen(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	ws := testWorkspace(t)
+	ag := New(NewAgentConfig{
+		Workspace: ws,
+		LLM: &fakeLLM{responses: []*llm.ChatResponse{{
+			Choices: []llm.Choice{{Message: llm.Message{Content: "hi"}, FinishReason: "stop"}},
+		}}},
+		Sender:         &fakeSender{},
+		PresenceWindow: time.Hour,
+	})
+	ag.startedAt = now.Add(-48 * time.Hour)
+	stubPresenceNow(t, now)
+	if ag.ownerPresent() {
+		t.Fatal("ownerPresent() = true before any message, want false")
+	}
+
+	ag.handleMessage(context.Background(), testMsg(1, "hello"))
+
+	if !ag.ownerPresent() {
+		t.Error("ownerPresent() = false after a message, want true")
+	}
+}
+
+func TestOwnerPresent_NoWindow(t *testing.T) {
+	ag := New(NewAgentConfig{})
+	if !ag.ownerPresent() {
+		t.Error("ownerPresent() = false without a presence window, want true")
+	}
+}
+
+// rangeSearcher serves fixed memory entries by time range.
+type rangeSearcher struct {
+	entries []memory.SearchResult
+	err     error
+}
+
+func (r *rangeSearcher) Search(ctx context.Context, keyword string, start, end time.Time, tags ...string) ([]memory.SearchResult, error) {
+	return nil, nil
+}
+
+func (r *rangeSearcher) ReadRange(_ context.Context, start, end time.Time) ([]memory.SearchResult, error) {
+	var out []memory.SearchResult
+	for _, e := range r.entries {
+		if !e.Time.Before(start) && !e.Time.After(end) {
+			out = append(out, e)
+		}
+	}
+	return out, r.err
+}
+
+func TestSeedOwnerSeen_RestartKeepsPresence(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		searcher *rangeSearcher
+		want     bool
+	}{
+		{"owner wrote recently", &rangeSearcher{entries: []memory.SearchResult{
+			{Time: now.Add(-30 * time.Hour), Source: "voice-transcription"},
+			{Time: now.Add(-time.Minute), Source: "agent"},
+		}}, true},
+		{"owner away before the restart", &rangeSearcher{entries: []memory.SearchResult{
+			{Time: now.Add(-72 * time.Hour), Source: "owner"},
+			{Time: now.Add(-time.Hour), Source: "heartbeat"},
+		}}, false},
+		{"no owner entry", &rangeSearcher{}, false},
+		{"memory unreadable", &rangeSearcher{err: errors.New("disk gone")}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stubPresenceNow(t, now)
+			ag := New(NewAgentConfig{Workspace: testWorkspace(t), MemorySearcher: tt.searcher, PresenceWindow: 48 * time.Hour})
+			ag.startedAt = now.Add(-time.Minute) // just restarted
+
+			ag.seedOwnerSeen(context.Background())
+
+			if got := ag.ownerPresent(); got != tt.want {
+				t.Errorf("ownerPresent() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSeedOwnerSeen_MessageWins(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	stubPresenceNow(t, now)
+	ag := New(NewAgentConfig{Workspace: testWorkspace(t), MemorySearcher: &rangeSearcher{}, PresenceWindow: time.Hour})
+	ag.markOwnerSeen()
+
+	ag.seedOwnerSeen(context.Background())
+
+	if !ag.ownerPresent() {
+		t.Error("ownerPresent() = false, want the in-process message kept over memory")
+	}
+}
@@ -0,0 +1,67 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/edouard/pureclaw/internal/platform"
+)
+
+// statusEditInterval is the minimum time between edits of a status placeholder,
+// keeping progress updates well below Telegram's flood limits.
+var statusEditInterval = 3 * time.Second
+
+// Replaceable for testing.
+var progressNow = time.Now
+
+// statusProgress edits the status placeholder of the message being handled to
+// report tool progress. Updates arriving sooner than statusEditInterval after
+// the previous edit (or the placeholder itself) are dropped.
+type statusProgress struct {
+	editor    StatusEditor
+	chatID    int64
+	messageID int64
+	prefix    string    // the placeholder text, kept above the progress line
+	last      time.Time // when the placeholder was posted or last edited
+}
+
+type statusProgressKey struct{}
+
+// withStatusProgress makes tool execution under ctx report progress by editing
+// the placeholder messageID. It returns ctx unchanged when no StatusEditor is set.
+func (a *Agent) withStatusProgress(ctx context.Context, chatID, messageID int64) context.Context {
+	if a.statusEditor == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, statusProgressKey{}, &statusProgress{
+		editor:    a.statusEditor,
+		chatID:    chatID,
+		messageID: messageID,
+		prefix:    a.statusText,
+		last:      progressNow(),
+	})
+}
+
+// reportToolProgress tells the owner which of n tool calls is running, if the
+// message under ctx has a status placeholder to edit.
+func reportToolProgress(ctx context.Context, i, n int, name string) {
+	p, ok := ctx.Value(statusProgressKey{}).(*statusProgress)
+	if !ok {
+		return
+	}
+	now := progressNow()
+	if now.Sub(p.last) < statusEditInterval {
+		return
+	}
+	p.last = now
+	text := fmt.Sprintf("%s\nRunning tool %d/%d: %s…", p.prefix, i, n, name)
+	if err := p.editor.EditMessageText(ctx, p.chatID, p.messageID, text); err != nil {
+		platform.Log(ctx).Debug("failed to edit status placeholder",
+			"component", "agent",
+			"operation", "status",
+			"message_id", p.messageID,
+			"error", err,
+		)
+	}
+}
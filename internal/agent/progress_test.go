@@ -0,0 +1,118 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/edouard/pureclaw/internal/llm"
+	"github.com/edouard/pureclaw/internal/tool"
+)
+
+type statusEdit struct {
+	messageID int64
+	text      string
+}
+
+type fakeStatusEditor struct {
+	edits []statusEdit
+	err   error
+}
+
+func (f *fakeStatusEditor) EditMessageText(ctx context.Context, chatID, messageID int64, text string) error {
+	f.edits = append(f.edits, statusEdit{messageID, text})
+	return f.err
+}
+
+// stubProgressClock makes every progressNow call return a time step later than the previous one.
+func stubProgressClock(t *testing.T, step time.Duration) {
+	t.Helper()
+	orig := progressNow
+	t.Cleanup(func() { progressNow = orig })
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	progressNow = func() time.Time {
+		now = now.Add(step)
+		return now
+	}
+}
+
+func threeToolsThenReply() *fakeLLM {
+	return &fakeLLM{responses: []*llm.ChatResponse{
+		makeToolCallResponse(tc("1", "a", "{}"), tc("2", "b", "{}"), tc("3", "c", "{}")),
+		makeResponse("message", "done"),
+	}}
+}
+
+func TestHandleMessage_StatusProgress(t *testing.T) {
+	// Each clock read is 2s after the previous one and edits need 3s between
+	// them, so of the three tool updates only the second is sent.
+	stubProgressClock(t, 2*time.Second)
+	sender := &fakeSender{}
+	editor := &fakeStatusEditor{}
+	ag := New(NewAgentConfig{
+		Workspace:       testWorkspace(t),
+		LLM:             threeToolsThenReply(),
+		Sender:          sender,
+		ToolExecutor:    &fakeToolExecutor{results: []tool.ToolResult{{Success: true}}},
+		StatusMessenger: &fakeStatusMessenger{sender: sender},
+		StatusEditor:    editor,
+		StatusText:      "Working…",
+	})
+
+	ag.handleMessage(context.Background(), textMessage(42, "go"))
+
+	want := []statusEdit{{777, "Working…\nRunning tool 2/3: b…"}}
+	if len(editor.edits) != len(want) || editor.edits[0] != want[0] {
+		t.Errorf("edits = %q, want %q", editor.edits, want)
+	}
+	if len(sender.sent) == 0 || sender.sent[len(sender.sent)-1].text != "done" {
+		t.Errorf("sent = %+v, want the reply last", sender.sent)
+	}
+}
+
+func TestHandleMessage_StatusProgressEditFailure(t *testing.T) {
+	stubProgressClock(t, 5*time.Second)
+	sender := &fakeSender{}
+	editor := &fakeStatusEditor{err: errors.New("message to edit not found")}
+	status := &fakeStatusMessenger{sender: sender}
+	ag := New(NewAgentConfig{
+		Workspace:       testWorkspace(t),
+		LLM:             threeToolsThenReply(),
+		Sender:          sender,
+		ToolExecutor:    &fakeToolExecutor{results: []tool.ToolResult{{Success: true}}},
+		StatusMessenger: status,
+		StatusEditor:    editor,
+		StatusText:      "Working…",
+	})
+
+	ag.handleMessage(context.Background(), textMessage(42, "go"))
+
+	if len(editor.edits) != 3 {
+		t.Errorf("edits = %d, want one per tool call", len(editor.edits))
+	}
+	if len(sender.sent) == 0 || sender.sent[len(sender.sent)-1].text != "done" {
+		t.Errorf("sent = %+v, want the reply despite failed edits", sender.sent)
+	}
+	if len(status.deleted) != 1 {
+		t.Errorf("deleted = %v, want the placeholder removed", status.deleted)
+	}
+}
+
+func TestHandleMessage_StatusProgressNeedsPlaceholder(t *testing.T) {
+	stubProgressClock(t, 5*time.Second)
+	editor := &fakeStatusEditor{}
+	ag := New(NewAgentConfig{
+		Workspace:    testWorkspace(t),
+		LLM:          threeToolsThenReply(),
+		Sender:       &fakeSender{},
+		ToolExecutor: &fakeToolExecutor{results: []tool.ToolResult{{Success: true}}},
+		StatusEditor: editor,
+	})
+
+	ag.handleMessage(context.Background(), textMessage(42, "go"))
+
+	if len(editor.edits) != 0 {
+		t.Errorf("edits = %q, want none without a status placeholder", editor.edits)
+	}
+}
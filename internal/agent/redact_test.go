@@ -0,0 +1,45 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/edouard/pureclaw/internal/llm"
+	"github.com/edouard/pureclaw/internal/platform"
+	"github.com/edouard/pureclaw/internal/tool"
+)
+
+func TestHandleMessage_ToolResultRedacted(t *testing.T) {
+	const secret = "sk-live0123456789abcdefXYZ"
+	r, err := platform.NewRedactor(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	llmFake := &fakeLLM{responses: []*llm.ChatResponse{
+		makeToolCallResponse(tc("1", "read_file", `{"path":".env"}`)),
+		makeResponse("message", "Your key is "+secret),
+	}}
+	exec := &fakeToolExecutor{results: []tool.ToolResult{{Success: true, Output: "API_KEY=" + secret}}}
+	mem := &fakeMemoryWriter{}
+	ag := New(NewAgentConfig{Workspace: testWorkspace(t), LLM: llmFake, Sender: &fakeSender{}, Memory: mem, ToolExecutor: exec, Redactor: r})
+
+	ag.handleMessage(context.Background(), testMsg(42, "show my .env"))
+
+	if len(llmFake.calls) < 2 {
+		t.Fatalf("LLM calls = %d, want 2", len(llmFake.calls))
+	}
+	msgs := llmFake.calls[1]
+	toolMsg := msgs[len(msgs)-1]
+	if toolMsg.Role != "tool" || strings.Contains(toolMsg.Content, secret) || !strings.Contains(toolMsg.Content, "API_KEY=[REDACTED]") {
+		t.Errorf("tool message = %q, want the key masked", toolMsg.Content)
+	}
+	if len(mem.entries) != 2 || mem.entries[1].content != "Your key is [REDACTED]" {
+		t.Errorf("memory entries = %+v, want the reply stored masked", mem.entries)
+	}
+	for _, e := range mem.entries {
+		if strings.Contains(e.content, secret) {
+			t.Errorf("memory entry %s leaks the secret: %q", e.source, e.content)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package agent
+
+import "time"
+
+// The setters below change settings the config tool may update while the agent
+// runs. Tools execute on the agent's loop, so no locking is needed.
+
+// SetReplyChunkLimit sets how many messages a long reply is split into at most
+// before it is sent as a document instead (0 or less = no limit).
+func (a *Agent) SetReplyChunkLimit(n int) {
+	a.maxReplyChunks = max(n, 0)
+}
+
+// SetDebounce sets how long the agent waits for more messages from a chat
+// before answering them as one turn (0 or less = off).
+func (a *Agent) SetDebounce(d time.Duration) {
+	a.debounce = d
+}
+
+// SetPresenceWindow sets how recently the owner must have written for a
+// heartbeat to run (0 or less = always run).
+func (a *Agent) SetPresenceWindow(d time.Duration) {
+	a.presenceWindow = d
+}
@@ -0,0 +1,27 @@
+package agent
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSetters(t *testing.T) {
+	ag := &Agent{maxReplyChunks: 5}
+
+	ag.SetReplyChunkLimit(-1)
+	if ag.maxReplyChunks != 0 {
+		t.Errorf("maxReplyChunks = %d, want 0 for a negative limit", ag.maxReplyChunks)
+	}
+	ag.SetReplyChunkLimit(3)
+	if ag.maxReplyChunks != 3 {
+		t.Errorf("maxReplyChunks = %d, want 3", ag.maxReplyChunks)
+	}
+	ag.SetDebounce(2 * time.Second)
+	if ag.debounce != 2*time.Second {
+		t.Errorf("debounce = %v, want 2s", ag.debounce)
+	}
+	ag.SetPresenceWindow(72 * time.Hour)
+	if ag.presenceWindow != 72*time.Hour {
+		t.Errorf("presenceWindow = %v, want 72h", ag.presenceWindow)
+	}
+}
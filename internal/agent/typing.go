@@ -0,0 +1,44 @@
+package agent
+
+import (
+	"context"
+	"time"
+
+	"github.com/edouard/pureclaw/internal/platform"
+	"github.com/edouard/pureclaw/internal/telegram"
+)
+
+// typingInterval is how often the typing indicator is refreshed while a message
+// is processed. Telegram clears it after about 5 seconds.
+var typingInterval = 4 * time.Second
+
+// startTyping shows the typing indicator in chatID, refreshed every
+// typingInterval from a background goroutine, until the returned stop function
+// is called or ctx ends. stop doesn't wait and may be called more than once.
+// Heartbeat work answers no message, so it never shows the indicator, and
+// neither does a model that usually answers faster than typingMinLatency.
+func (a *Agent) startTyping(ctx context.Context, chatID int64) (stop func()) {
+	if a.chatActions == nil || fromHeartbeat(ctx) || !a.expectSlowReply() {
+		return func() {}
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		ticker := time.NewTicker(typingInterval)
+		defer ticker.Stop()
+		for {
+			if err := a.chatActions.SendChatAction(ctx, chatID, telegram.ChatActionTyping); err != nil && ctx.Err() == nil {
+				platform.Log(ctx).Debug("failed to send typing indicator",
+					"component", "agent",
+					"operation", "typing",
+					"error", err,
+				)
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return cancel
+}
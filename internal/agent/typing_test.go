@@ -0,0 +1,158 @@
+package agent
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/edouard/pureclaw/internal/llm"
+	"github.com/edouard/pureclaw/internal/platform"
+)
+
+// fakeChatActions records the chat actions sent.
+type fakeChatActions struct {
+	mu      sync.Mutex
+	actions []string
+}
+
+func (f *fakeChatActions) SendChatAction(ctx context.Context, chatID int64, action string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.actions = append(f.actions, action)
+	return nil
+}
+
+func (f *fakeChatActions) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.actions)
+}
+
+// pausingLLM answers like fakeLLM after a fixed delay.
+type pausingLLM struct {
+	fakeLLM
+	delay time.Duration
+}
+
+func (p *pausingLLM) ChatCompletionWithRetry(ctx context.Context, messages []llm.Message, tools []llm.Tool) (*llm.ChatResponse, error) {
+	time.Sleep(p.delay)
+	return p.fakeLLM.ChatCompletionWithRetry(ctx, messages, tools)
+}
+
+func stubTypingInterval(t *testing.T, d time.Duration) {
+	t.Helper()
+	orig := typingInterval
+	t.Cleanup(func() { typingInterval = orig })
+	typingInterval = d
+}
+
+func TestHandleMessage_TypingRefreshedUntilReply(t *testing.T) {
+	stubTypingInterval(t, 10*time.Millisecond)
+	actions := &fakeChatActions{}
+	llmFake := &pausingLLM{
+		fakeLLM: fakeLLM{responses: []*llm.ChatResponse{makeResponse("message", "done")}},
+		delay:   80 * time.Millisecond,
+	}
+	ag := New(NewAgentConfig{Workspace: testWorkspace(t), LLM: llmFake, Sender: &fakeSender{}, ChatActions: actions})
+
+	ag.handleMessage(context.Background(), textMessage(42, "hello"))
+
+	sent := actions.count()
+	if sent < 2 {
+		t.Fatalf("sent %d typing actions, want it refreshed while the LLM worked", sent)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if after := actions.count(); after > sent+1 {
+		t.Errorf("typing kept refreshing after the reply: %d actions, then %d", sent, after)
+	}
+	actions.mu.Lock()
+	defer actions.mu.Unlock()
+	for _, a := range actions.actions {
+		if a != "typing" {
+			t.Errorf("action = %q, want typing", a)
+		}
+	}
+}
+
+func TestStartTyping_StopsOnContextCancel(t *testing.T) {
+	stubTypingInterval(t, 5*time.Millisecond)
+	actions := &fakeChatActions{}
+	ag := New(NewAgentConfig{ChatActions: actions})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stop := ag.startTyping(ctx, 42)
+	defer stop()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	time.Sleep(10 * time.Millisecond)
+
+	sent := actions.count()
+	if sent == 0 {
+		t.Fatal("no typing action sent")
+	}
+	time.Sleep(30 * time.Millisecond)
+	if after := actions.count(); after != sent {
+		t.Errorf("typing kept refreshing after cancellation: %d actions, then %d", sent, after)
+	}
+}
+
+func TestStartTyping_SkippedForHeartbeat(t *testing.T) {
+	actions := &fakeChatActions{}
+	ag := New(NewAgentConfig{ChatActions: actions})
+
+	stop := ag.startTyping(platform.WithOrigin(context.Background(), platform.OriginHeartbeat), 42)
+	stop()
+	stop() // stop is idempotent
+	time.Sleep(10 * time.Millisecond)
+
+	if n := actions.count(); n != 0 {
+		t.Errorf("sent %d typing actions for heartbeat work, want none", n)
+	}
+}
+
+// latencyLLM is a pausingLLM reporting a settable rolling latency.
+type latencyLLM struct {
+	pausingLLM
+	latency time.Duration
+	known   bool
+}
+
+func (l *latencyLLM) Latency() (time.Duration, bool) { return l.latency, l.known }
+
+func TestHandleMessage_TypingOnlyForSlowModels(t *testing.T) {
+	llmFake := &latencyLLM{pausingLLM: pausingLLM{delay: 30 * time.Millisecond}}
+	sender := &fakeSender{}
+	actions := &fakeChatActions{}
+	ag := New(NewAgentConfig{
+		Workspace:        testWorkspace(t),
+		LLM:              llmFake,
+		Sender:           sender,
+		ChatActions:      actions,
+		TypingMinLatency: 2 * time.Second,
+	})
+
+	// The rolling estimate climbs past the threshold; the decision flips with it.
+	for _, step := range []struct {
+		latency time.Duration
+		known   bool
+		want    bool
+	}{
+		{0, false, true},                      // no estimate yet: show it
+		{300 * time.Millisecond, true, false}, // fast model: skip
+		{1900 * time.Millisecond, true, false},
+		{2 * time.Second, true, true}, // reached the threshold: show it
+		{4 * time.Second, true, true},
+	} {
+		llmFake.latency, llmFake.known = step.latency, step.known
+		llmFake.responses = append(llmFake.responses, makeResponse("message", "Done!"))
+		before := actions.count()
+		ag.handleMessage(context.Background(), testMsg(42, "do it"))
+		if got := actions.count() > before; got != step.want {
+			t.Errorf("latency %v (known %v): typing shown = %v, want %v", step.latency, step.known, got, step.want)
+		}
+	}
+	if len(sender.sent) != 5 {
+		t.Errorf("sent %d replies, want every message answered", len(sender.sent))
+	}
+}
@@ -0,0 +1,179 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+
+	"github.com/edouard/pureclaw/internal/platform"
+	"github.com/edouard/pureclaw/internal/telegram"
+)
+
+// isChatUnreachable is replaceable for testing.
+var isChatUnreachable = telegram.IsChatUnreachable
+
+// errChatUnreachable is returned for sends skipped because the chat is unreachable.
+var errChatUnreachable = errors.New("agent: chat unreachable (bot blocked or chat gone)")
+
+// unreachableSender skips sends to chats Telegram refused for good (the user
+// blocked the bot, or the chat is gone), so every later reply, notification or
+// heartbeat doesn't fail and log again. A chat is marked on its first refused
+// call and becomes reachable again when it writes to the bot. The other
+// Telegram outputs (status placeholders, documents, chat actions) are gated
+// through it by wrapping them with its status, editor, documents and
+// chatActions methods.
+type unreachableSender struct {
+	Sender
+	mu    sync.Mutex
+	chats map[int64]bool
+}
+
+func newUnreachableSender(s Sender) *unreachableSender {
+	return &unreachableSender{Sender: s, chats: make(map[int64]bool)}
+}
+
+func (u *unreachableSender) Send(ctx context.Context, chatID int64, text string) error {
+	return u.do(ctx, chatID, "send", func() error {
+		return u.Sender.Send(ctx, chatID, text)
+	})
+}
+
+func (u *unreachableSender) React(ctx context.Context, chatID, messageID int64, emoji string) error {
+	return u.do(ctx, chatID, "react", func() error {
+		return u.Sender.React(ctx, chatID, messageID, emoji)
+	})
+}
+
+// do runs call unless chatID is marked unreachable, and marks chatID when call
+// fails because Telegram refuses the chat.
+func (u *unreachableSender) do(ctx context.Context, chatID int64, operation string, call func() error) error {
+	if u.isUnreachable(chatID) {
+		platform.Log(ctx).Debug("skipping call to unreachable chat",
+			"component", "agent",
+			"operation", operation,
+			"chat_id", chatID,
+		)
+		return errChatUnreachable
+	}
+	err := call()
+	if err != nil && isChatUnreachable(err) {
+		u.mu.Lock()
+		defer u.mu.Unlock()
+		if !u.chats[chatID] {
+			u.chats[chatID] = true
+			platform.Log(ctx).Warn("chat unreachable, skipping sends to it until it writes again",
+				"component", "agent",
+				"operation", operation,
+				"chat_id", chatID,
+				"error", err,
+			)
+		}
+	}
+	return err
+}
+
+func (u *unreachableSender) isUnreachable(chatID int64) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.chats[chatID]
+}
+
+// markReachable clears chatID's unreachable mark: a chat that writes to the bot
+// has unblocked it.
+func (u *unreachableSender) markReachable(chatID int64) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.chats[chatID] {
+		delete(u.chats, chatID)
+		slog.Info("chat reachable again",
+			"component", "agent",
+			"operation", "handle_message",
+			"chat_id", chatID,
+		)
+	}
+}
+
+// status gates m's calls on chat reachability. A nil m stays nil.
+func (u *unreachableSender) status(m StatusMessenger) StatusMessenger {
+	if m == nil {
+		return nil
+	}
+	return unreachableStatus{m: m, u: u}
+}
+
+// editor gates e's calls on chat reachability. A nil e stays nil.
+func (u *unreachableSender) editor(e StatusEditor) StatusEditor {
+	if e == nil {
+		return nil
+	}
+	return unreachableEditor{e: e, u: u}
+}
+
+// documents gates d's calls on chat reachability. A nil d stays nil.
+func (u *unreachableSender) documents(d DocumentSender) DocumentSender {
+	if d == nil {
+		return nil
+	}
+	return unreachableDocuments{d: d, u: u}
+}
+
+// chatActions gates c's calls on chat reachability. A nil c stays nil.
+func (u *unreachableSender) chatActions(c ChatActionSender) ChatActionSender {
+	if c == nil {
+		return nil
+	}
+	return unreachableChatActions{c: c, u: u}
+}
+
+type unreachableStatus struct {
+	m StatusMessenger
+	u *unreachableSender
+}
+
+func (s unreachableStatus) SendMessage(ctx context.Context, chatID int64, text string) (id int64, err error) {
+	err = s.u.do(ctx, chatID, "status", func() error {
+		id, err = s.m.SendMessage(ctx, chatID, text)
+		return err
+	})
+	return id, err
+}
+
+func (s unreachableStatus) DeleteMessage(ctx context.Context, chatID, messageID int64) error {
+	return s.u.do(ctx, chatID, "status", func() error {
+		return s.m.DeleteMessage(ctx, chatID, messageID)
+	})
+}
+
+type unreachableEditor struct {
+	e StatusEditor
+	u *unreachableSender
+}
+
+func (s unreachableEditor) EditMessageText(ctx context.Context, chatID, messageID int64, text string) error {
+	return s.u.do(ctx, chatID, "status", func() error {
+		return s.e.EditMessageText(ctx, chatID, messageID, text)
+	})
+}
+
+type unreachableDocuments struct {
+	d DocumentSender
+	u *unreachableSender
+}
+
+func (s unreachableDocuments) SendDocument(ctx context.Context, chatID int64, fileName string, data []byte, caption string) error {
+	return s.u.do(ctx, chatID, "send_document", func() error {
+		return s.d.SendDocument(ctx, chatID, fileName, data, caption)
+	})
+}
+
+type unreachableChatActions struct {
+	c ChatActionSender
+	u *unreachableSender
+}
+
+func (s unreachableChatActions) SendChatAction(ctx context.Context, chatID int64, action string) error {
+	return s.u.do(ctx, chatID, "typing", func() error {
+		return s.c.SendChatAction(ctx, chatID, action)
+	})
+}
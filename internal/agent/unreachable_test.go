@@ -0,0 +1,208 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/edouard/pureclaw/internal/llm"
+	"github.com/edouard/pureclaw/internal/subagent"
+)
+
+var errBotBlocked = errors.New("sendMessage: unexpected status 403: Forbidden: bot was blocked by the user")
+
+func stubChatUnreachable(t *testing.T) {
+	t.Helper()
+	orig := isChatUnreachable
+	t.Cleanup(func() { isChatUnreachable = orig })
+	isChatUnreachable = func(err error) bool { return errors.Is(err, errBotBlocked) }
+}
+
+func TestSkipUnreachable_StopsSendingToBlockedChat(t *testing.T) {
+	stubChatUnreachable(t)
+	sender := &fakeSender{err: errBotBlocked}
+	ag := New(NewAgentConfig{
+		Workspace:       testWorkspace(t),
+		LLM:             &fakeLLM{responses: []*llm.ChatResponse{makeResponse("message", "hi")}},
+		Sender:          sender,
+		OwnerIDs:        []int64{42},
+		SkipUnreachable: true,
+	})
+
+	ag.handleMessage(context.Background(), textMessage(42, "hello"))
+	if len(sender.sent) != 1 {
+		t.Fatalf("send attempts = %d, want 1", len(sender.sent))
+	}
+	if !ag.unreachable.isUnreachable(42) {
+		t.Fatal("chat 42 not marked unreachable after a 403")
+	}
+
+	// Notifications to the blocked chat are skipped without calling Telegram.
+	ag.handleSubAgentResult(context.Background(), subagent.SubAgentResult{TaskID: "t1", ResultContent: "done"})
+	if err := ag.send(context.Background(), 42, "again"); !errors.Is(err, errChatUnreachable) {
+		t.Errorf("send() error = %v, want errChatUnreachable", err)
+	}
+	if len(sender.sent) != 1 {
+		t.Errorf("send attempts = %d after the chat was marked, want still 1", len(sender.sent))
+	}
+}
+
+func TestSkipUnreachable_ChatWritingAgainIsReachable(t *testing.T) {
+	stubChatUnreachable(t)
+	sender := &fakeSender{err: errBotBlocked}
+	ag := New(NewAgentConfig{
+		Workspace:       testWorkspace(t),
+		LLM:             &fakeLLM{responses: []*llm.ChatResponse{makeResponse("message", "hi")}},
+		Sender:          sender,
+		SkipUnreachable: true,
+	})
+
+	ag.handleMessage(context.Background(), textMessage(42, "hello"))
+	sender.err = nil // the user unblocked the bot
+	ag.handleMessage(context.Background(), textMessage(42, "hello again"))
+
+	if len(sender.sent) != 2 {
+		t.Fatalf("send attempts = %d, want the second reply delivered", len(sender.sent))
+	}
+	if ag.unreachable.isUnreachable(42) {
+		t.Error("chat 42 still unreachable after it wrote again")
+	}
+}
+
+func TestSkipUnreachable_OtherErrorsAndChatsUnaffected(t *testing.T) {
+	stubChatUnreachable(t)
+	sender := &fakeSender{err: errors.New("sendMessage: unexpected status 502: Bad Gateway")}
+	ag := New(NewAgentConfig{Sender: sender, SkipUnreachable: true})
+
+	ag.send(context.Background(), 42, "one")
+	ag.send(context.Background(), 42, "two")
+	if len(sender.sent) != 2 || ag.unreachable.isUnreachable(42) {
+		t.Errorf("send attempts = %d, unreachable = %v; want transient errors to keep the chat reachable",
+			len(sender.sent), ag.unreachable.isUnreachable(42))
+	}
+
+	sender.err = errBotBlocked
+	ag.send(context.Background(), 42, "three")
+	sender.err = nil
+	if !ag.unreachable.isUnreachable(42) {
+		t.Error("chat 42 not marked unreachable after a 403")
+	}
+	if err := ag.send(context.Background(), 7, "other chat"); err != nil {
+		t.Errorf("send to another chat: %v", err)
+	}
+}
+
+func TestSkipUnreachable_GatesEveryOutput(t *testing.T) {
+	stubChatUnreachable(t)
+	sender := &fakeSender{}
+	status := &fakeStatusMessenger{sender: sender}
+	docs := &fakeDocumentSender{err: errBotBlocked}
+	actions := &fakeChatActions{}
+	ag := New(NewAgentConfig{
+		Sender:          sender,
+		StatusMessenger: status,
+		StatusText:      "Working…",
+		DocumentSender:  docs,
+		ChatActions:     actions,
+		SkipUnreachable: true,
+	})
+	ctx := context.Background()
+
+	// A refused upload marks the chat like a refused message does.
+	ag.sendDocument(ctx, 42, "a.txt", []byte("a"), "")
+	if !ag.unreachable.isUnreachable(42) {
+		t.Fatal("chat 42 not marked unreachable after a 403 on upload")
+	}
+
+	ag.sendDocument(ctx, 42, "b.txt", []byte("b"), "")
+	if _, ok := ag.postStatus(ctx, 42); ok {
+		t.Error("status placeholder posted to an unreachable chat")
+	}
+	if err := ag.chatActions.SendChatAction(ctx, 42, "typing"); !errors.Is(err, errChatUnreachable) {
+		t.Errorf("SendChatAction() error = %v, want errChatUnreachable", err)
+	}
+	if len(docs.docs) != 1 || len(status.posted) != 0 || actions.count() != 0 || len(sender.sent) != 0 {
+		t.Errorf("uploads = %d, placeholders = %d, actions = %d, sends = %d; want only the first upload tried",
+			len(docs.docs), len(status.posted), actions.count(), len(sender.sent))
+	}
+
+	// Other chats are unaffected.
+	if _, ok := ag.postStatus(ctx, 7); !ok {
+		t.Error("status placeholder not posted to a reachable chat")
+	}
+}
+
+func TestSkipUnreachable_Disabled(t *testing.T) {
+	stubChatUnreachable(t)
+	sender := &fakeSender{err: errBotBlocked}
+	ag := New(NewAgentConfig{Sender: sender})
+
+	ag.send(context.Background(), 42, "one")
+	ag.send(context.Background(), 42, "two")
+	if len(sender.sent) != 2 {
+		t.Errorf("send attempts = %d, want every send tried when disabled", len(sender.sent))
+	}
+}
+
+func TestUnreachableWrappers_SkipMarkedChatForwardOthers(t *testing.T) {
+	u := newUnreachableSender(&fakeSender{})
+	u.chats[42] = true
+	status := &fakeStatusMessenger{sender: &fakeSender{}}
+	editor := &fakeStatusEditor{}
+	docs := &fakeDocumentSender{}
+	actions := &fakeChatActions{}
+	gatedStatus := u.status(status)
+	gatedEditor := u.editor(editor)
+	gatedDocs := u.documents(docs)
+	gatedActions := u.chatActions(actions)
+
+	calls := []struct {
+		name string
+		call func(chatID int64) error
+	}{
+		{"SendMessage", func(chatID int64) error {
+			_, err := gatedStatus.SendMessage(context.Background(), chatID, "Working…")
+			return err
+		}},
+		{"DeleteMessage", func(chatID int64) error {
+			return gatedStatus.DeleteMessage(context.Background(), chatID, 777)
+		}},
+		{"EditMessageText", func(chatID int64) error {
+			return gatedEditor.EditMessageText(context.Background(), chatID, 777, "step 2")
+		}},
+		{"SendDocument", func(chatID int64) error {
+			return gatedDocs.SendDocument(context.Background(), chatID, "a.txt", []byte("a"), "")
+		}},
+		{"SendChatAction", func(chatID int64) error {
+			return gatedActions.SendChatAction(context.Background(), chatID, "typing")
+		}},
+	}
+	forwarded := func() int {
+		return len(status.posted) + len(status.deleted) + len(editor.edits) + len(docs.docs) + actions.count()
+	}
+
+	for _, c := range calls {
+		t.Run(c.name, func(t *testing.T) {
+			before := forwarded()
+			if err := c.call(42); !errors.Is(err, errChatUnreachable) {
+				t.Errorf("unreachable chat: error = %v, want errChatUnreachable", err)
+			}
+			if got := forwarded(); got != before {
+				t.Errorf("unreachable chat: %d calls forwarded, want 0", got-before)
+			}
+			if err := c.call(7); err != nil {
+				t.Errorf("reachable chat: %v", err)
+			}
+			if got := forwarded(); got != before+1 {
+				t.Errorf("reachable chat: %d calls forwarded, want 1", got-before)
+			}
+		})
+	}
+}
+
+func TestUnreachableWrappers_NilStaysNil(t *testing.T) {
+	u := newUnreachableSender(&fakeSender{})
+	if u.status(nil) != nil || u.editor(nil) != nil || u.documents(nil) != nil || u.chatActions(nil) != nil {
+		t.Error("wrapping a nil output returned a non-nil wrapper")
+	}
+}
@@ -0,0 +1,103 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/edouard/pureclaw/internal/platform"
+	"github.com/edouard/pureclaw/internal/subagent"
+)
+
+// Replaceable for testing.
+var (
+	webhookClient  = &http.Client{}
+	webhookTimeout = 10 * time.Second
+)
+
+// maxWebhookSummaryRunes caps the result text posted to the webhook.
+const maxWebhookSummaryRunes = 4000
+
+// resultWebhookPayload is the JSON body posted for each sub-agent completion.
+type resultWebhookPayload struct {
+	TaskID          string  `json:"task_id"`
+	Status          string  `json:"status"` // completed, failed, timed_out or partial
+	Summary         string  `json:"summary"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	Error           string  `json:"error,omitempty"`
+}
+
+// subAgentStatus classifies a sub-agent result for the webhook.
+func subAgentStatus(result subagent.SubAgentResult) string {
+	switch {
+	case result.TimedOut && result.ResultContent != "":
+		return "partial"
+	case result.TimedOut:
+		return "timed_out"
+	case result.Err != nil:
+		return "failed"
+	default:
+		return "completed"
+	}
+}
+
+// postResultWebhook posts a sub-agent result to the configured webhook, within
+// webhookTimeout. Failures are logged and returned.
+func (a *Agent) postResultWebhook(ctx context.Context, result subagent.SubAgentResult) error {
+	payload := resultWebhookPayload{
+		TaskID:          result.TaskID,
+		Status:          subAgentStatus(result),
+		Summary:         a.redactor.Redact(clipRunes(result.ResultContent, maxWebhookSummaryRunes)),
+		DurationSeconds: result.Duration.Seconds(),
+	}
+	if result.Err != nil {
+		payload.Error = a.redactor.Redact(result.Err.Error())
+	}
+
+	err := postJSON(ctx, a.webhookURL, payload)
+	if err != nil {
+		platform.Log(ctx).Error("sub-agent result webhook failed",
+			"component", "agent",
+			"operation", "result_webhook",
+			"task_id", result.TaskID,
+			"error", err,
+		)
+		return err
+	}
+	platform.Log(ctx).Info("sub-agent result posted to webhook",
+		"component", "agent",
+		"operation", "result_webhook",
+		"task_id", result.TaskID,
+		"status", payload.Status,
+	)
+	return nil
+}
+
+// postJSON POSTs v as JSON to url and fails on any non-2xx status.
+func postJSON(ctx context.Context, url string, v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("webhook: marshal: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("webhook: new request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook: post: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook: post: status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/edouard/pureclaw/internal/subagent"
+)
+
+// webhookServer records the payloads posted to it and answers with status.
+func webhookServer(t *testing.T, status int) (*httptest.Server, *[]resultWebhookPayload) {
+	t.Helper()
+	var got []resultWebhookPayload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("request = %s with Content-Type %q, want a JSON POST", r.Method, r.Header.Get("Content-Type"))
+		}
+		var p resultWebhookPayload
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+			t.Errorf("decode payload: %v", err)
+		}
+		got = append(got, p)
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &got
+}
+
+func TestHandleSubAgentResult_PostsWebhook(t *testing.T) {
+	srv, got := webhookServer(t, http.StatusNoContent)
+	sender := &fakeSender{}
+	ag := New(NewAgentConfig{Sender: sender, OwnerIDs: []int64{123}, ResultWebhookURL: srv.URL})
+
+	ag.handleSubAgentResult(context.Background(), subagent.SubAgentResult{
+		TaskID:        "report",
+		ResultContent: "3 issues found",
+		Duration:      90 * time.Second,
+	})
+	ag.webhookPosts.Wait()
+
+	if len(*got) != 1 {
+		t.Fatalf("webhook received %d posts, want 1", len(*got))
+	}
+	want := resultWebhookPayload{TaskID: "report", Status: "completed", Summary: "3 issues found", DurationSeconds: 90}
+	if (*got)[0] != want {
+		t.Errorf("payload = %+v, want %+v", (*got)[0], want)
+	}
+	if len(sender.sent) != 1 {
+		t.Errorf("sent %d Telegram messages, want the result delivered there too", len(sender.sent))
+	}
+}
+
+func TestHandleSubAgentResult_WebhookFailureStillDeliversTelegram(t *testing.T) {
+	h := captureLogs(t)
+	srv, got := webhookServer(t, http.StatusInternalServerError)
+	sender := &fakeSender{}
+	ag := New(NewAgentConfig{Sender: sender, OwnerIDs: []int64{123}, ResultWebhookURL: srv.URL, WebhookOnly: true})
+
+	ag.handleSubAgentResult(context.Background(), subagent.SubAgentResult{
+		TaskID: "deploy",
+		Err:    errors.New("exit status 1"),
+	})
+	ag.webhookPosts.Wait()
+
+	if len(*got) != 1 || (*got)[0].Status != "failed" || (*got)[0].Error != "exit status 1" {
+		t.Errorf("payloads = %+v, want one failed result", *got)
+	}
+	if len(sender.sent) != 1 {
+		t.Errorf("sent %d Telegram messages, want the fallback delivery", len(sender.sent))
+	}
+	if findRecord(h, "sub-agent result webhook failed") == nil {
+		t.Error("webhook failure was not logged")
+	}
+}
+
+func TestHandleSubAgentResult_WebhookOnly(t *testing.T) {
+	srv, got := webhookServer(t, http.StatusOK)
+	sender := &fakeSender{}
+	ag := New(NewAgentConfig{Sender: sender, OwnerIDs: []int64{123}, ResultWebhookURL: srv.URL, WebhookOnly: true})
+
+	ag.handleSubAgentResult(context.Background(), subagent.SubAgentResult{TaskID: "t", TimedOut: true, ResultContent: "half"})
+	ag.webhookPosts.Wait()
+
+	if len(*got) != 1 || (*got)[0].Status != "partial" {
+		t.Errorf("payloads = %+v, want one partial result", *got)
+	}
+	if len(sender.sent) != 0 {
+		t.Errorf("sent %d Telegram messages, want none in webhook-only mode", len(sender.sent))
+	}
+}
+
+func TestHandleSubAgentResult_WebhookDoesNotBlockTelegram(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	t.Cleanup(srv.Close)
+	sender := &fakeSender{}
+	ag := New(NewAgentConfig{Sender: sender, OwnerIDs: []int64{123}, ResultWebhookURL: srv.URL})
+
+	done := make(chan struct{})
+	go func() {
+		ag.handleSubAgentResult(context.Background(), subagent.SubAgentResult{TaskID: "slow", ResultContent: "ok"})
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("handleSubAgentResult blocked on the webhook post")
+	}
+	if len(sender.sent) != 1 {
+		t.Errorf("sent %d Telegram messages, want the result delivered before the post completes", len(sender.sent))
+	}
+	close(release)
+	ag.webhookPosts.Wait()
+}
+
+func TestPostJSON_Timeout(t *testing.T) {
+	orig := webhookTimeout
+	webhookTimeout = 20 * time.Millisecond
+	t.Cleanup(func() { webhookTimeout = orig })
+
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(release) })
+
+	start := time.Now()
+	if err := postJSON(context.Background(), srv.URL, map[string]string{"a": "b"}); err == nil {
+		t.Fatal("postJSON() = nil, want a timeout error")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("postJSON took %s, want it bounded by the timeout", elapsed)
+	}
+}
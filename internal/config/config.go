@@ -4,7 +4,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"net/url"
 	"os"
+	"regexp"
+	"text/template"
 	"time"
 
 	"github.com/edouard/pureclaw/internal/platform"
@@ -15,7 +18,7 @@ const configFilePerm = 0644
 
 // Replaceable for testing error paths.
 var (
-	atomicWrite      = platform.AtomicWrite
+	atomicWrite       = platform.AtomicWrite
 	jsonMarshalIndent = func(v any, prefix, indent string) ([]byte, error) { return json.MarshalIndent(v, prefix, indent) }
 )
 
@@ -45,12 +48,88 @@ func (d *Duration) UnmarshalJSON(b []byte) error {
 
 // Config holds the application configuration.
 type Config struct {
-	Workspace          string   `json:"workspace"`
-	ModelText          string   `json:"model_text"`
-	ModelAudio         string   `json:"model_audio"`
-	TelegramAllowedIDs []int64  `json:"telegram_allowed_ids"`
-	HeartbeatInterval  Duration `json:"heartbeat_interval"`
-	SubAgentTimeout    Duration `json:"sub_agent_timeout"`
+	Version               int      `json:"version,omitempty"` // Schema version, see CurrentVersion
+	Workspace             string   `json:"workspace"`
+	ModelText             string   `json:"model_text"`
+	ModelAudio            string   `json:"model_audio"`
+	TelegramAllowedIDs    []int64  `json:"telegram_allowed_ids"`
+	HeartbeatInterval     Duration `json:"heartbeat_interval"`
+	SubAgentTimeout       Duration `json:"sub_agent_timeout"`
+	FormatCodeBlocks      bool     `json:"format_code_blocks,omitempty"`       // Render ``` fences as Telegram code blocks
+	RetryBudget           int      `json:"retry_budget,omitempty"`             // Max retries shared across one message (0 = unlimited)
+	SubAgentMaxConcurrent int      `json:"sub_agent_max_concurrent,omitempty"` // Sub-agents allowed to run at once (default 1)
+	SubAgentBinary        string   `json:"sub_agent_binary,omitempty"`         // pureclaw binary for sub-agents (default: running executable, then PATH)
+	SubAgentDocument      bool     `json:"sub_agent_document,omitempty"`       // Also send each full sub-agent result as a document
+	SubAgentSoul          string   `json:"sub_agent_soul,omitempty"`           // SOUL.md for sub-agents instead of the parent's (spawn_agent "soul" overrides it)
+
+	ToolConfirmations []ToolConfirmation `json:"tool_confirmations,omitempty"`  // Tools that need owner approval before running
+	ToolConcurrency   int                `json:"tool_concurrency,omitempty"`    // Max tool calls running at once (0 = unlimited)
+	ToolLimits        map[string]int     `json:"tool_limits,omitempty"`         // Per-tool max concurrent calls, e.g. {"exec_command": 2}
+	StatusMessage     string             `json:"status_message,omitempty"`      // Placeholder posted while processing, e.g. "Working…"
+	KeepStatusMessage bool               `json:"keep_status_message,omitempty"` // Keep the placeholder instead of deleting it after the reply
+	TypingMinLatency  Duration           `json:"typing_min_latency,omitzero"`   // Show typing only for models whose rolling response latency is at least this, e.g. "2s" (0 = always)
+	StatusProgress    bool               `json:"status_progress,omitempty"`     // Edit the placeholder to show which tool is running (rate-limited)
+	MemoryVerbosity   string             `json:"memory_verbosity,omitempty"`    // Memory sources to persist: all (default), messages-only, none
+	FallbackReply     string             `json:"fallback_reply,omitempty"`      // Sent when a message gets no reply ("" = default text, "off" = disabled)
+	NotifyDefaultSoul bool               `json:"notify_default_soul,omitempty"` // Message the owners at startup if SOUL.md is still the default
+	NotifyShutdown    bool               `json:"notify_shutdown,omitempty"`     // Message the owners when the bot shuts down gracefully
+	MaxSkills         int                `json:"max_skills,omitempty"`          // Cap on skills loaded from skills/, highest priority first (0 = unlimited)
+	MaxMemoryResults  int                `json:"max_memory_results,omitempty"`  // Cap on entries a memory search/read returns, most recent kept (0 = unlimited)
+	ToolsFile         string             `json:"tools_file,omitempty"`          // tools.json selecting the built-in tools to register (default "tools.json" if present)
+	ResponseFormat    string             `json:"response_format,omitempty"`     // Structured output: json_schema (default), json_object, none (prompt-only)
+	MemoryDedup       bool               `json:"memory_dedup,omitempty"`        // Skip a memory entry identical (source + content) to the previous one in the same hour
+	ReplyAttachments  bool               `json:"reply_attachments,omitempty"`   // Let replies name workspace files to upload as documents
+	PollAlertAfter    int                `json:"poll_alert_after,omitempty"`    // Alert the owners after this many consecutive failed poll cycles (0 = default, negative = never)
+	ChatPromptSuffix  map[int64]string   `json:"chat_prompt_suffix,omitempty"`  // Extra system instruction per chat ID, e.g. {"123456": "Answer tersely."} to trial a prompt variant
+	MinFreeDiskMB     int                `json:"min_free_disk_mb,omitempty"`    // Below this much free disk, skip non-critical memory writes and alert the owners (0 = no check)
+	LLMMaxConcurrent  int                `json:"llm_max_concurrent,omitempty"`  // Max LLM requests in flight per process; excess requests queue (0 = unlimited)
+	RedactPatterns    []string           `json:"redact_patterns,omitempty"`     // Extra regexps masked in tool results and memory, on top of the built-in secret patterns
+	ReplyChunkLimit   int                `json:"reply_chunk_limit,omitempty"`   // Max messages a long reply is split into; beyond it the full reply is sent as a document (0 = default, negative = no limit)
+	DebounceWindow    Duration           `json:"debounce_window,omitzero"`      // Wait this long for more messages from a chat and answer them as one turn, e.g. "2s" (0 = off)
+	ResultWebhookURL  string             `json:"result_webhook_url,omitempty"`  // Sub-agent results are also POSTed as JSON (task_id, status, summary, duration) to this URL
+	ResultWebhookOnly bool               `json:"result_webhook_only,omitempty"` // Skip Telegram for sub-agent results the webhook accepted
+	PresenceWindow    Duration           `json:"presence_window,omitzero"`      // Suppress heartbeat alerts unless the owner wrote within this long, e.g. "72h" (0 = always alert)
+	EnvSummary        string             `json:"env_summary,omitempty"`         // Go template for the introspection memory entry, e.g. "Detected: {{.OS}}/{{.Arch}}, {{.DiskAvailable}} free" (empty = full environment section)
+	AssistantPrefix   string             `json:"assistant_prefix,omitempty"`    // Seed tool-less requests (heartbeat, compaction; not chat replies with tools) with this prefix, e.g. "{" (Mistral only; empty = off)
+	MemoryPerChat     bool               `json:"memory_per_chat,omitempty"`     // Keep each chat's memory under memory/<chatID>/ so owners' conversations don't mix (default: one shared layout)
+	HistoryMaxAge     Duration           `json:"history_max_age,omitzero"`      // Start a fresh conversation when the last turn is older than this, e.g. "12h" (0 = keep history)
+	MemoryJSONL       bool               `json:"memory_jsonl,omitempty"`        // Also append every memory entry as a JSON line to memory/jsonl/YYYY-MM-DD.jsonl
+	PromptSections    []string           `json:"prompt_sections,omitempty"`     // System prompt sections in order, from soul, agent, skills, environment; unlisted ones are left out (default: soul, agent with its environment, skills)
+	SkipBlockedChats  bool               `json:"skip_blocked_chats,omitempty"`  // Stop sending to a chat once Telegram reports the bot blocked or the chat gone, until it writes again
+	WatchInterval     Duration           `json:"watch_interval,omitzero"`       // How often workspace files are checked for changes; changes within one interval cause a single reload, e.g. "5s" (0 = 2s)
+	WarmUpTimeout     Duration           `json:"warm_up_timeout,omitzero"`      // Bound on the startup warm-up (introspection and workspace load) that runs before messages are accepted, e.g. "1m" (0 = 30s)
+
+	AllowedWriteExtensions []string `json:"allowed_write_extensions,omitempty"` // Extensions write_file and save_skill may write, e.g. [".md", ".txt", ".json"] (empty = any)
+
+	ModelQuirks   map[string]ModelQuirks     `json:"model_quirks,omitempty"`   // Tool-call format adaptations by model name, e.g. {"my-model": {"parameters_key": "arguments"}}
+	LLMProvider   string                     `json:"llm_provider,omitempty"`   // Chat completion API: mistral (default), openai (any OpenAI-compatible server) or ollama; other providers use the vault key llm_api_key if set
+	LLMBaseURL    string                     `json:"llm_base_url,omitempty"`   // API root of the provider, e.g. "http://localhost:8000/v1" (empty = the provider's default)
+	AllowedModels []string                   `json:"allowed_models,omitempty"` // Text models the /model command may switch to besides model_text (empty = /model disabled)
+	PersistModel  bool                       `json:"persist_model,omitempty"`  // Save a /model switch to config.json as model_text instead of keeping it until restart
+	Temperature   *float64                   `json:"temperature,omitempty"`    // Sampling temperature sent with chat completions (unset = provider default)
+	MaxTokens     int                        `json:"max_tokens,omitempty"`     // Cap on tokens per chat completion (0 = provider default)
+
+	TelegramMode  string `json:"telegram_mode,omitempty"`  // How updates are received: poll (default, long polling) or webhook
+	WebhookURL    string `json:"webhook_url,omitempty"`    // Public HTTPS URL Telegram posts updates to in webhook mode, e.g. "https://bot.example.com/telegram"
+	WebhookListen string `json:"webhook_listen,omitempty"` // Address the webhook server listens on, behind the TLS proxy serving webhook_url (default ":8080")
+
+	Timeouts Timeouts `json:"timeouts,omitzero"` // Per-component time limits; omitted fields use defaults
+}
+
+// ToolConfirmation requires owner approval for a tool. With ExceptPaths set, approval
+// is only required when the tool's "path" argument falls outside those directories
+// (relative entries are resolved against the workspace).
+type ToolConfirmation struct {
+	Tool        string   `json:"tool"`
+	ExceptPaths []string `json:"except_paths,omitempty"`
+}
+
+// ModelQuirks describes how a model's tool-call format departs from the
+// default, as llm.ModelQuirks, which it converts to.
+type ModelQuirks struct {
+	ParametersKey string `json:"parameters_key,omitempty"` // Key carrying each tool's JSON schema in requests (default "parameters")
+	ToolChoice    string `json:"tool_choice,omitempty"`    // tool_choice sent along with tools (default "auto")
+	ToolCallType  string `json:"tool_call_type,omitempty"` // Type given to tool calls that lack one, in responses and re-sent history (default "function")
 }
 
 // Load reads and parses a config.json file from the given path.
@@ -63,6 +142,49 @@ func Load(path string) (*Config, error) {
 	if err := json.Unmarshal(data, &cfg); err != nil {
 		return nil, fmt.Errorf("config: load: unmarshal: %w", err)
 	}
+	if err := cfg.Timeouts.Validate(); err != nil {
+		return nil, err
+	}
+	switch cfg.ResponseFormat {
+	case "", "json_schema", "json_object", "none":
+	default:
+		return nil, fmt.Errorf("config: validate: response_format must be json_schema, json_object or none, got %q", cfg.ResponseFormat)
+	}
+	switch cfg.LLMProvider {
+	case "", "mistral", "openai", "ollama":
+	default:
+		return nil, fmt.Errorf("config: validate: llm_provider must be mistral, openai or ollama, got %q", cfg.LLMProvider)
+	}
+	if cfg.LLMBaseURL != "" {
+		if u, err := url.Parse(cfg.LLMBaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("config: validate: llm_base_url must be an http(s) URL, got %q", cfg.LLMBaseURL)
+		}
+	}
+	for _, p := range cfg.RedactPatterns {
+		if _, err := regexp.Compile(p); err != nil {
+			return nil, fmt.Errorf("config: validate: redact_patterns: %w", err)
+		}
+	}
+	if _, err := template.New("env_summary").Parse(cfg.EnvSummary); err != nil {
+		return nil, fmt.Errorf("config: validate: env_summary: %w", err)
+	}
+	if cfg.ResultWebhookURL != "" {
+		if u, err := url.Parse(cfg.ResultWebhookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("config: validate: result_webhook_url must be an http(s) URL, got %q", cfg.ResultWebhookURL)
+		}
+	}
+	if err := validatePromptSections(cfg.PromptSections); err != nil {
+		return nil, fmt.Errorf("config: validate: prompt_sections: %w", err)
+	}
+	switch cfg.TelegramMode {
+	case "", TelegramModePoll:
+	case TelegramModeWebhook:
+		if u, err := url.Parse(cfg.WebhookURL); err != nil || u.Scheme != "https" || u.Host == "" {
+			return nil, fmt.Errorf("config: validate: webhook_url must be an https URL in webhook mode, got %q", cfg.WebhookURL)
+		}
+	default:
+		return nil, fmt.Errorf("config: validate: telegram_mode must be poll or webhook, got %q", cfg.TelegramMode)
+	}
 	slog.Info("config loaded", "component", "config", "operation", "load", "path", path)
 	return &cfg, nil
 }
@@ -80,3 +202,115 @@ func Save(cfg *Config, path string) error {
 	slog.Info("config saved", "component", "config", "operation", "save", "path", path)
 	return nil
 }
+
+// DefaultFallbackReply is sent when handling a message produced no reply.
+const DefaultFallbackReply = "Sorry, I couldn't process that."
+
+// DefaultPollAlertAfter is the number of consecutive failed poll cycles after which
+// the owners are alerted when poll_alert_after is unset.
+const DefaultPollAlertAfter = 5
+
+// DefaultReplyChunkLimit is the number of messages a long reply is split into at
+// most when reply_chunk_limit is unset.
+const DefaultReplyChunkLimit = 5
+
+// validatePromptSections checks that sections only names the system prompt
+// sections the workspace knows, each at most once.
+func validatePromptSections(sections []string) error {
+	seen := make(map[string]bool, len(sections))
+	for _, name := range sections {
+		switch name {
+		case "soul", "agent", "skills", "environment":
+		default:
+			return fmt.Errorf("unknown prompt section %q (want soul, agent, skills or environment)", name)
+		}
+		if seen[name] {
+			return fmt.Errorf("prompt section %q listed twice", name)
+		}
+		seen[name] = true
+	}
+	return nil
+}
+
+// Telegram update delivery modes for telegram_mode.
+const (
+	TelegramModePoll    = "poll"
+	TelegramModeWebhook = "webhook"
+)
+
+// DefaultWebhookListen is the address the webhook server listens on when
+// webhook_listen is unset.
+const DefaultWebhookListen = ":8080"
+
+// ResolvedWebhookListen returns the effective webhook listen address: the
+// default when unset.
+func (c *Config) ResolvedWebhookListen() string {
+	if c.WebhookListen == "" {
+		return DefaultWebhookListen
+	}
+	return c.WebhookListen
+}
+
+// DefaultWatchInterval is how often workspace files are checked for changes
+// when watch_interval is unset.
+const DefaultWatchInterval = 2 * time.Second
+
+// ResolvedWatchInterval returns the effective workspace watch interval: the
+// default when unset or not positive.
+func (c *Config) ResolvedWatchInterval() time.Duration {
+	if c.WatchInterval.Duration <= 0 {
+		return DefaultWatchInterval
+	}
+	return c.WatchInterval.Duration
+}
+
+// DefaultWarmUpTimeout bounds the startup warm-up when warm_up_timeout is unset.
+const DefaultWarmUpTimeout = 30 * time.Second
+
+// ResolvedWarmUpTimeout returns the effective startup warm-up timeout: the
+// default when unset or not positive.
+func (c *Config) ResolvedWarmUpTimeout() time.Duration {
+	if c.WarmUpTimeout.Duration <= 0 {
+		return DefaultWarmUpTimeout
+	}
+	return c.WarmUpTimeout.Duration
+}
+
+// ResolvedReplyChunkLimit returns the effective reply chunk limit: the default
+// when unset, or 0 (no limit) when negative.
+func (c *Config) ResolvedReplyChunkLimit() int {
+	switch {
+	case c.ReplyChunkLimit == 0:
+		return DefaultReplyChunkLimit
+	case c.ReplyChunkLimit < 0:
+		return 0
+	default:
+		return c.ReplyChunkLimit
+	}
+}
+
+// ResolvedPollAlertAfter returns the effective outage alert threshold: the default
+// when unset, or 0 (never alert) when negative.
+func (c *Config) ResolvedPollAlertAfter() int {
+	switch {
+	case c.PollAlertAfter == 0:
+		return DefaultPollAlertAfter
+	case c.PollAlertAfter < 0:
+		return 0
+	default:
+		return c.PollAlertAfter
+	}
+}
+
+// ResolvedFallbackReply returns the effective fallback reply: the default text when
+// unset, or "" when set to "off".
+func (c *Config) ResolvedFallbackReply() string {
+	switch c.FallbackReply {
+	case "":
+		return DefaultFallbackReply
+	case "off":
+		return ""
+	default:
+		return c.FallbackReply
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -260,3 +261,241 @@ func TestSave_Load_RoundTrip(t *testing.T) {
 		t.Fatalf("sub_agent_timeout: got %v, want %v", loaded.SubAgentTimeout.Duration, original.SubAgentTimeout.Duration)
 	}
 }
+
+func TestResolvedFallbackReply(t *testing.T) {
+	tests := []struct {
+		configured string
+		want       string
+	}{
+		{"", DefaultFallbackReply},
+		{"off", ""},
+		{"Oops, try again.", "Oops, try again."},
+	}
+	for _, tt := range tests {
+		cfg := &Config{FallbackReply: tt.configured}
+		if got := cfg.ResolvedFallbackReply(); got != tt.want {
+			t.Errorf("ResolvedFallbackReply(%q) = %q, want %q", tt.configured, got, tt.want)
+		}
+	}
+}
+
+func TestResolvedPollAlertAfter(t *testing.T) {
+	tests := []struct {
+		configured int
+		want       int
+	}{
+		{0, DefaultPollAlertAfter},
+		{-1, 0},
+		{12, 12},
+	}
+	for _, tt := range tests {
+		cfg := &Config{PollAlertAfter: tt.configured}
+		if got := cfg.ResolvedPollAlertAfter(); got != tt.want {
+			t.Errorf("ResolvedPollAlertAfter(%d) = %d, want %d", tt.configured, got, tt.want)
+		}
+	}
+}
+
+func TestResolvedReplyChunkLimit(t *testing.T) {
+	tests := []struct {
+		configured int
+		want       int
+	}{
+		{0, DefaultReplyChunkLimit},
+		{-1, 0},
+		{8, 8},
+	}
+	for _, tt := range tests {
+		cfg := &Config{ReplyChunkLimit: tt.configured}
+		if got := cfg.ResolvedReplyChunkLimit(); got != tt.want {
+			t.Errorf("ResolvedReplyChunkLimit(%d) = %d, want %d", tt.configured, got, tt.want)
+		}
+	}
+}
+
+func TestLoad_ResponseFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	for format, valid := range map[string]bool{"none": true, "json_object": true, "xml": false} {
+		if err := os.WriteFile(path, []byte(`{"response_format":"`+format+`"}`), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := Load(path)
+		if valid && (err != nil || cfg.ResponseFormat != format) {
+			t.Errorf("Load(%s) = %v, %v; want format kept", format, cfg, err)
+		}
+		if !valid && (err == nil || !strings.Contains(err.Error(), "response_format")) {
+			t.Errorf("Load(%s) error = %v, want response_format error", format, err)
+		}
+	}
+}
+
+func TestLoad_LLMProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	tests := []struct {
+		json    string
+		wantErr string
+	}{
+		{`{"llm_provider":"ollama","llm_base_url":"http://pi.local:11434/v1"}`, ""},
+		{`{"llm_provider":"openai"}`, ""},
+		{`{"llm_provider":"anthropic"}`, "llm_provider"},
+		{`{"llm_base_url":"localhost:8000"}`, "llm_base_url"},
+	}
+	for _, tt := range tests {
+		if err := os.WriteFile(path, []byte(tt.json), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := Load(path)
+		if tt.wantErr == "" && err != nil {
+			t.Errorf("Load(%s): %v", tt.json, err)
+		}
+		if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("Load(%s) error = %v, want %s error", tt.json, err, tt.wantErr)
+		}
+	}
+}
+
+func TestLoad_ChatPromptSuffix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"chat_prompt_suffix":{"123456":"Answer tersely."}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got := cfg.ChatPromptSuffix[123456]; got != "Answer tersely." {
+		t.Errorf("ChatPromptSuffix[123456] = %q, want %q", got, "Answer tersely.")
+	}
+}
+
+func TestLoad_ResultWebhookURL(t *testing.T) {
+	dir := t.TempDir()
+	for webhook, valid := range map[string]bool{"https://ci.example.com/hook": true, "ftp://example.com": false, "not a url": false} {
+		path := filepath.Join(dir, "config.json")
+		if err := os.WriteFile(path, []byte(`{"result_webhook_url":"`+webhook+`"}`), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := Load(path)
+		if valid && err != nil {
+			t.Errorf("Load(%s): %v", webhook, err)
+		}
+		if !valid && (err == nil || !strings.Contains(err.Error(), "result_webhook_url")) {
+			t.Errorf("Load(%s) error = %v, want result_webhook_url error", webhook, err)
+		}
+	}
+}
+
+func TestLoad_PromptSections(t *testing.T) {
+	dir := t.TempDir()
+	for sections, valid := range map[string]bool{`["skills","soul","agent"]`: true, `["soul","memory"]`: false, `["soul","soul"]`: false} {
+		path := filepath.Join(dir, "config.json")
+		if err := os.WriteFile(path, []byte(`{"prompt_sections":`+sections+`}`), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := Load(path)
+		if valid && err != nil {
+			t.Errorf("Load(%s): %v", sections, err)
+		}
+		if !valid && (err == nil || !strings.Contains(err.Error(), "prompt_sections")) {
+			t.Errorf("Load(%s) error = %v, want prompt_sections error", sections, err)
+		}
+	}
+}
+
+func TestLoad_TelegramMode(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		json    string
+		wantErr string
+	}{
+		{`{}`, ""},
+		{`{"telegram_mode":"poll"}`, ""},
+		{`{"telegram_mode":"webhook","webhook_url":"https://bot.example.com/telegram"}`, ""},
+		{`{"telegram_mode":"webhook"}`, "webhook_url"},
+		{`{"telegram_mode":"webhook","webhook_url":"http://bot.example.com/telegram"}`, "webhook_url"},
+		{`{"telegram_mode":"push"}`, "telegram_mode"},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(dir, "config.json")
+		if err := os.WriteFile(path, []byte(tt.json), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := Load(path)
+		if tt.wantErr == "" && err != nil {
+			t.Errorf("Load(%s): %v", tt.json, err)
+		}
+		if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("Load(%s) error = %v, want %s error", tt.json, err, tt.wantErr)
+		}
+	}
+}
+
+func TestResolvedWebhookListen(t *testing.T) {
+	if got := (&Config{}).ResolvedWebhookListen(); got != DefaultWebhookListen {
+		t.Errorf("ResolvedWebhookListen() = %q, want %q", got, DefaultWebhookListen)
+	}
+	if got := (&Config{WebhookListen: "127.0.0.1:9000"}).ResolvedWebhookListen(); got != "127.0.0.1:9000" {
+		t.Errorf("ResolvedWebhookListen() = %q, want 127.0.0.1:9000", got)
+	}
+}
+
+func TestResolvedWatchInterval(t *testing.T) {
+	tests := []struct {
+		set  time.Duration
+		want time.Duration
+	}{
+		{0, DefaultWatchInterval},
+		{-time.Second, DefaultWatchInterval},
+		{5 * time.Second, 5 * time.Second},
+	}
+	for _, tt := range tests {
+		c := &Config{WatchInterval: Duration{tt.set}}
+		if got := c.ResolvedWatchInterval(); got != tt.want {
+			t.Errorf("ResolvedWatchInterval() with %s = %s, want %s", tt.set, got, tt.want)
+		}
+	}
+}
+
+func TestResolvedWarmUpTimeout(t *testing.T) {
+	tests := []struct {
+		set  time.Duration
+		want time.Duration
+	}{
+		{0, DefaultWarmUpTimeout},
+		{-time.Second, DefaultWarmUpTimeout},
+		{time.Minute, time.Minute},
+	}
+	for _, tt := range tests {
+		c := &Config{WarmUpTimeout: Duration{tt.set}}
+		if got := c.ResolvedWarmUpTimeout(); got != tt.want {
+			t.Errorf("ResolvedWarmUpTimeout() with %s = %s, want %s", tt.set, got, tt.want)
+		}
+	}
+}
+
+func TestLoad_EnvSummary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"env_summary":"Detected: {{.OS"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "env_summary") {
+		t.Errorf("Load() error = %v, want env_summary error", err)
+	}
+}
+
+func TestLoad_RedactPatterns(t *testing.T) {
+	dir := t.TempDir()
+	for patterns, valid := range map[string]bool{`["tok_[a-z]+"]`: true, `["tok_[a-z"]`: false} {
+		path := filepath.Join(dir, "config.json")
+		if err := os.WriteFile(path, []byte(`{"redact_patterns":`+patterns+`}`), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := Load(path)
+		if valid && err != nil {
+			t.Errorf("Load(%s): %v", patterns, err)
+		}
+		if !valid && (err == nil || !strings.Contains(err.Error(), "redact_patterns")) {
+			t.Errorf("Load(%s) error = %v, want redact_patterns error", patterns, err)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CurrentVersion is the config schema version written by this build.
+// Version 1 is any config.json without a "version" key.
+const CurrentVersion = 2
+
+// Defaults filled in by Migrate when a key is missing.
+const (
+	DefaultModelText       = "mistral-large-latest"
+	DefaultModelAudio      = "voxtral-mini-latest"
+	DefaultSubAgentTimeout = 5 * time.Minute
+)
+
+// renamedKeys maps deprecated config keys to their current names.
+var renamedKeys = map[string]string{
+	"model":       "model_text",
+	"allowed_ids": "telegram_allowed_ids",
+}
+
+// Migrate upgrades the config file at path to CurrentVersion: deprecated keys
+// are renamed, missing fields get their defaults, unknown keys are dropped, and
+// the version is bumped. The original file is copied to path+".bak" before the
+// upgraded config is saved atomically. It returns a description of each change;
+// a config already at CurrentVersion is left untouched and yields no changes.
+func Migrate(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("config: migrate: %w", err)
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("config: migrate: unmarshal: %w", err)
+	}
+
+	version := 1
+	if v, ok := raw["version"]; ok {
+		if err := json.Unmarshal(v, &version); err != nil {
+			return nil, fmt.Errorf("config: migrate: version: %w", err)
+		}
+	}
+	if version >= CurrentVersion {
+		slog.Info("config already current", "component", "config", "operation", "migrate", "version", version)
+		return nil, nil
+	}
+
+	changes := renameKeys(raw)
+	changes = append(changes, unknownKeys(raw)...)
+
+	merged, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("config: migrate: marshal: %w", err)
+	}
+	var cfg Config
+	if err := json.Unmarshal(merged, &cfg); err != nil {
+		return nil, fmt.Errorf("config: migrate: unmarshal: %w", err)
+	}
+	changes = append(changes, applyDefaults(&cfg)...)
+	cfg.Version = CurrentVersion
+	changes = append(changes, fmt.Sprintf("version %d -> %d", version, CurrentVersion))
+
+	backup := path + ".bak"
+	if err := atomicWrite(backup, data, configFilePerm); err != nil {
+		return nil, fmt.Errorf("config: migrate: backup: %w", err)
+	}
+	if err := Save(&cfg, path); err != nil {
+		return nil, fmt.Errorf("config: migrate: %w", err)
+	}
+
+	slog.Info("config migrated",
+		"component", "config",
+		"operation", "migrate",
+		"path", path,
+		"backup", backup,
+		"from_version", version,
+		"to_version", CurrentVersion,
+		"changes", len(changes),
+	)
+	return changes, nil
+}
+
+// renameKeys moves deprecated keys in raw to their current names. When both the
+// old and new key are present, the new key wins and the old one is dropped.
+func renameKeys(raw map[string]json.RawMessage) []string {
+	var changes []string
+	for _, old := range sortedKeys(renamedKeys) {
+		val, ok := raw[old]
+		if !ok {
+			continue
+		}
+		current := renamedKeys[old]
+		delete(raw, old)
+		if _, exists := raw[current]; exists {
+			changes = append(changes, fmt.Sprintf("dropped deprecated %q (superseded by %q)", old, current))
+			continue
+		}
+		raw[current] = val
+		changes = append(changes, fmt.Sprintf("renamed %q to %q", old, current))
+	}
+	return changes
+}
+
+// unknownKeys reports keys in raw that the current Config does not define.
+func unknownKeys(raw map[string]json.RawMessage) []string {
+	known := map[string]bool{}
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		known[name] = true
+	}
+
+	var changes []string
+	for _, k := range sortedKeys(raw) {
+		if !known[k] {
+			changes = append(changes, fmt.Sprintf("dropped unknown key %q", k))
+		}
+	}
+	return changes
+}
+
+// applyDefaults fills zero-valued fields that have a documented default.
+func applyDefaults(cfg *Config) []string {
+	var changes []string
+	if cfg.ModelText == "" {
+		cfg.ModelText = DefaultModelText
+		changes = append(changes, fmt.Sprintf("set model_text to default %q", DefaultModelText))
+	}
+	if cfg.ModelAudio == "" {
+		cfg.ModelAudio = DefaultModelAudio
+		changes = append(changes, fmt.Sprintf("set model_audio to default %q", DefaultModelAudio))
+	}
+	if cfg.SubAgentTimeout.Duration == 0 {
+		cfg.SubAgentTimeout = Duration{DefaultSubAgentTimeout}
+		changes = append(changes, fmt.Sprintf("set sub_agent_timeout to default %s", DefaultSubAgentTimeout))
+	}
+	return changes
+}
+
+// sortedKeys returns the keys of m in sorted order for deterministic output.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,155 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMigrate_V1Config(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	v1 := `{
+  "workspace": "./workspace",
+  "model": "mistral-small-latest",
+  "telegram_allowed_ids": [42],
+  "heartbeat_interval": "30m",
+  "legacy_flag": true
+}`
+	if err := os.WriteFile(path, []byte(v1), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err := Migrate(path)
+	if err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load migrated: %v", err)
+	}
+	if cfg.Version != CurrentVersion {
+		t.Errorf("Version = %d, want %d", cfg.Version, CurrentVersion)
+	}
+	if cfg.ModelText != "mistral-small-latest" {
+		t.Errorf("ModelText = %q, want renamed value %q", cfg.ModelText, "mistral-small-latest")
+	}
+	if cfg.ModelAudio != DefaultModelAudio {
+		t.Errorf("ModelAudio = %q, want default %q", cfg.ModelAudio, DefaultModelAudio)
+	}
+	if cfg.SubAgentTimeout.Duration != DefaultSubAgentTimeout {
+		t.Errorf("SubAgentTimeout = %v, want default %v", cfg.SubAgentTimeout.Duration, DefaultSubAgentTimeout)
+	}
+	if cfg.HeartbeatInterval.Duration != 30*time.Minute {
+		t.Errorf("HeartbeatInterval = %v, want preserved 30m", cfg.HeartbeatInterval.Duration)
+	}
+	if len(cfg.TelegramAllowedIDs) != 1 || cfg.TelegramAllowedIDs[0] != 42 {
+		t.Errorf("TelegramAllowedIDs = %v, want [42]", cfg.TelegramAllowedIDs)
+	}
+
+	data, _ := os.ReadFile(path)
+	var raw map[string]json.RawMessage
+	json.Unmarshal(data, &raw)
+	for _, k := range []string{"model", "legacy_flag"} {
+		if _, ok := raw[k]; ok {
+			t.Errorf("migrated config still contains %q", k)
+		}
+	}
+
+	backup, err := os.ReadFile(path + ".bak")
+	if err != nil {
+		t.Fatalf("read backup: %v", err)
+	}
+	if string(backup) != v1 {
+		t.Errorf("backup content = %q, want original", backup)
+	}
+
+	joined := strings.Join(changes, "\n")
+	for _, want := range []string{`renamed "model" to "model_text"`, `dropped unknown key "legacy_flag"`, "model_audio", "sub_agent_timeout", "version 1 -> 2"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("changes missing %q:\n%s", want, joined)
+		}
+	}
+}
+
+func TestMigrate_RenamedKeySupersededByCurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	os.WriteFile(path, []byte(`{"allowed_ids":[1],"telegram_allowed_ids":[2]}`), 0644)
+
+	changes, err := Migrate(path)
+	if err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	cfg, _ := Load(path)
+	if len(cfg.TelegramAllowedIDs) != 1 || cfg.TelegramAllowedIDs[0] != 2 {
+		t.Errorf("TelegramAllowedIDs = %v, want current key value [2]", cfg.TelegramAllowedIDs)
+	}
+	if !strings.Contains(strings.Join(changes, "\n"), `dropped deprecated "allowed_ids"`) {
+		t.Errorf("changes = %v, want deprecated key dropped", changes)
+	}
+}
+
+func TestMigrate_AlreadyCurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	content := `{"version":2,"workspace":"./workspace"}`
+	os.WriteFile(path, []byte(content), 0644)
+
+	changes, err := Migrate(path)
+	if err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("changes = %v, want none", changes)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != content {
+		t.Error("current config should not be rewritten")
+	}
+	if _, err := os.Stat(path + ".bak"); !os.IsNotExist(err) {
+		t.Error("no backup expected for a current config")
+	}
+}
+
+func TestMigrate_Errors(t *testing.T) {
+	t.Run("missing file", func(t *testing.T) {
+		if _, err := Migrate(filepath.Join(t.TempDir(), "none.json")); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+	t.Run("invalid json", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.json")
+		os.WriteFile(path, []byte("{bad"), 0644)
+		if _, err := Migrate(path); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+	t.Run("invalid version", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.json")
+		os.WriteFile(path, []byte(`{"version":"two"}`), 0644)
+		if _, err := Migrate(path); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+	t.Run("backup error leaves original", func(t *testing.T) {
+		original := atomicWrite
+		defer func() { atomicWrite = original }()
+		atomicWrite = func(path string, data []byte, perm os.FileMode) error {
+			return errors.New("disk full")
+		}
+		path := filepath.Join(t.TempDir(), "config.json")
+		os.WriteFile(path, []byte(`{"model":"m"}`), 0644)
+		_, err := Migrate(path)
+		if err == nil || !strings.Contains(err.Error(), "backup") {
+			t.Fatalf("err = %v, want backup error", err)
+		}
+		data, _ := os.ReadFile(path)
+		if string(data) != `{"model":"m"}` {
+			t.Error("original config modified after failed backup")
+		}
+	})
+}
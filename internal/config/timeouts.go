@@ -0,0 +1,71 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// Default timeouts applied when a Timeouts field is omitted.
+const (
+	DefaultLLMTimeout      = 30 * time.Second
+	DefaultPollTimeout     = 30 * time.Second
+	DefaultToolTimeout     = 30 * time.Second
+	DefaultDownloadTimeout = 60 * time.Second
+	DefaultMessageTimeout  = 5 * time.Minute
+)
+
+// maxPollTimeout is the longest long-poll wait Telegram honours.
+const maxPollTimeout = 50 * time.Second
+
+// Timeouts groups the latency limits of every component in one place.
+// Zero fields fall back to their defaults (see Config.ResolvedTimeouts).
+type Timeouts struct {
+	LLM      Duration `json:"llm,omitzero"`       // HTTP timeout for each Mistral request
+	Poll     Duration `json:"poll,omitzero"`      // Telegram long-poll wait (whole seconds)
+	Tool     Duration `json:"tool,omitzero"`      // Time limit for one exec_command run
+	SubAgent Duration `json:"sub_agent,omitzero"` // Time limit for one sub-agent (overrides sub_agent_timeout)
+	Download Duration `json:"download,omitzero"`  // Time limit for downloading a Telegram file
+	Message  Duration `json:"message,omitzero"`   // Time limit for handling one incoming message end to end
+}
+
+// Validate rejects negative timeouts and poll waits Telegram cannot honour.
+func (t Timeouts) Validate() error {
+	for _, f := range []struct {
+		name string
+		d    time.Duration
+	}{
+		{"llm", t.LLM.Duration},
+		{"poll", t.Poll.Duration},
+		{"tool", t.Tool.Duration},
+		{"sub_agent", t.SubAgent.Duration},
+		{"download", t.Download.Duration},
+		{"message", t.Message.Duration},
+	} {
+		if f.d < 0 {
+			return fmt.Errorf("config: validate: timeouts.%s must not be negative, got %s", f.name, f.d)
+		}
+	}
+	if p := t.Poll.Duration; p != 0 && (p < time.Second || p > maxPollTimeout) {
+		return fmt.Errorf("config: validate: timeouts.poll must be between 1s and %s, got %s", maxPollTimeout, p)
+	}
+	return nil
+}
+
+// ResolvedTimeouts returns the effective timeouts, filling omitted fields with defaults.
+// The sub-agent limit falls back to the legacy sub_agent_timeout, then DefaultSubAgentTimeout.
+func (c *Config) ResolvedTimeouts() Timeouts {
+	t := c.Timeouts
+	fill := func(d *Duration, def time.Duration) {
+		if d.Duration == 0 {
+			d.Duration = def
+		}
+	}
+	fill(&t.LLM, DefaultLLMTimeout)
+	fill(&t.Poll, DefaultPollTimeout)
+	fill(&t.Tool, DefaultToolTimeout)
+	fill(&t.SubAgent, c.SubAgentTimeout.Duration)
+	fill(&t.SubAgent, DefaultSubAgentTimeout)
+	fill(&t.Download, DefaultDownloadTimeout)
+	fill(&t.Message, DefaultMessageTimeout)
+	return t
+}
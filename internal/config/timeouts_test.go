@@ -0,0 +1,126 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResolvedTimeouts_Defaults(t *testing.T) {
+	cfg := &Config{}
+	got := cfg.ResolvedTimeouts()
+
+	want := Timeouts{
+		LLM:      Duration{DefaultLLMTimeout},
+		Poll:     Duration{DefaultPollTimeout},
+		Tool:     Duration{DefaultToolTimeout},
+		SubAgent: Duration{DefaultSubAgentTimeout},
+		Download: Duration{DefaultDownloadTimeout},
+		Message:  Duration{DefaultMessageTimeout},
+	}
+	if got != want {
+		t.Errorf("ResolvedTimeouts() = %+v, want %+v", got, want)
+	}
+}
+
+func TestResolvedTimeouts_Configured(t *testing.T) {
+	cfg := &Config{
+		SubAgentTimeout: Duration{10 * time.Minute},
+		Timeouts: Timeouts{
+			Tool:     Duration{2 * time.Minute},
+			SubAgent: Duration{20 * time.Minute},
+		},
+	}
+	got := cfg.ResolvedTimeouts()
+	if got.Tool.Duration != 2*time.Minute {
+		t.Errorf("Tool = %s, want 2m", got.Tool.Duration)
+	}
+	if got.SubAgent.Duration != 20*time.Minute {
+		t.Errorf("SubAgent = %s, want timeouts.sub_agent to win", got.SubAgent.Duration)
+	}
+	if got.LLM.Duration != DefaultLLMTimeout {
+		t.Errorf("LLM = %s, want default for omitted field", got.LLM.Duration)
+	}
+}
+
+func TestResolvedTimeouts_LegacySubAgentTimeout(t *testing.T) {
+	cfg := &Config{SubAgentTimeout: Duration{10 * time.Minute}}
+	if got := cfg.ResolvedTimeouts().SubAgent.Duration; got != 10*time.Minute {
+		t.Errorf("SubAgent = %s, want sub_agent_timeout fallback 10m", got)
+	}
+}
+
+func TestTimeoutsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		t       Timeouts
+		wantErr string
+	}{
+		{"zero", Timeouts{}, ""},
+		{"valid", Timeouts{LLM: Duration{time.Minute}, Poll: Duration{50 * time.Second}}, ""},
+		{"negative", Timeouts{Tool: Duration{-time.Second}}, "timeouts.tool must not be negative"},
+		{"poll too short", Timeouts{Poll: Duration{500 * time.Millisecond}}, "timeouts.poll must be between"},
+		{"poll too long", Timeouts{Poll: Duration{2 * time.Minute}}, "timeouts.poll must be between"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.t.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Validate() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoad_Timeouts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	data := `{"workspace":"/ws","timeouts":{"llm":"45s","poll":"20s","message":"10m"}}`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Timeouts.LLM.Duration != 45*time.Second || cfg.Timeouts.Poll.Duration != 20*time.Second || cfg.Timeouts.Message.Duration != 10*time.Minute {
+		t.Errorf("Timeouts = %+v", cfg.Timeouts)
+	}
+}
+
+func TestLoad_InvalidTimeouts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"timeouts":{"download":"-5s"}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "timeouts.download") {
+		t.Fatalf("Load() = %v, want timeouts.download error", err)
+	}
+}
+
+func TestSave_OmitsUnsetTimeouts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := Save(&Config{Workspace: "/ws"}, path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "timeouts") {
+		t.Errorf("saved config should omit unset timeouts:\n%s", data)
+	}
+
+	if err := Save(&Config{Timeouts: Timeouts{Tool: Duration{time.Minute}}}, path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	data, _ = os.ReadFile(path)
+	if !strings.Contains(string(data), `"tool": "1m0s"`) || strings.Contains(string(data), `"llm"`) {
+		t.Errorf("saved config should only contain set timeouts:\n%s", data)
+	}
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+)
+
+// DefaultToolsPath is the tools file used when tools_file is not set.
+const DefaultToolsPath = "tools.json"
+
+// ToolsConfig is the content of tools.json: the built-in tools to register and
+// their per-tool settings. Tools not listed are not registered.
+type ToolsConfig struct {
+	Tools []ToolSettings `json:"tools"`
+}
+
+// ToolSettings configures one built-in tool.
+type ToolSettings struct {
+	Name        string   `json:"name"`
+	Enabled     *bool    `json:"enabled,omitempty"`      // Register the tool (default true)
+	Timeout     Duration `json:"timeout,omitzero"`       // Time limit for one call (0 = tool default)
+	Confirm     bool     `json:"confirm,omitempty"`      // Require owner approval before each call
+	ExceptPaths []string `json:"except_paths,omitempty"` // With confirm, paths that don't need approval
+}
+
+// IsEnabled reports whether the tool should be registered.
+func (s ToolSettings) IsEnabled() bool {
+	return s.Enabled == nil || *s.Enabled
+}
+
+// LoadTools reads and parses a tools file. A missing file is not an error: it
+// returns nil, meaning every built-in tool is registered with default settings.
+func LoadTools(path string) (*ToolsConfig, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("config: load_tools: %w", err)
+	}
+	var tc ToolsConfig
+	if err := json.Unmarshal(data, &tc); err != nil {
+		return nil, fmt.Errorf("config: load_tools: unmarshal: %w", err)
+	}
+	for _, t := range tc.Tools {
+		if t.Name == "" {
+			return nil, fmt.Errorf("config: load_tools: tool entry without a name")
+		}
+		if t.Timeout.Duration < 0 {
+			return nil, fmt.Errorf("config: load_tools: %s: timeout must not be negative, got %s", t.Name, t.Timeout.Duration)
+		}
+	}
+	slog.Info("tools config loaded", "component", "config", "operation", "load_tools", "path", path, "tools", len(tc.Tools))
+	return &tc, nil
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeTools(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "tools.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadTools_Missing(t *testing.T) {
+	tc, err := LoadTools(filepath.Join(t.TempDir(), "tools.json"))
+	if err != nil || tc != nil {
+		t.Errorf("LoadTools(missing) = %v, %v; want nil, nil", tc, err)
+	}
+}
+
+func TestLoadTools_Parse(t *testing.T) {
+	path := writeTools(t, `{"tools": [
+		{"name": "read_file"},
+		{"name": "exec_command", "timeout": "2m", "confirm": true},
+		{"name": "write_file", "enabled": false}
+	]}`)
+
+	tc, err := LoadTools(path)
+	if err != nil {
+		t.Fatalf("LoadTools: %v", err)
+	}
+	if len(tc.Tools) != 3 {
+		t.Fatalf("tools = %d, want 3", len(tc.Tools))
+	}
+	if !tc.Tools[0].IsEnabled() || tc.Tools[2].IsEnabled() {
+		t.Errorf("enabled = %v/%v, want true/false", tc.Tools[0].IsEnabled(), tc.Tools[2].IsEnabled())
+	}
+	if exec := tc.Tools[1]; exec.Timeout.Duration != 2*time.Minute || !exec.Confirm {
+		t.Errorf("exec_command = %+v, want 2m timeout with confirm", exec)
+	}
+}
+
+func TestLoadTools_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"malformed":        `{"tools": [`,
+		"missing name":     `{"tools": [{"timeout": "1s"}]}`,
+		"negative timeout": `{"tools": [{"name": "list_dir", "timeout": "-1s"}]}`,
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := LoadTools(writeTools(t, content))
+			if err == nil || !strings.HasPrefix(err.Error(), "config: load_tools") {
+				t.Errorf("LoadTools error = %v, want config: load_tools error", err)
+			}
+		})
+	}
+}
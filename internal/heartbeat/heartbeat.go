@@ -6,6 +6,7 @@ import (
 	"log/slog"
 
 	"github.com/edouard/pureclaw/internal/llm"
+	"github.com/edouard/pureclaw/internal/platform"
 )
 
 // LLMClient abstracts the LLM provider for testability.
@@ -126,7 +127,15 @@ func (e *Executor) Execute(ctx context.Context, heartbeatContent string) error {
 }
 
 // alertOwners sends a notification to ALL owner IDs. Sender errors are logged but not fatal.
+// Nothing is sent when ctx is marked quiet; the alert is still logged to memory.
 func (e *Executor) alertOwners(ctx context.Context, content string) {
+	if platform.IsQuiet(ctx) {
+		slog.Info("owner away, heartbeat alert not sent",
+			"component", "heartbeat",
+			"operation", "alert",
+		)
+		return
+	}
 	for _, id := range e.ownerIDs {
 		slog.Info("sending heartbeat alert",
 			"component", "heartbeat",
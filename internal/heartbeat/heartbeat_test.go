@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/edouard/pureclaw/internal/llm"
+	"github.com/edouard/pureclaw/internal/platform"
 )
 
 // --- Test doubles ---
@@ -133,6 +134,22 @@ func TestExecute_AlertSent(t *testing.T) {
 	}
 }
 
+func TestExecute_QuietSkipsAlert(t *testing.T) {
+	s := &fakeSender{}
+	m := &fakeMemory{}
+	e := NewExecutor(&fakeLLM{resp: makeResp("message", "disk full")}, s, m, []int64{42})
+
+	if err := e.Execute(platform.WithQuiet(context.Background()), "Check disk space"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(s.sent) != 0 {
+		t.Errorf("sent = %+v, want no alert while quiet", s.sent)
+	}
+	if len(m.entries) != 1 || !strings.Contains(m.entries[0].content, "disk full") {
+		t.Errorf("memory = %+v, want the alert logged", m.entries)
+	}
+}
+
 func TestExecute_Silent(t *testing.T) {
 	l := &fakeLLM{resp: makeResp("noop", "all clear")}
 	s := &fakeSender{}
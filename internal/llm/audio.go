@@ -6,10 +6,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log/slog"
 	"mime/multipart"
 	"net/http"
 	"time"
+
+	"github.com/edouard/pureclaw/internal/platform"
 )
 
 // TranscriptionResponse is the JSON response from the Voxtral transcription API.
@@ -30,7 +31,7 @@ type TranscriptionUsage struct {
 // Transcribe sends audio data to the Voxtral transcription API and returns the transcribed text.
 // It uses multipart/form-data encoding and retries on retryable errors (429, 5xx).
 func (c *Client) Transcribe(ctx context.Context, audioData []byte, filename string) (string, error) {
-	slog.Debug("transcription request", "component", "llm", "operation", "transcribe", "model", c.model, "audio_size", len(audioData))
+	platform.Log(ctx).Debug("transcription request", "component", "llm", "operation", "transcribe", "model", c.model, "audio_size", len(audioData))
 
 	if len(audioData) == 0 {
 		return "", fmt.Errorf("llm: transcribe: empty audio data")
@@ -60,7 +61,7 @@ func (c *Client) Transcribe(ctx context.Context, audioData []byte, filename stri
 			return fmt.Errorf("llm: transcribe: create request: %w", err)
 		}
 		req.Header.Set("Content-Type", w.FormDataContentType())
-		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+		c.setAuth(req)
 
 		resp, err := httpDo(c.httpClient, req)
 		if err != nil {
@@ -74,11 +75,7 @@ func (c *Client) Transcribe(ctx context.Context, audioData []byte, filename stri
 		}
 
 		if resp.StatusCode != http.StatusOK {
-			httpErr := &httpError{
-				StatusCode: resp.StatusCode,
-				Endpoint:   "audio/transcriptions",
-				Body:       string(body),
-			}
+			httpErr := c.newHTTPError("audio/transcriptions", resp.StatusCode, body)
 			if httpErr.IsRetryable() {
 				return httpErr
 			}
@@ -94,7 +91,7 @@ func (c *Client) Transcribe(ctx context.Context, audioData []byte, filename stri
 
 		result = transcription.Text
 
-		slog.Info("transcription completed",
+		platform.Log(ctx).Info("transcription completed",
 			"component", "llm",
 			"operation", "transcribe",
 			"text_length", len(result),
@@ -1,11 +1,14 @@
 package llm
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/edouard/pureclaw/internal/platform"
@@ -26,45 +29,180 @@ var agentResponseSchema = json.RawMessage(`{
 	"additionalProperties": false
 }`)
 
-// ChatCompletion sends a chat completion request to the Mistral API.
-// When tools are provided, response_format is omitted (Mistral rejects structured output + tools).
-// When no tools are provided, response_format uses json_schema with strict enforcement.
-func (c *Client) ChatCompletion(ctx context.Context, messages []Message, tools []Tool) (*ChatResponse, error) {
-	slog.Debug("chat completion request", "component", "llm", "operation", "chat_completion", "model", c.model)
+// attachmentsResponseSchema extends agentResponseSchema with an optional list of
+// workspace files to upload alongside a message (see SetAttachments).
+var attachmentsResponseSchema = json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"type": {"type": "string", "enum": ["message", "think", "noop"]},
+		"content": {"type": "string"},
+		"attachments": {"type": "array", "items": {"type": "string"}}
+	},
+	"required": ["type", "content"],
+	"additionalProperties": false
+}`)
 
-	req := ChatRequest{
-		Model:    c.model,
-		Messages: messages,
-	}
+// Structured output modes accepted by SetResponseFormat.
+const (
+	ResponseFormatJSONSchema = "json_schema" // strict schema enforcement (default)
+	ResponseFormatJSONObject = "json_object" // any JSON object
+	ResponseFormatNone       = "none"        // no response_format; the prompt alone describes the schema
+)
 
-	if len(tools) > 0 {
-		req.Tools = tools
-		req.ToolChoice = "auto"
-	} else {
-		req.ResponseFormat = &ResponseFormat{
+// SetResponseFormat selects how responses without tools are constrained to the
+// agent schema: json_schema, json_object, or none. "" uses the provider's
+// default: json_schema, or json_object for Ollama.
+// With none, ParseAgentResponse's tolerance for surrounding text or fences
+// recovers the JSON the prompt asks for.
+func (c *Client) SetResponseFormat(format string) {
+	c.responseFormat = format
+}
+
+// SetAttachments extends the enforced agent schema with the optional attachments field.
+func (c *Client) SetAttachments(on bool) {
+	c.attachments = on
+}
+
+// SetAssistantPrefix seeds every response without tools with prefix (e.g. "{"),
+// sent as a trailing assistant message the model must continue, which nudges
+// it into JSON. The prefix is joined back onto the reply before it is parsed.
+// An empty prefix disables it. Requests with tools are never seeded, since
+// the prefix would keep the model from calling them. Only Mistral accepts
+// prefixes; the client leaves them out for other providers.
+func (c *Client) SetAssistantPrefix(prefix string) {
+	c.prefix = prefix
+}
+
+// usesPrefix reports whether requests without tools are seeded with the
+// assistant prefix: one is set and the provider accepts it.
+func (c *Client) usesPrefix() bool {
+	return c.prefix != "" && c.spec().prefix
+}
+
+// responseFormatField returns the response_format to send for a request without
+// tools, or nil when it must be omitted.
+func (c *Client) responseFormatField() *ResponseFormat {
+	if c.formatDropped.Load() {
+		return nil
+	}
+	switch cmp.Or(c.responseFormat, c.spec().responseFormat) {
+	case ResponseFormatNone:
+		return nil
+	case ResponseFormatJSONObject:
+		return &ResponseFormat{Type: "json_object"}
+	default:
+		schema := agentResponseSchema
+		if c.attachments {
+			schema = attachmentsResponseSchema
+		}
+		return &ResponseFormat{
 			Type: "json_schema",
 			JSONSchema: &JSONSchema{
 				Name:        "agent_response",
 				Description: "Agent response with type and content fields",
-				Schema:      agentResponseSchema,
+				Schema:      schema,
 				Strict:      true,
 			},
 		}
 	}
+}
 
-	data, err := c.doPost(ctx, "chat/completions", req)
+// ChatCompletion sends a chat completion request to the Mistral API, adapted to
+// the model's tool-call quirks (see SetModelQuirks); every tool call in the
+// response has a type.
+// When tools are provided, response_format is omitted (Mistral rejects structured output + tools).
+// When no tools are provided, response_format follows SetResponseFormat. If the
+// provider rejects response_format as unsupported, the client drops it for good
+// and resends the request once without it.
+func (c *Client) ChatCompletion(ctx context.Context, messages []Message, tools []Tool) (*ChatResponse, error) {
+	model := c.Model()
+	platform.Log(ctx).Debug("chat completion request", "component", "llm", "operation", "chat_completion", "model", model)
+
+	quirks := c.quirksFor(model)
+	req := c.chatRequest(model, quirks, messages, tools)
+
+	started := time.Now()
+	data, err := c.doPost(ctx, "chat/completions", quirks.adaptRequest(req))
+	if c.dropRejectedFormat(ctx, "chat_completion", &req, err) {
+		started = time.Now()
+		data, err = c.doPost(ctx, "chat/completions", quirks.adaptRequest(req))
+	}
 	if err != nil {
 		return nil, err
 	}
+	c.latency.observe(model, time.Since(started))
 
 	var resp ChatResponse
 	if err := json.Unmarshal(data, &resp); err != nil {
 		return nil, fmt.Errorf("llm: chat/completions: unmarshal response: %w", err)
 	}
+	// Providers may route an alias or fall back to another model; keep the one
+	// they report, or the requested one if they don't say.
+	if resp.Model == "" {
+		resp.Model = model
+	}
+	c.usage.add(resp.Usage)
+	quirks.normalizeResponse(&resp)
+	if len(tools) == 0 && c.usesPrefix() {
+		for i := range resp.Choices {
+			resp.Choices[i].Message.Content = withPrefix(c.prefix, resp.Choices[i].Message.Content)
+		}
+	}
 
 	return &resp, nil
 }
 
+// chatRequest builds the chat completion request for messages and tools:
+// tools come with the model's tool_choice, while requests without tools get
+// the response_format and assistant prefix in effect.
+func (c *Client) chatRequest(model string, quirks ModelQuirks, messages []Message, tools []Tool) ChatRequest {
+	req := ChatRequest{
+		Model:       model,
+		Messages:    messages,
+		Temperature: c.sampling.Temperature,
+		MaxTokens:   c.sampling.MaxTokens,
+	}
+
+	if len(tools) > 0 {
+		req.Tools = tools
+		req.ToolChoice = quirks.toolChoice()
+	} else {
+		req.ResponseFormat = c.responseFormatField()
+		if c.usesPrefix() {
+			req.Messages = append(slices.Clip(messages), Message{Role: "assistant", Content: c.prefix, Prefix: true})
+		}
+	}
+	return req
+}
+
+// dropRejectedFormat reports whether err is the provider rejecting req's
+// response_format. If so, the client drops response_format for good and
+// removes it from req, which the caller then resends.
+func (c *Client) dropRejectedFormat(ctx context.Context, operation string, req *ChatRequest, err error) bool {
+	if err == nil || req.ResponseFormat == nil || !isUnsupportedResponseFormat(err) {
+		return false
+	}
+	platform.Log(ctx).Warn("provider rejected response_format, continuing without it",
+		"component", "llm",
+		"operation", operation,
+		"model", req.Model,
+		"response_format", req.ResponseFormat.Type,
+		"error", err,
+	)
+	c.formatDropped.Store(true)
+	req.ResponseFormat = nil
+	return true
+}
+
+// withPrefix joins an assistant prefix back onto the model's continuation.
+// Providers that echo the prefix in the reply are left as is.
+func withPrefix(prefix, content string) string {
+	if strings.HasPrefix(content, prefix) {
+		return content
+	}
+	return prefix + content
+}
+
 // ChatCompletionWithRetry wraps ChatCompletion with retry on transient HTTP errors.
 // It retries up to 3 times with exponential backoff starting at 1s.
 // Note: ParseAgentResponse handles non-JSON text gracefully via fallback,
@@ -99,3 +237,15 @@ func (c *Client) ChatCompletionWithRetry(ctx context.Context, messages []Message
 func HasToolCalls(choice *Choice) bool {
 	return choice.FinishReason == "tool_calls" && len(choice.Message.ToolCalls) > 0
 }
+
+// isUnsupportedResponseFormat reports whether err is a provider's rejection of the
+// response_format field (as opposed to any other bad request).
+func isUnsupportedResponseFormat(err error) bool {
+	var he *httpError
+	if !errors.As(err, &he) || (he.StatusCode != http.StatusBadRequest && he.StatusCode != http.StatusUnprocessableEntity) {
+		return false
+	}
+	body := strings.ToLower(he.Body)
+	return strings.Contains(body, "response_format") &&
+		(strings.Contains(body, "not supported") || strings.Contains(body, "unsupported") || strings.Contains(body, "not available"))
+}
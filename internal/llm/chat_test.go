@@ -242,6 +242,201 @@ func TestChatCompletion_ResponseFormatInRequest(t *testing.T) {
 	}
 }
 
+// formatRecorder serves chat completions and records the response_format raw JSON of each request.
+type formatRecorder struct {
+	formats []json.RawMessage
+	reject  string // body of a 400 returned while response_format is present
+}
+
+func (f *formatRecorder) handler(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]json.RawMessage
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		format, present := req["response_format"]
+		f.formats = append(f.formats, format)
+		if present && f.reject != "" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(f.reject))
+			return
+		}
+		json.NewEncoder(w).Encode(ChatResponse{Choices: []Choice{{
+			Message:      Message{Role: "assistant", Content: "```json\n{\"type\":\"message\",\"content\":\"hi\"}\n```"},
+			FinishReason: "stop",
+		}}})
+	}
+}
+
+func TestChatCompletion_ResponseFormatModes(t *testing.T) {
+	tests := []struct {
+		format string
+		want   string // response_format type, "" = field omitted
+	}{
+		{"", "json_schema"},
+		{ResponseFormatJSONSchema, "json_schema"},
+		{ResponseFormatJSONObject, "json_object"},
+		{ResponseFormatNone, ""},
+	}
+	for _, tt := range tests {
+		t.Run("format="+tt.format, func(t *testing.T) {
+			rec := &formatRecorder{}
+			srv := httptest.NewServer(rec.handler(t))
+			defer srv.Close()
+			client := newTestClient(t, srv)
+			client.SetResponseFormat(tt.format)
+
+			resp, err := client.ChatCompletion(context.Background(), []Message{{Role: "user", Content: "hi"}}, nil)
+			if err != nil {
+				t.Fatalf("ChatCompletion: %v", err)
+			}
+			var got struct{ Type string }
+			if rec.formats[0] != nil {
+				json.Unmarshal(rec.formats[0], &got)
+			}
+			if got.Type != tt.want {
+				t.Errorf("response_format = %s, want type %q", rec.formats[0], tt.want)
+			}
+			// Fenced JSON is still understood when nothing enforces the format.
+			parsed, _ := ParseAgentResponse(resp.Choices[0].Message.Content)
+			if parsed.Type != "message" || parsed.Content != "hi" {
+				t.Errorf("parsed = %+v, want message hi", parsed)
+			}
+		})
+	}
+}
+
+func TestChatCompletion_AttachmentsSchema(t *testing.T) {
+	for _, on := range []bool{false, true} {
+		rec := &formatRecorder{}
+		srv := httptest.NewServer(rec.handler(t))
+		client := newTestClient(t, srv)
+		client.SetAttachments(on)
+
+		if _, err := client.ChatCompletion(context.Background(), []Message{{Role: "user", Content: "hi"}}, nil); err != nil {
+			t.Fatalf("ChatCompletion: %v", err)
+		}
+		srv.Close()
+		if got := strings.Contains(string(rec.formats[0]), `"attachments"`); got != on {
+			t.Errorf("SetAttachments(%v): schema %s mentions attachments = %v", on, rec.formats[0], got)
+		}
+	}
+}
+
+// prefixServer records each request and answers with reply.
+func prefixServer(t *testing.T, reply string, got *ChatRequest) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(got); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		json.NewEncoder(w).Encode(ChatResponse{Choices: []Choice{{
+			Message:      Message{Role: "assistant", Content: reply},
+			FinishReason: "stop",
+		}}})
+	}))
+}
+
+func TestChatCompletion_AssistantPrefix(t *testing.T) {
+	var got ChatRequest
+	srv := prefixServer(t, `"type":"message","content":"hi"}`, &got)
+	defer srv.Close()
+	client := newTestClient(t, srv)
+	client.SetAssistantPrefix("{")
+
+	messages := []Message{{Role: "user", Content: "hi"}}
+	resp, err := client.ChatCompletion(context.Background(), messages, nil)
+	if err != nil {
+		t.Fatalf("ChatCompletion: %v", err)
+	}
+	if len(got.Messages) != 2 {
+		t.Fatalf("sent %d messages, want the user message plus the prefix", len(got.Messages))
+	}
+	if last := got.Messages[1]; last.Role != "assistant" || last.Content != "{" || !last.Prefix {
+		t.Errorf("last message = %+v, want assistant prefix {", last)
+	}
+	if len(messages) != 1 {
+		t.Error("the caller's messages must not be modified")
+	}
+	parsed, err := ParseAgentResponse(resp.Choices[0].Message.Content)
+	if err != nil || parsed.Type != "message" || parsed.Content != "hi" {
+		t.Errorf("parsed = %+v, %v; want message hi from the prefix plus continuation", parsed, err)
+	}
+}
+
+func TestChatCompletion_AssistantPrefixEchoed(t *testing.T) {
+	var got ChatRequest
+	srv := prefixServer(t, `{"type":"noop","content":""}`, &got)
+	defer srv.Close()
+	client := newTestClient(t, srv)
+	client.SetAssistantPrefix("{")
+
+	resp, err := client.ChatCompletion(context.Background(), []Message{{Role: "user", Content: "hi"}}, nil)
+	if err != nil {
+		t.Fatalf("ChatCompletion: %v", err)
+	}
+	if content := resp.Choices[0].Message.Content; content != `{"type":"noop","content":""}` {
+		t.Errorf("content = %q, want the echoed prefix kept once", content)
+	}
+}
+
+func TestChatCompletion_AssistantPrefixOff(t *testing.T) {
+	for _, tools := range [][]Tool{nil, {{Type: "function", Function: ToolFunction{Name: "read_file"}}}} {
+		var got ChatRequest
+		srv := prefixServer(t, `{"type":"message","content":"hi"}`, &got)
+		client := newTestClient(t, srv)
+		if tools != nil {
+			client.SetAssistantPrefix("{")
+		}
+
+		resp, err := client.ChatCompletion(context.Background(), []Message{{Role: "user", Content: "hi"}}, tools)
+		srv.Close()
+		if err != nil {
+			t.Fatalf("ChatCompletion: %v", err)
+		}
+		if len(got.Messages) != 1 {
+			t.Errorf("tools=%d: sent %d messages, want no prefix", len(tools), len(got.Messages))
+		}
+		if content := resp.Choices[0].Message.Content; content != `{"type":"message","content":"hi"}` {
+			t.Errorf("tools=%d: content = %q, want it unchanged", len(tools), content)
+		}
+	}
+}
+
+func TestChatCompletion_DowngradesUnsupportedResponseFormat(t *testing.T) {
+	rec := &formatRecorder{reject: `{"message":"response_format is not supported for this model"}`}
+	srv := httptest.NewServer(rec.handler(t))
+	defer srv.Close()
+	client := newTestClient(t, srv)
+
+	for range 2 {
+		if _, err := client.ChatCompletion(context.Background(), []Message{{Role: "user", Content: "hi"}}, nil); err != nil {
+			t.Fatalf("ChatCompletion: %v", err)
+		}
+	}
+
+	// First call: rejected with response_format, resent without. Second call: omitted from the start.
+	if len(rec.formats) != 3 {
+		t.Fatalf("requests = %d, want 3", len(rec.formats))
+	}
+	if rec.formats[0] == nil || rec.formats[1] != nil || rec.formats[2] != nil {
+		t.Errorf("response_format per request = %q, want present, omitted, omitted", rec.formats)
+	}
+}
+
+func TestChatCompletion_OtherBadRequestNotDowngraded(t *testing.T) {
+	rec := &formatRecorder{reject: `{"message":"invalid model"}`}
+	srv := httptest.NewServer(rec.handler(t))
+	defer srv.Close()
+	client := newTestClient(t, srv)
+
+	if _, err := client.ChatCompletion(context.Background(), []Message{{Role: "user", Content: "hi"}}, nil); err == nil {
+		t.Fatal("expected error")
+	}
+	if len(rec.formats) != 1 || client.formatDropped.Load() {
+		t.Errorf("unrelated 400 triggered a downgrade: %d requests", len(rec.formats))
+	}
+}
+
 func TestChatCompletion_HTTPError(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -619,3 +814,63 @@ func TestHasToolCalls(t *testing.T) {
 		})
 	}
 }
+
+func TestChatCompletion_ReportsAnsweringModel(t *testing.T) {
+	for _, tt := range []struct{ reported, want string }{
+		{"mistral-small-2409", "mistral-small-2409"}, // provider fell back to another model
+		{"", "test-model"},                           // provider doesn't say: the requested model
+	} {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			json.NewEncoder(w).Encode(ChatResponse{
+				ID:      "chat-1",
+				Model:   tt.reported,
+				Choices: []Choice{{Message: Message{Role: "assistant", Content: "hi"}, FinishReason: "stop"}},
+			})
+		}))
+		client := newTestClient(t, srv)
+
+		resp, err := client.ChatCompletion(context.Background(), []Message{{Role: "user", Content: "Hi"}}, nil)
+		srv.Close()
+		if err != nil {
+			t.Fatalf("ChatCompletion: %v", err)
+		}
+		if resp.Model != tt.want {
+			t.Errorf("reported %q: Model = %q, want %q", tt.reported, resp.Model, tt.want)
+		}
+	}
+}
+
+func TestChatCompletion_SetModel(t *testing.T) {
+	var models []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ChatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		models = append(models, req.Model)
+		json.NewEncoder(w).Encode(ChatResponse{Choices: []Choice{{Message: Message{Content: "hi"}, FinishReason: "stop"}}})
+	}))
+	defer srv.Close()
+	client := newTestClient(t, srv)
+	msgs := []Message{{Role: "user", Content: "hi"}}
+
+	if _, err := client.ChatCompletion(context.Background(), msgs, nil); err != nil {
+		t.Fatalf("ChatCompletion: %v", err)
+	}
+	client.SetModel("other-model")
+	if got := client.Model(); got != "other-model" {
+		t.Errorf("Model() = %q, want other-model", got)
+	}
+	if _, ok := client.Latency(); ok {
+		t.Error("Latency should have no estimate for a model not used yet")
+	}
+	resp, err := client.ChatCompletion(context.Background(), msgs, nil)
+	if err != nil {
+		t.Fatalf("ChatCompletion: %v", err)
+	}
+
+	if len(models) != 2 || models[0] != "test-model" || models[1] != "other-model" {
+		t.Errorf("requested models = %v, want [test-model other-model]", models)
+	}
+	if resp.Model != "other-model" {
+		t.Errorf("resp.Model = %q, want other-model", resp.Model)
+	}
+}
@@ -6,9 +6,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log/slog"
 	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/edouard/pureclaw/internal/platform"
 )
 
 // httpDo is a package-level variable for testability.
@@ -16,12 +20,27 @@ var httpDo = func(client *http.Client, req *http.Request) (*http.Response, error
 	return client.Do(req)
 }
 
-// Client is an HTTP client wrapper for the Mistral API.
+// Client is an HTTP client wrapper for the Mistral API, or another provider's
+// compatible API (see SetProvider).
 type Client struct {
 	apiKey     string
 	baseURL    string
+	provider   string // provider kind, see SetProvider ("" = mistral)
 	model      string
+	modelMu    sync.RWMutex // guards model, see SetModel
 	httpClient *http.Client
+
+	responseFormat string      // structured output mode, see SetResponseFormat
+	formatDropped  atomic.Bool // set once the provider rejected response_format
+	attachments    bool        // advertise the attachments field in the agent schema
+	prefix         string      // assistant prefix seeding responses without tools, see SetAssistantPrefix
+
+	slots chan struct{} // bounds in-flight requests, see SetMaxConcurrent (nil = unlimited)
+
+	quirks   map[string]ModelQuirks // tool-call format adaptations by model, see SetModelQuirks
+	sampling Sampling               // sampling parameters sent with chat completions, see SetSampling
+	latency  latencyTracker         // rolling chat completion latency per model, see Latency
+	usage    usageTracker           // token usage totals, see Info
 }
 
 // httpError represents an HTTP error response from the Mistral API.
@@ -29,61 +48,154 @@ type httpError struct {
 	StatusCode int
 	Endpoint   string
 	Body       string
+
+	retryStatuses []int // statuses below 500 worth retrying (nil = 429 only)
 }
 
 func (e *httpError) Error() string {
 	return fmt.Sprintf("llm: %s: status %d: %s", e.Endpoint, e.StatusCode, e.Body)
 }
 
-// IsRetryable returns true for 429 (rate limit) and 5xx (server error) status codes.
+// IsRetryable returns true for 5xx (server error) status codes and those the
+// provider marks as transient, 429 (rate limit) by default.
 func (e *httpError) IsRetryable() bool {
-	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+	if e.StatusCode >= 500 {
+		return true
+	}
+	if e.retryStatuses == nil {
+		return e.StatusCode == http.StatusTooManyRequests
+	}
+	return slices.Contains(e.retryStatuses, e.StatusCode)
 }
 
-// NewClient creates a new Mistral API client with HTTPS base URL and 30s timeout.
+// defaultHTTPTimeout bounds each Mistral request.
 // Mistral chat completions can take 10-20s on complex prompts with tools;
 // 30s provides headroom for slow networks (e.g. Raspberry Pi).
+const defaultHTTPTimeout = 30 * time.Second
+
+// NewClient creates a new Mistral API client with HTTPS base URL and 30s timeout.
 func NewClient(apiKey, model string) *Client {
+	return NewClientWithTimeout(apiKey, model, defaultHTTPTimeout)
+}
+
+// NewClientWithTimeout creates a Mistral API client whose requests time out after timeout.
+// A non-positive timeout uses the 30s default.
+func NewClientWithTimeout(apiKey, model string, timeout time.Duration) *Client {
+	if timeout <= 0 {
+		timeout = defaultHTTPTimeout
+	}
 	return &Client{
 		apiKey:  apiKey,
-		baseURL: "https://api.mistral.ai/v1/",
+		baseURL: providerSpecs[ProviderMistral].baseURL,
 		model:   model,
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout: timeout,
 		},
 	}
 }
 
+// Model returns the model used for chat completions.
+func (c *Client) Model() string {
+	c.modelMu.RLock()
+	defer c.modelMu.RUnlock()
+	return c.model
+}
+
+// SetModel switches the model used by subsequent chat completions. Requests
+// already in flight keep the model they started with. Safe for concurrent use.
+func (c *Client) SetModel(model string) {
+	c.modelMu.Lock()
+	defer c.modelMu.Unlock()
+	c.model = model
+}
+
+// SetMaxConcurrent bounds the requests this client has in flight at once, so
+// concurrent chats do not trip the provider's concurrency limit. Excess requests
+// wait for a free slot or for their context to end. n <= 0 means unlimited.
+// It must be called before the client is used.
+func (c *Client) SetMaxConcurrent(n int) {
+	if n <= 0 {
+		c.slots = nil
+		return
+	}
+	c.slots = make(chan struct{}, n)
+}
+
+// acquire waits for a request slot and returns the function releasing it.
+func (c *Client) acquire(ctx context.Context, endpoint string) (func(), error) {
+	if c.slots == nil {
+		return func() {}, nil
+	}
+	select {
+	case c.slots <- struct{}{}:
+		return func() { <-c.slots }, nil
+	default:
+	}
+	platform.Log(ctx).Debug("waiting for a free LLM request slot",
+		"component", "llm", "operation", endpoint, "max_concurrent", cap(c.slots))
+	select {
+	case c.slots <- struct{}{}:
+		return func() { <-c.slots }, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("llm: %s: waiting for request slot: %w", endpoint, ctx.Err())
+	}
+}
+
 // doPost sends a POST request with a JSON body to the given Mistral API endpoint.
 func (c *Client) doPost(ctx context.Context, endpoint string, body any) ([]byte, error) {
-	slog.Debug("mistral API POST", "component", "llm", "operation", endpoint)
+	resp, release, err := c.post(ctx, c.httpClient, endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("llm: %s: read body: %w", endpoint, err)
+	}
+	return respBody, nil
+}
+
+// post sends a POST request with a JSON body to the given Mistral API endpoint
+// through client and returns the response once its status is 200 OK. The
+// caller must close the response body and then call release to free the
+// request slot.
+func (c *Client) post(ctx context.Context, client *http.Client, endpoint string, body any) (*http.Response, func(), error) {
+	platform.Log(ctx).Debug("mistral API POST", "component", "llm", "operation", endpoint)
 
 	data, err := json.Marshal(body)
 	if err != nil {
-		return nil, fmt.Errorf("llm: %s: marshal: %w", endpoint, err)
+		return nil, nil, fmt.Errorf("llm: %s: marshal: %w", endpoint, err)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+endpoint, bytes.NewReader(data))
 	if err != nil {
-		return nil, fmt.Errorf("llm: %s: request: %w", endpoint, err)
+		return nil, nil, fmt.Errorf("llm: %s: request: %w", endpoint, err)
 	}
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	c.setAuth(req)
 
-	resp, err := httpDo(c.httpClient, req)
+	release, err := c.acquire(ctx, endpoint)
 	if err != nil {
-		return nil, fmt.Errorf("llm: %s: %w", endpoint, err)
+		return nil, nil, err
 	}
-	defer resp.Body.Close()
 
-	respBody, err := io.ReadAll(resp.Body)
+	resp, err := httpDo(client, req)
 	if err != nil {
-		return nil, fmt.Errorf("llm: %s: read body: %w", endpoint, err)
+		release()
+		return nil, nil, fmt.Errorf("llm: %s: %w", endpoint, err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, &httpError{StatusCode: resp.StatusCode, Endpoint: endpoint, Body: string(respBody)}
+		defer release()
+		defer resp.Body.Close()
+		respBody, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, nil, fmt.Errorf("llm: %s: read body: %w", endpoint, err)
+		}
+		return nil, nil, c.newHTTPError(endpoint, resp.StatusCode, respBody)
 	}
 
-	return respBody, nil
+	return resp, release, nil
 }
@@ -8,7 +8,10 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestNewClient(t *testing.T) {
@@ -338,3 +341,80 @@ func TestHTTPDo_Default(t *testing.T) {
 		t.Errorf("status = %d, want 200", resp.StatusCode)
 	}
 }
+
+func TestNewClientWithTimeout(t *testing.T) {
+	c := NewClientWithTimeout("key", "model", 90*time.Second)
+	if c.httpClient.Timeout != 90*time.Second {
+		t.Errorf("Timeout = %v, want 90s", c.httpClient.Timeout)
+	}
+	if c := NewClientWithTimeout("key", "model", 0); c.httpClient.Timeout != 30*time.Second {
+		t.Errorf("zero timeout: Timeout = %v, want 30s default", c.httpClient.Timeout)
+	}
+}
+
+func TestClient_MaxConcurrent(t *testing.T) {
+	const limit, calls = 2, 8
+	var active, peak atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := active.Add(1)
+		defer active.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte(`{"id":"chat-1","choices":[{"index":0,"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}],"usage":{}}`))
+	}))
+	defer srv.Close()
+
+	client := &Client{apiKey: "k", baseURL: srv.URL + "/", model: "m", httpClient: srv.Client()}
+	client.SetMaxConcurrent(limit)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, calls)
+	for range calls {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.ChatCompletion(context.Background(), []Message{{Role: "user", Content: "hi"}}, nil)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("ChatCompletion: %v", err)
+		}
+	}
+	if p := peak.Load(); p > limit {
+		t.Errorf("peak in-flight requests = %d, want at most %d", p, limit)
+	}
+	if p := peak.Load(); p < limit {
+		t.Errorf("peak in-flight requests = %d, want the limit reached (requests should run in parallel)", p)
+	}
+}
+
+func TestClient_MaxConcurrent_QueuedRequestHonoursContext(t *testing.T) {
+	client := &Client{apiKey: "k", baseURL: "http://localhost/", model: "m", httpClient: &http.Client{}}
+	client.SetMaxConcurrent(1)
+	client.slots <- struct{}{} // occupy the only slot
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := client.doPost(ctx, "chat/completions", map[string]string{})
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "waiting for request slot") {
+		t.Errorf("err = %v, want a deadline error while queued", err)
+	}
+}
+
+func TestClient_SetMaxConcurrent_Unlimited(t *testing.T) {
+	client := &Client{}
+	client.SetMaxConcurrent(3)
+	client.SetMaxConcurrent(0)
+	if client.slots != nil {
+		t.Error("slots set, want unlimited for n <= 0")
+	}
+}
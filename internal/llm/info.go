@@ -0,0 +1,79 @@
+package llm
+
+import (
+	"sync"
+	"time"
+)
+
+// Sampling holds the sampling parameters sent with chat completions. Nil
+// fields are left to the provider's defaults.
+type Sampling struct {
+	Temperature *float64
+	MaxTokens   *int
+}
+
+// SetSampling sets the sampling parameters sent with every chat completion.
+// It must be called before the client is used.
+func (c *Client) SetSampling(s Sampling) {
+	c.sampling = s
+}
+
+// UsageTotals accumulates the token usage reported for a client's chat completions.
+type UsageTotals struct {
+	Requests         int
+	PromptTokens     int
+	CompletionTokens int
+	TotalTokens      int
+	Last             Usage // usage of the most recent completion
+}
+
+// usageTracker sums token usage across concurrent chat completions.
+type usageTracker struct {
+	mu     sync.Mutex
+	totals UsageTotals
+}
+
+func (t *usageTracker) add(u Usage) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.totals.Requests++
+	t.totals.PromptTokens += u.PromptTokens
+	t.totals.CompletionTokens += u.CompletionTokens
+	t.totals.TotalTokens += u.TotalTokens
+	t.totals.Last = u
+}
+
+func (t *usageTracker) snapshot() UsageTotals {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.totals
+}
+
+// Info is a snapshot of a client's effective configuration and usage, for diagnostics.
+type Info struct {
+	Provider       string
+	BaseURL        string
+	Model          string
+	ResponseFormat string // structured output mode in effect: json_schema, json_object or none
+	Sampling       Sampling
+	Latency        time.Duration // rolling chat completion latency of Model (0 before the first one)
+	Usage          UsageTotals   // since the client was created
+}
+
+// Info returns the client's current configuration and usage totals.
+func (c *Client) Info() Info {
+	format := ResponseFormatNone
+	if rf := c.responseFormatField(); rf != nil {
+		format = rf.Type
+	}
+	latency, _ := c.Latency()
+	return Info{
+		Provider:       c.providerKind(),
+		BaseURL:        c.baseURL,
+		Model:          c.Model(),
+		ResponseFormat: format,
+		Sampling:       c.sampling,
+		Latency:        latency,
+		Usage:          c.usage.snapshot(),
+	}
+}
@@ -0,0 +1,83 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientInfo_ConfigurationAndUsage(t *testing.T) {
+	var bodies []map[string]any
+	usages := []Usage{
+		{PromptTokens: 100, CompletionTokens: 20, TotalTokens: 120},
+		{PromptTokens: 150, CompletionTokens: 30, TotalTokens: 180},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		bodies = append(bodies, body)
+		json.NewEncoder(w).Encode(ChatResponse{
+			Choices: []Choice{{Message: Message{Content: "hi"}, FinishReason: "stop"}},
+			Usage:   usages[len(bodies)-1],
+		})
+	}))
+	defer srv.Close()
+	client := newTestClient(t, srv)
+	client.SetResponseFormat(ResponseFormatJSONObject)
+	temperature, maxTokens := 0.3, 512
+	client.SetSampling(Sampling{Temperature: &temperature, MaxTokens: &maxTokens})
+
+	info := client.Info()
+	if info.Usage.Requests != 0 || info.Latency != 0 {
+		t.Errorf("fresh client info = %+v, want no usage or latency", info)
+	}
+
+	msgs := []Message{{Role: "user", Content: "hi"}}
+	for range usages {
+		if _, err := client.ChatCompletion(context.Background(), msgs, nil); err != nil {
+			t.Fatalf("ChatCompletion: %v", err)
+		}
+	}
+	if bodies[0]["temperature"] != 0.3 || bodies[0]["max_tokens"] != 512.0 {
+		t.Errorf("request sampling = %v/%v, want 0.3/512", bodies[0]["temperature"], bodies[0]["max_tokens"])
+	}
+
+	info = client.Info()
+	if info.Provider != ProviderMistral || info.BaseURL != srv.URL+"/" || info.Model != "test-model" {
+		t.Errorf("info = %s %s %s, want the client's provider, base URL and model", info.Provider, info.BaseURL, info.Model)
+	}
+	if info.ResponseFormat != "json_object" {
+		t.Errorf("ResponseFormat = %q, want json_object", info.ResponseFormat)
+	}
+	if *info.Sampling.Temperature != 0.3 || *info.Sampling.MaxTokens != 512 {
+		t.Errorf("Sampling = %v/%v, want 0.3/512", *info.Sampling.Temperature, *info.Sampling.MaxTokens)
+	}
+	if info.Latency <= 0 {
+		t.Errorf("Latency = %v, want a positive estimate", info.Latency)
+	}
+	want := UsageTotals{Requests: 2, PromptTokens: 250, CompletionTokens: 50, TotalTokens: 300, Last: usages[1]}
+	if info.Usage != want {
+		t.Errorf("Usage = %+v, want %+v", info.Usage, want)
+	}
+}
+
+func TestChatCompletion_NoSamplingByDefault(t *testing.T) {
+	var body map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+		json.NewEncoder(w).Encode(ChatResponse{Choices: []Choice{{Message: Message{Content: "hi"}, FinishReason: "stop"}}})
+	}))
+	defer srv.Close()
+	client := newTestClient(t, srv)
+
+	if _, err := client.ChatCompletion(context.Background(), []Message{{Role: "user", Content: "hi"}}, nil); err != nil {
+		t.Fatalf("ChatCompletion: %v", err)
+	}
+	for _, key := range []string{"temperature", "max_tokens"} {
+		if _, ok := body[key]; ok {
+			t.Errorf("request sets %s without sampling configured", key)
+		}
+	}
+}
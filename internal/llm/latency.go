@@ -0,0 +1,46 @@
+package llm
+
+import (
+	"sync"
+	"time"
+)
+
+// latencyWeight is the share of the newest sample in the rolling latency estimate.
+const latencyWeight = 0.2
+
+// latencyTracker keeps an exponentially weighted moving average of chat
+// completion latency per model.
+type latencyTracker struct {
+	mu       sync.Mutex
+	estimate map[string]time.Duration
+}
+
+// observe folds one completion's latency into the model's estimate. The first
+// sample seeds it.
+func (t *latencyTracker) observe(model string, d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.estimate == nil {
+		t.estimate = make(map[string]time.Duration)
+	}
+	prev, ok := t.estimate[model]
+	if !ok {
+		t.estimate[model] = d
+		return
+	}
+	t.estimate[model] = prev + time.Duration(latencyWeight*float64(d-prev))
+}
+
+// get returns the model's estimate, or false before any completion was observed.
+func (t *latencyTracker) get(model string) (time.Duration, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	d, ok := t.estimate[model]
+	return d, ok
+}
+
+// Latency returns the rolling estimate of how long a chat completion with this
+// client's model takes, or false before one has succeeded.
+func (c *Client) Latency() (time.Duration, bool) {
+	return c.latency.get(c.Model())
+}
@@ -0,0 +1,57 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLatencyTracker_RollingEstimate(t *testing.T) {
+	c := &Client{model: "fast-model"}
+	if _, ok := c.Latency(); ok {
+		t.Fatal("Latency should report no estimate before any completion")
+	}
+
+	const threshold = 2 * time.Second
+	c.latency.observe("fast-model", 200*time.Millisecond)
+	if d, _ := c.Latency(); d != 200*time.Millisecond {
+		t.Fatalf("Latency = %v, want the first sample", d)
+	}
+
+	// Slow samples pull the estimate up gradually rather than at once.
+	crossedAfter := 0
+	for i := 1; i <= 20; i++ {
+		c.latency.observe("fast-model", 5*time.Second)
+		if d, _ := c.Latency(); d >= threshold {
+			crossedAfter = i
+			break
+		}
+	}
+	if crossedAfter < 2 || crossedAfter > 5 {
+		t.Errorf("estimate crossed %v after %d slow samples, want a few", threshold, crossedAfter)
+	}
+
+	// Estimates are kept per model.
+	c.latency.observe("other-model", time.Minute)
+	if d, _ := c.Latency(); d >= time.Minute {
+		t.Errorf("Latency = %v, want other models' samples ignored", d)
+	}
+}
+
+func TestChatCompletion_RecordsLatency(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(ChatResponse{Choices: []Choice{{Message: Message{Content: "hi"}, FinishReason: "stop"}}})
+	}))
+	defer srv.Close()
+	client := newTestClient(t, srv)
+
+	if _, err := client.ChatCompletion(context.Background(), []Message{{Role: "user", Content: "hi"}}, nil); err != nil {
+		t.Fatalf("ChatCompletion: %v", err)
+	}
+	if d, ok := client.Latency(); !ok || d <= 0 {
+		t.Errorf("Latency = %v, %v; want a positive estimate after a completion", d, ok)
+	}
+}
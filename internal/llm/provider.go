@@ -0,0 +1,105 @@
+package llm
+
+import (
+	"cmp"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Provider kinds accepted by SetProvider.
+const (
+	ProviderMistral = "mistral" // Mistral's API (default)
+	ProviderOpenAI  = "openai"  // OpenAI or any OpenAI-compatible server
+	ProviderOllama  = "ollama"  // Ollama's OpenAI-compatible endpoint
+)
+
+// providerSpec describes how a provider kind departs from the others.
+type providerSpec struct {
+	baseURL        string // API root used when SetProvider is given none
+	responseFormat string // structured output mode when SetResponseFormat is unset
+	prefix         bool   // accepts assistant prefix messages, see SetAssistantPrefix
+	retryStatuses  []int  // statuses below 500 worth retrying; 5xx always are
+}
+
+var providerSpecs = map[string]providerSpec{
+	ProviderMistral: {
+		baseURL:        "https://api.mistral.ai/v1/",
+		responseFormat: ResponseFormatJSONSchema,
+		prefix:         true,
+		retryStatuses:  []int{http.StatusTooManyRequests},
+	},
+	ProviderOpenAI: {
+		baseURL:        "https://api.openai.com/v1/",
+		responseFormat: ResponseFormatJSONSchema,
+		retryStatuses:  []int{http.StatusRequestTimeout, http.StatusConflict, http.StatusTooManyRequests},
+	},
+	ProviderOllama: {
+		baseURL:        "http://localhost:11434/v1/",
+		responseFormat: ResponseFormatJSONObject, // json_schema depends on the server version and model
+		retryStatuses:  []int{http.StatusTooManyRequests},
+	},
+}
+
+// validateProvider checks that kind is a known provider kind ("" meaning
+// Mistral) and that baseURL, when set, is an http(s) URL.
+func validateProvider(kind, baseURL string) error {
+	if _, ok := providerSpecs[cmp.Or(kind, ProviderMistral)]; !ok {
+		return fmt.Errorf("llm: provider must be mistral, openai or ollama, got %q", kind)
+	}
+	if baseURL != "" {
+		if u, err := url.Parse(baseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("llm: provider base URL must be an http(s) URL, got %q", baseURL)
+		}
+	}
+	return nil
+}
+
+// SetProvider points the client at a provider kind, and at baseURL (e.g.
+// "http://localhost:8000/v1") instead of the kind's default API root when it
+// is set. The kind selects the default response_format, whether assistant
+// prefixes are sent and which HTTP statuses are retried. The API key is sent
+// as a bearer token unless it is empty. It must be called before the client
+// is used.
+func (c *Client) SetProvider(kind, baseURL string) error {
+	if err := validateProvider(kind, baseURL); err != nil {
+		return err
+	}
+	kind = cmp.Or(kind, ProviderMistral)
+	c.provider = kind
+	c.baseURL = cmp.Or(baseURL, providerSpecs[kind].baseURL)
+	if !strings.HasSuffix(c.baseURL, "/") {
+		c.baseURL += "/"
+	}
+	return nil
+}
+
+// DefaultResponseFormat returns the structured output mode used for kind when
+// SetResponseFormat is unset.
+func DefaultResponseFormat(kind string) string {
+	return providerSpecs[cmp.Or(kind, ProviderMistral)].responseFormat
+}
+
+// providerKind returns the client's provider kind.
+func (c *Client) providerKind() string {
+	return cmp.Or(c.provider, ProviderMistral)
+}
+
+// spec returns the behaviour of the client's provider kind.
+func (c *Client) spec() providerSpec {
+	return providerSpecs[c.providerKind()]
+}
+
+// setAuth adds the API key to req as a bearer token; keyless local servers get none.
+func (c *Client) setAuth(req *http.Request) {
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+}
+
+// newHTTPError returns the error for a non-200 response, retryable as the
+// client's provider classifies it.
+func (c *Client) newHTTPError(endpoint string, status int, body []byte) *httpError {
+	return &httpError{StatusCode: status, Endpoint: endpoint, Body: string(body), retryStatuses: c.spec().retryStatuses}
+}
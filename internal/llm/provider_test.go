@@ -0,0 +1,130 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSetProvider(t *testing.T) {
+	tests := []struct {
+		kind, baseURL string
+		wantKind      string
+		wantURL       string
+	}{
+		{"", "", ProviderMistral, "https://api.mistral.ai/v1/"},
+		{ProviderOpenAI, "", ProviderOpenAI, "https://api.openai.com/v1/"},
+		{ProviderOllama, "", ProviderOllama, "http://localhost:11434/v1/"},
+		{ProviderOpenAI, "http://127.0.0.1:8000/v1", ProviderOpenAI, "http://127.0.0.1:8000/v1/"},
+	}
+	for _, tt := range tests {
+		c := NewClient("key", "model")
+		if err := c.SetProvider(tt.kind, tt.baseURL); err != nil {
+			t.Fatalf("SetProvider(%q, %q): %v", tt.kind, tt.baseURL, err)
+		}
+		if info := c.Info(); info.Provider != tt.wantKind || info.BaseURL != tt.wantURL {
+			t.Errorf("SetProvider(%q, %q): provider, base URL = %q, %q; want %q, %q",
+				tt.kind, tt.baseURL, info.Provider, info.BaseURL, tt.wantKind, tt.wantURL)
+		}
+	}
+}
+
+func TestSetProvider_Invalid(t *testing.T) {
+	for _, tt := range []struct{ kind, baseURL string }{
+		{"anthropic", ""},
+		{ProviderOpenAI, "ftp://models.local/v1"},
+		{ProviderOllama, "localhost:11434"},
+	} {
+		c := NewClient("key", "model")
+		if err := c.SetProvider(tt.kind, tt.baseURL); err == nil {
+			t.Errorf("SetProvider(%q, %q) = nil, want an error", tt.kind, tt.baseURL)
+		}
+		if info := c.Info(); info.Provider != ProviderMistral || info.BaseURL != "https://api.mistral.ai/v1/" {
+			t.Errorf("client changed by a rejected provider: %+v", info)
+		}
+	}
+}
+
+func TestChatCompletion_ProviderRequests(t *testing.T) {
+	tests := []struct {
+		kind       string
+		apiKey     string
+		wantAuth   string
+		wantFormat string
+		wantPrefix bool
+	}{
+		{ProviderMistral, "sk-m", "Bearer sk-m", "json_schema", true},
+		{ProviderOpenAI, "sk-o", "Bearer sk-o", "json_schema", false},
+		{ProviderOllama, "", "", "json_object", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.kind, func(t *testing.T) {
+			var auth string
+			var req ChatRequest
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				auth = r.Header.Get("Authorization")
+				body, _ := io.ReadAll(r.Body)
+				if err := json.Unmarshal(body, &req); err != nil {
+					t.Errorf("unmarshal request: %v", err)
+				}
+				json.NewEncoder(w).Encode(ChatResponse{Choices: []Choice{{
+					Message:      Message{Role: "assistant", Content: `"type":"message","content":"ok"}`},
+					FinishReason: "stop",
+				}}})
+			}))
+			defer srv.Close()
+
+			c := NewClient(tt.apiKey, "model")
+			if err := c.SetProvider(tt.kind, srv.URL+"/v1"); err != nil {
+				t.Fatal(err)
+			}
+			c.SetAssistantPrefix("{")
+			resp, err := c.ChatCompletion(context.Background(), []Message{{Role: "user", Content: "Hi"}}, nil)
+			if err != nil {
+				t.Fatalf("ChatCompletion: %v", err)
+			}
+
+			if auth != tt.wantAuth {
+				t.Errorf("Authorization = %q, want %q", auth, tt.wantAuth)
+			}
+			if req.ResponseFormat == nil || req.ResponseFormat.Type != tt.wantFormat {
+				t.Errorf("response_format = %+v, want %s", req.ResponseFormat, tt.wantFormat)
+			}
+			if gotPrefix := req.Messages[len(req.Messages)-1].Prefix; gotPrefix != tt.wantPrefix {
+				t.Errorf("prefix message sent = %v, want %v", gotPrefix, tt.wantPrefix)
+			}
+			if content := resp.Choices[0].Message.Content; strings.HasPrefix(content, "{") != tt.wantPrefix {
+				t.Errorf("content = %q, want the prefix joined back only when sent", content)
+			}
+		})
+	}
+}
+
+func TestHTTPError_ProviderRetryStatuses(t *testing.T) {
+	tests := []struct {
+		kind      string
+		status    int
+		retryable bool
+	}{
+		{ProviderMistral, http.StatusTooManyRequests, true},
+		{ProviderMistral, http.StatusRequestTimeout, false},
+		{ProviderOpenAI, http.StatusRequestTimeout, true},
+		{ProviderOpenAI, http.StatusConflict, true},
+		{ProviderOpenAI, http.StatusBadRequest, false},
+		{ProviderOllama, http.StatusServiceUnavailable, true},
+		{ProviderOllama, http.StatusNotFound, false},
+	}
+	for _, tt := range tests {
+		c := NewClient("key", "model")
+		if err := c.SetProvider(tt.kind, ""); err != nil {
+			t.Fatal(err)
+		}
+		if got := c.newHTTPError("chat/completions", tt.status, nil).IsRetryable(); got != tt.retryable {
+			t.Errorf("%s status %d: IsRetryable() = %v, want %v", tt.kind, tt.status, got, tt.retryable)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package llm
+
+import "slices"
+
+// ModelQuirks describes how a model's tool-call format departs from the
+// default. The zero value is the standard Mistral format.
+type ModelQuirks struct {
+	ParametersKey string `json:"parameters_key,omitempty"` // Key carrying each tool's JSON schema in requests (default "parameters")
+	ToolChoice    string `json:"tool_choice,omitempty"`    // tool_choice sent along with tools (default "auto")
+	ToolCallType  string `json:"tool_call_type,omitempty"` // Type given to tool calls that lack one, in responses and re-sent history (default "function")
+}
+
+// SetModelQuirks registers tool-call formatting quirks by model name. Requests
+// for a model without an entry use the standard format. It must be called
+// before the client is used.
+func (c *Client) SetModelQuirks(quirks map[string]ModelQuirks) {
+	c.quirks = quirks
+}
+
+// quirksFor returns the quirks registered for model.
+func (c *Client) quirksFor(model string) ModelQuirks {
+	return c.quirks[model]
+}
+
+func (q ModelQuirks) toolChoice() string {
+	if q.ToolChoice == "" {
+		return "auto"
+	}
+	return q.ToolChoice
+}
+
+func (q ModelQuirks) toolCallType() string {
+	if q.ToolCallType == "" {
+		return "function"
+	}
+	return q.ToolCallType
+}
+
+// quirkedRequest is a ChatRequest whose tools carry their schema under a
+// model-specific key. Its Tools field shadows the embedded one when encoded.
+type quirkedRequest struct {
+	ChatRequest
+	Tools []map[string]any `json:"tools,omitempty"`
+}
+
+// adaptRequest returns the body to send for req: tool calls in the history get
+// a type, and tools are renamed as the model expects. The caller's messages
+// are not modified.
+func (q ModelQuirks) adaptRequest(req ChatRequest) any {
+	if slices.ContainsFunc(req.Messages, func(m Message) bool { return needsToolCallType(m.ToolCalls) }) {
+		messages := make([]Message, len(req.Messages))
+		for i, m := range req.Messages {
+			m.ToolCalls = q.withToolCallTypes(m.ToolCalls)
+			messages[i] = m
+		}
+		req.Messages = messages
+	}
+
+	if q.ParametersKey == "" || q.ParametersKey == "parameters" || len(req.Tools) == 0 {
+		return req
+	}
+	tools := make([]map[string]any, len(req.Tools))
+	for i, t := range req.Tools {
+		tools[i] = map[string]any{
+			"type": t.Type,
+			"function": map[string]any{
+				"name":          t.Function.Name,
+				"description":   t.Function.Description,
+				q.ParametersKey: t.Function.Parameters,
+			},
+		}
+	}
+	return quirkedRequest{ChatRequest: req, Tools: tools}
+}
+
+// normalizeResponse gives every tool call in resp a type, since some models
+// omit it and Mistral rejects an empty type when the history is sent back.
+func (q ModelQuirks) normalizeResponse(resp *ChatResponse) {
+	for i := range resp.Choices {
+		msg := &resp.Choices[i].Message
+		if needsToolCallType(msg.ToolCalls) {
+			msg.ToolCalls = q.withToolCallTypes(msg.ToolCalls)
+		}
+	}
+}
+
+// needsToolCallType reports whether any of calls lacks a type.
+func needsToolCallType(calls []ToolCall) bool {
+	for _, tc := range calls {
+		if tc.Type == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// withToolCallTypes returns calls, copied if needed, with missing types filled in.
+func (q ModelQuirks) withToolCallTypes(calls []ToolCall) []ToolCall {
+	if !needsToolCallType(calls) {
+		return calls
+	}
+	out := append([]ToolCall(nil), calls...)
+	for i := range out {
+		if out[i].Type == "" {
+			out[i].Type = q.toolCallType()
+		}
+	}
+	return out
+}
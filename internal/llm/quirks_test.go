@@ -0,0 +1,86 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// quirkServer records each raw request body and answers with a tool call lacking a type.
+func quirkServer(t *testing.T, bodies *[]map[string]any) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		*bodies = append(*bodies, body)
+		json.NewEncoder(w).Encode(ChatResponse{Choices: []Choice{{
+			Message: Message{Role: "assistant", ToolCalls: []ToolCall{
+				{ID: "c1", Function: ToolCallFunction{Name: "read_file", Arguments: `{"path":"a"}`}},
+			}},
+			FinishReason: "tool_calls",
+		}}})
+	}))
+}
+
+func TestChatCompletion_ModelQuirksProfiles(t *testing.T) {
+	tools := []Tool{{Type: "function", Function: ToolFunction{
+		Name:        "read_file",
+		Description: "Read a file",
+		Parameters:  map[string]any{"type": "object"},
+	}}}
+	history := []Message{
+		{Role: "user", Content: "read a"},
+		{Role: "assistant", ToolCalls: []ToolCall{{ID: "c0", Function: ToolCallFunction{Name: "read_file", Arguments: `{}`}}}},
+		{Role: "tool", Content: "ok", ToolCallID: "c0"},
+	}
+	quirks := map[string]ModelQuirks{
+		"legacy-model": {ParametersKey: "arguments", ToolChoice: "any", ToolCallType: "tool"},
+	}
+
+	tests := []struct {
+		model      string
+		schemaKey  string
+		toolChoice string
+		callType   string
+	}{
+		{"standard-model", "parameters", "auto", "function"},
+		{"legacy-model", "arguments", "any", "tool"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			var bodies []map[string]any
+			srv := quirkServer(t, &bodies)
+			defer srv.Close()
+			client := newTestClient(t, srv)
+			client.model = tt.model
+			client.SetModelQuirks(quirks)
+
+			resp, err := client.ChatCompletion(context.Background(), history, tools)
+			if err != nil {
+				t.Fatalf("ChatCompletion: %v", err)
+			}
+
+			body := bodies[0]
+			if body["tool_choice"] != tt.toolChoice {
+				t.Errorf("tool_choice = %v, want %q", body["tool_choice"], tt.toolChoice)
+			}
+			fn := body["tools"].([]any)[0].(map[string]any)["function"].(map[string]any)
+			if _, ok := fn[tt.schemaKey]; !ok || len(fn) != 3 {
+				t.Errorf("tool function = %v, want name, description and %s", fn, tt.schemaKey)
+			}
+			sent := body["messages"].([]any)[1].(map[string]any)["tool_calls"].([]any)[0].(map[string]any)
+			if sent["type"] != tt.callType {
+				t.Errorf("re-sent tool call type = %v, want %q", sent["type"], tt.callType)
+			}
+			if got := resp.Choices[0].Message.ToolCalls[0].Type; got != tt.callType {
+				t.Errorf("response tool call type = %q, want %q", got, tt.callType)
+			}
+		})
+	}
+	if history[1].ToolCalls[0].Type != "" {
+		t.Error("the caller's history must not be modified")
+	}
+}
@@ -2,6 +2,7 @@ package llm
 
 import (
 	"encoding/json"
+	"regexp"
 	"strings"
 )
 
@@ -14,6 +15,7 @@ type ChatRequest struct {
 	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
 	Tools          []Tool          `json:"tools,omitempty"`
 	ToolChoice     string          `json:"tool_choice,omitempty"`
+	Stream         bool            `json:"stream,omitempty"`
 }
 
 // Message represents a single message in a chat conversation.
@@ -23,6 +25,7 @@ type Message struct {
 	Name       string     `json:"name,omitempty"`
 	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
 	ToolCallID string     `json:"tool_call_id,omitempty"`
+	Prefix     bool       `json:"prefix,omitempty"` // assistant content the model must continue (Mistral prefix)
 }
 
 // ResponseFormat specifies the desired response format from the API.
@@ -69,6 +72,7 @@ type ToolCallFunction struct {
 // ChatResponse represents a Mistral chat completion API response.
 type ChatResponse struct {
 	ID      string   `json:"id"`
+	Model   string   `json:"model,omitempty"` // Model that produced the response, as reported by the provider
 	Choices []Choice `json:"choices"`
 	Usage   Usage    `json:"usage"`
 }
@@ -89,8 +93,9 @@ type Usage struct {
 
 // AgentResponse is the typed JSON envelope parsed from LLM output content.
 type AgentResponse struct {
-	Type    string `json:"type"`
-	Content string `json:"content"`
+	Type        string   `json:"type"`
+	Content     string   `json:"content"`
+	Attachments []string `json:"attachments,omitempty"` // Workspace-relative files to upload with a message
 }
 
 // ParseAgentResponse parses an LLM content string into an AgentResponse.
@@ -135,3 +140,60 @@ func tryParseAgent(s string) (*AgentResponse, bool) {
 		return nil, false
 	}
 }
+
+// truncatedMessageRe matches the start of a "message" envelope, and truncatedContentRe
+// the opening of its content string, in JSON that may have been cut off mid-stream.
+var (
+	truncatedMessageRe = regexp.MustCompile(`^\{\s*"type"\s*:\s*"message"`)
+	truncatedContentRe = regexp.MustCompile(`"content"\s*:\s*"`)
+)
+
+// RecoverTruncatedMessage extracts the partial content of a "message" envelope whose
+// JSON was cut off (e.g. finish_reason "length"). It returns ok=false when content is
+// not a truncated message envelope or no content text could be recovered.
+func RecoverTruncatedMessage(content string) (*AgentResponse, bool) {
+	trimmed := strings.TrimSpace(content)
+	if !truncatedMessageRe.MatchString(trimmed) {
+		return nil, false
+	}
+	if _, ok := tryParseAgent(trimmed); ok {
+		return nil, false
+	}
+	loc := truncatedContentRe.FindStringIndex(trimmed)
+	if loc == nil {
+		return nil, false
+	}
+
+	raw := partialJSONString(trimmed[loc[1]:])
+	var text string
+	if err := json.Unmarshal([]byte(`"`+raw+`"`), &text); err != nil {
+		return nil, false
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, false
+	}
+	return &AgentResponse{Type: "message", Content: text}, true
+}
+
+// partialJSONString returns the body of a JSON string literal (without the opening
+// quote), ending at the closing quote or, when the input was cut off, just before
+// any incomplete trailing escape sequence.
+func partialJSONString(s string) string {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			return s[:i]
+		case '\\':
+			if i+1 >= len(s) || (s[i+1] == 'u' && i+6 > len(s)) {
+				return s[:i]
+			}
+			if s[i+1] == 'u' {
+				i += 5
+			} else {
+				i++
+			}
+		}
+	}
+	return s
+}
@@ -303,6 +303,16 @@ func TestToolCall_JSONSnakeCase(t *testing.T) {
 	}
 }
 
+func TestParseAgentResponse_Attachments(t *testing.T) {
+	got, err := ParseAgentResponse(`{"type":"message","content":"Report attached.","attachments":["reports/disk.md"]}`)
+	if err != nil {
+		t.Fatalf("ParseAgentResponse: %v", err)
+	}
+	if got.Type != "message" || len(got.Attachments) != 1 || got.Attachments[0] != "reports/disk.md" {
+		t.Errorf("got %+v, want a message with reports/disk.md attached", got)
+	}
+}
+
 func TestParseAgentResponse(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -479,3 +489,41 @@ func TestResponseFormat_JSONMarshal(t *testing.T) {
 	}
 }
 
+
+func TestRecoverTruncatedMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		wantOK  bool
+	}{
+		{"cut mid-content", `{"type":"message","content":"Here is the long answer: first point, sec`, "Here is the long answer: first point, sec", true},
+		{"cut after closing quote", `{"type":"message","content":"Complete text"`, "Complete text", true},
+		{"spaces in envelope", `{ "type" : "message", "content" : "Hello wor`, "Hello wor", true},
+		{"escapes decoded", `{"type":"message","content":"line1\nline2 \"quoted\" caf\u00e9`, "line1\nline2 \"quoted\" café", true},
+		{"dangling backslash", `{"type":"message","content":"path C:\\dir and \`, `path C:\dir and`, true},
+		{"dangling unicode escape", `{"type":"message","content":"caf\u00e`, "caf", true},
+		{"complete json", `{"type":"message","content":"done"}`, "", false},
+		{"think envelope", `{"type":"think","content":"hmm`, "", false},
+		{"no content yet", `{"type":"message","con`, "", false},
+		{"empty content", `{"type":"message","content":"`, "", false},
+		{"plain text", `just text`, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := RecoverTruncatedMessage(tt.content)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if got.Type != "message" {
+				t.Errorf("Type = %q, want message", got.Type)
+			}
+			if got.Content != tt.want {
+				t.Errorf("Content = %q, want %q", got.Content, tt.want)
+			}
+		})
+	}
+}
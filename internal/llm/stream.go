@@ -0,0 +1,265 @@
+package llm
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/edouard/pureclaw/internal/platform"
+)
+
+// maxStreamLine bounds one server-sent event line of a streamed completion.
+const maxStreamLine = 1 << 20
+
+// StreamChunk is one item received from ChatCompletionStream. Chunks carrying
+// a content Delta come first; the last chunk carries either the assembled
+// Response or the Err that ended the stream.
+type StreamChunk struct {
+	Delta    string        // content appended to the message of choice Index
+	Index    int           // choice the delta belongs to
+	Response *ChatResponse // complete response, set on the last chunk only
+	Err      error         // error that ended the stream, set on the last chunk only
+}
+
+// streamEvent is the payload of one "data:" line of a streamed completion.
+type streamEvent struct {
+	ID      string `json:"id"`
+	Model   string `json:"model"`
+	Choices []struct {
+		Index        int         `json:"index"`
+		Delta        streamDelta `json:"delta"`
+		FinishReason string      `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *Usage `json:"usage"`
+}
+
+// streamDelta is the part of a message sent in one streamed event.
+type streamDelta struct {
+	Role      string          `json:"role"`
+	Content   string          `json:"content"`
+	ToolCalls []toolCallDelta `json:"tool_calls"`
+}
+
+// toolCallDelta is a fragment of a tool call. Fragments with the same index
+// (or, from providers that omit it, the same or no id) belong to one call
+// whose arguments are the concatenation of theirs.
+type toolCallDelta struct {
+	Index    *int             `json:"index"`
+	ID       string           `json:"id"`
+	Type     string           `json:"type"`
+	Function ToolCallFunction `json:"function"`
+}
+
+// ChatCompletionStream sends a streaming chat completion request, built as in
+// ChatCompletion, and returns the channel its chunks are delivered on. The
+// request itself is sent before returning, so HTTP errors (including a
+// rejected response_format, which is dropped and resent once) are returned
+// directly. The channel is closed after the final chunk, which holds the
+// assembled response, with tool call fragments merged so HasToolCalls works on
+// its choices, or the error that interrupted the stream.
+//
+// The client's timeout bounds the wait for the response and each gap between
+// events rather than the whole stream. The caller must read the channel until
+// it is closed or cancel ctx. ChatCompletionWithRetry does not stream.
+func (c *Client) ChatCompletionStream(ctx context.Context, messages []Message, tools []Tool) (<-chan StreamChunk, error) {
+	model := c.Model()
+	platform.Log(ctx).Debug("chat completion stream request", "component", "llm", "operation", "chat_completion_stream", "model", model)
+
+	quirks := c.quirksFor(model)
+	req := c.chatRequest(model, quirks, messages, tools)
+	req.Stream = true
+
+	streamCtx, cancel := context.WithCancelCause(ctx)
+	idle := newIdleTimer(c.httpClient.Timeout, cancel)
+	// The overall client timeout would cut long streams; the idle timer replaces it.
+	client := &http.Client{Transport: c.httpClient.Transport}
+
+	started := time.Now()
+	resp, release, err := c.post(streamCtx, client, "chat/completions", quirks.adaptRequest(req))
+	if c.dropRejectedFormat(ctx, "chat_completion_stream", &req, err) {
+		idle.reset()
+		started = time.Now()
+		resp, release, err = c.post(streamCtx, client, "chat/completions", quirks.adaptRequest(req))
+	}
+	if err != nil {
+		idle.stop()
+		cancel(nil)
+		return nil, err
+	}
+
+	chunks := make(chan StreamChunk)
+	go func() {
+		defer close(chunks)
+		defer release()
+		defer cancel(nil)
+		defer idle.stop()
+		defer resp.Body.Close()
+
+		// Only the caller giving up stops delivery; an idle timeout is reported.
+		send := func(chunk StreamChunk) bool {
+			select {
+			case chunks <- chunk:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		full, err := readStream(resp, idle.reset, func(index int, delta string) bool {
+			return send(StreamChunk{Delta: delta, Index: index})
+		})
+		if err != nil {
+			if streamCtx.Err() != nil {
+				err = fmt.Errorf("llm: chat/completions: stream: %w", context.Cause(streamCtx))
+			}
+			send(StreamChunk{Err: err})
+			return
+		}
+		c.latency.observe(model, time.Since(started))
+
+		if full.Model == "" {
+			full.Model = model
+		}
+		c.usage.add(full.Usage)
+		quirks.normalizeResponse(full)
+		if len(tools) == 0 && c.usesPrefix() {
+			for i := range full.Choices {
+				full.Choices[i].Message.Content = withPrefix(c.prefix, full.Choices[i].Message.Content)
+			}
+		}
+		send(StreamChunk{Response: full})
+	}()
+	return chunks, nil
+}
+
+// idleTimer cancels a stream that receives nothing for its timeout.
+type idleTimer struct {
+	timeout time.Duration
+	timer   *time.Timer // nil when timeout <= 0
+}
+
+func newIdleTimer(timeout time.Duration, cancel context.CancelCauseFunc) *idleTimer {
+	t := &idleTimer{timeout: timeout}
+	if timeout > 0 {
+		t.timer = time.AfterFunc(timeout, func() {
+			cancel(fmt.Errorf("no data received for %s", timeout))
+		})
+	}
+	return t
+}
+
+func (t *idleTimer) reset() {
+	if t.timer != nil {
+		t.timer.Reset(t.timeout)
+	}
+}
+
+func (t *idleTimer) stop() {
+	if t.timer != nil {
+		t.timer.Stop()
+	}
+}
+
+// readStream reads the server-sent events of resp until "data: [DONE]" and
+// returns the response they assemble. It calls onEvent for every line read and
+// onDelta for every non-empty content delta, stopping early if onDelta
+// returns false.
+func readStream(resp *http.Response, onEvent func(), onDelta func(index int, delta string) bool) (*ChatResponse, error) {
+	var full ChatResponse
+	positions := make(map[int]int) // choice index -> position in full.Choices
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxStreamLine)
+	for scanner.Scan() {
+		onEvent()
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue // blank separators, comments and other SSE fields
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			return &full, nil
+		}
+
+		var ev streamEvent
+		if err := json.Unmarshal([]byte(data), &ev); err != nil {
+			return nil, fmt.Errorf("llm: chat/completions: stream: unmarshal event: %w", err)
+		}
+		if full.ID == "" {
+			full.ID = ev.ID
+		}
+		if full.Model == "" {
+			full.Model = ev.Model
+		}
+		if ev.Usage != nil {
+			full.Usage = *ev.Usage
+		}
+		for _, ch := range ev.Choices {
+			pos, ok := positions[ch.Index]
+			if !ok {
+				pos = len(full.Choices)
+				positions[ch.Index] = pos
+				full.Choices = append(full.Choices, Choice{Index: ch.Index})
+			}
+			choice := &full.Choices[pos]
+			if ch.Delta.Role != "" {
+				choice.Message.Role = ch.Delta.Role
+			}
+			if ch.FinishReason != "" {
+				choice.FinishReason = ch.FinishReason
+			}
+			calls, err := mergeToolCallDeltas(choice.Message.ToolCalls, ch.Delta.ToolCalls)
+			if err != nil {
+				return nil, fmt.Errorf("llm: chat/completions: stream: %w", err)
+			}
+			choice.Message.ToolCalls = calls
+			if ch.Delta.Content != "" {
+				choice.Message.Content += ch.Delta.Content
+				if !onDelta(ch.Index, ch.Delta.Content) {
+					return nil, errors.New("llm: chat/completions: stream: abandoned by reader")
+				}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("llm: chat/completions: stream: %w", err)
+	}
+	return nil, errors.New("llm: chat/completions: stream: ended before [DONE]")
+}
+
+// mergeToolCallDeltas adds the tool call fragments deltas to calls. An index
+// may name an existing call or the next one, anything else is rejected so a
+// bad chunk can't panic or allocate a huge slice.
+func mergeToolCallDeltas(calls []ToolCall, deltas []toolCallDelta) ([]ToolCall, error) {
+	for _, d := range deltas {
+		i := len(calls) - 1
+		switch {
+		case d.Index != nil:
+			i = *d.Index
+			if i < 0 || i > len(calls) {
+				return nil, fmt.Errorf("tool call index %d out of range (have %d)", i, len(calls))
+			}
+			if i == len(calls) {
+				calls = append(calls, ToolCall{})
+			}
+		case i < 0 || (d.ID != "" && calls[i].ID != "" && d.ID != calls[i].ID):
+			calls = append(calls, ToolCall{})
+			i = len(calls) - 1
+		}
+		tc := &calls[i]
+		if d.ID != "" {
+			tc.ID = d.ID
+		}
+		if d.Type != "" {
+			tc.Type = d.Type
+		}
+		tc.Function.Name += d.Function.Name
+		tc.Function.Arguments += d.Function.Arguments
+	}
+	return calls, nil
+}
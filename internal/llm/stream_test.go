@@ -0,0 +1,238 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sseServer answers every request with the given server-sent event lines.
+func sseServer(t *testing.T, lines ...string) (*httptest.Server, *ChatRequest) {
+	t.Helper()
+	var got ChatRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &got); err != nil {
+			t.Errorf("unmarshal request: %v", err)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, line := range lines {
+			fmt.Fprintf(w, "%s\n\n", line)
+			w.(http.Flusher).Flush()
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &got
+}
+
+// collect reads chunks until the channel is closed and returns the deltas and
+// the final chunk.
+func collect(t *testing.T, chunks <-chan StreamChunk) ([]string, StreamChunk) {
+	t.Helper()
+	var deltas []string
+	var last StreamChunk
+	for chunk := range chunks {
+		if chunk.Response != nil || chunk.Err != nil {
+			last = chunk
+			continue
+		}
+		deltas = append(deltas, chunk.Delta)
+	}
+	return deltas, last
+}
+
+func TestChatCompletionStream_Content(t *testing.T) {
+	srv, got := sseServer(t,
+		": keep-alive",
+		`data: {"id":"s-1","model":"mistral-small-2503","choices":[{"index":0,"delta":{"role":"assistant","content":""}}]}`,
+		`data: {"id":"s-1","choices":[{"index":0,"delta":{"content":"{\"type\":\"message\","}}]}`,
+		`data: {"id":"s-1","choices":[{"index":0,"delta":{"content":"\"content\":\"Hi\"}"},"finish_reason":"stop"}],"usage":{"prompt_tokens":7,"completion_tokens":4,"total_tokens":11}}`,
+		"data: [DONE]",
+	)
+	c := newTestClient(t, srv)
+
+	chunks, err := c.ChatCompletionStream(context.Background(), []Message{{Role: "user", Content: "Hi"}}, nil)
+	if err != nil {
+		t.Fatalf("ChatCompletionStream: %v", err)
+	}
+	deltas, last := collect(t, chunks)
+
+	if !got.Stream || got.ResponseFormat == nil {
+		t.Errorf("request stream = %v, response_format = %v, want a streamed structured request", got.Stream, got.ResponseFormat)
+	}
+	if want := []string{`{"type":"message",`, `"content":"Hi"}`}; strings.Join(deltas, "|") != strings.Join(want, "|") {
+		t.Errorf("deltas = %q, want %q", deltas, want)
+	}
+	if last.Err != nil {
+		t.Fatalf("stream error: %v", last.Err)
+	}
+	resp := last.Response
+	if resp.ID != "s-1" || resp.Model != "mistral-small-2503" || len(resp.Choices) != 1 {
+		t.Fatalf("response = %+v", resp)
+	}
+	choice := resp.Choices[0]
+	if choice.Message.Role != "assistant" || choice.Message.Content != `{"type":"message","content":"Hi"}` || choice.FinishReason != "stop" {
+		t.Errorf("choice = %+v", choice)
+	}
+	if u := c.Info().Usage; u.Requests != 1 || u.TotalTokens != 11 {
+		t.Errorf("usage = %+v, want the streamed usage recorded", u)
+	}
+}
+
+func TestChatCompletionStream_ToolCallDeltas(t *testing.T) {
+	tools := []Tool{{Type: "function", Function: ToolFunction{Name: "exec_command"}}}
+	tests := []struct {
+		name  string
+		lines []string
+		want  []ToolCall
+	}{
+		{
+			name: "IndexedFragments",
+			lines: []string{
+				`data: {"id":"s-2","choices":[{"index":0,"delta":{"role":"assistant","tool_calls":[{"index":0,"id":"call-1","type":"function","function":{"name":"exec_command","arguments":""}}]}}]}`,
+				`data: {"id":"s-2","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"command\":"}}]}}]}`,
+				`data: {"id":"s-2","choices":[{"index":0,"delta":{"tool_calls":[{"index":1,"id":"call-2","function":{"name":"read_file","arguments":"{\"path\":\"a\"}"}}]}}]}`,
+				`data: {"id":"s-2","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"ls\"}"}}]},"finish_reason":"tool_calls"}]}`,
+				"data: [DONE]",
+			},
+			want: []ToolCall{
+				{ID: "call-1", Type: "function", Function: ToolCallFunction{Name: "exec_command", Arguments: `{"command":"ls"}`}},
+				{ID: "call-2", Type: "function", Function: ToolCallFunction{Name: "read_file", Arguments: `{"path":"a"}`}},
+			},
+		},
+		{
+			name: "WholeCallsWithoutIndex",
+			lines: []string{
+				`data: {"id":"s-3","choices":[{"index":0,"delta":{"role":"assistant","tool_calls":[{"id":"a1","function":{"name":"exec_command","arguments":"{\"command\":\"date\"}"}}]}}]}`,
+				`data: {"id":"s-3","choices":[{"index":0,"delta":{"tool_calls":[{"id":"a2","function":{"name":"exec_command","arguments":"{\"command\":\"uptime\"}"}}]},"finish_reason":"tool_calls"}]}`,
+				"data: [DONE]",
+			},
+			want: []ToolCall{
+				{ID: "a1", Type: "function", Function: ToolCallFunction{Name: "exec_command", Arguments: `{"command":"date"}`}},
+				{ID: "a2", Type: "function", Function: ToolCallFunction{Name: "exec_command", Arguments: `{"command":"uptime"}`}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, got := sseServer(t, tt.lines...)
+			c := newTestClient(t, srv)
+
+			chunks, err := c.ChatCompletionStream(context.Background(), []Message{{Role: "user", Content: "go"}}, tools)
+			if err != nil {
+				t.Fatalf("ChatCompletionStream: %v", err)
+			}
+			deltas, last := collect(t, chunks)
+			if last.Err != nil {
+				t.Fatalf("stream error: %v", last.Err)
+			}
+			if got.ResponseFormat != nil || got.ToolChoice != "auto" {
+				t.Errorf("request response_format = %v, tool_choice = %q, want tools request", got.ResponseFormat, got.ToolChoice)
+			}
+			if len(deltas) != 0 {
+				t.Errorf("deltas = %q, want none for tool calls", deltas)
+			}
+			choice := last.Response.Choices[0]
+			if !HasToolCalls(&choice) {
+				t.Fatalf("HasToolCalls = false for %+v", choice)
+			}
+			calls := choice.Message.ToolCalls
+			if len(calls) != len(tt.want) {
+				t.Fatalf("tool calls = %+v, want %+v", calls, tt.want)
+			}
+			for i := range calls {
+				if calls[i] != tt.want[i] {
+					t.Errorf("tool call %d = %+v, want %+v", i, calls[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestChatCompletionStream_ToolCallIndexOutOfRange(t *testing.T) {
+	for _, index := range []string{"-1", "1", "1000000000"} {
+		t.Run(index, func(t *testing.T) {
+			srv, _ := sseServer(t,
+				`data: {"id":"s-5","choices":[{"index":0,"delta":{"tool_calls":[{"index":`+index+`,"id":"call-1","function":{"name":"exec_command"}}]}}]}`,
+				"data: [DONE]",
+			)
+			c := newTestClient(t, srv)
+
+			chunks, err := c.ChatCompletionStream(context.Background(), []Message{{Role: "user", Content: "go"}}, nil)
+			if err != nil {
+				t.Fatalf("ChatCompletionStream: %v", err)
+			}
+			_, last := collect(t, chunks)
+			if last.Response != nil || last.Err == nil || !strings.Contains(last.Err.Error(), "tool call index "+index+" out of range") {
+				t.Errorf("last = %+v, want an out-of-range stream error", last)
+			}
+		})
+	}
+}
+
+func TestChatCompletionStream_HTTPError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"invalid model"}`, http.StatusBadRequest)
+	}))
+	defer srv.Close()
+	c := newTestClient(t, srv)
+
+	chunks, err := c.ChatCompletionStream(context.Background(), []Message{{Role: "user", Content: "Hi"}}, nil)
+	if err == nil || chunks != nil {
+		t.Fatalf("ChatCompletionStream = %v, %v, want an error", chunks, err)
+	}
+	if !strings.Contains(err.Error(), "status 400") {
+		t.Errorf("error = %v, want the HTTP status", err)
+	}
+}
+
+func TestChatCompletionStream_Interrupted(t *testing.T) {
+	srv, _ := sseServer(t,
+		`data: {"id":"s-4","choices":[{"index":0,"delta":{"content":"Hel"}}]}`,
+	)
+	c := newTestClient(t, srv)
+
+	chunks, err := c.ChatCompletionStream(context.Background(), []Message{{Role: "user", Content: "Hi"}}, nil)
+	if err != nil {
+		t.Fatalf("ChatCompletionStream: %v", err)
+	}
+	deltas, last := collect(t, chunks)
+	if len(deltas) != 1 || last.Response != nil || last.Err == nil || !strings.Contains(last.Err.Error(), "ended before [DONE]") {
+		t.Errorf("deltas = %q, last = %+v, want one delta then an interruption error", deltas, last)
+	}
+	if u := c.Info().Usage; u.Requests != 0 {
+		t.Errorf("usage requests = %d, want interrupted streams not counted", u.Requests)
+	}
+}
+
+func TestChatCompletionStream_IdleTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hi\"}}]}\n\n")
+		w.(http.Flusher).Flush()
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+	c := newTestClient(t, srv)
+	c.httpClient.Timeout = 100 * time.Millisecond
+
+	chunks, err := c.ChatCompletionStream(context.Background(), []Message{{Role: "user", Content: "Hi"}}, nil)
+	if err != nil {
+		t.Fatalf("ChatCompletionStream: %v", err)
+	}
+	_, last := collect(t, chunks)
+	if last.Err == nil || !strings.Contains(last.Err.Error(), "no data received for 100ms") {
+		t.Errorf("last chunk = %+v, want an idle timeout error", last)
+	}
+}
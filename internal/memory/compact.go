@@ -0,0 +1,195 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/edouard/pureclaw/internal/platform"
+)
+
+// BackupSuffix is appended to an hourly file's name for the copy kept by Compact.
+const BackupSuffix = ".bak"
+
+// Summarizer condenses the merged content of consecutive same-source entries.
+type Summarizer func(ctx context.Context, source, content string) (string, error)
+
+// CompactStats reports what a Compact run changed.
+type CompactStats struct {
+	Files         int // hourly files rewritten
+	Skipped       int // old files left alone because they hold malformed entries
+	EntriesBefore int // entries in the rewritten files before compaction
+	EntriesAfter  int // entries in the rewritten files after compaction
+}
+
+// Compact rewrites every hourly file whose hour ended before the given time,
+// collapsing consecutive entries with the same source and tags into one entry
+// that keeps the first timestamp and all of their content. With summarize set,
+// each collapsed group is condensed by it instead; a failed summary keeps the
+// merged content. The current hour is never touched.
+//
+// Each rewritten file is first copied to <file>.bak, then replaced atomically.
+// Files with nothing to collapse are left as they are, and so are files holding
+// an entry that does not parse, since rewriting them would drop it.
+func (m *Memory) Compact(ctx context.Context, before time.Time, summarize Summarizer) (CompactStats, error) {
+	before = minTime(before, timeNow().Truncate(time.Hour))
+	dir := filepath.Join(m.root, "memory")
+
+	files, err := m.allFiles(dir)
+	if err != nil {
+		return CompactStats{}, fmt.Errorf("memory: compact: %w", err)
+	}
+
+	var stats CompactStats
+	for _, path := range files {
+		if err := ctx.Err(); err != nil {
+			return stats, fmt.Errorf("memory: compact: %w", err)
+		}
+		hour, ok := FileHour(dir, path)
+		if !ok || hour.Add(time.Hour).After(before) {
+			continue
+		}
+
+		data, err := readWithRetry(ctx, path)
+		if err != nil {
+			return stats, fmt.Errorf("memory: compact: %w", err)
+		}
+		entries, ok := parseAll(string(data), path)
+		if !ok {
+			platform.Log(ctx).Warn("memory file has malformed entries, not compacting",
+				"component", "memory",
+				"operation", "compact",
+				"path", path,
+			)
+			stats.Skipped++
+			continue
+		}
+
+		merged := collapse(ctx, entries, summarize)
+		if len(merged) == len(entries) {
+			continue
+		}
+		if err := platform.AtomicWrite(path+BackupSuffix, data, 0o644); err != nil {
+			return stats, fmt.Errorf("memory: compact: backup: %w", err)
+		}
+		if err := platform.AtomicWrite(path, formatEntries(merged), 0o644); err != nil {
+			return stats, fmt.Errorf("memory: compact: %w", err)
+		}
+
+		stats.Files++
+		stats.EntriesBefore += len(entries)
+		stats.EntriesAfter += len(merged)
+		platform.Log(ctx).Info("memory file compacted",
+			"component", "memory",
+			"operation", "compact",
+			"path", path,
+			"entries_before", len(entries),
+			"entries_after", len(merged),
+		)
+	}
+	return stats, nil
+}
+
+// FileHour returns the hour an hourly file covers, from its path below the
+// memory directory dir, in the flat layout or a per-chat partition
+// (<chatID>/YYYY/MM/DD/HH.md). Any other file reports false.
+func FileHour(dir, path string) (time.Time, bool) {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return time.Time{}, false
+	}
+	rel = filepath.ToSlash(rel)
+	if chat, rest, ok := strings.Cut(rel, "/"); ok && strings.Count(rest, "/") == 3 {
+		if _, err := strconv.ParseInt(chat, 10, 64); err != nil {
+			return time.Time{}, false
+		}
+		rel = rest
+	}
+	t, err := time.ParseInLocation("2006/01/02/15.md", rel, time.UTC)
+	return t, err == nil
+}
+
+// parseAll parses every entry of a memory file. It returns false if any
+// non-empty segment is malformed.
+func parseAll(content, path string) ([]SearchResult, bool) {
+	var entries []SearchResult
+	for _, seg := range strings.Split(content, "---\n") {
+		seg = strings.TrimSpace(seg)
+		if seg == "" {
+			continue
+		}
+		e, ok := parseEntry(seg, path)
+		if !ok {
+			return nil, false
+		}
+		entries = append(entries, e)
+	}
+	return entries, true
+}
+
+// collapse merges runs of consecutive entries sharing source and tags.
+func collapse(ctx context.Context, entries []SearchResult, summarize Summarizer) []SearchResult {
+	var out []SearchResult
+	for i := 0; i < len(entries); {
+		j := i + 1
+		for j < len(entries) && entries[j].Source == entries[i].Source && slices.Equal(entries[j].Tags, entries[i].Tags) {
+			j++
+		}
+		group := entries[i:j]
+		merged := group[0]
+		if len(group) > 1 {
+			parts := make([]string, 0, len(group))
+			for _, e := range group {
+				if e.Content != "" {
+					parts = append(parts, e.Content)
+				}
+			}
+			merged.Content = strings.Join(parts, "\n\n")
+			if summarize != nil {
+				merged.Content = summarizeGroup(ctx, summarize, merged)
+			}
+		}
+		out = append(out, merged)
+		i = j
+	}
+	return out
+}
+
+// summarizeGroup condenses a merged entry, keeping the merged content on failure.
+func summarizeGroup(ctx context.Context, summarize Summarizer, e SearchResult) string {
+	summary, err := summarize(ctx, e.Source, e.Content)
+	if err != nil || strings.TrimSpace(summary) == "" {
+		platform.Log(ctx).Warn("memory summary failed, keeping merged entries",
+			"component", "memory",
+			"operation", "compact",
+			"path", e.FilePath,
+			"error", err,
+		)
+		return e.Content
+	}
+	return strings.TrimSpace(summary)
+}
+
+// formatEntries renders entries in the format written by WriteTagged.
+func formatEntries(entries []SearchResult) []byte {
+	var b strings.Builder
+	for _, e := range entries {
+		header := e.Source
+		for _, tag := range e.Tags {
+			header += " #" + tag
+		}
+		fmt.Fprintf(&b, "---\n**%s** — %s\n%s\n\n", e.Time.Format("2006-01-02 15:04"), header, e.Content)
+	}
+	return []byte(b.String())
+}
+
+func minTime(a, b time.Time) time.Time {
+	if b.Before(a) {
+		return b
+	}
+	return a
+}
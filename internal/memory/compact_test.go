@@ -0,0 +1,186 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompact_CollapsesConsecutiveSameSource(t *testing.T) {
+	origTimeNow := timeNow
+	t.Cleanup(func() { timeNow = origTimeNow })
+
+	root := t.TempDir()
+	m := New(root)
+	ctx := context.Background()
+	for i, w := range [][2]string{
+		{"owner", "first note"},
+		{"owner", "second note"},
+		{"agent", "reply"},
+		{"owner", "third note"},
+		{"owner", "fourth note"},
+		{"owner", "fifth note"},
+	} {
+		timeNow = fixedClock(2026, 3, 15, 14, 10+i)
+		if err := m.Write(ctx, w[0], w[1]); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	path := filepath.Join(root, "memory", "2026", "03", "15", "14.md")
+	original, _ := os.ReadFile(path)
+
+	timeNow = fixedClock(2026, 3, 20, 9, 0)
+	stats, err := m.Compact(ctx, time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC), nil)
+	if err != nil {
+		t.Fatalf("Compact: %v", err)
+	}
+	if stats.Files != 1 || stats.EntriesBefore != 6 || stats.EntriesAfter != 3 {
+		t.Errorf("stats = %+v, want 1 file, 6 -> 3 entries", stats)
+	}
+
+	entries, err := m.parseFile(context.Background(), path)
+	if err != nil {
+		t.Fatalf("parseFile: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("entries = %d, want 3", len(entries))
+	}
+	want := []struct{ source, content, time string }{
+		{"owner", "first note\n\nsecond note", "14:10"},
+		{"agent", "reply", "14:12"},
+		{"owner", "third note\n\nfourth note\n\nfifth note", "14:13"},
+	}
+	for i, w := range want {
+		e := entries[i]
+		if e.Source != w.source || e.Content != w.content || e.Time.Format("15:04") != w.time {
+			t.Errorf("entry %d = %s %s %q, want %s %s %q", i, e.Time.Format("15:04"), e.Source, e.Content, w.time, w.source, w.content)
+		}
+	}
+
+	backup, err := os.ReadFile(path + BackupSuffix)
+	if err != nil {
+		t.Fatalf("backup: %v", err)
+	}
+	if string(backup) != string(original) {
+		t.Error("backup should hold the original file")
+	}
+}
+
+func TestCompact_KeepsRecentAndMalformedFiles(t *testing.T) {
+	origTimeNow := timeNow
+	t.Cleanup(func() { timeNow = origTimeNow })
+
+	root := t.TempDir()
+	m := New(root)
+	ctx := context.Background()
+
+	// A recent file, after the cutoff.
+	for _, minute := range []int{1, 2} {
+		timeNow = fixedClock(2026, 3, 18, 10, minute)
+		if err := m.Write(ctx, "owner", "recent"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// An old file with a malformed entry.
+	malformed := filepath.Join(root, "memory", "2026", "03", "10", "08.md")
+	if err := os.MkdirAll(filepath.Dir(malformed), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	content := "---\n**2026-03-10 08:00** — owner\na\n\n---\n**2026-03-10 08:01** — owner\nb\n\n---\nno header here\n\n"
+	if err := os.WriteFile(malformed, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	timeNow = fixedClock(2026, 3, 20, 9, 0)
+	stats, err := m.Compact(ctx, time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC), nil)
+	if err != nil {
+		t.Fatalf("Compact: %v", err)
+	}
+	if stats.Files != 0 || stats.Skipped != 1 {
+		t.Errorf("stats = %+v, want nothing rewritten and the malformed file skipped", stats)
+	}
+	if data, _ := os.ReadFile(malformed); string(data) != content {
+		t.Error("malformed file should be left untouched")
+	}
+	recent := filepath.Join(root, "memory", "2026", "03", "18", "10.md")
+	if data, _ := os.ReadFile(recent); strings.Count(string(data), "recent") != 2 {
+		t.Error("recent file should be left untouched")
+	}
+	if _, err := os.Stat(recent + BackupSuffix); !os.IsNotExist(err) {
+		t.Error("no backup expected for an untouched file")
+	}
+}
+
+func TestCompact_NeverTouchesCurrentHour(t *testing.T) {
+	origTimeNow := timeNow
+	t.Cleanup(func() { timeNow = origTimeNow })
+	timeNow = fixedClock(2026, 3, 15, 14, 1)
+
+	root := t.TempDir()
+	m := New(root)
+	ctx := context.Background()
+	for range 2 {
+		if err := m.Write(ctx, "owner", "now"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stats, err := m.Compact(ctx, time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC), nil)
+	if err != nil {
+		t.Fatalf("Compact: %v", err)
+	}
+	if stats.Files != 0 {
+		t.Errorf("stats = %+v, want the current hour left alone", stats)
+	}
+}
+
+func TestCompact_Summarizer(t *testing.T) {
+	origTimeNow := timeNow
+	t.Cleanup(func() { timeNow = origTimeNow })
+
+	root := t.TempDir()
+	m := New(root)
+	ctx := context.Background()
+	for _, minute := range []int{1, 2, 3} {
+		timeNow = fixedClock(2026, 3, 15, 14, minute)
+		if err := m.Write(ctx, "owner", "note"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, minute := range []int{1, 2} {
+		timeNow = fixedClock(2026, 3, 15, 15, minute)
+		if err := m.Write(ctx, "agent", "step"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	timeNow = fixedClock(2026, 3, 20, 9, 0)
+	calls := 0
+	summarize := func(_ context.Context, source, content string) (string, error) {
+		calls++
+		if source == "agent" {
+			return "", errors.New("llm down")
+		}
+		return "summary of " + source, nil
+	}
+	if _, err := m.Compact(ctx, time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC), summarize); err != nil {
+		t.Fatalf("Compact: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("summarizer calls = %d, want one per collapsed group", calls)
+	}
+
+	summarized, _ := m.parseFile(context.Background(), filepath.Join(root, "memory", "2026", "03", "15", "14.md"))
+	if len(summarized) != 1 || summarized[0].Content != "summary of owner" {
+		t.Errorf("14.md = %+v, want the summary", summarized)
+	}
+	// A failed summary keeps the merged content.
+	merged, _ := m.parseFile(context.Background(), filepath.Join(root, "memory", "2026", "03", "15", "15.md"))
+	if len(merged) != 1 || merged[0].Content != "step\n\nstep" {
+		t.Errorf("15.md = %+v, want merged content", merged)
+	}
+}
@@ -0,0 +1,249 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/edouard/pureclaw/internal/platform"
+)
+
+// IndexFileName is the sidecar index stored in the memory directory.
+const IndexFileName = "index.json"
+
+// indexVersion is bumped whenever the index layout changes.
+const indexVersion = 1
+
+// Index is the sidecar search index derived from the hourly memory files.
+// It is a cache: the .md files remain the source of truth and the index can
+// always be rebuilt from them with Reindex. Once built, Write keeps it current
+// and keyword searches use it to skip files that can't match.
+type Index struct {
+	Version int              `json:"version"`
+	BuiltAt time.Time        `json:"built_at"`
+	Entries []IndexEntry     `json:"entries"`
+	Terms   map[string][]int `json:"terms"` // lowercase term -> positions in Entries
+	Files   map[string]int   `json:"files"` // file path relative to memory/ -> entry count
+}
+
+// IndexEntry is one memory entry as recorded in the index.
+type IndexEntry struct {
+	File   string    `json:"file"` // relative to memory/
+	Time   time.Time `json:"time"`
+	Source string    `json:"source"`
+	Tags   []string  `json:"tags,omitempty"`
+}
+
+// ReindexStats reports what a Reindex run produced.
+type ReindexStats struct {
+	Files    int
+	Entries  int
+	Terms    int
+	Duration time.Duration
+}
+
+// Reindex re-reads every memory file and rebuilds the sidecar index from scratch,
+// replacing any existing (possibly stale) index. Running it twice over the same
+// files yields the same index. If ctx is cancelled, the existing index is left untouched.
+func (m *Memory) Reindex(ctx context.Context) (ReindexStats, error) {
+	started := timeNow()
+	dir := filepath.Join(m.root, "memory")
+
+	files, err := m.allFiles(dir)
+	if err != nil {
+		return ReindexStats{}, fmt.Errorf("memory: reindex: %w", err)
+	}
+
+	idx := Index{
+		Version: indexVersion,
+		Terms:   make(map[string][]int),
+		Files:   make(map[string]int, len(files)),
+	}
+	for _, path := range files {
+		if err := ctx.Err(); err != nil {
+			return ReindexStats{}, fmt.Errorf("memory: reindex: %w", err)
+		}
+		entries, err := m.parseFile(ctx, path)
+		if err != nil {
+			platform.Log(ctx).Warn("failed to parse memory file",
+				"component", "memory",
+				"operation", "reindex",
+				"path", path,
+				"error", err,
+			)
+			continue
+		}
+		rel := relPath(dir, path)
+		idx.Files[rel] = 0
+		for _, e := range entries {
+			idx.add(rel, e)
+		}
+	}
+
+	idx.BuiltAt = timeNow()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return ReindexStats{}, fmt.Errorf("memory: reindex: %w", err)
+	}
+	if err := m.saveIndex(&idx); err != nil {
+		return ReindexStats{}, fmt.Errorf("memory: reindex: %w", err)
+	}
+
+	stats := ReindexStats{
+		Files:    len(idx.Files),
+		Entries:  len(idx.Entries),
+		Terms:    len(idx.Terms),
+		Duration: timeNow().Sub(started),
+	}
+	platform.Log(ctx).Info("memory index rebuilt",
+		"component", "memory",
+		"operation", "reindex",
+		"files", stats.Files,
+		"entries", stats.Entries,
+		"terms", stats.Terms,
+		"duration", stats.Duration,
+	)
+	return stats, nil
+}
+
+// LoadIndex reads the sidecar index. It returns an error wrapping fs.ErrNotExist
+// if the index has never been built.
+func (m *Memory) LoadIndex() (*Index, error) {
+	data, err := os.ReadFile(filepath.Join(m.root, "memory", IndexFileName))
+	if err != nil {
+		return nil, fmt.Errorf("memory: load_index: %w", err)
+	}
+	var idx Index
+	if err := json.Unmarshal(data, &idx); err != nil {
+		return nil, fmt.Errorf("memory: load_index: %w", err)
+	}
+	return &idx, nil
+}
+
+// saveIndex writes idx to the sidecar file.
+func (m *Memory) saveIndex(idx *Index) error {
+	data, err := json.MarshalIndent(idx, "", "  ")
+	if err != nil {
+		return err
+	}
+	return platform.AtomicWrite(filepath.Join(m.root, "memory", IndexFileName), data, 0o644)
+}
+
+// indexEntries records entries just written to path in the sidecar index, if
+// one has been built. Without an index there is nothing to keep current.
+func (m *Memory) indexEntries(path string, entries []SearchResult) error {
+	idx, err := m.LoadIndex()
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if idx.Terms == nil {
+		idx.Terms = make(map[string][]int)
+	}
+	if idx.Files == nil {
+		idx.Files = make(map[string]int)
+	}
+	rel := relPath(filepath.Join(m.root, "memory"), path)
+	for _, e := range entries {
+		idx.add(rel, e)
+	}
+	return m.saveIndex(idx)
+}
+
+// add appends entry e of file rel (relative to memory/) to the index.
+func (idx *Index) add(rel string, e SearchResult) {
+	pos := len(idx.Entries)
+	idx.Entries = append(idx.Entries, IndexEntry{File: rel, Time: e.Time, Source: e.Source, Tags: e.Tags})
+	idx.Files[rel]++
+	for _, term := range indexTerms(e.Source + " " + e.Content) {
+		idx.Terms[term] = append(idx.Terms[term], pos)
+	}
+}
+
+// candidates returns the files (relative to memory/) holding an entry that
+// may contain keyword as a substring: every term of the keyword must occur
+// within one of the entry's terms. ok is false when the keyword has no terms
+// to narrow the search with.
+func (idx *Index) candidates(keyword string) (files map[string]bool, ok bool) {
+	want := indexTerms(keyword)
+	if len(want) == 0 {
+		return nil, false
+	}
+	var hits map[int]bool
+	for _, w := range want {
+		next := make(map[int]bool)
+		for term, positions := range idx.Terms {
+			if !strings.Contains(term, w) {
+				continue
+			}
+			for _, pos := range positions {
+				if hits == nil || hits[pos] {
+					next[pos] = true
+				}
+			}
+		}
+		hits = next
+	}
+	files = make(map[string]bool)
+	for pos := range hits {
+		if pos >= 0 && pos < len(idx.Entries) {
+			files[idx.Entries[pos].File] = true
+		}
+	}
+	return files, true
+}
+
+// relPath returns path relative to dir, with forward slashes.
+func relPath(dir, path string) string {
+	rel, _ := filepath.Rel(dir, path)
+	return filepath.ToSlash(rel)
+}
+
+// allFiles returns every .md file under dir in lexical (and so chronological) order.
+// A missing memory directory yields no files.
+func (m *Memory) allFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir && os.IsNotExist(err) {
+				return fs.SkipAll
+			}
+			return err
+		}
+		if !d.IsDir() && filepath.Ext(path) == ".md" {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// indexTerms splits text into unique lowercase terms of at least two letters or digits.
+func indexTerms(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	seen := make(map[string]bool, len(fields))
+	var terms []string
+	for _, f := range fields {
+		if len([]rune(f)) < 2 || seen[f] {
+			continue
+		}
+		seen[f] = true
+		terms = append(terms, f)
+	}
+	return terms
+}
@@ -0,0 +1,245 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func writeIndexFixtures(t *testing.T, m *Memory) {
+	t.Helper()
+	origTimeNow := timeNow
+	t.Cleanup(func() { timeNow = origTimeNow })
+
+	timeNow = fixedClock(2026, 3, 15, 14, 23)
+	if err := m.Write(context.Background(), "owner", "Deploy the staging server"); err != nil {
+		t.Fatal(err)
+	}
+	timeNow = fixedClock(2026, 3, 15, 14, 40)
+	if err := m.WriteTagged(context.Background(), "agent", "Staging deploy done", []string{"ops"}); err != nil {
+		t.Fatal(err)
+	}
+	timeNow = fixedClock(2026, 3, 16, 9, 5)
+	if err := m.Write(context.Background(), "owner", "Buy milk"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReindex_BuildsMissingIndex(t *testing.T) {
+	root := t.TempDir()
+	m := New(root)
+	writeIndexFixtures(t, m)
+
+	if _, err := m.LoadIndex(); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("LoadIndex before reindex: got %v, want ErrNotExist", err)
+	}
+
+	stats, err := m.Reindex(context.Background())
+	if err != nil {
+		t.Fatalf("Reindex: %v", err)
+	}
+	if stats.Files != 2 || stats.Entries != 3 {
+		t.Errorf("stats = %+v, want 2 files and 3 entries", stats)
+	}
+
+	idx, err := m.LoadIndex()
+	if err != nil {
+		t.Fatalf("LoadIndex: %v", err)
+	}
+	if idx.Version != indexVersion {
+		t.Errorf("Version = %d, want %d", idx.Version, indexVersion)
+	}
+	want := []IndexEntry{
+		{File: "2026/03/15/14.md", Time: time.Date(2026, 3, 15, 14, 23, 0, 0, time.UTC), Source: "owner"},
+		{File: "2026/03/15/14.md", Time: time.Date(2026, 3, 15, 14, 40, 0, 0, time.UTC), Source: "agent", Tags: []string{"ops"}},
+		{File: "2026/03/16/09.md", Time: time.Date(2026, 3, 16, 9, 5, 0, 0, time.UTC), Source: "owner"},
+	}
+	if len(idx.Entries) != len(want) {
+		t.Fatalf("entries = %+v, want %+v", idx.Entries, want)
+	}
+	for i := range want {
+		got := idx.Entries[i]
+		if got.File != want[i].File || !got.Time.Equal(want[i].Time) || got.Source != want[i].Source || !reflect.DeepEqual(got.Tags, want[i].Tags) {
+			t.Errorf("entry %d = %+v, want %+v", i, got, want[i])
+		}
+	}
+	if got := idx.Terms["staging"]; !reflect.DeepEqual(got, []int{0, 1}) {
+		t.Errorf("Terms[staging] = %v, want [0 1]", got)
+	}
+	if got := idx.Terms["milk"]; !reflect.DeepEqual(got, []int{2}) {
+		t.Errorf("Terms[milk] = %v, want [2]", got)
+	}
+}
+
+func TestReindex_ReplacesStaleIndex(t *testing.T) {
+	root := t.TempDir()
+	m := New(root)
+	writeIndexFixtures(t, m)
+
+	stale := `{"version":1,"entries":[{"file":"2020/01/01/00.md","source":"ghost"}],"terms":{"ghost":[0]},"files":{"2020/01/01/00.md":1}}`
+	if err := os.WriteFile(filepath.Join(root, "memory", IndexFileName), []byte(stale), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// A manual edit removes the "Buy milk" entry.
+	if err := os.Remove(filepath.Join(root, "memory", "2026", "03", "16", "09.md")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.Reindex(context.Background()); err != nil {
+		t.Fatalf("Reindex: %v", err)
+	}
+	idx, err := m.LoadIndex()
+	if err != nil {
+		t.Fatalf("LoadIndex: %v", err)
+	}
+	if len(idx.Entries) != 2 {
+		t.Errorf("entries = %d, want 2", len(idx.Entries))
+	}
+	if _, ok := idx.Terms["ghost"]; ok {
+		t.Error("stale term should be dropped")
+	}
+	if _, ok := idx.Terms["milk"]; ok {
+		t.Error("term from deleted file should be dropped")
+	}
+	if !reflect.DeepEqual(idx.Files, map[string]int{"2026/03/15/14.md": 2}) {
+		t.Errorf("Files = %v", idx.Files)
+	}
+}
+
+func TestReindex_Idempotent(t *testing.T) {
+	root := t.TempDir()
+	m := New(root)
+	writeIndexFixtures(t, m)
+
+	origTimeNow := timeNow
+	t.Cleanup(func() { timeNow = origTimeNow })
+	timeNow = fixedClock(2026, 3, 17, 8, 0)
+
+	if _, err := m.Reindex(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	first, _ := os.ReadFile(filepath.Join(root, "memory", IndexFileName))
+	if _, err := m.Reindex(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	second, _ := os.ReadFile(filepath.Join(root, "memory", IndexFileName))
+	if string(first) != string(second) {
+		t.Error("reindexing unchanged files should produce an identical index")
+	}
+}
+
+func TestReindex_NoMemoryDir(t *testing.T) {
+	m := New(t.TempDir())
+	stats, err := m.Reindex(context.Background())
+	if err != nil {
+		t.Fatalf("Reindex: %v", err)
+	}
+	if stats.Files != 0 || stats.Entries != 0 {
+		t.Errorf("stats = %+v, want empty", stats)
+	}
+}
+
+func TestReindex_CancelledKeepsExistingIndex(t *testing.T) {
+	root := t.TempDir()
+	m := New(root)
+	writeIndexFixtures(t, m)
+
+	indexPath := filepath.Join(root, "memory", IndexFileName)
+	if err := os.WriteFile(indexPath, []byte("previous"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := m.Reindex(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Reindex: got %v, want context.Canceled", err)
+	}
+	data, _ := os.ReadFile(indexPath)
+	if string(data) != "previous" {
+		t.Error("cancelled reindex must not replace the existing index")
+	}
+}
+
+func TestWrite_KeepsIndexCurrent(t *testing.T) {
+	root := t.TempDir()
+	m := New(root)
+	writeIndexFixtures(t, m)
+	if _, err := m.Reindex(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	origTimeNow := timeNow
+	t.Cleanup(func() { timeNow = origTimeNow })
+	timeNow = fixedClock(2026, 3, 16, 9, 30)
+	if err := m.WriteTagged(context.Background(), "owner", "Buy bread too", []string{"shopping"}); err != nil {
+		t.Fatal(err)
+	}
+	written, err := m.LoadIndex()
+	if err != nil {
+		t.Fatalf("LoadIndex: %v", err)
+	}
+
+	if _, err := m.Reindex(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	rebuilt, _ := m.LoadIndex()
+	if !reflect.DeepEqual(written.Entries, rebuilt.Entries) || !reflect.DeepEqual(written.Terms, rebuilt.Terms) || !reflect.DeepEqual(written.Files, rebuilt.Files) {
+		t.Errorf("index after Write = %+v, want what Reindex builds: %+v", written, rebuilt)
+	}
+}
+
+func TestWrite_NoIndexNotCreated(t *testing.T) {
+	root := t.TempDir()
+	writeIndexFixtures(t, New(root))
+	if _, err := os.Stat(filepath.Join(root, "memory", IndexFileName)); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Write created an index: %v", err)
+	}
+}
+
+func TestSearch_UsesIndex(t *testing.T) {
+	root := t.TempDir()
+	m := New(root)
+	writeIndexFixtures(t, m)
+	origTimeNow := timeNow
+	t.Cleanup(func() { timeNow = origTimeNow })
+	timeNow = fixedClock(2026, 3, 16, 11, 0)
+	if err := m.Write(context.Background(), "owner", "Another zebra"); err != nil {
+		t.Fatal(err)
+	}
+	// The index knows the 09:00 file (without the zebra edited in below) but not the 11:00 one.
+	stale := `{"version":1,"entries":[{"file":"2026/03/16/09.md","source":"owner"}],"terms":{"milk":[0]},"files":{"2026/03/16/09.md":1}}`
+	if err := os.WriteFile(filepath.Join(root, "memory", IndexFileName), []byte(stale), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(filepath.Join(root, "memory", "2026", "03", "16", "09.md"), os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("---\n**2026-03-16 09:10** — owner\nFeed the zebra\n\n")
+	f.Close()
+
+	start := time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 3, 16, 23, 59, 0, 0, time.UTC)
+	got, err := m.Search(context.Background(), "zebra", start, end)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(got) != 1 || got[0].Content != "Another zebra" {
+		t.Errorf("Search(zebra) = %+v, want only the unindexed file's entry", got)
+	}
+	if got, _ := m.Search(context.Background(), "mil", start, end); len(got) != 1 {
+		t.Errorf("Search(mil) = %+v, want the indexed substring match", got)
+	}
+
+	if _, err := m.Reindex(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := m.Search(context.Background(), "zebra", start, end); len(got) != 2 {
+		t.Errorf("Search(zebra) after reindex = %+v, want both entries", got)
+	}
+}
@@ -0,0 +1,138 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/edouard/pureclaw/internal/platform"
+)
+
+// JSONLDir is the directory below memory/ holding the structured JSONL sink.
+const JSONLDir = "jsonl"
+
+// MemoryEntry is one memory entry as stored in the JSONL sink, one JSON object
+// per line. Unlike the markdown files it keeps full timestamp precision and
+// content verbatim.
+type MemoryEntry struct {
+	Time    time.Time `json:"time"`
+	Source  string    `json:"source"`
+	Content string    `json:"content"`
+	Tags    []string  `json:"tags,omitempty"`
+}
+
+// SetStructured makes Write and WriteTagged also append each entry they write
+// to the JSONL sink (see WriteStructured). The markdown files are unaffected.
+func (m *Memory) SetStructured(on bool) {
+	m.structured = on
+}
+
+// WriteStructured appends entry as a JSON line to memory/jsonl/YYYY-MM-DD.jsonl,
+// named after the entry's UTC date. A zero Time is set to the current time and
+// tags are normalized as for WriteTagged.
+func (m *Memory) WriteStructured(ctx context.Context, entry MemoryEntry) error {
+	if entry.Time.IsZero() {
+		entry.Time = timeNow()
+	}
+	entry.Tags = NormalizeTags(entry.Tags)
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("memory: write_structured: %w", err)
+	}
+
+	path := m.jsonlPath(entry.Time)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("memory: write_structured: %w", err)
+	}
+	// One append per entry keeps the sink append-only: earlier lines are never rewritten.
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("memory: write_structured: %w", err)
+	}
+	_, err = f.Write(append(line, '\n'))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("memory: write_structured: %w", err)
+	}
+
+	platform.Log(ctx).Debug("structured memory entry written",
+		"component", "memory",
+		"operation", "write_structured",
+		"source", entry.Source,
+		"path", path,
+	)
+	return nil
+}
+
+// ReadRangeJSONL reads the JSONL sink entries within [start, end] in
+// chronological order. Lines that don't decode are skipped with a warning, as
+// are unreadable files. If a result cap is set (see SetMaxResults), only the
+// most recent entries are returned.
+func (m *Memory) ReadRangeJSONL(ctx context.Context, start, end time.Time) ([]SearchResult, error) {
+	var results []SearchResult
+	for day := start.UTC().Truncate(24 * time.Hour); !day.After(end); day = day.Add(24 * time.Hour) {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("memory: read_range_jsonl: %w", err)
+		}
+		path := m.jsonlPath(day)
+		data, err := readWithRetry(ctx, path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			platform.Log(ctx).Warn("failed to read memory file",
+				"component", "memory",
+				"operation", "read_range_jsonl",
+				"path", path,
+				"error", err,
+			)
+			continue
+		}
+		for i, line := range bytes.Split(data, []byte("\n")) {
+			if len(bytes.TrimSpace(line)) == 0 {
+				continue
+			}
+			var e MemoryEntry
+			if err := json.Unmarshal(line, &e); err != nil {
+				platform.Log(ctx).Warn("malformed structured memory entry",
+					"component", "memory",
+					"operation", "read_range_jsonl",
+					"path", path,
+					"line", i+1,
+					"error", err,
+				)
+				continue
+			}
+			if e.Time.Before(start) || e.Time.After(end) {
+				continue
+			}
+			results = append(results, SearchResult{
+				Time:     e.Time,
+				Source:   e.Source,
+				Content:  e.Content,
+				Tags:     e.Tags,
+				FilePath: path,
+			})
+		}
+	}
+
+	slices.SortStableFunc(results, func(a, b SearchResult) int { return a.Time.Compare(b.Time) })
+	if m.maxResults > 0 && len(results) > m.maxResults {
+		results = results[len(results)-m.maxResults:]
+	}
+	return results, nil
+}
+
+// jsonlPath returns the JSONL sink file for the UTC date of t.
+func (m *Memory) jsonlPath(t time.Time) string {
+	return filepath.Join(m.root, "memory", JSONLDir, t.UTC().Format("2006-01-02")+".jsonl")
+}
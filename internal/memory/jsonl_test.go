@@ -0,0 +1,126 @@
+package memory
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestWriteStructured_RoundTrip(t *testing.T) {
+	root := t.TempDir()
+	m := New(root)
+	ctx := context.Background()
+	day := time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)
+
+	entries := []MemoryEntry{
+		{Time: day.Add(14*time.Hour + 23*time.Minute + 7*time.Second), Source: "owner", Content: "---\n**not** — a header\n\n", Tags: []string{"#Disk", "disk", "alerts"}},
+		{Time: day.Add(9 * time.Hour), Source: "agent", Content: "earlier entry"},
+		{Time: day.Add(30 * time.Hour), Source: "heartbeat", Content: "next day"},
+	}
+	for _, e := range entries {
+		if err := m.WriteStructured(ctx, e); err != nil {
+			t.Fatalf("WriteStructured: %v", err)
+		}
+	}
+	for _, name := range []string{"2026-03-15.jsonl", "2026-03-16.jsonl"} {
+		if _, err := os.Stat(filepath.Join(root, "memory", JSONLDir, name)); err != nil {
+			t.Errorf("expected %s: %v", name, err)
+		}
+	}
+
+	results, err := m.ReadRangeJSONL(ctx, day, day.Add(48*time.Hour))
+	if err != nil {
+		t.Fatalf("ReadRangeJSONL: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(results))
+	}
+	if results[0].Content != "earlier entry" || results[2].Content != "next day" {
+		t.Errorf("results not chronological: %q, %q, %q", results[0].Content, results[1].Content, results[2].Content)
+	}
+	got := results[1]
+	if !got.Time.Equal(entries[0].Time) {
+		t.Errorf("Time = %v, want %v (full precision)", got.Time, entries[0].Time)
+	}
+	if got.Source != "owner" || got.Content != entries[0].Content {
+		t.Errorf("entry = %q/%q, want the content verbatim", got.Source, got.Content)
+	}
+	if !slices.Equal(got.Tags, []string{"disk", "alerts"}) {
+		t.Errorf("Tags = %v, want normalized [disk alerts]", got.Tags)
+	}
+}
+
+func TestReadRangeJSONL_FiltersAndSkipsMalformed(t *testing.T) {
+	root := t.TempDir()
+	m := New(root)
+	dir := filepath.Join(root, "memory", JSONLDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	data := `{"time":"2026-03-15T08:00:00Z","source":"owner","content":"too early"}
+{"time":"2026-03-15T10:00:00Z","source":"owner","content":"kept"}
+not json at all
+{"time":"2026-03-15T10:30:00Z","source":"agent","content":"also kept","tags":["x"]}
+`
+	if err := os.WriteFile(filepath.Join(dir, "2026-03-15.jsonl"), []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2026, 3, 15, 9, 0, 0, 0, time.UTC)
+	results, err := m.ReadRangeJSONL(context.Background(), start, start.Add(3*time.Hour))
+	if err != nil {
+		t.Fatalf("ReadRangeJSONL: %v", err)
+	}
+	if len(results) != 2 || results[0].Content != "kept" || results[1].Content != "also kept" {
+		t.Fatalf("results = %+v, want the two in-range entries", results)
+	}
+
+	m.SetMaxResults(1)
+	results, err = m.ReadRangeJSONL(context.Background(), start, start.Add(3*time.Hour))
+	if err != nil {
+		t.Fatalf("ReadRangeJSONL: %v", err)
+	}
+	if len(results) != 1 || results[0].Content != "also kept" {
+		t.Errorf("capped results = %+v, want only the most recent", results)
+	}
+}
+
+func TestSetStructured_MirrorsWrites(t *testing.T) {
+	origTimeNow := timeNow
+	t.Cleanup(func() { timeNow = origTimeNow })
+	timeNow = fixedClock(2026, 3, 15, 14, 23)
+	ctx := context.Background()
+
+	root := t.TempDir()
+	m := New(root)
+	if err := m.Write(ctx, "owner", "not mirrored"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "memory", JSONLDir)); !os.IsNotExist(err) {
+		t.Fatalf("JSONL sink written while off, stat err = %v", err)
+	}
+
+	m.SetStructured(true)
+	if err := m.WriteTagged(ctx, "agent", "mirrored", []string{"note"}); err != nil {
+		t.Fatal(err)
+	}
+
+	day := time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)
+	structured, err := m.ReadRangeJSONL(ctx, day, day.Add(24*time.Hour))
+	if err != nil {
+		t.Fatalf("ReadRangeJSONL: %v", err)
+	}
+	if len(structured) != 1 || structured[0].Content != "mirrored" || !slices.Equal(structured[0].Tags, []string{"note"}) {
+		t.Errorf("structured = %+v, want the tagged entry only", structured)
+	}
+	markdown, err := m.ReadRange(ctx, day, day.Add(24*time.Hour))
+	if err != nil {
+		t.Fatalf("ReadRange: %v", err)
+	}
+	if len(markdown) != 2 {
+		t.Errorf("markdown entries = %d, want 2", len(markdown))
+	}
+}
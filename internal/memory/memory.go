@@ -3,10 +3,12 @@ package memory
 import (
 	"context"
 	"fmt"
-	"log/slog"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
+	"unicode"
 
 	"github.com/edouard/pureclaw/internal/platform"
 )
@@ -16,7 +18,11 @@ var timeNow = time.Now
 
 // Memory handles writing entries to hourly memory files.
 type Memory struct {
-	root string // workspace root path
+	root       string // workspace root path
+	maxResults int    // cap on entries returned by Search/ReadRange (0 = unlimited)
+	dedup      bool   // skip entries repeating the previous one in the same hourly file
+	perChat    bool   // keep each chat's entries under memory/<chatID>/, see SetPerChat
+	structured bool   // also append entries to the JSONL sink, see SetStructured
 }
 
 // New creates a Memory writer rooted at the given workspace path.
@@ -24,11 +30,38 @@ func New(root string) *Memory {
 	return &Memory{root: root}
 }
 
+// SetMaxResults caps how many entries Search and ReadRange return. When the cap is
+// reached, the most recent matches are kept. Zero or a negative n means unlimited.
+func (m *Memory) SetMaxResults(n int) {
+	m.maxResults = max(n, 0)
+}
+
+// SetDedup enables skipping a write whose source and content are identical to
+// the immediately preceding entry in the same hourly file.
+func (m *Memory) SetDedup(on bool) {
+	m.dedup = on
+}
+
+// SetPerChat enables per-chat partitioning. Entries written for a chat (see
+// platform.WithChatID) then go to memory/<chatID>/YYYY/MM/DD/HH.md, and reads
+// for a chat see its own partition plus the entries not tied to any chat, which
+// stay in the flat layout. Reads without a chat see every partition.
+func (m *Memory) SetPerChat(on bool) {
+	m.perChat = on
+}
+
 // Write appends an entry to the current hourly memory file.
 // Format: ---\n**YYYY-MM-DD HH:MM** — source\ncontent\n\n
 func (m *Memory) Write(ctx context.Context, source, content string) error {
+	return m.WriteTagged(ctx, source, content, nil)
+}
+
+// WriteTagged appends an entry labelled with tags to the current hourly memory file.
+// Tags are stored in the entry header after the source:
+// ---\n**YYYY-MM-DD HH:MM** — source #tag1 #tag2\ncontent\n\n
+func (m *Memory) WriteTagged(ctx context.Context, source, content string, tags []string) error {
 	now := timeNow()
-	path := m.hourlyPath(now)
+	path := hourFile(m.writeDir(ctx), now)
 
 	dir := filepath.Dir(path)
 	if err := os.MkdirAll(dir, 0o755); err != nil {
@@ -37,9 +70,23 @@ func (m *Memory) Write(ctx context.Context, source, content string) error {
 
 	existing, _ := os.ReadFile(path) // ignore error — file may not exist yet
 
+	if m.dedup && repeatsLastEntry(existing, path, source, content) {
+		platform.Log(ctx).Debug("duplicate memory entry skipped",
+			"component", "memory",
+			"operation", "write",
+			"source", source,
+			"path", path,
+		)
+		return nil
+	}
+
+	header := source
+	for _, tag := range NormalizeTags(tags) {
+		header += " #" + tag
+	}
 	entry := fmt.Sprintf("---\n**%s** — %s\n%s\n\n",
 		now.Format("2006-01-02 15:04"),
-		source,
+		header,
 		content,
 	)
 
@@ -48,21 +95,123 @@ func (m *Memory) Write(ctx context.Context, source, content string) error {
 		return fmt.Errorf("memory: write: %w", err)
 	}
 
-	slog.Info("memory entry written",
+	platform.Log(ctx).Info("memory entry written",
 		"component", "memory",
 		"operation", "write",
 		"source", source,
+		"tags", len(tags),
 		"path", path,
 	)
+
+	// Index the entry as Reindex would parse it back from the file.
+	if err := m.indexEntries(path, parseEntries(entry, path)); err != nil {
+		platform.Log(ctx).Warn("failed to update memory index, run memory reindex",
+			"component", "memory",
+			"operation", "write",
+			"error", err,
+		)
+	}
+
+	if m.structured {
+		// The markdown entry is the one of record; a failed mirror only costs the sink a line.
+		entry := MemoryEntry{Time: now, Source: source, Content: content, Tags: tags}
+		if err := m.WriteStructured(ctx, entry); err != nil {
+			platform.Log(ctx).Warn("failed to mirror memory entry to the JSONL sink",
+				"component", "memory",
+				"operation", "write",
+				"error", err,
+			)
+		}
+	}
 	return nil
 }
 
-// hourlyPath returns the file path for the hourly memory file at time t.
+// repeatsLastEntry reports whether the last entry in data has the given source
+// and content. Content is compared after trimming, as entries are parsed back.
+func repeatsLastEntry(data []byte, path, source, content string) bool {
+	segments := strings.Split(string(data), "---\n")
+	for i := len(segments) - 1; i >= 0; i-- {
+		seg := strings.TrimSpace(segments[i])
+		if seg == "" {
+			continue
+		}
+		last, ok := parseEntry(seg, path)
+		return ok && last.Source == source && last.Content == strings.TrimSpace(content)
+	}
+	return false
+}
+
+// NormalizeTags lowercases tags, strips a leading '#', and drops empty,
+// duplicate, or malformed tags. Valid tags contain only letters, digits, '-' and '_'.
+func NormalizeTags(tags []string) []string {
+	var out []string
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+		if tag == "" || seen[tag] || !validTag(tag) {
+			continue
+		}
+		seen[tag] = true
+		out = append(out, tag)
+	}
+	return out
+}
+
+// validTag reports whether tag contains only letters, digits, '-' and '_'.
+func validTag(tag string) bool {
+	for _, r := range tag {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// hourlyPath returns the file path for the hourly memory file at time t in the
+// flat layout.
 func (m *Memory) hourlyPath(t time.Time) string {
-	return filepath.Join(m.root, "memory",
+	return hourFile(filepath.Join(m.root, "memory"), t)
+}
+
+// hourFile returns the path of the hourly memory file at time t below dir.
+func hourFile(dir string, t time.Time) string {
+	return filepath.Join(dir,
 		t.Format("2006"),
 		t.Format("01"),
 		t.Format("02"),
 		t.Format("15")+".md",
 	)
 }
+
+// writeDir returns the directory new entries go to: the partition of the chat
+// in ctx with per-chat partitioning, memory/ otherwise.
+func (m *Memory) writeDir(ctx context.Context) string {
+	dir := filepath.Join(m.root, "memory")
+	if chatID, ok := platform.ChatIDFrom(ctx); ok && m.perChat {
+		return filepath.Join(dir, strconv.FormatInt(chatID, 10))
+	}
+	return dir
+}
+
+// readDirs returns the directories a read scans: memory/ and, with per-chat
+// partitioning, the partition of the chat in ctx or, without a chat, every
+// partition.
+func (m *Memory) readDirs(ctx context.Context) []string {
+	dir := filepath.Join(m.root, "memory")
+	if !m.perChat {
+		return []string{dir}
+	}
+	if chatID, ok := platform.ChatIDFrom(ctx); ok {
+		return []string{dir, filepath.Join(dir, strconv.FormatInt(chatID, 10))}
+	}
+	dirs := []string{dir}
+	entries, _ := os.ReadDir(dir) // no memory yet: nothing to partition
+	for _, e := range entries {
+		// Year directories of the flat layout are numeric too; scanning them as
+		// partitions finds no files, so they need not be told apart.
+		if _, err := strconv.ParseInt(e.Name(), 10, 64); err == nil && e.IsDir() {
+			dirs = append(dirs, filepath.Join(dir, e.Name()))
+		}
+	}
+	return dirs
+}
@@ -4,9 +4,12 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/edouard/pureclaw/internal/platform"
 )
 
 func fixedClock(year int, month time.Month, day, hour, min int) func() time.Time {
@@ -71,6 +74,75 @@ func TestWrite_AppendExisting(t *testing.T) {
 	}
 }
 
+func TestWrite_Dedup(t *testing.T) {
+	origTimeNow := timeNow
+	t.Cleanup(func() { timeNow = origTimeNow })
+
+	for _, tc := range []struct {
+		name  string
+		dedup bool
+		want  int
+	}{
+		{"on", true, 1},
+		{"off", false, 2},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			root := t.TempDir()
+			m := New(root)
+			m.SetDedup(tc.dedup)
+
+			timeNow = fixedClock(2026, 3, 15, 14, 23)
+			if err := m.Write(context.Background(), "owner", "Same thing"); err != nil {
+				t.Fatalf("Write 1: %v", err)
+			}
+			timeNow = fixedClock(2026, 3, 15, 14, 24)
+			if err := m.Write(context.Background(), "owner", "Same thing"); err != nil {
+				t.Fatalf("Write 2: %v", err)
+			}
+
+			path := filepath.Join(root, "memory", "2026", "03", "15", "14.md")
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("ReadFile: %v", err)
+			}
+			if got := strings.Count(string(data), "Same thing"); got != tc.want {
+				t.Errorf("stored %d entries, want %d:\n%s", got, tc.want, data)
+			}
+		})
+	}
+}
+
+func TestWrite_DedupOnlyConsecutive(t *testing.T) {
+	origTimeNow := timeNow
+	t.Cleanup(func() { timeNow = origTimeNow })
+	timeNow = fixedClock(2026, 3, 15, 14, 23)
+
+	root := t.TempDir()
+	m := New(root)
+	m.SetDedup(true)
+
+	ctx := context.Background()
+	for _, w := range [][2]string{
+		{"owner", "ping"},
+		{"agent", "ping"}, // different source: kept
+		{"owner", "ping"}, // not consecutive with the first: kept
+		{"owner", "pong"},
+	} {
+		if err := m.Write(ctx, w[0], w[1]); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+
+	path := filepath.Join(root, "memory", "2026", "03", "15", "14.md")
+	entries, err := m.parseFile(context.Background(), path)
+	if err != nil {
+		t.Fatalf("parseFile: %v", err)
+	}
+	if len(entries) != 4 {
+		t.Errorf("got %d entries, want 4", len(entries))
+	}
+}
+
 func TestWrite_CreatesDirectories(t *testing.T) {
 	origTimeNow := timeNow
 	t.Cleanup(func() { timeNow = origTimeNow })
@@ -296,3 +368,144 @@ func TestHourlyPath_Format(t *testing.T) {
 		})
 	}
 }
+
+func TestWriteTagged_HeaderFormat(t *testing.T) {
+	origTimeNow := timeNow
+	t.Cleanup(func() { timeNow = origTimeNow })
+	timeNow = fixedClock(2026, 3, 15, 14, 23)
+
+	root := t.TempDir()
+	m := New(root)
+
+	if err := m.WriteTagged(context.Background(), "agent", "Ship it", []string{"#Release", "release", "bad tag", ""}); err != nil {
+		t.Fatalf("WriteTagged: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(root, "memory", "2026", "03", "15", "14.md"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	want := "---\n**2026-03-15 14:23** — agent #release\nShip it\n\n"
+	if string(data) != want {
+		t.Errorf("content mismatch:\ngot:  %q\nwant: %q", string(data), want)
+	}
+}
+
+func TestPerChat_WritesAndReadsByChat(t *testing.T) {
+	origTimeNow := timeNow
+	t.Cleanup(func() { timeNow = origTimeNow })
+
+	root := t.TempDir()
+	m := New(root)
+	m.SetPerChat(true)
+	alice := platform.WithChatID(context.Background(), 111)
+	bob := platform.WithChatID(context.Background(), -222)
+
+	for _, w := range []struct {
+		ctx     context.Context
+		min     int
+		content string
+	}{
+		{alice, 5, "alice one"},
+		{context.Background(), 10, "heartbeat check"},
+		{bob, 15, "bob one"},
+		{alice, 20, "alice two"},
+	} {
+		timeNow = fixedClock(2026, 3, 15, 14, w.min)
+		if err := m.Write(w.ctx, "owner", w.content); err != nil {
+			t.Fatalf("Write(%q): %v", w.content, err)
+		}
+	}
+
+	for _, path := range []string{
+		filepath.Join(root, "memory", "111", "2026", "03", "15", "14.md"),
+		filepath.Join(root, "memory", "-222", "2026", "03", "15", "14.md"),
+		filepath.Join(root, "memory", "2026", "03", "15", "14.md"),
+	} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("expected memory file %s: %v", path, err)
+		}
+	}
+
+	start := time.Date(2026, 3, 15, 14, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want []string
+	}{
+		{"alice", alice, []string{"alice one", "heartbeat check", "alice two"}},
+		{"bob", bob, []string{"heartbeat check", "bob one"}},
+		{"no chat", context.Background(), []string{"alice one", "heartbeat check", "bob one", "alice two"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := m.ReadRange(tt.ctx, start, end)
+			if err != nil {
+				t.Fatalf("ReadRange: %v", err)
+			}
+			var got []string
+			for _, r := range results {
+				got = append(got, r.Content)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("entries = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPerChat_OffKeepsFlatLayout(t *testing.T) {
+	origTimeNow := timeNow
+	t.Cleanup(func() { timeNow = origTimeNow })
+	timeNow = fixedClock(2026, 3, 15, 14, 23)
+
+	root := t.TempDir()
+	m := New(root)
+	ctx := platform.WithChatID(context.Background(), 111)
+	if err := m.Write(ctx, "owner", "hello"); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "memory", "2026", "03", "15", "14.md")); err != nil {
+		t.Errorf("expected the flat hourly file: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "memory", "111")); !os.IsNotExist(err) {
+		t.Errorf("no chat partition expected without per-chat memory, stat err = %v", err)
+	}
+}
+
+func TestPerChat_MaintenanceCoversPartitions(t *testing.T) {
+	origTimeNow := timeNow
+	t.Cleanup(func() { timeNow = origTimeNow })
+
+	root := t.TempDir()
+	m := New(root)
+	m.SetPerChat(true)
+	ctx := platform.WithChatID(context.Background(), 111)
+	timeNow = fixedClock(2026, 3, 10, 9, 0)
+	if err := m.Write(ctx, "owner", "old"); err != nil {
+		t.Fatal(err)
+	}
+	timeNow = fixedClock(2026, 3, 15, 14, 0)
+	if err := m.Write(ctx, "owner", "recent"); err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := m.Stats(context.Background(), 0)
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if stats.Entries != 2 {
+		t.Errorf("Stats.Entries = %d, want 2", stats.Entries)
+	}
+	removed, err := m.Prune(context.Background(), time.Date(2026, 3, 12, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("Prune removed %d entries, want 1", removed)
+	}
+	if _, err := os.Stat(filepath.Join(root, "memory", "111", "2026", "03", "15", "14.md")); err != nil {
+		t.Errorf("recent partition file should remain: %v", err)
+	}
+}
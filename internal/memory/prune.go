@@ -0,0 +1,169 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/edouard/pureclaw/internal/platform"
+)
+
+// Prune deletes memory entries older than before and returns how many it
+// removed. Hourly files whose hour ended by the cutoff are deleted along with
+// any Compact backup; the file of the hour holding the cutoff is rewritten
+// atomically without its stale entries. A file to rewrite that holds a
+// malformed entry is left alone, since rewriting it would drop that entry.
+// Directories emptied by the deletions are removed. The JSONL sink is pruned
+// the same way, a day file at a time (see pruneJSONL).
+func (m *Memory) Prune(ctx context.Context, before time.Time) (int, error) {
+	dir := filepath.Join(m.root, "memory")
+	files, err := m.allFiles(dir)
+	if err != nil {
+		return 0, fmt.Errorf("memory: prune: %w", err)
+	}
+
+	removed := 0
+	for _, path := range files {
+		if err := ctx.Err(); err != nil {
+			return removed, fmt.Errorf("memory: prune: %w", err)
+		}
+		hour, ok := FileHour(dir, path)
+		if !ok || !hour.Before(before) {
+			continue
+		}
+
+		var n int
+		if hour.Add(time.Hour).After(before) {
+			n, err = pruneFile(ctx, path, before)
+		} else {
+			n, err = m.deleteFile(ctx, dir, path)
+		}
+		if err != nil {
+			return removed, fmt.Errorf("memory: prune: %w", err)
+		}
+		removed += n
+	}
+
+	n, err := m.pruneJSONL(ctx, before)
+	removed += n
+	if err != nil {
+		return removed, fmt.Errorf("memory: prune: %w", err)
+	}
+
+	platform.Log(ctx).Info("memory pruned",
+		"component", "memory",
+		"operation", "prune",
+		"before", before.Format(time.RFC3339),
+		"entries", removed,
+	)
+	return removed, nil
+}
+
+// deleteFile removes an hourly file, its Compact backup, and the directories
+// this leaves empty below dir. It returns how many entries the file held.
+func (m *Memory) deleteFile(ctx context.Context, dir, path string) (int, error) {
+	entries, err := m.parseFile(ctx, path)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.Remove(path); err != nil {
+		return 0, err
+	}
+	if err := os.Remove(path + BackupSuffix); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return 0, err
+	}
+	// os.Remove fails on a directory that still holds files, which ends the climb.
+	for d := filepath.Dir(path); d != dir; d = filepath.Dir(d) {
+		if os.Remove(d) != nil {
+			break
+		}
+	}
+	return len(entries), nil
+}
+
+// pruneFile rewrites the file at path without its entries older than before
+// and returns how many it dropped.
+func pruneFile(ctx context.Context, path string, before time.Time) (int, error) {
+	data, err := readWithRetry(ctx, path)
+	if err != nil {
+		return 0, err
+	}
+	entries, ok := parseAll(string(data), path)
+	if !ok {
+		platform.Log(ctx).Warn("memory file has malformed entries, not pruning",
+			"component", "memory",
+			"operation", "prune",
+			"path", path,
+		)
+		return 0, nil
+	}
+	var kept []SearchResult
+	for _, e := range entries {
+		if !e.Time.Before(before) {
+			kept = append(kept, e)
+		}
+	}
+	if len(kept) == len(entries) {
+		return 0, nil
+	}
+	if err := platform.AtomicWrite(path, formatEntries(kept), 0o644); err != nil {
+		return 0, err
+	}
+	return len(entries) - len(kept), nil
+}
+
+// pruneJSONL removes the JSONL sink entries older than before and returns how
+// many it removed. A day file left with no line is deleted, one with stale
+// lines among newer ones is rewritten atomically without them. Lines that
+// don't decode are kept.
+func (m *Memory) pruneJSONL(ctx context.Context, before time.Time) (int, error) {
+	dir := filepath.Join(m.root, "memory", JSONLDir)
+	files, err := filepath.Glob(filepath.Join(dir, "*.jsonl"))
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, path := range files {
+		if err := ctx.Err(); err != nil {
+			return removed, err
+		}
+		day, err := time.ParseInLocation("2006-01-02.jsonl", filepath.Base(path), time.UTC)
+		if err != nil || !day.Before(before) {
+			continue
+		}
+		data, err := readWithRetry(ctx, path)
+		if err != nil {
+			return removed, err
+		}
+		var kept [][]byte
+		n := 0
+		for _, line := range bytes.Split(data, []byte("\n")) {
+			if len(bytes.TrimSpace(line)) == 0 {
+				continue
+			}
+			var e MemoryEntry
+			if json.Unmarshal(line, &e) == nil && e.Time.Before(before) {
+				n++
+				continue
+			}
+			kept = append(kept, line)
+		}
+		switch {
+		case len(kept) == 0:
+			err = os.Remove(path)
+		case n > 0:
+			err = platform.AtomicWrite(path, append(bytes.Join(kept, []byte("\n")), '\n'), 0o644)
+		}
+		if err != nil {
+			return removed, err
+		}
+		removed += n
+	}
+	return removed, nil
+}
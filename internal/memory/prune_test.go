@@ -0,0 +1,150 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writePruneFixtures writes hourly files on both sides of the February/March boundary.
+func writePruneFixtures(t *testing.T, root string) {
+	t.Helper()
+	writeRawMemoryFile(t, root, time.Date(2026, 2, 27, 10, 0, 0, 0, time.UTC),
+		"---\n**2026-02-27 10:05** — owner\nOld note\n\n")
+	writeRawMemoryFile(t, root, time.Date(2026, 2, 28, 23, 0, 0, 0, time.UTC),
+		"---\n**2026-02-28 23:10** — owner\nLate February\n\n"+
+			"---\n**2026-02-28 23:45** — agent\nEnd of February\n\n")
+	writeRawMemoryFile(t, root, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+		"---\n**2026-03-01 00:10** — agent\nStart of March\n\n")
+}
+
+func readAllContents(t *testing.T, m *Memory) []string {
+	t.Helper()
+	results, err := m.ReadRange(context.Background(), time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("ReadRange: %v", err)
+	}
+	var contents []string
+	for _, r := range results {
+		contents = append(contents, r.Content)
+	}
+	return contents
+}
+
+func TestPrune_RewritesPartiallyOldFile(t *testing.T) {
+	root := t.TempDir()
+	writePruneFixtures(t, root)
+	m := New(root)
+
+	removed, err := m.Prune(context.Background(), time.Date(2026, 2, 28, 23, 30, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	if removed != 2 {
+		t.Errorf("removed = %d, want 2", removed)
+	}
+	got := readAllContents(t, m)
+	if len(got) != 2 || got[0] != "End of February" || got[1] != "Start of March" {
+		t.Errorf("remaining = %q, want the entries from 23:30 on", got)
+	}
+	if _, err := os.Stat(filepath.Join(root, "memory", "2026", "02", "27")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("emptied day directory still exists (err %v)", err)
+	}
+}
+
+func TestPrune_AcrossMonthBoundary(t *testing.T) {
+	root := t.TempDir()
+	writePruneFixtures(t, root)
+	m := New(root)
+	late := m.hourlyPath(time.Date(2026, 2, 28, 23, 0, 0, 0, time.UTC))
+	if err := os.WriteFile(late+BackupSuffix, []byte("backup"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The cutoff is exactly where the 23:00 file's hour ends, so it goes whole.
+	removed, err := m.Prune(context.Background(), time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	if removed != 3 {
+		t.Errorf("removed = %d, want 3", removed)
+	}
+	if got := readAllContents(t, m); len(got) != 1 || got[0] != "Start of March" {
+		t.Errorf("remaining = %q, want only March", got)
+	}
+	if _, err := os.Stat(filepath.Join(root, "memory", "2026", "02")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("February directory (and its backup) should be gone (err %v)", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "memory", "2026", "03", "01", "00.md")); err != nil {
+		t.Errorf("March file: %v", err)
+	}
+}
+
+func TestPrune_LeavesMalformedFileAlone(t *testing.T) {
+	root := t.TempDir()
+	content := "---\n**2026-03-01 00:10** — owner\nKept\n\n---\nnot an entry\n\n"
+	path := writeRawMemoryFile(t, root, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), content)
+
+	removed, err := New(root).Prune(context.Background(), time.Date(2026, 3, 1, 0, 30, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	if removed != 0 {
+		t.Errorf("removed = %d, want 0", removed)
+	}
+	if data, _ := os.ReadFile(path); string(data) != content {
+		t.Errorf("malformed file rewritten: %q", data)
+	}
+}
+
+func TestPrune_NothingOlder(t *testing.T) {
+	root := t.TempDir()
+	writePruneFixtures(t, root)
+	m := New(root)
+
+	removed, err := m.Prune(context.Background(), time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil || removed != 0 {
+		t.Errorf("Prune = %d, %v; want nothing removed", removed, err)
+	}
+	if got := readAllContents(t, m); len(got) != 4 {
+		t.Errorf("remaining = %q, want all 4 entries", got)
+	}
+}
+
+func TestPrune_JSONLSink(t *testing.T) {
+	root := t.TempDir()
+	m := New(root)
+	ctx := context.Background()
+	for _, e := range []MemoryEntry{
+		{Time: time.Date(2026, 2, 27, 10, 5, 0, 0, time.UTC), Source: "owner", Content: "Old note"},
+		{Time: time.Date(2026, 2, 28, 23, 10, 0, 0, time.UTC), Source: "owner", Content: "Late February"},
+		{Time: time.Date(2026, 2, 28, 23, 45, 0, 0, time.UTC), Source: "agent", Content: "End of February"},
+		{Time: time.Date(2026, 3, 1, 0, 10, 0, 0, time.UTC), Source: "agent", Content: "Start of March"},
+	} {
+		if err := m.WriteStructured(ctx, e); err != nil {
+			t.Fatalf("WriteStructured: %v", err)
+		}
+	}
+
+	removed, err := m.Prune(ctx, time.Date(2026, 2, 28, 23, 30, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	if removed != 2 {
+		t.Errorf("removed = %d, want 2", removed)
+	}
+	results, err := m.ReadRangeJSONL(ctx, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("ReadRangeJSONL: %v", err)
+	}
+	if len(results) != 2 || results[0].Content != "End of February" || results[1].Content != "Start of March" {
+		t.Errorf("remaining = %+v, want the entries from 23:30 on", results)
+	}
+	if _, err := os.Stat(filepath.Join(root, "memory", JSONLDir, "2026-02-27.jsonl")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("emptied day file still exists (err %v)", err)
+	}
+}
@@ -2,18 +2,42 @@ package memory
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"log/slog"
 	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
+	"syscall"
 	"time"
+
+	"github.com/edouard/pureclaw/internal/platform"
+)
+
+// Replaceable for testing.
+var (
+	readFile       = os.ReadFile
+	readRetryDelay = 50 * time.Millisecond
 )
 
+// clockSkewPadding widens the hourly file window on each side so entries stored in a
+// neighbouring hour file (e.g. after an NTP clock correction) are still scanned.
+// Entries are then filtered precisely by their own timestamp.
+const clockSkewPadding = time.Hour
+
+// maxReadAttempts bounds how often a memory file read is tried when it fails
+// with a transient error (e.g. the file is briefly locked by a concurrent write).
+const maxReadAttempts = 3
+
 // SearchResult represents a single parsed memory entry.
 type SearchResult struct {
 	Time     time.Time // Timestamp of the entry
 	Source   string    // Who wrote it: "owner", "agent", "heartbeat", "action"
 	Content  string    // The entry content (after the source line)
+	Tags     []string  // Tags from the entry header, without the leading '#'
 	FilePath string    // Path to the source file (for debugging)
 }
 
@@ -21,76 +45,187 @@ type SearchResult struct {
 // Keyword matching is case-insensitive on the full entry text (source + content).
 // Returns results in chronological order.
 // If keyword is empty, returns all entries in the range (equivalent to ReadRange).
-func (m *Memory) Search(ctx context.Context, keyword string, start, end time.Time) ([]SearchResult, error) {
-	slog.Info("searching memory",
+// If tags are given, only entries carrying at least one of them are returned.
+// If a result cap is set (see SetMaxResults), only the most recent matches are returned.
+func (m *Memory) Search(ctx context.Context, keyword string, start, end time.Time, tags ...string) ([]SearchResult, error) {
+	tags = NormalizeTags(tags)
+	platform.Log(ctx).Info("searching memory",
 		"component", "memory",
 		"operation", "search",
 		"keyword", keyword,
+		"tags", tags,
+		"start", start.Format(time.RFC3339),
+		"end", end.Format(time.RFC3339),
+	)
+
+	lowerKeyword := strings.ToLower(keyword)
+	return m.search(ctx, "search", lowerKeyword, start, end, tags, func(e SearchResult) bool {
+		return keyword == "" || strings.Contains(strings.ToLower(e.Source+" "+e.Content), lowerKeyword)
+	})
+}
+
+// SearchRegexp is Search with entries matched by the regular expression
+// pattern (RE2 syntax) on their content instead of by keyword. Matching is
+// case-sensitive unless the pattern starts with (?i). An invalid pattern is
+// reported before any file is read.
+func (m *Memory) SearchRegexp(ctx context.Context, pattern string, start, end time.Time, tags ...string) ([]SearchResult, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("memory: search_regexp: %w", err)
+	}
+	tags = NormalizeTags(tags)
+	platform.Log(ctx).Info("searching memory",
+		"component", "memory",
+		"operation", "search_regexp",
+		"pattern", pattern,
+		"tags", tags,
 		"start", start.Format(time.RFC3339),
 		"end", end.Format(time.RFC3339),
 	)
 
-	files := m.listFiles(start, end)
+	return m.search(ctx, "search_regexp", "", start, end, tags, func(e SearchResult) bool {
+		return re.MatchString(e.Content)
+	})
+}
+
+// search scans the memory files around [start, end] and returns, in
+// chronological order, the entries within the range that carry one of tags
+// (if any) and satisfy match. It honours the result cap set by SetMaxResults.
+// A non-empty keyword (lowercase) that match looks for lets the sidecar index,
+// when built, rule out files without a candidate entry.
+func (m *Memory) search(ctx context.Context, operation, keyword string, start, end time.Time, tags []string, match func(SearchResult) bool) ([]SearchResult, error) {
+	files := m.listFilesPadded(ctx, start, end, clockSkewPadding)
+	if keyword != "" {
+		files = m.narrowByIndex(ctx, files, keyword)
+	}
+	limit := m.maxResults
+	if limit > 0 {
+		// Scan newest-first so scanning can stop as soon as the cap is reached.
+		slices.Reverse(files)
+	}
 
 	var results []SearchResult
-	lowerKeyword := strings.ToLower(keyword)
+	scanned := 0
 
+scan:
 	for _, path := range files {
 		if err := ctx.Err(); err != nil {
-			return nil, fmt.Errorf("memory: search: %w", err)
+			return nil, fmt.Errorf("memory: %s: %w", operation, err)
 		}
+		scanned++
 
-		entries, err := m.parseFile(path)
+		entries, err := m.parseFile(ctx, path)
 		if err != nil {
-			slog.Warn("failed to parse memory file",
+			platform.Log(ctx).Warn("failed to parse memory file",
 				"component", "memory",
-				"operation", "search",
+				"operation", operation,
 				"path", path,
 				"error", err,
 			)
 			continue
 		}
+		if limit > 0 {
+			slices.Reverse(entries)
+		}
 
 		for _, e := range entries {
 			if e.Time.Before(start) || e.Time.After(end) {
 				continue
 			}
-			if keyword == "" || strings.Contains(strings.ToLower(e.Source+" "+e.Content), lowerKeyword) {
+			if len(tags) > 0 && !hasAnyTag(e.Tags, tags) {
+				continue
+			}
+			if match(e) {
 				results = append(results, e)
+				if limit > 0 && len(results) >= limit {
+					break scan
+				}
 			}
 		}
 	}
+	if limit > 0 {
+		// Restore chronological order.
+		slices.Reverse(results)
+	}
+	if m.perChat {
+		// Entries of one hour may come from a chat's partition and the shared file.
+		slices.SortStableFunc(results, func(a, b SearchResult) int { return a.Time.Compare(b.Time) })
+	}
 
-	slog.Info("search complete",
+	platform.Log(ctx).Info("search complete",
 		"component", "memory",
-		"operation", "search",
-		"files_scanned", len(files),
+		"operation", operation,
+		"files_scanned", scanned,
 		"results_found", len(results),
+		"max_results", limit,
 	)
 
 	return results, nil
 }
 
+// narrowByIndex drops the files the sidecar index shows hold no entry that
+// can contain keyword. Files the index doesn't know are kept, so entries it
+// hasn't caught up with are still found; without an index, files is returned as is.
+func (m *Memory) narrowByIndex(ctx context.Context, files []string, keyword string) []string {
+	idx, err := m.LoadIndex()
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			platform.Log(ctx).Warn("memory index unreadable, scanning every file",
+				"component", "memory",
+				"operation", "search",
+				"error", err,
+			)
+		}
+		return files
+	}
+	candidates, ok := idx.candidates(keyword)
+	if !ok {
+		return files
+	}
+	dir := filepath.Join(m.root, "memory")
+	return slices.DeleteFunc(files, func(path string) bool {
+		rel := relPath(dir, path)
+		_, indexed := idx.Files[rel]
+		return indexed && !candidates[rel]
+	})
+}
+
+// hasAnyTag reports whether entryTags contains at least one of want.
+func hasAnyTag(entryTags, want []string) bool {
+	for _, t := range entryTags {
+		for _, w := range want {
+			if t == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // ReadRange reads all memory entries within [start, end] in chronological order.
 // Returns all entries without filtering. Suitable for context reconstruction.
-// Delegates to Search with an empty keyword; Search handles logging.
+// Delegates to Search with an empty keyword; Search handles logging, and skips
+// unreadable files with a warning so one bad file doesn't fail the whole range.
 func (m *Memory) ReadRange(ctx context.Context, start, end time.Time) ([]SearchResult, error) {
 	return m.Search(ctx, "", start, end)
 }
 
-// listFiles enumerates hourly memory files within [start, end].
-// Returns paths in chronological order.
+// listFiles enumerates hourly memory files within [start, end] in the
+// directories readDirs picks for ctx. Returns paths in chronological order.
 // Uses hour-by-hour iteration for predictable performance.
-func (m *Memory) listFiles(start, end time.Time) []string {
+func (m *Memory) listFiles(ctx context.Context, start, end time.Time) []string {
+	dirs := m.readDirs(ctx)
 	// Truncate to the start of the hour.
 	t := start.Truncate(time.Hour)
 	endTrunc := end.Truncate(time.Hour)
 
 	var files []string
 	for !t.After(endTrunc) {
-		path := m.hourlyPath(t)
-		if _, err := os.Stat(path); err == nil {
-			files = append(files, path)
+		for _, dir := range dirs {
+			path := hourFile(dir, t)
+			if _, err := os.Stat(path); err == nil {
+				files = append(files, path)
+			}
 		}
 		t = t.Add(time.Hour)
 	}
@@ -98,18 +233,28 @@ func (m *Memory) listFiles(start, end time.Time) []string {
 	return files
 }
 
+// listFilesPadded is listFiles over [start-pad, end+pad]. Callers must filter
+// entries by timestamp, since padded files may hold entries outside [start, end].
+func (m *Memory) listFilesPadded(ctx context.Context, start, end time.Time, pad time.Duration) []string {
+	return m.listFiles(ctx, start.Add(-pad), end.Add(pad))
+}
+
 // parseFile reads a memory file and returns parsed entries.
 // Entry format: ---\n**YYYY-MM-DD HH:MM** — source\ncontent\n\n
 // Malformed entries are skipped with a warning log.
-func (m *Memory) parseFile(path string) ([]SearchResult, error) {
-	data, err := os.ReadFile(path)
+func (m *Memory) parseFile(ctx context.Context, path string) ([]SearchResult, error) {
+	data, err := readWithRetry(ctx, path)
 	if err != nil {
 		return nil, fmt.Errorf("memory: parse_file: %w", err)
 	}
 
-	content := string(data)
+	return parseEntries(string(data), path), nil
+}
+
+// parseEntries parses the entries of memory file content read from path.
+func parseEntries(content, path string) []SearchResult {
 	if content == "" {
-		return nil, nil
+		return nil
 	}
 
 	// Split on "---\n" separator.
@@ -130,7 +275,7 @@ func (m *Memory) parseFile(path string) ([]SearchResult, error) {
 		results = append(results, result)
 	}
 
-	return results, nil
+	return results
 }
 
 // parseEntry parses a single entry segment into a SearchResult.
@@ -164,7 +309,7 @@ func parseEntry(seg, filePath string) (SearchResult, bool) {
 	}
 
 	timestampStr := strings.TrimSpace(parts[0])
-	source := strings.TrimSpace(parts[1])
+	source, tags := splitSourceTags(strings.TrimSpace(parts[1]))
 
 	// Parse in UTC explicitly. Memory.Write() formats timestamps using timeNow()
 	// (local time). The system assumes UTC deployment (Raspberry Pi default).
@@ -185,6 +330,47 @@ func parseEntry(seg, filePath string) (SearchResult, bool) {
 		Time:     t,
 		Source:   source,
 		Content:  entryContent,
+		Tags:     tags,
 		FilePath: filePath,
 	}, true
 }
+
+// splitSourceTags separates "source #tag1 #tag2" into the source and its tags.
+func splitSourceTags(s string) (string, []string) {
+	parts := strings.Split(s, " #")
+	if len(parts) == 1 {
+		return s, nil
+	}
+	return strings.TrimSpace(parts[0]), NormalizeTags(parts[1:])
+}
+
+// readWithRetry reads path, retrying transient failures up to maxReadAttempts times
+// with platform.Retry's backoff. Permanent failures (permission denied, missing
+// file) are returned immediately so the caller can skip the file.
+func readWithRetry(ctx context.Context, path string) ([]byte, error) {
+	var data []byte
+	var permanent error
+	err := platform.Retry(ctx, maxReadAttempts, readRetryDelay, func() error {
+		var err error
+		data, err = readFile(path)
+		if err != nil && !isRetryableReadError(err) {
+			permanent = err
+			return nil
+		}
+		return err
+	})
+	if permanent != nil {
+		return nil, permanent
+	}
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// isRetryableReadError reports whether err is a transient I/O condition worth retrying.
+func isRetryableReadError(err error) bool {
+	return errors.Is(err, syscall.EBUSY) ||
+		errors.Is(err, syscall.EAGAIN) ||
+		errors.Is(err, syscall.EINTR)
+}
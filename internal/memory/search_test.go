@@ -2,9 +2,13 @@ package memory
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
+	"syscall"
 	"testing"
 	"time"
 )
@@ -331,7 +335,7 @@ func TestListFiles_CorrectRange(t *testing.T) {
 	start := time.Date(2026, 3, 15, 6, 0, 0, 0, time.UTC)
 	end := time.Date(2026, 3, 15, 17, 59, 0, 0, time.UTC)
 
-	files := m.listFiles(start, end)
+	files := m.listFiles(context.Background(), start, end)
 	if len(files) != 12 {
 		t.Fatalf("expected 12 files, got %d", len(files))
 	}
@@ -343,7 +347,7 @@ func TestListFiles_EmptyDir(t *testing.T) {
 	start := time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)
 	end := time.Date(2026, 3, 15, 23, 59, 0, 0, time.UTC)
 
-	files := m.listFiles(start, end)
+	files := m.listFiles(context.Background(), start, end)
 	if len(files) != 0 {
 		t.Fatalf("expected 0 files for empty dir, got %d", len(files))
 	}
@@ -360,7 +364,7 @@ func TestListFiles_SkipsOutOfRange(t *testing.T) {
 	start := time.Date(2026, 3, 15, 14, 0, 0, 0, time.UTC)
 	end := time.Date(2026, 3, 15, 18, 0, 0, 0, time.UTC)
 
-	files := m.listFiles(start, end)
+	files := m.listFiles(context.Background(), start, end)
 	if len(files) != 0 {
 		t.Fatalf("expected 0 files (out of range), got %d", len(files))
 	}
@@ -375,7 +379,7 @@ func TestParseFile_CorrectFormat(t *testing.T) {
 			"---\n**2026-03-15 14:30** — heartbeat\nThird entry\n\n")
 
 	m := New(root)
-	results, err := m.parseFile(path)
+	results, err := m.parseFile(context.Background(), path)
 	if err != nil {
 		t.Fatalf("parseFile: %v", err)
 	}
@@ -415,7 +419,7 @@ func TestParseFile_MalformedEntry(t *testing.T) {
 			"---\nBad header without separator\n\n")
 
 	m := New(root)
-	results, err := m.parseFile(path)
+	results, err := m.parseFile(context.Background(), path)
 	if err != nil {
 		t.Fatalf("parseFile: %v", err)
 	}
@@ -436,7 +440,7 @@ func TestParseFile_MalformedTimestamp(t *testing.T) {
 			"---\n**2026-03-15 14:10** — agent\nGood entry\n\n")
 
 	m := New(root)
-	results, err := m.parseFile(path)
+	results, err := m.parseFile(context.Background(), path)
 	if err != nil {
 		t.Fatalf("parseFile: %v", err)
 	}
@@ -454,7 +458,7 @@ func TestParseFile_EmptyFile(t *testing.T) {
 	path := writeRawMemoryFile(t, root, ts, "")
 
 	m := New(root)
-	results, err := m.parseFile(path)
+	results, err := m.parseFile(context.Background(), path)
 	if err != nil {
 		t.Fatalf("parseFile: %v", err)
 	}
@@ -466,7 +470,7 @@ func TestParseFile_EmptyFile(t *testing.T) {
 func TestParseFile_FileNotFound(t *testing.T) {
 	root := t.TempDir()
 	m := New(root)
-	_, err := m.parseFile(filepath.Join(root, "nonexistent.md"))
+	_, err := m.parseFile(context.Background(), filepath.Join(root, "nonexistent.md"))
 	if err == nil {
 		t.Fatal("expected error for non-existent file, got nil")
 	}
@@ -566,6 +570,138 @@ func TestSearch_UnreadableFile(t *testing.T) {
 	}
 }
 
+func TestReadRange_SkipsCorruptFile(t *testing.T) {
+	root := t.TempDir()
+	writeRawMemoryFile(t, root, time.Date(2026, 3, 15, 10, 0, 0, 0, time.UTC),
+		"---\n**2026-03-15 10:05** — owner\nMorning\n\n")
+	unreadable := writeRawMemoryFile(t, root, time.Date(2026, 3, 15, 11, 0, 0, 0, time.UTC),
+		"---\n**2026-03-15 11:05** — owner\nLost\n\n")
+	writeRawMemoryFile(t, root, time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC),
+		"\x00\x01\xffnot a memory file")
+	writeRawMemoryFile(t, root, time.Date(2026, 3, 15, 14, 0, 0, 0, time.UTC),
+		"---\n**2026-03-15 14:10** — agent\nAfternoon\n\n")
+	stubReadFile(t, func(path string) ([]byte, error) {
+		if path == unreadable {
+			return nil, &fs.PathError{Op: "open", Path: path, Err: syscall.EIO}
+		}
+		return os.ReadFile(path)
+	})
+
+	m := New(root)
+	start := time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 3, 15, 23, 59, 0, 0, time.UTC)
+	results, err := m.ReadRange(context.Background(), start, end)
+	if err != nil {
+		t.Fatalf("ReadRange: %v, want the bad files skipped", err)
+	}
+	if len(results) != 2 || results[0].Content != "Morning" || results[1].Content != "Afternoon" {
+		t.Errorf("results = %+v, want the entries of the two good files", results)
+	}
+}
+
+// stubReadFile replaces readFile with fn and disables the retry backoff for the test.
+func stubReadFile(t *testing.T, fn func(string) ([]byte, error)) {
+	t.Helper()
+	origRead, origDelay := readFile, readRetryDelay
+	t.Cleanup(func() { readFile, readRetryDelay = origRead, origDelay })
+	readFile = fn
+	readRetryDelay = 0
+}
+
+func TestSearch_TransientReadErrorRetried(t *testing.T) {
+	root := t.TempDir()
+	ts := time.Date(2026, 3, 15, 14, 0, 0, 0, time.UTC)
+	writeRawMemoryFile(t, root, ts,
+		"---\n**2026-03-15 14:10** — owner\nEntry\n\n")
+
+	calls := 0
+	stubReadFile(t, func(path string) ([]byte, error) {
+		calls++
+		if calls == 1 {
+			return nil, &fs.PathError{Op: "open", Path: path, Err: syscall.EBUSY}
+		}
+		return os.ReadFile(path)
+	})
+
+	m := New(root)
+	start := time.Date(2026, 3, 15, 14, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 3, 15, 15, 0, 0, 0, time.UTC)
+
+	results, err := m.Search(context.Background(), "Entry", start, end)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result after retry, got %d", len(results))
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 read attempts, got %d", calls)
+	}
+}
+
+func TestSearch_PermanentReadErrorSkipped(t *testing.T) {
+	root := t.TempDir()
+	ts := time.Date(2026, 3, 15, 14, 0, 0, 0, time.UTC)
+	writeRawMemoryFile(t, root, ts,
+		"---\n**2026-03-15 14:10** — owner\nEntry\n\n")
+
+	calls := 0
+	stubReadFile(t, func(path string) ([]byte, error) {
+		calls++
+		return nil, &fs.PathError{Op: "open", Path: path, Err: syscall.EACCES}
+	})
+
+	m := New(root)
+	start := time.Date(2026, 3, 15, 14, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 3, 15, 15, 0, 0, 0, time.UTC)
+
+	results, err := m.Search(context.Background(), "Entry", start, end)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) != 0 {
+		t.Fatalf("expected 0 results (permission denied), got %d", len(results))
+	}
+	if calls != 1 {
+		t.Errorf("permanent error should not be retried, got %d attempts", calls)
+	}
+}
+
+func TestReadWithRetry_GivesUpAfterMaxAttempts(t *testing.T) {
+	calls := 0
+	stubReadFile(t, func(path string) ([]byte, error) {
+		calls++
+		return nil, fmt.Errorf("wrapped: %w", syscall.EAGAIN)
+	})
+
+	_, err := readWithRetry(context.Background(), "/memory/x.md")
+	if err == nil {
+		t.Fatal("expected error after exhausting retries")
+	}
+	if calls != maxReadAttempts {
+		t.Errorf("expected %d attempts, got %d", maxReadAttempts, calls)
+	}
+}
+
+func TestReadWithRetry_StopsOnCancel(t *testing.T) {
+	calls := 0
+	stubReadFile(t, func(path string) ([]byte, error) {
+		calls++
+		return nil, syscall.EBUSY
+	})
+	readRetryDelay = time.Hour
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := readWithRetry(ctx, "/memory/x.md")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 attempt before the cancelled backoff, got %d", calls)
+	}
+}
+
 func TestSearch_EntryOutsideDateRange(t *testing.T) {
 	root := t.TempDir()
 	// File is at hour 14, but entry timestamp is for a different time that's outside the search range.
@@ -592,6 +728,54 @@ func TestSearch_EntryOutsideDateRange(t *testing.T) {
 	}
 }
 
+func TestSearch_ClockSkewEntryInNeighbouringFile(t *testing.T) {
+	root := t.TempDir()
+	// An entry stamped 14:59 landed in the 16:00 hour file because the clock was
+	// corrected between choosing the file and stamping the entry.
+	writeRawMemoryFile(t, root, time.Date(2026, 3, 15, 16, 0, 0, 0, time.UTC),
+		"---\n**2026-03-15 14:59** — owner\nEdge entry\n\n"+
+			"---\n**2026-03-15 16:05** — owner\nLater entry\n\n")
+
+	m := New(root)
+	start := time.Date(2026, 3, 15, 14, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 3, 15, 15, 0, 0, 0, time.UTC)
+
+	results, err := m.ReadRange(context.Background(), start, end)
+	if err != nil {
+		t.Fatalf("ReadRange: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(results))
+	}
+	if results[0].Content != "Edge entry" {
+		t.Errorf("expected 'Edge entry', got %q", results[0].Content)
+	}
+}
+
+func TestListFilesPadded_IncludesNeighbouringHours(t *testing.T) {
+	root := t.TempDir()
+	for h := 12; h <= 17; h++ {
+		ts := time.Date(2026, 3, 15, h, 0, 0, 0, time.UTC)
+		writeRawMemoryFile(t, root, ts, "---\n**"+ts.Format("2006-01-02 15:04")+"** — owner\nEntry\n\n")
+	}
+
+	m := New(root)
+	start := time.Date(2026, 3, 15, 14, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 3, 15, 15, 0, 0, 0, time.UTC)
+
+	if files := m.listFiles(context.Background(), start, end); len(files) != 2 {
+		t.Fatalf("listFiles: expected 2 files, got %d", len(files))
+	}
+	files := m.listFilesPadded(context.Background(), start, end, time.Hour)
+	if len(files) != 4 {
+		t.Fatalf("listFilesPadded: expected 4 files, got %d", len(files))
+	}
+	if files[0] != m.hourlyPath(time.Date(2026, 3, 15, 13, 0, 0, 0, time.UTC)) ||
+		files[3] != m.hourlyPath(time.Date(2026, 3, 15, 16, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected padded window: %v", files)
+	}
+}
+
 func TestSearch_MultilineContent(t *testing.T) {
 	root := t.TempDir()
 	ts := time.Date(2026, 3, 15, 14, 0, 0, 0, time.UTC)
@@ -621,7 +805,7 @@ func TestParseFile_FilePathSet(t *testing.T) {
 		"---\n**2026-03-15 14:10** — owner\nEntry\n\n")
 
 	m := New(root)
-	results, err := m.parseFile(path)
+	results, err := m.parseFile(context.Background(), path)
 	if err != nil {
 		t.Fatalf("parseFile: %v", err)
 	}
@@ -633,3 +817,216 @@ func TestParseFile_FilePathSet(t *testing.T) {
 	}
 }
 
+
+func TestSearch_TagFilter(t *testing.T) {
+	origTimeNow := timeNow
+	t.Cleanup(func() { timeNow = origTimeNow })
+
+	root := t.TempDir()
+	m := New(root)
+	ctx := context.Background()
+
+	timeNow = fixedClock(2026, 3, 15, 14, 10)
+	if err := m.WriteTagged(ctx, "agent", "Kickoff for project X", []string{"#Project-X", "meeting"}); err != nil {
+		t.Fatalf("WriteTagged: %v", err)
+	}
+	timeNow = fixedClock(2026, 3, 15, 14, 20)
+	if err := m.Write(ctx, "owner", "Untagged project note"); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	timeNow = fixedClock(2026, 3, 15, 14, 30)
+	if err := m.WriteTagged(ctx, "agent", "Groceries", []string{"todo"}); err != nil {
+		t.Fatalf("WriteTagged: %v", err)
+	}
+
+	start := time.Date(2026, 3, 15, 14, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 3, 15, 15, 0, 0, 0, time.UTC)
+
+	results, err := m.Search(ctx, "", start, end, "project-x")
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) != 1 || results[0].Content != "Kickoff for project X" {
+		t.Fatalf("tag-filtered results = %+v, want only the project-x entry", results)
+	}
+	if results[0].Source != "agent" {
+		t.Errorf("Source = %q, want %q", results[0].Source, "agent")
+	}
+	if len(results[0].Tags) != 2 || results[0].Tags[0] != "project-x" || results[0].Tags[1] != "meeting" {
+		t.Errorf("Tags = %v, want [project-x meeting]", results[0].Tags)
+	}
+
+	// Keyword and tag filters combine.
+	results, err = m.Search(ctx, "project", start, end, "#TODO")
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("keyword+tag results = %+v, want none", results)
+	}
+
+	// Untagged search behaves as before and returns every entry.
+	results, err = m.Search(ctx, "project", start, end)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("untagged search results = %d, want 2", len(results))
+	}
+	if results[1].Source != "owner" || results[1].Tags != nil {
+		t.Errorf("untagged entry = %+v, want owner source without tags", results[1])
+	}
+}
+
+func TestParseEntry_Tags(t *testing.T) {
+	r, ok := parseEntry("**2026-03-15 14:23** — agent #alpha #beta\nbody", "f.md")
+	if !ok {
+		t.Fatal("expected entry to parse")
+	}
+	if r.Source != "agent" {
+		t.Errorf("Source = %q, want %q", r.Source, "agent")
+	}
+	if len(r.Tags) != 2 || r.Tags[0] != "alpha" || r.Tags[1] != "beta" {
+		t.Errorf("Tags = %v, want [alpha beta]", r.Tags)
+	}
+}
+
+func TestSearch_MaxResultsKeepsMostRecent(t *testing.T) {
+	root := t.TempDir()
+	// Five hourly files with two entries each: 14:10/14:40, 15:10/15:40, ...
+	for h := 14; h < 19; h++ {
+		ts := time.Date(2026, 3, 15, h, 0, 0, 0, time.UTC)
+		writeRawMemoryFile(t, root, ts,
+			fmt.Sprintf("---\n**2026-03-15 %d:10** — owner\nentry %d-a\n\n", h, h)+
+				fmt.Sprintf("---\n**2026-03-15 %d:40** — agent\nentry %d-b\n\n", h, h))
+	}
+
+	m := New(root)
+	m.SetMaxResults(3)
+	start := time.Date(2026, 3, 15, 14, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 3, 15, 19, 0, 0, 0, time.UTC)
+
+	results, err := m.ReadRange(context.Background(), start, end)
+	if err != nil {
+		t.Fatalf("ReadRange: %v", err)
+	}
+	want := []string{"entry 17-b", "entry 18-a", "entry 18-b"}
+	if len(results) != len(want) {
+		t.Fatalf("expected %d results, got %d", len(want), len(results))
+	}
+	for i, w := range want {
+		if results[i].Content != w {
+			t.Errorf("results[%d] = %q, want %q", i, results[i].Content, w)
+		}
+	}
+}
+
+func TestSearch_MaxResultsStopsScanning(t *testing.T) {
+	root := t.TempDir()
+	for h := 10; h < 20; h++ {
+		ts := time.Date(2026, 3, 15, h, 0, 0, 0, time.UTC)
+		writeRawMemoryFile(t, root, ts,
+			fmt.Sprintf("---\n**2026-03-15 %d:10** — owner\nmatch %d\n\n", h, h))
+	}
+
+	calls := 0
+	stubReadFile(t, func(path string) ([]byte, error) {
+		calls++
+		return os.ReadFile(path)
+	})
+
+	m := New(root)
+	m.SetMaxResults(2)
+	start := time.Date(2026, 3, 15, 10, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 3, 15, 20, 0, 0, 0, time.UTC)
+
+	results, err := m.Search(context.Background(), "match", start, end)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) != 2 || results[0].Content != "match 18" || results[1].Content != "match 19" {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+	if calls != 2 {
+		t.Errorf("expected scanning to stop after 2 files, read %d", calls)
+	}
+}
+
+func TestSearch_ZeroMaxResultsUnlimited(t *testing.T) {
+	root := t.TempDir()
+	for h := 10; h < 20; h++ {
+		ts := time.Date(2026, 3, 15, h, 0, 0, 0, time.UTC)
+		writeRawMemoryFile(t, root, ts,
+			fmt.Sprintf("---\n**2026-03-15 %d:10** — owner\nmatch %d\n\n", h, h))
+	}
+
+	m := New(root)
+	m.SetMaxResults(0)
+	start := time.Date(2026, 3, 15, 10, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 3, 15, 20, 0, 0, 0, time.UTC)
+
+	results, err := m.Search(context.Background(), "match", start, end)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) != 10 {
+		t.Fatalf("expected 10 results, got %d", len(results))
+	}
+	if results[0].Content != "match 10" || results[9].Content != "match 19" {
+		t.Errorf("results not chronological: first %q, last %q", results[0].Content, results[9].Content)
+	}
+}
+
+func TestSearchRegexp(t *testing.T) {
+	root := t.TempDir()
+	ts := time.Date(2026, 3, 15, 14, 0, 0, 0, time.UTC)
+	writeRawMemoryFile(t, root, ts,
+		"---\n**2026-03-15 14:02** — heartbeat\nDisk usage at 91%\n\n"+
+			"---\n**2026-03-15 14:10** — heartbeat\nDisk usage at 45%\n\n"+
+			"---\n**2026-03-15 14:20** — owner\nDisk is fine\n\n"+
+			"---\n**2026-03-15 14:55** — heartbeat\nDisk usage at 97%\n\n")
+
+	m := New(root)
+	// 14:55 falls outside the range and must be filtered like Search does.
+	start := time.Date(2026, 3, 15, 14, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 3, 15, 14, 30, 0, 0, time.UTC)
+
+	results, err := m.SearchRegexp(context.Background(), `\b\d{1,3}%`, start, end)
+	if err != nil {
+		t.Fatalf("SearchRegexp: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	if results[0].Content != "Disk usage at 91%" || results[1].Content != "Disk usage at 45%" {
+		t.Errorf("unexpected results: %q, %q", results[0].Content, results[1].Content)
+	}
+
+	results, err = m.SearchRegexp(context.Background(), `usage at 9\d%`, start, end)
+	if err != nil {
+		t.Fatalf("SearchRegexp: %v", err)
+	}
+	if len(results) != 1 || results[0].Content != "Disk usage at 91%" {
+		t.Errorf("expected only the 91%% entry, got %+v", results)
+	}
+}
+
+func TestSearchRegexp_InvalidPattern(t *testing.T) {
+	root := t.TempDir()
+	ts := time.Date(2026, 3, 15, 14, 0, 0, 0, time.UTC)
+	writeRawMemoryFile(t, root, ts, "---\n**2026-03-15 14:10** — owner\nEntry\n\n")
+
+	stubReadFile(t, func(path string) ([]byte, error) {
+		t.Errorf("file %s read despite an invalid pattern", path)
+		return os.ReadFile(path)
+	})
+
+	m := New(root)
+	_, err := m.SearchRegexp(context.Background(), `(unclosed`, ts, ts.Add(time.Hour))
+	if err == nil {
+		t.Fatal("expected an error for an invalid pattern")
+	}
+	if !strings.Contains(err.Error(), "memory: search_regexp") {
+		t.Errorf("error = %v, want it prefixed with memory: search_regexp", err)
+	}
+}
@@ -0,0 +1,79 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/edouard/pureclaw/internal/platform"
+)
+
+// Stats summarizes the entries stored in memory files.
+type Stats struct {
+	Files    int            // memory files read
+	Skipped  int            // memory files that could not be read
+	Bytes    int64          // total size of the files read
+	Entries  int            // entries counted
+	BySource map[string]int // entries per source, e.g. "owner", "heartbeat"
+	Earliest time.Time      // timestamp of the oldest entry counted (zero without entries)
+	Latest   time.Time      // timestamp of the newest entry counted (zero without entries)
+}
+
+// Stats counts the memory files and their entries. With since > 0 only the
+// hourly files of the last since are read and older entries are left out;
+// otherwise every file under memory/ is. Unreadable files are logged and skipped.
+func (m *Memory) Stats(ctx context.Context, since time.Duration) (Stats, error) {
+	var files []string
+	var cutoff time.Time
+	if since > 0 {
+		now := timeNow()
+		cutoff = now.Add(-since)
+		files = m.listFiles(ctx, cutoff, now)
+	} else {
+		var err error
+		files, err = m.allFiles(filepath.Join(m.root, "memory"))
+		if err != nil {
+			return Stats{}, fmt.Errorf("memory: stats: %w", err)
+		}
+	}
+
+	stats := Stats{BySource: make(map[string]int)}
+	for _, path := range files {
+		if err := ctx.Err(); err != nil {
+			return Stats{}, fmt.Errorf("memory: stats: %w", err)
+		}
+		info, err := os.Stat(path)
+		var entries []SearchResult
+		if err == nil {
+			entries, err = m.parseFile(ctx, path)
+		}
+		if err != nil {
+			platform.Log(ctx).Warn("failed to parse memory file",
+				"component", "memory",
+				"operation", "stats",
+				"path", path,
+				"error", err,
+			)
+			stats.Skipped++
+			continue
+		}
+		stats.Files++
+		stats.Bytes += info.Size()
+		for _, e := range entries {
+			if e.Time.Before(cutoff) {
+				continue
+			}
+			stats.Entries++
+			stats.BySource[e.Source]++
+			if stats.Earliest.IsZero() || e.Time.Before(stats.Earliest) {
+				stats.Earliest = e.Time
+			}
+			if e.Time.After(stats.Latest) {
+				stats.Latest = e.Time
+			}
+		}
+	}
+	return stats, nil
+}
@@ -0,0 +1,73 @@
+package memory
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStats_AllFiles(t *testing.T) {
+	m := New(t.TempDir())
+	writeIndexFixtures(t, m)
+
+	stats, err := m.Stats(context.Background(), 0)
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if stats.Files != 2 || stats.Entries != 3 || stats.Skipped != 0 {
+		t.Errorf("stats = %+v, want 2 files, 3 entries, none skipped", stats)
+	}
+	if stats.BySource["owner"] != 2 || stats.BySource["agent"] != 1 {
+		t.Errorf("BySource = %v, want owner 2, agent 1", stats.BySource)
+	}
+	if want := time.Date(2026, 3, 15, 14, 23, 0, 0, time.UTC); !stats.Earliest.Equal(want) {
+		t.Errorf("Earliest = %v, want %v", stats.Earliest, want)
+	}
+	if want := time.Date(2026, 3, 16, 9, 5, 0, 0, time.UTC); !stats.Latest.Equal(want) {
+		t.Errorf("Latest = %v, want %v", stats.Latest, want)
+	}
+	var size int64
+	for _, rel := range []string{"2026/03/15/14.md", "2026/03/16/09.md"} {
+		info, err := os.Stat(filepath.Join(m.root, "memory", filepath.FromSlash(rel)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		size += info.Size()
+	}
+	if stats.Bytes != size {
+		t.Errorf("Bytes = %d, want %d", stats.Bytes, size)
+	}
+}
+
+func TestStats_SinceSkipsOlderAndUnreadable(t *testing.T) {
+	m := New(t.TempDir())
+	writeIndexFixtures(t, m)
+	// A directory where an hourly file is expected cannot be read.
+	if err := os.MkdirAll(m.hourlyPath(time.Date(2026, 3, 16, 10, 0, 0, 0, time.UTC)), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	timeNow = fixedClock(2026, 3, 16, 10, 30)
+
+	stats, err := m.Stats(context.Background(), 2*time.Hour)
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if stats.Files != 1 || stats.Entries != 1 || stats.Skipped != 1 {
+		t.Errorf("stats = %+v, want 1 file, 1 entry, 1 skipped", stats)
+	}
+	if stats.BySource["owner"] != 1 || len(stats.BySource) != 1 {
+		t.Errorf("BySource = %v, want only owner 1", stats.BySource)
+	}
+}
+
+func TestStats_NoMemory(t *testing.T) {
+	stats, err := New(t.TempDir()).Stats(context.Background(), 0)
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if stats.Files != 0 || stats.Entries != 0 || !stats.Earliest.IsZero() {
+		t.Errorf("stats = %+v, want nothing", stats)
+	}
+}
@@ -5,8 +5,14 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
+// TempSuffix is appended to the target file name (followed by a random string)
+// to name the temp file used by AtomicWrite, e.g. "vault.enc.tmp123456".
+// The predictable prefix lets CleanupStaleTemps find leftovers from interrupted writes.
+const TempSuffix = ".tmp"
+
 // Replaceable for testing error paths.
 var (
 	osCreateTemp = os.CreateTemp
@@ -20,7 +26,7 @@ var (
 func AtomicWrite(path string, data []byte, perm os.FileMode) error {
 	dir := filepath.Dir(path)
 
-	tmp, err := osCreateTemp(dir, ".pureclaw-tmp-*")
+	tmp, err := osCreateTemp(dir, filepath.Base(path)+TempSuffix+"*")
 	if err != nil {
 		return fmt.Errorf("atomic write: create temp: %w", err)
 	}
@@ -55,3 +61,44 @@ func AtomicWrite(path string, data []byte, perm os.FileMode) error {
 	slog.Info("file written", "component", "platform", "operation", "atomic_write", "path", path)
 	return nil
 }
+
+// CleanupStaleTemps removes temp files left next to path by an AtomicWrite that
+// was interrupted before its rename (e.g. the process died mid-write).
+// It returns the number of files removed. The file at path itself is never touched.
+// Only call it when no AtomicWrite to path can be in progress.
+func CleanupStaleTemps(path string) (int, error) {
+	matches, err := filepath.Glob(globEscape(path) + TempSuffix + "*")
+	if err != nil {
+		return 0, fmt.Errorf("cleanup temps: %w", err)
+	}
+	removed := 0
+	for _, m := range matches {
+		if m == path {
+			continue
+		}
+		if err := os.Remove(m); err != nil {
+			slog.Warn("failed to remove stale temp file",
+				"component", "platform", "operation", "cleanup_temps",
+				"path", m, "error", err)
+			continue
+		}
+		removed++
+		slog.Warn("removed stale temp file",
+			"component", "platform", "operation", "cleanup_temps",
+			"path", m)
+	}
+	return removed, nil
+}
+
+// globEscape escapes glob metacharacters so path is matched literally.
+func globEscape(path string) string {
+	var b strings.Builder
+	for _, r := range path {
+		switch r {
+		case '*', '?', '[', '\\':
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
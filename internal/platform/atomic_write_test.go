@@ -142,7 +142,7 @@ func TestAtomicWrite_chmodError(t *testing.T) {
 	// Temp file should be cleaned up.
 	entries, _ := os.ReadDir(dir)
 	for _, e := range entries {
-		if strings.HasPrefix(e.Name(), ".pureclaw-tmp-") {
+		if strings.HasPrefix(e.Name(), "test.txt"+TempSuffix) {
 			t.Errorf("temp file not cleaned up: %s", e.Name())
 		}
 	}
@@ -169,7 +169,7 @@ func TestAtomicWrite_renameError(t *testing.T) {
 	// Temp file should be cleaned up.
 	entries, _ := os.ReadDir(dir)
 	for _, e := range entries {
-		if strings.HasPrefix(e.Name(), ".pureclaw-tmp-") {
+		if strings.HasPrefix(e.Name(), "test.txt"+TempSuffix) {
 			t.Errorf("temp file not cleaned up: %s", e.Name())
 		}
 	}
@@ -196,3 +196,64 @@ func TestAtomicWrite_noPartialWrite(t *testing.T) {
 		t.Fatalf("original file corrupted: got %q", got)
 	}
 }
+
+func TestAtomicWrite_tempNameUsesTargetPrefix(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "vault.enc")
+
+	var pattern string
+	orig := osCreateTemp
+	osCreateTemp = func(d, p string) (*os.File, error) {
+		pattern = p
+		return orig(d, p)
+	}
+	t.Cleanup(func() { osCreateTemp = orig })
+
+	if err := AtomicWrite(path, []byte("data"), 0600); err != nil {
+		t.Fatalf("AtomicWrite: %v", err)
+	}
+	if pattern != "vault.enc"+TempSuffix+"*" {
+		t.Errorf("temp pattern = %q, want %q", pattern, "vault.enc"+TempSuffix+"*")
+	}
+}
+
+func TestCleanupStaleTemps(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "vault[1].enc")
+	for name, content := range map[string]string{
+		"vault[1].enc":          "real",
+		"vault[1].enc.tmp123":   "stale",
+		"vault[1].enc.tmp98765": "stale",
+		"vault1.enc.tmp1":       "other",
+		"notes.md.tmp1":         "other",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	removed, err := CleanupStaleTemps(path)
+	if err != nil {
+		t.Fatalf("CleanupStaleTemps: %v", err)
+	}
+	if removed != 2 {
+		t.Errorf("removed = %d, want 2", removed)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	want := []string{"notes.md.tmp1", "vault1.enc.tmp1", "vault[1].enc"}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Errorf("remaining = %v, want %v", names, want)
+	}
+}
+
+func TestCleanupStaleTemps_noTemps(t *testing.T) {
+	removed, err := CleanupStaleTemps(filepath.Join(t.TempDir(), "vault.enc"))
+	if err != nil || removed != 0 {
+		t.Errorf("CleanupStaleTemps = %d, %v; want 0, nil", removed, err)
+	}
+}
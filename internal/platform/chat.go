@@ -0,0 +1,17 @@
+package platform
+
+import "context"
+
+type chatIDKey struct{}
+
+// WithChatID returns a context tagged with the Telegram chat a unit of work is for.
+func WithChatID(ctx context.Context, chatID int64) context.Context {
+	return context.WithValue(ctx, chatIDKey{}, chatID)
+}
+
+// ChatIDFrom returns the chat ctx is tagged with, and false if it has none
+// (e.g. heartbeats and CLI commands).
+func ChatIDFrom(ctx context.Context) (int64, bool) {
+	id, ok := ctx.Value(chatIDKey{}).(int64)
+	return id, ok
+}
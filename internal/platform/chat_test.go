@@ -0,0 +1,16 @@
+package platform
+
+import (
+	"context"
+	"testing"
+)
+
+func TestChatID(t *testing.T) {
+	if id, ok := ChatIDFrom(context.Background()); ok {
+		t.Errorf("ChatIDFrom(untagged) = %d, true; want false", id)
+	}
+	ctx := WithChatID(context.Background(), -100123)
+	if id, ok := ChatIDFrom(ctx); !ok || id != -100123 {
+		t.Errorf("ChatIDFrom = %d, %v; want -100123, true", id, ok)
+	}
+}
@@ -0,0 +1,22 @@
+package platform
+
+import "context"
+
+// Origin identifies what triggered a unit of work.
+type Origin string
+
+// OriginHeartbeat marks work started by a heartbeat tick rather than an owner message.
+const OriginHeartbeat Origin = "heartbeat"
+
+type originKey struct{}
+
+// WithOrigin returns a context tagged with o.
+func WithOrigin(ctx context.Context, o Origin) context.Context {
+	return context.WithValue(ctx, originKey{}, o)
+}
+
+// OriginFrom returns the origin ctx is tagged with, or "" for ordinary message handling.
+func OriginFrom(ctx context.Context) Origin {
+	o, _ := ctx.Value(originKey{}).(Origin)
+	return o
+}
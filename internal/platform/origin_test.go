@@ -0,0 +1,16 @@
+package platform
+
+import (
+	"context"
+	"testing"
+)
+
+func TestOrigin(t *testing.T) {
+	if got := OriginFrom(context.Background()); got != "" {
+		t.Errorf("OriginFrom(untagged) = %q, want empty", got)
+	}
+	ctx := WithOrigin(context.Background(), OriginHeartbeat)
+	if got := OriginFrom(ctx); got != OriginHeartbeat {
+		t.Errorf("OriginFrom = %q, want %q", got, OriginHeartbeat)
+	}
+}
@@ -0,0 +1,17 @@
+package platform
+
+import "context"
+
+type quietKey struct{}
+
+// WithQuiet returns a context marking that work done under it must not notify
+// the owners, e.g. a heartbeat while the owner is away. The work itself still runs.
+func WithQuiet(ctx context.Context) context.Context {
+	return context.WithValue(ctx, quietKey{}, true)
+}
+
+// IsQuiet reports whether ctx was marked by WithQuiet.
+func IsQuiet(ctx context.Context) bool {
+	quiet, _ := ctx.Value(quietKey{}).(bool)
+	return quiet
+}
@@ -0,0 +1,15 @@
+package platform
+
+import (
+	"context"
+	"testing"
+)
+
+func TestQuiet(t *testing.T) {
+	if IsQuiet(context.Background()) {
+		t.Error("IsQuiet(unmarked) = true, want false")
+	}
+	if !IsQuiet(WithQuiet(context.Background())) {
+		t.Error("IsQuiet(marked) = false, want true")
+	}
+}
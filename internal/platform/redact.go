@@ -0,0 +1,70 @@
+package platform
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// redactedMarker replaces every masked secret.
+const redactedMarker = "[REDACTED]"
+
+// DefaultRedactPatterns match common credential shapes. They are always applied;
+// configured patterns are added to them.
+var DefaultRedactPatterns = []string{
+	`\bsk-[A-Za-z0-9_-]{16,}`,               // sk-... API keys
+	`\b\d{6,12}:[A-Za-z0-9_-]{30,}`,         // Telegram bot tokens
+	`\bAKIA[0-9A-Z]{16}\b`,                  // AWS access key IDs
+	`\bgh[pousr]_[A-Za-z0-9]{30,}`,          // GitHub tokens
+	`(?i)\bbearer\s+[A-Za-z0-9._~+/-]{20,}`, // bearer tokens in headers
+}
+
+// Redactor masks secrets in text: exact secret values (the vault's contents)
+// and strings matching secret patterns. It is the one place secrets are masked,
+// in tool output, memory, webhooks and the startup banner alike.
+type Redactor struct {
+	secrets  []string // longest first, so a secret containing another is masked whole
+	patterns []*regexp.Regexp
+}
+
+// NewRedactor builds a Redactor for the given secret values and the default
+// patterns plus extra. It fails if a pattern does not compile.
+func NewRedactor(secrets, extra []string) (*Redactor, error) {
+	r := SecretRedactor(secrets)
+	for _, p := range slices.Concat(DefaultRedactPatterns, extra) {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("platform: redact pattern %q: %w", p, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+// SecretRedactor builds a Redactor that masks only the given secret values,
+// without any pattern.
+func SecretRedactor(secrets []string) *Redactor {
+	r := &Redactor{}
+	for _, s := range secrets {
+		if s != "" {
+			r.secrets = append(r.secrets, s)
+		}
+	}
+	slices.SortFunc(r.secrets, func(a, b string) int { return len(b) - len(a) })
+	return r
+}
+
+// Redact returns s with every secret masked. A nil Redactor returns s unchanged.
+func (r *Redactor) Redact(s string) string {
+	if r == nil || s == "" {
+		return s
+	}
+	for _, secret := range r.secrets {
+		s = strings.ReplaceAll(s, secret, redactedMarker)
+	}
+	for _, re := range r.patterns {
+		s = re.ReplaceAllString(s, redactedMarker)
+	}
+	return s
+}
@@ -2,14 +2,60 @@ package platform
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"sync/atomic"
 	"time"
 )
 
+// ErrRetryBudgetExhausted is returned by Retry when the context's retry budget is spent.
+var ErrRetryBudgetExhausted = errors.New("retry budget exhausted")
+
+// RetryBudget caps the total number of retries shared by every Retry call
+// made with the same context. It is safe for concurrent use.
+type RetryBudget struct {
+	remaining atomic.Int64
+}
+
+// NewRetryBudget creates a budget allowing n retries in total.
+func NewRetryBudget(n int) *RetryBudget {
+	b := &RetryBudget{}
+	b.remaining.Store(int64(n))
+	return b
+}
+
+// Remaining returns the number of retries still available.
+func (b *RetryBudget) Remaining() int {
+	return int(max(b.remaining.Load(), 0))
+}
+
+// take consumes one retry. Returns false if the budget is already spent.
+func (b *RetryBudget) take() bool {
+	return b.remaining.Add(-1) >= 0
+}
+
+type retryBudgetKey struct{}
+
+// WithRetryBudget returns a context carrying b. Every Retry call using the
+// returned context (or a child of it) draws its retries from b.
+func WithRetryBudget(ctx context.Context, b *RetryBudget) context.Context {
+	return context.WithValue(ctx, retryBudgetKey{}, b)
+}
+
+// RetryBudgetFrom returns the retry budget carried by ctx, or nil if none.
+func RetryBudgetFrom(ctx context.Context) *RetryBudget {
+	b, _ := ctx.Value(retryBudgetKey{}).(*RetryBudget)
+	return b
+}
+
 // Retry calls fn up to maxAttempts times with exponential backoff.
 // Backoff: baseDelay * 2^attempt. Respects context cancellation.
 // Returns nil immediately if maxAttempts <= 0 (fn is never called).
+// If ctx carries a RetryBudget, each retry consumes one unit and Retry stops
+// early with ErrRetryBudgetExhausted (wrapping the last error) once it is spent.
 func Retry(ctx context.Context, maxAttempts int, baseDelay time.Duration, fn func() error) error {
+	budget := RetryBudgetFrom(ctx)
 	var lastErr error
 	for attempt := range maxAttempts {
 		lastErr = fn()
@@ -30,6 +76,15 @@ func Retry(ctx context.Context, maxAttempts int, baseDelay time.Duration, fn fun
 			break
 		}
 
+		if budget != nil && !budget.take() {
+			slog.Warn("retry budget exhausted",
+				"component", "platform",
+				"operation", "retry",
+				"attempt", attempt+1,
+			)
+			return fmt.Errorf("%w: %w", ErrRetryBudgetExhausted, lastErr)
+		}
+
 		delay := baseDelay * (1 << attempt)
 		timer := time.NewTimer(delay)
 		select {
@@ -136,3 +136,68 @@ func TestRetry_zeroAttempts(t *testing.T) {
 		t.Fatalf("expected 0 calls, got %d", calls)
 	}
 }
+
+func TestRetry_budgetLimitsRetries(t *testing.T) {
+	budget := NewRetryBudget(1)
+	ctx := WithRetryBudget(context.Background(), budget)
+	sentinel := errors.New("persistent error")
+	calls := 0
+	err := Retry(ctx, 5, time.Millisecond, func() error {
+		calls++
+		return sentinel
+	})
+	if calls != 2 {
+		t.Fatalf("expected 2 calls (1 attempt + 1 budgeted retry), got %d", calls)
+	}
+	if !errors.Is(err, ErrRetryBudgetExhausted) {
+		t.Fatalf("expected ErrRetryBudgetExhausted, got %v", err)
+	}
+	if !errors.Is(err, sentinel) {
+		t.Fatalf("expected wrapped sentinel error, got %v", err)
+	}
+	if budget.Remaining() != 0 {
+		t.Fatalf("expected remaining 0, got %d", budget.Remaining())
+	}
+}
+
+func TestRetry_budgetSharedAcrossCalls(t *testing.T) {
+	budget := NewRetryBudget(2)
+	ctx := WithRetryBudget(context.Background(), budget)
+
+	first := 0
+	if err := Retry(ctx, 3, time.Millisecond, func() error {
+		first++
+		if first < 3 {
+			return errors.New("flaky")
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("first Retry: unexpected error %v", err)
+	}
+
+	second := 0
+	err := Retry(ctx, 3, time.Millisecond, func() error {
+		second++
+		return errors.New("down")
+	})
+	if second != 1 {
+		t.Fatalf("expected second Retry to make 1 call, got %d", second)
+	}
+	if !errors.Is(err, ErrRetryBudgetExhausted) {
+		t.Fatalf("expected ErrRetryBudgetExhausted, got %v", err)
+	}
+}
+
+func TestRetry_noBudgetUnlimited(t *testing.T) {
+	if RetryBudgetFrom(context.Background()) != nil {
+		t.Fatal("expected nil budget on bare context")
+	}
+	calls := 0
+	Retry(context.Background(), 4, time.Millisecond, func() error {
+		calls++
+		return errors.New("fail")
+	})
+	if calls != 4 {
+		t.Fatalf("expected 4 calls, got %d", calls)
+	}
+}
@@ -0,0 +1,39 @@
+package platform
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+)
+
+type traceIDKey struct{}
+
+// NewTraceID returns a random 16-character hex identifier for correlating the
+// logs of one unit of work (typically one incoming message).
+func NewTraceID() string {
+	var b [8]byte
+	rand.Read(b[:]) // crypto/rand.Read never returns an error
+	return hex.EncodeToString(b[:])
+}
+
+// WithTraceID returns a context carrying id.
+func WithTraceID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, traceIDKey{}, id)
+}
+
+// TraceIDFrom returns the trace ID carried by ctx, or "" if none.
+func TraceIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(traceIDKey{}).(string)
+	return id
+}
+
+// Log returns the default logger, annotated with ctx's trace ID when it carries one.
+// Components log through it wherever a context is available so that every line
+// emitted while handling one message can be correlated.
+func Log(ctx context.Context) *slog.Logger {
+	if id := TraceIDFrom(ctx); id != "" {
+		return slog.Default().With("trace_id", id)
+	}
+	return slog.Default()
+}
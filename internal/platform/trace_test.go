@@ -0,0 +1,46 @@
+package platform
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestTraceID_RoundTrip(t *testing.T) {
+	if got := TraceIDFrom(context.Background()); got != "" {
+		t.Errorf("TraceIDFrom(empty) = %q, want empty", got)
+	}
+	id := NewTraceID()
+	if len(id) != 16 {
+		t.Errorf("NewTraceID() = %q, want 16 hex characters", id)
+	}
+	if id == NewTraceID() {
+		t.Error("NewTraceID returned the same ID twice")
+	}
+	if got := TraceIDFrom(WithTraceID(context.Background(), id)); got != id {
+		t.Errorf("TraceIDFrom = %q, want %q", got, id)
+	}
+}
+
+func TestLog_AddsTraceID(t *testing.T) {
+	var buf bytes.Buffer
+	orig := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(orig) })
+
+	Log(WithTraceID(context.Background(), "abc123")).Info("traced")
+	Log(context.Background()).Info("untraced")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d log lines, want 2", len(lines))
+	}
+	if !strings.Contains(lines[0], "trace_id=abc123") {
+		t.Errorf("traced line = %q, want trace_id", lines[0])
+	}
+	if strings.Contains(lines[1], "trace_id") {
+		t.Errorf("untraced line = %q, want no trace_id", lines[1])
+	}
+}
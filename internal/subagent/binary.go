@@ -0,0 +1,82 @@
+package subagent
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// binaryName is the executable looked up on PATH when no other candidate works.
+const binaryName = "pureclaw"
+
+// Replaceable for testing.
+var (
+	osExecutable = os.Executable
+	lookPath     = exec.LookPath
+)
+
+// ResolveBinary returns the pureclaw binary used to launch sub-agents. Candidates
+// are tried in order: the configured path (if any), the running executable, then
+// "pureclaw" on PATH. The first one that is a regular executable file wins.
+// If none qualifies, the error lists why each candidate was rejected.
+func ResolveBinary(configured string) (string, error) {
+	type candidate struct {
+		source string
+		find   func() (string, error)
+	}
+	candidates := []candidate{
+		{"os executable", osExecutable},
+		{"PATH lookup of " + binaryName, func() (string, error) { return lookPath(binaryName) }},
+	}
+	if configured != "" {
+		candidates = append([]candidate{{"configured sub_agent_binary", func() (string, error) { return configured, nil }}}, candidates...)
+	}
+
+	var problems []string
+	for _, c := range candidates {
+		path, err := c.find()
+		if err == nil {
+			err = checkExecutable(path)
+		}
+		if err != nil {
+			slog.Warn("sub-agent binary candidate rejected",
+				"component", "subagent",
+				"operation", "resolve_binary",
+				"source", c.source,
+				"path", path,
+				"error", err,
+			)
+			problems = append(problems, fmt.Sprintf("%s: %v", c.source, err))
+			continue
+		}
+		slog.Info("sub-agent binary resolved",
+			"component", "subagent",
+			"operation", "resolve_binary",
+			"source", c.source,
+			"path", path,
+		)
+		return path, nil
+	}
+	return "", fmt.Errorf("subagent: resolve_binary: no usable pureclaw binary (%s)", strings.Join(problems, "; "))
+}
+
+// checkExecutable reports an error unless path is a regular file with an execute bit set.
+func checkExecutable(path string) error {
+	if path == "" {
+		return errors.New("empty path")
+	}
+	info, err := osStat(path)
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("%s is not a regular file", path)
+	}
+	if info.Mode().Perm()&0o111 == 0 {
+		return fmt.Errorf("%s is not executable", path)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package subagent
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// stubBinaryLookup replaces os.Executable and PATH lookup for one test.
+func stubBinaryLookup(t *testing.T, executable func() (string, error), look func(string) (string, error)) {
+	t.Helper()
+	origExecutable, origLookPath := osExecutable, lookPath
+	t.Cleanup(func() { osExecutable, lookPath = origExecutable, origLookPath })
+	osExecutable = executable
+	lookPath = look
+}
+
+func writeBinary(t *testing.T, name string, perm os.FileMode) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"), perm); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestResolveBinary_Configured(t *testing.T) {
+	configured := writeBinary(t, "pureclaw-custom", 0o755)
+	self := writeBinary(t, "pureclaw", 0o755)
+	stubBinaryLookup(t,
+		func() (string, error) { return self, nil },
+		func(string) (string, error) { return "", errors.New("not on PATH") },
+	)
+
+	got, err := ResolveBinary(configured)
+	if err != nil {
+		t.Fatalf("ResolveBinary: %v", err)
+	}
+	if got != configured {
+		t.Errorf("got %q, want configured %q", got, configured)
+	}
+}
+
+func TestResolveBinary_FallsBackToExecutable(t *testing.T) {
+	notExecutable := writeBinary(t, "pureclaw-custom", 0o644)
+	self := writeBinary(t, "pureclaw", 0o755)
+	stubBinaryLookup(t,
+		func() (string, error) { return self, nil },
+		func(string) (string, error) { return "", errors.New("not on PATH") },
+	)
+
+	got, err := ResolveBinary(notExecutable)
+	if err != nil {
+		t.Fatalf("ResolveBinary: %v", err)
+	}
+	if got != self {
+		t.Errorf("got %q, want os executable %q", got, self)
+	}
+}
+
+func TestResolveBinary_FallsBackToPath(t *testing.T) {
+	onPath := writeBinary(t, "pureclaw", 0o755)
+	stubBinaryLookup(t,
+		func() (string, error) { return "", errors.New("executable unknown") },
+		func(name string) (string, error) {
+			if name != "pureclaw" {
+				t.Errorf("lookPath(%q), want pureclaw", name)
+			}
+			return onPath, nil
+		},
+	)
+
+	got, err := ResolveBinary("")
+	if err != nil {
+		t.Fatalf("ResolveBinary: %v", err)
+	}
+	if got != onPath {
+		t.Errorf("got %q, want PATH result %q", got, onPath)
+	}
+}
+
+func TestResolveBinary_AllFail(t *testing.T) {
+	dir := t.TempDir()
+	stubBinaryLookup(t,
+		func() (string, error) { return dir, nil }, // a directory, not a file
+		func(string) (string, error) { return "", errors.New("not on PATH") },
+	)
+
+	_, err := ResolveBinary(filepath.Join(dir, "missing"))
+	if err == nil {
+		t.Fatal("expected error when no candidate is usable")
+	}
+	for _, want := range []string{"no usable pureclaw binary", "configured sub_agent_binary", "not a regular file", "not on PATH"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q should mention %q", err, want)
+		}
+	}
+}
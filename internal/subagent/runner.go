@@ -21,6 +21,20 @@ type SubAgentResult struct {
 	ResultContent string // Contents of result.md, empty if not found
 	Err           error
 	TimedOut      bool
+	Duration      time.Duration // Wall-clock run time of the subprocess
+}
+
+// DeadlineEnv passes a sub-agent its working deadline, as a duration string.
+// It is shorter than the launch timeout so the sub-agent can write a partial
+// result.md and exit before the parent kills it.
+const DeadlineEnv = "PURECLAW_SUBAGENT_DEADLINE"
+
+// ResultDeadline returns how long a sub-agent killed after timeout may work:
+// a tenth of timeout, between 10s and 1m but never more than half of it, is
+// reserved for writing the result.
+func ResultDeadline(timeout time.Duration) time.Duration {
+	reserve := min(max(timeout/10, 10*time.Second), time.Minute, timeout/2)
+	return timeout - reserve
 }
 
 // RunnerConfig holds parameters for launching a sub-agent subprocess.
@@ -31,23 +45,52 @@ type RunnerConfig struct {
 	Timeout       time.Duration // Maximum execution time
 	ConfigPath    string        // Path to parent's config.json
 	VaultPath     string        // Path to parent's vault.enc
+
+	// Inline, when set, receives the result instead of the event loop while
+	// the caller waits on it. Once Abandoned is closed the result goes to the
+	// event loop as usual.
+	Inline    chan<- SubAgentResult
+	Abandoned <-chan struct{}
 }
 
 // Runner manages sub-agent subprocess lifecycle.
 type Runner struct {
-	mu     sync.Mutex
-	active bool
-	done   chan struct{} // closed when watchSubAgent completes
+	mu            sync.Mutex
+	active        bool          // true while at least one sub-agent is running
+	running       int           // number of sub-agents currently running
+	maxConcurrent int           // maximum number of sub-agents running at once
+	done          chan struct{} // closed when the last running sub-agent completes
 }
 
-// NewRunner creates a new sub-agent runner.
+// NewRunner creates a sub-agent runner that allows one sub-agent at a time.
 func NewRunner() *Runner {
-	slog.Info("runner created", "component", "subagent", "operation", "new_runner")
+	return NewConcurrentRunner(1)
+}
+
+// NewConcurrentRunner creates a sub-agent runner that allows up to maxConcurrent
+// sub-agents to run at once. Values below 1 are treated as 1.
+func NewConcurrentRunner(maxConcurrent int) *Runner {
+	if maxConcurrent < 1 {
+		maxConcurrent = 1
+	}
+	slog.Info("runner created", "component", "subagent", "operation", "new_runner",
+		"max_concurrent", maxConcurrent)
 	return &Runner{
-		done: make(chan struct{}),
+		maxConcurrent: maxConcurrent,
+		done:          make(chan struct{}),
 	}
 }
 
+// ResultBufferSize returns the capacity the result channel passed to LaunchSubAgent
+// needs so that every concurrently running sub-agent can deliver its result without
+// blocking its watcher goroutine, even if all of them complete at the same moment.
+func ResultBufferSize(maxConcurrent int) int {
+	if maxConcurrent < 1 {
+		return 1
+	}
+	return maxConcurrent
+}
+
 // IsActive returns whether a sub-agent is currently running.
 func (r *Runner) IsActive() bool {
 	r.mu.Lock()
@@ -55,7 +98,7 @@ func (r *Runner) IsActive() bool {
 	return r.active
 }
 
-// WaitForCompletion blocks until the active sub-agent completes or ctx expires.
+// WaitForCompletion blocks until all running sub-agents complete or ctx expires.
 // Returns nil immediately if no sub-agent is active.
 func (r *Runner) WaitForCompletion(ctx context.Context) error {
 	r.mu.Lock()
@@ -77,33 +120,34 @@ func (r *Runner) WaitForCompletion(ctx context.Context) error {
 // LaunchSubAgent spawns a sub-agent as a subprocess with timeout enforcement.
 // Non-blocking: starts the subprocess and a watcher goroutine that sends
 // the result on resultCh when the subprocess completes or times out.
-// Returns error immediately if another sub-agent is already active.
+// Returns error immediately if the maximum number of sub-agents is already active.
+// resultCh must have a capacity of at least ResultBufferSize(maxConcurrent).
 func (r *Runner) LaunchSubAgent(ctx context.Context, cfg RunnerConfig, resultCh chan<- SubAgentResult) error {
 	r.mu.Lock()
-	if r.active {
+	if r.running >= r.maxConcurrent {
+		err := fmt.Errorf("sub-agent already active (%d/%d running)", r.running, r.maxConcurrent)
 		r.mu.Unlock()
-		return fmt.Errorf("sub-agent already active")
+		return err
+	}
+	if r.running == 0 {
+		r.done = make(chan struct{})
 	}
+	r.running++
 	r.active = true
-	r.done = make(chan struct{})
 	r.mu.Unlock()
 
 	// Resolve to absolute path so the subprocess can find its workspace
 	// regardless of the parent's working directory.
 	absPath, err := filepath.Abs(cfg.WorkspacePath)
 	if err != nil {
-		r.mu.Lock()
-		r.active = false
-		r.mu.Unlock()
+		r.release()
 		return fmt.Errorf("resolve workspace path: %w", err)
 	}
 	cfg.WorkspacePath = absPath
 
 	// Validate workspace exists.
 	if _, err := osStat(cfg.WorkspacePath); err != nil {
-		r.mu.Lock()
-		r.active = false
-		r.mu.Unlock()
+		r.release()
 		if os.IsNotExist(err) {
 			return fmt.Errorf("workspace path does not exist: %s", cfg.WorkspacePath)
 		}
@@ -112,27 +156,21 @@ func (r *Runner) LaunchSubAgent(ctx context.Context, cfg RunnerConfig, resultCh
 
 	// Validate workspace is within its parent directory (path traversal guard).
 	if err := platform.ValidatePath(filepath.Dir(cfg.WorkspacePath), cfg.WorkspacePath); err != nil {
-		r.mu.Lock()
-		r.active = false
-		r.mu.Unlock()
+		r.release()
 		return fmt.Errorf("invalid workspace path: %w", err)
 	}
 
 	// Resolve config and vault to absolute paths for the subprocess.
 	absConfig, err := filepath.Abs(cfg.ConfigPath)
 	if err != nil {
-		r.mu.Lock()
-		r.active = false
-		r.mu.Unlock()
+		r.release()
 		return fmt.Errorf("resolve config path: %w", err)
 	}
 	cfg.ConfigPath = absConfig
 
 	absVault, err := filepath.Abs(cfg.VaultPath)
 	if err != nil {
-		r.mu.Lock()
-		r.active = false
-		r.mu.Unlock()
+		r.release()
 		return fmt.Errorf("resolve vault path: %w", err)
 	}
 	cfg.VaultPath = absVault
@@ -142,6 +180,11 @@ func (r *Runner) LaunchSubAgent(ctx context.Context, cfg RunnerConfig, resultCh
 	cmd := execCommand(timeoutCtx, cfg.BinaryPath, "run", "--agent", cfg.WorkspacePath,
 		"--config", cfg.ConfigPath, "--vault", cfg.VaultPath)
 	cmd.Dir = cfg.WorkspacePath
+	env := cmd.Env
+	if env == nil {
+		env = os.Environ()
+	}
+	cmd.Env = append(env, DeadlineEnv+"="+ResultDeadline(cfg.Timeout).String())
 	cmd.Stdout = os.Stderr // Sub-agent logs to parent's stderr
 	cmd.Stderr = os.Stderr
 
@@ -161,9 +204,7 @@ func (r *Runner) LaunchSubAgent(ctx context.Context, cfg RunnerConfig, resultCh
 
 	if err := cmd.Start(); err != nil {
 		cancel()
-		r.mu.Lock()
-		r.active = false
-		r.mu.Unlock()
+		r.release()
 		return fmt.Errorf("start sub-agent: %w", err)
 	}
 
@@ -182,7 +223,9 @@ func (r *Runner) watchSubAgent(timeoutCtx context.Context, cancel context.Cancel
 	}
 
 	// Wait for subprocess to complete.
+	start := time.Now()
 	err := cmd.Wait()
+	result.Duration = time.Since(start)
 	if err != nil {
 		if timeoutCtx.Err() == context.DeadlineExceeded {
 			result.TimedOut = true
@@ -216,19 +259,47 @@ func (r *Runner) watchSubAgent(timeoutCtx context.Context, cancel context.Cancel
 			"task_id", cfg.TaskID, "error", readErr)
 	}
 
-	// Release active flag and signal completion BEFORE sending result so callers
+	// Release the slot and signal completion BEFORE sending result so callers
 	// can immediately launch another sub-agent after receiving the result, and
 	// WaitForCompletion unblocks before the result is processed by the event loop.
-	r.mu.Lock()
-	r.active = false
-	done := r.done
-	r.mu.Unlock()
-	close(done)
+	r.release()
 
-	// Send result to event loop. The channel must be buffered (capacity >= 1).
+	deliverResult(cfg, resultCh, result)
+}
+
+// deliverResult hands result to a caller waiting inline or, once it stopped
+// waiting, to the event loop. Without a result channel the result is dropped.
+func deliverResult(cfg RunnerConfig, resultCh chan<- SubAgentResult, result SubAgentResult) {
+	if cfg.Inline != nil {
+		select {
+		case cfg.Inline <- result:
+			return
+		case <-cfg.Abandoned:
+		}
+	}
+	if resultCh == nil {
+		slog.Warn("sub-agent result dropped, no one is waiting for it",
+			"component", "subagent", "operation", "watch",
+			"task_id", cfg.TaskID)
+		return
+	}
+
+	// Send result to event loop. The channel must be buffered with
+	// ResultBufferSize(maxConcurrent) so simultaneous completions never block.
 	resultCh <- result
 }
 
+// release frees one running slot and closes done when no sub-agent remains.
+func (r *Runner) release() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.running--
+	r.active = r.running > 0
+	if r.running == 0 {
+		close(r.done)
+	}
+}
+
 // Replaceable vars for testing.
 var (
 	execCommand = exec.CommandContext
@@ -7,6 +7,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -120,6 +121,9 @@ func TestLaunchSubAgent_Success(t *testing.T) {
 		if result.TimedOut {
 			t.Error("TimedOut = true, want false")
 		}
+		if result.Duration <= 0 {
+			t.Errorf("Duration = %s, want the measured run time", result.Duration)
+		}
 	case <-time.After(10 * time.Second):
 		t.Fatal("timed out waiting for SubAgentResult")
 	}
@@ -788,3 +792,213 @@ func TestRunner_SIGTERM_SentToSubAgent(t *testing.T) {
 		t.Fatal("timed out waiting for SubAgentResult — SIGTERM may not have been sent")
 	}
 }
+
+func TestResultBufferSize(t *testing.T) {
+	tests := []struct {
+		max  int
+		want int
+	}{
+		{0, 1},
+		{-3, 1},
+		{1, 1},
+		{4, 4},
+	}
+	for _, tt := range tests {
+		if got := ResultBufferSize(tt.max); got != tt.want {
+			t.Errorf("ResultBufferSize(%d) = %d, want %d", tt.max, got, tt.want)
+		}
+	}
+}
+
+func TestLaunchSubAgent_ConcurrentCompletionsDoNotBlock(t *testing.T) {
+	saveRunnerVars(t)
+	execCommand = fakeCmd(0, 200)
+	osReadFile = func(path string) ([]byte, error) {
+		return nil, os.ErrNotExist
+	}
+
+	const maxConcurrent = 4
+	r := NewConcurrentRunner(maxConcurrent)
+	resultCh := make(chan SubAgentResult, ResultBufferSize(maxConcurrent))
+
+	for i := 0; i < maxConcurrent; i++ {
+		err := r.LaunchSubAgent(context.Background(), RunnerConfig{
+			BinaryPath:    os.Args[0],
+			WorkspacePath: t.TempDir(),
+			TaskID:        fmt.Sprintf("task-%d", i),
+			Timeout:       10 * time.Second,
+			ConfigPath:    "/tmp/config.json",
+			VaultPath:     "/tmp/vault.enc",
+		}, resultCh)
+		if err != nil {
+			t.Fatalf("LaunchSubAgent(%d) error = %v", i, err)
+		}
+	}
+
+	// One more than the limit is rejected while all slots are taken.
+	err := r.LaunchSubAgent(context.Background(), RunnerConfig{
+		BinaryPath:    os.Args[0],
+		WorkspacePath: t.TempDir(),
+		TaskID:        "overflow",
+		Timeout:       10 * time.Second,
+		ConfigPath:    "/tmp/config.json",
+		VaultPath:     "/tmp/vault.enc",
+	}, resultCh)
+	if err == nil || !strings.Contains(err.Error(), "sub-agent already active") {
+		t.Fatalf("overflow launch error = %v, want 'sub-agent already active'", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := r.WaitForCompletion(ctx); err != nil {
+		t.Fatalf("WaitForCompletion() error = %v", err)
+	}
+
+	// Without draining the channel, every result must land in the buffer:
+	// a blocked watcher would leave fewer than maxConcurrent queued.
+	deadline := time.Now().Add(5 * time.Second)
+	for len(resultCh) < maxConcurrent && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(resultCh) != maxConcurrent {
+		t.Fatalf("queued results = %d, want %d", len(resultCh), maxConcurrent)
+	}
+
+	seen := map[string]bool{}
+	for i := 0; i < maxConcurrent; i++ {
+		res := <-resultCh
+		if res.Err != nil {
+			t.Errorf("result %s error = %v", res.TaskID, res.Err)
+		}
+		seen[res.TaskID] = true
+	}
+	if len(seen) != maxConcurrent {
+		t.Errorf("distinct results = %d, want %d", len(seen), maxConcurrent)
+	}
+	if r.IsActive() {
+		t.Error("runner should not be active after all sub-agents complete")
+	}
+}
+
+func TestResultDeadline(t *testing.T) {
+	tests := []struct {
+		timeout, want time.Duration
+	}{
+		{5 * time.Minute, 4*time.Minute + 30*time.Second},
+		{time.Hour, 59 * time.Minute},
+		{time.Minute, 50 * time.Second},
+		{10 * time.Second, 5 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := ResultDeadline(tt.timeout); got != tt.want {
+			t.Errorf("ResultDeadline(%s) = %s, want %s", tt.timeout, got, tt.want)
+		}
+	}
+}
+
+func TestLaunchSubAgent_PassesDeadline(t *testing.T) {
+	saveRunnerVars(t)
+
+	var launched *exec.Cmd
+	var baseEnv int
+	fake := fakeCmd(0, 10)
+	execCommand = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		launched = fake(ctx, name, args...)
+		baseEnv = len(launched.Env)
+		return launched
+	}
+
+	r := NewRunner()
+	resultCh := make(chan SubAgentResult, 1)
+	err := r.LaunchSubAgent(context.Background(), RunnerConfig{
+		BinaryPath:    os.Args[0],
+		WorkspacePath: t.TempDir(),
+		TaskID:        "deadline",
+		Timeout:       5 * time.Minute,
+		ConfigPath:    "/tmp/config.json",
+		VaultPath:     "/tmp/vault.enc",
+	}, resultCh)
+	if err != nil {
+		t.Fatalf("LaunchSubAgent() error = %v", err)
+	}
+	<-resultCh
+
+	if !slices.Contains(launched.Env, DeadlineEnv+"=4m30s") {
+		t.Errorf("sub-agent env lacks %s=4m30s", DeadlineEnv)
+	}
+	if !slices.Contains(launched.Env, "GO_HELPER_PROCESS=1") || len(launched.Env) != baseEnv+1 {
+		t.Error("sub-agent env should extend the command's own environment")
+	}
+}
+
+func TestLaunchSubAgent_Inline(t *testing.T) {
+	saveRunnerVars(t)
+	execCommand = fakeCmd(0, 10)
+	osReadFile = func(path string) ([]byte, error) {
+		return []byte("inline result"), nil
+	}
+
+	launch := func(r *Runner, inline chan SubAgentResult, abandoned chan struct{}, resultCh chan SubAgentResult) {
+		t.Helper()
+		err := r.LaunchSubAgent(context.Background(), RunnerConfig{
+			BinaryPath:    os.Args[0],
+			WorkspacePath: t.TempDir(),
+			TaskID:        "inline",
+			Timeout:       5 * time.Second,
+			ConfigPath:    "/tmp/config.json",
+			VaultPath:     "/tmp/vault.enc",
+			Inline:        inline,
+			Abandoned:     abandoned,
+		}, resultCh)
+		if err != nil {
+			t.Fatalf("LaunchSubAgent() error = %v", err)
+		}
+	}
+
+	t.Run("waiting caller takes the result", func(t *testing.T) {
+		inline, resultCh := make(chan SubAgentResult), make(chan SubAgentResult, 1)
+		launch(NewRunner(), inline, make(chan struct{}), resultCh)
+		select {
+		case res := <-inline:
+			if res.ResultContent != "inline result" {
+				t.Errorf("ResultContent = %q, want %q", res.ResultContent, "inline result")
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatal("timed out waiting for the inline result")
+		}
+		if len(resultCh) != 0 {
+			t.Error("inline result was also sent to the event loop")
+		}
+	})
+
+	t.Run("abandoned wait goes to the event loop", func(t *testing.T) {
+		abandoned, resultCh := make(chan struct{}), make(chan SubAgentResult, 1)
+		close(abandoned)
+		launch(NewRunner(), make(chan SubAgentResult), abandoned, resultCh)
+		select {
+		case res := <-resultCh:
+			if res.ResultContent != "inline result" {
+				t.Errorf("ResultContent = %q, want %q", res.ResultContent, "inline result")
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatal("timed out waiting for the event loop result")
+		}
+	})
+}
+
+func TestDeliverResult_AbandonedWithoutResultChannel(t *testing.T) {
+	abandoned := make(chan struct{})
+	close(abandoned)
+	cfg := RunnerConfig{TaskID: "t", Inline: make(chan SubAgentResult), Abandoned: abandoned}
+
+	done := make(chan struct{})
+	go func() {
+		deliverResult(cfg, nil, SubAgentResult{TaskID: "t"})
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		t.Fatal("deliverResult blocked on a nil result channel after the wait timed out")
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/edouard/pureclaw/internal/platform"
 	"github.com/edouard/pureclaw/internal/workspace"
@@ -17,14 +19,29 @@ var (
 	osStat      = os.Stat
 )
 
+// maxParentHistoryBytes bounds the parent conversation copied into a sub-agent's AGENT.md.
+const maxParentHistoryBytes = 8 * 1024
+
+// maxParentTurnBytes bounds a single parent conversation turn copied into AGENT.md.
+const maxParentTurnBytes = 2 * 1024
+
+// HistoryTurn is one message of the parent agent's conversation.
+type HistoryTurn struct {
+	Role    string // "user" or "assistant"
+	Content string
+}
+
 // WorkspaceConfig holds the parameters for creating a sub-agent workspace.
 type WorkspaceConfig struct {
-	ParentWorkspace  *workspace.Workspace
-	TaskID           string
-	TaskDescription  string
-	AgentsDir        string // Parent's agents/ directory path
-	IncludeHeartbeat bool
-	IncludeSkills    bool
+	ParentWorkspace      *workspace.Workspace
+	TaskID               string
+	TaskDescription      string
+	AgentsDir            string // Parent's agents/ directory path
+	IncludeHeartbeat     bool
+	IncludeSkills        bool
+	IncludeParentHistory bool          // Seed AGENT.md with the parent's recent conversation
+	ParentHistory        []HistoryTurn // Parent conversation, oldest first (used with IncludeParentHistory)
+	Soul                 string        // Replaces the parent's SOUL.md when non-empty
 }
 
 // CreateWorkspace creates an isolated sub-agent workspace at AgentsDir/<TaskID>/.
@@ -73,12 +90,19 @@ func CreateWorkspace(cfg WorkspaceConfig) (string, error) {
 
 	// Generate task-specific AGENT.md.
 	agentMD := generateAgentMD(cfg.TaskID, cfg.TaskDescription)
+	if cfg.IncludeParentHistory {
+		agentMD = withParentHistory(agentMD, cfg.ParentHistory)
+	}
 	if err := atomicWrite(filepath.Join(wsPath, "AGENT.md"), []byte(agentMD), 0o644); err != nil {
 		return "", fmt.Errorf("write AGENT.md: %w", err)
 	}
 
-	// Copy SOUL.md from parent (raw copy — personality inherited).
-	if err := atomicWrite(filepath.Join(wsPath, "SOUL.md"), []byte(cfg.ParentWorkspace.SoulMD), 0o644); err != nil {
+	// Copy SOUL.md from parent (raw copy — personality inherited) unless overridden.
+	soul := cfg.ParentWorkspace.SoulMD
+	if strings.TrimSpace(cfg.Soul) != "" {
+		soul = cfg.Soul
+	}
+	if err := atomicWrite(filepath.Join(wsPath, "SOUL.md"), []byte(soul), 0o644); err != nil {
 		return "", fmt.Errorf("write SOUL.md: %w", err)
 	}
 
@@ -134,3 +158,42 @@ func generateAgentMD(taskID, taskDescription string) string {
 _To be populated by introspection on first run._
 `, taskID, taskDescription)
 }
+
+// withParentHistory inserts a "Parent Conversation" section before the Environment
+// section of agentMD. Only the most recent turns fitting in maxParentHistoryBytes
+// are kept, and each turn is cut to maxParentTurnBytes.
+func withParentHistory(agentMD string, history []HistoryTurn) string {
+	var turns []string
+	size := 0
+	for i := len(history) - 1; i >= 0; i-- {
+		content := strings.TrimSpace(history[i].Content)
+		if content == "" {
+			continue
+		}
+		if len(content) > maxParentTurnBytes {
+			content = strings.ToValidUTF8(content[:maxParentTurnBytes], "") + " [truncated]"
+		}
+		turn := fmt.Sprintf("**%s:** %s\n", history[i].Role, content)
+		if size+len(turn) > maxParentHistoryBytes {
+			break
+		}
+		size += len(turn)
+		turns = append(turns, turn)
+	}
+	if len(turns) == 0 {
+		return agentMD
+	}
+	slices.Reverse(turns)
+
+	var b strings.Builder
+	b.WriteString("## Parent Conversation\n\n")
+	b.WriteString("Recent messages between the owner and the parent agent, for context:\n\n")
+	b.WriteString(strings.Join(turns, "\n"))
+	b.WriteString("\n")
+
+	const envHeader = "## Environment"
+	if i := strings.Index(agentMD, envHeader); i >= 0 {
+		return agentMD[:i] + b.String() + "\n" + agentMD[i:]
+	}
+	return agentMD + "\n" + b.String()
+}
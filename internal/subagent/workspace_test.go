@@ -2,6 +2,7 @@ package subagent
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -154,6 +155,55 @@ func TestCreateWorkspace_SoulMDInherited(t *testing.T) {
 	}
 }
 
+func TestCreateWorkspace_SoulOverride(t *testing.T) {
+	parent := testParentWorkspace(t)
+	agentsDir := filepath.Join(t.TempDir(), "agents")
+
+	soul := "You are a terse log analyst. Report findings as bullet points."
+	wsPath, err := CreateWorkspace(WorkspaceConfig{
+		ParentWorkspace: parent,
+		TaskID:          "task-soul-override",
+		TaskDescription: "Analyze the logs",
+		AgentsDir:       agentsDir,
+		Soul:            soul,
+	})
+	if err != nil {
+		t.Fatalf("CreateWorkspace() error = %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(wsPath, "SOUL.md"))
+	if err != nil {
+		t.Fatalf("read SOUL.md: %v", err)
+	}
+	if string(data) != soul {
+		t.Errorf("SOUL.md = %q, want %q", string(data), soul)
+	}
+}
+
+func TestCreateWorkspace_BlankSoulInherits(t *testing.T) {
+	parent := testParentWorkspace(t)
+	agentsDir := filepath.Join(t.TempDir(), "agents")
+
+	wsPath, err := CreateWorkspace(WorkspaceConfig{
+		ParentWorkspace: parent,
+		TaskID:          "task-blank-soul",
+		TaskDescription: "Analyze the logs",
+		AgentsDir:       agentsDir,
+		Soul:            "  \n",
+	})
+	if err != nil {
+		t.Fatalf("CreateWorkspace() error = %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(wsPath, "SOUL.md"))
+	if err != nil {
+		t.Fatalf("read SOUL.md: %v", err)
+	}
+	if string(data) != parent.SoulMD {
+		t.Errorf("SOUL.md = %q, want parent soul %q", string(data), parent.SoulMD)
+	}
+}
+
 func TestCreateWorkspace_WithHeartbeat(t *testing.T) {
 	parent := testParentWorkspace(t)
 	agentsDir := filepath.Join(t.TempDir(), "agents")
@@ -636,3 +686,102 @@ func TestCreateWorkspace_MemoryDirError(t *testing.T) {
 		t.Fatalf("expected 'create memory dir' error, got: %v", err)
 	}
 }
+
+func parentHistoryFixture() []HistoryTurn {
+	return []HistoryTurn{
+		{Role: "user", Content: "The server logs are in /var/log/app"},
+		{Role: "assistant", Content: "Noted, I will look there."},
+		{Role: "user", Content: "Focus on errors since Monday"},
+	}
+}
+
+func TestCreateWorkspace_WithParentHistory(t *testing.T) {
+	wsPath, err := CreateWorkspace(WorkspaceConfig{
+		ParentWorkspace:      testParentWorkspace(t),
+		TaskID:               "logs",
+		TaskDescription:      "Summarize errors",
+		AgentsDir:            filepath.Join(t.TempDir(), "agents"),
+		IncludeParentHistory: true,
+		ParentHistory:        parentHistoryFixture(),
+	})
+	if err != nil {
+		t.Fatalf("CreateWorkspace() error = %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(wsPath, "AGENT.md"))
+	if err != nil {
+		t.Fatalf("read AGENT.md: %v", err)
+	}
+	content := string(data)
+
+	turns := []string{
+		"**user:** The server logs are in /var/log/app",
+		"**assistant:** Noted, I will look there.",
+		"**user:** Focus on errors since Monday",
+	}
+	last := -1
+	for _, turn := range turns {
+		i := strings.Index(content, turn)
+		if i < 0 {
+			t.Fatalf("AGENT.md missing turn %q:\n%s", turn, content)
+		}
+		if i < last {
+			t.Errorf("turn %q out of order", turn)
+		}
+		last = i
+	}
+	section := strings.Index(content, "## Parent Conversation")
+	env := strings.Index(content, "## Environment")
+	if section < 0 || env < 0 || section > env {
+		t.Errorf("Parent Conversation section should precede Environment:\n%s", content)
+	}
+}
+
+func TestCreateWorkspace_WithoutParentHistory(t *testing.T) {
+	wsPath, err := CreateWorkspace(WorkspaceConfig{
+		ParentWorkspace: testParentWorkspace(t),
+		TaskID:          "logs",
+		TaskDescription: "Summarize errors",
+		AgentsDir:       filepath.Join(t.TempDir(), "agents"),
+		ParentHistory:   parentHistoryFixture(), // ignored unless IncludeParentHistory is set
+	})
+	if err != nil {
+		t.Fatalf("CreateWorkspace() error = %v", err)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(wsPath, "AGENT.md"))
+	if strings.Contains(string(data), "Parent Conversation") || strings.Contains(string(data), "/var/log/app") {
+		t.Errorf("AGENT.md should not contain parent history:\n%s", data)
+	}
+}
+
+func TestWithParentHistory_Bounded(t *testing.T) {
+	var history []HistoryTurn
+	for i := 0; i < 50; i++ {
+		history = append(history, HistoryTurn{Role: "user", Content: fmt.Sprintf("message %02d %s", i, strings.Repeat("x", 500))})
+	}
+	history = append(history, HistoryTurn{Role: "assistant", Content: strings.Repeat("y", 10*1024)})
+
+	got := withParentHistory(generateAgentMD("t", "d"), history)
+	section := got[strings.Index(got, "## Parent Conversation"):strings.Index(got, "## Environment")]
+
+	if len(section) > maxParentHistoryBytes+512 {
+		t.Errorf("history section is %d bytes, want about %d at most", len(section), maxParentHistoryBytes)
+	}
+	if !strings.Contains(section, "[truncated]") {
+		t.Error("oversized turn should be truncated")
+	}
+	if !strings.Contains(section, "message 49") {
+		t.Error("most recent turns should be kept")
+	}
+	if strings.Contains(section, "message 00") {
+		t.Error("oldest turns should be dropped when over the limit")
+	}
+}
+
+func TestWithParentHistory_Empty(t *testing.T) {
+	md := generateAgentMD("t", "d")
+	if got := withParentHistory(md, []HistoryTurn{{Role: "user", Content: "  "}}); got != md {
+		t.Error("empty history should leave AGENT.md unchanged")
+	}
+}
@@ -0,0 +1,31 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+)
+
+// ChatActionTyping shows "typing…" in the chat header.
+const ChatActionTyping = "typing"
+
+// SendChatAction shows action (e.g. ChatActionTyping) to the users of a chat.
+// Telegram clears it after about 5 seconds or when the bot next sends a message.
+func (c *Client) SendChatAction(ctx context.Context, chatID int64, action string) error {
+	slog.Debug("telegram API sendChatAction", "component", "telegram", "operation", "send_chat_action", "chat_id", chatID, "action", action)
+
+	data, err := c.doPost(ctx, "sendChatAction", sendChatActionRequest{ChatID: chatID, Action: action})
+	if err != nil {
+		return fmt.Errorf("telegram: send_chat_action: %w", err)
+	}
+
+	var resp apiResponse[bool]
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return fmt.Errorf("telegram: send_chat_action: unmarshal: %w", err)
+	}
+	if !resp.Ok {
+		return fmt.Errorf("telegram: send_chat_action: %s", resp.Description)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSendChatAction(t *testing.T) {
+	var got sendChatActionRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/sendChatAction") {
+			t.Errorf("path = %s, want suffix /sendChatAction", r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		json.NewEncoder(w).Encode(apiResponse[bool]{Ok: true, Result: true})
+	}))
+	defer srv.Close()
+
+	client := &Client{baseURL: srv.URL + "/", httpClient: srv.Client()}
+	if err := client.SendChatAction(context.Background(), 42, ChatActionTyping); err != nil {
+		t.Fatalf("SendChatAction: %v", err)
+	}
+	if got.ChatID != 42 || got.Action != "typing" {
+		t.Errorf("request = %+v, want chat 42 and action typing", got)
+	}
+}
+
+func TestSendChatAction_APIError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(apiResponse[bool]{Ok: false, Description: "Bad Request: chat not found"})
+	}))
+	defer srv.Close()
+
+	client := &Client{baseURL: srv.URL + "/", httpClient: srv.Client()}
+	err := client.SendChatAction(context.Background(), 42, ChatActionTyping)
+	if err == nil || !strings.Contains(err.Error(), "chat not found") {
+		t.Fatalf("err = %v, want the API description", err)
+	}
+}
@@ -4,11 +4,15 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"mime/multipart"
 	"net/http"
 	"net/url"
+	"sort"
+	"strings"
 	"time"
 )
 
@@ -19,20 +23,58 @@ type Client struct {
 	httpClient *http.Client
 }
 
+// apiError represents a non-200 HTTP response from the Telegram Bot API.
+type apiError struct {
+	Method     string
+	StatusCode int
+	Body       string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%s: unexpected status %d: %s", e.Method, e.StatusCode, e.Body)
+}
+
+// IsRetryable returns true for 429 (rate limit) and 5xx (server error) status codes.
+func (e *apiError) IsRetryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// IsChatUnreachable reports whether err is Telegram refusing to deliver to a
+// chat for good: the user blocked the bot, deactivated their account or left
+// the chat (403), or the chat doesn't exist (400 "chat not found").
+func IsChatUnreachable(err error) bool {
+	var ae *apiError
+	if !errors.As(err, &ae) {
+		return false
+	}
+	return ae.StatusCode == http.StatusForbidden ||
+		(ae.StatusCode == http.StatusBadRequest && strings.Contains(ae.Body, "chat not found"))
+}
+
 // httpDo is a package-level variable for testability.
 var httpDo = func(client *http.Client, req *http.Request) (*http.Response, error) {
 	return client.Do(req)
 }
 
+// pollHeadroom is added to the long-poll wait to form the HTTP timeout,
+// leaving room for network latency on top of Telegram's server-side wait.
+const pollHeadroom = 10 * time.Second
+
 // NewClient creates a new Telegram Bot API client.
 // The HTTP timeout is set to 40s to accommodate Telegram long polling (30s server-side timeout)
 // with headroom for network latency. Per-request context deadlines further control individual calls.
 func NewClient(token string) *Client {
+	return NewClientWithPollTimeout(token, 30*time.Second)
+}
+
+// NewClientWithPollTimeout creates a Telegram Bot API client sized for the given
+// long-poll wait: its HTTP timeout is pollTimeout plus 10s of headroom.
+func NewClientWithPollTimeout(token string, pollTimeout time.Duration) *Client {
 	return &Client{
 		token:   token,
 		baseURL: "https://api.telegram.org/bot" + token + "/",
 		httpClient: &http.Client{
-			Timeout: 40 * time.Second,
+			Timeout: pollTimeout + pollHeadroom,
 		},
 	}
 }
@@ -64,12 +106,69 @@ func (c *Client) doPost(ctx context.Context, method string, body any) ([]byte, e
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("%s: unexpected status %d: %s", method, resp.StatusCode, string(respBody))
+		return nil, &apiError{Method: method, StatusCode: resp.StatusCode, Body: string(respBody)}
+	}
+
+	return respBody, nil
+}
+
+// doPostMultipart sends a multipart/form-data POST carrying one file upload plus
+// plain form fields to the given Telegram API method.
+func (c *Client) doPostMultipart(ctx context.Context, method string, fields map[string]string, fileField, fileName string, file []byte) ([]byte, error) {
+	slog.Debug("telegram API multipart POST", "component", "telegram", "operation", method, "file_name", fileName, "size", len(file))
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	for _, k := range sortedFieldKeys(fields) {
+		if err := mw.WriteField(k, fields[k]); err != nil {
+			return nil, fmt.Errorf("%s: write field: %w", method, err)
+		}
+	}
+	fw, err := mw.CreateFormFile(fileField, fileName)
+	if err != nil {
+		return nil, fmt.Errorf("%s: create form file: %w", method, err)
+	}
+	if _, err := fw.Write(file); err != nil {
+		return nil, fmt.Errorf("%s: write file: %w", method, err)
+	}
+	if err := mw.Close(); err != nil {
+		return nil, fmt.Errorf("%s: close multipart: %w", method, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+method, &buf)
+	if err != nil {
+		return nil, fmt.Errorf("%s: new request: %w", method, err)
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	resp, err := httpDo(c.httpClient, req)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%s: read body: %w", method, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &apiError{Method: method, StatusCode: resp.StatusCode, Body: string(respBody)}
 	}
 
 	return respBody, nil
 }
 
+// sortedFieldKeys returns the keys of fields in sorted order for deterministic requests.
+func sortedFieldKeys(fields map[string]string) []string {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // doGet sends a GET request with query parameters to the given Telegram API method.
 func (c *Client) doGet(ctx context.Context, method string, params url.Values) ([]byte, error) {
 	slog.Debug("telegram API GET", "component", "telegram", "operation", method)
@@ -96,7 +195,7 @@ func (c *Client) doGet(ctx context.Context, method string, params url.Values) ([
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("%s: unexpected status %d: %s", method, resp.StatusCode, string(respBody))
+		return nil, &apiError{Method: method, StatusCode: resp.StatusCode, Body: string(respBody)}
 	}
 
 	return respBody, nil
@@ -4,12 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestNewClient(t *testing.T) {
@@ -440,3 +442,32 @@ func TestClient_doGet_ReadBodyError(t *testing.T) {
 		t.Errorf("error = %q, want to contain 'read body'", err.Error())
 	}
 }
+
+func TestNewClientWithPollTimeout(t *testing.T) {
+	if c := NewClient("123:ABC"); c.httpClient.Timeout != 40*time.Second {
+		t.Errorf("NewClient Timeout = %v, want 40s", c.httpClient.Timeout)
+	}
+	if c := NewClientWithPollTimeout("123:ABC", 20*time.Second); c.httpClient.Timeout != 30*time.Second {
+		t.Errorf("Timeout = %v, want poll wait plus 10s headroom", c.httpClient.Timeout)
+	}
+}
+
+func TestIsChatUnreachable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"blocked", &apiError{Method: "sendMessage", StatusCode: 403, Body: `{"ok":false,"error_code":403,"description":"Forbidden: bot was blocked by the user"}`}, true},
+		{"wrapped", fmt.Errorf("telegram: send: %w", &apiError{StatusCode: 403, Body: "Forbidden: user is deactivated"}), true},
+		{"chat not found", &apiError{StatusCode: 400, Body: `{"ok":false,"error_code":400,"description":"Bad Request: chat not found"}`}, true},
+		{"other bad request", &apiError{StatusCode: 400, Body: "Bad Request: can't parse entities"}, false},
+		{"server error", &apiError{StatusCode: 502, Body: "Bad Gateway"}, false},
+		{"network", errors.New("connection refused"), false},
+	}
+	for _, tt := range tests {
+		if got := IsChatUnreachable(tt.err); got != tt.want {
+			t.Errorf("%s: IsChatUnreachable = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
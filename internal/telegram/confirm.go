@@ -0,0 +1,191 @@
+package telegram
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edouard/pureclaw/internal/platform"
+)
+
+// confirmTimeout bounds how long a tool call waits for the owner's answer
+// before it is denied.
+var confirmTimeout = 5 * time.Minute
+
+// maxConfirmArgs caps the tool arguments shown in a confirmation request, in runes.
+const maxConfirmArgs = 1000
+
+// confirmPrefix starts the callback data of confirmation buttons.
+const confirmPrefix = "confirm:"
+
+// CallbackHandler receives inline keyboard button presses from allowed users,
+// as routed by Poller and Webhook.
+type CallbackHandler interface {
+	HandleCallback(ctx context.Context, q CallbackQuery)
+	// Awaiting returns a channel that is closed while a button press is awaited.
+	Awaiting() <-chan struct{}
+}
+
+// Confirmer asks the owner to approve tool calls with Approve/Deny buttons and
+// waits for the answer, which arrives through HandleCallback. It implements
+// tool.Confirmer.
+type Confirmer struct {
+	client     *Client
+	allowedIDs map[int64]bool
+	fallback   int64 // chat asked when the call carries none (e.g. heartbeat work)
+
+	mu       sync.Mutex
+	pending  map[string]chan bool
+	awaiting chan struct{} // closed while pending is non-empty
+}
+
+// NewConfirmer creates a Confirmer that only accepts answers from allowedIDs.
+// Calls made outside a chat are confirmed with the first allowed ID.
+func NewConfirmer(client *Client, allowedIDs []int64) *Confirmer {
+	c := &Confirmer{
+		client:     client,
+		allowedIDs: allowedSet(allowedIDs),
+		pending:    make(map[string]chan bool),
+		awaiting:   make(chan struct{}),
+	}
+	if len(allowedIDs) > 0 {
+		c.fallback = allowedIDs[0]
+	}
+	return c
+}
+
+// Confirm sends a confirmation request for the tool call to the calling chat
+// and blocks until the owner answers, ctx ends, or confirmTimeout elapses.
+// An unanswered request is denied.
+func (c *Confirmer) Confirm(ctx context.Context, name string, args json.RawMessage) (bool, error) {
+	chatID, ok := platform.ChatIDFrom(ctx)
+	if !ok {
+		chatID = c.fallback
+	}
+	if chatID == 0 {
+		return false, fmt.Errorf("telegram: confirm: no chat to ask")
+	}
+
+	id := rand.Text()
+	answer := c.register(id)
+	defer c.unregister(id)
+
+	text := fmt.Sprintf("Allow tool %s to run?\n\n%s", name, truncateRunes(string(args), maxConfirmArgs))
+	body := sendKeyboardRequest{
+		ChatID: chatID,
+		Text:   text,
+		ReplyMarkup: inlineKeyboardMarkup{InlineKeyboard: [][]inlineKeyboardButton{{
+			{Text: "Approve", CallbackData: confirmPrefix + id + ":yes"},
+			{Text: "Deny", CallbackData: confirmPrefix + id + ":no"},
+		}}},
+	}
+	data, err := c.client.doPost(ctx, "sendMessage", body)
+	if err != nil {
+		return false, fmt.Errorf("telegram: confirm: %w", err)
+	}
+	var resp apiResponse[Message]
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return false, fmt.Errorf("telegram: confirm: unmarshal: %w", err)
+	}
+	if !resp.Ok {
+		return false, fmt.Errorf("telegram: confirm: %s", resp.Description)
+	}
+	platform.Log(ctx).Info("tool confirmation requested",
+		"component", "telegram", "operation", "confirm", "tool_name", name, "chat_id", chatID)
+
+	timer := time.NewTimer(confirmTimeout)
+	defer timer.Stop()
+	var approved bool
+	outcome := "denied"
+	select {
+	case approved = <-answer:
+		if approved {
+			outcome = "approved"
+		}
+	case <-timer.C:
+		outcome = "denied (no answer)"
+	case <-ctx.Done():
+		outcome = "cancelled"
+	}
+
+	// Replacing the text also removes the buttons.
+	editCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+	if err := c.client.EditMessageText(editCtx, chatID, resp.Result.MessageID, text+"\n\n"+outcome); err != nil {
+		platform.Log(ctx).Warn("failed to update confirmation request",
+			"component", "telegram", "operation", "confirm", "tool_name", name, "error", err)
+	}
+	if outcome == "cancelled" {
+		return false, fmt.Errorf("telegram: confirm: %w", ctx.Err())
+	}
+	return approved, nil
+}
+
+// HandleCallback delivers a confirmation button press to the waiting Confirm
+// call and answers the callback query so the client stops its spinner.
+// Presses from users outside the whitelist are ignored.
+func (c *Confirmer) HandleCallback(ctx context.Context, q CallbackQuery) {
+	if !isAllowedUser(c.allowedIDs, q.From) {
+		slog.Warn("rejected unauthorized callback query",
+			"component", "telegram", "operation", "confirm", "user_id", userID(q.From))
+		return
+	}
+	reply := "This request has expired."
+	if rest, ok := strings.CutPrefix(q.Data, confirmPrefix); ok {
+		id, verdict, _ := strings.Cut(rest, ":")
+		c.mu.Lock()
+		ch, found := c.pending[id]
+		c.mu.Unlock()
+		if found {
+			select {
+			case ch <- verdict == "yes":
+				reply = "Denied"
+				if verdict == "yes" {
+					reply = "Approved"
+				}
+			default:
+				reply = "Already answered."
+			}
+		}
+	}
+
+	req := answerCallbackQueryRequest{CallbackQueryID: q.ID, Text: reply}
+	if _, err := c.client.doPost(ctx, "answerCallbackQuery", req); err != nil {
+		slog.Warn("failed to answer callback query",
+			"component", "telegram", "operation", "confirm", "error", err)
+	}
+}
+
+// Awaiting returns a channel that is closed while a confirmation is pending.
+func (c *Confirmer) Awaiting() <-chan struct{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.awaiting
+}
+
+// register records a pending confirmation and returns the channel its answer arrives on.
+func (c *Confirmer) register(id string) <-chan bool {
+	ch := make(chan bool, 1)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.pending) == 0 {
+		close(c.awaiting)
+	}
+	c.pending[id] = ch
+	return ch
+}
+
+// unregister drops a pending confirmation, answered or not.
+func (c *Confirmer) unregister(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.pending, id)
+	if len(c.pending) == 0 {
+		c.awaiting = make(chan struct{})
+	}
+}
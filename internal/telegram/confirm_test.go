@@ -0,0 +1,273 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/edouard/pureclaw/internal/platform"
+)
+
+// confirmServer answers the Bot API calls made by Confirmer and records them.
+type confirmServer struct {
+	mu       sync.Mutex
+	keyboard sendKeyboardRequest
+	edits    []editMessageTextRequest
+	answers  []answerCallbackQueryRequest
+}
+
+func (s *confirmServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/sendMessage"):
+		json.NewDecoder(r.Body).Decode(&s.keyboard)
+		json.NewEncoder(w).Encode(apiResponse[Message]{Ok: true, Result: Message{MessageID: 7}})
+	case strings.HasSuffix(r.URL.Path, "/editMessageText"):
+		var e editMessageTextRequest
+		json.NewDecoder(r.Body).Decode(&e)
+		s.edits = append(s.edits, e)
+		json.NewEncoder(w).Encode(apiResponse[bool]{Ok: true, Result: true})
+	case strings.HasSuffix(r.URL.Path, "/answerCallbackQuery"):
+		var a answerCallbackQueryRequest
+		json.NewDecoder(r.Body).Decode(&a)
+		s.answers = append(s.answers, a)
+		json.NewEncoder(w).Encode(apiResponse[bool]{Ok: true, Result: true})
+	}
+}
+
+// button returns the callback data of the sent keyboard's button labelled text.
+func (s *confirmServer) button(text string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, row := range s.keyboard.ReplyMarkup.InlineKeyboard {
+		for _, b := range row {
+			if b.Text == text {
+				return b.CallbackData
+			}
+		}
+	}
+	return ""
+}
+
+func startConfirm(t *testing.T, c *Confirmer, ctx context.Context) <-chan struct {
+	ok  bool
+	err error
+} {
+	t.Helper()
+	res := make(chan struct {
+		ok  bool
+		err error
+	}, 1)
+	go func() {
+		ok, err := c.Confirm(ctx, "exec_command", json.RawMessage(`{"command":"ls"}`))
+		res <- struct {
+			ok  bool
+			err error
+		}{ok, err}
+	}()
+	return res
+}
+
+func TestConfirmer_ApproveRoundTrip(t *testing.T) {
+	cs := &confirmServer{}
+	srv := httptest.NewServer(cs)
+	defer srv.Close()
+	c := NewConfirmer(&Client{baseURL: srv.URL + "/", httpClient: srv.Client()}, []int64{111})
+
+	res := startConfirm(t, c, platform.WithChatID(context.Background(), 222))
+	<-c.Awaiting()
+	var data string
+	for deadline := time.Now().Add(3 * time.Second); data == "" && time.Now().Before(deadline); {
+		time.Sleep(5 * time.Millisecond)
+		data = cs.button("Approve")
+	}
+	if data == "" {
+		t.Fatal("no confirmation keyboard was sent")
+	}
+	c.HandleCallback(context.Background(), CallbackQuery{ID: "q1", From: &User{ID: 111}, Data: data})
+
+	r := <-res
+	if r.err != nil || !r.ok {
+		t.Fatalf("Confirm() = %v, %v; want true, nil", r.ok, r.err)
+	}
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	if cs.keyboard.ChatID != 222 || !strings.Contains(cs.keyboard.Text, "exec_command") {
+		t.Errorf("request = %+v, want the tool asked in chat 222", cs.keyboard)
+	}
+	if len(cs.answers) != 1 || cs.answers[0].CallbackQueryID != "q1" || cs.answers[0].Text != "Approved" {
+		t.Errorf("answers = %+v, want one Approved answer to q1", cs.answers)
+	}
+	if len(cs.edits) != 1 || cs.edits[0].MessageID != 7 || !strings.HasSuffix(cs.edits[0].Text, "approved") {
+		t.Errorf("edits = %+v, want message 7 marked approved", cs.edits)
+	}
+	select {
+	case <-c.Awaiting():
+		t.Error("Awaiting() still closed after the answer")
+	default:
+	}
+}
+
+func TestConfirmer_StrangerIgnoredAndTimeoutDenies(t *testing.T) {
+	orig := confirmTimeout
+	confirmTimeout = 50 * time.Millisecond
+	t.Cleanup(func() { confirmTimeout = orig })
+
+	cs := &confirmServer{}
+	srv := httptest.NewServer(cs)
+	defer srv.Close()
+	c := NewConfirmer(&Client{baseURL: srv.URL + "/", httpClient: srv.Client()}, []int64{111})
+
+	// Without a chat in ctx, the first owner is asked.
+	res := startConfirm(t, c, context.Background())
+	<-c.Awaiting()
+	time.Sleep(10 * time.Millisecond)
+	c.HandleCallback(context.Background(), CallbackQuery{ID: "q1", From: &User{ID: 999}, Data: cs.button("Approve")})
+
+	r := <-res
+	if r.err != nil || r.ok {
+		t.Fatalf("Confirm() = %v, %v; want false, nil", r.ok, r.err)
+	}
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	if cs.keyboard.ChatID != 111 {
+		t.Errorf("chat = %d, want the first owner 111", cs.keyboard.ChatID)
+	}
+	if len(cs.answers) != 0 {
+		t.Errorf("answered a stranger's callback: %+v", cs.answers)
+	}
+}
+
+func TestConfirmer_ExpiredCallback(t *testing.T) {
+	cs := &confirmServer{}
+	srv := httptest.NewServer(cs)
+	defer srv.Close()
+	c := NewConfirmer(&Client{baseURL: srv.URL + "/", httpClient: srv.Client()}, []int64{111})
+
+	c.HandleCallback(context.Background(), CallbackQuery{ID: "q1", From: &User{ID: 111}, Data: "confirm:gone:yes"})
+	if len(cs.answers) != 1 || !strings.Contains(cs.answers[0].Text, "expired") {
+		t.Errorf("answers = %+v, want an expiry notice", cs.answers)
+	}
+}
+
+// stubCallbacks records callback queries and reports a pending answer once awaiting is closed.
+type stubCallbacks struct {
+	awaiting chan struct{}
+	got      chan CallbackQuery
+}
+
+func (s *stubCallbacks) HandleCallback(_ context.Context, q CallbackQuery) { s.got <- q }
+func (s *stubCallbacks) Awaiting() <-chan struct{}                         { return s.awaiting }
+
+func TestPoller_Run_RoutesCallbackWhileMessageAwaitsAnswer(t *testing.T) {
+	origHTTPDo := httpDo
+	httpDo = func(c *http.Client, req *http.Request) (*http.Response, error) { return c.Do(req) }
+	t.Cleanup(func() { httpDo = origHTTPDo })
+	origRetry := retryFn
+	retryFn = func(_ context.Context, _ int, _ time.Duration, fn func() error) error { return fn() }
+	t.Cleanup(func() { retryFn = origRetry })
+
+	from := &User{ID: 111}
+	us := &updateServer{updates: []Update{
+		{UpdateID: 30, Message: &Message{MessageID: 1, From: from, Chat: Chat{ID: 111}, Text: "rm it"}},
+	}}
+	srv := httptest.NewServer(us)
+	defer srv.Close()
+	store := NewFileOffsetStore(filepath.Join(t.TempDir(), "telegram.offset"))
+
+	p := NewPoller(&Client{baseURL: srv.URL + "/", httpClient: srv.Client()}, []int64{111}, 1)
+	p.SetOffsetStore(store)
+	cb := &stubCallbacks{awaiting: make(chan struct{}), got: make(chan CallbackQuery, 1)}
+	p.SetCallbackHandler(cb)
+	if got := p.allowedUpdates(); !strings.Contains(got, "callback_query") {
+		t.Errorf("allowed_updates = %s, want callback_query", got)
+	}
+
+	out := make(chan TelegramMessage, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		p.Run(ctx, out)
+		close(done)
+	}()
+	t.Cleanup(func() { cancel(); <-done })
+
+	msg := receive(t, out)
+	// The message's tool call now waits for a button press; it is not acknowledged yet.
+	us.mu.Lock()
+	us.updates = append(us.updates, Update{UpdateID: 31, CallbackQuery: &CallbackQuery{ID: "q", From: from, Data: "confirm:x:yes"}})
+	us.mu.Unlock()
+	close(cb.awaiting)
+
+	select {
+	case q := <-cb.got:
+		if q.ID != "q" {
+			t.Errorf("callback = %+v", q)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("callback query was not routed while the message awaited an answer")
+	}
+	if saved, _ := store.LoadOffset(); saved > msg.UpdateID {
+		t.Errorf("checkpoint = %d, unacknowledged update %d was checkpointed", saved, msg.UpdateID)
+	}
+	p.Ack(msg.UpdateID)
+	if saved, _ := store.LoadOffset(); saved <= msg.UpdateID {
+		t.Errorf("checkpoint after Ack = %d, want past %d", saved, msg.UpdateID)
+	}
+}
+
+func TestPoller_Run_RoutesCallbackBehindBlockedMessage(t *testing.T) {
+	origHTTPDo := httpDo
+	httpDo = func(c *http.Client, req *http.Request) (*http.Response, error) { return c.Do(req) }
+	t.Cleanup(func() { httpDo = origHTTPDo })
+	origRetry := retryFn
+	retryFn = func(_ context.Context, _ int, _ time.Duration, fn func() error) error { return fn() }
+	t.Cleanup(func() { retryFn = origRetry })
+
+	from := &User{ID: 111}
+	us := &updateServer{updates: []Update{
+		{UpdateID: 41, Message: &Message{MessageID: 2, From: from, Chat: Chat{ID: 111}, Text: "also this"}},
+		{UpdateID: 42, CallbackQuery: &CallbackQuery{ID: "q", From: from, Data: "confirm:x:yes"}},
+	}}
+	srv := httptest.NewServer(us)
+	defer srv.Close()
+
+	p := NewPoller(&Client{baseURL: srv.URL + "/", httpClient: srv.Client()}, []int64{111}, 1)
+	p.SetOffsetStore(NewFileOffsetStore(filepath.Join(t.TempDir(), "telegram.offset")))
+	cb := &stubCallbacks{awaiting: make(chan struct{}), got: make(chan CallbackQuery, 1)}
+	close(cb.awaiting) // the consumer is blocked on a confirmation
+	p.SetCallbackHandler(cb)
+
+	// The buffer already holds a message the blocked consumer has not taken.
+	out := make(chan TelegramMessage, 1)
+	out <- TelegramMessage{UpdateID: 40, Message: Message{MessageID: 1, From: from, Chat: Chat{ID: 111}, Text: "queued"}}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		p.Run(ctx, out)
+		close(done)
+	}()
+	t.Cleanup(func() { cancel(); <-done })
+
+	select {
+	case q := <-cb.got:
+		if q.ID != "q" {
+			t.Errorf("callback = %+v", q)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("callback query stuck behind a message the consumer could not take")
+	}
+	if m := receive(t, out); m.UpdateID != 40 {
+		t.Errorf("first message = %d, want the buffered 40", m.UpdateID)
+	}
+	if m := receive(t, out); m.UpdateID != 41 || m.Message.Text != "also this" {
+		t.Errorf("second message = %+v, want 41 from the backlog", m)
+	}
+}
@@ -0,0 +1,40 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// EditMessageText replaces the text of a message the bot sent earlier. The
+// text is sent as is, without a parse mode. Editing a message to the text it
+// already has is treated as success.
+func (c *Client) EditMessageText(ctx context.Context, chatID, messageID int64, text string) error {
+	slog.Debug("telegram API editMessageText", "component", "telegram", "operation", "edit_message", "chat_id", chatID, "message_id", messageID)
+
+	body := editMessageTextRequest{ChatID: chatID, MessageID: messageID, Text: truncateRunes(text, MaxMessageLength)}
+	data, err := c.doPost(ctx, "editMessageText", body)
+	if err != nil && isNotModified(err.Error()) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("telegram: edit_message: %w", err)
+	}
+
+	var resp apiResponse[json.RawMessage]
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return fmt.Errorf("telegram: edit_message: unmarshal: %w", err)
+	}
+	if !resp.Ok && !isNotModified(resp.Description) {
+		return fmt.Errorf("telegram: edit_message: %s", resp.Description)
+	}
+	return nil
+}
+
+// isNotModified reports whether an API error message is Telegram's refusal of
+// an edit that would leave the message unchanged.
+func isNotModified(msg string) bool {
+	return strings.Contains(msg, "message is not modified")
+}
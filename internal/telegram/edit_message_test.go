@@ -0,0 +1,57 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestEditMessageText(t *testing.T) {
+	var got editMessageTextRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/editMessageText") {
+			t.Errorf("path = %s, want suffix /editMessageText", r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		json.NewEncoder(w).Encode(apiResponse[Message]{Ok: true, Result: Message{MessageID: 7}})
+	}))
+	defer srv.Close()
+
+	client := &Client{baseURL: srv.URL + "/", httpClient: srv.Client()}
+	if err := client.EditMessageText(context.Background(), 42, 7, "Running tool 2/3"); err != nil {
+		t.Fatalf("EditMessageText: %v", err)
+	}
+	if got.ChatID != 42 || got.MessageID != 7 || got.Text != "Running tool 2/3" {
+		t.Errorf("request = %+v, want chat 42, message 7 and the new text", got)
+	}
+}
+
+func TestEditMessageText_NotModified(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"ok":false,"error_code":400,"description":"Bad Request: message is not modified"}`))
+	}))
+	defer srv.Close()
+
+	client := &Client{baseURL: srv.URL + "/", httpClient: srv.Client()}
+	if err := client.EditMessageText(context.Background(), 42, 7, "same"); err != nil {
+		t.Errorf("EditMessageText = %v, want nil for an unchanged message", err)
+	}
+}
+
+func TestEditMessageText_APIError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"ok":false,"error_code":400,"description":"Bad Request: message to edit not found"}`))
+	}))
+	defer srv.Close()
+
+	client := &Client{baseURL: srv.URL + "/", httpClient: srv.Client()}
+	err := client.EditMessageText(context.Background(), 42, 7, "text")
+	if err == nil || !strings.Contains(err.Error(), "message to edit not found") {
+		t.Fatalf("err = %v, want the API description", err)
+	}
+}
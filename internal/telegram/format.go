@@ -0,0 +1,85 @@
+package telegram
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+// fencedCodeRe matches Markdown fenced code blocks with an optional language tag.
+var fencedCodeRe = regexp.MustCompile("(?s)```([A-Za-z0-9_+#.-]*)[ \t]*\n(.*?)```")
+
+// allowedTagRe matches an opening or closing Telegram-supported HTML tag at the start of a string.
+var allowedTagRe = regexp.MustCompile(`^</?(b|strong|i|em|u|ins|s|strike|del|code|pre|a|blockquote|tg-spoiler|span)(\s[^<>]*)?>`)
+
+// entityRe matches an HTML entity at the start of a string (e.g. &amp;, &#39;, &#x27;).
+var entityRe = regexp.MustCompile(`^&(#[0-9]+|#x[0-9a-fA-F]+|[a-zA-Z]+);`)
+
+// anyTagRe matches any HTML tag, used when degrading formatted text to plain text.
+var anyTagRe = regexp.MustCompile(`</?[a-zA-Z][^<>]*>`)
+
+// codeEscaper escapes the three characters Telegram requires inside <pre>/<code>.
+var codeEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// FormatCodeBlocks converts Markdown fenced code blocks into Telegram HTML code blocks,
+// preserving the language tag as class="language-<lang>". Text outside the blocks is
+// escaped so that stray <, > and & do not break HTML parsing, while Telegram-supported
+// tags written by the model are kept as-is. Text without fenced blocks is returned unchanged.
+func FormatCodeBlocks(text string) string {
+	matches := fencedCodeRe.FindAllStringSubmatchIndex(text, -1)
+	if len(matches) == 0 {
+		return text
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		b.WriteString(escapeProse(text[last:m[0]]))
+		lang := text[m[2]:m[3]]
+		code := codeEscaper.Replace(strings.TrimRight(text[m[4]:m[5]], "\n"))
+		if lang != "" {
+			b.WriteString(`<pre><code class="language-` + lang + `">` + code + "</code></pre>")
+		} else {
+			b.WriteString("<pre>" + code + "</pre>")
+		}
+		last = m[1]
+	}
+	b.WriteString(escapeProse(text[last:]))
+	return b.String()
+}
+
+// escapeProse escapes <, > and & in prose while preserving Telegram-supported
+// HTML tags and already-escaped entities.
+func escapeProse(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		switch s[i] {
+		case '<':
+			if tag := allowedTagRe.FindString(s[i:]); tag != "" {
+				b.WriteString(tag)
+				i += len(tag)
+				continue
+			}
+			b.WriteString("&lt;")
+		case '>':
+			b.WriteString("&gt;")
+		case '&':
+			if ent := entityRe.FindString(s[i:]); ent != "" {
+				b.WriteString(ent)
+				i += len(ent)
+				continue
+			}
+			b.WriteString("&amp;")
+		default:
+			b.WriteByte(s[i])
+		}
+		i++
+	}
+	return b.String()
+}
+
+// stripHTML removes HTML tags and unescapes entities, producing plain text
+// suitable for sending without a parse mode.
+func stripHTML(s string) string {
+	return html.UnescapeString(anyTagRe.ReplaceAllString(s, ""))
+}
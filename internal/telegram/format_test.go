@@ -0,0 +1,52 @@
+package telegram
+
+import "testing"
+
+func TestFormatCodeBlocks_NoFences(t *testing.T) {
+	in := "Hello <b>world</b> & a < b"
+	if got := FormatCodeBlocks(in); got != in {
+		t.Errorf("FormatCodeBlocks() = %q, want unchanged %q", got, in)
+	}
+}
+
+func TestFormatCodeBlocks_GoBlock(t *testing.T) {
+	in := "Use this when a < b & c > d:\n```go\nif a < b {\n\treturn \"x\"\n}\n```\nDone <b>ok</b>."
+	want := "Use this when a &lt; b &amp; c &gt; d:\n" +
+		`<pre><code class="language-go">if a &lt; b {` + "\n\treturn \"x\"\n}</code></pre>" +
+		"\nDone <b>ok</b>."
+	if got := FormatCodeBlocks(in); got != want {
+		t.Errorf("FormatCodeBlocks() =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestFormatCodeBlocks_NoLanguage(t *testing.T) {
+	in := "```\necho <hi>\n```"
+	want := "<pre>echo &lt;hi&gt;</pre>"
+	if got := FormatCodeBlocks(in); got != want {
+		t.Errorf("FormatCodeBlocks() = %q, want %q", got, want)
+	}
+}
+
+func TestFormatCodeBlocks_MultipleBlocks(t *testing.T) {
+	in := "A\n```sh\nls\n```\nB\n```python\nprint(1)\n```"
+	want := "A\n<pre><code class=\"language-sh\">ls</code></pre>\nB\n<pre><code class=\"language-python\">print(1)</code></pre>"
+	if got := FormatCodeBlocks(in); got != want {
+		t.Errorf("FormatCodeBlocks() = %q, want %q", got, want)
+	}
+}
+
+func TestFormatCodeBlocks_PreservesEntities(t *testing.T) {
+	in := "Tom &amp; Jerry &#39;x&#39;\n```\ny\n```"
+	want := "Tom &amp; Jerry &#39;x&#39;\n<pre>y</pre>"
+	if got := FormatCodeBlocks(in); got != want {
+		t.Errorf("FormatCodeBlocks() = %q, want %q", got, want)
+	}
+}
+
+func TestStripHTML(t *testing.T) {
+	in := `<pre><code class="language-go">a &lt; b</code></pre> <b>bold</b> &amp;`
+	want := "a < b bold &"
+	if got := stripHTML(in); got != want {
+		t.Errorf("stripHTML() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,29 @@
+package telegram
+
+import "time"
+
+// Telegram Bot API size limits, counted in characters after entity parsing.
+const (
+	MaxMessageLength = 4096 // text of a sendMessage call
+	MaxCaptionLength = 1024 // caption of a media or document message
+)
+
+// Telegram Bot API send rate limits, as minimum spacing between messages.
+const (
+	GlobalSendInterval = time.Second / 30 // about 30 messages per second across all chats
+	ChatSendInterval   = time.Second      // about one message per second in a single chat
+)
+
+// truncatedMarker ends text that was cut to fit a Telegram limit.
+const truncatedMarker = "…"
+
+// truncateRunes shortens text to at most limit runes, ending it with
+// truncatedMarker when anything was cut. It never splits a multi-byte character.
+func truncateRunes(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	keep := limit - len([]rune(truncatedMarker))
+	return string(runes[:keep]) + truncatedMarker
+}
@@ -0,0 +1,53 @@
+package telegram
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/edouard/pureclaw/internal/platform"
+)
+
+// OffsetStore persists the getUpdates offset so a restarted poller resumes after
+// the last processed update instead of replaying everything Telegram still holds.
+type OffsetStore interface {
+	LoadOffset() (int64, error)
+	SaveOffset(offset int64) error
+}
+
+// FileOffsetStore keeps the offset as a decimal number in a file.
+type FileOffsetStore struct {
+	Path string
+}
+
+// NewFileOffsetStore creates an OffsetStore backed by the file at path.
+func NewFileOffsetStore(path string) *FileOffsetStore {
+	return &FileOffsetStore{Path: path}
+}
+
+// LoadOffset returns the saved offset, or 0 if none has been saved yet.
+func (s *FileOffsetStore) LoadOffset() (int64, error) {
+	data, err := os.ReadFile(s.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("telegram: load_offset: %w", err)
+	}
+	offset, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("telegram: load_offset: %w", err)
+	}
+	return offset, nil
+}
+
+// SaveOffset atomically replaces the saved offset.
+func (s *FileOffsetStore) SaveOffset(offset int64) error {
+	if err := platform.AtomicWrite(s.Path, []byte(strconv.FormatInt(offset, 10)+"\n"), 0o600); err != nil {
+		return fmt.Errorf("telegram: save_offset: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,227 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFileOffsetStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "telegram.offset")
+	s := NewFileOffsetStore(path)
+
+	offset, err := s.LoadOffset()
+	if err != nil || offset != 0 {
+		t.Fatalf("LoadOffset() on missing file = %d, %v; want 0, nil", offset, err)
+	}
+	if err := s.SaveOffset(4242); err != nil {
+		t.Fatalf("SaveOffset: %v", err)
+	}
+	offset, err = s.LoadOffset()
+	if err != nil || offset != 4242 {
+		t.Errorf("LoadOffset() = %d, %v; want 4242, nil", offset, err)
+	}
+
+	if err := os.WriteFile(path, []byte("garbage"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.LoadOffset(); err == nil {
+		t.Error("expected error for corrupt offset file")
+	}
+}
+
+// updateServer serves a fixed set of updates, honoring the getUpdates offset
+// like Telegram does, and records the offsets it was asked for.
+type updateServer struct {
+	mu      sync.Mutex
+	updates []Update
+	offsets []int64
+}
+
+func (u *updateServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	offset, _ := strconv.ParseInt(r.URL.Query().Get("offset"), 10, 64)
+	u.mu.Lock()
+	u.offsets = append(u.offsets, offset)
+	var result []Update
+	for _, up := range u.updates {
+		if up.UpdateID >= offset {
+			result = append(result, up)
+		}
+	}
+	u.mu.Unlock()
+	if len(result) == 0 {
+		time.Sleep(10 * time.Millisecond) // behave like a (short) long poll
+	}
+	json.NewEncoder(w).Encode(apiResponse[[]Update]{Ok: true, Result: result})
+}
+
+func (u *updateServer) requestedOffsets() []int64 {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return append([]int64(nil), u.offsets...)
+}
+
+func startCheckpointPoller(t *testing.T, srv *httptest.Server, store OffsetStore) (*Poller, chan TelegramMessage, context.CancelFunc, chan struct{}) {
+	t.Helper()
+	origRetry := retryFn
+	retryFn = func(_ context.Context, _ int, _ time.Duration, fn func() error) error { return fn() }
+	t.Cleanup(func() { retryFn = origRetry })
+
+	client := &Client{baseURL: srv.URL + "/", httpClient: srv.Client()}
+	p := NewPoller(client, []int64{111}, 1)
+	p.SetOffsetStore(store)
+
+	out := make(chan TelegramMessage, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		p.Run(ctx, out)
+		close(done)
+	}()
+	t.Cleanup(func() { cancel(); <-done })
+	return p, out, cancel, done
+}
+
+func receive(t *testing.T, out <-chan TelegramMessage) TelegramMessage {
+	t.Helper()
+	select {
+	case msg := <-out:
+		return msg
+	case <-time.After(3 * time.Second):
+		t.Fatal("timeout waiting for message")
+		return TelegramMessage{}
+	}
+}
+
+func TestPoller_Run_CheckpointReplaysOnlyUnprocessed(t *testing.T) {
+	origHTTPDo := httpDo
+	httpDo = func(c *http.Client, req *http.Request) (*http.Response, error) { return c.Do(req) }
+	t.Cleanup(func() { httpDo = origHTTPDo })
+
+	from := &User{ID: 111, FirstName: "Test"}
+	us := &updateServer{updates: []Update{
+		{UpdateID: 10, Message: &Message{MessageID: 1, From: from, Chat: Chat{ID: 111}, Text: "first"}},
+		{UpdateID: 11, Message: &Message{MessageID: 2, From: from, Chat: Chat{ID: 111}, Text: "second"}},
+	}}
+	srv := httptest.NewServer(us)
+	defer srv.Close()
+	store := NewFileOffsetStore(filepath.Join(t.TempDir(), "telegram.offset"))
+
+	// First run: process "first", receive "second", then crash before acknowledging it.
+	p, out, cancel, done := startCheckpointPoller(t, srv, store)
+	first := receive(t, out)
+	if first.UpdateID != 10 || first.Message.Text != "first" {
+		t.Fatalf("first message = %+v", first)
+	}
+	p.Ack(first.UpdateID)
+	second := receive(t, out)
+	if second.UpdateID != 11 {
+		t.Fatalf("second message = %+v", second)
+	}
+	time.Sleep(50 * time.Millisecond) // give the poller a chance to (wrongly) poll again
+	cancel()
+	<-done
+
+	for _, o := range us.requestedOffsets() {
+		if o > 11 {
+			t.Errorf("poller confirmed unacknowledged update 11 to Telegram (requested offset %d)", o)
+		}
+	}
+	if saved, _ := store.LoadOffset(); saved != 11 {
+		t.Fatalf("checkpoint = %d, want 11", saved)
+	}
+
+	// Restart: only the unprocessed message is delivered again.
+	p2, out2, _, _ := startCheckpointPoller(t, srv, store)
+	replayed := receive(t, out2)
+	if replayed.UpdateID != 11 || replayed.Message.Text != "second" {
+		t.Fatalf("replayed = %+v, want only the second message", replayed)
+	}
+	p2.Ack(replayed.UpdateID)
+	if saved, _ := store.LoadOffset(); saved != 12 {
+		t.Errorf("checkpoint after replay = %d, want 12", saved)
+	}
+	select {
+	case extra := <-out2:
+		t.Errorf("unexpected extra delivery %+v", extra)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestPoller_Run_CheckpointSkipsFilteredUpdates(t *testing.T) {
+	origHTTPDo := httpDo
+	httpDo = func(c *http.Client, req *http.Request) (*http.Response, error) { return c.Do(req) }
+	t.Cleanup(func() { httpDo = origHTTPDo })
+
+	us := &updateServer{updates: []Update{
+		{UpdateID: 20, Message: &Message{MessageID: 1, From: &User{ID: 999}, Chat: Chat{ID: 999}, Text: "stranger"}},
+		{UpdateID: 21},
+	}}
+	srv := httptest.NewServer(us)
+	defer srv.Close()
+	store := NewFileOffsetStore(filepath.Join(t.TempDir(), "telegram.offset"))
+
+	startCheckpointPoller(t, srv, store)
+
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		if saved, _ := store.LoadOffset(); saved == 22 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("filtered updates were not checkpointed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestPoller_AckWithoutStore(t *testing.T) {
+	p := NewPoller(NewClient("token"), nil, 1)
+	p.Ack(5) // must not panic
+	if p.acked != 0 {
+		t.Errorf("acked = %d, want 0 without a store", p.acked)
+	}
+}
+
+func TestPoller_Run_ReleaseFetchesNextWithoutCheckpoint(t *testing.T) {
+	origHTTPDo := httpDo
+	httpDo = func(c *http.Client, req *http.Request) (*http.Response, error) { return c.Do(req) }
+	t.Cleanup(func() { httpDo = origHTTPDo })
+
+	from := &User{ID: 111}
+	us := &updateServer{updates: []Update{
+		{UpdateID: 50, Message: &Message{MessageID: 1, From: from, Chat: Chat{ID: 111}, Text: "first"}},
+	}}
+	srv := httptest.NewServer(us)
+	defer srv.Close()
+	store := NewFileOffsetStore(filepath.Join(t.TempDir(), "telegram.offset"))
+
+	p, out, _, _ := startCheckpointPoller(t, srv, store)
+	first := receive(t, out)
+	us.mu.Lock()
+	us.updates = append(us.updates, Update{UpdateID: 51, Message: &Message{MessageID: 2, From: from, Chat: Chat{ID: 111}, Text: "second"}})
+	us.mu.Unlock()
+	p.Release(first.UpdateID)
+
+	if second := receive(t, out); second.UpdateID != 51 {
+		t.Fatalf("second message = %+v, want 51 fetched after the release", second)
+	}
+	if saved, _ := store.LoadOffset(); saved > first.UpdateID {
+		t.Errorf("checkpoint = %d, released but unacknowledged update %d was checkpointed", saved, first.UpdateID)
+	}
+}
+
+func TestPoller_ReleaseWithoutStore(t *testing.T) {
+	p := NewPoller(NewClient("token"), nil, 1)
+	p.Release(5) // must not panic
+	if p.released != 0 {
+		t.Errorf("released = %d, want 0 without a store", p.released)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"log/slog"
 	"net/url"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/edouard/pureclaw/internal/platform"
@@ -16,7 +17,19 @@ import (
 var retryFn = platform.Retry
 
 // retryDelay is the delay after all retries are exhausted before starting a new cycle.
-var retryDelay = 5 * time.Second
+// It doubles with each consecutive failed cycle, up to maxRetryDelay.
+var (
+	retryDelay    = 5 * time.Second
+	maxRetryDelay = 5 * time.Minute
+)
+
+// backlogPollTimeout is the long-poll timeout, in seconds, while messages wait
+// in the backlog, so they are handed off soon after the awaited press arrives.
+const backlogPollTimeout = 1
+
+// OutageAlert is called once when consecutive failed poll cycles reach the alert
+// threshold. failures is the count so far and err the last poll error.
+type OutageAlert func(ctx context.Context, failures int, err error)
 
 // Poller receives updates from the Telegram Bot API using long polling.
 type Poller struct {
@@ -24,32 +37,168 @@ type Poller struct {
 	allowedIDs map[int64]bool
 	offset     int64
 	timeout    int
+
+	// Offset checkpointing, enabled by SetOffsetStore.
+	store    OffsetStore
+	ackMu    sync.Mutex
+	acked    int64         // offset covering every acknowledged update
+	released int64         // offset covering every released update, see Release
+	handed   int64         // update ID of the last message sent on out (0 = none)
+	ackSig   chan struct{} // signalled on each Ack and Release
+
+	backlog []TelegramMessage // fetched messages not yet sent on out
+
+	// Outage alerting, enabled by SetOutageAlert.
+	failures   int // consecutive failed poll cycles
+	alertAfter int
+	alert      OutageAlert
+
+	callbacks CallbackHandler // enabled by SetCallbackHandler
 }
 
 // NewPoller creates a new Poller with a whitelist of allowed user IDs.
 func NewPoller(client *Client, allowedIDs []int64, timeout int) *Poller {
-	allowed := make(map[int64]bool, len(allowedIDs))
-	for _, id := range allowedIDs {
-		allowed[id] = true
-	}
 	return &Poller{
 		client:     client,
-		allowedIDs: allowed,
+		allowedIDs: allowedSet(allowedIDs),
 		timeout:    timeout,
 	}
 }
 
+// SetOffsetStore enables offset checkpointing. Run resumes from the stored offset,
+// and every handed-off message must then be acknowledged with Ack once processed:
+// the offset is saved per acknowledged message, and the next batch is only fetched
+// (which confirms the previous one to Telegram) after the whole batch is acknowledged.
+// After a crash, only messages that were never acknowledged are delivered again.
+func (p *Poller) SetOffsetStore(store OffsetStore) {
+	p.store = store
+	p.ackSig = make(chan struct{}, 1)
+}
+
+// SetOutageAlert calls alert once when threshold consecutive poll cycles have
+// failed, each after its own retries. The count resets on the first successful
+// poll, re-arming the alert for the next outage. A threshold below 1 disables alerting.
+func (p *Poller) SetOutageAlert(threshold int, alert OutageAlert) {
+	p.alertAfter = threshold
+	p.alert = alert
+}
+
+// SetCallbackHandler subscribes to callback queries and routes button presses
+// from allowed users to h, on the polling goroutine. While h awaits a press,
+// the next batch is fetched without waiting for the current one to be
+// acknowledged: the message being processed may be what awaits the answer.
+func (p *Poller) SetCallbackHandler(h CallbackHandler) {
+	p.callbacks = h
+}
+
+// DeleteWebhook removes a webhook left by a previous run in webhook mode:
+// Telegram refuses getUpdates while one is set. Pending updates are kept.
+func (p *Poller) DeleteWebhook(ctx context.Context) error {
+	return p.client.DeleteWebhook(ctx)
+}
+
+// Ack records that the message with the given update ID has been processed and
+// checkpoints the offset past it. It is a no-op without an offset store.
+func (p *Poller) Ack(updateID int64) {
+	if p.store == nil {
+		return
+	}
+	p.checkpoint(updateID + 1)
+	select {
+	case p.ackSig <- struct{}{}:
+	default:
+	}
+}
+
+// Release lets the next batch be fetched before the message with the given
+// update ID is acknowledged, for a consumer that holds it back to merge it with
+// the messages that follow. Its offset is still only checkpointed by Ack, but
+// fetching confirms it to Telegram, so a crash before Ack loses it. It is a
+// no-op without an offset store.
+func (p *Poller) Release(updateID int64) {
+	if p.store == nil {
+		return
+	}
+	p.ackMu.Lock()
+	p.released = max(p.released, updateID+1)
+	p.ackMu.Unlock()
+	select {
+	case p.ackSig <- struct{}{}:
+	default:
+	}
+}
+
+// checkpoint saves offset if it moves the acknowledged offset forward.
+func (p *Poller) checkpoint(offset int64) {
+	p.ackMu.Lock()
+	defer p.ackMu.Unlock()
+	if offset <= p.acked {
+		return
+	}
+	p.acked = offset
+	if err := p.store.SaveOffset(offset); err != nil {
+		slog.Error("failed to save offset checkpoint",
+			"component", "telegram", "operation", "checkpoint", "offset", offset, "error", err)
+	}
+}
+
+// ackedOffset returns the offset covering every acknowledged update.
+func (p *Poller) ackedOffset() int64 {
+	p.ackMu.Lock()
+	defer p.ackMu.Unlock()
+	return p.acked
+}
+
+// settled reports whether every update below offset has been acknowledged or released.
+func (p *Poller) settled(offset int64) bool {
+	p.ackMu.Lock()
+	defer p.ackMu.Unlock()
+	return p.acked >= offset || p.released >= offset
+}
+
+// waitForAcks blocks until every update below offset has been acknowledged or
+// released, or the callback handler awaits a button press. It returns false if
+// ctx is cancelled first.
+func (p *Poller) waitForAcks(ctx context.Context, offset int64) bool {
+	for !p.settled(offset) {
+		var awaiting <-chan struct{}
+		if p.callbacks != nil {
+			awaiting = p.callbacks.Awaiting()
+		}
+		select {
+		case <-p.ackSig:
+		case <-awaiting:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+	return true
+}
+
+// allowedUpdates lists the update types requested from Telegram.
+func (p *Poller) allowedUpdates() string {
+	if p.callbacks != nil {
+		return `["message","callback_query"]`
+	}
+	return `["message"]`
+}
+
 // Poll performs a single getUpdates call and returns the updates.
 func (p *Poller) Poll(ctx context.Context) ([]Update, error) {
 	params := url.Values{}
 	if p.offset > 0 {
 		params.Set("offset", strconv.FormatInt(p.offset, 10))
 	}
-	params.Set("timeout", strconv.Itoa(p.timeout))
-	params.Set("allowed_updates", `["message"]`)
+	timeout := p.timeout
+	if len(p.backlog) > 0 {
+		timeout = min(timeout, backlogPollTimeout)
+	}
+	params.Set("timeout", strconv.Itoa(timeout))
+	params.Set("allowed_updates", p.allowedUpdates())
 
 	// Use a longer timeout for the HTTP request to accommodate long polling.
-	pollCtx, cancel := context.WithTimeout(ctx, time.Duration(p.timeout)*time.Second+5*time.Second)
+	pollCtx, cancel := context.WithTimeout(ctx, time.Duration(timeout)*time.Second+5*time.Second)
 	defer cancel()
 
 	data, err := p.client.doGet(pollCtx, "getUpdates", params)
@@ -74,6 +223,19 @@ func (p *Poller) Poll(ctx context.Context) ([]Update, error) {
 func (p *Poller) Run(ctx context.Context, out chan<- TelegramMessage) {
 	slog.Info("poller started", "component", "telegram", "operation", "poll_start")
 
+	if p.store != nil {
+		offset, err := p.store.LoadOffset()
+		if err != nil {
+			slog.Warn("failed to load offset checkpoint, starting from Telegram's",
+				"component", "telegram", "operation", "poll_start", "error", err)
+		} else if offset > p.offset {
+			p.offset = offset
+			p.acked = offset
+			slog.Info("resuming from offset checkpoint",
+				"component", "telegram", "operation", "poll_start", "offset", offset)
+		}
+	}
+
 	for {
 		var updates []Update
 		err := retryFn(ctx, 3, 2*time.Second, func() error {
@@ -86,20 +248,44 @@ func (p *Poller) Run(ctx context.Context, out chan<- TelegramMessage) {
 				slog.Info("poller stopped", "component", "telegram", "operation", "poll_stop")
 				return
 			}
-			slog.Error("poll failed after retries", "component", "telegram", "operation", "poll", "error", err)
+			p.failures++
+			delay := p.backoff()
+			slog.Error("poll failed after retries", "component", "telegram", "operation", "poll",
+				"failures", p.failures, "next_attempt_in", delay, "error", err)
+			if p.alert != nil && p.alertAfter > 0 && p.failures == p.alertAfter {
+				slog.Warn("poller outage threshold reached, alerting owners",
+					"component", "telegram", "operation", "poll", "failures", p.failures)
+				p.alert(ctx, p.failures, err)
+			}
 			select {
-			case <-time.After(retryDelay):
+			case <-time.After(delay):
 			case <-ctx.Done():
 				slog.Info("poller stopped", "component", "telegram", "operation", "poll_stop")
 				return
 			}
 			continue
 		}
+		if p.failures > 0 {
+			slog.Info("poller recovered", "component", "telegram", "operation", "poll", "failures", p.failures)
+			p.failures = 0
+		}
 
+		// Button presses are routed as they come; messages join the backlog and
+		// are handed off after the whole batch, since one blocked on out may be
+		// waiting for a press later in the batch.
 		for _, u := range updates {
 			if u.UpdateID >= p.offset {
 				p.offset = u.UpdateID + 1
 			}
+			if q := u.CallbackQuery; q != nil && p.callbacks != nil {
+				if p.isAllowed(q.From) {
+					p.callbacks.HandleCallback(ctx, *q)
+				} else {
+					slog.Warn("rejected unauthorized callback query",
+						"component", "telegram", "operation", "whitelist", "user_id", p.getUserID(q.From))
+				}
+				continue
+			}
 			if u.Message == nil {
 				continue
 			}
@@ -111,26 +297,93 @@ func (p *Poller) Run(ctx context.Context, out chan<- TelegramMessage) {
 				)
 				continue
 			}
-			select {
-			case out <- TelegramMessage{Message: *u.Message}:
-			case <-ctx.Done():
+			p.backlog = append(p.backlog, TelegramMessage{Message: *u.Message, UpdateID: u.UpdateID})
+		}
+		lastHandedOff, ok := p.handOff(ctx, out)
+		if !ok {
+			slog.Info("poller stopped", "component", "telegram", "operation", "poll_stop")
+			return
+		}
+
+		if p.store != nil && (len(updates) > 0 || lastHandedOff > 0) {
+			// Don't confirm the batch to Telegram before it has been processed.
+			if lastHandedOff > 0 && !p.waitForAcks(ctx, lastHandedOff+1) {
 				slog.Info("poller stopped", "component", "telegram", "operation", "poll_stop")
 				return
 			}
+			// A message still awaiting an answer is checkpointed by its own Ack,
+			// one still in the backlog once it has been handed off.
+			if len(p.backlog) == 0 && (p.handed == 0 || p.ackedOffset() > p.handed) {
+				p.checkpoint(p.offset)
+			}
+		}
+	}
+}
+
+// handOff sends the backlog on out, in order. While the callback handler awaits
+// a button press it stops early, keeping the rest for later, so the press can be
+// fetched: the consumer may be blocked on it. It returns the update ID of the
+// last message sent (0 = none), and false if ctx is cancelled.
+func (p *Poller) handOff(ctx context.Context, out chan<- TelegramMessage) (int64, bool) {
+	var last int64
+	for len(p.backlog) > 0 {
+		var awaiting <-chan struct{}
+		if p.callbacks != nil {
+			awaiting = p.callbacks.Awaiting()
 		}
+		select {
+		case out <- p.backlog[0]:
+			last = p.backlog[0].UpdateID
+			p.handed = last
+			p.backlog = p.backlog[1:]
+		case <-awaiting:
+			return last, true
+		case <-ctx.Done():
+			return last, false
+		}
+	}
+	return last, true
+}
+
+// backoff returns the delay before the next poll cycle after p.failures consecutive
+// failed cycles: retryDelay doubled per failure beyond the first, capped at maxRetryDelay.
+func (p *Poller) backoff() time.Duration {
+	d := retryDelay
+	for i := 1; i < p.failures && d < maxRetryDelay; i++ {
+		d *= 2
 	}
+	return min(d, maxRetryDelay)
 }
 
 // isAllowed checks if the user is in the whitelist.
 func (p *Poller) isAllowed(user *User) bool {
+	return isAllowedUser(p.allowedIDs, user)
+}
+
+// getUserID safely extracts the user ID for logging.
+func (p *Poller) getUserID(user *User) int64 {
+	return userID(user)
+}
+
+// allowedSet builds the whitelist lookup shared by Poller and Webhook.
+func allowedSet(ids []int64) map[int64]bool {
+	allowed := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		allowed[id] = true
+	}
+	return allowed
+}
+
+// isAllowedUser checks if the user is in the whitelist.
+func isAllowedUser(allowed map[int64]bool, user *User) bool {
 	if user == nil {
 		return false
 	}
-	return p.allowedIDs[user.ID]
+	return allowed[user.ID]
 }
 
-// getUserID safely extracts the user ID for logging.
-func (p *Poller) getUserID(user *User) int64 {
+// userID safely extracts the user ID for logging.
+func userID(user *User) int64 {
 	if user == nil {
 		return 0
 	}
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -862,3 +863,188 @@ func TestPoller_Poll_NetworkError(t *testing.T) {
 		t.Errorf("error = %q, want to contain 'telegram: poll:'", err.Error())
 	}
 }
+
+func TestPoller_Run_ForwardedMessageAllowlistUsesSender(t *testing.T) {
+	var callCount atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if callCount.Add(1) > 1 {
+			json.NewEncoder(w).Encode(apiResponse[[]Update]{Ok: true, Result: []Update{}})
+			return
+		}
+		json.NewEncoder(w).Encode(apiResponse[[]Update]{
+			Ok: true,
+			Result: []Update{
+				{
+					// A stranger forwarding the owner's message must be rejected.
+					UpdateID: 100,
+					Message: &Message{
+						MessageID:     1,
+						From:          &User{ID: 999, FirstName: "Stranger"},
+						Chat:          Chat{ID: 999, Type: "private"},
+						Text:          "owner said this",
+						ForwardOrigin: &MessageOrigin{Type: "user", SenderUser: &User{ID: 111, FirstName: "Owner"}},
+					},
+				},
+				{
+					// The owner forwarding a stranger's message is allowed.
+					UpdateID: 101,
+					Message: &Message{
+						MessageID:     2,
+						From:          &User{ID: 111, FirstName: "Owner"},
+						Chat:          Chat{ID: 111, Type: "private"},
+						Text:          "look at this",
+						ForwardOrigin: &MessageOrigin{Type: "user", SenderUser: &User{ID: 999, FirstName: "Stranger"}},
+					},
+				},
+			},
+		})
+	}))
+	defer srv.Close()
+
+	origHTTPDo := httpDo
+	httpDo = func(c *http.Client, req *http.Request) (*http.Response, error) {
+		return c.Do(req)
+	}
+	defer func() { httpDo = origHTTPDo }()
+
+	origRetry := retryFn
+	retryFn = func(_ context.Context, _ int, _ time.Duration, fn func() error) error {
+		return fn()
+	}
+	defer func() { retryFn = origRetry }()
+
+	p := NewPoller(&Client{baseURL: srv.URL + "/", httpClient: srv.Client()}, []int64{111}, 1)
+	out := make(chan TelegramMessage, 10)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		p.Run(ctx, out)
+		close(done)
+	}()
+
+	select {
+	case msg := <-out:
+		if msg.Message.MessageID != 2 {
+			t.Errorf("received message %d, want the owner's forward (2)", msg.Message.MessageID)
+		}
+		if origin, ok := msg.Message.ForwardedFrom(); !ok || origin != "Stranger" {
+			t.Errorf("ForwardedFrom = %q, %v; want Stranger", origin, ok)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("timeout waiting for message")
+	}
+	cancel()
+	<-done
+	if len(out) != 0 {
+		t.Errorf("unexpected extra messages in channel: %d", len(out))
+	}
+}
+
+func TestPoller_Run_OutageAlertOncePerOutage(t *testing.T) {
+	var callCount atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count := callCount.Add(1)
+		switch {
+		case count <= 4 || (count >= 6 && count <= 8): // two outages
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte("bad gateway"))
+		case count == 5 || count == 9:
+			json.NewEncoder(w).Encode(apiResponse[[]Update]{Ok: true, Result: []Update{{
+				UpdateID: int64(count),
+				Message: &Message{
+					MessageID: int64(count),
+					From:      &User{ID: 111},
+					Chat:      Chat{ID: 111, Type: "private"},
+					Text:      fmt.Sprintf("up %d", count),
+				},
+			}}})
+		default:
+			json.NewEncoder(w).Encode(apiResponse[[]Update]{Ok: true, Result: []Update{}})
+		}
+	}))
+	defer srv.Close()
+
+	origHTTPDo := httpDo
+	httpDo = func(c *http.Client, req *http.Request) (*http.Response, error) {
+		return c.Do(req)
+	}
+	defer func() { httpDo = origHTTPDo }()
+
+	origRetry := retryFn
+	retryFn = func(_ context.Context, _ int, _ time.Duration, fn func() error) error {
+		return fn()
+	}
+	defer func() { retryFn = origRetry }()
+
+	origDelay := retryDelay
+	retryDelay = time.Millisecond
+	defer func() { retryDelay = origDelay }()
+
+	client := &Client{
+		baseURL:    srv.URL + "/",
+		httpClient: srv.Client(),
+	}
+	p := NewPoller(client, []int64{111}, 1)
+	var alerts []int
+	var alertMu sync.Mutex
+	p.SetOutageAlert(3, func(_ context.Context, failures int, err error) {
+		if err == nil {
+			t.Error("alert should carry the last poll error")
+		}
+		alertMu.Lock()
+		alerts = append(alerts, failures)
+		alertMu.Unlock()
+	})
+
+	out := make(chan TelegramMessage, 10)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		p.Run(ctx, out)
+		close(done)
+	}()
+
+	for _, want := range []string{"up 5", "up 9"} {
+		select {
+		case msg := <-out:
+			if msg.Message.Text != want {
+				t.Fatalf("text = %q, want %q", msg.Message.Text, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timeout waiting for %q", want)
+		}
+	}
+	cancel()
+	<-done
+
+	alertMu.Lock()
+	defer alertMu.Unlock()
+	// Four failures in the first outage alert once at the threshold; recovery resets
+	// the count, so three failures in the second outage alert again.
+	if len(alerts) != 2 || alerts[0] != 3 || alerts[1] != 3 {
+		t.Errorf("alerts = %v, want [3 3]", alerts)
+	}
+}
+
+func TestPoller_Backoff(t *testing.T) {
+	origDelay, origMax := retryDelay, maxRetryDelay
+	retryDelay, maxRetryDelay = time.Second, 10*time.Second
+	defer func() { retryDelay, maxRetryDelay = origDelay, origMax }()
+
+	p := NewPoller(NewClient("test"), nil, 30)
+	for failures, want := range map[int]time.Duration{
+		1:   time.Second,
+		2:   2 * time.Second,
+		4:   8 * time.Second,
+		5:   10 * time.Second,
+		100: 10 * time.Second,
+	} {
+		p.failures = failures
+		if got := p.backoff(); got != want {
+			t.Errorf("backoff after %d failures = %v, want %v", failures, got, want)
+		}
+	}
+}
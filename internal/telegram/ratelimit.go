@@ -0,0 +1,102 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// rateLimiter spaces out sends: at most one per globalInterval overall and one per
+// chatInterval in each chat. Slots are reserved in call order, so sends to a chat
+// go out in the order they were requested.
+type rateLimiter struct {
+	mu             sync.Mutex
+	globalInterval time.Duration
+	chatInterval   time.Duration
+	nextGlobal     time.Time
+	nextChat       map[int64]time.Time
+}
+
+// reserve books the earliest send slot for chatID and returns when it starts.
+func (l *rateLimiter) reserve(chatID int64) time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	at := time.Now()
+	if l.nextGlobal.After(at) {
+		at = l.nextGlobal
+	}
+	if next := l.nextChat[chatID]; next.After(at) {
+		at = next
+	}
+	l.nextGlobal = at.Add(l.globalInterval)
+	l.nextChat[chatID] = at.Add(l.chatInterval)
+	return at
+}
+
+// wait blocks until chatID's next send slot, or until ctx is cancelled.
+func (l *rateLimiter) wait(ctx context.Context, chatID int64) error {
+	delay := time.Until(l.reserve(chatID))
+	if delay <= 0 {
+		return ctx.Err()
+	}
+	slog.Debug("send delayed by rate limit", "component", "telegram", "operation", "rate_limit", "chat_id", chatID, "delay", delay)
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// RateLimitedSender is a Sender that queues outgoing messages to stay under
+// Telegram's rate limits (about 30 messages per second overall and one per
+// second per chat) instead of sending bursts that get rejected with 429.
+// Reactions and deletions are not rate limited.
+type RateLimitedSender struct {
+	*Sender
+	limiter *rateLimiter
+}
+
+// NewRateLimitedSender wraps s with Telegram's default send limits.
+func NewRateLimitedSender(s *Sender) *RateLimitedSender {
+	return NewRateLimitedSenderWithIntervals(s, GlobalSendInterval, ChatSendInterval)
+}
+
+// NewRateLimitedSenderWithIntervals wraps s, allowing one send per global
+// interval overall and one per chat interval in each chat.
+func NewRateLimitedSenderWithIntervals(s *Sender, global, perChat time.Duration) *RateLimitedSender {
+	return &RateLimitedSender{
+		Sender: s,
+		limiter: &rateLimiter{
+			globalInterval: global,
+			chatInterval:   perChat,
+			nextChat:       make(map[int64]time.Time),
+		},
+	}
+}
+
+// Send waits for a send slot in the chat, then sends text.
+func (r *RateLimitedSender) Send(ctx context.Context, chatID int64, text string) error {
+	_, err := r.SendMessage(ctx, chatID, text)
+	return err
+}
+
+// SendMessage waits for a send slot in the chat, then sends text and returns its message ID.
+func (r *RateLimitedSender) SendMessage(ctx context.Context, chatID int64, text string) (int64, error) {
+	if err := r.limiter.wait(ctx, chatID); err != nil {
+		return 0, fmt.Errorf("telegram: send: %w", err)
+	}
+	return r.Sender.SendMessage(ctx, chatID, text)
+}
+
+// SendDocument waits for a send slot in the chat, then uploads the document.
+func (r *RateLimitedSender) SendDocument(ctx context.Context, chatID int64, fileName string, data []byte, caption string) error {
+	if err := r.limiter.wait(ctx, chatID); err != nil {
+		return fmt.Errorf("telegram: send_document: %w", err)
+	}
+	return r.Sender.SendDocument(ctx, chatID, fileName, data, caption)
+}
@@ -0,0 +1,141 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// sendLog records the chat, text and arrival time of each sendMessage call.
+type sendLog struct {
+	mu    sync.Mutex
+	sends []loggedSend
+}
+
+type loggedSend struct {
+	chatID int64
+	text   string
+	at     time.Time
+}
+
+func (l *sendLog) handler(w http.ResponseWriter, r *http.Request) {
+	var req sendMessageRequest
+	json.NewDecoder(r.Body).Decode(&req)
+	l.mu.Lock()
+	l.sends = append(l.sends, loggedSend{req.ChatID, req.Text, time.Now()})
+	l.mu.Unlock()
+	json.NewEncoder(w).Encode(apiResponse[Message]{Ok: true, Result: Message{MessageID: 1}})
+}
+
+func (l *sendLog) snapshot() []loggedSend {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]loggedSend(nil), l.sends...)
+}
+
+func TestRateLimitedSender_SpacesBurstInOrder(t *testing.T) {
+	log := &sendLog{}
+	const perChat = 40 * time.Millisecond
+	s := NewRateLimitedSenderWithIntervals(newTestSender(t, log.handler), 5*time.Millisecond, perChat)
+
+	texts := []string{"part 1", "part 2", "part 3", "part 4"}
+	for _, text := range texts {
+		if err := s.Send(context.Background(), 42, text); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+	}
+
+	sends := log.snapshot()
+	if len(sends) != len(texts) {
+		t.Fatalf("sent %d messages, want %d", len(sends), len(texts))
+	}
+	for i, sent := range sends {
+		if sent.text != texts[i] {
+			t.Errorf("message %d = %q, want %q", i, sent.text, texts[i])
+		}
+		if i > 0 {
+			if gap := sent.at.Sub(sends[i-1].at); gap < perChat-5*time.Millisecond {
+				t.Errorf("gap before message %d = %s, want at least %s", i, gap, perChat)
+			}
+		}
+	}
+}
+
+func TestRateLimitedSender_ConcurrentBurstKeepsChatOrder(t *testing.T) {
+	log := &sendLog{}
+	s := NewRateLimitedSenderWithIntervals(newTestSender(t, log.handler), 10*time.Millisecond, 30*time.Millisecond)
+
+	// Reserve slots in a known order, then let the sends race.
+	var wg sync.WaitGroup
+	for i, chatID := range []int64{1, 2, 1, 2} {
+		wg.Add(1)
+		go func(text string) {
+			defer wg.Done()
+			s.Send(context.Background(), chatID, text)
+		}(string(rune('a' + i)))
+		time.Sleep(5 * time.Millisecond)
+	}
+	wg.Wait()
+
+	sends := log.snapshot()
+	perChat := map[int64][]loggedSend{}
+	var prev time.Time
+	for _, sent := range sends {
+		perChat[sent.chatID] = append(perChat[sent.chatID], sent)
+		if !prev.IsZero() && sent.at.Sub(prev) < 5*time.Millisecond {
+			t.Errorf("global spacing violated: %s between sends", sent.at.Sub(prev))
+		}
+		prev = sent.at
+	}
+	if got := perChat[1]; len(got) != 2 || got[0].text != "a" || got[1].text != "c" {
+		t.Errorf("chat 1 order = %+v, want a then c", got)
+	}
+	if got := perChat[2]; len(got) != 2 || got[0].text != "b" || got[1].text != "d" {
+		t.Errorf("chat 2 order = %+v, want b then d", got)
+	}
+}
+
+func TestRateLimitedSender_CancellationReturnsPromptly(t *testing.T) {
+	log := &sendLog{}
+	s := NewRateLimitedSenderWithIntervals(newTestSender(t, log.handler), time.Millisecond, time.Hour)
+
+	if err := s.Send(context.Background(), 42, "first"); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 3)
+	for range 3 {
+		go func() { errs <- s.Send(ctx, 42, "queued") }()
+	}
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	for range 3 {
+		select {
+		case err := <-errs:
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("queued send error = %v, want context.Canceled", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("queued send did not return after cancellation")
+		}
+	}
+	if n := len(log.snapshot()); n != 1 {
+		t.Errorf("sent %d messages, want only the first", n)
+	}
+}
+
+func TestRateLimitedSender_KeepsSenderCapabilities(t *testing.T) {
+	var s any = NewRateLimitedSender(NewSender(NewClient("token")))
+	if _, ok := s.(interface {
+		DeleteMessage(ctx context.Context, chatID, messageID int64) error
+		React(ctx context.Context, chatID, messageID int64, emoji string) error
+	}); !ok {
+		t.Error("RateLimitedSender lost the wrapped sender's methods")
+	}
+}
@@ -3,10 +3,17 @@ package telegram
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// sendRetryDelay is the base backoff delay for transient send failures.
+var sendRetryDelay = time.Second
+
 // Sender sends messages via the Telegram Bot API.
 type Sender struct {
 	client *Client
@@ -18,7 +25,14 @@ func NewSender(client *Client) *Sender {
 }
 
 // Send sends a text message to the specified chat.
+// If Telegram rejects the HTML formatting, the message is re-sent once as plain text.
 func (s *Sender) Send(ctx context.Context, chatID int64, text string) error {
+	_, err := s.SendMessage(ctx, chatID, text)
+	return err
+}
+
+// SendMessage sends a text message like Send and returns the ID of the sent message.
+func (s *Sender) SendMessage(ctx context.Context, chatID int64, text string) (int64, error) {
 	slog.Debug("sending message", "component", "telegram", "operation", "send", "chat_id", chatID)
 
 	body := sendMessageRequest{
@@ -27,22 +41,28 @@ func (s *Sender) Send(ctx context.Context, chatID int64, text string) error {
 		ParseMode: "HTML",
 	}
 
-	data, err := s.client.doPost(ctx, "sendMessage", body)
+	data, err := s.postWithRetry(ctx, "sendMessage", body)
+	if err != nil && isParseEntitiesError(err) {
+		slog.Warn("HTML formatting rejected, falling back to plain text",
+			"component", "telegram", "operation", "send", "chat_id", chatID, "error", err)
+		body = sendMessageRequest{ChatID: chatID, Text: stripHTML(text)}
+		data, err = s.postWithRetry(ctx, "sendMessage", body)
+	}
 	if err != nil {
-		return fmt.Errorf("telegram: send: %w", err)
+		return 0, fmt.Errorf("telegram: send: %w", err)
 	}
 
 	var resp apiResponse[Message]
 	if err := json.Unmarshal(data, &resp); err != nil {
-		return fmt.Errorf("telegram: send: unmarshal: %w", err)
+		return 0, fmt.Errorf("telegram: send: unmarshal: %w", err)
 	}
 
 	if !resp.Ok {
-		return fmt.Errorf("telegram: send: %s", resp.Description)
+		return 0, fmt.Errorf("telegram: send: %s", resp.Description)
 	}
 
 	slog.Debug("message sent", "component", "telegram", "operation", "send", "message_id", resp.Result.MessageID)
-	return nil
+	return resp.Result.MessageID, nil
 }
 
 // React sets an emoji reaction on a message.
@@ -71,3 +91,113 @@ func (s *Sender) React(ctx context.Context, chatID, messageID int64, emoji strin
 
 	return nil
 }
+
+// DeleteMessage deletes a message from a chat. Messages Telegram refuses to
+// delete (too old, already deleted) are logged and treated as success.
+func (s *Sender) DeleteMessage(ctx context.Context, chatID, messageID int64) error {
+	slog.Debug("deleting message", "component", "telegram", "operation", "delete", "chat_id", chatID, "message_id", messageID)
+
+	body := deleteMessageRequest{ChatID: chatID, MessageID: messageID}
+	data, err := s.postWithRetry(ctx, "deleteMessage", body)
+	if err != nil && isUndeletableError(err) {
+		slog.Warn("message can't be deleted, skipping",
+			"component", "telegram", "operation", "delete", "chat_id", chatID, "message_id", messageID, "error", err)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("telegram: delete: %w", err)
+	}
+
+	var resp apiResponse[bool]
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return fmt.Errorf("telegram: delete: unmarshal: %w", err)
+	}
+	if !resp.Ok {
+		return fmt.Errorf("telegram: delete: %s", resp.Description)
+	}
+	return nil
+}
+
+// SendDocument uploads data as a file named fileName to the chat, with an optional caption.
+// A caption over MaxCaptionLength is truncated with a marker on the document, and the
+// full text follows as a separate plain-text message so none of it is lost.
+func (s *Sender) SendDocument(ctx context.Context, chatID int64, fileName string, data []byte, caption string) error {
+	slog.Debug("sending document", "component", "telegram", "operation", "send_document", "chat_id", chatID, "file_name", fileName)
+
+	fields := map[string]string{"chat_id": strconv.FormatInt(chatID, 10)}
+	var followUp string
+	if caption != "" {
+		fields["caption"] = truncateRunes(caption, MaxCaptionLength)
+		if fields["caption"] != caption {
+			followUp = caption
+		}
+	}
+	body, err := s.withRetry(ctx, func() ([]byte, error) {
+		return s.client.doPostMultipart(ctx, "sendDocument", fields, "document", fileName, data)
+	})
+	if err != nil {
+		return fmt.Errorf("telegram: send_document: %w", err)
+	}
+
+	var resp apiResponse[Message]
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return fmt.Errorf("telegram: send_document: unmarshal: %w", err)
+	}
+	if !resp.Ok {
+		return fmt.Errorf("telegram: send_document: %s", resp.Description)
+	}
+
+	if followUp != "" {
+		slog.Warn("document caption over limit, sending full text separately",
+			"component", "telegram", "operation", "send_document", "chat_id", chatID, "caption_length", len([]rune(followUp)))
+		msg := sendMessageRequest{ChatID: chatID, Text: truncateRunes(followUp, MaxMessageLength)}
+		if _, err := s.postWithRetry(ctx, "sendMessage", msg); err != nil {
+			return fmt.Errorf("telegram: send_document: caption follow-up: %w", err)
+		}
+	}
+	return nil
+}
+
+// postWithRetry calls doPost, retrying transient failures (network errors, 429, 5xx)
+// with exponential backoff. Client errors (4xx) are returned immediately.
+func (s *Sender) postWithRetry(ctx context.Context, method string, body any) ([]byte, error) {
+	return s.withRetry(ctx, func() ([]byte, error) {
+		return s.client.doPost(ctx, method, body)
+	})
+}
+
+// withRetry runs post with the send retry policy: transient failures are retried,
+// non-retryable API errors are returned immediately.
+func (s *Sender) withRetry(ctx context.Context, post func() ([]byte, error)) ([]byte, error) {
+	var data []byte
+	var nonRetryErr error
+	err := retryFn(ctx, 3, sendRetryDelay, func() error {
+		var postErr error
+		data, postErr = post()
+		var ae *apiError
+		if errors.As(postErr, &ae) && !ae.IsRetryable() {
+			nonRetryErr = postErr
+			return nil
+		}
+		return postErr
+	})
+	if nonRetryErr != nil {
+		return nil, nonRetryErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// isParseEntitiesError reports whether err is Telegram's rejection of malformed formatting.
+func isParseEntitiesError(err error) bool {
+	return strings.Contains(err.Error(), "can't parse entities")
+}
+
+// isUndeletableError reports whether err is Telegram's refusal to delete a message
+// that is too old, already gone, or not deletable by the bot.
+func isUndeletableError(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "message can't be deleted") || strings.Contains(msg, "message to delete not found")
+}
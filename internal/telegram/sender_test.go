@@ -3,13 +3,25 @@ package telegram
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/edouard/pureclaw/internal/platform"
 )
 
+// fastSendRetry shortens the send backoff so retry paths don't slow the suite.
+func fastSendRetry(t *testing.T) {
+	t.Helper()
+	orig := sendRetryDelay
+	sendRetryDelay = time.Millisecond
+	t.Cleanup(func() { sendRetryDelay = orig })
+}
+
 func TestNewSender(t *testing.T) {
 	client := NewClient("test-token")
 	s := NewSender(client)
@@ -99,6 +111,7 @@ func TestSender_Send_APIError(t *testing.T) {
 }
 
 func TestSender_Send_NetworkError(t *testing.T) {
+	fastSendRetry(t)
 	client := &Client{
 		baseURL:    "http://127.0.0.1:1/",
 		httpClient: &http.Client{},
@@ -149,6 +162,7 @@ func TestSender_Send_InvalidJSON(t *testing.T) {
 }
 
 func TestSender_Send_HTTPError(t *testing.T) {
+	fastSendRetry(t)
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusInternalServerError)
 		w.Write([]byte("internal error"))
@@ -175,3 +189,315 @@ func TestSender_Send_HTTPError(t *testing.T) {
 		t.Errorf("error = %q, want to contain 'telegram: send:'", err.Error())
 	}
 }
+
+func TestSender_Send_ParseEntitiesFallsBackToPlainText(t *testing.T) {
+	var reqs []sendMessageRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var req sendMessageRequest
+		json.Unmarshal(body, &req)
+		reqs = append(reqs, req)
+		if req.ParseMode == "HTML" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"ok":false,"description":"Bad Request: can't parse entities: unexpected end tag"}`))
+			return
+		}
+		json.NewEncoder(w).Encode(apiResponse[Message]{Ok: true, Result: Message{MessageID: 7}})
+	}))
+	defer srv.Close()
+
+	origHTTPDo := httpDo
+	httpDo = func(c *http.Client, req *http.Request) (*http.Response, error) {
+		return c.Do(req)
+	}
+	defer func() { httpDo = origHTTPDo }()
+
+	client := &Client{
+		baseURL:    srv.URL + "/",
+		httpClient: srv.Client(),
+	}
+	s := NewSender(client)
+
+	if err := s.Send(context.Background(), 12345, "<pre>a &lt; b</pre></b>"); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if len(reqs) != 2 {
+		t.Fatalf("requests = %d, want 2", len(reqs))
+	}
+	if reqs[1].ParseMode != "" {
+		t.Errorf("fallback ParseMode = %q, want empty", reqs[1].ParseMode)
+	}
+	if reqs[1].Text != "a < b" {
+		t.Errorf("fallback Text = %q, want %q", reqs[1].Text, "a < b")
+	}
+}
+
+func TestSender_Send_RetriesTransientError(t *testing.T) {
+	fastSendRetry(t)
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte("unavailable"))
+			return
+		}
+		json.NewEncoder(w).Encode(apiResponse[Message]{Ok: true, Result: Message{MessageID: 1}})
+	}))
+	defer srv.Close()
+
+	origHTTPDo := httpDo
+	httpDo = func(c *http.Client, req *http.Request) (*http.Response, error) {
+		return c.Do(req)
+	}
+	defer func() { httpDo = origHTTPDo }()
+
+	s := NewSender(&Client{baseURL: srv.URL + "/", httpClient: srv.Client()})
+	if err := s.Send(context.Background(), 1, "hi"); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("calls = %d, want 2", calls)
+	}
+}
+
+func TestSender_Send_ClientErrorNotRetried(t *testing.T) {
+	fastSendRetry(t)
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"ok":false,"description":"Bad Request: chat not found"}`))
+	}))
+	defer srv.Close()
+
+	origHTTPDo := httpDo
+	httpDo = func(c *http.Client, req *http.Request) (*http.Response, error) {
+		return c.Do(req)
+	}
+	defer func() { httpDo = origHTTPDo }()
+
+	s := NewSender(&Client{baseURL: srv.URL + "/", httpClient: srv.Client()})
+	if err := s.Send(context.Background(), 1, "hi"); err == nil {
+		t.Fatal("expected error for 400 response")
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1 (4xx must not be retried)", calls)
+	}
+}
+
+func TestSender_Send_RespectsRetryBudget(t *testing.T) {
+	fastSendRetry(t)
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	origHTTPDo := httpDo
+	httpDo = func(c *http.Client, req *http.Request) (*http.Response, error) {
+		return c.Do(req)
+	}
+	defer func() { httpDo = origHTTPDo }()
+
+	ctx := platform.WithRetryBudget(context.Background(), platform.NewRetryBudget(0))
+	s := NewSender(&Client{baseURL: srv.URL + "/", httpClient: srv.Client()})
+	err := s.Send(ctx, 1, "hi")
+	if !errors.Is(err, platform.ErrRetryBudgetExhausted) {
+		t.Fatalf("err = %v, want ErrRetryBudgetExhausted", err)
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
+
+// newTestSender returns a Sender whose API calls are served by handler.
+func newTestSender(t *testing.T, handler http.HandlerFunc) *Sender {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	origHTTPDo := httpDo
+	httpDo = func(c *http.Client, req *http.Request) (*http.Response, error) {
+		return c.Do(req)
+	}
+	t.Cleanup(func() { httpDo = origHTTPDo })
+
+	return NewSender(&Client{baseURL: srv.URL + "/", httpClient: srv.Client()})
+}
+
+func TestSender_SendMessage_ReturnsMessageID(t *testing.T) {
+	s := newTestSender(t, func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(apiResponse[Message]{Ok: true, Result: Message{MessageID: 99}})
+	})
+
+	id, err := s.SendMessage(context.Background(), 12345, "Working…")
+	if err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+	if id != 99 {
+		t.Errorf("message ID = %d, want 99", id)
+	}
+}
+
+func TestSender_DeleteMessage_Success(t *testing.T) {
+	var req deleteMessageRequest
+	s := newTestSender(t, func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/deleteMessage") {
+			t.Errorf("path = %s, want suffix /deleteMessage", r.URL.Path)
+		}
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &req)
+		w.Write([]byte(`{"ok":true,"result":true}`))
+	})
+
+	if err := s.DeleteMessage(context.Background(), 12345, 99); err != nil {
+		t.Fatalf("DeleteMessage: %v", err)
+	}
+	if req.ChatID != 12345 || req.MessageID != 99 {
+		t.Errorf("request = %+v, want chat 12345 message 99", req)
+	}
+}
+
+func TestSender_DeleteMessage_CannotBeDeletedIgnored(t *testing.T) {
+	s := newTestSender(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"ok":false,"description":"Bad Request: message can't be deleted"}`))
+	})
+
+	if err := s.DeleteMessage(context.Background(), 12345, 99); err != nil {
+		t.Errorf("DeleteMessage = %v, want nil for undeletable message", err)
+	}
+}
+
+func TestSender_DeleteMessage_OtherErrorReturned(t *testing.T) {
+	s := newTestSender(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"ok":false,"description":"Forbidden: bot was blocked by the user"}`))
+	})
+
+	err := s.DeleteMessage(context.Background(), 12345, 99)
+	if err == nil || !strings.Contains(err.Error(), "telegram: delete:") {
+		t.Errorf("DeleteMessage = %v, want wrapped delete error", err)
+	}
+}
+
+func TestSender_SendDocument(t *testing.T) {
+	var gotPath, gotChatID, gotCaption, gotFileName, gotFile string
+	s := newTestSender(t, func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("ParseMultipartForm: %v", err)
+			return
+		}
+		gotChatID = r.FormValue("chat_id")
+		gotCaption = r.FormValue("caption")
+		f, hdr, err := r.FormFile("document")
+		if err != nil {
+			t.Errorf("FormFile: %v", err)
+			return
+		}
+		defer f.Close()
+		data, _ := io.ReadAll(f)
+		gotFileName, gotFile = hdr.Filename, string(data)
+		json.NewEncoder(w).Encode(apiResponse[Message]{Ok: true, Result: Message{MessageID: 7}})
+	})
+
+	err := s.SendDocument(context.Background(), 12345, "export.md", []byte("# Transcript"), "Conversation export")
+	if err != nil {
+		t.Fatalf("SendDocument: %v", err)
+	}
+	if !strings.HasSuffix(gotPath, "/sendDocument") {
+		t.Errorf("path = %q, want sendDocument", gotPath)
+	}
+	if gotChatID != "12345" || gotCaption != "Conversation export" {
+		t.Errorf("fields = (%q, %q)", gotChatID, gotCaption)
+	}
+	if gotFileName != "export.md" || gotFile != "# Transcript" {
+		t.Errorf("file = (%q, %q)", gotFileName, gotFile)
+	}
+}
+
+// documentRecorder captures sendDocument captions and sendMessage texts.
+type documentRecorder struct {
+	captions []string
+	messages []sendMessageRequest
+}
+
+func (d *documentRecorder) handler(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/sendDocument") {
+			if err := r.ParseMultipartForm(1 << 20); err != nil {
+				t.Errorf("ParseMultipartForm: %v", err)
+				return
+			}
+			d.captions = append(d.captions, r.FormValue("caption"))
+		} else {
+			var req sendMessageRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			d.messages = append(d.messages, req)
+		}
+		json.NewEncoder(w).Encode(apiResponse[Message]{Ok: true, Result: Message{MessageID: 7}})
+	}
+}
+
+func TestSender_SendDocument_CaptionTruncated(t *testing.T) {
+	rec := &documentRecorder{}
+	s := newTestSender(t, rec.handler(t))
+
+	caption := strings.Repeat("é", MaxCaptionLength+10)
+	if err := s.SendDocument(context.Background(), 1, "a.txt", []byte("x"), caption); err != nil {
+		t.Fatalf("SendDocument: %v", err)
+	}
+	if len(rec.captions) != 1 {
+		t.Fatalf("captions = %d, want 1", len(rec.captions))
+	}
+	got := []rune(rec.captions[0])
+	if len(got) != MaxCaptionLength {
+		t.Errorf("caption length = %d runes, want %d", len(got), MaxCaptionLength)
+	}
+	if !strings.HasSuffix(rec.captions[0], truncatedMarker) {
+		t.Errorf("caption does not end with the truncation marker: %q", string(got[len(got)-5:]))
+	}
+}
+
+func TestSender_SendDocument_LongCaptionFollowUp(t *testing.T) {
+	rec := &documentRecorder{}
+	s := newTestSender(t, rec.handler(t))
+
+	description := strings.Repeat("long description ", 100)
+	if err := s.SendDocument(context.Background(), 42, "report.md", []byte("x"), description); err != nil {
+		t.Fatalf("SendDocument: %v", err)
+	}
+	if len(rec.messages) != 1 {
+		t.Fatalf("follow-up messages = %d, want 1", len(rec.messages))
+	}
+	if rec.messages[0].ChatID != 42 || rec.messages[0].Text != description {
+		t.Errorf("follow-up = %+v, want the full description", rec.messages[0])
+	}
+}
+
+func TestSender_SendDocument_ShortCaptionNoFollowUp(t *testing.T) {
+	rec := &documentRecorder{}
+	s := newTestSender(t, rec.handler(t))
+
+	if err := s.SendDocument(context.Background(), 42, "report.md", []byte("x"), "short"); err != nil {
+		t.Fatalf("SendDocument: %v", err)
+	}
+	if len(rec.messages) != 0 || rec.captions[0] != "short" {
+		t.Errorf("captions = %q, messages = %+v", rec.captions, rec.messages)
+	}
+}
+
+func TestSender_SendDocument_APIError(t *testing.T) {
+	s := newTestSender(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"ok":false,"description":"Bad Request: file is empty"}`))
+	})
+
+	err := s.SendDocument(context.Background(), 12345, "export.md", nil, "")
+	if err == nil || !strings.Contains(err.Error(), "file is empty") {
+		t.Fatalf("SendDocument error = %v, want API error", err)
+	}
+}
@@ -1,9 +1,20 @@
 package telegram
 
+import "strings"
+
 // Update represents a Telegram Bot API Update object.
 type Update struct {
-	UpdateID int64    `json:"update_id"`
-	Message  *Message `json:"message,omitempty"`
+	UpdateID      int64          `json:"update_id"`
+	Message       *Message       `json:"message,omitempty"`
+	CallbackQuery *CallbackQuery `json:"callback_query,omitempty"`
+}
+
+// CallbackQuery is a press on an inline keyboard button of a message the bot sent.
+type CallbackQuery struct {
+	ID      string   `json:"id"`
+	From    *User    `json:"from"`
+	Message *Message `json:"message,omitempty"`
+	Data    string   `json:"data,omitempty"`
 }
 
 // Message represents a Telegram message.
@@ -14,6 +25,16 @@ type Message struct {
 	Date      int64  `json:"date"`
 	Text      string `json:"text,omitempty"`
 	Voice     *Voice `json:"voice,omitempty"`
+
+	// Message kinds the agent can't process yet; only their presence is used.
+	Sticker  *Sticker  `json:"sticker,omitempty"`
+	Location *Location `json:"location,omitempty"`
+	Poll     *Poll     `json:"poll,omitempty"`
+
+	ForwardOrigin *MessageOrigin `json:"forward_origin,omitempty"` // Set when the message was forwarded
+	ForwardFrom   *User          `json:"forward_from,omitempty"`   // Legacy forward field from older Bot API versions
+
+	ReplyToMessage *Message `json:"reply_to_message,omitempty"` // Set when the message replies to an earlier one
 }
 
 // User represents a Telegram user.
@@ -21,12 +42,79 @@ type User struct {
 	ID        int64  `json:"id"`
 	IsBot     bool   `json:"is_bot"`
 	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name,omitempty"`
+	Username  string `json:"username,omitempty"`
 }
 
 // Chat represents a Telegram chat.
 type Chat struct {
-	ID   int64  `json:"id"`
-	Type string `json:"type"`
+	ID    int64  `json:"id"`
+	Type  string `json:"type"`
+	Title string `json:"title,omitempty"`
+}
+
+// MessageOrigin describes the original author of a forwarded message.
+// Type is one of "user", "hidden_user", "chat" or "channel".
+type MessageOrigin struct {
+	Type            string `json:"type"`
+	Date            int64  `json:"date"`
+	SenderUser      *User  `json:"sender_user,omitempty"`      // type "user"
+	SenderUserName  string `json:"sender_user_name,omitempty"` // type "hidden_user"
+	SenderChat      *Chat  `json:"sender_chat,omitempty"`      // type "chat"
+	Chat            *Chat  `json:"chat,omitempty"`             // type "channel"
+	AuthorSignature string `json:"author_signature,omitempty"` // channel post signature
+}
+
+// ForwardedFrom returns a human-readable description of the original author
+// of a forwarded message, and false if the message was not forwarded.
+func (m Message) ForwardedFrom() (string, bool) {
+	if o := m.ForwardOrigin; o != nil {
+		switch {
+		case o.SenderUser != nil:
+			return o.SenderUser.displayName(), true
+		case o.SenderUserName != "":
+			return o.SenderUserName, true
+		case o.SenderChat != nil:
+			return o.SenderChat.displayName(), true
+		case o.Chat != nil:
+			name := o.Chat.displayName()
+			if o.AuthorSignature != "" {
+				name += " (" + o.AuthorSignature + ")"
+			}
+			return name, true
+		}
+		return "unknown", true
+	}
+	if m.ForwardFrom != nil {
+		return m.ForwardFrom.displayName(), true
+	}
+	return "", false
+}
+
+// displayName formats a user as "First Last (@username)", omitting missing parts.
+func (u *User) displayName() string {
+	name := u.FirstName
+	if u.LastName != "" {
+		name += " " + u.LastName
+	}
+	if u.Username != "" {
+		if name == "" {
+			return "@" + u.Username
+		}
+		name += " (@" + u.Username + ")"
+	}
+	if name == "" {
+		return "unknown"
+	}
+	return name
+}
+
+// displayName returns the chat title, falling back to its type.
+func (c *Chat) displayName() string {
+	if c.Title != "" {
+		return c.Title
+	}
+	return c.Type
 }
 
 // Voice represents a Telegram voice message.
@@ -35,6 +123,63 @@ type Voice struct {
 	Duration int    `json:"duration"`
 }
 
+// Sticker represents a Telegram sticker.
+type Sticker struct {
+	FileID string `json:"file_id"`
+	Emoji  string `json:"emoji,omitempty"`
+}
+
+// Location represents a point on the map.
+type Location struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// Poll represents a Telegram poll.
+type Poll struct {
+	ID       string `json:"id"`
+	Question string `json:"question"`
+}
+
+// UnsupportedType returns the kind of a message the agent can't process
+// ("sticker", "location" or "poll"), and false for text and voice messages.
+func (m Message) UnsupportedType() (string, bool) {
+	switch {
+	case m.Sticker != nil:
+		return "sticker", true
+	case m.Location != nil:
+		return "location", true
+	case m.Poll != nil:
+		return "poll", true
+	}
+	return "", false
+}
+
+// maxQuoteLength caps the quoted text of a replied-to message, in runes.
+const maxQuoteLength = 300
+
+// QuotedText returns the text of the message m replies to, on one line and cut
+// to maxQuoteLength runes, and false if m is not a reply. A replied-to message
+// without text is described by its kind, e.g. "[voice message]".
+func (m Message) QuotedText() (string, bool) {
+	r := m.ReplyToMessage
+	if r == nil {
+		return "", false
+	}
+	text := strings.Join(strings.Fields(r.Text), " ")
+	if text == "" {
+		kind, ok := r.UnsupportedType()
+		switch {
+		case r.Voice != nil:
+			kind = "voice"
+		case !ok:
+			kind = "empty"
+		}
+		text = "[" + kind + " message]"
+	}
+	return truncateRunes(text, maxQuoteLength), true
+}
+
 // apiResponse is a generic wrapper for Telegram Bot API responses.
 type apiResponse[T any] struct {
 	Ok          bool   `json:"ok"`
@@ -49,6 +194,36 @@ type sendMessageRequest struct {
 	ParseMode string `json:"parse_mode,omitempty"`
 }
 
+// sendKeyboardRequest is the JSON body for a sendMessage call carrying an inline keyboard.
+type sendKeyboardRequest struct {
+	ChatID      int64                `json:"chat_id"`
+	Text        string               `json:"text"`
+	ReplyMarkup inlineKeyboardMarkup `json:"reply_markup"`
+}
+
+// inlineKeyboardMarkup is an inline keyboard attached to a message, as rows of buttons.
+type inlineKeyboardMarkup struct {
+	InlineKeyboard [][]inlineKeyboardButton `json:"inline_keyboard"`
+}
+
+// inlineKeyboardButton is a button that sends CallbackData back as a callback query.
+type inlineKeyboardButton struct {
+	Text         string `json:"text"`
+	CallbackData string `json:"callback_data"`
+}
+
+// answerCallbackQueryRequest is the JSON body for the answerCallbackQuery API call.
+type answerCallbackQueryRequest struct {
+	CallbackQueryID string `json:"callback_query_id"`
+	Text            string `json:"text,omitempty"`
+}
+
+// deleteMessageRequest is the JSON body for the deleteMessage API call.
+type deleteMessageRequest struct {
+	ChatID    int64 `json:"chat_id"`
+	MessageID int64 `json:"message_id"`
+}
+
 // setMessageReactionRequest is the JSON body for the setMessageReaction API call.
 type setMessageReactionRequest struct {
 	ChatID    int64          `json:"chat_id"`
@@ -64,5 +239,31 @@ type reactionType struct {
 
 // TelegramMessage carries a validated message to the event loop.
 type TelegramMessage struct {
-	Message Message
+	Message  Message
+	UpdateID int64 // Update that carried the message; acknowledge it once processed (0 = not from the poller)
+}
+
+// setWebhookRequest is the JSON body for the setWebhook API call.
+type setWebhookRequest struct {
+	URL            string   `json:"url"`
+	SecretToken    string   `json:"secret_token,omitempty"`
+	AllowedUpdates []string `json:"allowed_updates,omitempty"`
+}
+
+// deleteWebhookRequest is the JSON body for the deleteWebhook API call.
+type deleteWebhookRequest struct {
+	DropPendingUpdates bool `json:"drop_pending_updates"`
+}
+
+// sendChatActionRequest is the JSON body for the sendChatAction API call.
+type sendChatActionRequest struct {
+	ChatID int64  `json:"chat_id"`
+	Action string `json:"action"`
+}
+
+// editMessageTextRequest is the JSON body for the editMessageText API call.
+type editMessageTextRequest struct {
+	ChatID    int64  `json:"chat_id"`
+	MessageID int64  `json:"message_id"`
+	Text      string `json:"text"`
 }
@@ -2,6 +2,7 @@ package telegram
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
 )
 
@@ -88,6 +89,51 @@ func TestUpdate_WithVoice(t *testing.T) {
 	}
 }
 
+func TestUpdate_WithSticker(t *testing.T) {
+	raw := `{
+		"update_id": 101,
+		"message": {
+			"message_id": 3,
+			"chat": {"id": 111, "type": "private"},
+			"date": 1700000000,
+			"sticker": {"file_id": "stk1", "emoji": "😀"}
+		}
+	}`
+
+	var u Update
+	if err := json.Unmarshal([]byte(raw), &u); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if u.Message.Sticker == nil || u.Message.Sticker.FileID != "stk1" {
+		t.Fatalf("Sticker = %+v, want file_id stk1", u.Message.Sticker)
+	}
+	if kind, ok := u.Message.UnsupportedType(); !ok || kind != "sticker" {
+		t.Errorf("UnsupportedType() = %q, %v, want sticker, true", kind, ok)
+	}
+}
+
+func TestMessage_UnsupportedType(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  Message
+		want string
+	}{
+		{"text", Message{Text: "hi"}, ""},
+		{"voice", Message{Voice: &Voice{FileID: "v"}}, ""},
+		{"sticker", Message{Sticker: &Sticker{FileID: "s"}}, "sticker"},
+		{"location", Message{Location: &Location{Latitude: 48.85, Longitude: 2.35}}, "location"},
+		{"poll", Message{Poll: &Poll{ID: "p", Question: "?"}}, "poll"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.msg.UnsupportedType()
+			if got != tt.want || ok != (tt.want != "") {
+				t.Errorf("UnsupportedType() = %q, %v, want %q", got, ok, tt.want)
+			}
+		})
+	}
+}
+
 func TestUpdate_WithoutMessage(t *testing.T) {
 	raw := `{"update_id": 200}`
 
@@ -219,3 +265,80 @@ func TestAPIResponse_MessageResult(t *testing.T) {
 		t.Errorf("MessageID = %d, want 99", resp.Result.MessageID)
 	}
 }
+
+func TestMessage_ForwardedFrom(t *testing.T) {
+	tests := []struct {
+		name   string
+		msg    Message
+		want   string
+		wantOK bool
+	}{
+		{"not forwarded", Message{Text: "hi"}, "", false},
+		{"user", Message{ForwardOrigin: &MessageOrigin{Type: "user", SenderUser: &User{FirstName: "Ada", LastName: "Lovelace", Username: "ada"}}}, "Ada Lovelace (@ada)", true},
+		{"username only", Message{ForwardOrigin: &MessageOrigin{Type: "user", SenderUser: &User{Username: "bob"}}}, "@bob", true},
+		{"hidden user", Message{ForwardOrigin: &MessageOrigin{Type: "hidden_user", SenderUserName: "Anonymous Fox"}}, "Anonymous Fox", true},
+		{"chat", Message{ForwardOrigin: &MessageOrigin{Type: "chat", SenderChat: &Chat{Title: "Team"}}}, "Team", true},
+		{"channel with signature", Message{ForwardOrigin: &MessageOrigin{Type: "channel", Chat: &Chat{Title: "News"}, AuthorSignature: "Editor"}}, "News (Editor)", true},
+		{"untitled channel", Message{ForwardOrigin: &MessageOrigin{Type: "channel", Chat: &Chat{Type: "channel"}}}, "channel", true},
+		{"unknown origin", Message{ForwardOrigin: &MessageOrigin{Type: "user"}}, "unknown", true},
+		{"legacy forward_from", Message{ForwardFrom: &User{FirstName: "Old"}}, "Old", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.msg.ForwardedFrom()
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("ForwardedFrom() = %q, %v; want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestMessage_ForwardOriginJSON(t *testing.T) {
+	raw := `{"message_id":1,"chat":{"id":1,"type":"private"},"text":"x",
+		"forward_origin":{"type":"user","date":1700000000,"sender_user":{"id":5,"is_bot":false,"first_name":"Eve"}}}`
+	var m Message
+	if err := json.Unmarshal([]byte(raw), &m); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if m.ForwardOrigin == nil || m.ForwardOrigin.SenderUser == nil || m.ForwardOrigin.SenderUser.ID != 5 {
+		t.Fatalf("ForwardOrigin = %+v, want sender user 5", m.ForwardOrigin)
+	}
+}
+
+func TestMessage_QuotedText(t *testing.T) {
+	long := strings.Repeat("é", maxQuoteLength+50)
+	tests := []struct {
+		name   string
+		msg    Message
+		want   string
+		wantOK bool
+	}{
+		{"not a reply", Message{Text: "hi"}, "", false},
+		{"text", Message{ReplyToMessage: &Message{Text: "Meeting at 3pm?"}}, "Meeting at 3pm?", true},
+		{"multi-line", Message{ReplyToMessage: &Message{Text: "line one\n\n  line two"}}, "line one line two", true},
+		{"voice", Message{ReplyToMessage: &Message{Voice: &Voice{FileID: "v"}}}, "[voice message]", true},
+		{"sticker", Message{ReplyToMessage: &Message{Sticker: &Sticker{FileID: "s"}}}, "[sticker message]", true},
+		{"no content", Message{ReplyToMessage: &Message{}}, "[empty message]", true},
+		{"long", Message{ReplyToMessage: &Message{Text: long}}, strings.Repeat("é", maxQuoteLength-1) + truncatedMarker, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.msg.QuotedText()
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("QuotedText() = %q, %v; want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestMessage_ReplyToMessageJSON(t *testing.T) {
+	raw := `{"message_id":2,"chat":{"id":1,"type":"private"},"text":"yes",
+		"reply_to_message":{"message_id":1,"chat":{"id":1,"type":"private"},"text":"Deploy now?"}}`
+	var m Message
+	if err := json.Unmarshal([]byte(raw), &m); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if m.ReplyToMessage == nil || m.ReplyToMessage.Text != "Deploy now?" {
+		t.Errorf("ReplyToMessage = %+v, want the quoted message", m.ReplyToMessage)
+	}
+}
@@ -0,0 +1,193 @@
+package telegram
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// secretHeader carries the secret_token given to setWebhook on every update Telegram delivers.
+const secretHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// maxUpdateSize caps the body of an incoming update.
+const maxUpdateSize = 1 << 20
+
+// webhookShutdownTimeout bounds the graceful stop of the webhook server once Run's context ends.
+var webhookShutdownTimeout = 5 * time.Second
+
+// Webhook receives updates from the Telegram Bot API through an HTTPS webhook,
+// as an alternative to long polling with Poller.
+type Webhook struct {
+	client     *Client
+	allowedIDs map[int64]bool
+	url        string
+	secret     string
+	server     *http.Server
+	callbacks  CallbackHandler // enabled by SetCallbackHandler
+}
+
+// NewWebhook creates a Webhook that registers url with Telegram and serves
+// updates on listenAddr, with a whitelist of allowed user IDs. A fresh secret
+// token is generated so that only Telegram's requests are accepted.
+func NewWebhook(client *Client, allowedIDs []int64, url, listenAddr string) *Webhook {
+	return &Webhook{
+		client:     client,
+		allowedIDs: allowedSet(allowedIDs),
+		url:        url,
+		secret:     rand.Text(),
+		server:     &http.Server{Addr: listenAddr, ReadHeaderTimeout: 10 * time.Second},
+	}
+}
+
+// SetCallbackHandler subscribes to callback queries and routes button presses
+// from allowed users to h. It must be called before Run.
+func (w *Webhook) SetCallbackHandler(h CallbackHandler) {
+	w.callbacks = h
+}
+
+// allowedUpdates lists the update types requested from Telegram.
+func (w *Webhook) allowedUpdates() []string {
+	if w.callbacks != nil {
+		return []string{"message", "callback_query"}
+	}
+	return []string{"message"}
+}
+
+// Register points the bot's webhook at the configured URL. Pending updates are
+// kept and delivered to the webhook.
+func (w *Webhook) Register(ctx context.Context) error {
+	req := setWebhookRequest{URL: w.url, SecretToken: w.secret, AllowedUpdates: w.allowedUpdates()}
+	data, err := w.client.doPost(ctx, "setWebhook", req)
+	if err != nil {
+		return fmt.Errorf("telegram: set_webhook: %w", err)
+	}
+
+	var resp apiResponse[bool]
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return fmt.Errorf("telegram: set_webhook: unmarshal: %w", err)
+	}
+	if !resp.Ok {
+		return fmt.Errorf("telegram: set_webhook: %s", resp.Description)
+	}
+	return nil
+}
+
+// DeleteWebhook removes the bot's webhook, if any, so updates can be fetched
+// with getUpdates again. Pending updates are kept.
+func (c *Client) DeleteWebhook(ctx context.Context) error {
+	data, err := c.doPost(ctx, "deleteWebhook", deleteWebhookRequest{DropPendingUpdates: false})
+	if err != nil {
+		return fmt.Errorf("telegram: delete_webhook: %w", err)
+	}
+
+	var resp apiResponse[bool]
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return fmt.Errorf("telegram: delete_webhook: unmarshal: %w", err)
+	}
+	if !resp.Ok {
+		return fmt.Errorf("telegram: delete_webhook: %s", resp.Description)
+	}
+	return nil
+}
+
+// Run registers the webhook and serves updates, filtering messages by whitelist
+// and sending valid messages on the out channel. It blocks until ctx is
+// cancelled, then shuts the server down gracefully. An error is returned if the
+// webhook can't be registered or the server can't listen.
+func (w *Webhook) Run(ctx context.Context, out chan<- TelegramMessage) error {
+	err := retryFn(ctx, 3, 2*time.Second, func() error {
+		return w.Register(ctx)
+	})
+	if err != nil {
+		return err
+	}
+	w.server.Handler = w.handler(ctx, out)
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- w.server.ListenAndServe()
+	}()
+	slog.Info("webhook started", "component", "telegram", "operation", "webhook_start", "listen", w.server.Addr)
+
+	select {
+	case err := <-serveErr:
+		return fmt.Errorf("telegram: webhook: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), webhookShutdownTimeout)
+	defer cancel()
+	if err := w.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("webhook shutdown incomplete", "component", "telegram", "operation", "webhook_stop", "error", err)
+	}
+	slog.Info("webhook stopped", "component", "telegram", "operation", "webhook_stop")
+	return nil
+}
+
+// Shutdown stops the webhook server gracefully, waiting for in-flight updates
+// until ctx ends, then deletes the webhook so that a restart in polling mode
+// picks up the updates queued meanwhile.
+func (w *Webhook) Shutdown(ctx context.Context) error {
+	if err := w.server.Shutdown(ctx); err != nil {
+		return fmt.Errorf("telegram: webhook shutdown: %w", err)
+	}
+	return w.client.DeleteWebhook(ctx)
+}
+
+// handler decodes the updates Telegram posts, routes button presses to the
+// callback handler and hands allowed messages to out.
+// A message is only answered with 200 once out has taken it, so Telegram
+// redelivers it if ctx ends first.
+func (w *Webhook) handler(ctx context.Context, out chan<- TelegramMessage) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get(secretHeader)), []byte(w.secret)) != 1 {
+			slog.Warn("rejected webhook request with a bad secret token",
+				"component", "telegram", "operation", "webhook", "remote_addr", r.RemoteAddr)
+			http.Error(rw, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		var u Update
+		if err := json.NewDecoder(http.MaxBytesReader(rw, r.Body, maxUpdateSize)).Decode(&u); err != nil {
+			slog.Warn("malformed webhook update", "component", "telegram", "operation", "webhook", "error", err)
+			http.Error(rw, "bad request", http.StatusBadRequest)
+			return
+		}
+		if q := u.CallbackQuery; q != nil && w.callbacks != nil {
+			if isAllowedUser(w.allowedIDs, q.From) {
+				w.callbacks.HandleCallback(r.Context(), *q)
+			} else {
+				slog.Warn("rejected unauthorized callback query",
+					"component", "telegram", "operation", "whitelist", "user_id", userID(q.From))
+			}
+			return
+		}
+		if u.Message == nil {
+			return
+		}
+		if !isAllowedUser(w.allowedIDs, u.Message.From) {
+			slog.Warn("rejected unauthorized message",
+				"component", "telegram",
+				"operation", "whitelist",
+				"user_id", userID(u.Message.From),
+			)
+			return
+		}
+
+		select {
+		case out <- TelegramMessage{Message: *u.Message}:
+		case <-ctx.Done():
+			http.Error(rw, "shutting down", http.StatusServiceUnavailable)
+		case <-r.Context().Done():
+		}
+	})
+}
@@ -0,0 +1,304 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// postUpdate sends body to h as Telegram would, with the given secret token.
+func postUpdate(h http.Handler, secret, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set(secretHeader, secret)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestNewWebhook(t *testing.T) {
+	client := NewClient("test-token")
+	w := NewWebhook(client, []int64{111, 222}, "https://example.com/hook", ":8443")
+
+	if w.client != client {
+		t.Error("client mismatch")
+	}
+	if !w.allowedIDs[111] || !w.allowedIDs[222] || len(w.allowedIDs) != 2 {
+		t.Errorf("allowedIDs = %v, want 111 and 222", w.allowedIDs)
+	}
+	if w.server.Addr != ":8443" {
+		t.Errorf("Addr = %q, want :8443", w.server.Addr)
+	}
+	if w.secret == "" {
+		t.Error("secret is empty")
+	}
+	if other := NewWebhook(client, nil, "https://example.com/hook", ":8443"); other.secret == w.secret {
+		t.Error("two webhooks share the same secret")
+	}
+}
+
+func TestWebhook_Register(t *testing.T) {
+	var got setWebhookRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/setWebhook") {
+			t.Errorf("path = %s, want suffix /setWebhook", r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		json.NewEncoder(w).Encode(apiResponse[bool]{Ok: true, Result: true})
+	}))
+	defer srv.Close()
+
+	client := &Client{baseURL: srv.URL + "/", httpClient: srv.Client()}
+	w := NewWebhook(client, []int64{111}, "https://example.com/hook", ":0")
+
+	if err := w.Register(context.Background()); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if got.URL != "https://example.com/hook" {
+		t.Errorf("url = %q, want https://example.com/hook", got.URL)
+	}
+	if got.SecretToken != w.secret {
+		t.Errorf("secret_token = %q, want the webhook's secret", got.SecretToken)
+	}
+	if len(got.AllowedUpdates) != 1 || got.AllowedUpdates[0] != "message" {
+		t.Errorf("allowed_updates = %v, want [message]", got.AllowedUpdates)
+	}
+}
+
+func TestWebhook_Register_APIError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(apiResponse[bool]{Ok: false, Description: "bad webhook: HTTPS url must be provided"})
+	}))
+	defer srv.Close()
+
+	client := &Client{baseURL: srv.URL + "/", httpClient: srv.Client()}
+	w := NewWebhook(client, []int64{111}, "http://example.com/hook", ":0")
+
+	err := w.Register(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "HTTPS url must be provided") {
+		t.Fatalf("err = %v, want the API description", err)
+	}
+}
+
+func TestClient_DeleteWebhook(t *testing.T) {
+	var raw map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/deleteWebhook") {
+			t.Errorf("path = %s, want suffix /deleteWebhook", r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&raw)
+		json.NewEncoder(w).Encode(apiResponse[bool]{Ok: true, Result: true})
+	}))
+	defer srv.Close()
+
+	client := &Client{baseURL: srv.URL + "/", httpClient: srv.Client()}
+	if err := client.DeleteWebhook(context.Background()); err != nil {
+		t.Fatalf("DeleteWebhook: %v", err)
+	}
+	if drop, ok := raw["drop_pending_updates"]; !ok || drop != false {
+		t.Errorf("drop_pending_updates = %v, want false so queued updates are kept", drop)
+	}
+}
+
+func TestWebhook_Shutdown_DeletesWebhook(t *testing.T) {
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		json.NewEncoder(w).Encode(apiResponse[bool]{Ok: true, Result: true})
+	}))
+	defer srv.Close()
+
+	client := &Client{baseURL: srv.URL + "/", httpClient: srv.Client()}
+	w := NewWebhook(client, []int64{111}, "https://example.com/hook", ":0")
+
+	if err := w.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if len(paths) != 1 || !strings.HasSuffix(paths[0], "/deleteWebhook") {
+		t.Errorf("API calls = %v, want a single deleteWebhook", paths)
+	}
+}
+
+func TestWebhook_Handler_AllowedMessage(t *testing.T) {
+	w := NewWebhook(NewClient("test-token"), []int64{111}, "https://example.com/hook", ":0")
+	out := make(chan TelegramMessage, 1)
+	h := w.handler(context.Background(), out)
+
+	rec := postUpdate(h, w.secret, `{"update_id":100,"message":{"message_id":1,"from":{"id":111},"chat":{"id":111,"type":"private"},"text":"hello"}}`)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	select {
+	case msg := <-out:
+		if msg.Message.Text != "hello" {
+			t.Errorf("text = %q, want hello", msg.Message.Text)
+		}
+		if msg.UpdateID != 0 {
+			t.Errorf("UpdateID = %d, want 0 (webhook updates are not acknowledged)", msg.UpdateID)
+		}
+	default:
+		t.Fatal("no message handed off")
+	}
+}
+
+func TestWebhook_Handler_Rejections(t *testing.T) {
+	w := NewWebhook(NewClient("test-token"), []int64{111}, "https://example.com/hook", ":0")
+
+	tests := []struct {
+		name   string
+		secret string
+		body   string
+		want   int
+	}{
+		{"bad secret", "wrong", `{"update_id":1,"message":{"message_id":1,"from":{"id":111},"chat":{"id":111},"text":"hi"}}`, http.StatusUnauthorized},
+		{"missing secret", "", `{"update_id":1,"message":{"message_id":1,"from":{"id":111},"chat":{"id":111},"text":"hi"}}`, http.StatusUnauthorized},
+		{"bad json", w.secret, `{not json`, http.StatusBadRequest},
+		{"unauthorized user", w.secret, `{"update_id":1,"message":{"message_id":1,"from":{"id":999},"chat":{"id":999},"text":"hi"}}`, http.StatusOK},
+		{"no sender", w.secret, `{"update_id":1,"message":{"message_id":1,"chat":{"id":111},"text":"hi"}}`, http.StatusOK},
+		{"no message", w.secret, `{"update_id":1}`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := make(chan TelegramMessage, 1)
+			rec := postUpdate(w.handler(context.Background(), out), tt.secret, tt.body)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+			if len(out) != 0 {
+				t.Error("message handed off, want none")
+			}
+		})
+	}
+}
+
+func TestWebhook_Handler_MethodNotAllowed(t *testing.T) {
+	w := NewWebhook(NewClient("test-token"), []int64{111}, "https://example.com/hook", ":0")
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(secretHeader, w.secret)
+	rec := httptest.NewRecorder()
+	w.handler(context.Background(), make(chan TelegramMessage, 1)).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("status = %d, want 405", rec.Code)
+	}
+}
+
+func TestWebhook_Handler_ShuttingDown(t *testing.T) {
+	w := NewWebhook(NewClient("test-token"), []int64{111}, "https://example.com/hook", ":0")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Unbuffered and never read: the message can't be handed off.
+	rec := postUpdate(w.handler(ctx, make(chan TelegramMessage)), w.secret,
+		`{"update_id":1,"message":{"message_id":1,"from":{"id":111},"chat":{"id":111},"text":"hi"}}`)
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want 503 so Telegram redelivers", rec.Code)
+	}
+}
+
+func TestWebhook_Run_ServesUntilCancelled(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(apiResponse[bool]{Ok: true, Result: true})
+	}))
+	defer api.Close()
+
+	origRetry := retryFn
+	retryFn = func(_ context.Context, _ int, _ time.Duration, fn func() error) error {
+		return fn()
+	}
+	defer func() { retryFn = origRetry }()
+
+	// Reserve a free port for the webhook server.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	client := &Client{baseURL: api.URL + "/", httpClient: api.Client()}
+	w := NewWebhook(client, []int64{111}, "https://example.com/hook", addr)
+
+	out := make(chan TelegramMessage, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- w.Run(ctx, out) }()
+
+	body := `{"update_id":1,"message":{"message_id":1,"from":{"id":111},"chat":{"id":111},"text":"hello"}}`
+	var resp *http.Response
+	for range 50 {
+		req, _ := http.NewRequest(http.MethodPost, "http://"+addr+"/", strings.NewReader(body))
+		req.Header.Set(secretHeader, w.secret)
+		if resp, err = http.DefaultClient.Do(req); err == nil {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("webhook never came up: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	if msg := <-out; msg.Message.Text != "hello" {
+		t.Errorf("text = %q, want hello", msg.Message.Text)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Run = %v, want nil after cancellation", err)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("Run did not return after cancellation")
+	}
+}
+
+func TestWebhook_Run_RegisterFailure(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(apiResponse[bool]{Ok: false, Description: "bad webhook"})
+	}))
+	defer api.Close()
+
+	origRetry := retryFn
+	retryFn = func(_ context.Context, _ int, _ time.Duration, fn func() error) error {
+		return fn()
+	}
+	defer func() { retryFn = origRetry }()
+
+	client := &Client{baseURL: api.URL + "/", httpClient: api.Client()}
+	w := NewWebhook(client, []int64{111}, "https://example.com/hook", "127.0.0.1:0")
+
+	if err := w.Run(context.Background(), make(chan TelegramMessage, 1)); err == nil {
+		t.Fatal("Run = nil, want the registration error")
+	}
+}
+
+func TestWebhook_Handler_CallbackQuery(t *testing.T) {
+	w := NewWebhook(NewClient("test-token"), []int64{111}, "https://example.com/hook", ":0")
+	cb := &stubCallbacks{got: make(chan CallbackQuery, 2)}
+	w.SetCallbackHandler(cb)
+	if got := w.allowedUpdates(); len(got) != 2 || got[1] != "callback_query" {
+		t.Errorf("allowed_updates = %v, want message and callback_query", got)
+	}
+	out := make(chan TelegramMessage)
+	h := w.handler(context.Background(), out)
+
+	rec := postUpdate(h, w.secret, `{"update_id":1,"callback_query":{"id":"q","from":{"id":111},"data":"confirm:x:yes"}}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	postUpdate(h, w.secret, `{"update_id":2,"callback_query":{"id":"s","from":{"id":999},"data":"confirm:x:yes"}}`)
+
+	if len(cb.got) != 1 || (<-cb.got).ID != "q" {
+		t.Error("want only the owner's callback routed to the handler")
+	}
+}
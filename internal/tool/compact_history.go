@@ -0,0 +1,66 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+)
+
+// HistoryCompactor replaces an agent's conversation history with a summary.
+type HistoryCompactor interface {
+	CompactHistory(ctx context.Context, focus string) (int, error)
+}
+
+type compactHistoryArgs struct {
+	Focus string `json:"focus"`
+}
+
+// NewCompactHistory returns the definition for the compact_history tool, which lets
+// the agent summarize its own conversation history when the context grows long.
+func NewCompactHistory(c HistoryCompactor) Definition {
+	return Definition{
+		Name:        "compact_history",
+		Description: "Summarize the conversation history so far and replace it with a single summary, freeing context. Use it when the conversation has grown long and older details no longer need to be kept verbatim",
+		Parameters: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"focus": map[string]any{
+					"type":        "string",
+					"description": "Optional: what the summary must preserve (e.g. open tasks, decisions about project X)",
+				},
+			},
+		},
+		Handler: makeCompactHistoryHandler(c),
+	}
+}
+
+func makeCompactHistoryHandler(c HistoryCompactor) Handler {
+	return func(ctx context.Context, args json.RawMessage) ToolResult {
+		if c == nil {
+			return unavailable("compact_history")
+		}
+		var a compactHistoryArgs
+		if len(args) > 0 {
+			if err := json.Unmarshal(args, &a); err != nil {
+				slog.Warn("invalid arguments",
+					"component", "tool",
+					"operation", "compact_history",
+					"error", err,
+				)
+				return ToolResult{Success: false, Error: fmt.Sprintf("invalid arguments: %v", err)}
+			}
+		}
+
+		turns, err := c.CompactHistory(ctx, a.Focus)
+		if err != nil {
+			slog.Error("history compaction failed",
+				"component", "tool",
+				"operation", "compact_history",
+				"error", err,
+			)
+			return ToolResult{Success: false, Error: fmt.Sprintf("compaction failed: %v", err)}
+		}
+		return ToolResult{Success: true, Output: fmt.Sprintf("compacted %d history turn(s) into one summary", turns)}
+	}
+}
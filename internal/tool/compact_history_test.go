@@ -0,0 +1,55 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type fakeCompactor struct {
+	turns int
+	err   error
+	focus string
+}
+
+func (f *fakeCompactor) CompactHistory(ctx context.Context, focus string) (int, error) {
+	f.focus = focus
+	return f.turns, f.err
+}
+
+func TestCompactHistory_Success(t *testing.T) {
+	c := &fakeCompactor{turns: 6}
+	result := NewCompactHistory(c).Handler(context.Background(), json.RawMessage(`{"focus":"open tasks"}`))
+	if !result.Success {
+		t.Fatalf("expected success, got error %q", result.Error)
+	}
+	if result.Output != "compacted 6 history turn(s) into one summary" {
+		t.Errorf("Output = %q", result.Output)
+	}
+	if c.focus != "open tasks" {
+		t.Errorf("focus = %q, want %q", c.focus, "open tasks")
+	}
+}
+
+func TestCompactHistory_Error(t *testing.T) {
+	result := NewCompactHistory(&fakeCompactor{err: errors.New("llm down")}).Handler(context.Background(), json.RawMessage(`{}`))
+	if result.Success || !strings.Contains(result.Error, "llm down") {
+		t.Errorf("result = %+v, want failure with cause", result)
+	}
+}
+
+func TestCompactHistory_InvalidArgs(t *testing.T) {
+	result := NewCompactHistory(&fakeCompactor{}).Handler(context.Background(), json.RawMessage(`{"focus":1}`))
+	if result.Success || !strings.Contains(result.Error, "invalid arguments") {
+		t.Errorf("result = %+v, want invalid arguments", result)
+	}
+}
+
+func TestCompactHistory_Unavailable(t *testing.T) {
+	result := NewCompactHistory(nil).Handler(context.Background(), json.RawMessage(`{}`))
+	if result.Success {
+		t.Error("expected failure without a compactor")
+	}
+}
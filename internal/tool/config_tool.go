@@ -0,0 +1,155 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/edouard/pureclaw/internal/config"
+)
+
+// Replaceable for testing.
+var configSaveFn = config.Save
+
+// ConfigField is a config.json field the config tool may read and change while
+// the agent runs. Value returns a pointer to the field in cfg; Apply, if set,
+// pushes the field's new value to the components that use it.
+type ConfigField struct {
+	Value func(cfg *config.Config) any
+	Apply func(cfg *config.Config)
+}
+
+type configArgs struct {
+	Action string          `json:"action"`
+	Field  string          `json:"field"`
+	Value  json.RawMessage `json:"value"`
+}
+
+// NewConfigTool returns the definition for the config tool, which reads and
+// sets the hot-reloadable config fields listed in fields. A change is saved to
+// path and applied at once. Only calls made on behalf of an owner are allowed;
+// any other config.json field is rejected, since it only takes effect after a
+// restart.
+func NewConfigTool(cfg *config.Config, path string, fields map[string]ConfigField) Definition {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return Definition{
+		Name:        "config",
+		Description: "Read or change a pureclaw setting at runtime. Changes are saved to config.json and take effect immediately. Only these fields can be used: " + strings.Join(names, ", "),
+		Parameters: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"action": map[string]any{
+					"type":        "string",
+					"enum":        []string{"get", "set"},
+					"description": "get to read the field, set to change it",
+				},
+				"field": map[string]any{
+					"type":        "string",
+					"enum":        names,
+					"description": "config.json field name",
+				},
+				"value": map[string]any{
+					"description": "New value for set, as it would appear in config.json (e.g. 3, true, \"72h\")",
+				},
+			},
+			"required": []string{"action", "field"},
+		},
+		Handler: makeConfigHandler(cfg, path, fields),
+	}
+}
+
+func makeConfigHandler(cfg *config.Config, path string, fields map[string]ConfigField) Handler {
+	var mu sync.Mutex
+	return func(ctx context.Context, args json.RawMessage) ToolResult {
+		if cfg == nil || path == "" {
+			return unavailable("config")
+		}
+		if tc, ok := FromContext(ctx); !ok || !tc.Owner {
+			slog.Warn("config access denied",
+				"component", "tool",
+				"operation", "config",
+			)
+			return ToolResult{Success: false, Error: "config can only be used while handling an owner's message"}
+		}
+		var a configArgs
+		if err := json.Unmarshal(args, &a); err != nil {
+			return ToolResult{Success: false, Error: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+		field, ok := fields[a.Field]
+		if !ok {
+			if slices.Contains(configFieldNames(), a.Field) {
+				return ToolResult{Success: false, Error: fmt.Sprintf("%s only takes effect after a restart: edit config.json and restart pureclaw", a.Field)}
+			}
+			return ToolResult{Success: false, Error: fmt.Sprintf("unknown config field %q", a.Field)}
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		switch a.Action {
+		case "get":
+			current, err := json.Marshal(field.Value(cfg))
+			if err != nil {
+				return ToolResult{Success: false, Error: err.Error()}
+			}
+			return ToolResult{Success: true, Output: fmt.Sprintf("%s = %s", a.Field, current)}
+		case "set":
+			return setConfigField(cfg, path, a.Field, field, a.Value)
+		default:
+			return ToolResult{Success: false, Error: fmt.Sprintf("invalid arguments: action must be get or set, got %q", a.Action)}
+		}
+	}
+}
+
+// setConfigField stores value in the field, saves cfg to path and applies the
+// change. cfg is left unchanged if the value doesn't parse or the save fails.
+func setConfigField(cfg *config.Config, path, name string, field ConfigField, value json.RawMessage) ToolResult {
+	if len(value) == 0 {
+		return ToolResult{Success: false, Error: "invalid arguments: value is required for set"}
+	}
+	prev := *cfg
+	if err := json.Unmarshal(value, field.Value(cfg)); err != nil {
+		*cfg = prev
+		return ToolResult{Success: false, Error: fmt.Sprintf("invalid value for %s: %v", name, err)}
+	}
+	if err := configSaveFn(cfg, path); err != nil {
+		*cfg = prev
+		slog.Error("config save failed",
+			"component", "tool",
+			"operation", "config",
+			"field", name,
+			"error", err,
+		)
+		return ToolResult{Success: false, Error: err.Error()}
+	}
+	if field.Apply != nil {
+		field.Apply(cfg)
+	}
+	updated, _ := json.Marshal(field.Value(cfg))
+	slog.Info("config field changed",
+		"component", "tool",
+		"operation", "config",
+		"field", name,
+		"value", string(updated),
+	)
+	return ToolResult{Success: true, Output: fmt.Sprintf("%s set to %s and saved to %s", name, updated, path)}
+}
+
+// configFieldNames returns the JSON names of every config.json field.
+func configFieldNames() []string {
+	t := reflect.TypeFor[config.Config]()
+	names := make([]string, 0, t.NumField())
+	for i := range t.NumField() {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,135 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/edouard/pureclaw/internal/config"
+)
+
+// configTestFields whitelists presence_window, recording each applied value.
+func configTestFields(applied *[]time.Duration) map[string]ConfigField {
+	return map[string]ConfigField{
+		"presence_window": {
+			Value: func(cfg *config.Config) any { return &cfg.PresenceWindow },
+			Apply: func(cfg *config.Config) { *applied = append(*applied, cfg.PresenceWindow.Duration) },
+		},
+	}
+}
+
+func ownerCtx() context.Context {
+	return WithContext(context.Background(), ToolContext{Owner: true})
+}
+
+func TestConfigTool_Get(t *testing.T) {
+	cfg := &config.Config{PresenceWindow: config.Duration{Duration: 72 * time.Hour}}
+	def := NewConfigTool(cfg, filepath.Join(t.TempDir(), "config.json"), configTestFields(new([]time.Duration)))
+
+	result := def.Handler(ownerCtx(), json.RawMessage(`{"action":"get","field":"presence_window"}`))
+	if !result.Success {
+		t.Fatalf("get failed: %s", result.Error)
+	}
+	if result.Output != `presence_window = "72h0m0s"` {
+		t.Errorf("output = %q", result.Output)
+	}
+}
+
+func TestConfigTool_SetPersistsAndApplies(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	cfg := &config.Config{Workspace: "/ws"}
+	var applied []time.Duration
+	def := NewConfigTool(cfg, path, configTestFields(&applied))
+
+	result := def.Handler(ownerCtx(), json.RawMessage(`{"action":"set","field":"presence_window","value":"48h"}`))
+	if !result.Success {
+		t.Fatalf("set failed: %s", result.Error)
+	}
+	if cfg.PresenceWindow.Duration != 48*time.Hour {
+		t.Errorf("cfg.PresenceWindow = %v, want 48h", cfg.PresenceWindow.Duration)
+	}
+	if len(applied) != 1 || applied[0] != 48*time.Hour {
+		t.Errorf("applied = %v, want [48h]", applied)
+	}
+	saved, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("load saved config: %v", err)
+	}
+	if saved.PresenceWindow.Duration != 48*time.Hour || saved.Workspace != "/ws" {
+		t.Errorf("saved config = %+v, want presence_window 48h and the other fields kept", saved)
+	}
+}
+
+func TestConfigTool_RejectsRestartRequiredField(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	cfg := &config.Config{Workspace: "/ws"}
+	def := NewConfigTool(cfg, path, configTestFields(new([]time.Duration)))
+
+	result := def.Handler(ownerCtx(), json.RawMessage(`{"action":"set","field":"workspace","value":"/elsewhere"}`))
+	if result.Success {
+		t.Fatal("setting workspace should be rejected")
+	}
+	if !strings.Contains(result.Error, "restart") {
+		t.Errorf("error = %q, want it to mention a restart", result.Error)
+	}
+	if cfg.Workspace != "/ws" {
+		t.Errorf("cfg.Workspace = %q, want it unchanged", cfg.Workspace)
+	}
+
+	result = def.Handler(ownerCtx(), json.RawMessage(`{"action":"get","field":"no_such_field"}`))
+	if result.Success || !strings.Contains(result.Error, "unknown config field") {
+		t.Errorf("unknown field result = %+v", result)
+	}
+}
+
+func TestConfigTool_RequiresOwner(t *testing.T) {
+	cfg := &config.Config{}
+	def := NewConfigTool(cfg, filepath.Join(t.TempDir(), "config.json"), configTestFields(new([]time.Duration)))
+	args := json.RawMessage(`{"action":"set","field":"presence_window","value":"1h"}`)
+
+	for name, ctx := range map[string]context.Context{
+		"no tool context": context.Background(),
+		"not owner":       WithContext(context.Background(), ToolContext{}),
+	} {
+		if result := def.Handler(ctx, args); result.Success {
+			t.Errorf("%s: set should be refused", name)
+		}
+	}
+	if cfg.PresenceWindow.Duration != 0 {
+		t.Errorf("cfg.PresenceWindow = %v, want unchanged", cfg.PresenceWindow.Duration)
+	}
+}
+
+func TestConfigTool_SetErrorsLeaveConfigUnchanged(t *testing.T) {
+	cfg := &config.Config{PresenceWindow: config.Duration{Duration: time.Hour}}
+	var applied []time.Duration
+	def := NewConfigTool(cfg, filepath.Join(t.TempDir(), "config.json"), configTestFields(&applied))
+
+	result := def.Handler(ownerCtx(), json.RawMessage(`{"action":"set","field":"presence_window","value":"soon"}`))
+	if result.Success || !strings.Contains(result.Error, "invalid value") {
+		t.Errorf("bad value result = %+v", result)
+	}
+
+	orig := configSaveFn
+	configSaveFn = func(*config.Config, string) error { return errors.New("disk full") }
+	defer func() { configSaveFn = orig }()
+	result = def.Handler(ownerCtx(), json.RawMessage(`{"action":"set","field":"presence_window","value":"2h"}`))
+	if result.Success || !strings.Contains(result.Error, "disk full") {
+		t.Errorf("save failure result = %+v", result)
+	}
+
+	if cfg.PresenceWindow.Duration != time.Hour || len(applied) != 0 {
+		t.Errorf("PresenceWindow = %v, applied = %v; want 1h and nothing applied", cfg.PresenceWindow.Duration, applied)
+	}
+}
+
+func TestConfigTool_Unavailable(t *testing.T) {
+	def := NewConfigTool(nil, "", nil)
+	if result := def.Handler(ownerCtx(), json.RawMessage(`{}`)); result.Success {
+		t.Error("config without a config should be unavailable")
+	}
+}
@@ -0,0 +1,65 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+
+	"github.com/edouard/pureclaw/internal/platform"
+)
+
+// ConfirmationRule marks a tool as requiring owner confirmation before it runs.
+// A rule without ExceptPaths always requires confirmation. A rule with ExceptPaths
+// only requires confirmation when the call's "path" argument resolves outside
+// every listed directory (relative entries are resolved against the policy root).
+type ConfirmationRule struct {
+	Tool        string
+	ExceptPaths []string
+}
+
+// ConfirmationPolicy decides which tool calls need owner confirmation.
+type ConfirmationPolicy struct {
+	Root  string // base directory for relative ExceptPaths entries
+	Rules []ConfirmationRule
+}
+
+// Confirmer asks the owner to approve a tool call and reports the decision.
+type Confirmer interface {
+	Confirm(ctx context.Context, name string, args json.RawMessage) (bool, error)
+}
+
+// Requires reports whether the given tool call needs confirmation under the policy.
+func (p ConfirmationPolicy) Requires(name string, args json.RawMessage) bool {
+	for _, rule := range p.Rules {
+		if rule.Tool != name {
+			continue
+		}
+		if len(rule.ExceptPaths) == 0 {
+			return true
+		}
+		if !p.pathExempt(rule, args) {
+			return true
+		}
+	}
+	return false
+}
+
+// pathExempt reports whether the call's "path" argument falls within one of the
+// rule's exempt directories. Missing or unparsable paths are never exempt.
+func (p ConfirmationPolicy) pathExempt(rule ConfirmationRule, args json.RawMessage) bool {
+	var a struct {
+		Path string `json:"path"`
+	}
+	if err := json.Unmarshal(args, &a); err != nil || a.Path == "" {
+		return false
+	}
+	for _, dir := range rule.ExceptPaths {
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(p.Root, dir)
+		}
+		if platform.ValidatePath(dir, a.Path) == nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,27 @@
+package tool
+
+import (
+	"context"
+
+	"github.com/edouard/pureclaw/internal/llm"
+)
+
+// ToolContext carries per-call execution context from the agent to tool handlers.
+type ToolContext struct {
+	WorkspaceRoot string        // root of the workspace the calling agent runs in
+	History       []llm.Message // the agent's recent conversation, oldest first (read-only)
+	Owner         bool          // the call was made while handling a message from an owner
+}
+
+type toolContextKey struct{}
+
+// WithContext returns a copy of ctx carrying tc.
+func WithContext(ctx context.Context, tc ToolContext) context.Context {
+	return context.WithValue(ctx, toolContextKey{}, tc)
+}
+
+// FromContext returns the ToolContext carried by ctx, if any.
+func FromContext(ctx context.Context) (ToolContext, bool) {
+	tc, ok := ctx.Value(toolContextKey{}).(ToolContext)
+	return tc, ok
+}
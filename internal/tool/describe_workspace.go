@@ -0,0 +1,162 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/edouard/pureclaw/internal/memory"
+	"github.com/edouard/pureclaw/internal/platform"
+	"github.com/edouard/pureclaw/internal/workspace"
+)
+
+// describeMaxDepth limits how deep the workspace tree summary descends.
+const describeMaxDepth = 2
+
+// describeMaxEntries caps the entries listed per directory in the tree summary.
+const describeMaxEntries = 20
+
+// NewDescribeWorkspace returns the definition for the describe_workspace tool,
+// which summarizes the workspace structure without dumping file contents.
+// The summary is jailed to root: symlinks resolving outside it are not followed.
+func NewDescribeWorkspace(ws *workspace.Workspace, root string) Definition {
+	return Definition{
+		Name:        "describe_workspace",
+		Description: "Summarize the workspace: core files (AGENT.md, SOUL.md, HEARTBEAT.md) with sizes, loaded skills, memory files, and the directory tree. Does not return file contents",
+		Parameters: map[string]any{
+			"type":       "object",
+			"properties": map[string]any{},
+		},
+		Handler: makeDescribeWorkspaceHandler(ws, root),
+	}
+}
+
+func makeDescribeWorkspaceHandler(ws *workspace.Workspace, root string) Handler {
+	// args is intentionally ignored — this tool takes no parameters.
+	return func(ctx context.Context, args json.RawMessage) ToolResult {
+		if ws == nil || root == "" {
+			return unavailable("describe_workspace")
+		}
+		slog.Info("describing workspace",
+			"component", "tool",
+			"operation", "describe_workspace",
+			"root", root,
+		)
+
+		info, err := os.Stat(root)
+		if err != nil {
+			return ToolResult{Success: false, Error: fmt.Sprintf("workspace unavailable: %v", err)}
+		}
+		if !info.IsDir() {
+			return ToolResult{Success: false, Error: "workspace root is not a directory: " + root}
+		}
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "Workspace: %s\n", root)
+
+		b.WriteString("\nCore files:\n")
+		for _, name := range []string{"AGENT.md", "SOUL.md", "HEARTBEAT.md"} {
+			fi, err := os.Stat(filepath.Join(root, name))
+			if err != nil {
+				fmt.Fprintf(&b, "  %s (missing)\n", name)
+				continue
+			}
+			fmt.Fprintf(&b, "  %s (%d bytes)\n", name, fi.Size())
+		}
+
+		var skills []string
+		for _, s := range ws.Skills {
+			skills = append(skills, s.Name)
+		}
+		if len(skills) == 0 {
+			b.WriteString("\nSkills: none\n")
+		} else {
+			fmt.Fprintf(&b, "\nSkills (%d): %s\n", len(skills), strings.Join(skills, ", "))
+		}
+
+		files, latest := memoryFiles(filepath.Join(root, "memory"))
+		if files == 0 {
+			b.WriteString("\nMemory: none\n")
+		} else {
+			fmt.Fprintf(&b, "\nMemory: %d files, latest %s\n", files, latest)
+		}
+
+		b.WriteString("\nTree:\n")
+		if err := describeDir(ctx, &b, root, root, 0); err != nil {
+			return ToolResult{Success: false, Error: fmt.Sprintf("describe workspace: %v", err)}
+		}
+
+		return ToolResult{Success: true, Output: strings.TrimRight(b.String(), "\n")}
+	}
+}
+
+// describeDir writes an indented listing of dir to b, descending up to describeMaxDepth.
+// Memory is summarized separately, so its subtree is not expanded.
+func describeDir(ctx context.Context, b *strings.Builder, root, dir string, depth int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	entries, err := osReadDir(dir)
+	if err != nil {
+		return err
+	}
+	indent := strings.Repeat("  ", depth+1)
+	for i, entry := range entries {
+		if i == describeMaxEntries {
+			fmt.Fprintf(b, "%s... (%d more)\n", indent, len(entries)-i)
+			break
+		}
+		path := filepath.Join(dir, entry.Name())
+		if platform.ValidatePath(root, path) != nil {
+			fmt.Fprintf(b, "%s%s (outside workspace, skipped)\n", indent, entry.Name())
+			continue
+		}
+		fi, err := os.Stat(path)
+		if err != nil {
+			fmt.Fprintf(b, "%s%s (unreadable)\n", indent, entry.Name())
+			continue
+		}
+		if !fi.IsDir() {
+			fmt.Fprintf(b, "%s%s (%d bytes)\n", indent, entry.Name(), fi.Size())
+			continue
+		}
+		children, _ := osReadDir(path)
+		fmt.Fprintf(b, "%s%s/ (%d entries)\n", indent, entry.Name(), len(children))
+		if depth+1 < describeMaxDepth && !(depth == 0 && entry.Name() == "memory") {
+			if err := describeDir(ctx, b, root, path, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// memoryFiles counts the hourly memory files under dir and returns the path,
+// relative to dir, of the one covering the most recent hour. Backups and
+// other files are ignored.
+func memoryFiles(dir string) (int, string) {
+	count := 0
+	latest := ""
+	var latestHour time.Time
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		hour, ok := memory.FileHour(dir, path)
+		if !ok {
+			return nil
+		}
+		count++
+		if rel, err := filepath.Rel(dir, path); err == nil && !hour.Before(latestHour) {
+			latest, latestHour = rel, hour
+		}
+		return nil
+	})
+	return count, latest
+}
@@ -0,0 +1,131 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/edouard/pureclaw/internal/workspace"
+)
+
+// populatedWorkspace builds a workspace under a parent directory that also holds
+// config.json and vault.enc, mirroring the default layout.
+func populatedWorkspace(t *testing.T) (string, *workspace.Workspace) {
+	t.Helper()
+	parent := t.TempDir()
+	root := filepath.Join(parent, "workspace")
+	files := map[string]string{
+		"AGENT.md":                "agent instructions",
+		"SOUL.md":                 "soul",
+		"skills/weather/SKILL.md": "weather skill",
+		"memory/2026/03/15/14.md": "---\n**2026-03-15 14:00** — owner\nhi\n\n",
+		"memory/2026/03/16/09.md": "---\n**2026-03-16 09:00** — owner\nhello\n\n",
+		"notes/todo.txt":          "buy milk",
+		"../config.json":          `{"workspace":"./workspace"}`,
+		"../vault.enc":            `{"salt":"x"}`,
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(filepath.Join(parent, "vault.enc"), filepath.Join(root, "vault-link")); err != nil {
+		t.Fatal(err)
+	}
+	ws := &workspace.Workspace{
+		Root:   root,
+		Skills: []workspace.Skill{{Name: "weather", Content: "weather skill"}},
+	}
+	return root, ws
+}
+
+func TestDescribeWorkspace_Summary(t *testing.T) {
+	root, ws := populatedWorkspace(t)
+	def := NewDescribeWorkspace(ws, root)
+
+	result := def.Handler(context.Background(), json.RawMessage(`{}`))
+	if !result.Success {
+		t.Fatalf("expected success, got error %q", result.Error)
+	}
+
+	for _, want := range []string{
+		"AGENT.md (18 bytes)",
+		"SOUL.md (4 bytes)",
+		"HEARTBEAT.md (missing)",
+		"Skills (1): weather",
+		"Memory: 2 files, latest " + filepath.Join("2026", "03", "16", "09.md"),
+		"notes/ (1 entries)",
+		"todo.txt (8 bytes)",
+		"skills/ (1 entries)",
+		"vault-link (outside workspace, skipped)",
+	} {
+		if !strings.Contains(result.Output, want) {
+			t.Errorf("summary missing %q:\n%s", want, result.Output)
+		}
+	}
+	for _, unwanted := range []string{"config.json", "vault.enc", "agent instructions", "buy milk"} {
+		if strings.Contains(result.Output, unwanted) {
+			t.Errorf("summary should not contain %q:\n%s", unwanted, result.Output)
+		}
+	}
+}
+
+func TestMemoryFiles_LatestByHour(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"2026/03/15/14.md",
+		"2026/03/16/09.md",
+		"42/2026/03/14/10.md",       // per-chat, sorts after the flat files by name
+		"2026/03/16/09.md.bak",      // Compact backup
+		"archive/2026-03-20.md",     // not an hourly file
+		"jsonl/2026/03/17/08.jsonl", // structured copy
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	count, latest := memoryFiles(dir)
+	if count != 3 {
+		t.Errorf("count = %d, want the 3 hourly files", count)
+	}
+	if want := filepath.Join("2026", "03", "16", "09.md"); latest != want {
+		t.Errorf("latest = %q, want %q", latest, want)
+	}
+}
+
+func TestDescribeWorkspace_MissingRoot(t *testing.T) {
+	def := NewDescribeWorkspace(&workspace.Workspace{}, filepath.Join(t.TempDir(), "absent"))
+	result := def.Handler(context.Background(), json.RawMessage(`{}`))
+	if result.Success {
+		t.Fatal("expected failure for missing workspace root")
+	}
+	if !strings.Contains(result.Error, "workspace unavailable") {
+		t.Errorf("Error = %q, want 'workspace unavailable'", result.Error)
+	}
+}
+
+func TestDescribeWorkspace_Empty(t *testing.T) {
+	root := t.TempDir()
+	def := NewDescribeWorkspace(&workspace.Workspace{Root: root}, root)
+	result := def.Handler(context.Background(), json.RawMessage(`{}`))
+	if !result.Success {
+		t.Fatalf("expected success, got error %q", result.Error)
+	}
+	for _, want := range []string{"Skills: none", "Memory: none", "AGENT.md (missing)"} {
+		if !strings.Contains(result.Output, want) {
+			t.Errorf("summary missing %q:\n%s", want, result.Output)
+		}
+	}
+}
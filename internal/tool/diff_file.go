@@ -0,0 +1,259 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path/filepath"
+	"strings"
+
+	"github.com/edouard/pureclaw/internal/platform"
+)
+
+const (
+	// diffContextLines is the number of unchanged lines shown around each change.
+	diffContextLines = 3
+	// maxDiffCells bounds the line-comparison table built for a diff, once the
+	// common head and tail are stripped, so huge rewrites fail fast instead of
+	// exhausting memory.
+	maxDiffCells = 4_000_000
+)
+
+type diffFileArgs struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
+
+// NewDiffFile returns the definition for the diff_file tool, which shows the
+// unified diff between a workspace file and proposed new content without
+// writing anything. Paths are relative to root and may not leave it.
+func NewDiffFile(root string) Definition {
+	return Definition{
+		Name:        "diff_file",
+		Description: "Show a unified diff between a workspace file and proposed new content, without writing it. Use before write_file to review a change. A missing file diffs as all additions",
+		Parameters: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"path": map[string]any{
+					"type":        "string",
+					"description": "Workspace-relative path of the file",
+				},
+				"content": map[string]any{
+					"type":        "string",
+					"description": "Proposed new content of the file",
+				},
+			},
+			"required": []string{"path", "content"},
+		},
+		Handler: makeDiffFileHandler(root),
+	}
+}
+
+func makeDiffFileHandler(root string) Handler {
+	return func(ctx context.Context, args json.RawMessage) ToolResult {
+		if root == "" {
+			return unavailable("diff_file")
+		}
+		var a diffFileArgs
+		if err := json.Unmarshal(args, &a); err != nil {
+			return ToolResult{Success: false, Error: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+		if a.Path == "" {
+			return ToolResult{Success: false, Error: "invalid arguments: path is required"}
+		}
+
+		path := filepath.Join(root, a.Path)
+		if err := platform.ValidatePath(root, path); err != nil {
+			slog.Warn("diff path rejected",
+				"component", "tool",
+				"operation", "diff_file",
+				"path", a.Path,
+				"error", err,
+			)
+			return ToolResult{Success: false, Error: fmt.Sprintf("path %q: %v", a.Path, err)}
+		}
+
+		old, err := osReadFile(path)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return ToolResult{Success: false, Error: err.Error()}
+		}
+		name := filepath.ToSlash(filepath.Clean(a.Path))
+		diff, err := unifiedDiff(name, string(old), a.Content, err == nil)
+		if err != nil {
+			return ToolResult{Success: false, Error: err.Error()}
+		}
+		if diff == "" {
+			return ToolResult{Success: true, Output: "no changes: " + name + " already has this content"}
+		}
+		return ToolResult{Success: true, Output: diff}
+	}
+}
+
+// diffOp is one line of an edit script: ' ' kept, '-' removed, '+' added.
+type diffOp struct {
+	kind byte
+	text string
+}
+
+// unifiedDiff returns the unified diff turning old into new, or "" when they
+// are equal. A file that doesn't exist yet is shown against /dev/null.
+func unifiedDiff(name, old, new string, exists bool) (string, error) {
+	if exists && old == new {
+		return "", nil
+	}
+	ops, err := diffLines(splitLines(old), splitLines(new))
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	if exists {
+		fmt.Fprintf(&b, "--- a/%s\n", name)
+	} else {
+		b.WriteString("--- /dev/null\n")
+	}
+	fmt.Fprintf(&b, "+++ b/%s\n", name)
+	writeHunks(&b, ops)
+	return b.String(), nil
+}
+
+// splitLines splits s into lines, keeping a final line without a newline.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines computes a shortest edit script from a to b: the common head and
+// tail are kept as is and the middle is aligned on its longest common subsequence.
+func diffLines(a, b []string) ([]diffOp, error) {
+	head := 0
+	for head < len(a) && head < len(b) && a[head] == b[head] {
+		head++
+	}
+	tail := 0
+	for tail < len(a)-head && tail < len(b)-head && a[len(a)-1-tail] == b[len(b)-1-tail] {
+		tail++
+	}
+	ma, mb := a[head:len(a)-tail], b[head:len(b)-tail]
+	if (len(ma)+1)*(len(mb)+1) > maxDiffCells {
+		return nil, fmt.Errorf("change too large to diff: %d lines replaced by %d", len(ma), len(mb))
+	}
+
+	var ops []diffOp
+	for _, l := range a[:head] {
+		ops = append(ops, diffOp{' ', l})
+	}
+
+	// lcs[i][j] is the LCS length of ma[i:] and mb[j:].
+	cols := len(mb) + 1
+	lcs := make([]int32, (len(ma)+1)*cols)
+	for i := len(ma) - 1; i >= 0; i-- {
+		for j := len(mb) - 1; j >= 0; j-- {
+			if ma[i] == mb[j] {
+				lcs[i*cols+j] = lcs[(i+1)*cols+j+1] + 1
+			} else {
+				lcs[i*cols+j] = max(lcs[(i+1)*cols+j], lcs[i*cols+j+1])
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(ma) && j < len(mb) {
+		switch {
+		case ma[i] == mb[j]:
+			ops = append(ops, diffOp{' ', ma[i]})
+			i++
+			j++
+		case lcs[(i+1)*cols+j] >= lcs[i*cols+j+1]:
+			ops = append(ops, diffOp{'-', ma[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', mb[j]})
+			j++
+		}
+	}
+	for ; i < len(ma); i++ {
+		ops = append(ops, diffOp{'-', ma[i]})
+	}
+	for ; j < len(mb); j++ {
+		ops = append(ops, diffOp{'+', mb[j]})
+	}
+
+	for _, l := range a[len(a)-tail:] {
+		ops = append(ops, diffOp{' ', l})
+	}
+	return ops, nil
+}
+
+// writeHunks writes ops as unified diff hunks with diffContextLines of context.
+func writeHunks(b *strings.Builder, ops []diffOp) {
+	for start := 0; start < len(ops); {
+		// Find the next change; stop when only context remains.
+		first := start
+		for first < len(ops) && ops[first].kind == ' ' {
+			first++
+		}
+		if first == len(ops) {
+			return
+		}
+		// Extend the hunk until a run of unchanged lines long enough to split it.
+		from := max(first-diffContextLines, start)
+		end := first
+		for k := first; k < len(ops); k++ {
+			if ops[k].kind != ' ' {
+				end = k + 1
+			} else if k-end >= 2*diffContextLines {
+				break
+			}
+		}
+		to := min(end+diffContextLines, len(ops))
+
+		oldStart, newStart := 1, 1
+		for _, op := range ops[:from] {
+			if op.kind != '+' {
+				oldStart++
+			}
+			if op.kind != '-' {
+				newStart++
+			}
+		}
+		var oldLen, newLen int
+		for _, op := range ops[from:to] {
+			if op.kind != '+' {
+				oldLen++
+			}
+			if op.kind != '-' {
+				newLen++
+			}
+		}
+		fmt.Fprintf(b, "@@ -%s +%s @@\n", hunkRange(oldStart, oldLen), hunkRange(newStart, newLen))
+		for _, op := range ops[from:to] {
+			b.WriteByte(op.kind)
+			b.WriteString(op.text)
+			if !strings.HasSuffix(op.text, "\n") {
+				b.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		start = to
+	}
+}
+
+// hunkRange formats a hunk's line range as unified diff does: an empty range
+// starts at the line before it.
+func hunkRange(start, n int) string {
+	if n == 0 {
+		return fmt.Sprintf("%d,0", start-1)
+	}
+	if n == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, n)
+}
@@ -0,0 +1,106 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func diffFile(t *testing.T, root, path, content string) ToolResult {
+	t.Helper()
+	args, _ := json.Marshal(diffFileArgs{Path: path, Content: content})
+	return NewDiffFile(root).Handler(context.Background(), args)
+}
+
+func TestDiffFile_ExistingFile(t *testing.T) {
+	root := t.TempDir()
+	var lines []string
+	for i := 1; i <= 20; i++ {
+		lines = append(lines, fmt.Sprintf("line %d\n", i))
+	}
+	old := strings.Join(lines, "")
+	if err := os.WriteFile(filepath.Join(root, "notes.txt"), []byte(old), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	proposed := strings.Replace(old, "line 5\n", "line five\n", 1)
+	proposed = strings.Replace(proposed, "line 17\n", "", 1) + "line 21\n"
+	result := diffFile(t, root, "notes.txt", proposed)
+	if !result.Success {
+		t.Fatalf("diff failed: %s", result.Error)
+	}
+	want := `--- a/notes.txt
++++ b/notes.txt
+@@ -2,7 +2,7 @@
+ line 2
+ line 3
+ line 4
+-line 5
++line five
+ line 6
+ line 7
+ line 8
+@@ -14,7 +14,7 @@
+ line 14
+ line 15
+ line 16
+-line 17
+ line 18
+ line 19
+ line 20
++line 21
+`
+	if result.Output != want {
+		t.Errorf("diff =\n%s\nwant\n%s", result.Output, want)
+	}
+	data, _ := os.ReadFile(filepath.Join(root, "notes.txt"))
+	if string(data) != old {
+		t.Error("diff_file modified the file")
+	}
+}
+
+func TestDiffFile_NewFile(t *testing.T) {
+	result := diffFile(t, t.TempDir(), "drafts/plan.md", "# Plan\nship it")
+	if !result.Success {
+		t.Fatalf("diff failed: %s", result.Error)
+	}
+	want := "--- /dev/null\n+++ b/drafts/plan.md\n@@ -0,0 +1,2 @@\n+# Plan\n+ship it\n\\ No newline at end of file\n"
+	if result.Output != want {
+		t.Errorf("diff = %q, want %q", result.Output, want)
+	}
+}
+
+func TestDiffFile_NoChanges(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "a.md"), []byte("same\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	result := diffFile(t, root, "a.md", "same\n")
+	if !result.Success || !strings.Contains(result.Output, "no changes") {
+		t.Errorf("result = %+v, want a no-changes report", result)
+	}
+}
+
+func TestDiffFile_TraversalRejected(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "ws")
+	if err := os.Mkdir(root, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	result := diffFile(t, root, "../outside.txt", "x")
+	if result.Success {
+		t.Fatal("expected traversal path to be rejected")
+	}
+	if !strings.Contains(result.Error, "outside") {
+		t.Errorf("error = %q, want the path named", result.Error)
+	}
+}
+
+func TestDiffFile_Unavailable(t *testing.T) {
+	if result := diffFile(t, "", "a.md", "x"); result.Success {
+		t.Error("expected failure without a workspace root")
+	}
+}
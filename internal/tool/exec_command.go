@@ -5,10 +5,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"os"
 	"os/exec"
-	"sort"
+	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/edouard/pureclaw/internal/platform"
 )
 
 const (
@@ -16,40 +19,113 @@ const (
 	maxExecOutputSize  = 1 << 20 // 1 MB
 )
 
-// Replaceable for testing.
-var execCommandFn = func(ctx context.Context, command string) ([]byte, error) {
-	return exec.CommandContext(ctx, "sh", "-c", command).CombinedOutput()
+// execOptions controls the subprocess environment of a command.
+type execOptions struct {
+	Dir string   // working directory; empty = inherit the agent's
+	Env []string // environment as KEY=value pairs
 }
 
+// Replaceable for testing.
+var (
+	execCommandFn = func(ctx context.Context, command string, opts execOptions) ([]byte, error) {
+		cmd := exec.CommandContext(ctx, "sh", "-c", command)
+		cmd.Dir = opts.Dir
+		cmd.Env = opts.Env
+		return cmd.CombinedOutput()
+	}
+	osEnviron = os.Environ
+)
+
+// sensitiveEnvSuffixes mark environment variables stripped from commands,
+// in addition to PURECLAW_VAULT_PASSPHRASE and any variable holding a vault secret.
+var sensitiveEnvSuffixes = []string{"_PASSPHRASE", "_PASSWORD", "_SECRET", "_TOKEN", "_API_KEY"}
+
 type execCommandArgs struct {
 	Command string `json:"command"`
+	Cwd     string `json:"cwd"`
 }
 
-// sanitize replaces all secret values with [REDACTED] in the output string.
-// Secrets are sorted by length (longest first) to prevent partial redaction
-// when one secret is a substring of another.
-func sanitize(output string, secrets []string) string {
-	sorted := make([]string, 0, len(secrets))
+// commandEnv returns the agent's environment without the vault passphrase,
+// credential-looking variables, or variables whose value is a vault secret.
+func commandEnv(secrets []string) []string {
+	secretSet := make(map[string]bool, len(secrets))
 	for _, s := range secrets {
 		if s != "" {
-			sorted = append(sorted, s)
+			secretSet[s] = true
+		}
+	}
+	env := []string{} // non-nil: a nil Env would make exec inherit everything
+	for _, kv := range osEnviron() {
+		name, value, _ := strings.Cut(kv, "=")
+		if isSensitiveEnv(name) || secretSet[value] {
+			continue
+		}
+		env = append(env, kv)
+	}
+	return env
+}
+
+// isSensitiveEnv reports whether an environment variable name looks like a credential.
+func isSensitiveEnv(name string) bool {
+	upper := strings.ToUpper(name)
+	if upper == "PURECLAW_VAULT_PASSPHRASE" {
+		return true
+	}
+	for _, suffix := range sensitiveEnvSuffixes {
+		if strings.HasSuffix(upper, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// commandDir resolves the working directory for a command: the requested cwd
+// (relative paths are resolved against the workspace root) or the workspace root
+// itself. The directory must stay within the workspace.
+func commandDir(ctx context.Context, cwd string) (string, error) {
+	tc, _ := FromContext(ctx)
+	root := tc.WorkspaceRoot
+	if root == "" {
+		if cwd != "" {
+			return "", fmt.Errorf("cwd is not supported without a workspace")
 		}
+		return "", nil
+	}
+	if cwd == "" {
+		return root, nil
+	}
+	dir := cwd
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(root, dir)
+	}
+	if err := platform.ValidatePath(root, dir); err != nil {
+		return "", fmt.Errorf("cwd %q: %w", cwd, err)
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return "", fmt.Errorf("cwd %q: %w", cwd, err)
 	}
-	sort.Slice(sorted, func(i, j int) bool {
-		return len(sorted[i]) > len(sorted[j])
-	})
-	for _, s := range sorted {
-		output = strings.ReplaceAll(output, s, "[REDACTED]")
+	if !info.IsDir() {
+		return "", fmt.Errorf("cwd %q is not a directory", cwd)
 	}
-	return output
+	return dir, nil
 }
 
 // NewExecCommand creates an exec_command tool that sanitizes secrets from output.
 // secrets is a list of vault secret values to redact from command output.
 func NewExecCommand(secrets []string) Definition {
+	return NewExecCommandWithTimeout(secrets, defaultExecTimeout)
+}
+
+// NewExecCommandWithTimeout creates an exec_command tool whose commands are killed
+// after timeout. A non-positive timeout uses the 30s default.
+func NewExecCommandWithTimeout(secrets []string, timeout time.Duration) Definition {
+	if timeout <= 0 {
+		timeout = defaultExecTimeout
+	}
 	return Definition{
 		Name:        "exec_command",
-		Description: "Execute a shell command on the host system. Returns stdout/stderr with secrets redacted.",
+		Description: "Execute a shell command on the host system, in the workspace directory by default. Returns stdout/stderr with secrets redacted.",
 		Parameters: map[string]any{
 			"type": "object",
 			"properties": map[string]any{
@@ -57,14 +133,19 @@ func NewExecCommand(secrets []string) Definition {
 					"type":        "string",
 					"description": "The command to execute (passed to sh -c)",
 				},
+				"cwd": map[string]any{
+					"type":        "string",
+					"description": "Working directory, relative to the workspace root (default: workspace root). Must stay within the workspace",
+				},
 			},
 			"required": []string{"command"},
 		},
-		Handler: makeExecHandler(secrets),
+		Handler: makeExecHandler(secrets, timeout),
 	}
 }
 
-func makeExecHandler(secrets []string) Handler {
+func makeExecHandler(secrets []string, timeout time.Duration) Handler {
+	redactor := platform.SecretRedactor(secrets)
 	return func(ctx context.Context, args json.RawMessage) ToolResult {
 		var a execCommandArgs
 		if err := json.Unmarshal(args, &a); err != nil {
@@ -84,15 +165,27 @@ func makeExecHandler(secrets []string) Handler {
 			return ToolResult{Success: false, Error: "command is required"}
 		}
 
+		dir, err := commandDir(ctx, a.Cwd)
+		if err != nil {
+			slog.Warn("invalid cwd",
+				"component", "tool",
+				"operation", "exec_command",
+				"cwd", a.Cwd,
+				"error", err,
+			)
+			return ToolResult{Success: false, Error: err.Error()}
+		}
+
 		slog.Info("executing command",
 			"component", "tool",
 			"operation", "exec_command",
+			"dir", dir,
 		)
 
-		childCtx, cancel := context.WithTimeout(ctx, defaultExecTimeout)
+		childCtx, cancel := context.WithTimeout(ctx, timeout)
 		defer cancel()
 
-		output, err := execCommandFn(childCtx, a.Command)
+		output, err := execCommandFn(childCtx, a.Command, execOptions{Dir: dir, Env: commandEnv(secrets)})
 
 		// Truncate output if too large.
 		out := string(output)
@@ -106,8 +199,9 @@ func makeExecHandler(secrets []string) Handler {
 				slog.Warn("command timed out",
 					"component", "tool",
 					"operation", "exec_command",
+					"timeout", timeout,
 				)
-				return ToolResult{Success: false, Error: "command timed out after 30s"}
+				return ToolResult{Success: false, Error: fmt.Sprintf("command timed out after %s", timeout)}
 			}
 
 			slog.Warn("command failed",
@@ -117,11 +211,11 @@ func makeExecHandler(secrets []string) Handler {
 			)
 			return ToolResult{
 				Success: false,
-				Output:  sanitize(out, secrets),
-				Error:   sanitize(err.Error(), secrets),
+				Output:  redactor.Redact(out),
+				Error:   redactor.Redact(err.Error()),
 			}
 		}
 
-		return ToolResult{Success: true, Output: sanitize(out, secrets)}
+		return ToolResult{Success: true, Output: redactor.Redact(out)}
 	}
 }
@@ -4,7 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -12,7 +14,7 @@ import (
 
 func TestExecCommand_Success(t *testing.T) {
 	original := execCommandFn
-	execCommandFn = func(ctx context.Context, command string) ([]byte, error) {
+	execCommandFn = func(ctx context.Context, command string, opts execOptions) ([]byte, error) {
 		return []byte("hello\n"), nil
 	}
 	defer func() { execCommandFn = original }()
@@ -31,7 +33,7 @@ func TestExecCommand_Success(t *testing.T) {
 
 func TestExecCommand_SecretSanitization(t *testing.T) {
 	original := execCommandFn
-	execCommandFn = func(ctx context.Context, command string) ([]byte, error) {
+	execCommandFn = func(ctx context.Context, command string, opts execOptions) ([]byte, error) {
 		return []byte("token: sk-abc123-secret\n"), nil
 	}
 	defer func() { execCommandFn = original }()
@@ -54,7 +56,7 @@ func TestExecCommand_SecretSanitization(t *testing.T) {
 
 func TestExecCommand_MultipleSecrets(t *testing.T) {
 	original := execCommandFn
-	execCommandFn = func(ctx context.Context, command string) ([]byte, error) {
+	execCommandFn = func(ctx context.Context, command string, opts execOptions) ([]byte, error) {
 		return []byte("key1=secret1 key2=secret2\n"), nil
 	}
 	defer func() { execCommandFn = original }()
@@ -77,7 +79,7 @@ func TestExecCommand_MultipleSecrets(t *testing.T) {
 
 func TestExecCommand_SecretInError(t *testing.T) {
 	original := execCommandFn
-	execCommandFn = func(ctx context.Context, command string) ([]byte, error) {
+	execCommandFn = func(ctx context.Context, command string, opts execOptions) ([]byte, error) {
 		return []byte("partial output with mysecret"), errors.New("failed: mysecret leaked")
 	}
 	defer func() { execCommandFn = original }()
@@ -103,7 +105,7 @@ func TestExecCommand_SecretInError(t *testing.T) {
 
 func TestExecCommand_NonZeroExit(t *testing.T) {
 	original := execCommandFn
-	execCommandFn = func(ctx context.Context, command string) ([]byte, error) {
+	execCommandFn = func(ctx context.Context, command string, opts execOptions) ([]byte, error) {
 		return []byte("some output\n"), &exec.ExitError{}
 	}
 	defer func() { execCommandFn = original }()
@@ -125,7 +127,7 @@ func TestExecCommand_NonZeroExit(t *testing.T) {
 
 func TestExecCommand_NonZeroExitWithSecrets(t *testing.T) {
 	original := execCommandFn
-	execCommandFn = func(ctx context.Context, command string) ([]byte, error) {
+	execCommandFn = func(ctx context.Context, command string, opts execOptions) ([]byte, error) {
 		return []byte("output with mysecret\n"), &exec.ExitError{}
 	}
 	defer func() { execCommandFn = original }()
@@ -148,7 +150,7 @@ func TestExecCommand_NonZeroExitWithSecrets(t *testing.T) {
 
 func TestExecCommand_Timeout(t *testing.T) {
 	original := execCommandFn
-	execCommandFn = func(ctx context.Context, command string) ([]byte, error) {
+	execCommandFn = func(ctx context.Context, command string, opts execOptions) ([]byte, error) {
 		<-ctx.Done()
 		return nil, ctx.Err()
 	}
@@ -198,7 +200,7 @@ func TestExecCommand_InvalidArgs(t *testing.T) {
 
 func TestExecCommand_EmptySecrets(t *testing.T) {
 	original := execCommandFn
-	execCommandFn = func(ctx context.Context, command string) ([]byte, error) {
+	execCommandFn = func(ctx context.Context, command string, opts execOptions) ([]byte, error) {
 		return []byte("output with no secrets\n"), nil
 	}
 	defer func() { execCommandFn = original }()
@@ -218,7 +220,7 @@ func TestExecCommand_EmptySecrets(t *testing.T) {
 func TestExecCommand_OutputTruncation(t *testing.T) {
 	original := execCommandFn
 	bigOutput := strings.Repeat("x", maxExecOutputSize+100)
-	execCommandFn = func(ctx context.Context, command string) ([]byte, error) {
+	execCommandFn = func(ctx context.Context, command string, opts execOptions) ([]byte, error) {
 		return []byte(bigOutput), nil
 	}
 	defer func() { execCommandFn = original }()
@@ -257,71 +259,123 @@ func TestExecCommand_Definition(t *testing.T) {
 	}
 }
 
-func TestSanitize(t *testing.T) {
-	tests := []struct {
-		name    string
-		input   string
-		secrets []string
-		want    string
-	}{
-		{
-			name:    "single secret",
-			input:   "the password is hunter2",
-			secrets: []string{"hunter2"},
-			want:    "the password is [REDACTED]",
-		},
-		{
-			name:    "multiple occurrences",
-			input:   "abc123 and abc123 again",
-			secrets: []string{"abc123"},
-			want:    "[REDACTED] and [REDACTED] again",
-		},
-		{
-			name:    "multiple secrets",
-			input:   "key=aaa val=bbb",
-			secrets: []string{"aaa", "bbb"},
-			want:    "key=[REDACTED] val=[REDACTED]",
-		},
-		{
-			name:    "no match",
-			input:   "nothing to redact",
-			secrets: []string{"xyz"},
-			want:    "nothing to redact",
-		},
-		{
-			name:    "empty string in secrets skipped",
-			input:   "output text",
-			secrets: []string{"", "text"},
-			want:    "output [REDACTED]",
-		},
-		{
-			name:    "substring secret replaced longest first",
-			input:   "token is abcdef123",
-			secrets: []string{"abc", "abcdef123"},
-			want:    "token is [REDACTED]",
-		},
-	}
-
-	for _, tc := range tests {
-		t.Run(tc.name, func(t *testing.T) {
-			got := sanitize(tc.input, tc.secrets)
-			if got != tc.want {
-				t.Errorf("sanitize(%q, %v) = %q, want %q", tc.input, tc.secrets, got, tc.want)
-			}
-		})
+func TestExecCommand_RunsInWorkspaceDir(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "scripts"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	wantRoot, _ := filepath.EvalSymlinks(root)
+	ctx := WithContext(context.Background(), ToolContext{WorkspaceRoot: root})
+	def := NewExecCommand(nil)
+
+	result := def.Handler(ctx, json.RawMessage(`{"command":"pwd -P"}`))
+	if !result.Success {
+		t.Fatalf("expected success, got error %q", result.Error)
+	}
+	if got := strings.TrimSpace(result.Output); got != wantRoot {
+		t.Errorf("default cwd = %q, want workspace root %q", got, wantRoot)
+	}
+
+	result = def.Handler(ctx, json.RawMessage(`{"command":"pwd -P","cwd":"scripts"}`))
+	if !result.Success {
+		t.Fatalf("expected success, got error %q", result.Error)
+	}
+	if got := strings.TrimSpace(result.Output); got != filepath.Join(wantRoot, "scripts") {
+		t.Errorf("cwd = %q, want %q", got, filepath.Join(wantRoot, "scripts"))
+	}
+}
+
+func TestExecCommand_RejectsCwdOutsideWorkspace(t *testing.T) {
+	original := execCommandFn
+	called := false
+	execCommandFn = func(ctx context.Context, command string, opts execOptions) ([]byte, error) {
+		called = true
+		return nil, nil
+	}
+	defer func() { execCommandFn = original }()
+
+	root := t.TempDir()
+	ctx := WithContext(context.Background(), ToolContext{WorkspaceRoot: root})
+	def := NewExecCommand(nil)
+
+	for _, cwd := range []string{"..", "/etc", "missing-dir"} {
+		args, _ := json.Marshal(execCommandArgs{Command: "ls", Cwd: cwd})
+		result := def.Handler(ctx, args)
+		if result.Success {
+			t.Errorf("cwd %q: expected rejection", cwd)
+		}
+		if !strings.Contains(result.Error, "cwd") {
+			t.Errorf("cwd %q: error = %q, want cwd error", cwd, result.Error)
+		}
+	}
+	if called {
+		t.Error("command should not run with an invalid cwd")
 	}
 }
 
-func TestSanitize_EmptySecrets(t *testing.T) {
-	got := sanitize("hello world", nil)
-	if got != "hello world" {
-		t.Errorf("expected unchanged output, got %q", got)
+func TestExecCommand_CwdWithoutWorkspace(t *testing.T) {
+	args, _ := json.Marshal(execCommandArgs{Command: "ls", Cwd: "sub"})
+	result := NewExecCommand(nil).Handler(context.Background(), args)
+	if result.Success || !strings.Contains(result.Error, "without a workspace") {
+		t.Errorf("expected cwd rejection without workspace, got %+v", result)
 	}
 }
 
-func TestSanitize_EmptyOutput(t *testing.T) {
-	got := sanitize("", []string{"secret"})
-	if got != "" {
-		t.Errorf("expected empty string, got %q", got)
+func TestExecCommand_StripsSecretEnv(t *testing.T) {
+	t.Setenv("PURECLAW_VAULT_PASSPHRASE", "hunter2")
+	t.Setenv("MISTRAL_API_KEY", "mk-123")
+	t.Setenv("BOT_TOKEN", "tok-456")
+	t.Setenv("INNOCENT_NAME", "vault-secret-value")
+	t.Setenv("HARMLESS_VAR", "visible")
+
+	ctx := WithContext(context.Background(), ToolContext{WorkspaceRoot: t.TempDir()})
+	def := NewExecCommand([]string{"vault-secret-value"})
+
+	result := def.Handler(ctx, json.RawMessage(`{"command":"env"}`))
+	if !result.Success {
+		t.Fatalf("expected success, got error %q", result.Error)
+	}
+	for _, name := range []string{"PURECLAW_VAULT_PASSPHRASE", "MISTRAL_API_KEY", "BOT_TOKEN", "INNOCENT_NAME"} {
+		if strings.Contains(result.Output, name+"=") {
+			t.Errorf("subprocess environment exposes %s", name)
+		}
+	}
+	if !strings.Contains(result.Output, "HARMLESS_VAR=visible") {
+		t.Error("non-sensitive variables should be kept")
+	}
+}
+
+func TestIsSensitiveEnv(t *testing.T) {
+	for name, want := range map[string]bool{
+		"PURECLAW_VAULT_PASSPHRASE": true,
+		"github_token":              true,
+		"OPENAI_API_KEY":            true,
+		"DB_PASSWORD":               true,
+		"AWS_SECRET":                true,
+		"PATH":                      false,
+		"HOME":                      false,
+		"TOKENIZER_MODE":            false,
+	} {
+		if got := isSensitiveEnv(name); got != want {
+			t.Errorf("isSensitiveEnv(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestExecCommand_ConfiguredTimeout(t *testing.T) {
+	original := execCommandFn
+	execCommandFn = func(ctx context.Context, command string, opts execOptions) ([]byte, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	defer func() { execCommandFn = original }()
+
+	def := NewExecCommandWithTimeout(nil, 20*time.Millisecond)
+	result := def.Handler(context.Background(), json.RawMessage(`{"command":"sleep 10"}`))
+	if result.Success {
+		t.Fatal("expected timeout failure")
+	}
+	if result.Error != "command timed out after 20ms" {
+		t.Errorf("Error = %q, want configured timeout in message", result.Error)
 	}
 }
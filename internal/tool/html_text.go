@@ -0,0 +1,91 @@
+package tool
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+// htmlDropTags hold no readable text: their whole element is removed.
+var htmlDropTags = []string{"head", "script", "style", "noscript", "template", "svg", "nav", "header", "footer", "aside", "form"}
+
+var (
+	htmlDropRes     = dropElementRegexps(htmlDropTags)
+	htmlCommentRe   = regexp.MustCompile(`(?s)<!--.*?-->`)
+	htmlTitleRe     = regexp.MustCompile(`(?is)<title\b[^>]*>(.*?)</title\s*>`)
+	htmlArticleRe   = regexp.MustCompile(`(?is)<article\b[^>]*>(.*)</article\s*>`)
+	htmlMainRe      = regexp.MustCompile(`(?is)<main\b[^>]*>(.*)</main\s*>`)
+	htmlHeadingRe   = regexp.MustCompile(`(?is)<h([1-6])\b[^>]*>(.*?)</h[1-6]\s*>`)
+	htmlLinkRe      = regexp.MustCompile(`(?is)<a\b[^>]*?\bhref\s*=\s*["']([^"']*)["'][^>]*>(.*?)</a\s*>`)
+	htmlListItemRe  = regexp.MustCompile(`(?i)<li\b[^>]*>`)
+	htmlBlockRe     = regexp.MustCompile(`(?i)</?(p|div|br|hr|tr|section|article|main|ul|ol|table|blockquote|pre|dl|dt|dd|figure|figcaption)\b[^>]*>`)
+	htmlTagRe       = regexp.MustCompile(`(?s)<[^>]*>`)
+	htmlSpaceRe     = regexp.MustCompile(`[ \t\r\f\v\x{a0}]+`)
+	htmlBlankRunsRe = regexp.MustCompile(`\n{3,}`)
+	htmlBareItemRe  = regexp.MustCompile(`(?m)^-\n+`) // "- " whose text starts on a later line
+)
+
+func dropElementRegexps(tags []string) []*regexp.Regexp {
+	res := make([]*regexp.Regexp, len(tags))
+	for i, tag := range tags {
+		res[i] = regexp.MustCompile(`(?is)<` + tag + `\b.*?</` + tag + `\s*>`)
+	}
+	return res
+}
+
+// htmlTitle returns the text of the page's <title>, or "".
+func htmlTitle(page string) string {
+	m := htmlTitleRe.FindStringSubmatch(page)
+	if m == nil {
+		return ""
+	}
+	return strings.TrimSpace(htmlSpaceRe.ReplaceAllString(html.UnescapeString(htmlTagRe.ReplaceAllString(m[1], "")), " "))
+}
+
+// htmlToMarkdown extracts the readable text of an HTML page as lightweight
+// markdown: headings become "#" lines, list items "- " lines and absolute
+// links "[text](url)". Scripts, styles, navigation and other chrome are
+// dropped, and when the page has an <article> (or else <main>) element only
+// its content is kept.
+func htmlToMarkdown(page string) string {
+	page = htmlCommentRe.ReplaceAllString(page, "")
+	for _, re := range htmlDropRes {
+		page = re.ReplaceAllString(page, "")
+	}
+	if m := htmlArticleRe.FindStringSubmatch(page); m != nil {
+		page = m[1]
+	} else if m := htmlMainRe.FindStringSubmatch(page); m != nil {
+		page = m[1]
+	}
+
+	page = htmlHeadingRe.ReplaceAllStringFunc(page, func(s string) string {
+		m := htmlHeadingRe.FindStringSubmatch(s)
+		return "\n\n" + strings.Repeat("#", int(m[1][0]-'0')) + " " + inlineText(m[2]) + "\n\n"
+	})
+	page = htmlLinkRe.ReplaceAllStringFunc(page, func(s string) string {
+		m := htmlLinkRe.FindStringSubmatch(s)
+		text := inlineText(m[2])
+		href := html.UnescapeString(m[1])
+		if text == "" || !(strings.HasPrefix(href, "http://") || strings.HasPrefix(href, "https://")) {
+			return text
+		}
+		return "[" + text + "](" + href + ")"
+	})
+	page = htmlListItemRe.ReplaceAllString(page, "\n- ")
+	page = htmlBlockRe.ReplaceAllString(page, "\n")
+	page = html.UnescapeString(htmlTagRe.ReplaceAllString(page, ""))
+
+	lines := strings.Split(page, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(htmlSpaceRe.ReplaceAllString(line, " "))
+	}
+	text := htmlBareItemRe.ReplaceAllString(strings.Join(lines, "\n"), "- ")
+	text = htmlBlankRunsRe.ReplaceAllString(text, "\n\n")
+	return strings.TrimSpace(text)
+}
+
+// inlineText flattens an HTML fragment to one line of text.
+func inlineText(fragment string) string {
+	text := html.UnescapeString(htmlTagRe.ReplaceAllString(fragment, " "))
+	return strings.TrimSpace(strings.Join(strings.Fields(text), " "))
+}
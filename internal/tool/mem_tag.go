@@ -0,0 +1,91 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/edouard/pureclaw/internal/memory"
+	"github.com/edouard/pureclaw/internal/platform"
+)
+
+// TaggedMemoryWriter writes memory entries labelled with tags.
+type TaggedMemoryWriter interface {
+	WriteTagged(ctx context.Context, source, content string, tags []string) error
+}
+
+type memTagArgs struct {
+	Content string   `json:"content"`
+	Tags    []string `json:"tags"`
+}
+
+// NewMemTag returns the definition for the mem_tag tool, which records a tagged
+// note in memory so related entries can later be found by tag.
+func NewMemTag(mem TaggedMemoryWriter) Definition {
+	return Definition{
+		Name:        "mem_tag",
+		Description: "Record a note in memory labelled with tags (e.g. project-x, todo) so related entries can be retrieved by tag later",
+		Parameters: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"content": map[string]any{
+					"type":        "string",
+					"description": "The note to record",
+				},
+				"tags": map[string]any{
+					"type":        "array",
+					"items":       map[string]any{"type": "string"},
+					"description": "Tags for the note, with or without a leading '#'. Letters, digits, '-' and '_' only",
+				},
+			},
+			"required": []string{"content", "tags"},
+		},
+		Handler: makeMemTagHandler(mem),
+	}
+}
+
+func makeMemTagHandler(mem TaggedMemoryWriter) Handler {
+	return func(ctx context.Context, args json.RawMessage) ToolResult {
+		if mem == nil {
+			return unavailable("mem_tag")
+		}
+		var a memTagArgs
+		if err := json.Unmarshal(args, &a); err != nil {
+			slog.Warn("invalid arguments",
+				"component", "tool",
+				"operation", "mem_tag",
+				"error", err,
+			)
+			return ToolResult{Success: false, Error: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+		if strings.TrimSpace(a.Content) == "" {
+			return ToolResult{Success: false, Error: "invalid arguments: content is required"}
+		}
+		tags := memory.NormalizeTags(a.Tags)
+		if len(tags) == 0 {
+			return ToolResult{Success: false, Error: "invalid arguments: at least one valid tag is required"}
+		}
+
+		source := "agent"
+		if platform.OriginFrom(ctx) == platform.OriginHeartbeat {
+			source = "heartbeat"
+		}
+		if err := mem.WriteTagged(ctx, source, a.Content, tags); err != nil {
+			slog.Error("tagged memory write failed",
+				"component", "tool",
+				"operation", "mem_tag",
+				"error", err,
+			)
+			return ToolResult{Success: false, Error: fmt.Sprintf("memory write failed: %v", err)}
+		}
+
+		slog.Info("tagged memory entry written",
+			"component", "tool",
+			"operation", "mem_tag",
+			"tags", tags,
+		)
+		return ToolResult{Success: true, Output: "note recorded with tags: #" + strings.Join(tags, " #")}
+	}
+}
@@ -0,0 +1,88 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/edouard/pureclaw/internal/platform"
+)
+
+type fakeTaggedWriter struct {
+	source  string
+	content string
+	tags    []string
+	err     error
+}
+
+func (f *fakeTaggedWriter) WriteTagged(ctx context.Context, source, content string, tags []string) error {
+	f.source, f.content, f.tags = source, content, tags
+	return f.err
+}
+
+func TestMemTag_Success(t *testing.T) {
+	w := &fakeTaggedWriter{}
+	def := NewMemTag(w)
+
+	result := def.Handler(context.Background(), json.RawMessage(`{"content":"Deploy on Friday","tags":["#Project-X","deploy"]}`))
+	if !result.Success {
+		t.Fatalf("expected success, got error %q", result.Error)
+	}
+	if w.source != "agent" || w.content != "Deploy on Friday" {
+		t.Errorf("written (%q, %q), want (agent, Deploy on Friday)", w.source, w.content)
+	}
+	if len(w.tags) != 2 || w.tags[0] != "project-x" || w.tags[1] != "deploy" {
+		t.Errorf("tags = %v, want normalized [project-x deploy]", w.tags)
+	}
+	if !strings.Contains(result.Output, "#project-x") {
+		t.Errorf("Output = %q, want tag listing", result.Output)
+	}
+}
+
+func TestMemTag_InvalidArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		args string
+		want string
+	}{
+		{"bad json", `{invalid`, "invalid arguments"},
+		{"empty content", `{"content":"  ","tags":["a"]}`, "content is required"},
+		{"no tags", `{"content":"x","tags":[]}`, "valid tag is required"},
+		{"only invalid tags", `{"content":"x","tags":["has space"]}`, "valid tag is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &fakeTaggedWriter{}
+			result := NewMemTag(w).Handler(context.Background(), json.RawMessage(tt.args))
+			if result.Success {
+				t.Fatal("expected failure")
+			}
+			if !strings.Contains(result.Error, tt.want) {
+				t.Errorf("Error = %q, want %q", result.Error, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemTag_WriteError(t *testing.T) {
+	w := &fakeTaggedWriter{err: errors.New("disk full")}
+	result := NewMemTag(w).Handler(context.Background(), json.RawMessage(`{"content":"x","tags":["a"]}`))
+	if result.Success || !strings.Contains(result.Error, "disk full") {
+		t.Errorf("expected write error in result, got %+v", result)
+	}
+}
+
+func TestMemTag_HeartbeatSource(t *testing.T) {
+	w := &fakeTaggedWriter{}
+	ctx := platform.WithOrigin(context.Background(), platform.OriginHeartbeat)
+
+	result := NewMemTag(w).Handler(ctx, json.RawMessage(`{"content":"Disk at 91%","tags":["ops"]}`))
+	if !result.Success {
+		t.Fatalf("expected success, got error %q", result.Error)
+	}
+	if w.source != "heartbeat" {
+		t.Errorf("source = %q, want heartbeat", w.source)
+	}
+}
@@ -0,0 +1,78 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/edouard/pureclaw/internal/llm"
+)
+
+// LLMProber reports an LLM client's effective configuration and usage.
+type LLMProber interface {
+	Info() llm.Info
+}
+
+// NewProbeLLM returns the definition for the probe_llm tool, which reports the
+// model in use, the provider, the sampling parameters and the token usage
+// accumulated so far. Only calls made on behalf of an owner are allowed.
+func NewProbeLLM(p LLMProber) Definition {
+	return Definition{
+		Name:        "probe_llm",
+		Description: "Report the LLM configuration in effect (provider, model, response format, sampling parameters, latency) and the token usage accumulated since startup. Use it to diagnose your own behavior",
+		Parameters: map[string]any{
+			"type":       "object",
+			"properties": map[string]any{},
+		},
+		Handler: makeProbeLLMHandler(p),
+	}
+}
+
+func makeProbeLLMHandler(p LLMProber) Handler {
+	return func(ctx context.Context, _ json.RawMessage) ToolResult {
+		if p == nil {
+			return unavailable("probe_llm")
+		}
+		if tc, ok := FromContext(ctx); !ok || !tc.Owner {
+			slog.Warn("probe_llm access denied",
+				"component", "tool",
+				"operation", "probe_llm",
+			)
+			return ToolResult{Success: false, Error: "probe_llm can only be used while handling an owner's message"}
+		}
+		return ToolResult{Success: true, Output: formatLLMInfo(p.Info())}
+	}
+}
+
+// formatLLMInfo renders info as one "key: value" line per field.
+func formatLLMInfo(info llm.Info) string {
+	temperature, maxTokens := "provider default", "provider default"
+	if t := info.Sampling.Temperature; t != nil {
+		temperature = strconv.FormatFloat(*t, 'g', -1, 64)
+	}
+	if n := info.Sampling.MaxTokens; n != nil {
+		maxTokens = strconv.Itoa(*n)
+	}
+	latency := "unknown"
+	if info.Latency > 0 {
+		latency = info.Latency.String()
+	}
+	u := info.Usage
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "provider: %s\n", info.Provider)
+	fmt.Fprintf(&b, "base_url: %s\n", info.BaseURL)
+	fmt.Fprintf(&b, "model: %s\n", info.Model)
+	fmt.Fprintf(&b, "response_format: %s\n", info.ResponseFormat)
+	fmt.Fprintf(&b, "temperature: %s\n", temperature)
+	fmt.Fprintf(&b, "max_tokens: %s\n", maxTokens)
+	fmt.Fprintf(&b, "latency: %s\n", latency)
+	fmt.Fprintf(&b, "usage: %d request(s), %d prompt + %d completion = %d total tokens\n",
+		u.Requests, u.PromptTokens, u.CompletionTokens, u.TotalTokens)
+	fmt.Fprintf(&b, "last_usage: %d prompt + %d completion = %d total tokens",
+		u.Last.PromptTokens, u.Last.CompletionTokens, u.Last.TotalTokens)
+	return b.String()
+}
@@ -0,0 +1,76 @@
+package tool
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/edouard/pureclaw/internal/llm"
+)
+
+type fakeProber struct {
+	info llm.Info
+}
+
+func (f *fakeProber) Info() llm.Info { return f.info }
+
+func ownerContext() context.Context {
+	return WithContext(context.Background(), ToolContext{Owner: true})
+}
+
+func TestProbeLLM_ReportsInfo(t *testing.T) {
+	temperature := 0.7
+	prober := &fakeProber{info: llm.Info{
+		Provider:       "mistral",
+		BaseURL:        "https://api.mistral.ai/v1/",
+		Model:          "mistral-small-latest",
+		ResponseFormat: "json_schema",
+		Sampling:       llm.Sampling{Temperature: &temperature},
+		Latency:        1500 * time.Millisecond,
+		Usage: llm.UsageTotals{
+			Requests: 3, PromptTokens: 300, CompletionTokens: 45, TotalTokens: 345,
+			Last: llm.Usage{PromptTokens: 110, CompletionTokens: 15, TotalTokens: 125},
+		},
+	}}
+
+	result := NewProbeLLM(prober).Handler(ownerContext(), nil)
+	if !result.Success {
+		t.Fatalf("expected success, got error %q", result.Error)
+	}
+	for _, want := range []string{
+		"provider: mistral\n",
+		"base_url: https://api.mistral.ai/v1/\n",
+		"model: mistral-small-latest\n",
+		"response_format: json_schema\n",
+		"temperature: 0.7\n",
+		"max_tokens: provider default\n",
+		"latency: 1.5s\n",
+		"usage: 3 request(s), 300 prompt + 45 completion = 345 total tokens\n",
+		"last_usage: 110 prompt + 15 completion = 125 total tokens",
+	} {
+		if !strings.Contains(result.Output, want) {
+			t.Errorf("output missing %q:\n%s", want, result.Output)
+		}
+	}
+}
+
+func TestProbeLLM_OwnerOnly(t *testing.T) {
+	prober := &fakeProber{info: llm.Info{Model: "secret-model"}}
+	for _, ctx := range []context.Context{
+		context.Background(),
+		WithContext(context.Background(), ToolContext{Owner: false}),
+	} {
+		result := NewProbeLLM(prober).Handler(ctx, nil)
+		if result.Success || strings.Contains(result.Output, "secret-model") {
+			t.Errorf("result = %+v, want a refusal outside owner messages", result)
+		}
+	}
+}
+
+func TestProbeLLM_Unavailable(t *testing.T) {
+	result := NewProbeLLM(nil).Handler(ownerContext(), nil)
+	if result.Success {
+		t.Error("expected failure without an LLM client")
+	}
+}
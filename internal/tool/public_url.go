@@ -0,0 +1,91 @@
+package tool
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// errBlockedAddress is returned when a URL resolves to an address the agent
+// must not reach (loopback, private, link-local...), guarding against SSRF.
+var errBlockedAddress = errors.New("address not allowed")
+
+// Replaceable for testing.
+var blockedIP = isInternalIP
+
+// newPublicClient returns an HTTP client that refuses to connect to internal
+// addresses. Redirects are dialled through the same transport, so their
+// targets are checked too.
+func newPublicClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy: nil, // a proxy would dial on our behalf and bypass the address check
+			DialContext: (&net.Dialer{
+				Timeout: timeout,
+				Control: dialControl,
+			}).DialContext,
+		},
+	}
+}
+
+// validatePublicURL accepts only http(s) URLs whose host is not a literal internal address.
+// Hostnames are checked again against their resolved addresses when dialled.
+func validatePublicURL(target string) error {
+	u, err := url.Parse(target)
+	if err != nil {
+		return fmt.Errorf("invalid URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("URL scheme %q not allowed: use http or https", u.Scheme)
+	}
+	host := u.Hostname()
+	if host == "" {
+		return fmt.Errorf("URL %q has no host", target)
+	}
+	if host == "localhost" {
+		return fmt.Errorf("host %q: %w", host, errBlockedAddress)
+	}
+	if ip := net.ParseIP(host); ip != nil && blockedIP(ip) {
+		return fmt.Errorf("host %q: %w", host, errBlockedAddress)
+	}
+	return nil
+}
+
+// dialControl refuses connections to internal addresses, after DNS resolution,
+// so a public hostname pointing at an internal address is still rejected.
+func dialControl(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || blockedIP(ip) {
+		return fmt.Errorf("dial %s: %w", address, errBlockedAddress)
+	}
+	return nil
+}
+
+// Special-purpose IPv4 ranges the net.IP predicates do not cover.
+var internalNets = []*net.IPNet{
+	{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}, // shared address space (carrier-grade NAT), RFC 6598
+	{IP: net.IPv4(192, 0, 0, 0), Mask: net.CIDRMask(24, 32)},  // IETF protocol assignments, RFC 6890
+}
+
+// isInternalIP reports whether ip is loopback, private, link-local, multicast,
+// unspecified, or in one of internalNets.
+func isInternalIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() {
+		return true
+	}
+	for _, n := range internalNets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,28 @@
+package tool
+
+import (
+	"net"
+	"testing"
+)
+
+func TestIsInternalIP(t *testing.T) {
+	tests := map[string]bool{
+		"127.0.0.1":         true,
+		"10.1.2.3":          true,
+		"169.254.169.254":   true,
+		"::1":               true,
+		"100.64.0.1":        true,
+		"100.127.255.254":   true,
+		"192.0.0.170":       true,
+		"::ffff:100.64.0.1": true,
+		"100.128.0.1":       false,
+		"192.0.1.1":         false,
+		"8.8.8.8":           false,
+		"2001:4860::8888":   false,
+	}
+	for addr, want := range tests {
+		if got := isInternalIP(net.ParseIP(addr)); got != want {
+			t.Errorf("isInternalIP(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"log/slog"
+	"time"
 
 	"github.com/edouard/pureclaw/internal/llm"
+	"github.com/edouard/pureclaw/internal/platform"
 )
 
 // ToolResult is the structured response from tool execution.
@@ -15,6 +17,16 @@ type ToolResult struct {
 	Error   string `json:"error"`
 }
 
+// unavailable is the result returned when a tool's dependency is not configured,
+// so the LLM can explain the limitation instead of the call failing opaquely.
+func unavailable(name string) ToolResult {
+	slog.Warn("tool dependency not configured",
+		"component", "tool",
+		"operation", name,
+	)
+	return ToolResult{Success: false, Error: name + " is not available in this deployment"}
+}
+
 // Handler is the function signature for tool execution.
 type Handler func(ctx context.Context, args json.RawMessage) ToolResult
 
@@ -30,12 +42,20 @@ type Definition struct {
 type Registry struct {
 	tools map[string]Definition
 	order []string // preserves registration order for deterministic output
+
+	policy    ConfirmationPolicy
+	confirmer Confirmer
+
+	global  chan struct{}            // global concurrency semaphore, nil = unlimited
+	perTool map[string]chan struct{} // per-tool concurrency semaphores
+
+	timeouts map[string]time.Duration // per-tool call time limits
 }
 
 // NewRegistry creates a new empty tool registry.
 func NewRegistry() *Registry {
 	slog.Info("registry created", "component", "tool", "operation", "registry")
-	return &Registry{tools: make(map[string]Definition)}
+	return &Registry{tools: make(map[string]Definition), timeouts: make(map[string]time.Duration)}
 }
 
 // Register adds a tool definition to the registry.
@@ -48,24 +68,126 @@ func (r *Registry) Register(def Definition) {
 	slog.Info("tool registered", "component", "tool", "operation", "registry", "tool_name", def.Name)
 }
 
+// SetConfirmation installs the confirmation policy and the confirmer used to
+// approve matching tool calls. A nil confirmer rejects every call the policy matches.
+func (r *Registry) SetConfirmation(policy ConfirmationPolicy, confirmer Confirmer) {
+	r.policy = policy
+	r.confirmer = confirmer
+	slog.Info("tool confirmation policy set",
+		"component", "tool",
+		"operation", "registry",
+		"rules", len(policy.Rules),
+		"confirmer", confirmer != nil,
+	)
+}
+
+// SetConcurrencyLimits caps how many tool calls run at once. global limits all
+// tools together and perTool limits individual tools by name; zero or negative
+// values mean unlimited. Calls over a limit queue until a slot frees up or their
+// context is cancelled. Must be called before Execute is used concurrently.
+func (r *Registry) SetConcurrencyLimits(global int, perTool map[string]int) {
+	r.global = nil
+	if global > 0 {
+		r.global = make(chan struct{}, global)
+	}
+	r.perTool = make(map[string]chan struct{}, len(perTool))
+	for name, limit := range perTool {
+		if limit > 0 {
+			r.perTool[name] = make(chan struct{}, limit)
+		}
+	}
+	slog.Info("tool concurrency limits set",
+		"component", "tool",
+		"operation", "registry",
+		"global", global,
+		"per_tool", len(r.perTool),
+	)
+}
+
+// SetTimeout bounds each call of the named tool to d; zero or negative removes the limit.
+// The handler sees the limit as its context deadline. Must be called before Execute
+// is used concurrently.
+func (r *Registry) SetTimeout(name string, d time.Duration) {
+	if d <= 0 {
+		delete(r.timeouts, name)
+		return
+	}
+	r.timeouts[name] = d
+	slog.Info("tool timeout set",
+		"component", "tool",
+		"operation", "registry",
+		"tool_name", name,
+		"timeout", d,
+	)
+}
+
+// acquire takes a per-tool slot and then a global slot for name, waiting while
+// limits are reached. The per-tool slot is taken first so a call queued on its
+// own tool's cap does not hold a global slot other tools could use.
+// It returns a release function, or an error if ctx is cancelled while waiting.
+func (r *Registry) acquire(ctx context.Context, name string) (func(), error) {
+	var held []chan struct{}
+	release := func() {
+		for _, sem := range held {
+			<-sem
+		}
+	}
+	for _, sem := range []chan struct{}{r.perTool[name], r.global} {
+		if sem == nil {
+			continue
+		}
+		select {
+		case sem <- struct{}{}:
+			held = append(held, sem)
+		case <-ctx.Done():
+			release()
+			return nil, ctx.Err()
+		}
+	}
+	return release, nil
+}
+
 // Execute dispatches a tool call by name and returns the result.
 func (r *Registry) Execute(ctx context.Context, name string, args json.RawMessage) ToolResult {
 	def, ok := r.tools[name]
 	if !ok {
-		slog.Warn("unknown tool requested",
+		platform.Log(ctx).Warn("unknown tool requested",
 			"component", "tool",
 			"operation", "execute",
 			"tool_name", name,
 		)
 		return ToolResult{Success: false, Error: "unknown tool: " + name}
 	}
-	slog.Info("executing tool",
+	if r.policy.Requires(name, args) {
+		if res, ok := r.confirm(ctx, name, args); !ok {
+			return res
+		}
+	}
+	release, err := r.acquire(ctx, name)
+	if err != nil {
+		platform.Log(ctx).Warn("tool call cancelled while queued",
+			"component", "tool",
+			"operation", "execute",
+			"tool_name", name,
+			"error", err,
+		)
+		return ToolResult{Success: false, Error: "tool " + name + " cancelled while waiting for a free slot: " + err.Error()}
+	}
+	defer release()
+
+	if d, ok := r.timeouts[name]; ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d)
+		defer cancel()
+	}
+
+	platform.Log(ctx).Info("executing tool",
 		"component", "tool",
 		"operation", "execute",
 		"tool_name", name,
 	)
 	result := def.Handler(ctx, args)
-	slog.Info("tool execution completed",
+	platform.Log(ctx).Info("tool execution completed",
 		"component", "tool",
 		"operation", "execute",
 		"tool_name", name,
@@ -74,6 +196,39 @@ func (r *Registry) Execute(ctx context.Context, name string, args json.RawMessag
 	return result
 }
 
+// confirm asks the confirmer to approve a tool call. It returns ok=false with
+// the result to report to the LLM when the call must not run.
+func (r *Registry) confirm(ctx context.Context, name string, args json.RawMessage) (ToolResult, bool) {
+	if r.confirmer == nil {
+		platform.Log(ctx).Warn("tool requires confirmation but no confirmer configured",
+			"component", "tool",
+			"operation", "confirm",
+			"tool_name", name,
+		)
+		return ToolResult{Success: false, Error: "tool " + name + " requires owner confirmation, but no approval channel is configured"}, false
+	}
+	approved, err := r.confirmer.Confirm(ctx, name, args)
+	if err != nil {
+		platform.Log(ctx).Warn("tool confirmation failed",
+			"component", "tool",
+			"operation", "confirm",
+			"tool_name", name,
+			"error", err,
+		)
+		return ToolResult{Success: false, Error: "tool " + name + " confirmation failed: " + err.Error()}, false
+	}
+	platform.Log(ctx).Info("tool confirmation answered",
+		"component", "tool",
+		"operation", "confirm",
+		"tool_name", name,
+		"approved", approved,
+	)
+	if !approved {
+		return ToolResult{Success: false, Error: "tool " + name + " was denied by the owner"}, false
+	}
+	return ToolResult{}, true
+}
+
 // Definitions returns LLM-compatible tool definitions for function calling.
 func (r *Registry) Definitions() []llm.Tool {
 	defs := make([]llm.Tool, 0, len(r.order))
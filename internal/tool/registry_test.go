@@ -3,7 +3,16 @@ package tool
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/edouard/pureclaw/internal/subagent"
+	"github.com/edouard/pureclaw/internal/workspace"
 )
 
 func TestNewRegistry(t *testing.T) {
@@ -164,3 +173,299 @@ func TestDefinitions_Empty(t *testing.T) {
 		t.Errorf("expected empty slice, got %d elements", len(defs))
 	}
 }
+
+// fakeConfirmer records confirmation requests and answers with a fixed decision.
+type fakeConfirmer struct {
+	approve bool
+	err     error
+	asked   []string
+}
+
+func (f *fakeConfirmer) Confirm(ctx context.Context, name string, args json.RawMessage) (bool, error) {
+	f.asked = append(f.asked, name)
+	return f.approve, f.err
+}
+
+func confirmTestRegistry(t *testing.T, policy ConfirmationPolicy, c Confirmer) (*Registry, map[string]int) {
+	t.Helper()
+	calls := map[string]int{}
+	r := NewRegistry()
+	for _, name := range []string{"exec_command", "read_file", "write_file"} {
+		r.Register(Definition{
+			Name: name,
+			Handler: func(ctx context.Context, args json.RawMessage) ToolResult {
+				calls[name]++
+				return ToolResult{Success: true, Output: name}
+			},
+		})
+	}
+	r.SetConfirmation(policy, c)
+	return r, calls
+}
+
+func TestExecute_ConfirmationPolicyPerTool(t *testing.T) {
+	c := &fakeConfirmer{approve: false}
+	r, calls := confirmTestRegistry(t, ConfirmationPolicy{Rules: []ConfirmationRule{{Tool: "exec_command"}}}, c)
+
+	res := r.Execute(context.Background(), "exec_command", json.RawMessage(`{"command":"ls"}`))
+	if res.Success {
+		t.Fatal("expected exec_command to be blocked when denied")
+	}
+	if !strings.Contains(res.Error, "denied") {
+		t.Errorf("Error = %q, want denial message", res.Error)
+	}
+	if calls["exec_command"] != 0 {
+		t.Error("denied tool handler should not run")
+	}
+
+	res = r.Execute(context.Background(), "read_file", json.RawMessage(`{"path":"/etc/hosts"}`))
+	if !res.Success || calls["read_file"] != 1 {
+		t.Errorf("read_file should run without confirmation, got %+v", res)
+	}
+	if len(c.asked) != 1 || c.asked[0] != "exec_command" {
+		t.Errorf("confirmer asked for %v, want [exec_command]", c.asked)
+	}
+
+	c.approve = true
+	res = r.Execute(context.Background(), "exec_command", json.RawMessage(`{"command":"ls"}`))
+	if !res.Success || calls["exec_command"] != 1 {
+		t.Errorf("approved exec_command should run, got %+v", res)
+	}
+}
+
+func TestExecute_ConfirmationPolicyPathCondition(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "memory"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	c := &fakeConfirmer{approve: false}
+	policy := ConfirmationPolicy{
+		Root:  root,
+		Rules: []ConfirmationRule{{Tool: "write_file", ExceptPaths: []string{"memory"}}},
+	}
+	r, calls := confirmTestRegistry(t, policy, c)
+
+	inside, _ := json.Marshal(map[string]string{"path": filepath.Join(root, "memory", "notes.md")})
+	if res := r.Execute(context.Background(), "write_file", inside); !res.Success {
+		t.Errorf("write inside memory/ should not need confirmation, got %+v", res)
+	}
+	if len(c.asked) != 0 {
+		t.Errorf("confirmer should not be asked for memory/ writes, asked %v", c.asked)
+	}
+
+	outside, _ := json.Marshal(map[string]string{"path": filepath.Join(root, "AGENT.md")})
+	if res := r.Execute(context.Background(), "write_file", outside); res.Success {
+		t.Error("write outside memory/ should be blocked when denied")
+	}
+	if len(c.asked) != 1 {
+		t.Errorf("confirmer should be asked once, asked %v", c.asked)
+	}
+	if calls["write_file"] != 1 {
+		t.Errorf("write_file handler ran %d times, want 1", calls["write_file"])
+	}
+}
+
+func TestExecute_ConfirmationWithoutConfirmer(t *testing.T) {
+	r, calls := confirmTestRegistry(t, ConfirmationPolicy{Rules: []ConfirmationRule{{Tool: "exec_command"}}}, nil)
+
+	res := r.Execute(context.Background(), "exec_command", json.RawMessage(`{"command":"ls"}`))
+	if res.Success || !strings.Contains(res.Error, "no approval channel") {
+		t.Errorf("expected rejection without confirmer, got %+v", res)
+	}
+	if calls["exec_command"] != 0 {
+		t.Error("handler should not run without confirmation")
+	}
+}
+
+func TestExecute_ConfirmerError(t *testing.T) {
+	c := &fakeConfirmer{err: errors.New("timed out")}
+	r, _ := confirmTestRegistry(t, ConfirmationPolicy{Rules: []ConfirmationRule{{Tool: "exec_command"}}}, c)
+
+	res := r.Execute(context.Background(), "exec_command", json.RawMessage(`{}`))
+	if res.Success || !strings.Contains(res.Error, "timed out") {
+		t.Errorf("expected confirmer error in result, got %+v", res)
+	}
+}
+
+// concurrencyProbe is a tool handler that records how many calls overlap.
+type concurrencyProbe struct {
+	mu      sync.Mutex
+	current int
+	peak    int
+	release chan struct{}
+}
+
+func (p *concurrencyProbe) handler(ctx context.Context, args json.RawMessage) ToolResult {
+	p.mu.Lock()
+	p.current++
+	if p.current > p.peak {
+		p.peak = p.current
+	}
+	p.mu.Unlock()
+	<-p.release
+	p.mu.Lock()
+	p.current--
+	p.mu.Unlock()
+	return ToolResult{Success: true}
+}
+
+func (p *concurrencyProbe) running() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.current
+}
+
+// waitRunning polls until n calls are running on probe or the deadline passes.
+func waitRunning(t *testing.T, probe *concurrencyProbe, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for probe.running() < n && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := probe.running(); got != n {
+		t.Fatalf("running = %d, want %d", got, n)
+	}
+}
+
+func TestExecute_PerToolConcurrencyLimit(t *testing.T) {
+	execProbe := &concurrencyProbe{release: make(chan struct{})}
+	readProbe := &concurrencyProbe{release: make(chan struct{})}
+	r := NewRegistry()
+	r.Register(Definition{Name: "exec_command", Handler: execProbe.handler})
+	r.Register(Definition{Name: "read_file", Handler: readProbe.handler})
+	r.SetConcurrencyLimits(0, map[string]int{"exec_command": 2})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(2)
+		go func() { defer wg.Done(); r.Execute(context.Background(), "exec_command", nil) }()
+		go func() { defer wg.Done(); r.Execute(context.Background(), "read_file", nil) }()
+	}
+
+	// exec_command is capped at 2 while uncapped read_file calls all run.
+	waitRunning(t, execProbe, 2)
+	waitRunning(t, readProbe, 6)
+	time.Sleep(20 * time.Millisecond)
+	if got := execProbe.running(); got != 2 {
+		t.Fatalf("exec_command running = %d, want 2 (excess calls queued)", got)
+	}
+
+	close(execProbe.release)
+	close(readProbe.release)
+	wg.Wait()
+	if execProbe.peak != 2 {
+		t.Errorf("exec_command peak concurrency = %d, want 2", execProbe.peak)
+	}
+}
+
+func TestExecute_GlobalConcurrencyLimit(t *testing.T) {
+	execProbe := &concurrencyProbe{release: make(chan struct{})}
+	readProbe := &concurrencyProbe{release: make(chan struct{})}
+	r := NewRegistry()
+	r.Register(Definition{Name: "exec_command", Handler: execProbe.handler})
+	r.Register(Definition{Name: "read_file", Handler: readProbe.handler})
+	r.SetConcurrencyLimits(3, map[string]int{"exec_command": 1})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() { defer wg.Done(); r.Execute(context.Background(), "exec_command", nil) }()
+		go func() { defer wg.Done(); r.Execute(context.Background(), "read_file", nil) }()
+	}
+
+	// One exec_command (per-tool cap) plus two read_file fill the global cap of 3.
+	waitRunning(t, execProbe, 1)
+	waitRunning(t, readProbe, 2)
+	time.Sleep(20 * time.Millisecond)
+	if total := execProbe.running() + readProbe.running(); total != 3 {
+		t.Fatalf("total running = %d, want global cap 3", total)
+	}
+
+	close(execProbe.release)
+	close(readProbe.release)
+	wg.Wait()
+	if execProbe.peak != 1 {
+		t.Errorf("exec_command peak = %d, want 1", execProbe.peak)
+	}
+	if execProbe.peak+readProbe.peak > 4 {
+		t.Errorf("peaks exec=%d read=%d exceed limits", execProbe.peak, readProbe.peak)
+	}
+}
+
+func TestExecute_QueuedCallCancelled(t *testing.T) {
+	probe := &concurrencyProbe{release: make(chan struct{})}
+	r := NewRegistry()
+	r.Register(Definition{Name: "exec_command", Handler: probe.handler})
+	r.SetConcurrencyLimits(1, nil)
+
+	done := make(chan struct{})
+	go func() { r.Execute(context.Background(), "exec_command", nil); close(done) }()
+	waitRunning(t, probe, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	res := r.Execute(ctx, "exec_command", nil)
+	if res.Success || !strings.Contains(res.Error, "waiting for a free slot") {
+		t.Errorf("expected queued call to be cancelled, got %+v", res)
+	}
+
+	close(probe.release)
+	<-done
+	// The slot is released after the first call, so a new call runs.
+	probe.release = make(chan struct{})
+	close(probe.release)
+	if res := r.Execute(context.Background(), "exec_command", nil); !res.Success {
+		t.Errorf("expected call to run after slot freed, got %+v", res)
+	}
+}
+
+func TestTools_NilDependenciesReportUnavailable(t *testing.T) {
+	tests := []struct {
+		def  Definition
+		args string
+	}{
+		{NewSpawnAgent(SpawnAgentDeps{}), `{"task_id":"t1","task_description":"do it"}`},
+		{NewSpawnAgent(SpawnAgentDeps{Runner: subagent.NewRunner(), ParentWorkspace: &workspace.Workspace{}, BinaryPath: "/bin/pureclaw"}), `{"task_id":"t1","task_description":"do it"}`},
+		{NewReloadWorkspace(nil), `{}`},
+		{NewDescribeWorkspace(nil, ""), `{}`},
+		{NewMemTag(nil), `{"content":"note","tags":["x"]}`},
+		{NewReindexMemory(nil), `{}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.def.Name, func(t *testing.T) {
+			r := NewRegistry()
+			r.Register(tt.def)
+
+			result := r.Execute(context.Background(), tt.def.Name, json.RawMessage(tt.args))
+			if result.Success {
+				t.Fatal("expected failure for unconfigured dependency")
+			}
+			want := tt.def.Name + " is not available in this deployment"
+			if result.Error != want {
+				t.Errorf("Error = %q, want %q", result.Error, want)
+			}
+		})
+	}
+}
+
+func TestRegistry_SetTimeout(t *testing.T) {
+	r := NewRegistry()
+	r.Register(Definition{
+		Name: "slow",
+		Handler: func(ctx context.Context, args json.RawMessage) ToolResult {
+			<-ctx.Done()
+			return ToolResult{Success: false, Error: ctx.Err().Error()}
+		},
+	})
+	r.SetTimeout("slow", 20*time.Millisecond)
+
+	result := r.Execute(context.Background(), "slow", nil)
+	if result.Success || result.Error != context.DeadlineExceeded.Error() {
+		t.Errorf("result = %+v, want deadline exceeded", result)
+	}
+
+	r.SetTimeout("slow", 0)
+	if _, ok := r.timeouts["slow"]; ok {
+		t.Error("SetTimeout(0) did not remove the limit")
+	}
+}
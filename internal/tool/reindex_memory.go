@@ -0,0 +1,55 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/edouard/pureclaw/internal/memory"
+)
+
+// MemoryIndexer rebuilds the memory search index.
+type MemoryIndexer interface {
+	Reindex(ctx context.Context) (memory.ReindexStats, error)
+}
+
+// NewReindexMemory returns the definition for the reindex_memory tool, which
+// rebuilds the memory index from the memory files after manual edits.
+func NewReindexMemory(mem MemoryIndexer) Definition {
+	return Definition{
+		Name:        "reindex_memory",
+		Description: "Rebuild the memory search index from scratch by re-reading all memory files. Use after memory files were edited by hand",
+		Parameters: map[string]any{
+			"type":       "object",
+			"properties": map[string]any{},
+		},
+		Handler: makeReindexMemoryHandler(mem),
+	}
+}
+
+func makeReindexMemoryHandler(mem MemoryIndexer) Handler {
+	// args is intentionally ignored — this tool takes no parameters.
+	return func(ctx context.Context, args json.RawMessage) ToolResult {
+		if mem == nil {
+			return unavailable("reindex_memory")
+		}
+		stats, err := mem.Reindex(ctx)
+		if err != nil {
+			slog.Error("memory reindex failed",
+				"component", "tool",
+				"operation", "reindex_memory",
+				"error", err,
+			)
+			return ToolResult{Success: false, Error: fmt.Sprintf("reindex failed: %v", err)}
+		}
+		return ToolResult{Success: true, Output: FormatReindexStats(stats)}
+	}
+}
+
+// FormatReindexStats renders reindex results for the owner.
+func FormatReindexStats(stats memory.ReindexStats) string {
+	return fmt.Sprintf("memory index rebuilt: %d files, %d entries, %d terms in %s",
+		stats.Files, stats.Entries, stats.Terms, stats.Duration.Round(time.Millisecond))
+}
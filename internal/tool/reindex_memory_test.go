@@ -0,0 +1,66 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/edouard/pureclaw/internal/memory"
+)
+
+type fakeIndexer struct {
+	stats memory.ReindexStats
+	err   error
+}
+
+func (f *fakeIndexer) Reindex(ctx context.Context) (memory.ReindexStats, error) {
+	return f.stats, f.err
+}
+
+func TestReindexMemory_Success(t *testing.T) {
+	def := NewReindexMemory(&fakeIndexer{stats: memory.ReindexStats{Files: 3, Entries: 12, Terms: 40, Duration: 1500 * time.Microsecond}})
+
+	result := def.Handler(context.Background(), json.RawMessage(`{}`))
+	if !result.Success {
+		t.Fatalf("expected success, got error %q", result.Error)
+	}
+	want := "memory index rebuilt: 3 files, 12 entries, 40 terms in 2ms"
+	if result.Output != want {
+		t.Errorf("Output = %q, want %q", result.Output, want)
+	}
+}
+
+func TestReindexMemory_Error(t *testing.T) {
+	def := NewReindexMemory(&fakeIndexer{err: errors.New("disk full")})
+
+	result := def.Handler(context.Background(), json.RawMessage(`{}`))
+	if result.Success {
+		t.Fatal("expected failure")
+	}
+	if !strings.Contains(result.Error, "disk full") {
+		t.Errorf("Error = %q, want underlying cause", result.Error)
+	}
+}
+
+func TestReindexMemory_RebuildsRealIndex(t *testing.T) {
+	root := t.TempDir()
+	mem := memory.New(root)
+	if err := mem.Write(context.Background(), "owner", "hello index"); err != nil {
+		t.Fatal(err)
+	}
+
+	result := NewReindexMemory(mem).Handler(context.Background(), json.RawMessage(`{}`))
+	if !result.Success {
+		t.Fatalf("expected success, got error %q", result.Error)
+	}
+	idx, err := mem.LoadIndex()
+	if err != nil {
+		t.Fatalf("LoadIndex: %v", err)
+	}
+	if len(idx.Entries) != 1 || len(idx.Terms["index"]) != 1 {
+		t.Errorf("index = %+v, want the single written entry", idx)
+	}
+}
@@ -10,7 +10,7 @@ import (
 )
 
 // Replaceable for testing.
-var workspaceLoadFn = workspace.Load
+var workspaceLoadFn = workspace.LoadWithMaxSkills
 
 // NewReloadWorkspace creates a tool that reloads workspace files from disk.
 // ws is shared with the agent — mutating it updates the agent's view.
@@ -29,6 +29,9 @@ func NewReloadWorkspace(ws *workspace.Workspace) Definition {
 func makeReloadHandler(ws *workspace.Workspace) Handler {
 	// args is intentionally ignored — this tool takes no parameters.
 	return func(ctx context.Context, args json.RawMessage) ToolResult {
+		if ws == nil {
+			return unavailable("reload_workspace")
+		}
 		slog.Info("reloading workspace",
 			"component", "tool",
 			"operation", "reload_workspace",
@@ -40,7 +43,7 @@ func makeReloadHandler(ws *workspace.Workspace) Handler {
 			return ToolResult{Success: false, Error: fmt.Sprintf("workspace reload cancelled: %v", err)}
 		}
 
-		newWS, err := workspaceLoadFn(ws.Root)
+		newWS, err := workspaceLoadFn(ws.Root, ws.MaxSkills)
 		if err != nil {
 			slog.Error("workspace reload failed",
 				"component", "tool",
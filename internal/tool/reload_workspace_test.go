@@ -12,7 +12,7 @@ import (
 
 func TestReloadWorkspace_Success(t *testing.T) {
 	original := workspaceLoadFn
-	workspaceLoadFn = func(root string) (*workspace.Workspace, error) {
+	workspaceLoadFn = func(root string, _ int) (*workspace.Workspace, error) {
 		return &workspace.Workspace{
 			Root:    root,
 			AgentMD: "new agent",
@@ -42,7 +42,7 @@ func TestReloadWorkspace_Success(t *testing.T) {
 
 func TestReloadWorkspace_UpdatesFields(t *testing.T) {
 	original := workspaceLoadFn
-	workspaceLoadFn = func(root string) (*workspace.Workspace, error) {
+	workspaceLoadFn = func(root string, _ int) (*workspace.Workspace, error) {
 		return &workspace.Workspace{
 			Root:        root,
 			AgentMD:     "updated agent",
@@ -79,7 +79,7 @@ func TestReloadWorkspace_UpdatesFields(t *testing.T) {
 
 func TestReloadWorkspace_PreservesRoot(t *testing.T) {
 	original := workspaceLoadFn
-	workspaceLoadFn = func(root string) (*workspace.Workspace, error) {
+	workspaceLoadFn = func(root string, _ int) (*workspace.Workspace, error) {
 		return &workspace.Workspace{
 			Root:    root,
 			AgentMD: "new",
@@ -103,7 +103,7 @@ func TestReloadWorkspace_PreservesRoot(t *testing.T) {
 
 func TestReloadWorkspace_PreservesOnError(t *testing.T) {
 	original := workspaceLoadFn
-	workspaceLoadFn = func(root string) (*workspace.Workspace, error) {
+	workspaceLoadFn = func(root string, _ int) (*workspace.Workspace, error) {
 		return nil, errors.New("AGENT.md missing")
 	}
 	defer func() { workspaceLoadFn = original }()
@@ -133,7 +133,7 @@ func TestReloadWorkspace_PreservesOnError(t *testing.T) {
 
 func TestReloadWorkspace_WithSkills(t *testing.T) {
 	original := workspaceLoadFn
-	workspaceLoadFn = func(root string) (*workspace.Workspace, error) {
+	workspaceLoadFn = func(root string, _ int) (*workspace.Workspace, error) {
 		return &workspace.Workspace{
 			Root:    root,
 			AgentMD: "agent",
@@ -160,7 +160,7 @@ func TestReloadWorkspace_WithSkills(t *testing.T) {
 
 func TestReloadWorkspace_WithoutHeartbeat(t *testing.T) {
 	original := workspaceLoadFn
-	workspaceLoadFn = func(root string) (*workspace.Workspace, error) {
+	workspaceLoadFn = func(root string, _ int) (*workspace.Workspace, error) {
 		return &workspace.Workspace{
 			Root:    root,
 			AgentMD: "agent",
@@ -183,7 +183,7 @@ func TestReloadWorkspace_WithoutHeartbeat(t *testing.T) {
 
 func TestReloadWorkspace_InvalidJSONArgs(t *testing.T) {
 	original := workspaceLoadFn
-	workspaceLoadFn = func(root string) (*workspace.Workspace, error) {
+	workspaceLoadFn = func(root string, _ int) (*workspace.Workspace, error) {
 		return &workspace.Workspace{
 			Root:    root,
 			AgentMD: "agent",
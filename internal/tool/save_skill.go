@@ -0,0 +1,124 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/edouard/pureclaw/internal/platform"
+	"github.com/edouard/pureclaw/internal/workspace"
+)
+
+// maxSkillNameLen caps a sanitized skill name, which becomes a directory name.
+const maxSkillNameLen = 64
+
+type saveSkillArgs struct {
+	Name    string `json:"name"`
+	Content string `json:"content"`
+}
+
+// NewSaveSkill creates a tool that writes skills/<name>/SKILL.md below root and
+// reloads the workspace so the skill is usable right away.
+// ws is shared with the agent — the reload updates the agent's view.
+// allowed restricts written extensions as for write_file.
+func NewSaveSkill(ws *workspace.Workspace, root string, allowed ...string) Definition {
+	return Definition{
+		Name:        "save_skill",
+		Description: "Save a reusable procedure as a skill (skills/<name>/SKILL.md) and reload the workspace so it is available immediately. Saving an existing name replaces that skill.",
+		Parameters: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"name": map[string]any{
+					"type":        "string",
+					"description": "Skill name: letters, digits, spaces, dashes and underscores (stored lowercase, spaces as dashes)",
+				},
+				"content": map[string]any{
+					"type":        "string",
+					"description": "Markdown content of SKILL.md",
+				},
+			},
+			"required": []string{"name", "content"},
+		},
+		Handler: makeSaveSkillHandler(ws, root, allowed),
+	}
+}
+
+func makeSaveSkillHandler(ws *workspace.Workspace, root string, allowed []string) Handler {
+	return func(ctx context.Context, args json.RawMessage) ToolResult {
+		if ws == nil || root == "" {
+			return unavailable("save_skill")
+		}
+		var a saveSkillArgs
+		if err := json.Unmarshal(args, &a); err != nil {
+			return ToolResult{Success: false, Error: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+		name, err := sanitizeSkillName(a.Name)
+		if err != nil {
+			return ToolResult{Success: false, Error: err.Error()}
+		}
+		if strings.TrimSpace(a.Content) == "" {
+			return ToolResult{Success: false, Error: "invalid arguments: content is required"}
+		}
+
+		path := filepath.Join(root, "skills", name, "SKILL.md")
+		if err := platform.ValidatePath(root, path); err != nil {
+			return ToolResult{Success: false, Error: fmt.Sprintf("skill %q: %v", name, err)}
+		}
+		if err := checkWriteExtension(path, allowed); err != nil {
+			return ToolResult{Success: false, Error: err.Error()}
+		}
+		slog.Info("saving skill",
+			"component", "tool",
+			"operation", "save_skill",
+			"skill", name,
+		)
+		if err := osMkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return ToolResult{Success: false, Error: err.Error()}
+		}
+		if err := atomicWrite(path, []byte(a.Content), 0o644); err != nil {
+			slog.Warn("write failed",
+				"component", "tool",
+				"operation", "save_skill",
+				"path", path,
+				"error", err,
+			)
+			return ToolResult{Success: false, Error: err.Error()}
+		}
+
+		newWS, err := workspaceLoadFn(ws.Root, ws.MaxSkills)
+		if err != nil {
+			return ToolResult{Success: false, Error: fmt.Sprintf("skill %q written but workspace reload failed: %v", name, err)}
+		}
+		*ws = *newWS
+
+		if !slices.ContainsFunc(ws.Skills, func(s workspace.Skill) bool { return s.Name == name }) {
+			return ToolResult{Success: true, Output: fmt.Sprintf("skill %q written to skills/%s/SKILL.md but not loaded: the skill limit (%d) keeps higher-priority skills", name, name, ws.MaxSkills)}
+		}
+		return ToolResult{Success: true, Output: fmt.Sprintf("skill %q saved to skills/%s/SKILL.md and loaded (%d skill(s))", name, name, len(ws.Skills))}
+	}
+}
+
+// sanitizeSkillName lowercases name and turns spaces into dashes. Names holding
+// path separators or "..", or any other character, are rejected.
+func sanitizeSkillName(name string) (string, error) {
+	if strings.ContainsAny(name, `/\`) || strings.Contains(name, "..") {
+		return "", fmt.Errorf("invalid skill name %q: must not contain path separators or \"..\"", name)
+	}
+	name = strings.Join(strings.Fields(strings.ToLower(name)), "-")
+	if name == "" {
+		return "", fmt.Errorf("invalid arguments: name is required")
+	}
+	if len(name) > maxSkillNameLen {
+		return "", fmt.Errorf("invalid skill name %q: longer than %d characters", name, maxSkillNameLen)
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return "", fmt.Errorf("invalid skill name %q: use letters, digits, dashes and underscores", name)
+		}
+	}
+	return name, nil
+}
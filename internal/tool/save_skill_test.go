@@ -0,0 +1,101 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/edouard/pureclaw/internal/workspace"
+)
+
+func saveSkillWorkspace(t *testing.T) *workspace.Workspace {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range map[string]string{"AGENT.md": "agent", "SOUL.md": "soul"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ws, err := workspace.Load(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ws
+}
+
+func saveSkill(ws *workspace.Workspace, name, content string) ToolResult {
+	args, _ := json.Marshal(saveSkillArgs{Name: name, Content: content})
+	return NewSaveSkill(ws, ws.Root).Handler(context.Background(), args)
+}
+
+func TestSaveSkill_WritesAndReloads(t *testing.T) {
+	ws := saveSkillWorkspace(t)
+
+	result := saveSkill(ws, "  Deploy Site ", "# Deploy\n1. build\n2. upload")
+	if !result.Success {
+		t.Fatalf("save failed: %s", result.Error)
+	}
+	data, err := os.ReadFile(filepath.Join(ws.Root, "skills", "deploy-site", "SKILL.md"))
+	if err != nil {
+		t.Fatalf("skill file not written under the sanitized name: %v", err)
+	}
+	if string(data) != "# Deploy\n1. build\n2. upload" {
+		t.Errorf("SKILL.md = %q", data)
+	}
+	if len(ws.Skills) != 1 || ws.Skills[0].Name != "deploy-site" {
+		t.Errorf("skills after reload = %+v, want deploy-site", ws.Skills)
+	}
+	if !strings.Contains(result.Output, "loaded") {
+		t.Errorf("output = %q, want the skill reported loaded", result.Output)
+	}
+
+	// Saving again replaces the skill.
+	if result := saveSkill(ws, "deploy-site", "v2"); !result.Success || ws.Skills[0].Content != "v2" {
+		t.Errorf("update: result = %+v, skills = %+v", result, ws.Skills)
+	}
+}
+
+func TestSaveSkill_RejectsInvalidNames(t *testing.T) {
+	ws := saveSkillWorkspace(t)
+	for _, name := range []string{"", "   ", "../escape", "a/b", `a\b`, "..", "rm -rf *", "émoji", strings.Repeat("x", 65)} {
+		if result := saveSkill(ws, name, "content"); result.Success {
+			t.Errorf("name %q accepted, want rejected", name)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(ws.Root, "escape")); !os.IsNotExist(err) {
+		t.Errorf("traversal wrote outside skills/: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(ws.Root, "skills")); !os.IsNotExist(err) {
+		t.Errorf("skills/ created for rejected names: %v", err)
+	}
+}
+
+func TestSaveSkill_RejectsEmptyContent(t *testing.T) {
+	ws := saveSkillWorkspace(t)
+	if result := saveSkill(ws, "empty", " \n"); result.Success {
+		t.Error("empty content accepted")
+	}
+}
+
+func TestSaveSkill_NoWorkspace(t *testing.T) {
+	result := NewSaveSkill(nil, "").Handler(context.Background(), json.RawMessage(`{"name":"x","content":"y"}`))
+	if result.Success || !strings.Contains(result.Error, "not available") {
+		t.Errorf("result = %+v, want unavailable", result)
+	}
+}
+
+func TestSaveSkill_AllowedExtensions(t *testing.T) {
+	ws := saveSkillWorkspace(t)
+	args, _ := json.Marshal(saveSkillArgs{Name: "deploy", Content: "# Deploy"})
+
+	if result := NewSaveSkill(ws, ws.Root, ".md").Handler(context.Background(), args); !result.Success {
+		t.Errorf("save with .md allowed failed: %s", result.Error)
+	}
+	result := NewSaveSkill(ws, ws.Root, ".txt").Handler(context.Background(), args)
+	if result.Success || !strings.Contains(result.Error, "policy") {
+		t.Errorf("save with only .txt allowed = %+v, want a policy error", result)
+	}
+}
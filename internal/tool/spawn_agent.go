@@ -20,13 +20,18 @@ type SpawnAgentDeps struct {
 	ConfigPath      string
 	VaultPath       string
 	Timeout         time.Duration
-	AgentsDir       string // Parent's agents/ directory path
+	AgentsDir       string        // Parent's agents/ directory path
+	WaitTimeout     time.Duration // Max time a wait=true call blocks (0 = defaultSpawnWaitTimeout)
+	Soul            string        // Default sub-agent SOUL.md (empty = inherit the parent's)
 }
 
+// defaultSpawnWaitTimeout bounds how long spawn_agent blocks when wait=true.
+const defaultSpawnWaitTimeout = 2 * time.Minute
+
 // Replaceable for testing.
 var (
-	createWorkspaceFn  = subagent.CreateWorkspace
-	launchSubAgentFn = func(r *subagent.Runner, ctx context.Context, cfg subagent.RunnerConfig, ch chan<- subagent.SubAgentResult) error {
+	createWorkspaceFn = subagent.CreateWorkspace
+	launchSubAgentFn  = func(r *subagent.Runner, ctx context.Context, cfg subagent.RunnerConfig, ch chan<- subagent.SubAgentResult) error {
 		return r.LaunchSubAgent(ctx, cfg, ch)
 	}
 )
@@ -35,7 +40,7 @@ var (
 func NewSpawnAgent(deps SpawnAgentDeps) Definition {
 	return Definition{
 		Name:        "spawn_agent",
-		Description: "Spawn an isolated sub-agent to handle a complex or long-running task autonomously. The sub-agent runs in its own workspace as a subprocess with a timeout. Use this when a task requires multiple steps, extensive file analysis, or would take too long for a single interaction. Returns immediately — results arrive asynchronously.",
+		Description: "Spawn an isolated sub-agent to handle a complex or long-running task autonomously. The sub-agent runs in its own workspace as a subprocess with a timeout. Use this when a task requires multiple steps, extensive file analysis, or would take too long for a single interaction. Returns immediately — results arrive asynchronously — unless wait is true.",
 		Parameters: map[string]any{
 			"type": "object",
 			"properties": map[string]any{
@@ -55,6 +60,18 @@ func NewSpawnAgent(deps SpawnAgentDeps) Definition {
 					"type":        "boolean",
 					"description": "Whether to copy skills/ to the sub-agent workspace (default: false)",
 				},
+				"include_parent_history": map[string]any{
+					"type":        "boolean",
+					"description": "Whether to give the sub-agent the recent conversation with the owner for context (default: false)",
+				},
+				"soul": map[string]any{
+					"type":        "string",
+					"description": "Task-oriented persona replacing SOUL.md for this sub-agent (default: the configured sub-agent soul, else your own)",
+				},
+				"wait": map[string]any{
+					"type":        "boolean",
+					"description": "Block until the sub-agent finishes and return its result directly (default: false). Use for short sub-tasks whose result you need in your current reply.",
+				},
 			},
 			"required": []string{"task_id", "task_description"},
 		},
@@ -67,6 +84,9 @@ type spawnArgs struct {
 	TaskDescription  string `json:"task_description"`
 	IncludeHeartbeat bool   `json:"include_heartbeat"`
 	IncludeSkills    bool   `json:"include_skills"`
+	IncludeHistory   bool   `json:"include_parent_history"`
+	Soul             string `json:"soul"`
+	Wait             bool   `json:"wait"`
 }
 
 func makeSpawnHandler(deps SpawnAgentDeps) Handler {
@@ -74,6 +94,10 @@ func makeSpawnHandler(deps SpawnAgentDeps) Handler {
 		slog.Info("spawn_agent called",
 			"component", "tool", "operation", "spawn_agent")
 
+		if deps.Runner == nil || deps.ParentWorkspace == nil || deps.BinaryPath == "" {
+			return unavailable("spawn_agent")
+		}
+
 		if err := ctx.Err(); err != nil {
 			slog.Warn("spawn_agent cancelled",
 				"component", "tool", "operation", "spawn_agent", "error", err)
@@ -90,6 +114,10 @@ func makeSpawnHandler(deps SpawnAgentDeps) Handler {
 		if a.TaskDescription == "" {
 			return ToolResult{Success: false, Error: "task_description is required"}
 		}
+		// Without a result channel, an asynchronous sub-agent's result would be lost.
+		if deps.ResultCh == nil && !a.Wait {
+			return unavailable("spawn_agent")
+		}
 
 		// 1. Create isolated workspace.
 		wsCfg := subagent.WorkspaceConfig{
@@ -99,6 +127,14 @@ func makeSpawnHandler(deps SpawnAgentDeps) Handler {
 			AgentsDir:        deps.AgentsDir,
 			IncludeHeartbeat: a.IncludeHeartbeat,
 			IncludeSkills:    a.IncludeSkills,
+			Soul:             deps.Soul,
+		}
+		if a.Soul != "" {
+			wsCfg.Soul = a.Soul
+		}
+		if a.IncludeHistory {
+			wsCfg.IncludeParentHistory = true
+			wsCfg.ParentHistory = parentHistory(ctx)
 		}
 		wsPath, err := createWorkspaceFn(wsCfg)
 		if err != nil {
@@ -117,7 +153,17 @@ func makeSpawnHandler(deps SpawnAgentDeps) Handler {
 			ConfigPath:    deps.ConfigPath,
 			VaultPath:     deps.VaultPath,
 		}
-		if err := launchSubAgentFn(deps.Runner, ctx, runCfg, deps.ResultCh); err != nil {
+		var inline chan subagent.SubAgentResult
+		var abandoned chan struct{}
+		if a.Wait {
+			inline = make(chan subagent.SubAgentResult)
+			abandoned = make(chan struct{})
+			runCfg.Inline, runCfg.Abandoned = inline, abandoned
+		}
+		// The sub-agent outlives this tool call: the handler ctx carries the
+		// message timeout and is cancelled once the reply is sent. The runner
+		// applies its own deadline (deps.Timeout) to the detached context.
+		if err := launchSubAgentFn(deps.Runner, context.WithoutCancel(ctx), runCfg, deps.ResultCh); err != nil {
 			slog.Error("sub-agent launch failed",
 				"component", "tool", "operation", "spawn_agent",
 				"task_id", a.TaskID, "error", err)
@@ -127,7 +173,11 @@ func makeSpawnHandler(deps SpawnAgentDeps) Handler {
 		slog.Info("sub-agent spawned",
 			"component", "tool", "operation", "spawn_agent",
 			"task_id", a.TaskID, "workspace", wsPath,
-			"timeout", deps.Timeout)
+			"timeout", deps.Timeout, "wait", a.Wait)
+
+		if a.Wait {
+			return waitForSubAgent(ctx, deps, a.TaskID, inline, abandoned)
+		}
 
 		return ToolResult{
 			Success: true,
@@ -135,3 +185,65 @@ func makeSpawnHandler(deps SpawnAgentDeps) Handler {
 		}
 	}
 }
+
+// waitForSubAgent blocks until the sub-agent result arrives on inline, the wait
+// timeout elapses, or ctx is cancelled. Giving up closes abandoned, so the runner
+// sends the result to the event loop and the owner is still notified asynchronously.
+func waitForSubAgent(ctx context.Context, deps SpawnAgentDeps, taskID string, inline <-chan subagent.SubAgentResult, abandoned chan<- struct{}) ToolResult {
+	timeout := deps.WaitTimeout
+	if timeout <= 0 {
+		timeout = defaultSpawnWaitTimeout
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case res := <-inline:
+		slog.Info("sub-agent result returned inline",
+			"component", "tool", "operation", "spawn_agent",
+			"task_id", taskID, "timed_out", res.TimedOut, "result_bytes", len(res.ResultContent))
+		return subAgentToolResult(res)
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+
+	slog.Warn("sub-agent wait timed out, result will be delivered asynchronously",
+		"component", "tool", "operation", "spawn_agent",
+		"task_id", taskID, "wait_timeout", timeout)
+	close(abandoned)
+	return ToolResult{
+		Success: false,
+		Error:   fmt.Sprintf("sub-agent '%s' did not finish within %s; it keeps running and its result will be reported when complete", taskID, timeout),
+	}
+}
+
+// subAgentToolResult converts a completed sub-agent result into a tool result.
+func subAgentToolResult(res subagent.SubAgentResult) ToolResult {
+	switch {
+	case res.TimedOut && res.ResultContent != "":
+		return ToolResult{Success: true, Output: "[partial result — sub-agent timed out]\n\n" + res.ResultContent}
+	case res.Err != nil:
+		return ToolResult{Success: false, Output: res.ResultContent, Error: fmt.Sprintf("sub-agent '%s' failed: %v", res.TaskID, res.Err)}
+	case res.ResultContent == "":
+		return ToolResult{Success: true, Output: fmt.Sprintf("Sub-agent '%s' completed without producing a result.", res.TaskID)}
+	default:
+		return ToolResult{Success: true, Output: res.ResultContent}
+	}
+}
+
+// parentHistory converts the calling agent's conversation from the tool context
+// into sub-agent history turns. Tool-call plumbing messages are skipped.
+func parentHistory(ctx context.Context) []subagent.HistoryTurn {
+	tc, ok := FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	var turns []subagent.HistoryTurn
+	for _, m := range tc.History {
+		if m.Role != "user" && m.Role != "assistant" {
+			continue
+		}
+		turns = append(turns, subagent.HistoryTurn{Role: m.Role, Content: m.Content})
+	}
+	return turns
+}
@@ -8,6 +8,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/edouard/pureclaw/internal/llm"
 	"github.com/edouard/pureclaw/internal/subagent"
 	"github.com/edouard/pureclaw/internal/workspace"
 )
@@ -379,3 +380,282 @@ func TestSpawnAgent_DefaultOptionalFlags(t *testing.T) {
 		t.Error("expected IncludeSkills=false by default")
 	}
 }
+
+// deliverResult hands res over as the runner does: to a caller waiting inline,
+// or to the event loop channel once that caller gave up, if there is one.
+func deliverResult(cfg subagent.RunnerConfig, ch chan<- subagent.SubAgentResult, res subagent.SubAgentResult) bool {
+	if cfg.Inline != nil {
+		select {
+		case cfg.Inline <- res:
+			return true
+		case <-cfg.Abandoned:
+		}
+	}
+	if ch == nil {
+		return false
+	}
+	ch <- res
+	return true
+}
+
+func TestSpawnAgent_WaitReturnsResultInline(t *testing.T) {
+	saveSpawnVars(t)
+	createWorkspaceFn = func(cfg subagent.WorkspaceConfig) (string, error) {
+		return "/test/workspace/agents/" + cfg.TaskID, nil
+	}
+	launchSubAgentFn = func(r *subagent.Runner, ctx context.Context, cfg subagent.RunnerConfig, ch chan<- subagent.SubAgentResult) error {
+		go deliverResult(cfg, ch, subagent.SubAgentResult{TaskID: cfg.TaskID, ResultContent: "42 errors found"})
+		return nil
+	}
+
+	resultCh := make(chan subagent.SubAgentResult, 1)
+	deps := testSpawnDeps()
+	deps.ResultCh = resultCh
+	def := NewSpawnAgent(deps)
+	args := `{"task_id": "fast", "task_description": "count errors", "wait": true}`
+	result := def.Handler(context.Background(), json.RawMessage(args))
+
+	if !result.Success {
+		t.Fatalf("expected success, got error %q", result.Error)
+	}
+	if result.Output != "42 errors found" {
+		t.Errorf("Output = %q, want inline result content", result.Output)
+	}
+	select {
+	case r := <-resultCh:
+		t.Errorf("result should not also be sent to the event loop, got %+v", r)
+	default:
+	}
+}
+
+func TestSpawnAgent_WaitTimeoutForwardsResult(t *testing.T) {
+	saveSpawnVars(t)
+	createWorkspaceFn = func(cfg subagent.WorkspaceConfig) (string, error) {
+		return "/test/workspace/agents/" + cfg.TaskID, nil
+	}
+	release := make(chan struct{})
+	launchSubAgentFn = func(r *subagent.Runner, ctx context.Context, cfg subagent.RunnerConfig, ch chan<- subagent.SubAgentResult) error {
+		go func() {
+			<-release
+			deliverResult(cfg, ch, subagent.SubAgentResult{TaskID: cfg.TaskID, ResultContent: "late result"})
+		}()
+		return nil
+	}
+
+	resultCh := make(chan subagent.SubAgentResult, 1)
+	deps := testSpawnDeps()
+	deps.ResultCh = resultCh
+	deps.WaitTimeout = 20 * time.Millisecond
+	def := NewSpawnAgent(deps)
+	args := `{"task_id": "slow", "task_description": "long task", "wait": true}`
+
+	start := time.Now()
+	result := def.Handler(context.Background(), json.RawMessage(args))
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("handler blocked %v, expected to return at wait timeout", elapsed)
+	}
+	if result.Success {
+		t.Fatal("expected success=false on wait timeout")
+	}
+	if !strings.Contains(result.Error, "did not finish within") {
+		t.Errorf("Error = %q, want wait timeout message", result.Error)
+	}
+
+	close(release)
+	select {
+	case r := <-resultCh:
+		if r.ResultContent != "late result" {
+			t.Errorf("forwarded ResultContent = %q, want %q", r.ResultContent, "late result")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("late result was not forwarded to the event loop channel")
+	}
+}
+
+func TestSpawnAgent_WaitTimeoutWithoutResultChannel(t *testing.T) {
+	saveSpawnVars(t)
+	createWorkspaceFn = func(cfg subagent.WorkspaceConfig) (string, error) {
+		return "/test/workspace/agents/" + cfg.TaskID, nil
+	}
+	release := make(chan struct{})
+	delivered := make(chan bool, 1)
+	launchSubAgentFn = func(r *subagent.Runner, ctx context.Context, cfg subagent.RunnerConfig, ch chan<- subagent.SubAgentResult) error {
+		go func() {
+			<-release
+			delivered <- deliverResult(cfg, ch, subagent.SubAgentResult{TaskID: cfg.TaskID, ResultContent: "late"})
+		}()
+		return nil
+	}
+
+	deps := testSpawnDeps()
+	deps.ResultCh = nil
+	deps.WaitTimeout = 20 * time.Millisecond
+	result := NewSpawnAgent(deps).Handler(context.Background(), json.RawMessage(`{"task_id": "slow", "task_description": "d", "wait": true}`))
+	if result.Success || !strings.Contains(result.Error, "did not finish within") {
+		t.Fatalf("result = %+v, want the wait timeout error", result)
+	}
+
+	close(release)
+	select {
+	case ok := <-delivered:
+		if ok {
+			t.Error("result delivered with no one to receive it")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("late result delivery blocked without a result channel")
+	}
+}
+
+func TestSpawnAgent_WaitRespectsContextCancel(t *testing.T) {
+	saveSpawnVars(t)
+	createWorkspaceFn = func(cfg subagent.WorkspaceConfig) (string, error) {
+		return "/test/workspace/agents/" + cfg.TaskID, nil
+	}
+	launchSubAgentFn = func(r *subagent.Runner, ctx context.Context, cfg subagent.RunnerConfig, ch chan<- subagent.SubAgentResult) error {
+		return nil
+	}
+
+	deps := testSpawnDeps()
+	def := NewSpawnAgent(deps)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	result := def.Handler(ctx, json.RawMessage(`{"task_id": "t", "task_description": "d", "wait": true}`))
+	if result.Success {
+		t.Fatal("expected success=false when context expires")
+	}
+}
+
+func TestSubAgentToolResult(t *testing.T) {
+	tests := []struct {
+		name        string
+		res         subagent.SubAgentResult
+		wantSuccess bool
+		wantSubstr  string
+	}{
+		{"success", subagent.SubAgentResult{TaskID: "a", ResultContent: "done"}, true, "done"},
+		{"empty", subagent.SubAgentResult{TaskID: "a"}, true, "without producing a result"},
+		{"partial", subagent.SubAgentResult{TaskID: "a", TimedOut: true, Err: fmt.Errorf("timeout"), ResultContent: "half"}, true, "partial result"},
+		{"failed", subagent.SubAgentResult{TaskID: "a", Err: fmt.Errorf("exit 1")}, false, "failed: exit 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := subAgentToolResult(tt.res)
+			if got.Success != tt.wantSuccess {
+				t.Errorf("Success = %v, want %v", got.Success, tt.wantSuccess)
+			}
+			if !strings.Contains(got.Output+got.Error, tt.wantSubstr) {
+				t.Errorf("result %+v should contain %q", got, tt.wantSubstr)
+			}
+		})
+	}
+}
+
+func TestSpawnAgent_IncludeParentHistory(t *testing.T) {
+	saveSpawnVars(t)
+
+	var captured subagent.WorkspaceConfig
+	createWorkspaceFn = func(cfg subagent.WorkspaceConfig) (string, error) {
+		captured = cfg
+		return "/test/workspace/agents/my-task", nil
+	}
+	launchSubAgentFn = func(r *subagent.Runner, ctx context.Context, cfg subagent.RunnerConfig, ch chan<- subagent.SubAgentResult) error {
+		return nil
+	}
+
+	ctx := WithContext(context.Background(), ToolContext{History: []llm.Message{
+		{Role: "user", Content: "check the backups"},
+		{Role: "tool", Content: `{"success":true}`},
+		{Role: "assistant", Content: "Backups are on the NAS."},
+	}})
+	def := NewSpawnAgent(testSpawnDeps())
+
+	result := def.Handler(ctx, json.RawMessage(`{"task_id":"my-task","task_description":"verify backups","include_parent_history":true}`))
+	if !result.Success {
+		t.Fatalf("expected success, got error %q", result.Error)
+	}
+	if !captured.IncludeParentHistory {
+		t.Error("IncludeParentHistory should be set")
+	}
+	want := []subagent.HistoryTurn{
+		{Role: "user", Content: "check the backups"},
+		{Role: "assistant", Content: "Backups are on the NAS."},
+	}
+	if fmt.Sprint(captured.ParentHistory) != fmt.Sprint(want) {
+		t.Errorf("ParentHistory = %+v, want %+v", captured.ParentHistory, want)
+	}
+
+	// Without the flag, no history is passed even if the context carries it.
+	result = def.Handler(ctx, json.RawMessage(`{"task_id":"my-task","task_description":"verify backups"}`))
+	if !result.Success {
+		t.Fatalf("expected success, got error %q", result.Error)
+	}
+	if captured.IncludeParentHistory || captured.ParentHistory != nil {
+		t.Errorf("history should not be passed without include_parent_history: %+v", captured)
+	}
+}
+
+func TestSpawnAgent_Soul(t *testing.T) {
+	saveSpawnVars(t)
+
+	var captured subagent.WorkspaceConfig
+	createWorkspaceFn = func(cfg subagent.WorkspaceConfig) (string, error) {
+		captured = cfg
+		return "/test/workspace/agents/my-task", nil
+	}
+	launchSubAgentFn = func(r *subagent.Runner, ctx context.Context, cfg subagent.RunnerConfig, ch chan<- subagent.SubAgentResult) error {
+		return nil
+	}
+
+	tests := []struct {
+		name     string
+		depsSoul string
+		args     string
+		want     string
+	}{
+		{"inherit", "", `{"task_id":"my-task","task_description":"d"}`, ""},
+		{"configured", "configured soul", `{"task_id":"my-task","task_description":"d"}`, "configured soul"},
+		{"spawn parameter", "configured soul", `{"task_id":"my-task","task_description":"d","soul":"task soul"}`, "task soul"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deps := testSpawnDeps()
+			deps.Soul = tt.depsSoul
+			result := NewSpawnAgent(deps).Handler(context.Background(), json.RawMessage(tt.args))
+			if !result.Success {
+				t.Fatalf("expected success, got error %q", result.Error)
+			}
+			if captured.Soul != tt.want {
+				t.Errorf("WorkspaceConfig.Soul = %q, want %q", captured.Soul, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpawnAgent_OutlivesHandlerContext(t *testing.T) {
+	saveSpawnVars(t)
+	createWorkspaceFn = func(cfg subagent.WorkspaceConfig) (string, error) {
+		return "/test/workspace/agents/" + cfg.TaskID, nil
+	}
+	launched := make(chan context.Context, 1)
+	launchSubAgentFn = func(r *subagent.Runner, ctx context.Context, cfg subagent.RunnerConfig, ch chan<- subagent.SubAgentResult) error {
+		launched <- ctx
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	def := NewSpawnAgent(testSpawnDeps())
+	result := def.Handler(ctx, json.RawMessage(`{"task_id": "bg", "task_description": "d"}`))
+	if !result.Success {
+		t.Fatalf("expected success, got error: %s", result.Error)
+	}
+	cancel()
+
+	subCtx := <-launched
+	if err := subCtx.Err(); err != nil {
+		t.Errorf("sub-agent context cancelled with the handler: %v", err)
+	}
+	if _, ok := subCtx.Deadline(); ok {
+		t.Error("sub-agent context inherited the message deadline")
+	}
+}
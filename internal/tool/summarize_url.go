@@ -0,0 +1,211 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/edouard/pureclaw/internal/llm"
+)
+
+const (
+	defaultSummaryWords = 150
+	maxSummaryWords     = 500
+	summarizeFetchLimit = 2 << 20 // bytes of the page read at most
+	summarizeInputLimit = 20000   // runes of extracted text sent to the LLM at most
+	summarizeURLTimeout = 20 * time.Second
+)
+
+// summarizeURLClient fetches pages when summarize_url is given no fetcher.
+var summarizeURLClient HTTPDoer = newPublicClient(summarizeURLTimeout)
+
+// ChatCompleter runs a chat completion, e.g. *llm.Client.
+type ChatCompleter interface {
+	ChatCompletionWithRetry(ctx context.Context, messages []llm.Message, tools []llm.Tool) (*llm.ChatResponse, error)
+}
+
+// HTTPDoer sends an HTTP request, e.g. *http.Client.
+type HTTPDoer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+type summarizeURLArgs struct {
+	URL      string `json:"url"`
+	MaxWords int    `json:"max_words"`
+}
+
+// NewSummarizeURL returns the definition for the summarize_url tool, which
+// fetches a public web page, extracts its readable text and has c summarize
+// it. A nil fetcher uses a client that refuses internal addresses; a custom
+// one must provide that protection itself, since only literal internal hosts
+// are rejected before fetching.
+func NewSummarizeURL(c ChatCompleter, fetcher HTTPDoer) Definition {
+	return Definition{
+		Name:        "summarize_url",
+		Description: "Fetch a public web page (http or https) and return a summary of its readable text. Use it to summarize an article or page instead of reading the raw HTML",
+		Parameters: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"url": map[string]any{
+					"type":        "string",
+					"description": "The page to summarize",
+				},
+				"max_words": map[string]any{
+					"type":        "integer",
+					"description": fmt.Sprintf("Length limit of the summary in words (default %d, max %d)", defaultSummaryWords, maxSummaryWords),
+				},
+			},
+			"required": []string{"url"},
+		},
+		Handler: makeSummarizeURLHandler(c, fetcher),
+	}
+}
+
+func makeSummarizeURLHandler(c ChatCompleter, fetcher HTTPDoer) Handler {
+	return func(ctx context.Context, args json.RawMessage) ToolResult {
+		if c == nil {
+			return unavailable("summarize_url")
+		}
+		if fetcher == nil {
+			fetcher = summarizeURLClient
+		}
+		var a summarizeURLArgs
+		if err := json.Unmarshal(args, &a); err != nil {
+			return ToolResult{Success: false, Error: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+		if a.URL == "" {
+			return ToolResult{Success: false, Error: "url is required"}
+		}
+		if err := validatePublicURL(a.URL); err != nil {
+			slog.Warn("summarize_url target rejected",
+				"component", "tool",
+				"operation", "summarize_url",
+				"url", a.URL,
+				"error", err,
+			)
+			return ToolResult{Success: false, Error: err.Error()}
+		}
+		maxWords := a.MaxWords
+		if maxWords <= 0 {
+			maxWords = defaultSummaryWords
+		}
+		maxWords = min(maxWords, maxSummaryWords)
+
+		title, text, err := fetchReadableText(ctx, fetcher, a.URL)
+		if err != nil {
+			slog.Warn("summarize_url fetch failed",
+				"component", "tool",
+				"operation", "summarize_url",
+				"url", a.URL,
+				"error", err,
+			)
+			return ToolResult{Success: false, Error: err.Error()}
+		}
+		if text == "" {
+			return ToolResult{Success: false, Error: fmt.Sprintf("no readable text found at %s", a.URL)}
+		}
+
+		summary, err := summarizeText(ctx, c, a.URL, title, text, maxWords)
+		if err != nil {
+			slog.Error("summarize_url summary failed",
+				"component", "tool",
+				"operation", "summarize_url",
+				"url", a.URL,
+				"error", err,
+			)
+			return ToolResult{Success: false, Error: err.Error()}
+		}
+
+		slog.Info("url summarized",
+			"component", "tool",
+			"operation", "summarize_url",
+			"url", a.URL,
+			"text_runes", len([]rune(text)),
+			"summary_words", len(strings.Fields(summary)),
+		)
+		heading := "Summary of " + a.URL
+		if title != "" {
+			heading += " (" + title + ")"
+		}
+		return ToolResult{Success: true, Output: heading + ":\n\n" + summary}
+	}
+}
+
+// fetchReadableText downloads target and returns its title and readable text.
+// HTML is converted with htmlToMarkdown; plain text and markdown are kept as is.
+func fetchReadableText(ctx context.Context, fetcher HTTPDoer, target string) (title, text string, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid URL: %w", err)
+	}
+	req.Header.Set("Accept", "text/html, text/plain;q=0.9, */*;q=0.1")
+	resp, err := fetcher.Do(req)
+	if err != nil {
+		if errors.Is(err, errBlockedAddress) {
+			return "", "", err
+		}
+		return "", "", fmt.Errorf("fetch %s: %w", target, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", "", fmt.Errorf("fetch %s: HTTP %d", target, resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, summarizeFetchLimit))
+	if err != nil {
+		return "", "", fmt.Errorf("fetch %s: %w", target, err)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	switch {
+	case mediaType == "text/html" || mediaType == "application/xhtml+xml":
+		page := string(body)
+		return htmlTitle(page), htmlToMarkdown(page), nil
+	case mediaType == "" || strings.HasPrefix(mediaType, "text/"):
+		return "", strings.TrimSpace(string(body)), nil
+	default:
+		return "", "", fmt.Errorf("fetch %s: unsupported content type %q", target, mediaType)
+	}
+}
+
+// summarizeText asks c for a summary of text of at most maxWords words, and
+// cuts the reply at maxWords if the model overshoots.
+func summarizeText(ctx context.Context, c ChatCompleter, target, title, text string, maxWords int) (string, error) {
+	if runes := []rune(text); len(runes) > summarizeInputLimit {
+		text = string(runes[:summarizeInputLimit]) + "\n\n[page truncated]"
+	}
+	page := "Page: " + target
+	if title != "" {
+		page += "\nTitle: " + title
+	}
+	messages := []llm.Message{
+		{Role: "system", Content: fmt.Sprintf("Summarize the web page below in at most %d words. Keep the key facts, figures and conclusions; leave out navigation, ads and boilerplate. Reply with the summary only, in the page's language, without a preamble.", maxWords)},
+		{Role: "user", Content: page + "\n\n" + text},
+	}
+	resp, err := c.ChatCompletionWithRetry(ctx, messages, nil)
+	if err != nil {
+		return "", fmt.Errorf("summarize: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return "", errors.New("summarize: LLM returned no choices")
+	}
+	// The agent's client may enforce its JSON reply schema; take the message content.
+	parsed, err := llm.ParseAgentResponse(resp.Choices[0].Message.Content)
+	if err != nil {
+		return "", fmt.Errorf("summarize: %w", err)
+	}
+	summary := strings.TrimSpace(parsed.Content)
+	if summary == "" {
+		return "", errors.New("summarize: LLM returned an empty summary")
+	}
+	if words := strings.Fields(summary); len(words) > maxWords {
+		summary = strings.Join(words[:maxWords], " ") + "…"
+	}
+	return summary, nil
+}
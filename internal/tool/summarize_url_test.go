@@ -0,0 +1,181 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/edouard/pureclaw/internal/llm"
+)
+
+// fakeCompleter returns reply (or err) and records the messages it was sent.
+type fakeCompleter struct {
+	reply    string
+	err      error
+	messages []llm.Message
+}
+
+func (f *fakeCompleter) ChatCompletionWithRetry(ctx context.Context, messages []llm.Message, tools []llm.Tool) (*llm.ChatResponse, error) {
+	f.messages = messages
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &llm.ChatResponse{Choices: []llm.Choice{{Message: llm.Message{Content: f.reply}}}}, nil
+}
+
+// allowLoopback lets the public client reach httptest servers.
+func allowLoopback(t *testing.T) {
+	t.Helper()
+	orig := blockedIP
+	t.Cleanup(func() { blockedIP = orig })
+	blockedIP = func(net.IP) bool { return false }
+}
+
+func longArticle() string {
+	var b strings.Builder
+	b.WriteString(`<!DOCTYPE html><html><head><title>Pi clusters &amp; you</title>
+<script>trackVisitor("SECRET_TRACKER")</script><style>body{color:red}</style></head>
+<body><nav><a href="/">Home</a> | <a href="/about">About</a></nav>
+<article><h1>Building a Pi cluster</h1>`)
+	for range 200 {
+		b.WriteString("<p>Four Raspberry Pi boards share one switch and a <a href=\"https://example.com/k3s\">k3s</a> control plane.</p>\n")
+	}
+	b.WriteString(`<ul><li>Cost: 240 euros</li><li>Power: 20 W</li></ul></article>
+<footer>Copyright FOOTER_TEXT</footer></body></html>`)
+	return b.String()
+}
+
+func TestSummarizeURL_SummarizesArticle(t *testing.T) {
+	allowLoopback(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(longArticle()))
+	}))
+	defer srv.Close()
+	c := &fakeCompleter{reply: `{"type":"message","content":"A four-node Pi cluster running k3s for 240 euros."}`}
+
+	res := NewSummarizeURL(c, nil).Handler(context.Background(), json.RawMessage(`{"url":"`+srv.URL+`/post","max_words":80}`))
+
+	if !res.Success {
+		t.Fatalf("expected success, got error %q", res.Error)
+	}
+	if !strings.Contains(res.Output, "A four-node Pi cluster running k3s for 240 euros.") || !strings.Contains(res.Output, "(Pi clusters & you)") {
+		t.Errorf("output = %q, want the summary under the page title", res.Output)
+	}
+	if len(c.messages) != 2 || !strings.Contains(c.messages[0].Content, "at most 80 words") {
+		t.Fatalf("messages = %+v, want a system prompt with the word limit", c.messages)
+	}
+	page := c.messages[1].Content
+	for _, want := range []string{"# Building a Pi cluster", "[k3s](https://example.com/k3s)", "- Cost: 240 euros"} {
+		if !strings.Contains(page, want) {
+			t.Errorf("page text lacks %q", want)
+		}
+	}
+	for _, unwanted := range []string{"SECRET_TRACKER", "color:red", "FOOTER_TEXT", "About", "<p>"} {
+		if strings.Contains(page, unwanted) {
+			t.Errorf("page text contains %q, want it stripped", unwanted)
+		}
+	}
+}
+
+func TestSummarizeURL_CutsLongSummary(t *testing.T) {
+	allowLoopback(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("Plain text release notes."))
+	}))
+	defer srv.Close()
+	c := &fakeCompleter{reply: strings.Repeat("word ", 50)}
+
+	res := NewSummarizeURL(c, nil).Handler(context.Background(), json.RawMessage(`{"url":"`+srv.URL+`","max_words":10}`))
+
+	if !res.Success {
+		t.Fatalf("expected success, got error %q", res.Error)
+	}
+	_, summary, _ := strings.Cut(res.Output, ":\n\n")
+	if n := len(strings.Fields(summary)); n != 10 || !strings.HasSuffix(summary, "…") {
+		t.Errorf("summary = %q (%d words), want it cut to 10 words", summary, n)
+	}
+}
+
+func TestSummarizeURL_RejectsInternalAddresses(t *testing.T) {
+	for _, target := range []string{"http://10.0.0.5/admin", "http://127.0.0.1:8080/", "http://localhost/", "file:///etc/passwd"} {
+		t.Run(target, func(t *testing.T) {
+			c := &fakeCompleter{reply: "unused"}
+			res := NewSummarizeURL(c, nil).Handler(context.Background(), json.RawMessage(`{"url":"`+target+`"}`))
+			if res.Success {
+				t.Fatalf("expected %s to be rejected", target)
+			}
+			if c.messages != nil {
+				t.Error("LLM called for a rejected URL")
+			}
+		})
+	}
+
+	// A server on a loopback address is refused when dialled, whatever the URL says.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("blocked server was reached")
+	}))
+	defer srv.Close()
+	_, _, err := fetchReadableText(context.Background(), summarizeURLClient, srv.URL)
+	if !errors.Is(err, errBlockedAddress) {
+		t.Errorf("fetch of loopback server: err = %v, want errBlockedAddress", err)
+	}
+}
+
+func TestSummarizeURL_Errors(t *testing.T) {
+	allowLoopback(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing":
+			http.NotFound(w, r)
+		case "/image":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte{0x89, 'P', 'N', 'G'})
+		case "/empty":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html><body><script>x()</script></body></html>"))
+		default:
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte("Some text."))
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name string
+		c    ChatCompleter
+		args string
+		want string
+	}{
+		{"NoLLM", nil, `{"url":"` + srv.URL + `"}`, "not available"},
+		{"MissingURL", &fakeCompleter{}, `{}`, "url is required"},
+		{"NotFound", &fakeCompleter{}, `{"url":"` + srv.URL + `/missing"}`, "HTTP 404"},
+		{"Binary", &fakeCompleter{}, `{"url":"` + srv.URL + `/image"}`, `unsupported content type "image/png"`},
+		{"NoText", &fakeCompleter{}, `{"url":"` + srv.URL + `/empty"}`, "no readable text"},
+		{"LLMError", &fakeCompleter{err: errors.New("rate limited")}, `{"url":"` + srv.URL + `"}`, "rate limited"},
+		{"EmptySummary", &fakeCompleter{reply: " "}, `{"url":"` + srv.URL + `"}`, "empty summary"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := NewSummarizeURL(tt.c, nil).Handler(context.Background(), json.RawMessage(tt.args))
+			if res.Success || !strings.Contains(res.Error, tt.want) {
+				t.Errorf("result = %+v, want failure containing %q", res, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTMLToMarkdown(t *testing.T) {
+	page := `<html><body><main><h2>Intro</h2><p>Hello&nbsp;<b>world</b>.</p>
+<ul><li><p>one</p></li><li>two</li></ul><a href="/rel">relative</a></main></body></html>`
+	want := "## Intro\n\nHello world.\n\n- one\n\n- two\nrelative"
+	if got := htmlToMarkdown(page); got != want {
+		t.Errorf("htmlToMarkdown() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,205 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/edouard/pureclaw/internal/platform"
+)
+
+const (
+	defaultWaitForTimeout  = time.Minute
+	maxWaitForTimeout      = 10 * time.Minute
+	defaultWaitForInterval = 2 * time.Second
+	minWaitForInterval     = 100 * time.Millisecond
+	waitForProbeTimeout    = 10 * time.Second // bound on a single HTTP request
+)
+
+// Replaceable for testing.
+var waitForClient = newPublicClient(waitForProbeTimeout)
+
+type waitForArgs struct {
+	Condition       string  `json:"condition"`
+	Target          string  `json:"target"`
+	TimeoutSeconds  float64 `json:"timeout_seconds"`
+	IntervalSeconds float64 `json:"interval_seconds"`
+}
+
+// waitCheck reports whether a condition holds. A non-nil error aborts the wait.
+type waitCheck func(ctx context.Context) (bool, error)
+
+// NewWaitFor creates a wait_for tool that polls a file or URL until a
+// condition holds or the wait times out. secrets are masked in its results.
+// Commands are not polled: they would bypass exec_command's confirmation and
+// tools.json settings.
+func NewWaitFor(secrets []string) Definition {
+	return Definition{
+		Name:        "wait_for",
+		Description: "Wait until a condition holds: a file exists in the workspace or a public http(s) URL answers 200. Polls until the condition is met or the timeout elapses.",
+		Parameters: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"condition": map[string]any{
+					"type":        "string",
+					"enum":        []string{"file_exists", "http_ok"},
+					"description": "What to wait for",
+				},
+				"target": map[string]any{
+					"type":        "string",
+					"description": "File path (relative to the workspace root) or URL, depending on condition",
+				},
+				"timeout_seconds": map[string]any{
+					"type":        "number",
+					"description": "How long to wait before giving up (default 60, max 600)",
+				},
+				"interval_seconds": map[string]any{
+					"type":        "number",
+					"description": "Delay between checks (default 2, min 0.1)",
+				},
+			},
+			"required": []string{"condition", "target"},
+		},
+		Handler: makeWaitForHandler(secrets),
+	}
+}
+
+func makeWaitForHandler(secrets []string) Handler {
+	redactor := platform.SecretRedactor(secrets)
+	return func(ctx context.Context, args json.RawMessage) ToolResult {
+		var a waitForArgs
+		if err := json.Unmarshal(args, &a); err != nil {
+			return ToolResult{Success: false, Error: fmt.Sprintf("invalid arguments: %v", err)}
+		}
+		if a.Target == "" {
+			return ToolResult{Success: false, Error: "target is required"}
+		}
+
+		var check waitCheck
+		switch a.Condition {
+		case "file_exists":
+			path, err := waitForPath(ctx, a.Target)
+			if err != nil {
+				return ToolResult{Success: false, Error: err.Error()}
+			}
+			check = fileExists(path)
+		case "http_ok":
+			if err := validatePublicURL(a.Target); err != nil {
+				return ToolResult{Success: false, Error: err.Error()}
+			}
+			check = httpOK(a.Target)
+		default:
+			return ToolResult{Success: false, Error: fmt.Sprintf("unknown condition %q: use file_exists or http_ok", a.Condition)}
+		}
+
+		timeout := secondsOr(a.TimeoutSeconds, defaultWaitForTimeout)
+		timeout = min(timeout, maxWaitForTimeout)
+		interval := max(secondsOr(a.IntervalSeconds, defaultWaitForInterval), minWaitForInterval)
+
+		platform.Log(ctx).Info("waiting for condition",
+			"component", "tool",
+			"operation", "wait_for",
+			"condition", a.Condition,
+			"timeout", timeout,
+			"interval", interval,
+		)
+		start := time.Now()
+		err := poll(ctx, check, timeout, interval)
+		elapsed := time.Since(start).Round(time.Millisecond)
+		if err != nil {
+			platform.Log(ctx).Warn("wait_for failed",
+				"component", "tool",
+				"operation", "wait_for",
+				"condition", a.Condition,
+				"elapsed", elapsed,
+				"error", err,
+			)
+			return ToolResult{Success: false, Error: redactor.Redact(err.Error())}
+		}
+		return ToolResult{Success: true, Output: fmt.Sprintf("condition %s met for %s after %s", a.Condition, redactor.Redact(a.Target), elapsed)}
+	}
+}
+
+// poll runs check every interval until it reports true, it fails, timeout
+// elapses, or ctx is done.
+func poll(parent context.Context, check waitCheck, timeout, interval time.Duration) error {
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		ok, err := check(ctx)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			if err := parent.Err(); err != nil {
+				return fmt.Errorf("wait cancelled: %w", err)
+			}
+			return fmt.Errorf("condition not met within %s", timeout)
+		}
+	}
+}
+
+// secondsOr converts a seconds argument to a duration, using def when it is not positive.
+func secondsOr(seconds float64, def time.Duration) time.Duration {
+	if seconds <= 0 {
+		return def
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// waitForPath resolves a file_exists target against the workspace root and
+// rejects paths escaping it.
+func waitForPath(ctx context.Context, target string) (string, error) {
+	tc, _ := FromContext(ctx)
+	if tc.WorkspaceRoot == "" {
+		return "", fmt.Errorf("file_exists is not supported without a workspace")
+	}
+	path := target
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(tc.WorkspaceRoot, path)
+	}
+	if err := platform.ValidatePath(tc.WorkspaceRoot, path); err != nil {
+		return "", fmt.Errorf("target %q: %w", target, err)
+	}
+	return path, nil
+}
+
+func fileExists(path string) waitCheck {
+	return func(context.Context) (bool, error) {
+		_, err := os.Stat(path)
+		return err == nil, nil
+	}
+}
+
+func httpOK(target string) waitCheck {
+	return func(ctx context.Context) (bool, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+		if err != nil {
+			return false, fmt.Errorf("invalid URL: %w", err)
+		}
+		resp, err := waitForClient.Do(req)
+		if err != nil {
+			if errors.Is(err, errBlockedAddress) {
+				return false, err
+			}
+			return false, nil // not reachable yet
+		}
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+		resp.Body.Close()
+		return resp.StatusCode == http.StatusOK, nil
+	}
+}
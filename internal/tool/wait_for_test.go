@@ -0,0 +1,158 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func waitForCtx(t *testing.T) (context.Context, string) {
+	t.Helper()
+	root := t.TempDir()
+	return WithContext(context.Background(), ToolContext{WorkspaceRoot: root}), root
+}
+
+func TestWaitFor_FileAppearsMidWait(t *testing.T) {
+	ctx, root := waitForCtx(t)
+	go func() {
+		time.Sleep(150 * time.Millisecond)
+		_ = os.WriteFile(filepath.Join(root, "done.flag"), []byte("ok"), 0o644)
+	}()
+
+	res := NewWaitFor(nil).Handler(ctx, json.RawMessage(`{"condition":"file_exists","target":"done.flag","timeout_seconds":5,"interval_seconds":0.1}`))
+	if !res.Success {
+		t.Fatalf("expected success, got error %q", res.Error)
+	}
+	if !strings.Contains(res.Output, "file_exists") {
+		t.Errorf("output = %q, want the condition named", res.Output)
+	}
+}
+
+func TestWaitFor_NeverSatisfiedTimesOut(t *testing.T) {
+	ctx, _ := waitForCtx(t)
+
+	start := time.Now()
+	res := NewWaitFor(nil).Handler(ctx, json.RawMessage(`{"condition":"file_exists","target":"never.flag","timeout_seconds":0.3,"interval_seconds":0.1}`))
+	if res.Success {
+		t.Fatal("expected timeout failure")
+	}
+	if !strings.Contains(res.Error, "not met within") {
+		t.Errorf("error = %q, want timeout message", res.Error)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("wait took %s, want about 300ms", elapsed)
+	}
+}
+
+func TestWaitFor_RespectsContextCancellation(t *testing.T) {
+	ctx, _ := waitForCtx(t)
+	ctx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+	defer cancel()
+
+	res := NewWaitFor(nil).Handler(ctx, json.RawMessage(`{"condition":"file_exists","target":"never.flag","timeout_seconds":60,"interval_seconds":0.1}`))
+	if res.Success || !strings.Contains(res.Error, "cancelled") {
+		t.Fatalf("got %+v, want a cancellation error when the tool context expires", res)
+	}
+}
+
+func TestWaitFor_PathOutsideWorkspace(t *testing.T) {
+	ctx, _ := waitForCtx(t)
+	for _, target := range []string{"../outside.flag", "/etc/passwd"} {
+		res := NewWaitFor(nil).Handler(ctx, json.RawMessage(`{"condition":"file_exists","target":"`+target+`"}`))
+		if res.Success || !strings.Contains(res.Error, "outside allowed root") {
+			t.Errorf("target %q: got %+v, want path rejected", target, res)
+		}
+	}
+}
+
+func TestWaitFor_FileWithoutWorkspace(t *testing.T) {
+	res := NewWaitFor(nil).Handler(context.Background(), json.RawMessage(`{"condition":"file_exists","target":"x"}`))
+	if res.Success {
+		t.Fatal("expected failure without a workspace")
+	}
+}
+
+func TestWaitFor_URLValidation(t *testing.T) {
+	tests := []string{
+		"file:///etc/passwd",
+		"ftp://example.com/",
+		"http://localhost:8080/",
+		"http://127.0.0.1/",
+		"http://10.0.0.1/",
+		"http://192.168.1.1/",
+		"http://169.254.169.254/latest/meta-data/",
+		"http://[::1]/",
+		"http:///nohost",
+	}
+	for _, target := range tests {
+		res := NewWaitFor(nil).Handler(context.Background(), json.RawMessage(`{"condition":"http_ok","target":"`+target+`"}`))
+		if res.Success {
+			t.Errorf("target %q: expected rejection", target)
+		}
+	}
+}
+
+func TestWaitFor_HTTPDialGuard(t *testing.T) {
+	// Addresses are checked after DNS resolution, so a hostname pointing at an
+	// internal address is rejected when dialled.
+	err := dialControl("tcp", "127.0.0.1:80", nil)
+	if !errors.Is(err, errBlockedAddress) {
+		t.Errorf("dialControl(loopback) = %v, want errBlockedAddress", err)
+	}
+	if err := dialControl("tcp", "93.184.216.34:443", nil); err != nil {
+		t.Errorf("dialControl(public) = %v, want nil", err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	if ok, err := httpOK(srv.URL)(context.Background()); ok || !errors.Is(err, errBlockedAddress) {
+		t.Errorf("httpOK(loopback server) = %v, %v; want blocked", ok, err)
+	}
+}
+
+func TestWaitFor_HTTPOK(t *testing.T) {
+	orig := blockedIP
+	t.Cleanup(func() { blockedIP = orig })
+	blockedIP = func(net.IP) bool { return false }
+
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	res := NewWaitFor(nil).Handler(context.Background(), json.RawMessage(`{"condition":"http_ok","target":"`+srv.URL+`","timeout_seconds":5,"interval_seconds":0.1}`))
+	if !res.Success {
+		t.Fatalf("expected success, got error %q", res.Error)
+	}
+	if n := hits.Load(); n != 3 {
+		t.Errorf("hits = %d, want 3", n)
+	}
+}
+
+func TestWaitFor_InvalidArguments(t *testing.T) {
+	tests := map[string]string{
+		"bad json":          `{`,
+		"missing target":    `{"condition":"file_exists"}`,
+		"unknown condition": `{"condition":"port_open","target":"x"}`,
+		"command condition": `{"condition":"command_succeeds","target":"pgrep backup"}`,
+	}
+	for name, args := range tests {
+		t.Run(name, func(t *testing.T) {
+			if res := NewWaitFor(nil).Handler(context.Background(), json.RawMessage(args)); res.Success {
+				t.Errorf("expected failure for %s", args)
+			}
+		})
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/edouard/pureclaw/internal/platform"
 )
@@ -22,8 +24,9 @@ type writeFileArgs struct {
 	Content string `json:"content"`
 }
 
-// NewWriteFile returns the definition for the write_file tool.
-func NewWriteFile() Definition {
+// NewWriteFile returns the definition for the write_file tool. With allowed
+// extensions (e.g. ".md"), it refuses to write any other kind of file.
+func NewWriteFile(allowed ...string) Definition {
 	return Definition{
 		Name:        "write_file",
 		Description: "Write content to a file at the given path using atomic write",
@@ -41,11 +44,36 @@ func NewWriteFile() Definition {
 			},
 			"required": []string{"path", "content"},
 		},
-		Handler: handleWriteFile,
+		Handler: makeWriteFileHandler(allowed),
 	}
 }
 
-func handleWriteFile(ctx context.Context, args json.RawMessage) ToolResult {
+func makeWriteFileHandler(allowed []string) Handler {
+	return func(ctx context.Context, args json.RawMessage) ToolResult {
+		return handleWriteFile(ctx, args, allowed)
+	}
+}
+
+// checkWriteExtension returns a policy error when path's extension is not in
+// allowed. Extensions compare case-insensitively, with or without the leading
+// dot; an empty allowed list permits everything.
+func checkWriteExtension(path string, allowed []string) error {
+	if len(allowed) == 0 {
+		return nil
+	}
+	ext := strings.ToLower(filepath.Ext(path))
+	if slices.ContainsFunc(allowed, func(a string) bool {
+		return ext != "" && strings.ToLower("."+strings.TrimPrefix(a, ".")) == ext
+	}) {
+		return nil
+	}
+	if ext == "" {
+		ext = "(none)"
+	}
+	return fmt.Errorf("policy: file extension %s is not allowed for writes (allowed: %s)", ext, strings.Join(allowed, ", "))
+}
+
+func handleWriteFile(ctx context.Context, args json.RawMessage, allowed []string) ToolResult {
 	var a writeFileArgs
 	if err := json.Unmarshal(args, &a); err != nil {
 		slog.Warn("invalid arguments",
@@ -64,6 +92,15 @@ func handleWriteFile(ctx context.Context, args json.RawMessage) ToolResult {
 		return ToolResult{Success: false, Error: "invalid arguments: path is required"}
 	}
 
+	if err := checkWriteExtension(a.Path, allowed); err != nil {
+		slog.Warn("write rejected by extension policy",
+			"component", "tool",
+			"operation", "write_file",
+			"path", a.Path,
+		)
+		return ToolResult{Success: false, Error: err.Error()}
+	}
+
 	slog.Info("writing file",
 		"component", "tool",
 		"operation", "write_file",
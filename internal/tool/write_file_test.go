@@ -14,7 +14,7 @@ func TestWriteFile_Success(t *testing.T) {
 	path := filepath.Join(dir, "output.txt")
 
 	args, _ := json.Marshal(writeFileArgs{Path: path, Content: "hello world"})
-	result := handleWriteFile(context.Background(), args)
+	result := handleWriteFile(context.Background(), args, nil)
 
 	if !result.Success {
 		t.Fatalf("expected success=true, got false, error: %s", result.Error)
@@ -38,7 +38,7 @@ func TestWriteFile_CreatesDirs(t *testing.T) {
 	path := filepath.Join(dir, "a", "b", "c", "deep.txt")
 
 	args, _ := json.Marshal(writeFileArgs{Path: path, Content: "nested"})
-	result := handleWriteFile(context.Background(), args)
+	result := handleWriteFile(context.Background(), args, nil)
 
 	if !result.Success {
 		t.Fatalf("expected success=true, got false, error: %s", result.Error)
@@ -59,14 +59,14 @@ func TestWriteFile_OverwriteExisting(t *testing.T) {
 
 	// First write.
 	args1, _ := json.Marshal(writeFileArgs{Path: path, Content: "first"})
-	result1 := handleWriteFile(context.Background(), args1)
+	result1 := handleWriteFile(context.Background(), args1, nil)
 	if !result1.Success {
 		t.Fatalf("first write failed: %s", result1.Error)
 	}
 
 	// Second write (overwrite).
 	args2, _ := json.Marshal(writeFileArgs{Path: path, Content: "second"})
-	result2 := handleWriteFile(context.Background(), args2)
+	result2 := handleWriteFile(context.Background(), args2, nil)
 	if !result2.Success {
 		t.Fatalf("second write failed: %s", result2.Error)
 	}
@@ -81,7 +81,7 @@ func TestWriteFile_OverwriteExisting(t *testing.T) {
 }
 
 func TestWriteFile_InvalidArgs(t *testing.T) {
-	result := handleWriteFile(context.Background(), json.RawMessage(`{invalid`))
+	result := handleWriteFile(context.Background(), json.RawMessage(`{invalid`), nil)
 
 	if result.Success {
 		t.Fatal("expected success=false for invalid args")
@@ -96,7 +96,7 @@ func TestWriteFile_EmptyContent(t *testing.T) {
 	path := filepath.Join(dir, "empty.txt")
 
 	args, _ := json.Marshal(writeFileArgs{Path: path, Content: ""})
-	result := handleWriteFile(context.Background(), args)
+	result := handleWriteFile(context.Background(), args, nil)
 
 	if !result.Success {
 		t.Fatalf("expected success=true for empty content, got false, error: %s", result.Error)
@@ -113,7 +113,7 @@ func TestWriteFile_EmptyContent(t *testing.T) {
 
 func TestWriteFile_EmptyPath(t *testing.T) {
 	args, _ := json.Marshal(writeFileArgs{Path: "", Content: "data"})
-	result := handleWriteFile(context.Background(), args)
+	result := handleWriteFile(context.Background(), args, nil)
 
 	if result.Success {
 		t.Fatal("expected success=false for empty path")
@@ -148,7 +148,7 @@ func TestWriteFile_MkdirAllError(t *testing.T) {
 	defer func() { osMkdirAll = original }()
 
 	args, _ := json.Marshal(writeFileArgs{Path: "/fake/path/file.txt", Content: "data"})
-	result := handleWriteFile(context.Background(), args)
+	result := handleWriteFile(context.Background(), args, nil)
 
 	if result.Success {
 		t.Fatal("expected success=false on mkdir error")
@@ -168,7 +168,7 @@ func TestWriteFile_AtomicWriteError(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "file.txt")
 	args, _ := json.Marshal(writeFileArgs{Path: path, Content: "data"})
-	result := handleWriteFile(context.Background(), args)
+	result := handleWriteFile(context.Background(), args, nil)
 
 	if result.Success {
 		t.Fatal("expected success=false on atomic write error")
@@ -177,3 +177,38 @@ func TestWriteFile_AtomicWriteError(t *testing.T) {
 		t.Errorf("expected error to contain 'permission denied', got %q", result.Error)
 	}
 }
+
+func TestWriteFile_AllowedExtensions(t *testing.T) {
+	dir := t.TempDir()
+	handler := NewWriteFile(".md", "TXT").Handler
+
+	for _, name := range []string{"notes.md", "README.MD", "log.txt"} {
+		args, _ := json.Marshal(writeFileArgs{Path: filepath.Join(dir, name), Content: "ok"})
+		if result := handler(context.Background(), args); !result.Success {
+			t.Errorf("write %s: expected success, got error %q", name, result.Error)
+		}
+	}
+
+	for _, name := range []string{"deploy.sh", "Makefile"} {
+		path := filepath.Join(dir, name)
+		args, _ := json.Marshal(writeFileArgs{Path: path, Content: "rm -rf /"})
+		result := handler(context.Background(), args)
+		if result.Success {
+			t.Errorf("write %s: expected policy rejection", name)
+		}
+		if !strings.Contains(result.Error, "policy") {
+			t.Errorf("write %s: error = %q, want a policy error", name, result.Error)
+		}
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s was written despite the policy", name)
+		}
+	}
+}
+
+func TestWriteFile_NoAllowedExtensionsAllowsAll(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "deploy.sh")
+	args, _ := json.Marshal(writeFileArgs{Path: path, Content: "echo hi"})
+	if result := NewWriteFile().Handler(context.Background(), args); !result.Success {
+		t.Fatalf("expected success with no extension policy, got %q", result.Error)
+	}
+}
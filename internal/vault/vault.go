@@ -8,6 +8,7 @@ import (
 	"log/slog"
 	"os"
 	"sort"
+	"time"
 
 	"github.com/edouard/pureclaw/internal/platform"
 )
@@ -25,12 +26,39 @@ const vaultFilePerm = 0600
 var (
 	atomicWrite      = platform.AtomicWrite
 	jsonMarshalIndent = func(v any, prefix, indent string) ([]byte, error) { return json.MarshalIndent(v, prefix, indent) }
+	timeNow           = time.Now
 )
 
 // vaultFile is the on-disk JSON representation of the vault.
 type vaultFile struct {
-	Salt    string            `json:"salt"`
-	Entries map[string]string `json:"entries"`
+	Salt    string                `json:"salt"`
+	Entries map[string]vaultEntry `json:"entries"`
+}
+
+// vaultEntry is the on-disk form of one secret. Vaults written before entries
+// carried timestamps store the base64 ciphertext alone, as a JSON string.
+type vaultEntry struct {
+	Ciphertext string    `json:"ciphertext"`
+	CreatedAt  time.Time `json:"created_at,omitzero"`
+	UpdatedAt  time.Time `json:"updated_at,omitzero"`
+}
+
+// UnmarshalJSON decodes an entry in either the current or the string-only format.
+func (e *vaultEntry) UnmarshalJSON(b []byte) error {
+	var ciphertext string
+	if err := json.Unmarshal(b, &ciphertext); err == nil {
+		*e = vaultEntry{Ciphertext: ciphertext}
+		return nil
+	}
+	type plain vaultEntry
+	return json.Unmarshal(b, (*plain)(e))
+}
+
+// Metadata records when a secret was first stored and last changed. Both are
+// zero for secrets stored before the vault recorded them.
+type Metadata struct {
+	CreatedAt time.Time
+	UpdatedAt time.Time
 }
 
 // Vault holds encrypted secrets in memory and persists them to disk.
@@ -38,7 +66,8 @@ type Vault struct {
 	key     []byte
 	path    string
 	salt    []byte
-	entries map[string][]byte // key name → encrypted value
+	entries map[string][]byte   // key name → encrypted value
+	meta    map[string]Metadata // key name → timestamps, when known
 }
 
 // LoadSalt reads just the salt from an existing vault file.
@@ -67,6 +96,7 @@ func Create(derivedKey []byte, salt []byte, path string) (*Vault, error) {
 		path:    path,
 		salt:    salt,
 		entries: make(map[string][]byte),
+		meta:    make(map[string]Metadata),
 	}
 	if err := v.save(); err != nil {
 		return nil, fmt.Errorf("vault: create: %w", err)
@@ -81,6 +111,7 @@ func Open(derivedKey []byte, path string) (*Vault, error) {
 		key:     derivedKey,
 		path:    path,
 		entries: make(map[string][]byte),
+		meta:    make(map[string]Metadata),
 	}
 	if err := v.load(); err != nil {
 		return nil, err
@@ -89,6 +120,16 @@ func Open(derivedKey []byte, path string) (*Vault, error) {
 	return v, nil
 }
 
+// CleanupStaleTemps removes temp files left by a vault save that was interrupted
+// before its atomic rename. The vault file itself is never touched. A sub-agent
+// or CLI command may be saving the vault whenever it is open, so only call it at
+// agent startup, before anything else opens the vault.
+func CleanupStaleTemps(path string) {
+	if _, err := platform.CleanupStaleTemps(path); err != nil {
+		slog.Warn("vault temp cleanup failed", "component", "vault", "operation", "cleanup", "path", path, "error", err)
+	}
+}
+
 // Get decrypts and returns the value for the given key.
 func (v *Vault) Get(key string) (string, error) {
 	ciphertext, ok := v.entries[key]
@@ -103,13 +144,22 @@ func (v *Vault) Get(key string) (string, error) {
 }
 
 // Set encrypts the value and stores it under the given key, then saves atomically.
+// Overwriting a key keeps its creation time and moves its update time.
 func (v *Vault) Set(key string, value string) error {
 	ciphertext, err := Encrypt(v.key, []byte(value))
 	if err != nil {
 		return fmt.Errorf("vault: set: encrypt: %w", err)
 	}
 	prev, existed := v.entries[key]
+	prevMeta, hadMeta := v.meta[key]
+	now := timeNow().UTC()
+	meta := Metadata{CreatedAt: now, UpdatedAt: now}
+	if existed {
+		// A secret stored before timestamps were recorded keeps an unknown creation time.
+		meta.CreatedAt = prevMeta.CreatedAt
+	}
 	v.entries[key] = ciphertext
+	v.meta[key] = meta
 	if err := v.save(); err != nil {
 		// Rollback in-memory state on save failure.
 		if existed {
@@ -117,6 +167,11 @@ func (v *Vault) Set(key string, value string) error {
 		} else {
 			delete(v.entries, key)
 		}
+		if hadMeta {
+			v.meta[key] = prevMeta
+		} else {
+			delete(v.meta, key)
+		}
 		return fmt.Errorf("vault: set: %w", err)
 	}
 	slog.Info("secret stored", "component", "vault", "operation", "set", "key", key)
@@ -129,16 +184,48 @@ func (v *Vault) Delete(key string) error {
 	if !ok {
 		return ErrKeyNotFound
 	}
+	meta, hadMeta := v.meta[key]
 	delete(v.entries, key)
+	delete(v.meta, key)
 	if err := v.save(); err != nil {
 		// Rollback in-memory state on save failure.
 		v.entries[key] = ciphertext
+		if hadMeta {
+			v.meta[key] = meta
+		}
 		return fmt.Errorf("vault: delete: %w", err)
 	}
 	slog.Info("secret deleted", "component", "vault", "operation", "delete", "key", key)
 	return nil
 }
 
+// Rekey re-encrypts every entry under newKey, records newSalt, and saves once.
+// Every entry is decrypted before anything changes, so a wrong current key
+// fails with ErrDecrypt and leaves the vault untouched; a failed save restores
+// the previous key, salt and entries.
+func (v *Vault) Rekey(newKey, newSalt []byte) error {
+	entries := make(map[string][]byte, len(v.entries))
+	for k, ct := range v.entries {
+		plaintext, err := Decrypt(v.key, ct)
+		if err != nil {
+			return fmt.Errorf("%w: entry %q: %w", ErrDecrypt, k, err)
+		}
+		entries[k], err = Encrypt(newKey, plaintext)
+		if err != nil {
+			return fmt.Errorf("vault: rekey: encrypt %q: %w", k, err)
+		}
+	}
+
+	prevKey, prevSalt, prevEntries := v.key, v.salt, v.entries
+	v.key, v.salt, v.entries = newKey, newSalt, entries
+	if err := v.save(); err != nil {
+		v.key, v.salt, v.entries = prevKey, prevSalt, prevEntries
+		return fmt.Errorf("vault: rekey: %w", err)
+	}
+	slog.Info("vault rekeyed", "component", "vault", "operation", "rekey", "entries", len(entries))
+	return nil
+}
+
 // List returns sorted key names from the vault. No decryption is performed.
 func (v *Vault) List() []string {
 	keys := make([]string, 0, len(v.entries))
@@ -149,14 +236,27 @@ func (v *Vault) List() []string {
 	return keys
 }
 
+// Metadata returns the timestamps recorded for key. It reports false if the
+// key doesn't exist; a key stored before timestamps were recorded has zero times.
+func (v *Vault) Metadata(key string) (Metadata, bool) {
+	if _, ok := v.entries[key]; !ok {
+		return Metadata{}, false
+	}
+	return v.meta[key], true
+}
+
 // save serializes the vault to JSON and writes it atomically.
 func (v *Vault) save() error {
 	f := vaultFile{
 		Salt:    base64.StdEncoding.EncodeToString(v.salt),
-		Entries: make(map[string]string, len(v.entries)),
+		Entries: make(map[string]vaultEntry, len(v.entries)),
 	}
 	for k, ct := range v.entries {
-		f.Entries[k] = base64.StdEncoding.EncodeToString(ct)
+		f.Entries[k] = vaultEntry{
+			Ciphertext: base64.StdEncoding.EncodeToString(ct),
+			CreatedAt:  v.meta[k].CreatedAt,
+			UpdatedAt:  v.meta[k].UpdatedAt,
+		}
 	}
 	data, err := jsonMarshalIndent(f, "", "  ")
 	if err != nil {
@@ -180,12 +280,15 @@ func (v *Vault) load() error {
 		return fmt.Errorf("vault: open: decode salt: %w", err)
 	}
 	v.salt = salt
-	for k, encoded := range f.Entries {
-		ct, err := base64.StdEncoding.DecodeString(encoded)
+	for k, e := range f.Entries {
+		ct, err := base64.StdEncoding.DecodeString(e.Ciphertext)
 		if err != nil {
 			return fmt.Errorf("vault: open: decode entry %q: %w", k, err)
 		}
 		v.entries[k] = ct
+		if !e.CreatedAt.IsZero() || !e.UpdatedAt.IsZero() {
+			v.meta[k] = Metadata{CreatedAt: e.CreatedAt, UpdatedAt: e.UpdatedAt}
+		}
 	}
 	return nil
 }
@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 // testKey derives a key for testing purposes.
@@ -126,7 +127,7 @@ func TestOpen_invalidJSON(t *testing.T) {
 func TestOpen_invalidSaltBase64(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "vault.enc")
-	f := vaultFile{Salt: "!!!not-base64!!!", Entries: map[string]string{}}
+	f := vaultFile{Salt: "!!!not-base64!!!", Entries: map[string]vaultEntry{}}
 	data, _ := json.Marshal(f)
 	if err := os.WriteFile(path, data, 0600); err != nil {
 		t.Fatal(err)
@@ -145,7 +146,7 @@ func TestOpen_invalidEntryBase64(t *testing.T) {
 	path := filepath.Join(dir, "vault.enc")
 	f := vaultFile{
 		Salt:    base64.StdEncoding.EncodeToString([]byte("1234567890123456")),
-		Entries: map[string]string{"key1": "!!!not-base64!!!"},
+		Entries: map[string]vaultEntry{"key1": {Ciphertext: "!!!not-base64!!!"}},
 	}
 	data, _ := json.Marshal(f)
 	if err := os.WriteFile(path, data, 0600); err != nil {
@@ -208,7 +209,7 @@ func TestLoadSalt_invalidJSON(t *testing.T) {
 func TestLoadSalt_invalidBase64(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "vault.enc")
-	f := vaultFile{Salt: "!!!not-base64!!!", Entries: map[string]string{}}
+	f := vaultFile{Salt: "!!!not-base64!!!", Entries: map[string]vaultEntry{}}
 	data, _ := json.Marshal(f)
 	if err := os.WriteFile(path, data, 0600); err != nil {
 		t.Fatal(err)
@@ -648,3 +649,224 @@ func TestVault_deleteAndPersist(t *testing.T) {
 		t.Fatalf("keep = %q, want %q", got, "val1")
 	}
 }
+
+func TestCleanupStaleTemps_keepsVault(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "vault.enc")
+	salt := []byte("1234567890123456")
+	key := DeriveKey("pass", salt)
+
+	v, err := Create(key, salt, path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := v.Set("api_key", "secret-value-123"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	before, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read vault: %v", err)
+	}
+
+	// Simulate a save interrupted before its rename.
+	stale := filepath.Join(dir, "vault.enc.tmp123456")
+	if err := os.WriteFile(stale, []byte(`{"salt":"trunc`), 0600); err != nil {
+		t.Fatalf("write stale temp: %v", err)
+	}
+	unrelated := filepath.Join(dir, "other.tmp1")
+	if err := os.WriteFile(unrelated, []byte("keep"), 0600); err != nil {
+		t.Fatalf("write unrelated: %v", err)
+	}
+
+	// Opening leaves temp files alone: they may belong to another process's save.
+	v2, err := Open(key, path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if _, err := os.Stat(stale); err != nil {
+		t.Fatalf("Open removed a temp file: %v", err)
+	}
+
+	CleanupStaleTemps(path)
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("stale temp file should be removed, stat err = %v", err)
+	}
+	if _, err := os.Stat(unrelated); err != nil {
+		t.Errorf("unrelated file should be kept: %v", err)
+	}
+	after, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read vault: %v", err)
+	}
+	if string(after) != string(before) {
+		t.Error("vault file changed during temp cleanup")
+	}
+	if val, err := v2.Get("api_key"); err != nil || val != "secret-value-123" {
+		t.Errorf("Get = %q, %v; want secret-value-123", val, err)
+	}
+}
+
+func TestVault_Rekey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vault.enc")
+	oldSalt := []byte("1234567890123456")
+	v, err := Create(DeriveKey("old", oldSalt), oldSalt, path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	for k, val := range map[string]string{"a": "alpha", "b": "beta"} {
+		if err := v.Set(k, val); err != nil {
+			t.Fatalf("Set(%q): %v", k, err)
+		}
+	}
+
+	newSalt := []byte("6543210987654321")
+	if err := v.Rekey(DeriveKey("new", newSalt), newSalt); err != nil {
+		t.Fatalf("Rekey failed: %v", err)
+	}
+
+	salt, err := LoadSalt(path)
+	if err != nil || string(salt) != string(newSalt) {
+		t.Fatalf("LoadSalt = %q, %v; want the new salt", salt, err)
+	}
+	reopened, err := Open(DeriveKey("new", newSalt), path)
+	if err != nil {
+		t.Fatalf("Open with new key: %v", err)
+	}
+	if got, err := reopened.Get("b"); err != nil || got != "beta" {
+		t.Errorf("Get(b) = %q, %v; want beta", got, err)
+	}
+	stale, err := Open(DeriveKey("old", oldSalt), path)
+	if err != nil {
+		t.Fatalf("Open with old key: %v", err)
+	}
+	if _, err := stale.Get("a"); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("Get with old key error = %v, want ErrDecrypt", err)
+	}
+}
+
+func TestVault_Rekey_wrongKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vault.enc")
+	salt := []byte("1234567890123456")
+	v, err := Create(DeriveKey("right", salt), salt, path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := v.Set("a", "alpha"); err != nil {
+		t.Fatal(err)
+	}
+	before, _ := os.ReadFile(path)
+
+	wrong, err := Open(DeriveKey("wrong", salt), path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if err := wrong.Rekey(DeriveKey("new", salt), salt); !errors.Is(err, ErrDecrypt) {
+		t.Fatalf("Rekey error = %v, want ErrDecrypt", err)
+	}
+	after, _ := os.ReadFile(path)
+	if string(before) != string(after) {
+		t.Error("vault file changed after a failed rekey")
+	}
+}
+
+func TestVault_Rekey_saveError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vault.enc")
+	salt := []byte("1234567890123456")
+	key := DeriveKey("old", salt)
+	v, err := Create(key, salt, path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := v.Set("a", "alpha"); err != nil {
+		t.Fatal(err)
+	}
+
+	orig := atomicWrite
+	atomicWrite = func(string, []byte, os.FileMode) error {
+		return errors.New("injected write error")
+	}
+	t.Cleanup(func() { atomicWrite = orig })
+
+	newSalt := []byte("6543210987654321")
+	if err := v.Rekey(DeriveKey("new", newSalt), newSalt); err == nil {
+		t.Fatal("expected error when save fails")
+	}
+	if got, err := v.Get("a"); err != nil || got != "alpha" {
+		t.Errorf("Get after failed rekey = %q, %v; want the old key still in use", got, err)
+	}
+	if string(v.salt) != string(salt) {
+		t.Error("salt not rolled back after failed rekey")
+	}
+}
+
+func TestVault_Metadata(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vault.enc")
+	salt := []byte("1234567890123456")
+	key := DeriveKey("pass", salt)
+	v, err := Create(key, salt, path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	created := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	updated := created.Add(48 * time.Hour)
+	orig := timeNow
+	t.Cleanup(func() { timeNow = orig })
+
+	timeNow = func() time.Time { return created }
+	if err := v.Set("key", "value1"); err != nil {
+		t.Fatal(err)
+	}
+	timeNow = func() time.Time { return updated }
+	if err := v.Set("key", "value2"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := Metadata{CreatedAt: created, UpdatedAt: updated}
+	if got, ok := v.Metadata("key"); !ok || got != want {
+		t.Errorf("Metadata = %+v, %v; want %+v", got, ok, want)
+	}
+	reopened, err := Open(key, path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if got, _ := reopened.Metadata("key"); !got.CreatedAt.Equal(created) || !got.UpdatedAt.Equal(updated) {
+		t.Errorf("Metadata after reopen = %+v, want %+v", got, want)
+	}
+	if _, ok := reopened.Metadata("missing"); ok {
+		t.Error("Metadata of a missing key should report false")
+	}
+}
+
+func TestOpen_legacyStringEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vault.enc")
+	salt := []byte("1234567890123456")
+	key := DeriveKey("pass", salt)
+	ct, err := Encrypt(key, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy := `{"salt":"` + base64.StdEncoding.EncodeToString(salt) + `","entries":{"old":"` + base64.StdEncoding.EncodeToString(ct) + `"}}`
+	if err := os.WriteFile(path, []byte(legacy), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := Open(key, path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if got, err := v.Get("old"); err != nil || got != "secret" {
+		t.Errorf("Get = %q, %v; want secret", got, err)
+	}
+	if meta, ok := v.Metadata("old"); !ok || meta != (Metadata{}) {
+		t.Errorf("Metadata = %+v, %v; want zero times for a legacy entry", meta, ok)
+	}
+
+	// Overwriting a legacy entry records an update time but no invented creation time.
+	if err := v.Set("old", "rotated"); err != nil {
+		t.Fatal(err)
+	}
+	if meta, _ := v.Metadata("old"); !meta.CreatedAt.IsZero() || meta.UpdatedAt.IsZero() {
+		t.Errorf("Metadata after overwrite = %+v, want only UpdatedAt set", meta)
+	}
+}
@@ -3,16 +3,26 @@ package watcher
 import (
 	"context"
 	"log/slog"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"time"
 )
 
+// Change reports the workspace files that changed under Root. Several
+// watchers may share one channel, so receivers filter by path.
+type Change struct {
+	Root  string
+	Paths []string // changed, added or removed files, sorted
+}
+
 // Watcher polls workspace files for mtime changes and signals on a channel.
 type Watcher struct {
 	root     string
 	interval time.Duration
 	mtimes   map[string]time.Time
+	pending  map[string]struct{} // changed paths not yet delivered
 }
 
 // New creates a Watcher that polls files under root at the given interval.
@@ -21,13 +31,15 @@ func New(root string, interval time.Duration) *Watcher {
 		root:     root,
 		interval: interval,
 		mtimes:   make(map[string]time.Time),
+		pending:  make(map[string]struct{}),
 	}
 }
 
-// Run polls workspace files at the configured interval, sending a signal on
-// changes whenever any file mtime differs from the last snapshot. It blocks
-// until ctx is cancelled.
-func (w *Watcher) Run(ctx context.Context, changes chan<- struct{}) {
+// Run polls workspace files at the configured interval, sending a Change on
+// changes whenever any file mtime differs from the last snapshot. Changes
+// within one interval are coalesced into a single event. It blocks until ctx
+// is cancelled.
+func (w *Watcher) Run(ctx context.Context, changes chan<- Change) {
 	slog.Info("watcher started",
 		"component", "watcher",
 		"operation", "run",
@@ -55,46 +67,46 @@ func (w *Watcher) Run(ctx context.Context, changes chan<- struct{}) {
 	}
 }
 
-// poll compares current mtimes with stored state and sends a single event if
-// any file changed, appeared, or disappeared.
-func (w *Watcher) poll(changes chan<- struct{}) {
+// poll compares current mtimes with stored state and sends a single Change
+// listing every file that changed, appeared, or disappeared. If the channel
+// is full the paths are kept and sent, with any later ones, at the next poll.
+func (w *Watcher) poll(changes chan<- Change) {
 	current := w.snapshot()
 
-	var changedFile string
-
 	// Check for changed or disappeared files.
 	for path, oldTime := range w.mtimes {
 		newTime, exists := current[path]
 		if !exists || !newTime.Equal(oldTime) {
-			changedFile = path
-			break
+			w.pending[path] = struct{}{}
 		}
 	}
 
 	// Check for new files (present in current but not in old).
-	if changedFile == "" {
-		for path := range current {
-			if _, exists := w.mtimes[path]; !exists {
-				changedFile = path
-				break
-			}
+	for path := range current {
+		if _, exists := w.mtimes[path]; !exists {
+			w.pending[path] = struct{}{}
 		}
 	}
+	w.mtimes = current
 
-	if changedFile != "" {
-		slog.Info("workspace file change detected",
-			"component", "watcher",
-			"operation", "detect_change",
-			"file", changedFile,
-		)
-		// Non-blocking send: skip if channel already has a pending event.
-		select {
-		case changes <- struct{}{}:
-		default:
-		}
+	if len(w.pending) == 0 {
+		return
 	}
+	change := Change{Root: w.root, Paths: slices.Sorted(maps.Keys(w.pending))}
+	slog.Info("workspace file change detected",
+		"component", "watcher",
+		"operation", "detect_change",
+		"root", w.root,
+		"files", change.Paths,
+	)
 
-	w.mtimes = current
+	// Non-blocking send: a pending event (possibly another watcher's) keeps
+	// these paths for the next poll instead of dropping them.
+	select {
+	case changes <- change:
+		clear(w.pending)
+	default:
+	}
 }
 
 // snapshot builds a map of watched file paths to their modification times.
@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
 	"time"
 )
@@ -45,7 +46,7 @@ func TestNew(t *testing.T) {
 func TestRun_DetectsChange(t *testing.T) {
 	root := setupWorkspace(t)
 	w := New(root, testInterval)
-	changes := make(chan struct{}, 1)
+	changes := make(chan Change, 1)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -70,7 +71,7 @@ func TestRun_DetectsChange(t *testing.T) {
 func TestRun_NoChangeNoEvent(t *testing.T) {
 	root := setupWorkspace(t)
 	w := New(root, testInterval)
-	changes := make(chan struct{}, 1)
+	changes := make(chan Change, 1)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -92,7 +93,7 @@ func TestRun_MissingOptionalFiles(t *testing.T) {
 	root := setupWorkspace(t)
 	// No HEARTBEAT.md, no skills/ directory — should not error.
 	w := New(root, testInterval)
-	changes := make(chan struct{}, 1)
+	changes := make(chan Change, 1)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -113,7 +114,7 @@ func TestRun_MissingOptionalFiles(t *testing.T) {
 func TestRun_NewSkillAppears(t *testing.T) {
 	root := setupWorkspace(t)
 	w := New(root, testInterval)
-	changes := make(chan struct{}, 1)
+	changes := make(chan Change, 1)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -141,7 +142,7 @@ func TestRun_SkillRemoved(t *testing.T) {
 	writeFile(t, skillPath, "greeting skill")
 
 	w := New(root, testInterval)
-	changes := make(chan struct{}, 1)
+	changes := make(chan Change, 1)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -168,7 +169,7 @@ func TestRun_SkillRemoved(t *testing.T) {
 func TestRun_ContextCancellation(t *testing.T) {
 	root := setupWorkspace(t)
 	w := New(root, testInterval)
-	changes := make(chan struct{}, 1)
+	changes := make(chan Change, 1)
 
 	ctx, cancel := context.WithCancel(context.Background())
 
@@ -193,7 +194,7 @@ func TestRun_ContextCancellation(t *testing.T) {
 func TestRun_CoalescesMultipleChanges(t *testing.T) {
 	root := setupWorkspace(t)
 	w := New(root, testInterval)
-	changes := make(chan struct{}, 1)
+	changes := make(chan Change, 1)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -226,16 +227,72 @@ func TestRun_CoalescesMultipleChanges(t *testing.T) {
 	}
 }
 
+// touch moves path's mtime forward so the next poll sees it changed.
+func touch(t *testing.T, path string, d time.Duration) {
+	t.Helper()
+	at := time.Now().Add(d)
+	if err := os.Chtimes(path, at, at); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPoll_ChangeListsPaths(t *testing.T) {
+	root := setupWorkspace(t)
+	w := New(root, testInterval)
+	w.mtimes = w.snapshot()
+	changes := make(chan Change, 1)
+
+	touch(t, filepath.Join(root, "SOUL.md"), time.Minute)
+	writeFile(t, filepath.Join(root, "skills", "deploy", "SKILL.md"), "deploy")
+	w.poll(changes)
+
+	got := <-changes
+	want := []string{filepath.Join(root, "SOUL.md"), filepath.Join(root, "skills", "deploy", "SKILL.md")}
+	if got.Root != root || !slices.Equal(got.Paths, want) {
+		t.Errorf("change = %+v, want root %s and paths %v", got, root, want)
+	}
+}
+
+func TestPoll_FullChannelKeepsPaths(t *testing.T) {
+	root := setupWorkspace(t)
+	w := New(root, testInterval)
+	w.mtimes = w.snapshot()
+	changes := make(chan Change, 1)
+	other := Change{Root: "/other", Paths: []string{"/other/AGENT.md"}}
+	changes <- other
+
+	touch(t, filepath.Join(root, "AGENT.md"), time.Minute)
+	w.poll(changes)
+	touch(t, filepath.Join(root, "SOUL.md"), time.Minute)
+	w.poll(changes)
+
+	if got := <-changes; got.Root != other.Root {
+		t.Fatalf("first change = %+v, want the other watcher's", got)
+	}
+	w.poll(changes)
+	got := <-changes
+	want := []string{filepath.Join(root, "AGENT.md"), filepath.Join(root, "SOUL.md")}
+	if got.Root != root || !slices.Equal(got.Paths, want) {
+		t.Errorf("change = %+v, want both paths held back while the channel was full", got)
+	}
+	w.poll(changes)
+	select {
+	case got := <-changes:
+		t.Errorf("unexpected change %+v after delivery", got)
+	default:
+	}
+}
+
 func TestRun_BufferedChannelNonBlocking(t *testing.T) {
 	root := setupWorkspace(t)
 	w := New(root, testInterval)
-	changes := make(chan struct{}, 1)
+	changes := make(chan Change, 1)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
 	// Pre-fill the channel buffer.
-	changes <- struct{}{}
+	changes <- Change{}
 
 	go w.Run(ctx, changes)
 
@@ -273,7 +330,7 @@ func TestRun_SkillDirWithoutSkillMD(t *testing.T) {
 	}
 
 	w := New(root, testInterval)
-	changes := make(chan struct{}, 1)
+	changes := make(chan Change, 1)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -301,7 +358,7 @@ func TestRun_SkillsNonDirectoryIgnored(t *testing.T) {
 	writeFile(t, filepath.Join(skillsDir, "README.md"), "not a skill dir")
 
 	w := New(root, testInterval)
-	changes := make(chan struct{}, 1)
+	changes := make(chan Change, 1)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -362,7 +419,7 @@ func TestSnapshot_SkillsDirPermissionDenied(t *testing.T) {
 
 func TestRun_WorkspaceRootNotFound(t *testing.T) {
 	w := New("/nonexistent/workspace/path", testInterval)
-	changes := make(chan struct{}, 1)
+	changes := make(chan Change, 1)
 
 	ctx, cancel := context.WithCancel(context.Background())
 
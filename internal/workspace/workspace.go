@@ -7,8 +7,12 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
+
+	"github.com/edouard/pureclaw/internal/platform"
 )
 
 // Workspace holds the loaded contents of a pureclaw workspace directory.
@@ -18,23 +22,35 @@ type Workspace struct {
 	SoulMD      string
 	HeartbeatMD string
 	Skills      []Skill
+	MaxSkills   int // Cap on loaded skills, kept so reloads apply it too (0 = unlimited)
 }
 
 // Skill represents a single skill definition loaded from skills/*/SKILL.md.
 type Skill struct {
-	Name    string
-	Content string
+	Name     string
+	Content  string
+	Priority int // From a "priority: N" front-matter line; higher loads first under MaxSkills
 }
 
+// skillEnabledKey is the front-matter key that turns a skill off when set to false.
+const skillEnabledKey = "enabled"
+
 // Load reads a workspace directory and returns a populated Workspace.
 // AGENT.md and SOUL.md are required; HEARTBEAT.md and skills/ are optional.
 func Load(root string) (*Workspace, error) {
+	return LoadWithMaxSkills(root, 0)
+}
+
+// LoadWithMaxSkills is Load with a cap on the number of skills loaded. When there
+// are more skills than maxSkills, the highest-priority ones are kept (ties broken
+// by name) and the rest are skipped with a warning. Zero means unlimited.
+func LoadWithMaxSkills(root string, maxSkills int) (*Workspace, error) {
 	slog.Info("loading workspace",
 		"component", "workspace",
 		"operation", "load",
 		"root", root)
 
-	w := &Workspace{Root: root}
+	w := &Workspace{Root: root, MaxSkills: maxSkills}
 
 	// Required files — error if missing
 	agentData, err := os.ReadFile(filepath.Join(root, "AGENT.md"))
@@ -71,6 +87,7 @@ func Load(root string) (*Workspace, error) {
 			"operation", "load",
 			"error", err)
 	}
+	w.Skills = limitSkills(w.Skills, maxSkills)
 
 	slog.Info("workspace loaded",
 		"component", "workspace",
@@ -109,9 +126,17 @@ func discoverSkills(skillsDir string) ([]Skill, error) {
 			}
 			continue
 		}
+		if !skillEnabled(string(data)) {
+			slog.Debug("skipping disabled skill",
+				"component", "workspace",
+				"operation", "discover_skills",
+				"skill", entry.Name())
+			continue
+		}
 		skills = append(skills, Skill{
-			Name:    entry.Name(),
-			Content: string(data),
+			Name:     entry.Name(),
+			Content:  string(data),
+			Priority: skillPriority(string(data)),
 		})
 	}
 
@@ -122,26 +147,238 @@ func discoverSkills(skillsDir string) ([]Skill, error) {
 	return skills, nil
 }
 
+// limitSkills keeps at most maxSkills skills, preferring higher Priority and then
+// name order, and logs the skipped ones. The result stays sorted by name.
+func limitSkills(skills []Skill, maxSkills int) []Skill {
+	if maxSkills <= 0 || len(skills) <= maxSkills {
+		return skills
+	}
+
+	ranked := slices.Clone(skills)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].Priority != ranked[j].Priority {
+			return ranked[i].Priority > ranked[j].Priority
+		}
+		return ranked[i].Name < ranked[j].Name
+	})
+
+	skipped := make([]string, 0, len(ranked)-maxSkills)
+	for _, s := range ranked[maxSkills:] {
+		skipped = append(skipped, s.Name)
+	}
+	slog.Warn("skill limit reached, skipping skills",
+		"component", "workspace",
+		"operation", "load",
+		"max_skills", maxSkills,
+		"skipped", skipped)
+
+	kept := ranked[:maxSkills]
+	sort.Slice(kept, func(i, j int) bool {
+		return kept[i].Name < kept[j].Name
+	})
+	return kept
+}
+
+// skillPriority reads a "priority: N" line from a leading "---" front-matter block.
+// Skills without front matter or with an invalid value have priority 0.
+func skillPriority(content string) int {
+	value, ok := frontMatterValue(content, "priority")
+	if !ok {
+		return 0
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// skillEnabled reads an "enabled: false" line from a leading "---" front-matter
+// block. Skills without the line or with an invalid value are enabled.
+func skillEnabled(content string) bool {
+	value, ok := frontMatterValue(content, skillEnabledKey)
+	if !ok {
+		return true
+	}
+	enabled, err := strconv.ParseBool(value)
+	return err != nil || enabled
+}
+
+// splitFrontMatter splits content into the lines of its leading "---"
+// front-matter block and the remaining body, starting at the closing "\n---".
+func splitFrontMatter(content string) (header []string, body string, ok bool) {
+	rest, ok := strings.CutPrefix(content, "---\n")
+	if !ok {
+		return nil, content, false
+	}
+	raw, _, ok := strings.Cut(rest, "\n---")
+	if !ok {
+		return nil, content, false
+	}
+	return strings.Split(raw, "\n"), rest[len(raw):], true
+}
+
+// frontMatterValue returns the trimmed value of key in content's front matter.
+func frontMatterValue(content, key string) (string, bool) {
+	header, _, ok := splitFrontMatter(content)
+	if !ok {
+		return "", false
+	}
+	for _, line := range header {
+		k, value, ok := strings.Cut(line, ":")
+		if ok && strings.TrimSpace(k) == key {
+			return strings.TrimSpace(value), true
+		}
+	}
+	return "", false
+}
+
+// setFrontMatterValue returns content with key set to value in its front
+// matter, replacing an existing line or adding one. A front-matter block is
+// prepended when content has none.
+func setFrontMatterValue(content, key, value string) string {
+	line := key + ": " + value
+	header, body, ok := splitFrontMatter(content)
+	if !ok {
+		return "---\n" + line + "\n---\n" + content
+	}
+	i := slices.IndexFunc(header, func(l string) bool {
+		k, _, ok := strings.Cut(l, ":")
+		return ok && strings.TrimSpace(k) == key
+	})
+	if i < 0 {
+		header = append(header, line)
+	} else {
+		header[i] = line
+	}
+	return "---\n" + strings.Join(header, "\n") + body
+}
+
+// SetSkillEnabled turns the skill in skills/<name>/SKILL.md on or off by
+// setting "enabled" in its front matter. Disabled skills are skipped by Load;
+// the caller reloads the workspace for the change to take effect. A missing
+// skill yields an error wrapping fs.ErrNotExist.
+func SetSkillEnabled(root, name string, enabled bool) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("workspace: set_skill_enabled: invalid skill name %q", name)
+	}
+	path := filepath.Join(root, "skills", name, "SKILL.md")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("workspace: set_skill_enabled: %w", err)
+	}
+	content := string(data)
+	if skillEnabled(content) == enabled {
+		return nil
+	}
+	updated := setFrontMatterValue(content, skillEnabledKey, strconv.FormatBool(enabled))
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("workspace: set_skill_enabled: %w", err)
+	}
+	if err := platform.AtomicWrite(path, []byte(updated), info.Mode().Perm()); err != nil {
+		return fmt.Errorf("workspace: set_skill_enabled: %w", err)
+	}
+	slog.Info("skill toggled",
+		"component", "workspace",
+		"operation", "set_skill_enabled",
+		"skill", name,
+		"enabled", enabled)
+	return nil
+}
+
+// Prompt sections, named for the section order given to SystemPromptSections.
+const (
+	SectionSoul        = "soul"        // SOUL.md
+	SectionAgent       = "agent"       // AGENT.md
+	SectionSkills      = "skills"      // "## Available Skills" with every loaded skill
+	SectionEnvironment = "environment" // AGENT.md's "## Environment" section, when listed on its own
+)
+
+// DefaultPromptSections is the section order SystemPrompt uses. The
+// environment section stays inside AGENT.md.
+var DefaultPromptSections = []string{SectionSoul, SectionAgent, SectionSkills}
+
+// environmentHeader starts the section introspection appends to AGENT.md.
+const environmentHeader = "## Environment"
+
 // SystemPrompt assembles the system prompt from loaded workspace files.
 // Order: soul → agent → skills.
 func (w *Workspace) SystemPrompt() string {
-	var b strings.Builder
+	return w.SystemPromptSections(DefaultPromptSections)
+}
 
-	b.WriteString(w.SoulMD)
-	b.WriteString("\n\n")
+// SystemPromptSections assembles the system prompt from the named sections, in
+// the given order; sections not listed are left out. Listing the environment
+// section moves it out of AGENT.md to its own position.
+func (w *Workspace) SystemPromptSections(sections []string) string {
+	agentMD, envMD := w.AgentMD, ""
+	if slices.Contains(sections, SectionEnvironment) {
+		agentMD, envMD = cutSection(w.AgentMD, environmentHeader)
+	}
 
-	b.WriteString(w.AgentMD)
+	parts := make([]string, 0, len(sections))
+	for _, name := range sections {
+		var part string
+		switch name {
+		case SectionSoul:
+			part = w.SoulMD
+		case SectionAgent:
+			part = agentMD
+		case SectionSkills:
+			part = w.skillsSection()
+		case SectionEnvironment:
+			part = envMD
+		}
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.TrimSpace(strings.Join(parts, "\n\n"))
+}
+
+// skillsSection renders the loaded skills, or "" when there are none.
+func (w *Workspace) skillsSection() string {
+	if len(w.Skills) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("## Available Skills\n\n")
+	for _, s := range w.Skills {
+		b.WriteString("### ")
+		b.WriteString(s.Name)
+		b.WriteString("\n\n")
+		b.WriteString(s.Content)
+		b.WriteString("\n\n")
+	}
+	return b.String()
+}
 
-	if len(w.Skills) > 0 {
-		b.WriteString("\n\n## Available Skills\n\n")
-		for _, s := range w.Skills {
-			b.WriteString("### ")
-			b.WriteString(s.Name)
-			b.WriteString("\n\n")
-			b.WriteString(s.Content)
-			b.WriteString("\n\n")
+// cutSection splits the "## " section starting with header (up to the next
+// "## " header) out of content, returning the rest and the section.
+func cutSection(content, header string) (rest, section string) {
+	lines := strings.Split(content, "\n")
+	start := slices.IndexFunc(lines, func(line string) bool { return strings.TrimSpace(line) == header })
+	if start < 0 {
+		return content, ""
+	}
+	end := len(lines)
+	for i := start + 1; i < len(lines); i++ {
+		if strings.HasPrefix(lines[i], "## ") {
+			end = i
+			break
 		}
 	}
 
-	return strings.TrimSpace(b.String())
+	before := strings.TrimRight(strings.Join(lines[:start], "\n"), "\n")
+	after := strings.Join(lines[end:], "\n")
+	section = strings.TrimSpace(strings.Join(lines[start:end], "\n"))
+	switch {
+	case before == "":
+		return after, section
+	case after == "":
+		return before, section
+	default:
+		return before + "\n\n" + after, section
+	}
 }
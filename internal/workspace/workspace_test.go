@@ -1,6 +1,10 @@
 package workspace
 
 import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
@@ -347,3 +351,218 @@ func TestSystemPrompt(t *testing.T) {
 		})
 	}
 }
+
+func TestSystemPromptSections(t *testing.T) {
+	w := Workspace{
+		SoulMD:  "SOUL_CONTENT",
+		AgentMD: "AGENT_CONTENT\n\n## Environment\n\n- **OS:** linux\n\n## Capabilities\n\nCAPS_CONTENT",
+		Skills:  []Skill{{Name: "coding", Content: "SKILL_CONTENT"}},
+	}
+
+	tests := []struct {
+		name     string
+		sections []string
+		want     []string // in order
+		absent   []string
+	}{
+		{"Default", DefaultPromptSections, []string{"SOUL_CONTENT", "AGENT_CONTENT", "## Environment", "CAPS_CONTENT", "SKILL_CONTENT"}, nil},
+		{"SkillsFirst", []string{"skills", "agent", "soul"}, []string{"SKILL_CONTENT", "AGENT_CONTENT", "## Environment", "SOUL_CONTENT"}, nil},
+		{"EnvironmentMovedFirst", []string{"environment", "soul", "agent", "skills"}, []string{"## Environment", "- **OS:** linux", "SOUL_CONTENT", "AGENT_CONTENT", "CAPS_CONTENT", "SKILL_CONTENT"}, nil},
+		{"Omitted", []string{"agent", "environment"}, []string{"AGENT_CONTENT", "CAPS_CONTENT", "## Environment"}, []string{"SOUL_CONTENT", "SKILL_CONTENT"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := w.SystemPromptSections(tt.sections)
+			pos := -1
+			for _, s := range tt.want {
+				i := strings.Index(got, s)
+				if i <= pos {
+					t.Fatalf("%q missing or out of order\ngot: %s", s, got)
+				}
+				pos = i
+			}
+			for _, s := range tt.absent {
+				if strings.Contains(got, s) {
+					t.Errorf("SystemPromptSections(%v) should not contain %q", tt.sections, s)
+				}
+			}
+			if n := strings.Count(got, "## Environment"); n != 1 {
+				t.Errorf("environment section appears %d times, want 1", n)
+			}
+		})
+	}
+
+	if got, want := w.SystemPromptSections(DefaultPromptSections), w.SystemPrompt(); got != want {
+		t.Errorf("default sections = %q, want SystemPrompt() %q", got, want)
+	}
+}
+
+func TestLoadWithMaxSkills(t *testing.T) {
+	dir := setupTestWorkspace(t, map[string]string{
+		"AGENT.md":                 "# Agent",
+		"SOUL.md":                  "# Soul",
+		"skills/alpha/SKILL.md":    "Alpha",
+		"skills/bravo/SKILL.md":    "---\npriority: 5\n---\nBravo",
+		"skills/charlie/SKILL.md":  "Charlie",
+		"skills/delta/SKILL.md":    "---\npriority: 10\n---\nDelta",
+		"skills/echo/SKILL.md":     "Echo",
+		"skills/foxtrot/SKILL.md":  "---\npriority: nope\n---\nFoxtrot",
+		"skills/golf/SKILL.md":     "Golf",
+		"skills/hotel/SKILL.md":    "Hotel",
+		"skills/india/SKILL.md":    "India",
+		"skills/juliett/SKILL.md":  "Juliett",
+		"skills/kilo/SKILL.md":     "Kilo",
+		"skills/lima/SKILL.md":     "Lima",
+		"skills/mike/SKILL.md":     "Mike",
+		"skills/november/SKILL.md": "November",
+	})
+
+	var logs bytes.Buffer
+	orig := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+	t.Cleanup(func() { slog.SetDefault(orig) })
+
+	w, err := LoadWithMaxSkills(dir, 3)
+	if err != nil {
+		t.Fatalf("LoadWithMaxSkills: %v", err)
+	}
+	if w.MaxSkills != 3 {
+		t.Errorf("MaxSkills = %d, want 3", w.MaxSkills)
+	}
+
+	var names []string
+	for _, s := range w.Skills {
+		names = append(names, s.Name)
+	}
+	// delta (10) and bravo (5) win on priority, alpha on name among the rest; sorted by name.
+	if got := strings.Join(names, ","); got != "alpha,bravo,delta" {
+		t.Errorf("loaded skills = %s, want alpha,bravo,delta", got)
+	}
+
+	out := logs.String()
+	if !strings.Contains(out, "skill limit reached") {
+		t.Errorf("expected skip warning, logs:\n%s", out)
+	}
+	if !strings.Contains(out, "charlie") || !strings.Contains(out, "november") {
+		t.Errorf("expected skipped skill names in logs:\n%s", out)
+	}
+}
+
+func TestLoadWithMaxSkills_ZeroIsUnlimited(t *testing.T) {
+	files := map[string]string{"AGENT.md": "# Agent", "SOUL.md": "# Soul"}
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		files["skills/"+name+"/SKILL.md"] = name
+	}
+	dir := setupTestWorkspace(t, files)
+
+	w, err := LoadWithMaxSkills(dir, 0)
+	if err != nil {
+		t.Fatalf("LoadWithMaxSkills: %v", err)
+	}
+	if len(w.Skills) != 5 {
+		t.Errorf("Skills = %d, want 5", len(w.Skills))
+	}
+}
+
+func TestSkillPriority(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    int
+	}{
+		{"NoFrontMatter", "Just content", 0},
+		{"Priority", "---\npriority: 7\n---\nBody", 7},
+		{"OtherKeys", "---\nname: x\npriority:  -2 \n---\nBody", -2},
+		{"Invalid", "---\npriority: high\n---\nBody", 0},
+		{"Unterminated", "---\npriority: 3\nBody", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := skillPriority(tt.content); got != tt.want {
+				t.Errorf("skillPriority() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetSkillEnabled(t *testing.T) {
+	dir := setupTestWorkspace(t, map[string]string{
+		"AGENT.md":               "# Agent",
+		"SOUL.md":                "# Soul",
+		"skills/plain/SKILL.md":  "Plain skill",
+		"skills/ranked/SKILL.md": "---\npriority: 5\n---\nRanked skill",
+	})
+
+	for _, name := range []string{"plain", "ranked"} {
+		if err := SetSkillEnabled(dir, name, false); err != nil {
+			t.Fatalf("SetSkillEnabled(%s, false): %v", name, err)
+		}
+	}
+	w, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(w.Skills) != 0 {
+		t.Errorf("Skills = %v, want none after disabling", w.Skills)
+	}
+	if strings.Contains(w.SystemPrompt(), "skill") {
+		t.Errorf("SystemPrompt() = %q, want disabled skills left out", w.SystemPrompt())
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "skills", "ranked", "SKILL.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "---\npriority: 5\nenabled: false\n---\nRanked skill"; string(data) != want {
+		t.Errorf("ranked SKILL.md = %q, want %q", data, want)
+	}
+
+	for _, name := range []string{"plain", "ranked"} {
+		if err := SetSkillEnabled(dir, name, true); err != nil {
+			t.Fatalf("SetSkillEnabled(%s, true): %v", name, err)
+		}
+	}
+	w, err = Load(dir)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(w.Skills) != 2 || w.Skills[1].Priority != 5 {
+		t.Errorf("Skills = %+v, want both back with their priority", w.Skills)
+	}
+	if !strings.Contains(w.Skills[0].Content, "Plain skill") {
+		t.Errorf("plain content = %q, want the body kept", w.Skills[0].Content)
+	}
+}
+
+func TestSetSkillEnabled_Errors(t *testing.T) {
+	dir := setupTestWorkspace(t, map[string]string{"AGENT.md": "# Agent", "SOUL.md": "# Soul"})
+
+	if err := SetSkillEnabled(dir, "missing", false); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("missing skill: err = %v, want fs.ErrNotExist", err)
+	}
+	for _, name := range []string{"", "..", "a/b", `a\b`} {
+		if err := SetSkillEnabled(dir, name, false); err == nil || !strings.Contains(err.Error(), "invalid skill name") {
+			t.Errorf("SetSkillEnabled(%q) err = %v, want invalid skill name", name, err)
+		}
+	}
+}
+
+func TestSkillEnabled(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    bool
+	}{
+		{"NoFrontMatter", "Just content", true},
+		{"Disabled", "---\nenabled: false\n---\nBody", false},
+		{"Enabled", "---\nenabled: true\n---\nBody", true},
+		{"Invalid", "---\nenabled: maybe\n---\nBody", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := skillEnabled(tt.content); got != tt.want {
+				t.Errorf("skillEnabled() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}